@@ -0,0 +1,193 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/repository"
+)
+
+//go:generate mockgen -destination=mock/apikey.go -package=apikeymock github.com/mshto/fruit-store/apikey Service
+
+// Header header with an api key
+const Header = "X-API-Key"
+
+// api key scopes
+const (
+	ScopeProductsRead = "products:read"
+	ScopeCartRead     = "cart:read"
+	ScopeCartWrite    = "cart:write"
+	ScopePaymentWrite = "payment:write"
+)
+
+// Scopes all known scopes
+var Scopes = []string{ScopeProductsRead, ScopeCartRead, ScopeCartWrite, ScopePaymentWrite}
+
+const (
+	keyPrefix   = "fsk_"
+	prefixLen   = 8
+	secretBytes = 32
+
+	// last used time is written at most once per interval
+	touchInterval = time.Minute
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Service interface
+type Service interface {
+	Create(ownerUUID uuid.UUID, req entity.CreateAPIKey) (*entity.CreatedAPIKey, error)
+	List(ownerUUID uuid.UUID, serviceAccountID *uuid.UUID) ([]entity.APIKey, error)
+	Revoke(ownerUUID, keyUUID uuid.UUID) error
+	Validate(key string) (*entity.APIKey, error)
+}
+
+// New generate a new api key service
+func New(log *logrus.Logger, repo repository.APIKeys) Service {
+	return &serviceImpl{
+		log:  log,
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+type serviceImpl struct {
+	log  *logrus.Logger
+	repo repository.APIKeys
+	now  func() time.Time
+}
+
+// Create create api key for owner or for service account of owner, the plain key is returned only here
+func (si *serviceImpl) Create(ownerUUID uuid.UUID, req entity.CreateAPIKey) (*entity.CreatedAPIKey, error) {
+	err := ValidateScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	userUUID, err := si.keyOwner(ownerUUID, req.ServiceAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, prefix, err := Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	created := &entity.CreatedAPIKey{
+		APIKey: entity.APIKey{
+			UserID: userUUID,
+			Name:   req.Name,
+			Prefix: prefix,
+			Scopes: req.Scopes,
+		},
+		Key: key,
+	}
+	err = si.repo.CreateAPIKey(&created.APIKey, Hash(key))
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// List list api keys of owner or of service account of owner
+func (si *serviceImpl) List(ownerUUID uuid.UUID, serviceAccountID *uuid.UUID) ([]entity.APIKey, error) {
+	userUUID, err := si.keyOwner(ownerUUID, serviceAccountID)
+	if err != nil {
+		return nil, err
+	}
+	return si.repo.GetAPIKeys(userUUID)
+}
+
+// Revoke revoke api key of owner or of service account of owner
+func (si *serviceImpl) Revoke(ownerUUID, keyUUID uuid.UUID) error {
+	return si.repo.RevokeAPIKey(ownerUUID, keyUUID)
+}
+
+// Validate resolve plain key into a stored not revoked api key
+func (si *serviceImpl) Validate(key string) (*entity.APIKey, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return nil, entity.ErrAPIKeyInvalid
+	}
+
+	stored, err := si.repo.GetAPIKeyByHash(Hash(key))
+	if err == entity.ErrAPIKeyNotFound {
+		return nil, entity.ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := si.now()
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= touchInterval {
+		err = si.repo.TouchAPIKey(stored.ID, now)
+		if err != nil {
+			si.log.Warnf("failed to touch api key, key: %v, error: %v", stored.ID, err)
+		}
+	}
+	return stored, nil
+}
+
+func (si *serviceImpl) keyOwner(ownerUUID uuid.UUID, serviceAccountID *uuid.UUID) (uuid.UUID, error) {
+	if serviceAccountID == nil {
+		return ownerUUID, nil
+	}
+
+	ok, err := si.repo.IsServiceAccountOwner(ownerUUID, *serviceAccountID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !ok {
+		return uuid.Nil, entity.ErrServiceAccountNotFound
+	}
+	return *serviceAccountID, nil
+}
+
+// Generate generate a new plain api key and its public prefix
+func Generate() (string, string, error) {
+	buf := make([]byte, secretBytes)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret := strings.ToLower(encoding.EncodeToString(buf))
+	return keyPrefix + secret, keyPrefix + secret[:prefixLen], nil
+}
+
+// Hash hash of plain api key stored instead of the key
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateScopes check that scopes are not empty and known
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return entity.ErrEmptyScopes
+	}
+	for _, scope := range scopes {
+		if !HasScope(Scopes, scope) {
+			return entity.ErrInvalidScope
+		}
+	}
+	return nil
+}
+
+// HasScope check whether scope is in scopes
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package apikey
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+)
+
+func TestGenerate(t *testing.T) {
+	key, prefix, err := Generate()
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(key, prefix))
+	assert.Equal(t, len(keyPrefix)+prefixLen, len(prefix))
+
+	other, _, err := Generate()
+	assert.Nil(t, err)
+	assert.NotEqual(t, key, other)
+	assert.NotEqual(t, Hash(key), Hash(other))
+	assert.Equal(t, Hash(key), Hash(key))
+}
+
+func TestValidateScopes(t *testing.T) {
+	assert.Nil(t, ValidateScopes([]string{ScopeCartRead, ScopePaymentWrite}))
+	assert.Equal(t, entity.ErrEmptyScopes, ValidateScopes(nil))
+	assert.Equal(t, entity.ErrInvalidScope, ValidateScopes([]string{ScopeCartRead, "admin"}))
+}
+
+func TestCreate(t *testing.T) {
+	type payload struct {
+		req      entity.CreateAPIKey
+		repoMock func(repoMock *repomock.MockAPIKeys)
+	}
+	type expected struct {
+		userUUID uuid.UUID
+		err      error
+	}
+
+	userUUID := uuid.New()
+	accountUUID := uuid.New()
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Create for user with success",
+			payload: payload{
+				req: entity.CreateAPIKey{Name: "ci", Scopes: []string{ScopeCartRead}},
+				repoMock: func(repoMock *repomock.MockAPIKeys) {
+					repoMock.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Return(nil)
+				},
+			},
+			expected: expected{
+				userUUID: userUUID,
+			},
+		},
+		{
+			name: "Create for service account with success",
+			payload: payload{
+				req: entity.CreateAPIKey{Name: "ci", Scopes: []string{ScopeCartRead}, ServiceAccountID: &accountUUID},
+				repoMock: func(repoMock *repomock.MockAPIKeys) {
+					repoMock.EXPECT().IsServiceAccountOwner(userUUID, accountUUID).Return(true, nil)
+					repoMock.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Return(nil)
+				},
+			},
+			expected: expected{
+				userUUID: accountUUID,
+			},
+		},
+		{
+			name: "Create for foreign service account with fail",
+			payload: payload{
+				req: entity.CreateAPIKey{Name: "ci", Scopes: []string{ScopeCartRead}, ServiceAccountID: &accountUUID},
+				repoMock: func(repoMock *repomock.MockAPIKeys) {
+					repoMock.EXPECT().IsServiceAccountOwner(userUUID, accountUUID).Return(false, nil)
+				},
+			},
+			expected: expected{
+				err: entity.ErrServiceAccountNotFound,
+			},
+		},
+		{
+			name: "Create unknown scope with fail",
+			payload: payload{
+				req:      entity.CreateAPIKey{Name: "ci", Scopes: []string{"admin"}},
+				repoMock: func(repoMock *repomock.MockAPIKeys) {},
+			},
+			expected: expected{
+				err: entity.ErrInvalidScope,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			repo := repomock.NewMockAPIKeys(mockCtrl)
+			test.payload.repoMock(repo)
+
+			created, err := New(logger, repo).Create(userUUID, test.payload.req)
+			assert.Equal(t, test.expected.err, err)
+			if err == nil {
+				assert.Equal(t, test.expected.userUUID, created.UserID)
+				assert.True(t, strings.HasPrefix(created.Key, created.Prefix))
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	type payload struct {
+		key      string
+		repoMock func(repoMock *repomock.MockAPIKeys)
+	}
+	type expected struct {
+		err error
+	}
+
+	now := time.Date(2020, 11, 24, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-time.Second)
+	stale := now.Add(-time.Hour)
+	keyUUID := uuid.New()
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Validate first use touches key with success",
+			payload: payload{
+				key: "fsk_key",
+				repoMock: func(repoMock *repomock.MockAPIKeys) {
+					repoMock.EXPECT().GetAPIKeyByHash(Hash("fsk_key")).Return(&entity.APIKey{ID: keyUUID}, nil)
+					repoMock.EXPECT().TouchAPIKey(keyUUID, now).Return(nil)
+				},
+			},
+		},
+		{
+			name: "Validate recently used key is not touched with success",
+			payload: payload{
+				key: "fsk_key",
+				repoMock: func(repoMock *repomock.MockAPIKeys) {
+					repoMock.EXPECT().GetAPIKeyByHash(Hash("fsk_key")).Return(&entity.APIKey{ID: keyUUID, LastUsedAt: &recent}, nil)
+				},
+			},
+		},
+		{
+			name: "Validate TouchAPIKey error with success",
+			payload: payload{
+				key: "fsk_key",
+				repoMock: func(repoMock *repomock.MockAPIKeys) {
+					repoMock.EXPECT().GetAPIKeyByHash(Hash("fsk_key")).Return(&entity.APIKey{ID: keyUUID, LastUsedAt: &stale}, nil)
+					repoMock.EXPECT().TouchAPIKey(keyUUID, now).Return(errors.New("error"))
+				},
+			},
+		},
+		{
+			name: "Validate unknown key with fail",
+			payload: payload{
+				key: "fsk_key",
+				repoMock: func(repoMock *repomock.MockAPIKeys) {
+					repoMock.EXPECT().GetAPIKeyByHash(Hash("fsk_key")).Return(&entity.APIKey{}, entity.ErrAPIKeyNotFound)
+				},
+			},
+			expected: expected{
+				err: entity.ErrAPIKeyInvalid,
+			},
+		},
+		{
+			name: "Validate malformed key with fail",
+			payload: payload{
+				key:      "key",
+				repoMock: func(repoMock *repomock.MockAPIKeys) {},
+			},
+			expected: expected{
+				err: entity.ErrAPIKeyInvalid,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			repo := repomock.NewMockAPIKeys(mockCtrl)
+			test.payload.repoMock(repo)
+
+			svc := &serviceImpl{log: logger, repo: repo, now: func() time.Time { return now }}
+			_, err := svc.Validate(test.payload.key)
+			assert.Equal(t, test.expected.err, err)
+		})
+	}
+}
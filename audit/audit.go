@@ -0,0 +1,81 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/repository"
+	"github.com/mshto/fruit-store/web/common/request"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+//go:generate mockgen -destination=mock/audit.go -package=auditmock github.com/mshto/fruit-store/audit Auditor
+
+// actor prefixes of credentials which aren't a user access token
+const (
+	guestPrefix  = "guest:"
+	apiKeyPrefix = "apikey:"
+)
+
+// Auditor records security events, failures to record are logged and don't fail the request
+type Auditor interface {
+	Record(r *http.Request, event entity.AuditEvent)
+}
+
+// New generate a new auditor
+func New(log *logrus.Logger, repo repository.Audit) Auditor {
+	return &auditorImpl{
+		log:  log,
+		repo: repo,
+	}
+}
+
+type auditorImpl struct {
+	log  *logrus.Logger
+	repo repository.Audit
+}
+
+// Record append event with client of request, actor is taken from request when it's not set
+func (aui *auditorImpl) Record(r *http.Request, event entity.AuditEvent) {
+	client := request.GetClient(r)
+	event.IP = client.IP
+	event.UserAgent = client.UserAgent
+	if event.Actor == "" {
+		event.Actor = Actor(r.Context())
+	}
+
+	err := aui.repo.CreateAuditEvent(&event)
+	if err != nil {
+		aui.log.Errorf("failed to record audit event, action: %v, actor: %v, target: %v, outcome: %v, error: %v",
+			event.Action, event.Actor, event.Target, event.Outcome, err)
+	}
+}
+
+// Event build event of action on target, event is failed when failure is set
+func Event(action, target string, failure error) entity.AuditEvent {
+	event := entity.AuditEvent{
+		Action:  action,
+		Target:  target,
+		Outcome: entity.AuditSuccess,
+	}
+	if failure != nil {
+		event.Outcome = entity.AuditFailure
+		event.Details = failure.Error()
+	}
+	return event
+}
+
+// Actor returns user uuid of authenticated request, prefixed guest or api key uuid otherwise
+func Actor(ctx context.Context) string {
+	if keyID, ok := ctx.Value(middleware.APIKeyID).(string); ok {
+		return apiKeyPrefix + keyID
+	}
+	userUUID, _ := ctx.Value(middleware.UserUUID).(string)
+	if isGuest, _ := ctx.Value(middleware.IsGuest).(bool); isGuest && userUUID != "" {
+		return guestPrefix + userUUID
+	}
+	return userUUID
+}
@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+func TestRecord(t *testing.T) {
+	type payload struct {
+		event   entity.AuditEvent
+		ctxMock func(ctx context.Context) context.Context
+		err     error
+	}
+	type expected struct {
+		event entity.AuditEvent
+		logs  int
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Record user event with success",
+			payload: payload{
+				event: entity.AuditEvent{Action: entity.AuditLogout, Target: "user", Outcome: entity.AuditSuccess},
+				ctxMock: func(ctx context.Context) context.Context {
+					return context.WithValue(ctx, middleware.UserUUID, "user")
+				},
+			},
+			expected: expected{
+				event: entity.AuditEvent{Actor: "user", IP: "10.0.0.1", UserAgent: "agent", Action: entity.AuditLogout, Target: "user", Outcome: entity.AuditSuccess},
+			},
+		},
+		{
+			name: "Record event with actor set with success",
+			payload: payload{
+				event: entity.AuditEvent{Actor: "other", Action: entity.AuditSignin, Target: "test", Outcome: entity.AuditSuccess},
+				ctxMock: func(ctx context.Context) context.Context {
+					return ctx
+				},
+			},
+			expected: expected{
+				event: entity.AuditEvent{Actor: "other", IP: "10.0.0.1", UserAgent: "agent", Action: entity.AuditSignin, Target: "test", Outcome: entity.AuditSuccess},
+			},
+		},
+		{
+			name: "Record guest event with success",
+			payload: payload{
+				event: entity.AuditEvent{Action: entity.AuditCouponApply, Target: "sale", Outcome: entity.AuditSuccess},
+				ctxMock: func(ctx context.Context) context.Context {
+					ctx = context.WithValue(ctx, middleware.UserUUID, "guest")
+					return context.WithValue(ctx, middleware.IsGuest, true)
+				},
+			},
+			expected: expected{
+				event: entity.AuditEvent{Actor: "guest:guest", IP: "10.0.0.1", UserAgent: "agent", Action: entity.AuditCouponApply, Target: "sale", Outcome: entity.AuditSuccess},
+			},
+		},
+		{
+			name: "Record api key event with success",
+			payload: payload{
+				event: entity.AuditEvent{Action: entity.AuditCouponApply, Target: "sale", Outcome: entity.AuditSuccess},
+				ctxMock: func(ctx context.Context) context.Context {
+					ctx = context.WithValue(ctx, middleware.UserUUID, "user")
+					return context.WithValue(ctx, middleware.APIKeyID, "key")
+				},
+			},
+			expected: expected{
+				event: entity.AuditEvent{Actor: "apikey:key", IP: "10.0.0.1", UserAgent: "agent", Action: entity.AuditCouponApply, Target: "sale", Outcome: entity.AuditSuccess},
+			},
+		},
+		{
+			name: "Record CreateAuditEvent error is logged",
+			payload: payload{
+				event: entity.AuditEvent{Action: entity.AuditRefresh, Outcome: entity.AuditFailure},
+				ctxMock: func(ctx context.Context) context.Context {
+					return ctx
+				},
+				err: errors.New("error"),
+			},
+			expected: expected{
+				event: entity.AuditEvent{IP: "10.0.0.1", UserAgent: "agent", Action: entity.AuditRefresh, Outcome: entity.AuditFailure},
+				logs:  1,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, hook := loggermock.NewNullLogger()
+
+			repo := repomock.NewMockAudit(mockCtrl)
+			repo.EXPECT().CreateAuditEvent(&test.expected.event).Return(test.payload.err)
+
+			req, _ := http.NewRequest(http.MethodPost, "url", nil)
+			req.RemoteAddr = "10.0.0.1:5432"
+			req.Header.Set("User-Agent", "agent")
+
+			New(logger, repo).Record(req.WithContext(test.payload.ctxMock(req.Context())), test.payload.event)
+
+			assert.Len(t, hook.Entries, test.expected.logs)
+		})
+	}
+}
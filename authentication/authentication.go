@@ -16,10 +16,25 @@ import (
 
 //go:generate mockgen -destination=mock/authentication.go -package=authmock github.com/mshto/fruit-store/authentication Auth
 
+// refreshTokenTTL lifetime of refresh token, sessions of user are kept as long
+const refreshTokenTTL = time.Hour * 24 * 7
+
+// typ claim tells kind of token, token of other kind is rejected even if its signature is valid
+const (
+	typClaim         = "typ"
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+	tokenTypeGuest   = "guest"
+)
+
+// ErrInvalidTokenType token is of other kind than expected
+var ErrInvalidTokenType = errors.New("token type is invalid")
+
 // AccessDetails access details struct
 type AccessDetails struct {
 	AccessUUID string
 	UserUUID   string
+	SessionID  string
 }
 
 // TokenDetails token details struct
@@ -28,6 +43,7 @@ type TokenDetails struct {
 	RefreshToken string
 	AccessUUID   string
 	RefreshUUID  string
+	FamilyID     string
 	AtExpires    int64
 	RtExpires    int64
 }
@@ -35,18 +51,44 @@ type TokenDetails struct {
 // Auth interface
 type Auth interface {
 	GetUserUUID(accessUUID string) (string, error)
-	CreateTokens(userUUID uuid.UUID) (*entity.Tokens, error)
+	CreateTokens(userUUID uuid.UUID, client entity.Client) (*entity.Tokens, error)
 	RefreshTokens(refreshToken string) (*entity.Tokens, error)
 	ValidateToken(token string) (*AccessDetails, error)
 	RemoveTokens(accessUUID, userUUID string) error
+
+	GetSessions(userUUID string) ([]entity.Session, error)
+	TouchSession(userUUID, sessionID string) error
+	RevokeSession(userUUID, sessionID string) error
+	RevokeSessions(userUUID string) error
+	RevokeOtherSessions(userUUID, keepSessionID string) error
+
+	CreateResetToken(userUUID uuid.UUID) (string, error)
+	ConsumeResetToken(token string) (uuid.UUID, error)
+
+	SigninAllowed(username, ip string) (time.Duration, error)
+	SigninFailed(username, ip string) (time.Duration, error)
+	SigninSucceeded(username string) error
+
+	CreateChallenge(userUUID uuid.UUID) (string, error)
+	GetChallenge(token string) (uuid.UUID, error)
+	CompleteChallenge(token string) error
+
+	CreateOIDCState(state entity.OIDCState) (string, error)
+	ConsumeOIDCState(token string) (entity.OIDCState, error)
+
+	CreateGuestToken() (string, error)
+	ValidateGuestToken(token string) (uuid.UUID, error)
+
+	JWKS() entity.JWKS
 }
 
-// New generate a new Auth
-func New(cfg *config.Config, log *logrus.Logger, cache cache.Cache) Auth {
+// New generate a new Auth, tokens are signed with HMAC secrets when key set is nil
+func New(cfg *config.Config, log *logrus.Logger, cache cache.Cache, keys *KeySet) Auth {
 	return &authImpl{
 		cfg:   cfg,
 		log:   log,
 		cache: cache,
+		keys:  keys,
 	}
 }
 
@@ -54,48 +96,29 @@ type authImpl struct {
 	cache cache.Cache
 	cfg   *config.Config
 	log   *logrus.Logger
+	keys  *KeySet
 }
 
 func (aui *authImpl) GetUserUUID(accessUUID string) (string, error) {
 	return aui.cache.Get(accessUUID)
 }
 
-// CreateTokens create user tokens
-func (aui *authImpl) CreateTokens(userUUID uuid.UUID) (*entity.Tokens, error) {
-	td := &TokenDetails{}
+// CreateTokens create user tokens, every call starts a new token family and session
+func (aui *authImpl) CreateTokens(userUUID uuid.UUID, client entity.Client) (*entity.Tokens, error) {
+	familyID := uuid.New().String()
 
-	td.AtExpires = time.Now().Add(time.Duration(aui.cfg.Auth.AccessSecretAtExpiresInMin) * time.Minute).Unix()
-	td.AccessUUID = uuid.New().String()
-
-	td.RtExpires = time.Now().Add(time.Hour * 24 * 7).Unix()
-	td.RefreshUUID = td.AccessUUID + "++" + userUUID.String()
-
-	err := aui.createAccessToken(userUUID, td)
-	if err != nil {
-		return nil, err
-	}
-
-	err = aui.createRefreshToken(userUUID, td)
+	tokens, err := aui.createTokens(userUUID, familyID)
 	if err != nil {
-		return nil, err
+		return tokens, err
 	}
 
-	err = aui.createAuth(userUUID, td)
-
-	return &entity.Tokens{
-		AccessToken:  td.AccessToken,
-		RefreshToken: td.RefreshToken,
-	}, err
+	return tokens, aui.createSession(userUUID.String(), familyID, client)
 }
 
-// RefreshTokens refresh tokens
+// RefreshTokens rotate tokens of refresh token family.
+// Refresh token which was already rotated out revokes the whole family.
 func (aui *authImpl) RefreshTokens(refreshToken string) (*entity.Tokens, error) {
-	token, err := jwt.Parse(refreshToken, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(aui.cfg.Auth.RefreshSecret), nil
-	})
+	token, err := jwt.Parse(refreshToken, aui.keyFunc(aui.cfg.Auth.RefreshSecret))
 	if err != nil {
 		return nil, err
 	}
@@ -106,10 +129,17 @@ func (aui *authImpl) RefreshTokens(refreshToken string) (*entity.Tokens, error)
 	if !ok || !token.Valid {
 		return nil, errors.New("refresh token is expired")
 	}
+	if claims[typClaim] != tokenTypeRefresh {
+		return nil, ErrInvalidTokenType
+	}
 	refreshUUID, ok := claims["refresh_uuid"].(string)
 	if !ok {
 		return nil, errors.New("refresh token is invalid")
 	}
+	familyID, ok := claims["family_id"].(string)
+	if !ok {
+		return nil, errors.New("refresh token is invalid")
+	}
 	userID, ok := claims["user_id"].(string)
 	if !ok {
 		return nil, errors.New("userUUIS is invalid")
@@ -118,23 +148,46 @@ func (aui *authImpl) RefreshTokens(refreshToken string) (*entity.Tokens, error)
 	if err != nil {
 		return nil, err
 	}
+
+	family, err := aui.getFamily(familyID)
+	if err == cache.ErrNotFound {
+		return nil, ErrTokenRevoked
+	}
+	if err != nil {
+		return nil, err
+	}
+	if family.RefreshUUID != refreshUUID {
+		return nil, aui.reportReuse(familyID, refreshUUID, family)
+	}
+
+	// refresh key can be deleted only once, so concurrent use of the same token is a reuse as well
 	err = aui.cache.Del(refreshUUID)
+	if err == cache.ErrNotFound {
+		return nil, aui.reportReuse(familyID, refreshUUID, family)
+	}
 	if err != nil {
 		return nil, err
 	}
+	err = aui.cache.Del(family.AccessUUID)
+	if err != nil && err != cache.ErrNotFound {
+		return nil, err
+	}
 
-	return aui.CreateTokens(userUUID)
+	tokens, err := aui.createTokens(userUUID, familyID)
+	if err != nil {
+		return tokens, err
+	}
+
+	err = aui.TouchSession(userID, familyID)
+	if err != nil && err != entity.ErrSessionNotFound {
+		return tokens, err
+	}
+	return tokens, nil
 }
 
 // ValidateToken validate token
 func (aui *authImpl) ValidateToken(tokenString string) (*AccessDetails, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-
-		return []byte(aui.cfg.Auth.AccessSecret), nil
-	})
+	token, err := jwt.Parse(tokenString, aui.keyFunc(aui.cfg.Auth.AccessSecret))
 	if err != nil {
 		return nil, err
 	}
@@ -145,30 +198,119 @@ func (aui *authImpl) ValidateToken(tokenString string) (*AccessDetails, error) {
 	if !ok {
 		return nil, errors.New("token is invalid")
 	}
+	if claims[typClaim] != tokenTypeAccess {
+		return nil, ErrInvalidTokenType
+	}
 
 	accessUUID, ok := claims["access_uuid"].(string)
 	if !ok {
-		return nil, err
+		return nil, errors.New("access token is invalid")
 	}
 	userUUID, ok := claims["user_id"].(string)
 	if !ok {
-		return nil, err
+		return nil, errors.New("userUUID is invalid")
 	}
+	// tokens issued before sessions were introduced have no family
+	sessionID, _ := claims["family_id"].(string)
+
 	return &AccessDetails{
 		AccessUUID: accessUUID,
 		UserUUID:   userUUID,
+		SessionID:  sessionID,
 	}, nil
 }
 
-// RemoveTokens remove tokens
+// RemoveTokens remove tokens and their family
 func (aui *authImpl) RemoveTokens(accessUUID, userUUID string) error {
 	err := aui.cache.Del(accessUUID)
 	if err != nil {
 		return err
 	}
 
-	err = aui.cache.Del(fmt.Sprintf("%s++%s", accessUUID, userUUID))
-	return err
+	refreshUUID := fmt.Sprintf("%s++%s", accessUUID, userUUID)
+	familyID, err := aui.cache.Get(refreshUUID)
+	if err != nil {
+		return err
+	}
+
+	err = aui.cache.Del(refreshUUID)
+	if err != nil {
+		return err
+	}
+
+	err = aui.cache.Del(fmt.Sprintf(familyPattern, familyID))
+	if err != nil && err != cache.ErrNotFound {
+		return err
+	}
+
+	return aui.removeSession(userUUID, familyID)
+}
+
+func (aui *authImpl) createTokens(userUUID uuid.UUID, familyID string) (*entity.Tokens, error) {
+	td := &TokenDetails{}
+
+	td.AtExpires = time.Now().Add(time.Duration(aui.cfg.Auth.AccessSecretAtExpiresInMin) * time.Minute).Unix()
+	td.AccessUUID = uuid.New().String()
+
+	td.RtExpires = time.Now().Add(refreshTokenTTL).Unix()
+	td.RefreshUUID = td.AccessUUID + "++" + userUUID.String()
+	td.FamilyID = familyID
+
+	err := aui.createAccessToken(userUUID, td)
+	if err != nil {
+		return nil, err
+	}
+
+	err = aui.createRefreshToken(userUUID, td)
+	if err != nil {
+		return nil, err
+	}
+
+	err = aui.createAuth(userUUID, td)
+
+	return &entity.Tokens{
+		AccessToken:  td.AccessToken,
+		RefreshToken: td.RefreshToken,
+	}, err
+}
+
+// JWKS returns public keys which verify tokens
+func (aui *authImpl) JWKS() entity.JWKS {
+	return aui.keys.JWKS()
+}
+
+// sign signs claims with active key or with HMAC secret when there are no keys
+func (aui *authImpl) sign(claims jwt.MapClaims, secret string) (string, error) {
+	key := aui.keys.activeKey()
+	if key == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// keyFunc returns verification key of token, HMAC tokens are rejected once keys are configured
+func (aui *authImpl) keyFunc(secret string) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if aui.keys == nil {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return []byte(secret), nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, ok := aui.keys.key(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %v", kid)
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.Public, nil
+	}
 }
 
 func (aui *authImpl) createAccessToken(userUUID uuid.UUID, td *TokenDetails) error {
@@ -176,12 +318,13 @@ func (aui *authImpl) createAccessToken(userUUID uuid.UUID, td *TokenDetails) err
 
 	atClaims := jwt.MapClaims{}
 	atClaims["authorized"] = true
+	atClaims[typClaim] = tokenTypeAccess
 	atClaims["access_uuid"] = td.AccessUUID
+	atClaims["family_id"] = td.FamilyID
 	atClaims["user_id"] = userUUID
 	atClaims["exp"] = td.AtExpires
-	at := jwt.NewWithClaims(jwt.SigningMethodHS256, atClaims)
 
-	td.AccessToken, err = at.SignedString([]byte(aui.cfg.Auth.AccessSecret))
+	td.AccessToken, err = aui.sign(atClaims, aui.cfg.Auth.AccessSecret)
 	return err
 }
 
@@ -189,12 +332,13 @@ func (aui *authImpl) createRefreshToken(userUUID uuid.UUID, td *TokenDetails) er
 	var err error
 
 	rtClaims := jwt.MapClaims{}
+	rtClaims[typClaim] = tokenTypeRefresh
 	rtClaims["refresh_uuid"] = td.RefreshUUID
+	rtClaims["family_id"] = td.FamilyID
 	rtClaims["user_id"] = userUUID
 	rtClaims["exp"] = td.RtExpires
-	rt := jwt.NewWithClaims(jwt.SigningMethodHS256, rtClaims)
 
-	td.RefreshToken, err = rt.SignedString([]byte(aui.cfg.Auth.RefreshSecret))
+	td.RefreshToken, err = aui.sign(rtClaims, aui.cfg.Auth.RefreshSecret)
 	return err
 }
 
@@ -208,6 +352,14 @@ func (aui *authImpl) createAuth(userUUID uuid.UUID, td *TokenDetails) error {
 		return err
 	}
 
-	err = aui.cache.Set(td.RefreshUUID, userUUID.String(), rt.Sub(now))
-	return err
+	err = aui.cache.Set(td.RefreshUUID, td.FamilyID, rt.Sub(now))
+	if err != nil {
+		return err
+	}
+
+	return aui.setFamily(td.FamilyID, tokenFamily{
+		UserUUID:    userUUID.String(),
+		AccessUUID:  td.AccessUUID,
+		RefreshUUID: td.RefreshUUID,
+	}, rt.Sub(now))
 }
@@ -0,0 +1,47 @@
+package authentication
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mshto/fruit-store/cache"
+)
+
+// ErrChallengeInvalid sign in challenge is unknown, expired or was already completed
+var ErrChallengeInvalid = errors.New("challenge token is invalid or expired")
+
+const defaultChallengeExpiresInMin = 5
+
+var (
+	challengePattern = "challenge_%s"
+)
+
+// CreateChallenge create a short-lived token of user who passed the first sign in step
+func (aui *authImpl) CreateChallenge(userUUID uuid.UUID) (string, error) {
+	expiresInMin := aui.cfg.Auth.ChallengeExpiresInMin
+	if expiresInMin <= 0 {
+		expiresInMin = defaultChallengeExpiresInMin
+	}
+
+	return aui.createOpaqueToken(challengePattern, userUUID, time.Duration(expiresInMin)*time.Minute)
+}
+
+// GetChallenge get user of challenge, challenge is kept so user can retry a mistyped code
+func (aui *authImpl) GetChallenge(token string) (uuid.UUID, error) {
+	userUUID, err := aui.getOpaqueToken(challengePattern, token)
+	if err == cache.ErrNotFound {
+		return uuid.Nil, ErrChallengeInvalid
+	}
+	return userUUID, err
+}
+
+// CompleteChallenge remove challenge once the second sign in step is passed, challenge can be completed only once
+func (aui *authImpl) CompleteChallenge(token string) error {
+	err := aui.removeOpaqueToken(challengePattern, token)
+	if err == cache.ErrNotFound {
+		return ErrChallengeInvalid
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package authentication
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/google/uuid"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/cache"
+	"github.com/mshto/fruit-store/config"
+)
+
+func TestChallenge(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal("failed to init miniredis")
+	}
+	defer s.Close()
+
+	redis, err := cache.New(cache.Redis{Address: s.Addr()})
+	if err != nil {
+		t.Fatal("failed to init cache")
+	}
+
+	logger, _ := loggermock.NewNullLogger()
+	auth := New(&config.Config{}, logger, redis, nil)
+	userUUID := uuid.New()
+
+	token, err := auth.CreateChallenge(userUUID)
+	assert.Nil(t, err)
+	assert.Equal(t, defaultChallengeExpiresInMin*time.Minute, s.TTL(opaqueTokenKey(challengePattern, token)))
+
+	_, err = auth.GetChallenge("unknown")
+	assert.Equal(t, ErrChallengeInvalid, err)
+
+	// challenge is kept until it is completed
+	for i := 0; i < 2; i++ {
+		challenged, err := auth.GetChallenge(token)
+		assert.Nil(t, err)
+		assert.Equal(t, userUUID, challenged)
+	}
+
+	err = auth.CompleteChallenge(token)
+	assert.Nil(t, err)
+	err = auth.CompleteChallenge(token)
+	assert.Equal(t, ErrChallengeInvalid, err)
+	_, err = auth.GetChallenge(token)
+	assert.Equal(t, ErrChallengeInvalid, err)
+
+	// reset token can't be used as challenge
+	resetToken, err := auth.CreateResetToken(userUUID)
+	assert.Nil(t, err)
+	_, err = auth.GetChallenge(resetToken)
+	assert.Equal(t, ErrChallengeInvalid, err)
+}
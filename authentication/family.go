@@ -0,0 +1,71 @@
+package authentication
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mshto/fruit-store/cache"
+)
+
+// refresh token errors
+var (
+	ErrTokenRevoked       = errors.New("refresh token is revoked")
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+var (
+	familyPattern = "%s_family"
+)
+
+// tokenFamily tokens issued from one sign in, only the latest refresh token of a family is valid
+type tokenFamily struct {
+	UserUUID    string
+	AccessUUID  string
+	RefreshUUID string
+}
+
+func (aui *authImpl) getFamily(familyID string) (tokenFamily, error) {
+	var family tokenFamily
+
+	value, err := aui.cache.Get(fmt.Sprintf(familyPattern, familyID))
+	if err != nil {
+		return family, err
+	}
+
+	err = json.Unmarshal([]byte(value), &family)
+	return family, err
+}
+
+func (aui *authImpl) setFamily(familyID string, family tokenFamily, exp time.Duration) error {
+	serialized, err := json.Marshal(family)
+	if err != nil {
+		return err
+	}
+
+	return aui.cache.Set(fmt.Sprintf(familyPattern, familyID), serialized, exp)
+}
+
+// revokeFamily remove the latest tokens of family, family itself and its session
+func (aui *authImpl) revokeFamily(familyID string, family tokenFamily) error {
+	for _, key := range []string{family.AccessUUID, family.RefreshUUID, fmt.Sprintf(familyPattern, familyID)} {
+		err := aui.cache.Del(key)
+		if err != nil && err != cache.ErrNotFound {
+			return err
+		}
+	}
+	return aui.removeSession(family.UserUUID, familyID)
+}
+
+// reportReuse revokes family of reused refresh token and logs a security event
+func (aui *authImpl) reportReuse(familyID, refreshUUID string, family tokenFamily) error {
+	aui.log.Warnf("security event: refresh token reuse detected, user: %v, family: %v, token: %v", family.UserUUID, familyID, refreshUUID)
+
+	err := aui.revokeFamily(familyID, family)
+	if err != nil {
+		aui.log.Errorf("failed to revoke token family, user: %v, family: %v, error: %v", family.UserUUID, familyID, err)
+		return err
+	}
+	return ErrRefreshTokenReused
+}
@@ -0,0 +1,83 @@
+package authentication
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/cache"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+)
+
+func TestTokenFamily(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal("failed to init miniredis")
+	}
+	defer s.Close()
+
+	redis, err := cache.New(cache.Redis{Address: s.Addr()})
+	if err != nil {
+		t.Fatal("failed to init cache")
+	}
+
+	cfg := &config.Config{
+		Auth: config.Auth{
+			AccessSecret:               "accessSecret",
+			RefreshSecret:              "refreshSecret",
+			AccessSecretAtExpiresInMin: 1,
+		},
+	}
+	logger, hook := loggermock.NewNullLogger()
+	auth := New(cfg, logger, redis, nil)
+	userUUID := uuid.New()
+
+	first, err := auth.CreateTokens(userUUID, entity.Client{})
+	assert.Nil(t, err)
+	other, err := auth.CreateTokens(userUUID, entity.Client{})
+	assert.Nil(t, err)
+
+	second, err := auth.RefreshTokens(first.RefreshToken)
+	assert.Nil(t, err)
+
+	// rotated out access token is not usable anymore
+	firstAccess, err := auth.ValidateToken(first.AccessToken)
+	assert.Nil(t, err)
+	_, err = auth.GetUserUUID(firstAccess.AccessUUID)
+	assert.Equal(t, cache.ErrNotFound, err)
+
+	secondAccess, err := auth.ValidateToken(second.AccessToken)
+	assert.Nil(t, err)
+	user, err := auth.GetUserUUID(secondAccess.AccessUUID)
+	assert.Nil(t, err)
+	assert.Equal(t, userUUID.String(), user)
+
+	// reuse of rotated out refresh token revokes the whole family
+	_, err = auth.RefreshTokens(first.RefreshToken)
+	assert.Equal(t, ErrRefreshTokenReused, err)
+	assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+
+	_, err = auth.GetUserUUID(secondAccess.AccessUUID)
+	assert.Equal(t, cache.ErrNotFound, err)
+	_, err = auth.RefreshTokens(second.RefreshToken)
+	assert.Equal(t, ErrTokenRevoked, err)
+
+	// other families of the same user are not affected
+	_, err = auth.RefreshTokens(other.RefreshToken)
+	assert.Nil(t, err)
+
+	// logout removes the family
+	third, err := auth.CreateTokens(userUUID, entity.Client{})
+	assert.Nil(t, err)
+	thirdAccess, err := auth.ValidateToken(third.AccessToken)
+	assert.Nil(t, err)
+	err = auth.RemoveTokens(thirdAccess.AccessUUID, thirdAccess.UserUUID)
+	assert.Nil(t, err)
+	_, err = auth.RefreshTokens(third.RefreshToken)
+	assert.Equal(t, ErrTokenRevoked, err)
+}
@@ -0,0 +1,40 @@
+package authentication
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+)
+
+// CreateGuestToken create a signed token for an anonymous cart owner
+func (aui *authImpl) CreateGuestToken() (string, error) {
+	gtClaims := jwt.MapClaims{}
+	gtClaims[typClaim] = tokenTypeGuest
+	gtClaims["guest_id"] = uuid.New()
+	gtClaims["exp"] = time.Now().Add(time.Duration(aui.cfg.Auth.GuestExpiresInMin) * time.Minute).Unix()
+
+	return aui.sign(gtClaims, aui.cfg.Auth.AccessSecret)
+}
+
+// ValidateGuestToken validate guest token and return guest uuid
+func (aui *authImpl) ValidateGuestToken(tokenString string) (uuid.UUID, error) {
+	token, err := jwt.Parse(tokenString, aui.keyFunc(aui.cfg.Auth.AccessSecret))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return uuid.Nil, errors.New("guest token is invalid")
+	}
+	if claims[typClaim] != tokenTypeGuest {
+		return uuid.Nil, ErrInvalidTokenType
+	}
+
+	guestID, ok := claims["guest_id"].(string)
+	if !ok {
+		return uuid.Nil, errors.New("guest token is invalid")
+	}
+	return uuid.Parse(guestID)
+}
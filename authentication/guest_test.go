@@ -0,0 +1,137 @@
+package authentication
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	redismock "github.com/mshto/fruit-store/cache/mock"
+	"github.com/mshto/fruit-store/config"
+)
+
+func TestGuestToken(t *testing.T) {
+	type payload struct {
+		cfg      *config.Config
+		getToken func(auth Auth) string
+	}
+	type expected struct {
+		isErr bool
+	}
+
+	cfg := &config.Config{
+		Auth: config.Auth{
+			AccessSecret:      "accessSecret",
+			RefreshSecret:     "refreshSecret",
+			GuestExpiresInMin: 1,
+		},
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Validate guest token with success",
+			payload: payload{
+				cfg: cfg,
+				getToken: func(auth Auth) string {
+					token, _ := auth.CreateGuestToken()
+					return token
+				},
+			},
+			expected: expected{
+				isErr: false,
+			},
+		},
+		{
+			name: "Validate expired guest token with failed",
+			payload: payload{
+				cfg: &config.Config{
+					Auth: config.Auth{
+						AccessSecret:      "accessSecret",
+						GuestExpiresInMin: -1,
+					},
+				},
+				getToken: func(auth Auth) string {
+					token, _ := auth.CreateGuestToken()
+					return token
+				},
+			},
+			expected: expected{
+				isErr: true,
+			},
+		},
+		{
+			name: "Validate access token as guest token with failed",
+			payload: payload{
+				cfg: cfg,
+				getToken: func(auth Auth) string {
+					td := &TokenDetails{AccessUUID: uuid.New().String(), AtExpires: time.Now().Add(time.Minute).Unix()}
+					_ = auth.(*authImpl).createAccessToken(uuid.New(), td)
+					return td.AccessToken
+				},
+			},
+			expected: expected{
+				isErr: true,
+			},
+		},
+		{
+			name: "Validate refresh token as guest token with failed",
+			payload: payload{
+				cfg: &config.Config{
+					Auth: config.Auth{
+						AccessSecret:  "accessSecret",
+						RefreshSecret: "accessSecret",
+					},
+				},
+				getToken: func(auth Auth) string {
+					td := &TokenDetails{RefreshUUID: uuid.New().String(), RtExpires: time.Now().Add(time.Minute).Unix()}
+					_ = auth.(*authImpl).createRefreshToken(uuid.New(), td)
+					return td.RefreshToken
+				},
+			},
+			expected: expected{
+				isErr: true,
+			},
+		},
+		{
+			name: "Validate invalid guest token with failed",
+			payload: payload{
+				cfg: cfg,
+				getToken: func(auth Auth) string {
+					return "invalid"
+				},
+			},
+			expected: expected{
+				isErr: true,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+			cache := redismock.NewMockCache(mockCtrl)
+			auth := New(test.payload.cfg, logger, cache, nil)
+
+			guestUUID, err := auth.ValidateGuestToken(test.payload.getToken(auth))
+			if test.expected.isErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.NotEqual(t, uuid.Nil, guestUUID)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package authentication
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+)
+
+// key errors
+var (
+	ErrInvalidPEM         = errors.New("failed to decode PEM block")
+	ErrUnsupportedKeyType = errors.New("unsupported key type, only RSA and Ed25519 keys are supported")
+)
+
+// Key token signing key
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+}
+
+// KeySet keys to sign and verify tokens.
+// Only active key signs tokens, all keys verify them so retired keys keep tokens valid until they expire.
+type KeySet struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+// NewKeySet generate a new key set, active key must have a private key
+func NewKeySet(keys []*Key, activeKeyID string) (*KeySet, error) {
+	ks := &KeySet{
+		keys: map[string]*Key{},
+	}
+
+	for _, key := range keys {
+		if _, ok := ks.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id: %v", key.ID)
+		}
+		ks.keys[key.ID] = key
+	}
+
+	active, ok := ks.keys[activeKeyID]
+	if !ok || active.Private == nil {
+		return nil, fmt.Errorf("active key %q is not found or has no private key", activeKeyID)
+	}
+	ks.active = active
+
+	return ks, nil
+}
+
+// LoadKeySet read PEM encoded keys from files, nil key set means tokens are signed with HMAC secrets
+func LoadKeySet(cfg config.Auth) (*KeySet, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]*Key, 0, len(cfg.Keys))
+	for _, keyCfg := range cfg.Keys {
+		key, err := loadKey(keyCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key %v, error: %v", keyCfg.ID, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return NewKeySet(keys, cfg.ActiveKeyID)
+}
+
+// JWKS returns public keys in JSON Web Key Set format
+func (ks *KeySet) JWKS() entity.JWKS {
+	jwks := entity.JWKS{
+		Keys: []entity.JWK{},
+	}
+	if ks == nil {
+		return jwks
+	}
+
+	for _, key := range ks.keys {
+		jwk := entity.JWK{
+			Kid: key.ID,
+			Use: "sig",
+			Alg: key.Method.Alg(),
+		}
+
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+	return jwks
+}
+
+func (ks *KeySet) activeKey() *Key {
+	if ks == nil {
+		return nil
+	}
+	return ks.active
+}
+
+func (ks *KeySet) key(kid string) (*Key, bool) {
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+func loadKey(cfg config.Key) (*Key, error) {
+	key := &Key{ID: cfg.ID}
+
+	if cfg.PrivateKeyPath != "" {
+		data, err := ioutil.ReadFile(cfg.PrivateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		key.Private, key.Public, err = parsePrivateKey(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.PublicKeyPath != "" {
+		data, err := ioutil.ReadFile(cfg.PublicKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		key.Public, err = parsePublicKey(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch key.Public.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = SigningMethodEdDSA
+	default:
+		return nil, ErrUnsupportedKeyType
+	}
+	return key, nil
+}
+
+func parsePrivateKey(data []byte) (crypto.PrivateKey, crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, ErrInvalidPEM
+	}
+
+	if rsaKey, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return rsaKey, &rsaKey.PublicKey, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		return private, &private.PublicKey, nil
+	case ed25519.PrivateKey:
+		return private, private.Public(), nil
+	}
+	return nil, nil, ErrUnsupportedKeyType
+}
+
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidPEM
+	}
+
+	if rsaKey, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return rsaKey, nil
+	}
+
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
@@ -0,0 +1,319 @@
+package authentication
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/cache"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+)
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	path := filepath.Join(dir, name)
+	err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600)
+	if err != nil {
+		t.Fatalf("failed to write key, error: %v", err)
+	}
+	return path
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keys")
+	if err != nil {
+		t.Fatal("failed to create temp dir")
+	}
+	defer os.RemoveAll(dir)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("failed to generate rsa key")
+	}
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate ed25519 key")
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate ecdsa key")
+	}
+
+	edPrivateDER, _ := x509.MarshalPKCS8PrivateKey(edPrivate)
+	edPublicDER, _ := x509.MarshalPKIXPublicKey(edPublic)
+	ecPrivateDER, _ := x509.MarshalPKCS8PrivateKey(ecKey)
+
+	rsaPath := writePEM(t, dir, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+	edPath := writePEM(t, dir, "ed25519.pem", "PRIVATE KEY", edPrivateDER)
+	edPublicPath := writePEM(t, dir, "ed25519.pub", "PUBLIC KEY", edPublicDER)
+	ecPath := writePEM(t, dir, "ecdsa.pem", "PRIVATE KEY", ecPrivateDER)
+	invalidPath := filepath.Join(dir, "invalid.pem")
+	_ = ioutil.WriteFile(invalidPath, []byte("invalid"), 0600)
+
+	type expected struct {
+		kids  []string
+		isErr bool
+	}
+
+	tc := []struct {
+		name     string
+		cfg      config.Auth
+		expected expected
+	}{
+		{
+			name: "Load key set without keys with success",
+			cfg:  config.Auth{},
+		},
+		{
+			name: "Load key set with success",
+			cfg: config.Auth{
+				ActiveKeyID: "rsa",
+				Keys: []config.Key{
+					{ID: "rsa", PrivateKeyPath: rsaPath},
+					{ID: "ed25519", PrivateKeyPath: edPath},
+					{ID: "retired", PublicKeyPath: edPublicPath},
+				},
+			},
+			expected: expected{
+				kids: []string{"ed25519", "retired", "rsa"},
+			},
+		},
+		{
+			name: "Load key set verification only active key with failed",
+			cfg: config.Auth{
+				ActiveKeyID: "retired",
+				Keys:        []config.Key{{ID: "retired", PublicKeyPath: edPublicPath}},
+			},
+			expected: expected{isErr: true},
+		},
+		{
+			name: "Load key set unknown active key with failed",
+			cfg: config.Auth{
+				ActiveKeyID: "unknown",
+				Keys:        []config.Key{{ID: "rsa", PrivateKeyPath: rsaPath}},
+			},
+			expected: expected{isErr: true},
+		},
+		{
+			name: "Load key set duplicate key with failed",
+			cfg: config.Auth{
+				ActiveKeyID: "rsa",
+				Keys:        []config.Key{{ID: "rsa", PrivateKeyPath: rsaPath}, {ID: "rsa", PrivateKeyPath: edPath}},
+			},
+			expected: expected{isErr: true},
+		},
+		{
+			name: "Load key set unsupported key with failed",
+			cfg: config.Auth{
+				ActiveKeyID: "ecdsa",
+				Keys:        []config.Key{{ID: "ecdsa", PrivateKeyPath: ecPath}},
+			},
+			expected: expected{isErr: true},
+		},
+		{
+			name: "Load key set invalid PEM with failed",
+			cfg: config.Auth{
+				ActiveKeyID: "invalid",
+				Keys:        []config.Key{{ID: "invalid", PrivateKeyPath: invalidPath}},
+			},
+			expected: expected{isErr: true},
+		},
+		{
+			name: "Load key set missing file with failed",
+			cfg: config.Auth{
+				ActiveKeyID: "missing",
+				Keys:        []config.Key{{ID: "missing", PrivateKeyPath: filepath.Join(dir, "missing.pem")}},
+			},
+			expected: expected{isErr: true},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			keys, err := LoadKeySet(test.cfg)
+			if test.expected.isErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+
+			kids := []string{}
+			for _, jwk := range keys.JWKS().Keys {
+				kids = append(kids, jwk.Kid)
+			}
+			if test.expected.kids == nil {
+				assert.Nil(t, keys)
+				assert.Empty(t, kids)
+				return
+			}
+			assert.Equal(t, test.expected.kids, kids)
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("failed to generate rsa key")
+	}
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate ed25519 key")
+	}
+
+	keys, err := NewKeySet([]*Key{
+		{ID: "rsa", Method: jwt.SigningMethodRS256, Private: rsaKey, Public: &rsaKey.PublicKey},
+		{ID: "ed25519", Method: SigningMethodEdDSA, Private: edPrivate, Public: edPublic},
+	}, "rsa")
+	assert.Nil(t, err)
+
+	jwks := keys.JWKS()
+	assert.Len(t, jwks.Keys, 2)
+
+	ed := jwks.Keys[0]
+	assert.Equal(t, entity.JWK{Kty: "OKP", Kid: "ed25519", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: ed.X}, ed)
+	assert.Len(t, ed.X, 43)
+
+	rs := jwks.Keys[1]
+	assert.Equal(t, "RSA", rs.Kty)
+	assert.Equal(t, "RS256", rs.Alg)
+	assert.Equal(t, "AQAB", rs.E)
+	assert.Len(t, rs.N, 342)
+}
+
+func TestAsymmetricTokens(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("failed to generate rsa key")
+	}
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate ed25519 key")
+	}
+
+	rsaSigner := &Key{ID: "rsa", Method: jwt.SigningMethodRS256, Private: rsaKey, Public: &rsaKey.PublicKey}
+	edSigner := &Key{ID: "ed25519", Method: SigningMethodEdDSA, Private: edPrivate, Public: edPublic}
+	rsaVerifier := &Key{ID: "rsa", Method: jwt.SigningMethodRS256, Public: &rsaKey.PublicKey}
+
+	tc := []struct {
+		name   string
+		signer *Key
+	}{
+		{
+			name:   "RS256 tokens with success",
+			signer: rsaSigner,
+		},
+		{
+			name:   "EdDSA tokens with success",
+			signer: edSigner,
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			s, err := miniredis.Run()
+			if err != nil {
+				t.Fatal("failed to init miniredis")
+			}
+			defer s.Close()
+
+			redis, err := cache.New(cache.Redis{Address: s.Addr()})
+			if err != nil {
+				t.Fatal("failed to init cache")
+			}
+
+			cfg := &config.Config{
+				Auth: config.Auth{
+					AccessSecret:               "accessSecret",
+					RefreshSecret:              "refreshSecret",
+					AccessSecretAtExpiresInMin: 1,
+					GuestExpiresInMin:          1,
+				},
+			}
+			logger, _ := loggermock.NewNullLogger()
+
+			keys, err := NewKeySet([]*Key{test.signer}, test.signer.ID)
+			assert.Nil(t, err)
+			auth := New(cfg, logger, redis, keys)
+
+			tokens, err := auth.CreateTokens(uuid.New(), entity.Client{})
+			assert.Nil(t, err)
+
+			token, _, err := new(jwt.Parser).ParseUnverified(tokens.AccessToken, jwt.MapClaims{})
+			assert.Nil(t, err)
+			assert.Equal(t, test.signer.ID, token.Header["kid"])
+			assert.Equal(t, test.signer.Method.Alg(), token.Header["alg"])
+
+			_, err = auth.ValidateToken(tokens.AccessToken)
+			assert.Nil(t, err)
+
+			guestToken, err := auth.CreateGuestToken()
+			assert.Nil(t, err)
+			_, err = auth.ValidateGuestToken(guestToken)
+			assert.Nil(t, err)
+
+			// tokens signed with the same key are accepted only as their own kind
+			_, err = auth.ValidateToken(tokens.RefreshToken)
+			assert.Equal(t, ErrInvalidTokenType, err)
+			_, err = auth.ValidateToken(guestToken)
+			assert.Equal(t, ErrInvalidTokenType, err)
+			_, err = auth.RefreshTokens(tokens.AccessToken)
+			assert.Equal(t, ErrInvalidTokenType, err)
+			_, err = auth.ValidateGuestToken(tokens.AccessToken)
+			assert.Equal(t, ErrInvalidTokenType, err)
+
+			// rotated key keeps issued tokens valid while new tokens are signed with the new key
+			otherSigner := rsaSigner
+			if test.signer == rsaSigner {
+				otherSigner = edSigner
+			}
+			retired := &Key{ID: test.signer.ID, Method: test.signer.Method, Public: test.signer.Public}
+			rotated, err := NewKeySet([]*Key{otherSigner, retired}, otherSigner.ID)
+			assert.Nil(t, err)
+			rotatedAuth := New(cfg, logger, redis, rotated)
+
+			_, err = rotatedAuth.ValidateToken(tokens.AccessToken)
+			assert.Nil(t, err)
+			refreshed, err := rotatedAuth.RefreshTokens(tokens.RefreshToken)
+			assert.Nil(t, err)
+
+			// removed key invalidates its tokens
+			_, err = auth.ValidateToken(refreshed.AccessToken)
+			assert.NotNil(t, err)
+
+			// HMAC tokens are rejected once keys are configured
+			hmacAuth := New(cfg, logger, redis, nil)
+			hmacTokens, err := hmacAuth.CreateTokens(uuid.New(), entity.Client{})
+			assert.Nil(t, err)
+			_, err = auth.ValidateToken(hmacTokens.AccessToken)
+			assert.NotNil(t, err)
+
+			// token with kid of other algorithm is rejected
+			forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"access_uuid": "a", "user_id": "u"})
+			forged.Header["kid"] = test.signer.ID
+			forgedToken, err := forged.SignedString([]byte("accessSecret"))
+			assert.Nil(t, err)
+			_, err = auth.ValidateToken(forgedToken)
+			assert.NotNil(t, err)
+		})
+	}
+
+	_, err = NewKeySet([]*Key{rsaVerifier}, "rsa")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,128 @@
+package authentication
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mshto/fruit-store/cache"
+)
+
+// default sign in limits, used when they are not configured
+const (
+	defaultSigninMaxAttempts    = 5
+	defaultSigninMaxIPAttempts  = 20
+	defaultSigninBackoffBaseSec = 1
+	defaultSigninLockoutMin     = 15
+)
+
+var (
+	signinAttemptsPattern = "signin_attempts_%s_%s"
+	signinBlockPattern    = "signin_block_%s_%s"
+)
+
+// signinCounter failed sign in attempts counted by one subject, username or client ip
+type signinCounter struct {
+	subject     string
+	value       string
+	maxAttempts int
+}
+
+// SigninAllowed return time left until sign in of username from ip is allowed again, zero when it is allowed now
+func (aui *authImpl) SigninAllowed(username, ip string) (time.Duration, error) {
+	var retryAfter time.Duration
+	for _, counter := range aui.signinCounters(username, ip) {
+		ttl, err := aui.cache.TTL(fmt.Sprintf(signinBlockPattern, counter.subject, counter.value))
+		if err == cache.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if ttl > retryAfter {
+			retryAfter = ttl
+		}
+	}
+	return retryAfter, nil
+}
+
+// SigninFailed count failed sign in attempt and block next attempts with exponential backoff,
+// username or ip is locked out once it reaches max attempts. Time until the next allowed attempt is returned.
+func (aui *authImpl) SigninFailed(username, ip string) (time.Duration, error) {
+	lockout := aui.signinLockout()
+
+	var retryAfter time.Duration
+	for _, counter := range aui.signinCounters(username, ip) {
+		attempts, err := aui.cache.Incr(fmt.Sprintf(signinAttemptsPattern, counter.subject, counter.value), lockout)
+		if err != nil {
+			return 0, err
+		}
+
+		delay := lockout
+		if attempts < int64(counter.maxAttempts) {
+			delay = aui.signinBackoff(attempts)
+		}
+
+		err = aui.cache.Set(fmt.Sprintf(signinBlockPattern, counter.subject, counter.value), attempts, delay)
+		if err != nil {
+			return 0, err
+		}
+		if delay > retryAfter {
+			retryAfter = delay
+		}
+	}
+	return retryAfter, nil
+}
+
+// SigninSucceeded reset failed attempts of username, attempts of ip are kept until they expire
+func (aui *authImpl) SigninSucceeded(username string) error {
+	for _, pattern := range []string{signinAttemptsPattern, signinBlockPattern} {
+		err := aui.cache.Del(fmt.Sprintf(pattern, "user", username))
+		if err != nil && err != cache.ErrNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+func (aui *authImpl) signinCounters(username, ip string) []signinCounter {
+	maxAttempts := aui.cfg.Auth.SigninMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultSigninMaxAttempts
+	}
+	maxIPAttempts := aui.cfg.Auth.SigninMaxIPAttempts
+	if maxIPAttempts <= 0 {
+		maxIPAttempts = defaultSigninMaxIPAttempts
+	}
+
+	counters := []signinCounter{{subject: "user", value: username, maxAttempts: maxAttempts}}
+	if ip != "" {
+		counters = append(counters, signinCounter{subject: "ip", value: ip, maxAttempts: maxIPAttempts})
+	}
+	return counters
+}
+
+// signinBackoff delay after n-th failed attempt, doubled with every attempt and capped by lockout
+func (aui *authImpl) signinBackoff(attempts int64) time.Duration {
+	baseSec := aui.cfg.Auth.SigninBackoffBaseSec
+	if baseSec <= 0 {
+		baseSec = defaultSigninBackoffBaseSec
+	}
+
+	lockout := aui.signinLockout()
+	delay := time.Duration(baseSec) * time.Second
+	for i := int64(1); i < attempts && delay < lockout; i++ {
+		delay *= 2
+	}
+	if delay > lockout {
+		return lockout
+	}
+	return delay
+}
+
+func (aui *authImpl) signinLockout() time.Duration {
+	lockoutMin := aui.cfg.Auth.SigninLockoutMin
+	if lockoutMin <= 0 {
+		lockoutMin = defaultSigninLockoutMin
+	}
+	return time.Duration(lockoutMin) * time.Minute
+}
@@ -0,0 +1,137 @@
+package authentication
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/cache"
+	"github.com/mshto/fruit-store/config"
+)
+
+func TestSigninLimiter(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal("failed to init miniredis")
+	}
+	defer s.Close()
+
+	redis, err := cache.New(cache.Redis{Address: s.Addr()})
+	if err != nil {
+		t.Fatal("failed to init cache")
+	}
+
+	cfg := &config.Config{
+		Auth: config.Auth{
+			SigninMaxAttempts:    3,
+			SigninMaxIPAttempts:  4,
+			SigninBackoffBaseSec: 2,
+			SigninLockoutMin:     10,
+		},
+	}
+	logger, _ := loggermock.NewNullLogger()
+	auth := New(cfg, logger, redis, nil)
+
+	retryAfter, err := auth.SigninAllowed("test", "10.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), retryAfter)
+
+	// backoff is doubled with every failed attempt
+	retryAfter, err = auth.SigninFailed("test", "10.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, 2*time.Second, retryAfter)
+
+	retryAfter, err = auth.SigninAllowed("test", "10.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, 2*time.Second, retryAfter)
+
+	s.FastForward(2 * time.Second)
+	retryAfter, err = auth.SigninAllowed("test", "10.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), retryAfter)
+
+	retryAfter, err = auth.SigninFailed("test", "10.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, 4*time.Second, retryAfter)
+
+	// username is locked out after max attempts
+	s.FastForward(4 * time.Second)
+	retryAfter, err = auth.SigninFailed("test", "10.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, 10*time.Minute, retryAfter)
+
+	// other username from another ip is not affected
+	retryAfter, err = auth.SigninAllowed("other", "10.0.0.2")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), retryAfter)
+
+	// successful sign in resets username attempts but keeps ip attempts
+	err = auth.SigninSucceeded("test")
+	assert.Nil(t, err)
+	retryAfter, err = auth.SigninAllowed("test", "10.0.0.2")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), retryAfter)
+	retryAfter, err = auth.SigninAllowed("test", "10.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, 8*time.Second, retryAfter)
+
+	// ip is locked out after max attempts of different usernames
+	s.FastForward(8 * time.Second)
+	retryAfter, err = auth.SigninFailed("other", "10.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, 10*time.Minute, retryAfter)
+	retryAfter, err = auth.SigninAllowed("third", "10.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, 10*time.Minute, retryAfter)
+
+	// lockout expires
+	s.FastForward(10 * time.Minute)
+	retryAfter, err = auth.SigninAllowed("third", "10.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), retryAfter)
+}
+
+func TestSigninBackoff(t *testing.T) {
+	tc := []struct {
+		name     string
+		cfg      config.Auth
+		attempts int64
+		expected time.Duration
+	}{
+		{
+			name:     "Backoff default first attempt",
+			attempts: 1,
+			expected: time.Second,
+		},
+		{
+			name:     "Backoff default fourth attempt",
+			attempts: 4,
+			expected: 8 * time.Second,
+		},
+		{
+			name:     "Backoff capped by lockout",
+			cfg:      config.Auth{SigninLockoutMin: 1},
+			attempts: 40,
+			expected: time.Minute,
+		},
+		{
+			name:     "Backoff base longer than lockout",
+			cfg:      config.Auth{SigninBackoffBaseSec: 120, SigninLockoutMin: 1},
+			attempts: 1,
+			expected: time.Minute,
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			auth := &authImpl{cfg: &config.Config{Auth: test.cfg}}
+			assert.Equal(t, test.expected, auth.signinBackoff(test.attempts))
+		})
+	}
+}
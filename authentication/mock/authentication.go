@@ -10,6 +10,7 @@ import (
 	authentication "github.com/mshto/fruit-store/authentication"
 	entity "github.com/mshto/fruit-store/entity"
 	reflect "reflect"
+	time "time"
 )
 
 // MockAuth is a mock of Auth interface
@@ -35,19 +36,153 @@ func (m *MockAuth) EXPECT() *MockAuthMockRecorder {
 	return m.recorder
 }
 
+// CompleteChallenge mocks base method
+func (m *MockAuth) CompleteChallenge(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteChallenge", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteChallenge indicates an expected call of CompleteChallenge
+func (mr *MockAuthMockRecorder) CompleteChallenge(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteChallenge", reflect.TypeOf((*MockAuth)(nil).CompleteChallenge), arg0)
+}
+
+// ConsumeOIDCState mocks base method
+func (m *MockAuth) ConsumeOIDCState(arg0 string) (entity.OIDCState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeOIDCState", arg0)
+	ret0, _ := ret[0].(entity.OIDCState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeOIDCState indicates an expected call of ConsumeOIDCState
+func (mr *MockAuthMockRecorder) ConsumeOIDCState(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeOIDCState", reflect.TypeOf((*MockAuth)(nil).ConsumeOIDCState), arg0)
+}
+
+// ConsumeResetToken mocks base method
+func (m *MockAuth) ConsumeResetToken(arg0 string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeResetToken", arg0)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeResetToken indicates an expected call of ConsumeResetToken
+func (mr *MockAuthMockRecorder) ConsumeResetToken(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeResetToken", reflect.TypeOf((*MockAuth)(nil).ConsumeResetToken), arg0)
+}
+
+// CreateChallenge mocks base method
+func (m *MockAuth) CreateChallenge(arg0 uuid.UUID) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateChallenge", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateChallenge indicates an expected call of CreateChallenge
+func (mr *MockAuthMockRecorder) CreateChallenge(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChallenge", reflect.TypeOf((*MockAuth)(nil).CreateChallenge), arg0)
+}
+
+// CreateGuestToken mocks base method
+func (m *MockAuth) CreateGuestToken() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGuestToken")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateGuestToken indicates an expected call of CreateGuestToken
+func (mr *MockAuthMockRecorder) CreateGuestToken() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGuestToken", reflect.TypeOf((*MockAuth)(nil).CreateGuestToken))
+}
+
+// CreateOIDCState mocks base method
+func (m *MockAuth) CreateOIDCState(arg0 entity.OIDCState) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOIDCState", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOIDCState indicates an expected call of CreateOIDCState
+func (mr *MockAuthMockRecorder) CreateOIDCState(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOIDCState", reflect.TypeOf((*MockAuth)(nil).CreateOIDCState), arg0)
+}
+
+// CreateResetToken mocks base method
+func (m *MockAuth) CreateResetToken(arg0 uuid.UUID) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateResetToken", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateResetToken indicates an expected call of CreateResetToken
+func (mr *MockAuthMockRecorder) CreateResetToken(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateResetToken", reflect.TypeOf((*MockAuth)(nil).CreateResetToken), arg0)
+}
+
 // CreateTokens mocks base method
-func (m *MockAuth) CreateTokens(arg0 uuid.UUID) (*entity.Tokens, error) {
+func (m *MockAuth) CreateTokens(arg0 uuid.UUID, arg1 entity.Client) (*entity.Tokens, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateTokens", arg0)
+	ret := m.ctrl.Call(m, "CreateTokens", arg0, arg1)
 	ret0, _ := ret[0].(*entity.Tokens)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateTokens indicates an expected call of CreateTokens
-func (mr *MockAuthMockRecorder) CreateTokens(arg0 interface{}) *gomock.Call {
+func (mr *MockAuthMockRecorder) CreateTokens(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTokens", reflect.TypeOf((*MockAuth)(nil).CreateTokens), arg0, arg1)
+}
+
+// GetChallenge mocks base method
+func (m *MockAuth) GetChallenge(arg0 string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChallenge", arg0)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChallenge indicates an expected call of GetChallenge
+func (mr *MockAuthMockRecorder) GetChallenge(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTokens", reflect.TypeOf((*MockAuth)(nil).CreateTokens), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChallenge", reflect.TypeOf((*MockAuth)(nil).GetChallenge), arg0)
+}
+
+// GetSessions mocks base method
+func (m *MockAuth) GetSessions(arg0 string) ([]entity.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", arg0)
+	ret0, _ := ret[0].([]entity.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions
+func (mr *MockAuthMockRecorder) GetSessions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockAuth)(nil).GetSessions), arg0)
 }
 
 // GetUserUUID mocks base method
@@ -65,6 +200,20 @@ func (mr *MockAuthMockRecorder) GetUserUUID(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserUUID", reflect.TypeOf((*MockAuth)(nil).GetUserUUID), arg0)
 }
 
+// JWKS mocks base method
+func (m *MockAuth) JWKS() entity.JWKS {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(entity.JWKS)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS
+func (mr *MockAuthMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockAuth)(nil).JWKS))
+}
+
 // RefreshTokens mocks base method
 func (m *MockAuth) RefreshTokens(arg0 string) (*entity.Tokens, error) {
 	m.ctrl.T.Helper()
@@ -94,6 +243,121 @@ func (mr *MockAuthMockRecorder) RemoveTokens(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveTokens", reflect.TypeOf((*MockAuth)(nil).RemoveTokens), arg0, arg1)
 }
 
+// RevokeOtherSessions mocks base method
+func (m *MockAuth) RevokeOtherSessions(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeOtherSessions", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeOtherSessions indicates an expected call of RevokeOtherSessions
+func (mr *MockAuthMockRecorder) RevokeOtherSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeOtherSessions", reflect.TypeOf((*MockAuth)(nil).RevokeOtherSessions), arg0, arg1)
+}
+
+// RevokeSession mocks base method
+func (m *MockAuth) RevokeSession(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession
+func (mr *MockAuthMockRecorder) RevokeSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockAuth)(nil).RevokeSession), arg0, arg1)
+}
+
+// RevokeSessions mocks base method
+func (m *MockAuth) RevokeSessions(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSessions", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSessions indicates an expected call of RevokeSessions
+func (mr *MockAuthMockRecorder) RevokeSessions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSessions", reflect.TypeOf((*MockAuth)(nil).RevokeSessions), arg0)
+}
+
+// SigninAllowed mocks base method
+func (m *MockAuth) SigninAllowed(arg0, arg1 string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SigninAllowed", arg0, arg1)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SigninAllowed indicates an expected call of SigninAllowed
+func (mr *MockAuthMockRecorder) SigninAllowed(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SigninAllowed", reflect.TypeOf((*MockAuth)(nil).SigninAllowed), arg0, arg1)
+}
+
+// SigninFailed mocks base method
+func (m *MockAuth) SigninFailed(arg0, arg1 string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SigninFailed", arg0, arg1)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SigninFailed indicates an expected call of SigninFailed
+func (mr *MockAuthMockRecorder) SigninFailed(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SigninFailed", reflect.TypeOf((*MockAuth)(nil).SigninFailed), arg0, arg1)
+}
+
+// SigninSucceeded mocks base method
+func (m *MockAuth) SigninSucceeded(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SigninSucceeded", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SigninSucceeded indicates an expected call of SigninSucceeded
+func (mr *MockAuthMockRecorder) SigninSucceeded(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SigninSucceeded", reflect.TypeOf((*MockAuth)(nil).SigninSucceeded), arg0)
+}
+
+// TouchSession mocks base method
+func (m *MockAuth) TouchSession(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchSession", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchSession indicates an expected call of TouchSession
+func (mr *MockAuthMockRecorder) TouchSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchSession", reflect.TypeOf((*MockAuth)(nil).TouchSession), arg0, arg1)
+}
+
+// ValidateGuestToken mocks base method
+func (m *MockAuth) ValidateGuestToken(arg0 string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateGuestToken", arg0)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateGuestToken indicates an expected call of ValidateGuestToken
+func (mr *MockAuthMockRecorder) ValidateGuestToken(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateGuestToken", reflect.TypeOf((*MockAuth)(nil).ValidateGuestToken), arg0)
+}
+
 // ValidateToken mocks base method
 func (m *MockAuth) ValidateToken(arg0 string) (*authentication.AccessDetails, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,57 @@
+package authentication
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/mshto/fruit-store/cache"
+	"github.com/mshto/fruit-store/entity"
+)
+
+// ErrOIDCStateInvalid oidc login state is unknown, expired or was already used
+var ErrOIDCStateInvalid = errors.New("oidc state is invalid or expired")
+
+const defaultOIDCStateExpiresInMin = 10
+
+var (
+	oidcStatePattern = "oidc_state_%s"
+)
+
+// CreateOIDCState store nonce and PKCE verifier of login, returned token is passed to identity provider as state
+func (aui *authImpl) CreateOIDCState(state entity.OIDCState) (string, error) {
+	expiresInMin := aui.cfg.Auth.OIDC.StateExpiresInMin
+	if expiresInMin <= 0 {
+		expiresInMin = defaultOIDCStateExpiresInMin
+	}
+
+	value, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	return aui.createOpaqueValue(oidcStatePattern, string(value), time.Duration(expiresInMin)*time.Minute)
+}
+
+// ConsumeOIDCState get and remove state of login, state can be consumed only once
+func (aui *authImpl) ConsumeOIDCState(token string) (entity.OIDCState, error) {
+	state := entity.OIDCState{}
+
+	value, err := aui.cache.Get(opaqueTokenKey(oidcStatePattern, token))
+	if err == cache.ErrNotFound {
+		return state, ErrOIDCStateInvalid
+	}
+	if err != nil {
+		return state, err
+	}
+
+	err = aui.removeOpaqueToken(oidcStatePattern, token)
+	if err == cache.ErrNotFound {
+		return state, ErrOIDCStateInvalid
+	}
+	if err != nil {
+		return state, err
+	}
+
+	err = json.Unmarshal([]byte(value), &state)
+	return state, err
+}
@@ -0,0 +1,52 @@
+package authentication
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/cache"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+)
+
+func TestOIDCState(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal("failed to init miniredis")
+	}
+	defer s.Close()
+
+	redis, err := cache.New(cache.Redis{Address: s.Addr()})
+	if err != nil {
+		t.Fatal("failed to init cache")
+	}
+
+	logger, _ := loggermock.NewNullLogger()
+	cfg := &config.Config{Auth: config.Auth{OIDC: config.OIDC{StateExpiresInMin: 3}}}
+	auth := New(cfg, logger, redis, nil)
+	state := entity.OIDCState{Nonce: "nonce", CodeVerifier: "verifier"}
+
+	token, err := auth.CreateOIDCState(state)
+	assert.Nil(t, err)
+	assert.Equal(t, 3*time.Minute, s.TTL(opaqueTokenKey(oidcStatePattern, token)))
+
+	_, err = auth.ConsumeOIDCState("unknown")
+	assert.Equal(t, ErrOIDCStateInvalid, err)
+
+	consumed, err := auth.ConsumeOIDCState(token)
+	assert.Nil(t, err)
+	assert.Equal(t, state, consumed)
+
+	_, err = auth.ConsumeOIDCState(token)
+	assert.Equal(t, ErrOIDCStateInvalid, err)
+
+	token, err = auth.CreateOIDCState(state)
+	assert.Nil(t, err)
+	s.FastForward(4 * time.Minute)
+	_, err = auth.ConsumeOIDCState(token)
+	assert.Equal(t, ErrOIDCStateInvalid, err)
+}
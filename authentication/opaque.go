@@ -0,0 +1,50 @@
+package authentication
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// createOpaqueToken create random token which refers to user, only a hash of the token is stored
+// so the cache content can't be used in place of the token
+func (aui *authImpl) createOpaqueToken(pattern string, userUUID uuid.UUID, exp time.Duration) (string, error) {
+	return aui.createOpaqueValue(pattern, userUUID.String(), exp)
+}
+
+// createOpaqueValue create random token which refers to any value
+func (aui *authImpl) createOpaqueValue(pattern, value string, exp time.Duration) (string, error) {
+	raw := make([]byte, 32)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	err = aui.cache.Set(opaqueTokenKey(pattern, token), value, exp)
+	return token, err
+}
+
+// getOpaqueToken get user of token, cache.ErrNotFound is returned for unknown or expired token
+func (aui *authImpl) getOpaqueToken(pattern, token string) (uuid.UUID, error) {
+	userID, err := aui.cache.Get(opaqueTokenKey(pattern, token))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.Parse(userID)
+}
+
+// removeOpaqueToken remove token, only one of concurrent calls succeeds and others get cache.ErrNotFound
+func (aui *authImpl) removeOpaqueToken(pattern, token string) error {
+	return aui.cache.Del(opaqueTokenKey(pattern, token))
+}
+
+func opaqueTokenKey(pattern, token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return fmt.Sprintf(pattern, hex.EncodeToString(hash[:]))
+}
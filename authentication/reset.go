@@ -0,0 +1,49 @@
+package authentication
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mshto/fruit-store/cache"
+)
+
+// ErrResetTokenInvalid reset token is unknown, expired or was already used
+var ErrResetTokenInvalid = errors.New("reset token is invalid or expired")
+
+const defaultResetTokenExpiresInMin = 30
+
+var (
+	resetTokenPattern = "reset_%s"
+)
+
+// CreateResetToken create a single use password reset token
+func (aui *authImpl) CreateResetToken(userUUID uuid.UUID) (string, error) {
+	expiresInMin := aui.cfg.Auth.ResetTokenExpiresInMin
+	if expiresInMin <= 0 {
+		expiresInMin = defaultResetTokenExpiresInMin
+	}
+
+	return aui.createOpaqueToken(resetTokenPattern, userUUID, time.Duration(expiresInMin)*time.Minute)
+}
+
+// ConsumeResetToken validate reset token and return its user uuid, token can be consumed only once
+func (aui *authImpl) ConsumeResetToken(token string) (uuid.UUID, error) {
+	userUUID, err := aui.getOpaqueToken(resetTokenPattern, token)
+	if err == cache.ErrNotFound {
+		return uuid.Nil, ErrResetTokenInvalid
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	err = aui.removeOpaqueToken(resetTokenPattern, token)
+	if err == cache.ErrNotFound {
+		return uuid.Nil, ErrResetTokenInvalid
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return userUUID, nil
+}
@@ -0,0 +1,56 @@
+package authentication
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/google/uuid"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/cache"
+	"github.com/mshto/fruit-store/config"
+)
+
+func TestResetToken(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal("failed to init miniredis")
+	}
+	defer s.Close()
+
+	redis, err := cache.New(cache.Redis{Address: s.Addr()})
+	if err != nil {
+		t.Fatal("failed to init cache")
+	}
+
+	logger, _ := loggermock.NewNullLogger()
+	auth := New(&config.Config{Auth: config.Auth{ResetTokenExpiresInMin: 5}}, logger, redis, nil)
+	userUUID := uuid.New()
+
+	token, err := auth.CreateResetToken(userUUID)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, token)
+
+	// raw token is never stored
+	assert.False(t, s.Exists("reset_"+token))
+	assert.Equal(t, 5*time.Minute, s.TTL(opaqueTokenKey(resetTokenPattern, token)))
+
+	_, err = auth.ConsumeResetToken("unknown")
+	assert.Equal(t, ErrResetTokenInvalid, err)
+
+	consumed, err := auth.ConsumeResetToken(token)
+	assert.Nil(t, err)
+	assert.Equal(t, userUUID, consumed)
+
+	_, err = auth.ConsumeResetToken(token)
+	assert.Equal(t, ErrResetTokenInvalid, err)
+
+	// token expires
+	token, err = auth.CreateResetToken(userUUID)
+	assert.Nil(t, err)
+	s.FastForward(6 * time.Minute)
+	_, err = auth.ConsumeResetToken(token)
+	assert.Equal(t, ErrResetTokenInvalid, err)
+}
@@ -0,0 +1,191 @@
+package authentication
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mshto/fruit-store/cache"
+	"github.com/mshto/fruit-store/entity"
+)
+
+var (
+	sessionsPattern = "%s_sessions"
+)
+
+// GetSessions get active sessions of user, sessions of expired token families are pruned
+func (aui *authImpl) GetSessions(userUUID string) ([]entity.Session, error) {
+	sessions := []entity.Session{}
+
+	values, err := aui.cache.HGetAll(fmt.Sprintf(sessionsPattern, userUUID))
+	if err != nil {
+		return sessions, err
+	}
+
+	for sessionID, value := range values {
+		_, err = aui.getFamily(sessionID)
+		if err == cache.ErrNotFound {
+			err = aui.removeSession(userUUID, sessionID)
+			if err != nil {
+				return sessions, err
+			}
+			continue
+		}
+		if err != nil {
+			return sessions, err
+		}
+
+		session := entity.Session{}
+		err = json.Unmarshal([]byte(value), &session)
+		if err != nil {
+			return sessions, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+	return sessions, nil
+}
+
+// TouchSession update last used time of session, session revoked in the meantime isn't stored again
+func (aui *authImpl) TouchSession(userUUID, sessionID string) error {
+	key := fmt.Sprintf(sessionsPattern, userUUID)
+
+	value, err := aui.cache.HGet(key, sessionID)
+	if err == cache.ErrNotFound {
+		return entity.ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	session := entity.Session{}
+	err = json.Unmarshal([]byte(value), &session)
+	if err != nil {
+		return err
+	}
+
+	session.LastUsedAt = time.Now().UTC()
+	serialized, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	err = aui.cache.HSetExisting(key, sessionID, serialized, refreshTokenTTL)
+	if err == cache.ErrNotFound {
+		return entity.ErrSessionNotFound
+	}
+	return err
+}
+
+// RevokeSession revoke all tokens of user session
+func (aui *authImpl) RevokeSession(userUUID, sessionID string) error {
+	family, err := aui.getFamily(sessionID)
+	if err == cache.ErrNotFound {
+		err = aui.cache.HDel(fmt.Sprintf(sessionsPattern, userUUID), sessionID)
+		if err == cache.ErrNotFound {
+			return entity.ErrSessionNotFound
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if family.UserUUID != userUUID {
+		return entity.ErrSessionNotFound
+	}
+
+	return aui.revokeFamily(sessionID, family)
+}
+
+// RevokeSessions revoke tokens of all user sessions
+func (aui *authImpl) RevokeSessions(userUUID string) error {
+	key := fmt.Sprintf(sessionsPattern, userUUID)
+
+	values, err := aui.cache.HGetAll(key)
+	if err != nil {
+		return err
+	}
+
+	for sessionID := range values {
+		family, err := aui.getFamily(sessionID)
+		if err == cache.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = aui.revokeFamily(sessionID, family)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = aui.cache.Del(key)
+	if err == cache.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// RevokeOtherSessions revoke tokens of all user sessions except the kept one
+func (aui *authImpl) RevokeOtherSessions(userUUID, keepSessionID string) error {
+	values, err := aui.cache.HGetAll(fmt.Sprintf(sessionsPattern, userUUID))
+	if err != nil {
+		return err
+	}
+
+	for sessionID := range values {
+		if sessionID == keepSessionID {
+			continue
+		}
+
+		family, err := aui.getFamily(sessionID)
+		if err == cache.ErrNotFound {
+			err = aui.removeSession(userUUID, sessionID)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = aui.revokeFamily(sessionID, family)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createSession store session of user, sessions of user expire with the latest issued refresh token
+func (aui *authImpl) createSession(userUUID, sessionID string, client entity.Client) error {
+	now := time.Now().UTC()
+
+	serialized, err := json.Marshal(entity.Session{
+		ID:         sessionID,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	return aui.cache.HSet(fmt.Sprintf(sessionsPattern, userUUID), sessionID, serialized, refreshTokenTTL)
+}
+
+func (aui *authImpl) removeSession(userUUID, sessionID string) error {
+	err := aui.cache.HDel(fmt.Sprintf(sessionsPattern, userUUID), sessionID)
+	if err == cache.ErrNotFound {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+package authentication
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis"
+	"github.com/google/uuid"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/cache"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+)
+
+func TestSessions(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal("failed to init miniredis")
+	}
+	defer s.Close()
+
+	redis, err := cache.New(cache.Redis{Address: s.Addr()})
+	if err != nil {
+		t.Fatal("failed to init cache")
+	}
+
+	cfg := &config.Config{
+		Auth: config.Auth{
+			AccessSecret:               "accessSecret",
+			RefreshSecret:              "refreshSecret",
+			AccessSecretAtExpiresInMin: 1,
+		},
+	}
+	logger, _ := loggermock.NewNullLogger()
+	auth := New(cfg, logger, redis, nil)
+	userUUID := uuid.New()
+
+	laptop, err := auth.CreateTokens(userUUID, entity.Client{UserAgent: "laptop", IP: "10.0.0.1"})
+	assert.Nil(t, err)
+	phone, err := auth.CreateTokens(userUUID, entity.Client{UserAgent: "phone", IP: "10.0.0.2"})
+	assert.Nil(t, err)
+	tablet, err := auth.CreateTokens(userUUID, entity.Client{UserAgent: "tablet", IP: "10.0.0.3"})
+	assert.Nil(t, err)
+
+	sessions, err := auth.GetSessions(userUUID.String())
+	assert.Nil(t, err)
+	assert.Len(t, sessions, 3)
+	assert.Equal(t, refreshTokenTTL, s.TTL(userUUID.String()+"_sessions"))
+
+	laptopAccess, err := auth.ValidateToken(laptop.AccessToken)
+	assert.Nil(t, err)
+	phoneAccess, err := auth.ValidateToken(phone.AccessToken)
+	assert.Nil(t, err)
+	tabletAccess, err := auth.ValidateToken(tablet.AccessToken)
+	assert.Nil(t, err)
+
+	// refresh keeps the session and moves it to the top
+	laptop, err = auth.RefreshTokens(laptop.RefreshToken)
+	assert.Nil(t, err)
+	sessions, err = auth.GetSessions(userUUID.String())
+	assert.Nil(t, err)
+	assert.Equal(t, laptopAccess.SessionID, sessions[0].ID)
+	assert.Equal(t, "laptop", sessions[0].UserAgent)
+	assert.Equal(t, "10.0.0.1", sessions[0].IP)
+
+	// sessions of other users can not be revoked
+	err = auth.RevokeSession(uuid.New().String(), phoneAccess.SessionID)
+	assert.Equal(t, entity.ErrSessionNotFound, err)
+	err = auth.RevokeSession(userUUID.String(), "unknown")
+	assert.Equal(t, entity.ErrSessionNotFound, err)
+
+	err = auth.RevokeSession(userUUID.String(), phoneAccess.SessionID)
+	assert.Nil(t, err)
+	_, err = auth.GetUserUUID(phoneAccess.AccessUUID)
+	assert.Equal(t, cache.ErrNotFound, err)
+	_, err = auth.RefreshTokens(phone.RefreshToken)
+	assert.Equal(t, ErrTokenRevoked, err)
+
+	// revoked session isn't stored again when touched
+	err = auth.TouchSession(userUUID.String(), phoneAccess.SessionID)
+	assert.Equal(t, entity.ErrSessionNotFound, err)
+	assert.Equal(t, "", s.HGet(userUUID.String()+"_sessions", phoneAccess.SessionID))
+
+	// sessions of expired families are pruned
+	s.Del(tabletAccess.SessionID + "_family")
+	sessions, err = auth.GetSessions(userUUID.String())
+	assert.Nil(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, "", s.HGet(userUUID.String()+"_sessions", tabletAccess.SessionID))
+
+	err = auth.RevokeSessions(userUUID.String())
+	assert.Nil(t, err)
+	laptopAccess, err = auth.ValidateToken(laptop.AccessToken)
+	assert.Nil(t, err)
+	_, err = auth.GetUserUUID(laptopAccess.AccessUUID)
+	assert.Equal(t, cache.ErrNotFound, err)
+	sessions, err = auth.GetSessions(userUUID.String())
+	assert.Nil(t, err)
+	assert.Empty(t, sessions)
+
+	err = auth.RevokeSessions(userUUID.String())
+	assert.Nil(t, err)
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal("failed to init miniredis")
+	}
+	defer s.Close()
+
+	redis, err := cache.New(cache.Redis{Address: s.Addr()})
+	if err != nil {
+		t.Fatal("failed to init cache")
+	}
+
+	cfg := &config.Config{
+		Auth: config.Auth{
+			AccessSecret:               "accessSecret",
+			RefreshSecret:              "refreshSecret",
+			AccessSecretAtExpiresInMin: 1,
+		},
+	}
+	logger, _ := loggermock.NewNullLogger()
+	auth := New(cfg, logger, redis, nil)
+	userUUID := uuid.New()
+
+	current, err := auth.CreateTokens(userUUID, entity.Client{UserAgent: "laptop"})
+	assert.Nil(t, err)
+	other, err := auth.CreateTokens(userUUID, entity.Client{UserAgent: "phone"})
+	assert.Nil(t, err)
+
+	currentAccess, err := auth.ValidateToken(current.AccessToken)
+	assert.Nil(t, err)
+	otherAccess, err := auth.ValidateToken(other.AccessToken)
+	assert.Nil(t, err)
+
+	err = auth.RevokeOtherSessions(userUUID.String(), currentAccess.SessionID)
+	assert.Nil(t, err)
+
+	_, err = auth.GetUserUUID(otherAccess.AccessUUID)
+	assert.Equal(t, cache.ErrNotFound, err)
+	_, err = auth.RefreshTokens(other.RefreshToken)
+	assert.Equal(t, ErrTokenRevoked, err)
+
+	_, err = auth.GetUserUUID(currentAccess.AccessUUID)
+	assert.Nil(t, err)
+	sessions, err := auth.GetSessions(userUUID.String())
+	assert.Nil(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, currentAccess.SessionID, sessions[0].ID)
+}
@@ -1,22 +1,26 @@
 package bill
 
 import (
-	"fmt"
+	"math"
 	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/mshto/fruit-store/cache"
 	"github.com/sirupsen/logrus"
 
 	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/currency"
 	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/tax"
 )
 
 //go:generate mockgen -destination=mock/bill.go -package=billmock github.com/mshto/fruit-store/bill Bill
 
 // Bill interface
 type Bill interface {
-	GetTotalInfo(userUUID uuid.UUID, products []entity.GetUserProduct) (TotalInfo, error)
+	GetTotalInfo(userUUID uuid.UUID, products []entity.GetUserProduct, display currency.Display, region string) (TotalInfo, error)
+	GetQuoteInfo(products []entity.GetUserProduct, display currency.Display, region string, coupons ...config.GeneralSale) TotalInfo
 
 	GetDiscountByUser(userUUID uuid.UUID) (config.GeneralSale, error)
 	SetDiscount(userUUID uuid.UUID, sale config.GeneralSale) error
@@ -28,29 +32,38 @@ type Bill interface {
 // Sale sale info struct
 type Sale struct {
 	Name     string
-	Elements map[string]int
+	Elements map[string]float64
 	Discount int
 }
 
-// TotalInfo total info struct
+// TotalInfo total info struct, price, savings and taxes are in display currency,
+// base price is the price in base currency checkout settles in and base total and base tax are values in it.
+// Price is the gross price.
 type TotalInfo struct {
-	Price   string
-	Savings string
-	Amount  string
+	Price     string
+	Savings   string
+	Amount    string
+	Net       string
+	Tax       string
+	Taxes     []entity.TaxLine
+	BasePrice string
+	BaseTotal float64
+	BaseTax   float64
 }
 
 // Result result struct
 type Result struct {
 	Name     string
 	Price    float32
-	Amount   int
+	Amount   float64
 	Discount int
 }
 
-// ProductMap product map struct
+// ProductMap product map struct, amount is in the unit of product
 type ProductMap struct {
-	Price  float32
-	Amount int
+	Price    float32
+	Amount   float64
+	Category string
 }
 
 // New generate a new bill
@@ -68,8 +81,8 @@ type billImpl struct {
 	cache cache.Cache
 }
 
-// GetTotalInfo get total price info
-func (bli *billImpl) GetTotalInfo(userUUID uuid.UUID, products []entity.GetUserProduct) (TotalInfo, error) {
+// GetTotalInfo get total price info with taxes of region, prices of products are in base currency
+func (bli *billImpl) GetTotalInfo(userUUID uuid.UUID, products []entity.GetUserProduct, display currency.Display, region string) (TotalInfo, error) {
 	var sales []config.GeneralSale
 	userDiscount, err := bli.GetDiscountByUser(userUUID)
 
@@ -80,33 +93,70 @@ func (bli *billImpl) GetTotalInfo(userUUID uuid.UUID, products []entity.GetUserP
 	default:
 		sales = append(sales, userDiscount)
 	}
+
+	return bli.GetQuoteInfo(products, display, region, sales...), nil
+}
+
+// GetQuoteInfo get total price info with taxes of region for products with given coupons and general sales,
+// user discount is not applied, prices of products are in base currency
+func (bli *billImpl) GetQuoteInfo(products []entity.GetUserProduct, display currency.Display, region string, coupons ...config.GeneralSale) TotalInfo {
+	sales := append([]config.GeneralSale{}, coupons...)
 	sales = append(sales, bli.cfg.Sales...)
 
 	prdMap, priceWithoutSale := bli.getPriceWithoutSale(products)
 	salePrds, prd := bli.getProductsWithSale(sales, prdMap)
 
-	totalInfo := bli.getTotalInfo(salePrds, prd, priceWithoutSale)
-	return totalInfo, nil
+	return bli.getTotalInfo(salePrds, prd, priceWithoutSale, display, region)
 }
 
-func (bli *billImpl) getTotalInfo(salePrds []Result, products map[string]ProductMap, price float32) TotalInfo {
+// getTotalInfo sum discounted products and calculate taxes of their totals
+func (bli *billImpl) getTotalInfo(salePrds []Result, products map[string]ProductMap, price float32, display currency.Display, region string) TotalInfo {
 	var totalPrice float32
-	var amount int
+	var amount float64
+	lineTotals := map[string]float32{}
 
 	for _, salePrd := range salePrds {
-		totalPrice = totalPrice + (float32(salePrd.Amount) * salePrd.Price * ((100 - float32(salePrd.Discount)) / 100))
+		lineTotal := float32(salePrd.Amount) * salePrd.Price * ((100 - float32(salePrd.Discount)) / 100)
+		lineTotals[salePrd.Name] += lineTotal
+		totalPrice = totalPrice + lineTotal
 		amount = amount + salePrd.Amount
 	}
 
-	for _, product := range products {
-		totalPrice = totalPrice + float32(product.Amount)*product.Price
+	for name, product := range products {
+		lineTotal := float32(product.Amount) * product.Price
+		lineTotals[name] += lineTotal
+		totalPrice = totalPrice + lineTotal
 		amount = amount + product.Amount
 	}
 
+	lines := make([]tax.Line, 0, len(lineTotals))
+	for name, lineTotal := range lineTotals {
+		lines = append(lines, tax.Line{Category: products[name].Category, Total: float64(lineTotal)})
+	}
+	taxes := bli.cfg.Tax.Calculate(lines, region, display)
+	base := bli.cfg.Currency.BaseCode()
+	baseTaxes := bli.cfg.Tax.Calculate(lines, region, currency.Display{Code: base, Rate: 1})
+
+	taxLines := make([]entity.TaxLine, 0, len(taxes.Groups))
+	for _, group := range taxes.Groups {
+		taxLines = append(taxLines, entity.TaxLine{
+			Class: group.Class,
+			Rate:  group.Rate,
+			Net:   currency.Format(group.Net, display.Code),
+			Tax:   currency.Format(group.Tax, display.Code),
+		})
+	}
+
 	return TotalInfo{
-		Price:   fmt.Sprintf("%.2f", totalPrice),
-		Savings: fmt.Sprintf("%.2f", price-totalPrice),
-		Amount:  strconv.Itoa(amount),
+		Price:     currency.Format(taxes.Gross, display.Code),
+		Savings:   display.Format(float64(price - totalPrice)),
+		Amount:    strconv.FormatFloat(entity.RoundAmount(amount), 'f', -1, 64),
+		Net:       currency.Format(taxes.Net, display.Code),
+		Tax:       currency.Format(taxes.Tax, display.Code),
+		Taxes:     taxLines,
+		BasePrice: currency.Format(baseTaxes.Gross, base),
+		BaseTotal: baseTaxes.Gross,
+		BaseTax:   baseTaxes.Tax,
 	}
 }
 
@@ -115,8 +165,9 @@ func (bli *billImpl) getPriceWithoutSale(products []entity.GetUserProduct) (map[
 	prdMap := map[string]ProductMap{}
 	for _, product := range products {
 		prdMap[product.Name] = ProductMap{
-			Price:  product.Price,
-			Amount: product.Amount,
+			Price:    product.Price,
+			Amount:   product.Amount,
+			Category: product.Category,
 		}
 		totalPrice = totalPrice + product.Price*float32(product.Amount)
 	}
@@ -126,7 +177,12 @@ func (bli *billImpl) getPriceWithoutSale(products []entity.GetUserProduct) (map[
 func (bli *billImpl) getProductsWithSale(sales []config.GeneralSale, products map[string]ProductMap) ([]Result, map[string]ProductMap) {
 	results := []Result{}
 	for _, sale := range sales {
-		var count int
+		if sale.Category != "" {
+			results = append(results, getCategoryResults(sale, products)...)
+			continue
+		}
+
+		var count float64
 		var isElementsMissed bool
 		var isCountUpdated bool
 
@@ -136,7 +192,7 @@ func (bli *billImpl) getProductsWithSale(sales []config.GeneralSale, products ma
 				isElementsMissed = true
 				break
 			}
-			crtCount := product.Amount / productV
+			crtCount := times(product.Amount, productV)
 			if crtCount < count || count == 0 && !isCountUpdated {
 				isCountUpdated = true
 				count = crtCount
@@ -163,8 +219,8 @@ func (bli *billImpl) getProductsWithSale(sales []config.GeneralSale, products ma
 				result.Amount = product.Amount
 				product.Amount = 0
 			case "eq":
-				result.Amount = count * productV
-				product.Amount = product.Amount - result.Amount
+				result.Amount = entity.RoundAmount(count * productV)
+				product.Amount = entity.RoundAmount(product.Amount - result.Amount)
 			default:
 				continue
 			}
@@ -176,3 +232,36 @@ func (bli *billImpl) getProductsWithSale(sales []config.GeneralSale, products ma
 	}
 	return results, products
 }
+
+// times how many whole thresholds fit into amount, both are in the unit of product.
+// Small epsilon keeps decimal amounts like 0.3 kg / 0.1 kg from rounding down.
+func times(amount, threshold float64) float64 {
+	if threshold <= 0 {
+		return 0
+	}
+	return math.Floor(amount/threshold + 1e-9)
+}
+
+// getCategoryResults discount all not yet discounted units of products in sale category
+func getCategoryResults(sale config.GeneralSale, products map[string]ProductMap) []Result {
+	results := []Result{}
+	for productK, product := range products {
+		if product.Amount == 0 || !inCategory(product.Category, sale.Category) {
+			continue
+		}
+		results = append(results, Result{
+			Name:     productK,
+			Price:    product.Price,
+			Amount:   product.Amount,
+			Discount: sale.Discount,
+		})
+		product.Amount = 0
+		products[productK] = product
+	}
+	return results
+}
+
+// inCategory check whether category path is the given category or one of its subcategories
+func inCategory(path, category string) bool {
+	return path == category || strings.HasPrefix(path, category+"/")
+}
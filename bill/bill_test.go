@@ -10,7 +10,21 @@ import (
 
 	redismock "github.com/mshto/fruit-store/cache/mock"
 	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/currency"
 	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/tax"
+)
+
+var (
+	usd = currency.Display{Code: "USD", Rate: 1}
+	vat = tax.Tax{
+		Region:  "DE",
+		Classes: map[string]string{"fruits": "reduced"},
+		Rates: map[string]map[string]float64{
+			"DE": {tax.ClassStandard: 19, "reduced": 7},
+			"PL": {tax.ClassStandard: 23},
+		},
+	}
 )
 
 func TestGetTotalInfo(t *testing.T) {
@@ -55,9 +69,14 @@ func TestGetTotalInfo(t *testing.T) {
 
 			expected: expected{
 				total: TotalInfo{
-					Price:   "90.00",
-					Savings: "10.00",
-					Amount:  "1",
+					Price:     "90.00",
+					Savings:   "10.00",
+					Amount:    "1",
+					Net:       "90.00",
+					Tax:       "0.00",
+					Taxes:     []entity.TaxLine{{Class: tax.ClassStandard, Net: "90.00", Tax: "0.00"}},
+					BasePrice: "90.00",
+					BaseTotal: 90,
 				},
 				isErr: false,
 			},
@@ -68,14 +87,14 @@ func TestGetTotalInfo(t *testing.T) {
 				cfg: &config.Config{
 					Sales: []config.GeneralSale{
 						{
-							Elements: map[string]int{
+							Elements: map[string]float64{
 								"Apples": 1,
 							},
 							Rule:     "eq",
 							Discount: 10,
 						},
 						{
-							Elements: map[string]int{
+							Elements: map[string]float64{
 								"New": 1,
 							},
 							Rule:     "new",
@@ -98,9 +117,14 @@ func TestGetTotalInfo(t *testing.T) {
 
 			expected: expected{
 				total: TotalInfo{
-					Price:   "90.00",
-					Savings: "10.00",
-					Amount:  "1",
+					Price:     "90.00",
+					Savings:   "10.00",
+					Amount:    "1",
+					Net:       "90.00",
+					Tax:       "0.00",
+					Taxes:     []entity.TaxLine{{Class: tax.ClassStandard, Net: "90.00", Tax: "0.00"}},
+					BasePrice: "90.00",
+					BaseTotal: 90,
 				},
 				isErr: false,
 			},
@@ -121,7 +145,7 @@ func TestGetTotalInfo(t *testing.T) {
 
 			test.payload.cacheMock(cache)
 
-			total, err := bill.GetTotalInfo(test.payload.userUUID, test.payload.products)
+			total, err := bill.GetTotalInfo(test.payload.userUUID, test.payload.products, usd, "")
 			assert.Equal(t, total, test.expected.total)
 			if test.expected.isErr {
 				assert.NotNil(t, err)
@@ -129,3 +153,358 @@ func TestGetTotalInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestGetQuoteInfo(t *testing.T) {
+	type expected struct {
+		total TotalInfo
+	}
+	type payload struct {
+		cfg      *config.Config
+		products []entity.GetUserProduct
+		coupons  []config.GeneralSale
+		display  currency.Display
+		region   string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Get quote info with coupon with success",
+			payload: payload{
+				cfg: &config.Config{},
+				products: []entity.GetUserProduct{
+					{
+						Name:   "Apples",
+						Price:  100,
+						Amount: 2,
+					},
+				},
+				coupons: []config.GeneralSale{
+					{
+						Elements: map[string]float64{
+							"Apples": 1,
+						},
+						Rule:     "more",
+						Discount: 10,
+					},
+				},
+			},
+			expected: expected{
+				total: TotalInfo{
+					Price:     "180.00",
+					Savings:   "20.00",
+					Amount:    "2",
+					Net:       "180.00",
+					Tax:       "0.00",
+					Taxes:     []entity.TaxLine{{Class: tax.ClassStandard, Net: "180.00", Tax: "0.00"}},
+					BasePrice: "180.00",
+					BaseTotal: 180,
+				},
+			},
+		},
+		{
+			name: "Get quote info with category sale with success",
+			payload: payload{
+				cfg: &config.Config{
+					Sales: []config.GeneralSale{
+						{
+							Category: "tropical",
+							Discount: 50,
+						},
+					},
+				},
+				products: []entity.GetUserProduct{
+					{
+						Name:     "Bananas",
+						Price:    10,
+						Amount:   2,
+						Category: "tropical",
+					},
+					{
+						Name:     "Mangoes",
+						Price:    20,
+						Amount:   1,
+						Category: "tropical/exotic",
+					},
+					{
+						Name:     "Oranges",
+						Price:    5,
+						Amount:   2,
+						Category: "citrus",
+					},
+					{
+						Name:     "Pineapples",
+						Price:    30,
+						Amount:   1,
+						Category: "tropicalish",
+					},
+				},
+			},
+			expected: expected{
+				total: TotalInfo{
+					Price:     "60.00",
+					Savings:   "20.00",
+					Amount:    "6",
+					Net:       "60.00",
+					Tax:       "0.00",
+					Taxes:     []entity.TaxLine{{Class: tax.ClassStandard, Net: "60.00", Tax: "0.00"}},
+					BasePrice: "60.00",
+					BaseTotal: 60,
+				},
+			},
+		},
+		{
+			name: "Get quote info with threshold in kg with success",
+			payload: payload{
+				cfg: &config.Config{},
+				products: []entity.GetUserProduct{
+					{
+						Name:   "Apples",
+						Price:  2,
+						Unit:   entity.UnitKg,
+						Amount: 1.3,
+					},
+					{
+						Name:   "Pears",
+						Price:  4,
+						Unit:   entity.UnitKg,
+						Amount: 0.3,
+					},
+				},
+				coupons: []config.GeneralSale{
+					{
+						Elements: map[string]float64{
+							"Apples": 0.5,
+							"Pears":  0.1,
+						},
+						Rule:     "eq",
+						Discount: 50,
+					},
+				},
+			},
+			expected: expected{
+				total: TotalInfo{
+					Price:     "2.40",
+					Savings:   "1.40",
+					Amount:    "1.6",
+					Net:       "2.40",
+					Tax:       "0.00",
+					Taxes:     []entity.TaxLine{{Class: tax.ClassStandard, Net: "2.40", Tax: "0.00"}},
+					BasePrice: "2.40",
+					BaseTotal: 2.4,
+				},
+			},
+		},
+		{
+			name: "Get quote info without coupon with success",
+			payload: payload{
+				cfg: &config.Config{},
+				products: []entity.GetUserProduct{
+					{
+						Name:   "Apples",
+						Price:  100,
+						Amount: 2,
+					},
+				},
+			},
+			expected: expected{
+				total: TotalInfo{
+					Price:     "200.00",
+					Savings:   "0.00",
+					Amount:    "2",
+					Net:       "200.00",
+					Tax:       "0.00",
+					Taxes:     []entity.TaxLine{{Class: tax.ClassStandard, Net: "200.00", Tax: "0.00"}},
+					BasePrice: "200.00",
+					BaseTotal: 200,
+				},
+			},
+		},
+		{
+			name: "Get quote info in display currency with success",
+			payload: payload{
+				cfg: &config.Config{},
+				products: []entity.GetUserProduct{
+					{
+						Name:   "Apples",
+						Price:  1.99,
+						Amount: 3,
+					},
+				},
+				display: currency.Display{Code: "JPY", Rate: 104.3},
+			},
+			expected: expected{
+				total: TotalInfo{
+					Price:     "623",
+					Savings:   "0",
+					Amount:    "3",
+					Net:       "623",
+					Tax:       "0",
+					Taxes:     []entity.TaxLine{{Class: tax.ClassStandard, Net: "623", Tax: "0"}},
+					BasePrice: "5.97",
+					BaseTotal: 5.97,
+				},
+			},
+		},
+		{
+			name: "Get quote info with tax-exclusive prices after discount with success",
+			payload: payload{
+				cfg: &config.Config{Tax: vat},
+				products: []entity.GetUserProduct{
+					{
+						Name:     "Apples",
+						Price:    2,
+						Amount:   3,
+						Category: "fruits/apples",
+					},
+					{
+						Name:     "Juice",
+						Price:    10,
+						Amount:   1,
+						Category: "drinks",
+					},
+				},
+				coupons: []config.GeneralSale{
+					{
+						Elements: map[string]float64{
+							"Apples": 1,
+						},
+						Rule:     "more",
+						Discount: 50,
+					},
+				},
+				region: "de",
+			},
+			expected: expected{
+				total: TotalInfo{
+					Price:   "15.11",
+					Savings: "3.00",
+					Amount:  "4",
+					Net:     "13.00",
+					Tax:     "2.11",
+					Taxes: []entity.TaxLine{
+						{Class: "reduced", Rate: 7, Net: "3.00", Tax: "0.21"},
+						{Class: tax.ClassStandard, Rate: 19, Net: "10.00", Tax: "1.90"},
+					},
+					BasePrice: "15.11",
+					BaseTotal: 15.11,
+					BaseTax:   2.11,
+				},
+			},
+		},
+		{
+			name: "Get quote info with tax-inclusive prices rounded per line with success",
+			payload: payload{
+				cfg: &config.Config{Tax: tax.Tax{Inclusive: true, Rounding: tax.RoundingLine, Rates: vat.Rates}},
+				products: []entity.GetUserProduct{
+					{
+						Name:   "Apples",
+						Price:  0.99,
+						Amount: 1,
+					},
+					{
+						Name:   "Pears",
+						Price:  0.99,
+						Amount: 1,
+					},
+				},
+				region: "PL",
+			},
+			expected: expected{
+				total: TotalInfo{
+					Price:     "1.98",
+					Savings:   "0.00",
+					Amount:    "2",
+					Net:       "1.60",
+					Tax:       "0.38",
+					Taxes:     []entity.TaxLine{{Class: tax.ClassStandard, Rate: 23, Net: "1.60", Tax: "0.38"}},
+					BasePrice: "1.98",
+					BaseTotal: 1.98,
+					BaseTax:   0.38,
+				},
+			},
+		},
+		{
+			name: "Get quote info with tax-inclusive prices rounded per invoice with success",
+			payload: payload{
+				cfg: &config.Config{Tax: tax.Tax{Inclusive: true, Rounding: tax.RoundingInvoice, Rates: vat.Rates}},
+				products: []entity.GetUserProduct{
+					{
+						Name:   "Apples",
+						Price:  0.99,
+						Amount: 1,
+					},
+					{
+						Name:   "Pears",
+						Price:  0.99,
+						Amount: 1,
+					},
+				},
+				region: "PL",
+			},
+			expected: expected{
+				total: TotalInfo{
+					Price:     "1.98",
+					Savings:   "0.00",
+					Amount:    "2",
+					Net:       "1.61",
+					Tax:       "0.37",
+					Taxes:     []entity.TaxLine{{Class: tax.ClassStandard, Rate: 23, Net: "1.61", Tax: "0.37"}},
+					BasePrice: "1.98",
+					BaseTotal: 1.98,
+					BaseTax:   0.37,
+				},
+			},
+		},
+		{
+			name: "Get quote info with taxes in display currency with success",
+			payload: payload{
+				cfg: &config.Config{Tax: vat},
+				products: []entity.GetUserProduct{
+					{
+						Name:   "Juice",
+						Price:  10,
+						Amount: 1,
+					},
+				},
+				display: currency.Display{Code: "EUR", Rate: 0.8},
+			},
+			expected: expected{
+				total: TotalInfo{
+					Price:     "9.52",
+					Savings:   "0.00",
+					Amount:    "1",
+					Net:       "8.00",
+					Tax:       "1.52",
+					Taxes:     []entity.TaxLine{{Class: tax.ClassStandard, Rate: 19, Net: "8.00", Tax: "1.52"}},
+					BasePrice: "11.90",
+					BaseTotal: 11.9,
+					BaseTax:   1.9,
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+			bill := New(test.payload.cfg, logger, redismock.NewMockCache(mockCtrl))
+
+			display := test.payload.display
+			if display.Code == "" {
+				display = usd
+			}
+			total := bill.GetQuoteInfo(test.payload.products, display, test.payload.region, test.payload.coupons...)
+			assert.Equal(t, test.expected.total, total)
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	uuid "github.com/google/uuid"
 	bill "github.com/mshto/fruit-store/bill"
 	config "github.com/mshto/fruit-store/config"
+	currency "github.com/mshto/fruit-store/currency"
 	entity "github.com/mshto/fruit-store/entity"
 	reflect "reflect"
 )
@@ -51,19 +52,38 @@ func (mr *MockBillMockRecorder) GetDiscountByUser(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDiscountByUser", reflect.TypeOf((*MockBill)(nil).GetDiscountByUser), arg0)
 }
 
+// GetQuoteInfo mocks base method
+func (m *MockBill) GetQuoteInfo(arg0 []entity.GetUserProduct, arg1 currency.Display, arg2 string, arg3 ...config.GeneralSale) bill.TotalInfo {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetQuoteInfo", varargs...)
+	ret0, _ := ret[0].(bill.TotalInfo)
+	return ret0
+}
+
+// GetQuoteInfo indicates an expected call of GetQuoteInfo
+func (mr *MockBillMockRecorder) GetQuoteInfo(arg0, arg1, arg2 interface{}, arg3 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuoteInfo", reflect.TypeOf((*MockBill)(nil).GetQuoteInfo), varargs...)
+}
+
 // GetTotalInfo mocks base method
-func (m *MockBill) GetTotalInfo(arg0 uuid.UUID, arg1 []entity.GetUserProduct) (bill.TotalInfo, error) {
+func (m *MockBill) GetTotalInfo(arg0 uuid.UUID, arg1 []entity.GetUserProduct, arg2 currency.Display, arg3 string) (bill.TotalInfo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTotalInfo", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetTotalInfo", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(bill.TotalInfo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetTotalInfo indicates an expected call of GetTotalInfo
-func (mr *MockBillMockRecorder) GetTotalInfo(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockBillMockRecorder) GetTotalInfo(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalInfo", reflect.TypeOf((*MockBill)(nil).GetTotalInfo), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalInfo", reflect.TypeOf((*MockBill)(nil).GetTotalInfo), arg0, arg1, arg2, arg3)
 }
 
 // RemoveDiscount mocks base method
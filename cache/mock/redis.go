@@ -62,6 +62,112 @@ func (mr *MockCacheMockRecorder) Get(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCache)(nil).Get), arg0)
 }
 
+// HDel mocks base method
+func (m *MockCache) HDel(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HDel", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HDel indicates an expected call of HDel
+func (mr *MockCacheMockRecorder) HDel(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HDel", reflect.TypeOf((*MockCache)(nil).HDel), arg0, arg1)
+}
+
+// HGet mocks base method
+func (m *MockCache) HGet(arg0, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HGet", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HGet indicates an expected call of HGet
+func (mr *MockCacheMockRecorder) HGet(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HGet", reflect.TypeOf((*MockCache)(nil).HGet), arg0, arg1)
+}
+
+// HGetAll mocks base method
+func (m *MockCache) HGetAll(arg0 string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HGetAll", arg0)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HGetAll indicates an expected call of HGetAll
+func (mr *MockCacheMockRecorder) HGetAll(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HGetAll", reflect.TypeOf((*MockCache)(nil).HGetAll), arg0)
+}
+
+// HSet mocks base method
+func (m *MockCache) HSet(arg0, arg1 string, arg2 interface{}, arg3 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HSet", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HSet indicates an expected call of HSet
+func (mr *MockCacheMockRecorder) HSet(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HSet", reflect.TypeOf((*MockCache)(nil).HSet), arg0, arg1, arg2, arg3)
+}
+
+// HSetExisting mocks base method
+func (m *MockCache) HSetExisting(arg0, arg1 string, arg2 interface{}, arg3 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HSetExisting", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HSetExisting indicates an expected call of HSetExisting
+func (mr *MockCacheMockRecorder) HSetExisting(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HSetExisting", reflect.TypeOf((*MockCache)(nil).HSetExisting), arg0, arg1, arg2, arg3)
+}
+
+// Incr mocks base method
+func (m *MockCache) Incr(arg0 string, arg1 time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Incr", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Incr indicates an expected call of Incr
+func (mr *MockCacheMockRecorder) Incr(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Incr", reflect.TypeOf((*MockCache)(nil).Incr), arg0, arg1)
+}
+
+// MGet mocks base method
+func (m *MockCache) MGet(arg0 ...string) ([]string, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range arg0 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "MGet", varargs...)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MGet indicates an expected call of MGet
+func (mr *MockCacheMockRecorder) MGet(arg0 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MGet", reflect.TypeOf((*MockCache)(nil).MGet), arg0...)
+}
+
 // Set mocks base method
 func (m *MockCache) Set(arg0 string, arg1 interface{}, arg2 time.Duration) error {
 	m.ctrl.T.Helper()
@@ -75,3 +181,92 @@ func (mr *MockCacheMockRecorder) Set(arg0, arg1, arg2 interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockCache)(nil).Set), arg0, arg1, arg2)
 }
+
+// SetVersioned mocks base method
+func (m *MockCache) SetVersioned(arg0 string, arg1 int64, arg2 map[string]string, arg3 time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVersioned", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetVersioned indicates an expected call of SetVersioned
+func (mr *MockCacheMockRecorder) SetVersioned(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVersioned", reflect.TypeOf((*MockCache)(nil).SetVersioned), arg0, arg1, arg2, arg3)
+}
+
+// TTL mocks base method
+func (m *MockCache) TTL(arg0 string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TTL", arg0)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TTL indicates an expected call of TTL
+func (mr *MockCacheMockRecorder) TTL(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TTL", reflect.TypeOf((*MockCache)(nil).TTL), arg0)
+}
+
+// ZAddLimited mocks base method
+func (m *MockCache) ZAddLimited(arg0, arg1 string, arg2, arg3 float64, arg4 int64, arg5 time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ZAddLimited", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ZAddLimited indicates an expected call of ZAddLimited
+func (mr *MockCacheMockRecorder) ZAddLimited(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZAddLimited", reflect.TypeOf((*MockCache)(nil).ZAddLimited), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// ZCount mocks base method
+func (m *MockCache) ZCount(arg0 string, arg1 float64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ZCount", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ZCount indicates an expected call of ZCount
+func (mr *MockCacheMockRecorder) ZCount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZCount", reflect.TypeOf((*MockCache)(nil).ZCount), arg0, arg1)
+}
+
+// ZRem mocks base method
+func (m *MockCache) ZRem(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ZRem", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ZRem indicates an expected call of ZRem
+func (mr *MockCacheMockRecorder) ZRem(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZRem", reflect.TypeOf((*MockCache)(nil).ZRem), arg0, arg1)
+}
+
+// ZScore mocks base method
+func (m *MockCache) ZScore(arg0, arg1 string) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ZScore", arg0, arg1)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ZScore indicates an expected call of ZScore
+func (mr *MockCacheMockRecorder) ZScore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZScore", reflect.TypeOf((*MockCache)(nil).ZScore), arg0, arg1)
+}
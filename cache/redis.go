@@ -2,7 +2,7 @@ package cache
 
 import (
 	"errors"
-	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis"
@@ -12,9 +12,61 @@ import (
 
 // error
 var (
-	ErrNotFound = errors.New("not found")
+	ErrNotFound       = errors.New("not found")
+	ErrVersionChanged = errors.New("version was changed")
 )
 
+// setVersionedScript sets values of keys and increments version counter at KEYS[1] when it equals ARGV[1],
+// missing counter equals 0. Every key expires after ARGV[2] milliseconds, zero keeps keys without expiration.
+var setVersionedScript = redis.NewScript(`
+local version = tonumber(redis.call('GET', KEYS[1]) or '0')
+if version ~= tonumber(ARGV[1]) then
+	return -1
+end
+local ttl = tonumber(ARGV[2])
+for i = 2, #KEYS do
+	redis.call('SET', KEYS[i], ARGV[i + 1])
+	if ttl > 0 then
+		redis.call('PEXPIRE', KEYS[i], ttl)
+	end
+end
+version = redis.call('INCR', KEYS[1])
+if ttl > 0 then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return version
+`)
+
+// hSetExistingScript sets field ARGV[1] of hash at KEYS[1] to ARGV[2] only when the field exists and returns 1,
+// 0 is returned otherwise. The hash expires after ARGV[3] milliseconds, zero keeps hash without expiration.
+var hSetExistingScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+if tonumber(ARGV[3]) > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return 1
+`)
+
+// zAddLimitedScript removes members of sorted set at KEYS[1] scored up to ARGV[3], then adds member ARGV[1] with
+// score ARGV[2] unless ARGV[4] other members are left and returns 1, 0 is returned otherwise.
+// The set expires at ARGV[5] milliseconds of unix time.
+var zAddLimitedScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[3])
+local count = redis.call('ZCARD', KEYS[1])
+if redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	count = count - 1
+end
+if count >= tonumber(ARGV[4]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+redis.call('PEXPIREAT', KEYS[1], ARGV[5])
+return 1
+`)
+
 // Redis info struct
 type Redis struct {
 	Address     string `json:"Address"      envconfig:"REDIS_ADDRESS"       validate:"required"`
@@ -47,8 +99,23 @@ func New(cfg Redis) (*CacheStr, error) {
 // Cache interface
 type Cache interface {
 	Get(key string) (string, error)
+	MGet(keys ...string) ([]string, error)
 	Set(key string, value interface{}, exp time.Duration) error
+	SetVersioned(versionKey string, expected int64, values map[string]string, exp time.Duration) (int64, error)
 	Del(key string) error
+	Incr(key string, exp time.Duration) (int64, error)
+	TTL(key string) (time.Duration, error)
+
+	HSet(key, field string, value interface{}, exp time.Duration) error
+	HSetExisting(key, field string, value interface{}, exp time.Duration) error
+	HGet(key, field string) (string, error)
+	HGetAll(key string) (map[string]string, error)
+	HDel(key, field string) error
+
+	ZAddLimited(key, member string, score, min float64, limit int64, expireAt time.Time) (bool, error)
+	ZScore(key, member string) (float64, error)
+	ZCount(key string, min float64) (int64, error)
+	ZRem(key, member string) error
 }
 
 // Get retrieves value from cache
@@ -62,16 +129,176 @@ func (m *CacheStr) Get(key string) (string, error) {
 	return value, err
 }
 
+// MGet retrieves values of keys from cache at once, missing values are empty
+func (m *CacheStr) MGet(keys ...string) ([]string, error) {
+	values, err := m.redis.MGet(keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, len(values))
+	for i, value := range values {
+		if str, ok := value.(string); ok {
+			result[i] = str
+		}
+	}
+	return result, nil
+}
+
 // Set stores value to cache
 func (m *CacheStr) Set(key string, value interface{}, exp time.Duration) error {
 	return m.redis.Set(key, value, exp).Err()
 }
 
+// SetVersioned stores values to cache and increments version counter at versionKey in one step, only when the counter
+// still equals expected version, ErrVersionChanged is returned otherwise. Missing counter equals 0.
+func (m *CacheStr) SetVersioned(versionKey string, expected int64, values map[string]string, exp time.Duration) (int64, error) {
+	keys := make([]string, 0, len(values)+1)
+	args := make([]interface{}, 0, len(values)+2)
+	keys = append(keys, versionKey)
+	args = append(args, expected, exp.Milliseconds())
+	for key, value := range values {
+		keys = append(keys, key)
+		args = append(args, value)
+	}
+
+	version, err := setVersionedScript.Run(m.redis, keys, args...).Int64()
+	if err != nil {
+		return 0, err
+	}
+	if version < 0 {
+		return 0, ErrVersionChanged
+	}
+	return version, nil
+}
+
 // Del invalidates value in cache
 func (m *CacheStr) Del(key string) error {
 	deletedAt, err := m.redis.Del(key).Result()
 	if err == nil && deletedAt != 1 {
-		return fmt.Errorf("failed to remove record, key: %s", key)
+		return ErrNotFound
+	}
+	return err
+}
+
+// Incr increments counter in cache and resets its expiration
+func (m *CacheStr) Incr(key string, exp time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := m.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(key)
+		pipe.Expire(key, exp)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// TTL retrieves remaining time to live of value in cache, value without expiration has zero TTL
+func (m *CacheStr) TTL(key string) (time.Duration, error) {
+	ttl, err := m.redis.TTL(key).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// redis replies -2 for missing key and -1 for key without expiration
+	switch {
+	case ttl == -2*time.Second:
+		return 0, ErrNotFound
+	case ttl < 0:
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// HSet stores field of hash to cache and resets expiration of hash, zero exp keeps hash without expiration
+func (m *CacheStr) HSet(key, field string, value interface{}, exp time.Duration) error {
+	_, err := m.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HSet(key, field, value)
+		if exp > 0 {
+			pipe.Expire(key, exp)
+		}
+		return nil
+	})
+	return err
+}
+
+// HSetExisting stores field of hash to cache only when the field exists, ErrNotFound is returned otherwise.
+// Expiration of hash is reset like by HSet.
+func (m *CacheStr) HSetExisting(key, field string, value interface{}, exp time.Duration) error {
+	set, err := hSetExistingScript.Run(m.redis, []string{key}, field, value, exp.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if set == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// HGet retrieves field of hash from cache
+func (m *CacheStr) HGet(key, field string) (string, error) {
+	value, err := m.redis.HGet(key, field).Result()
+
+	if err == redis.Nil {
+		return value, ErrNotFound
+	}
+
+	return value, err
+}
+
+// HGetAll retrieves all fields of hash from cache, missing hash is empty
+func (m *CacheStr) HGetAll(key string) (map[string]string, error) {
+	return m.redis.HGetAll(key).Result()
+}
+
+// HDel invalidates field of hash in cache
+func (m *CacheStr) HDel(key, field string) error {
+	deletedAt, err := m.redis.HDel(key, field).Result()
+	if err == nil && deletedAt != 1 {
+		return ErrNotFound
 	}
 	return err
 }
+
+// ZAddLimited adds member of sorted set with score unless limit of other members scored above min is reached,
+// members scored up to min are removed and the set expires at expireAt. The check and the add are one step.
+func (m *CacheStr) ZAddLimited(key, member string, score, min float64, limit int64, expireAt time.Time) (bool, error) {
+	expireAtMillis := expireAt.UnixNano() / int64(time.Millisecond)
+	added, err := zAddLimitedScript.Run(m.redis, []string{key},
+		member, formatScore(score), formatScore(min), limit, expireAtMillis).Int64()
+	if err != nil {
+		return false, err
+	}
+	return added == 1, nil
+}
+
+// ZScore retrieves score of member of sorted set from cache
+func (m *CacheStr) ZScore(key, member string) (float64, error) {
+	score, err := m.redis.ZScore(key, member).Result()
+
+	if err == redis.Nil {
+		return score, ErrNotFound
+	}
+
+	return score, err
+}
+
+// ZCount counts members of sorted set scored above min
+func (m *CacheStr) ZCount(key string, min float64) (int64, error) {
+	return m.redis.ZCount(key, "("+formatScore(min), "+inf").Result()
+}
+
+// ZRem invalidates member of sorted set in cache
+func (m *CacheStr) ZRem(key, member string) error {
+	deletedAt, err := m.redis.ZRem(key, member).Result()
+	if err == nil && deletedAt != 1 {
+		return ErrNotFound
+	}
+	return err
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
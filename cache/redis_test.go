@@ -56,5 +56,207 @@ func TestRedisDelNegative(t *testing.T) {
 	}
 
 	err = cache.Del(key)
-	assert.NotNil(t, err)
+	assert.Equal(t, err, ErrNotFound)
+}
+
+func TestRedisHash(t *testing.T) {
+	key := "test"
+
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal("failed to init miniredis")
+	}
+	defer s.Close()
+
+	cache, err := New(Redis{
+		Address: s.Addr(),
+	})
+	if err != nil {
+		t.Error("failed to init cache")
+	}
+
+	values, err := cache.HGetAll(key)
+	assert.Nil(t, err)
+	assert.Empty(t, values)
+
+	_, err = cache.HGet(key, "first")
+	assert.Equal(t, ErrNotFound, err)
+
+	err = cache.HSet(key, "first", "value", 0)
+	assert.Nil(t, err)
+	err = cache.HSet(key, "second", "value", 0)
+	assert.Nil(t, err)
+
+	value, err := cache.HGet(key, "first")
+	assert.Nil(t, err)
+	assert.Equal(t, "value", value)
+
+	values, err = cache.HGetAll(key)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"first": "value", "second": "value"}, values)
+
+	err = cache.HSetExisting(key, "second", "changed", time.Minute)
+	assert.Nil(t, err)
+	value, err = cache.HGet(key, "second")
+	assert.Nil(t, err)
+	assert.Equal(t, "changed", value)
+	assert.Equal(t, time.Minute, s.TTL(key))
+
+	err = cache.HDel(key, "first")
+	assert.Nil(t, err)
+	err = cache.HDel(key, "first")
+	assert.Equal(t, ErrNotFound, err)
+
+	err = cache.HSetExisting(key, "first", "value", time.Minute)
+	assert.Equal(t, ErrNotFound, err)
+	_, err = cache.HGet(key, "first")
+	assert.Equal(t, ErrNotFound, err)
+
+	err = cache.HSet(key, "first", "value", time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Hour, s.TTL(key))
+}
+
+func TestRedisCounter(t *testing.T) {
+	key := "test"
+
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal("failed to init miniredis")
+	}
+	defer s.Close()
+
+	cache, err := New(Redis{
+		Address: s.Addr(),
+	})
+	if err != nil {
+		t.Error("failed to init cache")
+	}
+
+	_, err = cache.TTL(key)
+	assert.Equal(t, ErrNotFound, err)
+
+	count, err := cache.Incr(key, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	count, err = cache.Incr(key, 2*time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+
+	ttl, err := cache.TTL(key)
+	assert.Nil(t, err)
+	assert.Equal(t, 2*time.Minute, ttl)
+
+	s.FastForward(3 * time.Minute)
+	_, err = cache.TTL(key)
+	assert.Equal(t, ErrNotFound, err)
+
+	err = cache.Set(key, "value", 0)
+	assert.Nil(t, err)
+	ttl, err = cache.TTL(key)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), ttl)
+}
+
+func TestRedisVersioned(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal("failed to init miniredis")
+	}
+	defer s.Close()
+
+	cache, err := New(Redis{
+		Address: s.Addr(),
+	})
+	if err != nil {
+		t.Error("failed to init cache")
+	}
+
+	values, err := cache.MGet("version", "first", "second")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"", "", ""}, values)
+
+	// missing version equals 0
+	version, err := cache.SetVersioned("version", 0, map[string]string{"first": "1", "second": "2"}, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), version)
+
+	values, err = cache.MGet("version", "first", "second")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "1", "2"}, values)
+
+	// stale version doesn't change values
+	_, err = cache.SetVersioned("version", 0, map[string]string{"first": "3"}, time.Minute)
+	assert.Equal(t, ErrVersionChanged, err)
+	first, err := cache.Get("first")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", first)
+
+	version, err = cache.SetVersioned("version", 1, nil, 2*time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), version)
+
+	ttl, err := cache.TTL("version")
+	assert.Nil(t, err)
+	assert.Equal(t, 2*time.Minute, ttl)
+	ttl, err = cache.TTL("first")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Minute, ttl)
+}
+
+func TestRedisSortedSet(t *testing.T) {
+	key := "test"
+
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal("failed to init miniredis")
+	}
+	defer s.Close()
+
+	cache, err := New(Redis{
+		Address: s.Addr(),
+	})
+	if err != nil {
+		t.Error("failed to init cache")
+	}
+	expireAt := time.Now().Add(time.Hour)
+
+	_, err = cache.ZScore(key, "first")
+	assert.Equal(t, ErrNotFound, err)
+
+	added, err := cache.ZAddLimited(key, "first", 10, 0, 2, expireAt)
+	assert.Nil(t, err)
+	assert.True(t, added)
+	added, err = cache.ZAddLimited(key, "second", 20, 0, 2, expireAt)
+	assert.Nil(t, err)
+	assert.True(t, added)
+
+	added, err = cache.ZAddLimited(key, "third", 30, 0, 2, expireAt)
+	assert.Nil(t, err)
+	assert.False(t, added)
+
+	added, err = cache.ZAddLimited(key, "first", 15, 0, 2, expireAt)
+	assert.Nil(t, err)
+	assert.True(t, added, "member doesn't count against limit of itself")
+	assert.True(t, s.TTL(key) > 0)
+
+	score, err := cache.ZScore(key, "first")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(15), score)
+
+	added, err = cache.ZAddLimited(key, "third", 30, 15, 2, expireAt)
+	assert.Nil(t, err)
+	assert.True(t, added, "members scored up to min don't count")
+	_, err = cache.ZScore(key, "first")
+	assert.Equal(t, ErrNotFound, err)
+
+	count, err := cache.ZCount(key, 20)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	err = cache.ZRem(key, "third")
+	assert.Nil(t, err)
+	err = cache.ZRem(key, "third")
+	assert.Equal(t, ErrNotFound, err)
 }
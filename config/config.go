@@ -2,14 +2,22 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 
 	"github.com/kelseyhightower/envconfig"
 	"gopkg.in/go-playground/validator.v9"
 
 	"github.com/mshto/fruit-store/cache"
+	"github.com/mshto/fruit-store/currency"
 	"github.com/mshto/fruit-store/database"
 	"github.com/mshto/fruit-store/logger"
+	"github.com/mshto/fruit-store/media"
+	"github.com/mshto/fruit-store/notifier"
+	"github.com/mshto/fruit-store/password"
+	"github.com/mshto/fruit-store/shipping"
+	"github.com/mshto/fruit-store/tax"
 )
 
 //Config struct stores system state configuration
@@ -21,22 +29,94 @@ type Config struct {
 	Database   database.Database `json:"Database"`
 	Redis      cache.Redis       `json:"Redis"`
 	Auth       Auth              `json:"Auth"`
+	Password   password.Password `json:"Password"`
+	Notifier   notifier.Notifier `json:"Notifier"`
+	Media      media.Media       `json:"Media"`
+	Currency   currency.Currency `json:"Currency"`
+	Tax        tax.Tax           `json:"Tax"`
+	Shipping   shipping.Shipping `json:"Shipping"`
+	Proxy      Proxy             `json:"Proxy"`
 	Sales      []GeneralSale
 }
 
+// Proxy struct stores addresses and CIDR networks of reverse proxies whose X-Forwarded-For header is trusted
+type Proxy struct {
+	TrustedProxies []string `json:"TrustedProxies"  envconfig:"TRUSTED_PROXIES"  validate:"dive,ip|cidr"`
+}
+
 // Auth struct stores auth secret keys
 type Auth struct {
-	AccessSecret               string `json:"AccessSecret"    envconfig:"AUTH_ACCESS_SECRET"     validate:"required"`
-	RefreshSecret              string `json:"RefreshSecret"   envconfig:"AUTH_REFRESH_SECRET"    validate:"required"`
+	AccessSecret               string `json:"AccessSecret"    envconfig:"AUTH_ACCESS_SECRET"`
+	RefreshSecret              string `json:"RefreshSecret"   envconfig:"AUTH_REFRESH_SECRET"`
 	AccessSecretAtExpiresInMin int    `json:"AccessSecretAtExpiresInMin"`
+	GuestExpiresInMin          int    `json:"GuestExpiresInMin"`
+	ResetTokenExpiresInMin     int    `json:"ResetTokenExpiresInMin"`
+	SigninMaxAttempts          int    `json:"SigninMaxAttempts"`
+	SigninMaxIPAttempts        int    `json:"SigninMaxIPAttempts"`
+	SigninBackoffBaseSec       int    `json:"SigninBackoffBaseSec"`
+	SigninLockoutMin           int    `json:"SigninLockoutMin"`
+	ChallengeExpiresInMin      int    `json:"ChallengeExpiresInMin"`
+	TOTPIssuer                 string `json:"TOTPIssuer"`
+	TOTPEnabled                bool   `json:"TOTPEnabled"`
+	TOTPEncryptionKey          string `json:"TOTPEncryptionKey"  envconfig:"AUTH_TOTP_ENCRYPTION_KEY"  validate:"required_with=TOTPEnabled"`
+	ActiveKeyID                string `json:"ActiveKeyID"     envconfig:"AUTH_ACTIVE_KEY_ID"`
+	Keys                       []Key  `json:"Keys"            validate:"dive"`
+	OIDC                       OIDC   `json:"OIDC"`
+}
+
+// OIDC struct stores OpenID Connect provider settings, login is disabled when issuer is empty
+type OIDC struct {
+	IssuerURL         string   `json:"IssuerURL"      envconfig:"AUTH_OIDC_ISSUER_URL"`
+	ClientID          string   `json:"ClientID"       envconfig:"AUTH_OIDC_CLIENT_ID"       validate:"required_with=IssuerURL"`
+	ClientSecret      string   `json:"ClientSecret"   envconfig:"AUTH_OIDC_CLIENT_SECRET"`
+	RedirectURL       string   `json:"RedirectURL"    envconfig:"AUTH_OIDC_REDIRECT_URL"    validate:"required_with=IssuerURL"`
+	Scopes            []string `json:"Scopes"`
+	StateExpiresInMin int      `json:"StateExpiresInMin"`
+}
+
+// Key struct stores paths of PEM encoded token signing keys, key without private part only verifies tokens
+type Key struct {
+	ID             string `json:"ID"             validate:"required"`
+	PrivateKeyPath string `json:"PrivateKeyPath"`
+	PublicKeyPath  string `json:"PublicKeyPath"`
 }
 
 // GeneralSale GeneralSale
 type GeneralSale struct {
 	ID       string
-	Elements map[string]int
+	Elements map[string]float64
 	Rule     string
 	Discount int
+	// Category applies discount to every product of category and its subcategories,
+	// Elements and Rule are ignored then
+	Category string `json:",omitempty"`
+}
+
+// ErrAuthSecretsRequired tokens are signed with HMAC secrets when there are no keys
+var ErrAuthSecretsRequired = errors.New("access and refresh secrets are required unless signing keys are set")
+
+// redacted replaces secrets of config when it is logged
+const redacted = "[redacted]"
+
+// String returns config with secrets redacted, so it is safe to log
+func (c Config) String() string {
+	type plain Config
+
+	c.Database.Password = redact(c.Database.Password)
+	c.Redis.Password = redact(c.Redis.Password)
+	c.Auth.AccessSecret = redact(c.Auth.AccessSecret)
+	c.Auth.RefreshSecret = redact(c.Auth.RefreshSecret)
+	c.Auth.TOTPEncryptionKey = redact(c.Auth.TOTPEncryptionKey)
+	c.Auth.OIDC.ClientSecret = redact(c.Auth.OIDC.ClientSecret)
+	c.Media.S3.SecretAccessKey = redact(c.Media.S3.SecretAccessKey)
+	return fmt.Sprintf("%v", plain(c))
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
 }
 
 // New is reading json file, validating and returning config
@@ -73,7 +153,14 @@ func New(configPath string, salesCfg string) (*Config, error) {
 func validate(c *Config) error {
 	v := validator.New()
 
-	return v.Struct(c)
+	err := v.Struct(c)
+	if err != nil {
+		return err
+	}
+	if len(c.Auth.Keys) == 0 && (c.Auth.AccessSecret == "" || c.Auth.RefreshSecret == "") {
+		return ErrAuthSecretsRequired
+	}
+	return nil
 }
 
 // readConfigFromENV reads data from environment variables
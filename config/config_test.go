@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -28,6 +29,11 @@ func TestNewDefaultConfig(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestNewShippedConfig(t *testing.T) {
+	_, err := New("../fruit_store_cfg.json", "../fruit_store_sales_cfg.json")
+	assert.Nil(t, err)
+}
+
 func TestNewInvalidJsonConfig(t *testing.T) {
 	_, err := New("mock/invalid_config.json", "mock/valid_sale_config.json")
 	assert.NotNil(t, err)
@@ -42,3 +48,49 @@ func TestNewInvalidSaleJsonConfig(t *testing.T) {
 	_, err := New("mock/valid_config.json", "mock/invalid_config.json")
 	assert.NotNil(t, err)
 }
+
+func TestConfigTOTPKeyRequired(t *testing.T) {
+	cfg, err := New("mock/valid_config.json", "mock/valid_sale_config.json")
+	assert.Nil(t, err)
+
+	cfg.Auth.TOTPEncryptionKey = ""
+	assert.NotNil(t, validate(cfg))
+
+	cfg.Auth.TOTPEnabled = false
+	assert.Nil(t, validate(cfg))
+}
+
+func TestConfigAuthSecretsRequired(t *testing.T) {
+	cfg, err := New("mock/valid_config.json", "mock/valid_sale_config.json")
+	assert.Nil(t, err)
+
+	cfg.Auth.RefreshSecret = ""
+	assert.Equal(t, ErrAuthSecretsRequired, validate(cfg))
+
+	// key pairs sign tokens instead of secrets
+	cfg.Auth.AccessSecret = ""
+	cfg.Auth.Keys = []Key{{ID: "key", PrivateKeyPath: "key.pem"}}
+	cfg.Auth.ActiveKeyID = "key"
+	assert.Nil(t, validate(cfg))
+}
+
+func TestConfigString(t *testing.T) {
+	cfg := &Config{ListenURL: "80"}
+	cfg.Database.Password = "db-password"
+	cfg.Redis.Password = "redis-password"
+	cfg.Auth.AccessSecret = "access-secret"
+	cfg.Auth.RefreshSecret = "refresh-secret"
+	cfg.Auth.TOTPEncryptionKey = "totp-key"
+	cfg.Auth.OIDC.ClientSecret = "client-secret"
+	cfg.Media.S3.SecretAccessKey = "s3-secret"
+
+	logged := fmt.Sprintf("config: %v", cfg)
+	for _, secret := range []string{"db-password", "redis-password", "access-secret", "refresh-secret", "totp-key", "client-secret", "s3-secret"} {
+		assert.NotContains(t, logged, secret)
+	}
+	assert.Contains(t, logged, redacted)
+	assert.Contains(t, logged, "80")
+
+	// config itself is kept
+	assert.Equal(t, "db-password", cfg.Database.Password)
+}
@@ -0,0 +1,54 @@
+package currency
+
+import (
+	"github.com/mshto/fruit-store/entity"
+)
+
+// ConvertProducts convert prices of products from their currencies to display currency
+func (r Rates) ConvertProducts(products []entity.Product, display Display) error {
+	for i := range products {
+		price, err := r.ToBase(float64(products[i].Price), products[i].Currency)
+		if err != nil {
+			return err
+		}
+		products[i].Price = float32(display.Convert(price))
+		products[i].Currency = display.Code
+	}
+	return nil
+}
+
+// CartToBase convert prices of cart products from their currencies to base currency, prices are not rounded
+func (r Rates) CartToBase(products []entity.GetUserProduct) error {
+	for i := range products {
+		price, err := r.ToBase(float64(products[i].Price), products[i].Currency)
+		if err != nil {
+			return err
+		}
+		if products[i].AddedPrice != nil {
+			addedPrice, err := r.ToBase(float64(*products[i].AddedPrice), products[i].Currency)
+			if err != nil {
+				return err
+			}
+			products[i].AddedPrice = float32Ptr(addedPrice)
+		}
+		products[i].Price = float32(price)
+		products[i].Currency = r.base
+	}
+	return nil
+}
+
+// ConvertCart convert prices of cart products from base currency to display currency
+func (d Display) ConvertCart(products []entity.GetUserProduct) {
+	for i := range products {
+		products[i].Price = float32(d.Convert(float64(products[i].Price)))
+		if products[i].AddedPrice != nil {
+			products[i].AddedPrice = float32Ptr(d.Convert(float64(*products[i].AddedPrice)))
+		}
+		products[i].Currency = d.Code
+	}
+}
+
+func float32Ptr(value float64) *float32 {
+	v := float32(value)
+	return &v
+}
@@ -0,0 +1,165 @@
+package currency
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+// DefaultBase currency prices are stored and checkout settles in, unless configured
+const DefaultBase = "USD"
+
+// currency errors
+var (
+	ErrUnsupported = errors.New("currency is not supported")
+	ErrNoRate      = errors.New("exchange rate of currency is not set")
+)
+
+// minorUnits number of decimal places of supported currencies, ISO 4217
+var minorUnits = map[string]int{
+	"CHF": 2,
+	"CZK": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"JPY": 0,
+	"KWD": 3,
+	"PLN": 2,
+	"SEK": 2,
+	"UAH": 2,
+	"USD": 2,
+}
+
+// Currency struct stores currency configuration
+type Currency struct {
+	Base string `json:"Base"  envconfig:"CURRENCY_BASE"  validate:"omitempty,len=3"`
+}
+
+// BaseCode code of base currency
+func (c Currency) BaseCode() string {
+	if c.Base == "" {
+		return DefaultBase
+	}
+	return strings.ToUpper(c.Base)
+}
+
+// Parse normalize code of supported currency
+func Parse(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if _, ok := minorUnits[code]; !ok {
+		return "", ErrUnsupported
+	}
+	return code, nil
+}
+
+// Round round amount half away from zero to minor units of currency.
+// Amount is rounded to 6 more places first, so 1.005 stored as 1.00499... rounds up.
+func Round(amount float64, code string) float64 {
+	scale := math.Pow10(decimals(code))
+	return math.Round(math.Round(amount*scale*1e6)/1e6) / scale
+}
+
+// Format round amount to minor units of currency and format it with all of them
+func Format(amount float64, code string) string {
+	return strconv.FormatFloat(Round(amount, code), 'f', decimals(code), 64)
+}
+
+// decimals minor units of currency, 2 for unknown ones
+func decimals(code string) int {
+	if units, ok := minorUnits[code]; ok {
+		return units
+	}
+	return 2
+}
+
+// Rates exchange rates of currencies, units of currency per unit of base currency
+type Rates struct {
+	base  string
+	rates map[string]float64
+}
+
+// NewRates init exchange rates to base currency
+func NewRates(base string, rates []entity.ExchangeRate) Rates {
+	rts := Rates{base: base, rates: make(map[string]float64, len(rates))}
+	for _, rate := range rates {
+		rts.rates[rate.Currency] = rate.Rate
+	}
+	return rts
+}
+
+// Source source of exchange rates
+type Source interface {
+	GetExchangeRates() ([]entity.ExchangeRate, error)
+}
+
+// Load exchange rates of source to base currency and display currency of code
+func Load(src Source, base, code string) (Rates, Display, error) {
+	rates, err := src.GetExchangeRates()
+	if err != nil {
+		return Rates{}, Display{}, err
+	}
+	rts := NewRates(base, rates)
+	display, err := rts.Display(code)
+	return rts, display, err
+}
+
+// IsInvalid whether err is about currency requested by user rather than a failure of loading rates
+func IsInvalid(err error) bool {
+	return err == ErrUnsupported || err == ErrNoRate
+}
+
+// Base code of base currency
+func (r Rates) Base() string {
+	return r.base
+}
+
+// Display currency amounts are displayed in, empty code is the base currency
+func (r Rates) Display(code string) (Display, error) {
+	if code == "" {
+		return Display{Code: r.base, Rate: 1}, nil
+	}
+	code, err := Parse(code)
+	if err != nil {
+		return Display{}, err
+	}
+	rate, err := r.rate(code)
+	return Display{Code: code, Rate: rate}, err
+}
+
+// ToBase convert amount in currency to base currency, not rounded, empty code is the base currency
+func (r Rates) ToBase(amount float64, code string) (float64, error) {
+	rate, err := r.rate(code)
+	if err != nil {
+		return 0, err
+	}
+	return amount / rate, nil
+}
+
+func (r Rates) rate(code string) (float64, error) {
+	if code == "" || code == r.base {
+		return 1, nil
+	}
+	rate, ok := r.rates[code]
+	if !ok {
+		return 0, ErrNoRate
+	}
+	return rate, nil
+}
+
+// Display currency with its exchange rate to base currency
+type Display struct {
+	Code string
+	Rate float64
+}
+
+// Convert convert amount in base currency and round it to minor units of display currency
+func (d Display) Convert(amount float64) float64 {
+	return Round(amount*d.Rate, d.Code)
+}
+
+// Format convert amount in base currency and format it in display currency
+func (d Display) Format(amount float64) string {
+	return Format(amount*d.Rate, d.Code)
+}
@@ -0,0 +1,179 @@
+package currency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+func TestFormat(t *testing.T) {
+	tc := []struct {
+		name     string
+		amount   float64
+		code     string
+		expected string
+	}{
+		{
+			name:     "Format amount in two decimals currency",
+			amount:   12.3456,
+			code:     "EUR",
+			expected: "12.35",
+		},
+		{
+			name:     "Format half amount rounded up",
+			amount:   1.005,
+			code:     "USD",
+			expected: "1.01",
+		},
+		{
+			name:     "Format negative half amount rounded away from zero",
+			amount:   -1.005,
+			code:     "PLN",
+			expected: "-1.01",
+		},
+		{
+			name:     "Format amount in currency without minor units",
+			amount:   149.5,
+			code:     "JPY",
+			expected: "150",
+		},
+		{
+			name:     "Format amount in three decimals currency",
+			amount:   0.12345,
+			code:     "KWD",
+			expected: "0.123",
+		},
+		{
+			name:     "Format amount in unknown currency",
+			amount:   2,
+			code:     "",
+			expected: "2.00",
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, Format(test.amount, test.code))
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	code, err := Parse(" eur ")
+	assert.Nil(t, err)
+	assert.Equal(t, "EUR", code)
+
+	_, err = Parse("XYZ")
+	assert.Equal(t, ErrUnsupported, err)
+}
+
+func TestBaseCode(t *testing.T) {
+	assert.Equal(t, DefaultBase, Currency{}.BaseCode())
+	assert.Equal(t, "EUR", Currency{Base: "eur"}.BaseCode())
+}
+
+func TestRates(t *testing.T) {
+	rates := NewRates("USD", []entity.ExchangeRate{{Currency: "EUR", Rate: 0.8}, {Currency: "JPY", Rate: 104.3}})
+
+	tc := []struct {
+		name     string
+		code     string
+		amount   float64
+		expected string
+		err      error
+	}{
+		{
+			name:     "Display in base currency by default",
+			amount:   10,
+			expected: "10.00",
+		},
+		{
+			name:     "Display in currency with rate",
+			code:     "eur",
+			amount:   1.99,
+			expected: "1.59",
+		},
+		{
+			name:     "Display in currency without minor units",
+			code:     "JPY",
+			amount:   1.99,
+			expected: "208",
+		},
+		{
+			name: "Display in currency without rate",
+			code: "PLN",
+			err:  ErrNoRate,
+		},
+		{
+			name: "Display in unsupported currency",
+			code: "ABC",
+			err:  ErrUnsupported,
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			display, err := rates.Display(test.code)
+			assert.Equal(t, test.err, err)
+			if err == nil {
+				assert.Equal(t, test.expected, display.Format(test.amount))
+			}
+		})
+	}
+}
+
+func TestToBase(t *testing.T) {
+	rates := NewRates("USD", []entity.ExchangeRate{{Currency: "EUR", Rate: 0.8}})
+
+	amount, err := rates.ToBase(2, "EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, 2.5, amount)
+
+	amount, err = rates.ToBase(2, "")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(2), amount)
+
+	_, err = rates.ToBase(2, "PLN")
+	assert.Equal(t, ErrNoRate, err)
+}
+
+func TestConvertProducts(t *testing.T) {
+	rates := NewRates("USD", []entity.ExchangeRate{{Currency: "EUR", Rate: 0.8}, {Currency: "PLN", Rate: 3.6}})
+	display, _ := rates.Display("PLN")
+
+	products := []entity.Product{{Name: "Apples", Price: 2}, {Name: "Pears", Price: 2, Currency: "EUR"}}
+	err := rates.ConvertProducts(products, display)
+	assert.Nil(t, err)
+	assert.Equal(t, []entity.Product{{Name: "Apples", Price: 7.2, Currency: "PLN"}, {Name: "Pears", Price: 9, Currency: "PLN"}}, products)
+
+	err = rates.ConvertProducts([]entity.Product{{Price: 2, Currency: "GBP"}}, display)
+	assert.Equal(t, ErrNoRate, err)
+}
+
+func TestConvertCart(t *testing.T) {
+	rates := NewRates("USD", []entity.ExchangeRate{{Currency: "EUR", Rate: 0.8}})
+	display, _ := rates.Display("EUR")
+
+	addedPrice := float32(1.6)
+	products := []entity.GetUserProduct{{Name: "Pears", Price: 2, Currency: "EUR", AddedPrice: &addedPrice, PriceChanged: true}}
+
+	err := rates.CartToBase(products)
+	assert.Nil(t, err)
+	assert.Equal(t, float32(2.5), products[0].Price)
+	assert.Equal(t, float32(2), *products[0].AddedPrice)
+	assert.Equal(t, "USD", products[0].Currency)
+
+	display.ConvertCart(products)
+	assert.Equal(t, float32(2), products[0].Price)
+	assert.Equal(t, float32(1.6), *products[0].AddedPrice)
+	assert.Equal(t, "EUR", products[0].Currency)
+	assert.Equal(t, float32(1.6), addedPrice)
+}
@@ -0,0 +1,72 @@
+package encryption
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+func TestEncryptDecrypt(t *testing.T) {
+	c, err := New(testKey)
+	assert.Nil(t, err)
+
+	first, err := c.Encrypt("secret")
+	assert.Nil(t, err)
+	second, err := c.Encrypt("secret")
+	assert.Nil(t, err)
+	assert.NotEqual(t, first, second)
+
+	plaintext, err := c.Decrypt(first)
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", plaintext)
+
+	other, err := New("ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=")
+	assert.Nil(t, err)
+	_, err = other.Decrypt(first)
+	assert.Equal(t, ErrInvalidCiphertext, err)
+}
+
+func TestNew(t *testing.T) {
+	tc := []struct {
+		name     string
+		key      string
+		expected error
+	}{
+		{
+			name: "New with success",
+			key:  testKey,
+		},
+		{
+			name:     "New not base64 key with fail",
+			key:      "not base64",
+			expected: ErrInvalidKey,
+		},
+		{
+			name:     "New short key with fail",
+			key:      "c2hvcnQ=",
+			expected: ErrInvalidKey,
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := New(test.key)
+			assert.Equal(t, test.expected, err)
+		})
+	}
+}
+
+func TestDecryptInvalid(t *testing.T) {
+	c, err := New(testKey)
+	assert.Nil(t, err)
+
+	for _, ciphertext := range []string{"", "not base64", "c2hvcnQ="} {
+		_, err = c.Decrypt(ciphertext)
+		assert.Equal(t, ErrInvalidCiphertext, err)
+	}
+}
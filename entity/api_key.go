@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// api key errors
+var (
+	ErrAPIKeyNotFound         = errors.New("api key not found")
+	ErrAPIKeyInvalid          = errors.New("api key is invalid or revoked")
+	ErrInvalidScope           = errors.New("unknown api key scope")
+	ErrEmptyScopes            = errors.New("api key must have at least one scope")
+	ErrServiceAccountNotFound = errors.New("service account not found")
+)
+
+// APIKey struct, the key itself is shown only once on creation
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"userId"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// CreateAPIKey struct, key is created for the caller unless service account is set
+type CreateAPIKey struct {
+	Name             string     `json:"name"`
+	Scopes           []string   `json:"scopes"`
+	ServiceAccountID *uuid.UUID `json:"serviceAccountId,omitempty"`
+}
+
+// CreatedAPIKey struct
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// ServiceAccount struct, user without password owned by another user
+type ServiceAccount struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
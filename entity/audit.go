@@ -0,0 +1,89 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// audit actions
+const (
+	AuditSignin             = "auth.signin"
+	AuditSigninTwoFactor    = "auth.signin_2fa"
+	AuditSigninOIDC         = "auth.signin_oidc"
+	AuditIdentityLink       = "auth.identity_link"
+	AuditRefresh            = "auth.refresh"
+	AuditLogout             = "auth.logout"
+	AuditLogoutAll          = "auth.logout_all"
+	AuditPasswordChange     = "auth.password_change"
+	AuditPasswordReset      = "auth.password_reset"
+	AuditCouponApply        = "cart.coupon_apply"
+	AuditRoleChange         = "admin.role_change"
+	AuditCategoryCreate     = "admin.category_create"
+	AuditCategoryDelete     = "admin.category_delete"
+	AuditProductCategory    = "admin.product_category"
+	AuditProductTags        = "admin.product_tags"
+	AuditMediaUpload        = "admin.media_upload"
+	AuditMediaDelete        = "admin.media_delete"
+	AuditPriceSchedule      = "admin.price_schedule"
+	AuditPriceCancel        = "admin.price_cancel"
+	AuditExchangeRateSet    = "admin.exchange_rate_set"
+	AuditExchangeRateDelete = "admin.exchange_rate_delete"
+	AuditProductCurrency    = "admin.product_currency"
+	AuditSlotCreate         = "admin.slot_create"
+	AuditSlotDelete         = "admin.slot_delete"
+	AuditOrderStatus        = "admin.order_status"
+	AuditProductStock       = "admin.product_stock"
+)
+
+// audit outcomes
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// user roles
+const (
+	RoleCustomer = "customer"
+	RoleAdmin    = "admin"
+)
+
+// ErrInvalidRole unknown role
+var ErrInvalidRole = errors.New("role is invalid")
+
+// AuditEvent struct
+type AuditEvent struct {
+	ID         int64     `json:"id"`
+	OccurredAt time.Time `json:"occurredAt"`
+	Actor      string    `json:"actor"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"userAgent"`
+	Action     string    `json:"action"`
+	Target     string    `json:"target"`
+	Outcome    string    `json:"outcome"`
+	Details    string    `json:"details,omitempty"`
+}
+
+// AuditFilter struct, empty fields are not filtered by
+type AuditFilter struct {
+	Actor   string
+	Action  string
+	Target  string
+	Outcome string
+	From    *time.Time
+	To      *time.Time
+	Limit   int
+	Offset  int
+}
+
+// AuditEvents page of audit events
+type AuditEvents struct {
+	Events []AuditEvent `json:"events"`
+	Total  int          `json:"total"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+}
+
+// RoleUpdate struct
+type RoleUpdate struct {
+	Role string `json:"role"`
+}
@@ -2,6 +2,7 @@ package entity
 
 import (
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -10,6 +11,10 @@ import (
 var (
 	ErrUserNotFound     = errors.New("user not found")
 	ErrUserAlreadyExist = errors.New("user with current name is already exist")
+	ErrSessionNotFound  = errors.New("session not found")
+
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrTooManyAttempts    = errors.New("too many sign in attempts, try again later")
 )
 
 // Credentials struct
@@ -18,6 +23,7 @@ type Credentials struct {
 	Username       string    `json:"username"`
 	Password       string    `json:"password"`
 	PasswordRepeat string    `json:"passwordRepeat"`
+	TOTPEnabled    bool      `json:"-"`
 }
 
 // Tokens struct
@@ -25,3 +31,61 @@ type Tokens struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 }
+
+// GuestToken struct
+type GuestToken struct {
+	GuestToken string `json:"guest_token"`
+}
+
+// Client struct
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+// Session struct
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	Current    bool      `json:"current"`
+}
+
+// JWK struct
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS struct
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PasswordChange struct
+type PasswordChange struct {
+	CurrentPassword string `json:"currentPassword"`
+	Password        string `json:"password"`
+	PasswordRepeat  string `json:"passwordRepeat"`
+}
+
+// PasswordForgot struct
+type PasswordForgot struct {
+	Username string `json:"username"`
+}
+
+// PasswordReset struct
+type PasswordReset struct {
+	Token          string `json:"token"`
+	Password       string `json:"password"`
+	PasswordRepeat string `json:"passwordRepeat"`
+}
@@ -1,29 +1,79 @@
 package entity
 
 import (
+	"errors"
+
 	"github.com/google/uuid"
 )
 
+// cart errors
+var (
+	ErrCartVersionMismatch = errors.New("cart version mismatch")
+	ErrProductNotFound     = errors.New("product not found")
+	ErrInvalidAmount       = errors.New("amount must not be negative")
+	ErrInvalidQuantity     = errors.New("amount must be at least the minimum and a multiple of the step of product")
+	ErrInvalidPatch        = errors.New("either delta or amount must be set")
+	ErrDuplicateProduct    = errors.New("duplicate product in cart")
+)
+
 // UserProduct struct
 type UserProduct struct {
 	ProductUUID uuid.UUID `json:"id"`
 	UserID      uuid.UUID `json:"userId"`
-	Amount      int       `json:"amount"`
+	Amount      float64   `json:"amount"`
+}
+
+// CartProductPatch struct
+type CartProductPatch struct {
+	Delta  *float64 `json:"delta"`
+	Amount *float64 `json:"amount"`
 }
 
-// GetUserProduct struct
+// GetUserProduct struct, AddedPrice is set when price changed since product was added to the cart
 type GetUserProduct struct {
-	ProductUUID uuid.UUID `json:"id"`
-	Name        string    `json:"name"`
-	Price       float32   `json:"price"`
-	Amount      int       `json:"amount"`
+	ProductUUID  uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Price        float32   `json:"price"`
+	Currency     string    `json:"currency"`
+	Unit         string    `json:"unit,omitempty"`
+	Amount       float64   `json:"amount"`
+	PriceChanged bool      `json:"priceChanged,omitempty"`
+	AddedPrice   *float32  `json:"addedPrice,omitempty"`
+	Category     string    `json:"-"`
+}
+
+// SetAddedPrice flag product when its price differs from the price it was added to the cart with
+func (p *GetUserProduct) SetAddedPrice(addedPrice float32) {
+	if addedPrice == p.Price {
+		return
+	}
+	p.PriceChanged = true
+	p.AddedPrice = &addedPrice
+}
+
+// TaxLine tax of cart products of the same tax class
+type TaxLine struct {
+	Class string  `json:"class"`
+	Rate  float64 `json:"rate"`
+	Net   string  `json:"net"`
+	Tax   string  `json:"tax"`
 }
 
-// UserCart struct
+// UserCart struct, prices are in Currency, checkout settles BaseTotalPrice in BaseCurrency
+// which are set only when Currency is not the base currency. TotalPrice is the gross total.
 type UserCart struct {
 	CartProducts    []GetUserProduct `json:"products"`
 	TotalPrice      string           `json:"totalPrice"`
 	TotalSavings    string           `json:"totalSavings"`
 	Amount          string           `json:"totalAmount"`
+	TotalNet        string           `json:"totalNet"`
+	TotalTax        string           `json:"totalTax"`
+	TotalGross      string           `json:"totalGross"`
+	Taxes           []TaxLine        `json:"taxes"`
+	TaxRegion       string           `json:"taxRegion,omitempty"`
+	Currency        string           `json:"currency"`
+	BaseCurrency    string           `json:"baseCurrency,omitempty"`
+	BaseTotalPrice  string           `json:"baseTotalPrice,omitempty"`
+	Shipping        *Shipping        `json:"shipping,omitempty"`
 	IsDiscountAdded bool             `json:"isDiscountAdded"`
 }
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// category and tag errors
+var (
+	ErrCategoryNotFound     = errors.New("category not found")
+	ErrCategoryAlreadyExist = errors.New("category already exists")
+	ErrCategoryNotEmpty     = errors.New("category has subcategories")
+	ErrInvalidSlug          = errors.New("slug must be 1 to 50 lowercase letters, digits or dashes")
+	ErrInvalidCategoryName  = errors.New("category name must be 1 to 100 characters long")
+	ErrInvalidTag           = errors.New("tag must be 1 to 50 characters long")
+)
+
+// Category struct, path is slugs of ancestors and the category joined by '/'
+type Category struct {
+	ID        uuid.UUID  `json:"id"`
+	ParentID  *uuid.UUID `json:"parentId,omitempty"`
+	Slug      string     `json:"slug"`
+	Name      string     `json:"name"`
+	Path      string     `json:"path"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// CategoryCreate struct
+type CategoryCreate struct {
+	ParentID *uuid.UUID `json:"parentId"`
+	Slug     string     `json:"slug"`
+	Name     string     `json:"name"`
+}
+
+// ProductCategory struct, nil category removes product from its category
+type ProductCategory struct {
+	CategoryID *uuid.UUID `json:"categoryId"`
+}
+
+// ProductTags struct
+type ProductTags struct {
+	Tags []string `json:"tags"`
+}
@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// exchange rate errors
+var (
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+	ErrInvalidExchangeRate  = errors.New("rate must be positive")
+	ErrExchangeRateInUse    = errors.New("exchange rate is used by products")
+	ErrBaseCurrencyRate     = errors.New("rate of base currency can't be set")
+)
+
+// ExchangeRate units of currency per unit of base currency
+type ExchangeRate struct {
+	Currency  string    `json:"currency"`
+	Rate      float64   `json:"rate"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ExchangeRateUpdate struct
+type ExchangeRateUpdate struct {
+	Rate float64 `json:"rate"`
+}
+
+// ProductCurrency struct, null currency prices product in base currency
+type ProductCurrency struct {
+	Currency *string `json:"currency"`
+}
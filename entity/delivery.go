@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// delivery errors
+var (
+	ErrAddressNotFound     = errors.New("delivery address not found")
+	ErrInvalidAddress      = errors.New("line1, city, postal code and country of address must be set")
+	ErrInvalidMethod       = errors.New("shipping method must be courier or pickup")
+	ErrAddressRequired     = errors.New("courier delivery requires address")
+	ErrDeliveryNotSelected = errors.New("delivery is not selected")
+)
+
+// DeliveryAddress struct
+type DeliveryAddress struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"-"`
+	Address
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CartDelivery delivery of user cart, courier delivers to address, pickup has none.
+// SlotID is delivery slot reserved for cart.
+type CartDelivery struct {
+	Method    string           `json:"method"`
+	AddressID *uuid.UUID       `json:"addressId,omitempty"`
+	Address   *DeliveryAddress `json:"address,omitempty"`
+	SlotID    *uuid.UUID       `json:"slotId,omitempty"`
+}
+
+// Shipping shipping of cart, amounts are in currency of cart
+type Shipping struct {
+	Method     string     `json:"method"`
+	AddressID  *uuid.UUID `json:"addressId,omitempty"`
+	Zone       string     `json:"zone,omitempty"`
+	SlotID     *uuid.UUID `json:"slotId,omitempty"`
+	Cost       string     `json:"cost"`
+	LeftToFree string     `json:"leftToFreeShipping,omitempty"`
+}
@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// media errors
+var (
+	ErrMediaNotFound    = errors.New("media not found")
+	ErrMediaFileMissing = errors.New("multipart form must contain image in file field")
+	ErrMediaTooLarge    = errors.New("media file is too large")
+)
+
+// Media image of product, keys address the image and its thumbnail in media storage
+type Media struct {
+	ID           uuid.UUID `json:"id"`
+	ProductID    uuid.UUID `json:"-"`
+	Key          string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnailUrl"`
+	ContentType  string    `json:"contentType"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
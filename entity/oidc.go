@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// oidc errors
+var (
+	ErrIdentityAlreadyLinked = errors.New("identity provider account is already linked to another user")
+	ErrIdentityEmailMismatch = errors.New("verified email of identity provider account doesn't match email of user")
+	ErrIdentityNoUsername    = errors.New("identity provider didn't return a usable username")
+	ErrOIDCDisabled          = errors.New("oidc login is not configured")
+)
+
+// Identity user account at an external identity provider
+type Identity struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+}
+
+// OIDCState state of login which is pending at identity provider, user is set when signed in user links the identity
+type OIDCState struct {
+	Nonce        string     `json:"nonce"`
+	CodeVerifier string     `json:"codeVerifier"`
+	UserID       *uuid.UUID `json:"userId,omitempty"`
+}
+
+// OIDCLink authorization url which signed in user follows to link identity provider account
+type OIDCLink struct {
+	URL string `json:"url"`
+}
@@ -0,0 +1,86 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// order errors
+var (
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrInvalidOrderStatus  = errors.New("order status is invalid")
+	ErrInvalidTransition   = errors.New("order can't move to this status from its current one")
+	ErrOrderNotCancellable = errors.New("order can't be cancelled after it is packed")
+	ErrEmptyCart           = errors.New("cart is empty")
+	ErrOutOfStock          = errors.New("product is out of stock")
+	ErrInvalidStock        = errors.New("stock can't be negative")
+)
+
+// Order order placed by payment of user cart, amounts are in base currency. Subtotal is the gross total of products
+// after discounts with Tax included in it, Total adds Shipping to it and Paid is the amount captured from the user.
+type Order struct {
+	ID        uuid.UUID           `json:"id"`
+	UserID    *uuid.UUID          `json:"userId,omitempty"`
+	Status    string              `json:"status"`
+	Currency  string              `json:"currency"`
+	Items     []OrderItem         `json:"items,omitempty"`
+	Subtotal  float64             `json:"subtotal"`
+	Tax       float64             `json:"tax"`
+	Shipping  float64             `json:"shipping"`
+	Total     float64             `json:"total"`
+	Paid      float64             `json:"paid"`
+	Method    string              `json:"method"`
+	AddressID *uuid.UUID          `json:"addressId,omitempty"`
+	SlotID    *uuid.UUID          `json:"slotId,omitempty"`
+	History   []OrderStatusChange `json:"history,omitempty"`
+	CreatedAt time.Time           `json:"createdAt"`
+	UpdatedAt time.Time           `json:"updatedAt"`
+}
+
+// OrderItem product of order with its price per unit before discounts, product is unset once it's deleted
+type OrderItem struct {
+	ProductID *uuid.UUID `json:"productId"`
+	Name      string     `json:"name"`
+	Price     float64    `json:"price"`
+	Amount    float64    `json:"amount"`
+}
+
+// OrderStatusChange entry of order history
+type OrderStatusChange struct {
+	Status    string    `json:"status"`
+	Note      string    `json:"note,omitempty"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// OrderStatusUpdate struct
+type OrderStatusUpdate struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+// OrderFilter struct, empty fields are not filtered by, zero limit returns all orders
+type OrderFilter struct {
+	UserID *uuid.UUID
+	Status string
+	Limit  int
+	Offset int
+}
+
+// OrderTransition move of order to status To from one of From statuses and Note recorded in its history.
+// Capture sets the paid amount to the order total and Void returns it,
+// Release returns stock of order items and its place in delivery slot.
+type OrderTransition struct {
+	From    []string
+	To      string
+	Note    string
+	Capture bool
+	Void    bool
+	Release bool
+}
+
+// ProductStock struct, nil stock isn't tracked
+type ProductStock struct {
+	Stock *float64 `json:"stock"`
+}
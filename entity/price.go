@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// price errors
+var (
+	ErrPriceNotFound         = errors.New("scheduled price not found")
+	ErrInvalidPrice          = errors.New("price must be set and must not be negative")
+	ErrPriceInPast           = errors.New("effective from must not be in the past")
+	ErrPriceAlreadyScheduled = errors.New("price of product is already scheduled at this time")
+)
+
+// ProductPrice price of product effective from the time until the next price of product
+type ProductPrice struct {
+	ID            uuid.UUID `json:"id"`
+	ProductID     uuid.UUID `json:"-"`
+	Price         float32   `json:"price"`
+	EffectiveFrom time.Time `json:"effectiveFrom"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// PriceSchedule struct, price is effective immediately when effectiveFrom is not set
+type PriceSchedule struct {
+	Price         *float32   `json:"price"`
+	EffectiveFrom *time.Time `json:"effectiveFrom"`
+}
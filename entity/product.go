@@ -1,15 +1,105 @@
 package entity
 
 import (
+	"math"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-// Product Product
+// product sort fields
+const (
+	ProductSortCreatedAt = "created_at"
+	ProductSortName      = "name"
+	ProductSortPrice     = "price"
+)
+
+// units of measure, price of product is per its unit
+const (
+	UnitPiece = "piece"
+	UnitKg    = "kg"
+	Unit100g  = "100g"
+	UnitBunch = "bunch"
+)
+
+// amounts are stored with 3 decimal places
+const (
+	amountScale     = 1000
+	amountPrecision = 1.0 / amountScale
+)
+
+// Product Product, amount of product in cart is at least MinAmount and a multiple of Step
 type Product struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	Price     float32   `json:"price"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Price      float32    `json:"price"`
+	Currency   string     `json:"currency"`
+	Unit       string     `json:"unit"`
+	MinAmount  float64    `json:"minAmount"`
+	Step       float64    `json:"step"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	CategoryID *uuid.UUID `json:"categoryId,omitempty"`
+	Category   string     `json:"category,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	Media      []Media    `json:"media,omitempty"`
+}
+
+// CheckAmount check that positive amount fits minimum and step of product
+func (p Product) CheckAmount(amount float64) error {
+	return CheckAmount(amount, p.MinAmount, p.Step)
+}
+
+// CheckAmount check that positive amount is at least minAmount and a multiple of step
+func CheckAmount(amount, minAmount, step float64) error {
+	if amount < 0 {
+		return ErrInvalidAmount
+	}
+	if amount == 0 || step <= 0 {
+		return nil
+	}
+	steps := amount / step
+	if amount < minAmount-amountPrecision/2 || math.Abs(steps-math.Round(steps))*step > amountPrecision/2 {
+		return ErrInvalidQuantity
+	}
+	return nil
+}
+
+// RoundAmount round amount to stored precision, so sums of decimal amounts stay exact
+func RoundAmount(amount float64) float64 {
+	return math.Round(amount*amountScale) / amountScale
+}
+
+// ProductQuery filter, sort and page of products, empty fields are not filtered by.
+// Category is a category path, products of its subcategories match as well. Price range is in base currency.
+type ProductQuery struct {
+	Search   string
+	Category string
+	Tag      string
+	MinPrice *float32
+	MaxPrice *float32
+	Sort     string
+	Desc     bool
+	Limit    int
+	After    *ProductCursor
+}
+
+// ProductCursor position of the last product of a page
+type ProductCursor struct {
+	Sort  string    `json:"s"`
+	Desc  bool      `json:"d,omitempty"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// ProductPage page of products
+type ProductPage struct {
+	Products []Product `json:"products"`
+	Paging   Paging    `json:"paging"`
+}
+
+// Paging metadata of a page, next cursor is empty on the last page
+type Paging struct {
+	Limit      int    `json:"limit"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"nextCursor,omitempty"`
 }
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAmount(t *testing.T) {
+	tc := []struct {
+		name     string
+		expected error
+		amount   float64
+	}{
+		{name: "Zero amount", amount: 0},
+		{name: "Minimum amount", amount: 0.5},
+		{name: "Multiple of step", amount: 1.3},
+		{name: "Sum of decimal steps", amount: 0.1 + 0.2 + 0.3},
+		{name: "Negative amount", amount: -0.1, expected: ErrInvalidAmount},
+		{name: "Below minimum", amount: 0.4, expected: ErrInvalidQuantity},
+		{name: "Out of steps", amount: 0.55, expected: ErrInvalidQuantity},
+	}
+
+	product := Product{Unit: UnitKg, MinAmount: 0.5, Step: 0.1}
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, product.CheckAmount(test.amount))
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// profile errors
+var (
+	ErrEmailAlreadyUsed = errors.New("email is already used by another user")
+	ErrInvalidEmail     = errors.New("email is invalid")
+	ErrFieldTooLong     = errors.New("profile field is too long")
+)
+
+// Address struct
+type Address struct {
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	PostalCode string `json:"postalCode"`
+	Country    string `json:"country"`
+}
+
+// Profile struct
+type Profile struct {
+	ID          uuid.UUID `json:"id"`
+	Username    string    `json:"username"`
+	Email       string    `json:"email"`
+	DisplayName string    `json:"displayName"`
+	Address     Address   `json:"address"`
+}
+
+// ProfileUpdate struct, fields which aren't set are kept
+type ProfileUpdate struct {
+	Email       *string  `json:"email"`
+	DisplayName *string  `json:"displayName"`
+	Address     *Address `json:"address"`
+}
+
+// AccountDelete struct, password is required for users who have one
+type AccountDelete struct {
+	Password string `json:"password"`
+}
+
+// AccountExport struct
+type AccountExport struct {
+	ExportedAt time.Time         `json:"exportedAt"`
+	Profile    Profile           `json:"profile"`
+	Cart       []GetUserProduct  `json:"cart"`
+	Addresses  []DeliveryAddress `json:"addresses"`
+	Orders     []Order           `json:"orders"`
+	Sessions   []Session         `json:"sessions"`
+}
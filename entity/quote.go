@@ -0,0 +1,7 @@
+package entity
+
+// QuoteRequest struct
+type QuoteRequest struct {
+	Products []UserProduct `json:"products"`
+	Coupon   string        `json:"coupon"`
+}
@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// delivery slot errors
+var (
+	ErrSlotNotFound    = errors.New("delivery slot not found")
+	ErrInvalidSlot     = errors.New("delivery slot must end after it starts and have positive capacity")
+	ErrSlotStarted     = errors.New("delivery slot already started")
+	ErrSlotFull        = errors.New("delivery slot is full")
+	ErrSlotHasBookings = errors.New("delivery slot has bookings")
+	ErrSlotNotReserved = errors.New("delivery slot is not reserved")
+	ErrSlotHoldExpired = errors.New("reservation of delivery slot expired")
+	ErrInvalidDate     = errors.New("date must be in format 2006-01-02")
+)
+
+// DeliverySlot time window of delivery, Booked places are confirmed by payment
+type DeliverySlot struct {
+	ID       uuid.UUID `json:"id"`
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+	Capacity int       `json:"capacity"`
+	Booked   int       `json:"booked"`
+}
+
+// AvailableSlot delivery slot with count of places which are neither booked nor held
+type AvailableSlot struct {
+	DeliverySlot
+	Available int `json:"available"`
+}
+
+// SlotReservation hold of delivery slot for user cart until ExpiresAt
+type SlotReservation struct {
+	SlotID    uuid.UUID `json:"slotId"`
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+}
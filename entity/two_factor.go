@@ -0,0 +1,44 @@
+package entity
+
+import "errors"
+
+// two-factor authentication errors
+var (
+	ErrTOTPDisabled       = errors.New("two-factor authentication is not configured")
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotSetUp       = errors.New("two-factor authentication is not set up")
+	ErrInvalidTOTPCode    = errors.New("invalid two-factor code")
+)
+
+// TOTP struct, secret is encrypted
+type TOTP struct {
+	Secret  string
+	Enabled bool
+}
+
+// TOTPSetup struct
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TOTPCode struct
+type TOTPCode struct {
+	Code string `json:"code"`
+}
+
+// RecoveryCodes struct
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// Challenge struct
+type Challenge struct {
+	ChallengeToken string `json:"challenge_token"`
+}
+
+// ChallengeVerify struct, code is either TOTP code or recovery code
+type ChallengeVerify struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+}
@@ -9,21 +9,33 @@ import (
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/urfave/negroni"
 
+	"github.com/mshto/fruit-store/authentication"
 	"github.com/mshto/fruit-store/cache"
 	"github.com/mshto/fruit-store/config"
 	"github.com/mshto/fruit-store/database"
+	"github.com/mshto/fruit-store/encryption"
 	"github.com/mshto/fruit-store/logger"
+	"github.com/mshto/fruit-store/media"
+	"github.com/mshto/fruit-store/notifier"
+	"github.com/mshto/fruit-store/oidc"
+	"github.com/mshto/fruit-store/password"
 	"github.com/mshto/fruit-store/repository"
 	"github.com/mshto/fruit-store/web"
+	"github.com/mshto/fruit-store/web/common/request"
 	"github.com/mshto/fruit-store/web/middleware"
 )
 
 var (
 	configPath      = "fruit_store_cfg.json"
 	salesConfigPath = "fruit_store_sales_cfg.json"
+	oidcTimeout     = 10 * time.Second
+	mediaTimeout    = 30 * time.Second
+	priceInterval   = time.Minute
 )
 
 func main() {
@@ -54,10 +66,48 @@ func main() {
 		log.Fatalf("failed to setup redis, error: %v", err)
 	}
 
+	keys, err := authentication.LoadKeySet(config.Auth)
+	if err != nil {
+		log.Fatalf("failed to load token signing keys, error: %v", err)
+	}
+
+	policy, err := password.New(config.Password)
+	if err != nil {
+		log.Fatalf("failed to setup password policy, error: %v", err)
+	}
+
+	ntf, err := notifier.New(config.Notifier, log)
+	if err != nil {
+		log.Fatalf("failed to setup notifier, error: %v", err)
+	}
+
+	var cph *encryption.Cipher
+	if config.Auth.TOTPEnabled {
+		cph, err = encryption.New(config.Auth.TOTPEncryptionKey)
+		if err != nil {
+			log.Fatalf("failed to setup totp encryption, error: %v", err)
+		}
+	}
+
+	provider := oidc.New(config.Auth.OIDC, &http.Client{Timeout: oidcTimeout})
+
+	storage, err := media.New(config.Media, &http.Client{Timeout: mediaTimeout})
+	if err != nil {
+		log.Fatalf("failed to setup media storage, error: %v", err)
+	}
+
+	proxies, err := request.NewTrustedProxies(config.Proxy.TrustedProxies)
+	if err != nil {
+		log.Fatalf("failed to parse trusted proxies, error: %v", err)
+	}
+
 	repo := repository.New(db)
 
-	router := web.New(config, log, repo, redis)
-	serverMiddleware := setWebServerMiddleware()
+	wg.Add(1)
+	go applyScheduledPrices(ctx, wg, log, repo.Prices)
+
+	router := web.New(config, log, repo, redis, keys, policy, ntf, cph, provider, storage)
+	serverMiddleware := setWebServerMiddleware(proxies)
 	serverMiddleware.UseHandler(router)
 
 	server := &http.Server{
@@ -85,10 +135,34 @@ func main() {
 	log.Infof("graceful shutdown: %v, %v", err, e)
 }
 
+// applyScheduledPrices apply due scheduled prices of products every priceInterval until context is cancelled
+func applyScheduledPrices(ctx context.Context, wg *sync.WaitGroup, log *logrus.Logger, prices repository.Prices) {
+	defer wg.Done()
+
+	ticker := time.NewTicker(priceInterval)
+	defer ticker.Stop()
+
+	for {
+		applied, err := prices.ApplyScheduledPrices()
+		if err != nil {
+			log.Errorf("failed to apply scheduled prices, error: %v", err)
+		} else if applied > 0 {
+			log.Infof("applied scheduled prices, products: %v", applied)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 // move to middleware
-func setWebServerMiddleware() *negroni.Negroni {
+func setWebServerMiddleware(proxies request.TrustedProxies) *negroni.Negroni {
 	middlewareManager := negroni.New()
 	middlewareManager.Use(negroni.NewRecovery())
+	middlewareManager.Use(middleware.NewRealIPMiddleware(proxies))
 	middlewareManager.Use(middleware.NewWithCORSMiddleware())
 
 	return middlewareManager
@@ -0,0 +1,137 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// supported image content types
+const (
+	ContentTypeJPEG = "image/jpeg"
+	ContentTypePNG  = "image/png"
+)
+
+// maxPixels images with more pixels are rejected before they are decoded
+const maxPixels = 50 * 1000 * 1000
+
+const jpegQuality = 85
+
+// image errors
+var (
+	ErrUnsupportedType = errors.New("media must be a JPEG or PNG image")
+	ErrInvalidImage    = errors.New("image can't be decoded or is too large")
+)
+
+// Image decoded image with its encoded form
+type Image struct {
+	ContentType string
+	Data        []byte
+	Width       int
+	Height      int
+	img         image.Image
+}
+
+// Decode sniff content type of data and decode it, only JPEG and PNG images are accepted
+func Decode(data []byte) (*Image, error) {
+	contentType := http.DetectContentType(data)
+	if contentType != ContentTypeJPEG && contentType != ContentTypePNG {
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrInvalidImage
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	return &Image{
+		ContentType: contentType,
+		Data:        data,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		img:         img,
+	}, nil
+}
+
+// Extension file extension of image
+func (i *Image) Extension() string {
+	if i.ContentType == ContentTypePNG {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// Thumbnail scale image down to fit size x size keeping aspect ratio and encode it in format of image,
+// smaller images are not enlarged
+func (i *Image) Thumbnail(size int) (*Image, error) {
+	thumb := resize(i.img, size)
+
+	var buf bytes.Buffer
+	var err error
+	if i.ContentType == ContentTypePNG {
+		err = png.Encode(&buf, thumb)
+	} else {
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := thumb.Bounds()
+	return &Image{
+		ContentType: i.ContentType,
+		Data:        buf.Bytes(),
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		img:         thumb,
+	}, nil
+}
+
+// resize scale image down with box filter, every target pixel is average of source pixels it covers
+func resize(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return img
+	}
+
+	targetWidth, targetHeight := size, size
+	if width > height {
+		targetHeight = max(1, height*size/width)
+	} else {
+		targetWidth = max(1, width*size/height)
+	}
+
+	dst := image.NewRGBA64(image.Rect(0, 0, targetWidth, targetHeight))
+	for y := 0; y < targetHeight; y++ {
+		y0, y1 := bounds.Min.Y+y*height/targetHeight, bounds.Min.Y+(y+1)*height/targetHeight
+		for x := 0; x < targetWidth; x++ {
+			x0, x1 := bounds.Min.X+x*width/targetWidth, bounds.Min.X+(x+1)*width/targetWidth
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,143 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// encodeImage encode image of size filled with left red and right blue halves
+func encodeImage(t *testing.T, contentType string, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 255, A: 255})
+			if x >= width/2 {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if contentType == ContentTypePNG {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatalf("failed to encode image, error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	type expected struct {
+		contentType string
+		extension   string
+		err         error
+	}
+
+	tc := []struct {
+		name     string
+		expected expected
+		data     []byte
+	}{
+		{
+			name:     "Decode jpeg with success",
+			expected: expected{contentType: ContentTypeJPEG, extension: ".jpg"},
+			data:     encodeImage(t, ContentTypeJPEG, 40, 20),
+		},
+		{
+			name:     "Decode png with success",
+			expected: expected{contentType: ContentTypePNG, extension: ".png"},
+			data:     encodeImage(t, ContentTypePNG, 40, 20),
+		},
+		{
+			name:     "Decode gif",
+			expected: expected{err: ErrUnsupportedType},
+			data:     []byte("GIF89a\x01\x00\x01\x00"),
+		},
+		{
+			name:     "Decode text",
+			expected: expected{err: ErrUnsupportedType},
+			data:     []byte("<html></html>"),
+		},
+		{
+			name:     "Decode truncated png",
+			expected: expected{err: ErrInvalidImage},
+			data:     encodeImage(t, ContentTypePNG, 40, 20)[:60],
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			img, err := Decode(test.data)
+			assert.Equal(t, test.expected.err, err)
+			if test.expected.err != nil {
+				return
+			}
+			assert.Equal(t, test.expected.contentType, img.ContentType)
+			assert.Equal(t, test.expected.extension, img.Extension())
+			assert.Equal(t, 40, img.Width)
+			assert.Equal(t, 20, img.Height)
+		})
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	tc := []struct {
+		name          string
+		contentType   string
+		width, height int
+		size          int
+		thumbWidth    int
+		thumbHeight   int
+	}{
+		{name: "Thumbnail of wide png", contentType: ContentTypePNG, width: 400, height: 100, size: 64, thumbWidth: 64, thumbHeight: 16},
+		{name: "Thumbnail of tall jpeg", contentType: ContentTypeJPEG, width: 90, height: 300, size: 30, thumbWidth: 9, thumbHeight: 30},
+		{name: "Thumbnail of small png", contentType: ContentTypePNG, width: 20, height: 10, size: 64, thumbWidth: 20, thumbHeight: 10},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			img, err := Decode(encodeImage(t, test.contentType, test.width, test.height))
+			assert.Nil(t, err)
+
+			thumb, err := img.Thumbnail(test.size)
+			assert.Nil(t, err)
+			assert.Equal(t, test.contentType, thumb.ContentType)
+			assert.Equal(t, test.thumbWidth, thumb.Width)
+			assert.Equal(t, test.thumbHeight, thumb.Height)
+
+			decoded, err := Decode(thumb.Data)
+			assert.Nil(t, err)
+			assert.Equal(t, test.thumbWidth, decoded.Width)
+			assert.Equal(t, test.thumbHeight, decoded.Height)
+		})
+	}
+}
+
+func TestResize(t *testing.T) {
+	img, err := Decode(encodeImage(t, ContentTypePNG, 8, 4))
+	assert.Nil(t, err)
+
+	thumb := resize(img.img, 2)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), thumb.Bounds())
+
+	r, _, b, a := thumb.At(0, 0).RGBA()
+	assert.Equal(t, []uint32{0xffff, 0, 0xffff}, []uint32{r, b, a})
+	r, _, b, _ = thumb.At(1, 0).RGBA()
+	assert.Equal(t, []uint32{0, 0xffff}, []uint32{r, b})
+}
@@ -0,0 +1,50 @@
+package media
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// NewLocal init storage which keeps files in directory, they are served under baseURL
+func NewLocal(root, baseURL string) Storage {
+	return &localImpl{
+		root:    root,
+		baseURL: baseURL,
+	}
+}
+
+type localImpl struct {
+	root    string
+	baseURL string
+}
+
+// Put write file, missing directories are created
+func (li *localImpl) Put(key, contentType string, data []byte) error {
+	name := li.name(key)
+	err := os.MkdirAll(filepath.Dir(name), 0750)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, data, 0640)
+}
+
+// Delete remove file, missing file is not an error
+func (li *localImpl) Delete(key string) error {
+	err := os.Remove(li.name(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// URL url of file
+func (li *localImpl) URL(key string) string {
+	return li.baseURL + "/" + key
+}
+
+// name file name of key, key can't point outside of root
+func (li *localImpl) name(key string) string {
+	return filepath.Join(li.root, filepath.FromSlash(path.Clean("/"+key)))
+}
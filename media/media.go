@@ -0,0 +1,116 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+//go:generate mockgen -destination=mock/storage.go -package=mediamock github.com/mshto/fruit-store/media Storage
+
+// storage types
+const (
+	TypeLocal = "local"
+	TypeS3    = "s3"
+)
+
+// LocalURL path files of local storage are served under, unless base url is configured
+const LocalURL = "/media"
+
+// defaults of media configuration
+const (
+	defaultMaxSizeKB     = 5 * 1024
+	defaultThumbnailSize = 256
+)
+
+// media errors
+var (
+	ErrEmptyPath   = errors.New("path of local media storage is empty")
+	ErrEmptyBucket = errors.New("bucket of s3 media storage is empty")
+)
+
+// Media struct stores media configuration, files are stored locally by default
+type Media struct {
+	Storage       string `json:"Storage"        envconfig:"MEDIA_STORAGE"   validate:"omitempty,oneof=local s3"`
+	Path          string `json:"Path"           envconfig:"MEDIA_PATH"`
+	BaseURL       string `json:"BaseURL"        envconfig:"MEDIA_BASE_URL"`
+	MaxSizeKB     int    `json:"MaxSizeKB"`
+	ThumbnailSize int    `json:"ThumbnailSize"`
+	S3            S3     `json:"S3"`
+}
+
+// S3 struct stores settings of S3 compatible storage, objects are addressed path style
+type S3 struct {
+	Endpoint        string `json:"Endpoint"         envconfig:"MEDIA_S3_ENDPOINT"`
+	Region          string `json:"Region"           envconfig:"MEDIA_S3_REGION"`
+	Bucket          string `json:"Bucket"           envconfig:"MEDIA_S3_BUCKET"`
+	AccessKeyID     string `json:"AccessKeyID"      envconfig:"MEDIA_S3_ACCESS_KEY_ID"`
+	SecretAccessKey string `json:"SecretAccessKey"  envconfig:"MEDIA_S3_SECRET_ACCESS_KEY"`
+}
+
+// MaxSize max size of uploaded file in bytes
+func (m Media) MaxSize() int64 {
+	if m.MaxSizeKB <= 0 {
+		return defaultMaxSizeKB * 1024
+	}
+	return int64(m.MaxSizeKB) * 1024
+}
+
+// Thumbnail max width and height of thumbnail in pixels
+func (m Media) Thumbnail() int {
+	if m.ThumbnailSize <= 0 {
+		return defaultThumbnailSize
+	}
+	return m.ThumbnailSize
+}
+
+// Storage interface, objects are addressed by slash separated keys
+type Storage interface {
+	Put(key, contentType string, data []byte) error
+	Delete(key string) error
+	URL(key string) string
+}
+
+// New init media storage
+func New(cfg Media, client *http.Client) (Storage, error) {
+	switch cfg.Storage {
+	case "", TypeLocal:
+		if cfg.Path == "" {
+			return nil, ErrEmptyPath
+		}
+		return NewLocal(cfg.Path, baseURL(cfg.BaseURL, LocalURL)), nil
+	case TypeS3:
+		if cfg.S3.Bucket == "" {
+			return nil, ErrEmptyBucket
+		}
+		return NewS3(cfg.S3, cfg.BaseURL, client), nil
+	}
+	return nil, fmt.Errorf("unknown media storage: %v", cfg.Storage)
+}
+
+// FileServer serve files of local storage under LocalURL, nil is returned for other storages
+func FileServer(cfg Media) http.Handler {
+	if (cfg.Storage != "" && cfg.Storage != TypeLocal) || cfg.Path == "" {
+		return nil
+	}
+	return http.StripPrefix(LocalURL+"/", noListing(http.FileServer(http.Dir(cfg.Path))))
+}
+
+// noListing hide directory listings of file server
+func noListing(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "" || strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func baseURL(url, fallback string) string {
+	if url == "" {
+		return fallback
+	}
+	return strings.TrimSuffix(url, "/")
+}
@@ -0,0 +1,133 @@
+package media
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	tc := []struct {
+		name  string
+		cfg   Media
+		isErr bool
+	}{
+		{
+			name: "New default storage with success",
+			cfg:  Media{Path: "media"},
+		},
+		{
+			name: "New s3 storage with success",
+			cfg:  Media{Storage: TypeS3, S3: S3{Endpoint: "http://localhost:9000", Bucket: "fruit-store"}},
+		},
+		{
+			name:  "New local storage without path with failed",
+			cfg:   Media{Storage: TypeLocal},
+			isErr: true,
+		},
+		{
+			name:  "New s3 storage without bucket with failed",
+			cfg:   Media{Storage: TypeS3},
+			isErr: true,
+		},
+		{
+			name:  "New unknown storage with failed",
+			cfg:   Media{Storage: "ftp"},
+			isErr: true,
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			storage, err := New(test.cfg, http.DefaultClient)
+			if test.isErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.NotNil(t, storage)
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	assert.Equal(t, int64(5*1024*1024), Media{}.MaxSize())
+	assert.Equal(t, int64(100*1024), Media{MaxSizeKB: 100}.MaxSize())
+	assert.Equal(t, 256, Media{}.Thumbnail())
+	assert.Equal(t, 64, Media{ThumbnailSize: 64}.Thumbnail())
+}
+
+func TestLocal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "media")
+	if err != nil {
+		t.Fatal("failed to create temp dir")
+	}
+	defer os.RemoveAll(dir)
+
+	storage, err := New(Media{Path: dir}, nil)
+	assert.Nil(t, err)
+
+	err = storage.Put("products/apple.jpg", ContentTypeJPEG, []byte("apple"))
+	assert.Nil(t, err)
+	content, err := ioutil.ReadFile(filepath.Join(dir, "products", "apple.jpg"))
+	assert.Nil(t, err)
+	assert.Equal(t, "apple", string(content))
+	assert.Equal(t, "/media/products/apple.jpg", storage.URL("products/apple.jpg"))
+
+	// keys can't escape root directory
+	err = storage.Put("../../pear.jpg", ContentTypeJPEG, []byte("pear"))
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "pear.jpg"))
+	assert.Nil(t, err)
+
+	err = storage.Delete("products/apple.jpg")
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "products", "apple.jpg"))
+	assert.True(t, os.IsNotExist(err))
+	assert.Nil(t, storage.Delete("products/apple.jpg"))
+
+	assert.Equal(t, "https://cdn.example.com/products/apple.jpg",
+		NewLocal(dir, "https://cdn.example.com").URL("products/apple.jpg"))
+}
+
+func TestFileServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "media")
+	if err != nil {
+		t.Fatal("failed to create temp dir")
+	}
+	defer os.RemoveAll(dir)
+
+	assert.Nil(t, FileServer(Media{Storage: TypeS3, Path: dir}))
+
+	err = NewLocal(dir, LocalURL).Put("products/apple.jpg", ContentTypeJPEG, []byte("apple"))
+	assert.Nil(t, err)
+	server := FileServer(Media{Path: dir})
+
+	tc := []struct {
+		name string
+		url  string
+		code int
+	}{
+		{name: "Serve file with success", url: "/media/products/apple.jpg", code: http.StatusOK},
+		{name: "Serve missing file", url: "/media/products/pear.jpg", code: http.StatusNotFound},
+		{name: "Serve directory listing", url: "/media/products/", code: http.StatusNotFound},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, test.url, nil)
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, req)
+			assert.Equal(t, test.code, rw.Code)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package media
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// signature version 4 constants
+const (
+	signAlgorithm = "AWS4-HMAC-SHA256"
+	signService   = "s3"
+	amzDateFormat = "20060102T150405Z"
+	defaultRegion = "us-east-1"
+)
+
+// NewS3 init storage which keeps files in bucket of S3 compatible service,
+// files are served under baseURL when it is set and from the bucket otherwise
+func NewS3(cfg S3, baseURL string, client *http.Client) Storage {
+	if cfg.Region == "" {
+		cfg.Region = defaultRegion
+	}
+	bucketURL := strings.TrimSuffix(cfg.Endpoint, "/") + "/" + cfg.Bucket
+	return &s3Impl{
+		cfg:       cfg,
+		bucketURL: bucketURL,
+		baseURL:   baseURL,
+		client:    client,
+		now:       time.Now,
+	}
+}
+
+type s3Impl struct {
+	cfg       S3
+	bucketURL string
+	baseURL   string
+	client    *http.Client
+	now       func() time.Time
+}
+
+// Put upload object
+func (si *s3Impl) Put(key, contentType string, data []byte) error {
+	return si.do(http.MethodPut, key, contentType, data)
+}
+
+// Delete delete object, missing object is not an error
+func (si *s3Impl) Delete(key string) error {
+	return si.do(http.MethodDelete, key, "", nil)
+}
+
+// URL url of object
+func (si *s3Impl) URL(key string) string {
+	return baseURL(si.baseURL, si.bucketURL) + "/" + uriEncode(key)
+}
+
+// do send signed request for object
+func (si *s3Impl) do(method, key, contentType string, data []byte) error {
+	req, err := http.NewRequest(method, si.bucketURL+"/"+uriEncode(key), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	si.sign(req, data)
+
+	resp, err := si.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body) // nolint
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("s3 %v of %v failed with status: %v", method, key, resp.StatusCode)
+	}
+	return nil
+}
+
+// sign add signature version 4 authorization to request
+func (si *s3Impl) sign(req *http.Request, payload []byte) {
+	now := si.now().UTC()
+	amzDate := now.Format(amzDateFormat)
+	payloadHash := hashHex(payload)
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+
+	var headers strings.Builder
+	for _, name := range names {
+		headers.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		headers.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{now.Format("20060102"), si.cfg.Region, signService, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{signAlgorithm, amzDate, scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := []byte("AWS4" + si.cfg.SecretAccessKey)
+	for _, part := range strings.Split(scope, "/") {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Del("Host")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm, si.cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data)) // nolint
+	return mac.Sum(nil)
+}
+
+// uriEncode percent-encode everything but unreserved characters and slashes
+func uriEncode(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,42 @@
+package notifier
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// NewFile init notifier which appends messages to file as JSON lines
+func NewFile(path string) Sender {
+	return &fileImpl{
+		path: path,
+	}
+}
+
+type fileImpl struct {
+	mu   sync.Mutex
+	path string
+}
+
+// Send appends message to file
+func (fi *fileImpl) Send(msg Message) error {
+	serialized, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	file, err := os.OpenFile(fi.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(append(serialized, '\n'))
+	if err != nil {
+		_ = file.Close() // nolint
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,22 @@
+package notifier
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// NewLog init notifier which writes messages to log, for development only
+func NewLog(log *logrus.Logger) Sender {
+	return &logImpl{
+		log: log,
+	}
+}
+
+type logImpl struct {
+	log *logrus.Logger
+}
+
+// Send writes message to log
+func (li *logImpl) Send(msg Message) error {
+	li.log.Infof("notification, to: %v, subject: %v, body: %v", msg.To, msg.Subject, msg.Body)
+	return nil
+}
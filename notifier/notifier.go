@@ -0,0 +1,51 @@
+package notifier
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+//go:generate mockgen -destination=mock/notifier.go -package=notifiermock github.com/mshto/fruit-store/notifier Sender
+
+// notifier types
+const (
+	TypeLog  = "log"
+	TypeFile = "file"
+)
+
+// ErrEmptyPath file notifier requires a path
+var ErrEmptyPath = errors.New("path of file notifier is empty")
+
+// Notifier struct stores notifier configuration
+type Notifier struct {
+	Type string `json:"Type"  envconfig:"NOTIFIER_TYPE"  validate:"omitempty,oneof=log file"`
+	Path string `json:"Path"  envconfig:"NOTIFIER_PATH"`
+}
+
+// Message message delivered to user
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Sender interface
+type Sender interface {
+	Send(msg Message) error
+}
+
+// New init notifier, messages are logged by default
+func New(cfg Notifier, log *logrus.Logger) (Sender, error) {
+	switch cfg.Type {
+	case "", TypeLog:
+		return NewLog(log), nil
+	case TypeFile:
+		if cfg.Path == "" {
+			return nil, ErrEmptyPath
+		}
+		return NewFile(cfg.Path), nil
+	}
+	return nil, fmt.Errorf("unknown notifier type: %v", cfg.Type)
+}
@@ -0,0 +1,93 @@
+package notifier
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	tc := []struct {
+		name  string
+		cfg   Notifier
+		isErr bool
+	}{
+		{
+			name: "New default notifier with success",
+			cfg:  Notifier{},
+		},
+		{
+			name: "New log notifier with success",
+			cfg:  Notifier{Type: TypeLog},
+		},
+		{
+			name: "New file notifier with success",
+			cfg:  Notifier{Type: TypeFile, Path: "notifications.log"},
+		},
+		{
+			name:  "New file notifier without path with failed",
+			cfg:   Notifier{Type: TypeFile},
+			isErr: true,
+		},
+		{
+			name:  "New unknown notifier with failed",
+			cfg:   Notifier{Type: "smtp"},
+			isErr: true,
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			logger, _ := loggermock.NewNullLogger()
+			sender, err := New(test.cfg, logger)
+			if test.isErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.NotNil(t, sender)
+		})
+	}
+}
+
+func TestLogSend(t *testing.T) {
+	logger, hook := loggermock.NewNullLogger()
+
+	err := NewLog(logger).Send(Message{To: "user", Subject: "subject", Body: "body"})
+	assert.Nil(t, err)
+	assert.Equal(t, logrus.InfoLevel, hook.LastEntry().Level)
+	assert.Equal(t, "notification, to: user, subject: subject, body: body", hook.LastEntry().Message)
+}
+
+func TestFileSend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "notifier")
+	if err != nil {
+		t.Fatal("failed to create temp dir")
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "notifications.log")
+	sender := NewFile(path)
+
+	err = sender.Send(Message{To: "first", Subject: "subject", Body: "body"})
+	assert.Nil(t, err)
+	err = sender.Send(Message{To: "second", Subject: "subject", Body: "body"})
+	assert.Nil(t, err)
+
+	content, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"to":"first","subject":"subject","body":"body"}
+{"to":"second","subject":"subject","body":"body"}
+`, string(content))
+
+	err = NewFile(filepath.Join(dir, "missing", "notifications.log")).Send(Message{})
+	assert.NotNil(t, err)
+}
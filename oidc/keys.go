@@ -0,0 +1,61 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+// parseJWKS parse RSA and EC keys by key id, keys of other types are skipped
+func parseJWKS(jwks entity.JWKS) map[string]interface{} {
+	keys := map[string]interface{}{}
+
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		switch jwk.Kty {
+		case "RSA":
+			n, errN := decodeInt(jwk.N)
+			e, errE := decodeInt(jwk.E)
+			if errN != nil || errE != nil || !e.IsInt64() {
+				continue
+			}
+			keys[jwk.Kid] = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		case "EC":
+			curve := ellipticCurve(jwk.Crv)
+			x, errX := decodeInt(jwk.X)
+			y, errY := decodeInt(jwk.Y)
+			if curve == nil || errX != nil || errY != nil || !curve.IsOnCurve(x, y) {
+				continue
+			}
+			keys[jwk.Kid] = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		}
+	}
+	return keys
+}
+
+func ellipticCurve(crv string) elliptic.Curve {
+	switch crv {
+	case "P-256":
+		return elliptic.P256()
+	case "P-384":
+		return elliptic.P384()
+	case "P-521":
+		return elliptic.P521()
+	}
+	return nil
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,274 @@
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+)
+
+//go:generate mockgen -destination=mock/oidc.go -package=oidcmock github.com/mshto/fruit-store/oidc Provider
+
+// oidc errors
+var (
+	ErrInvalidIDToken = errors.New("id token is invalid")
+	ErrNonceMismatch  = errors.New("id token nonce doesn't match")
+	ErrUnknownKey     = errors.New("id token is signed with unknown key")
+)
+
+const discoveryPath = "/.well-known/openid-configuration"
+
+var defaultScopes = []string{"openid", "email", "profile"}
+
+// Provider OpenID Connect identity provider, authorization code flow with PKCE
+type Provider interface {
+	AuthCodeURL(state, nonce, codeChallenge string) (string, error)
+	Exchange(code, codeVerifier, nonce string) (*entity.Identity, error)
+}
+
+// New generate a new provider, nil is returned when issuer isn't configured.
+// Provider metadata is discovered on first use, so provider doesn't have to be available on start.
+func New(cfg config.OIDC, client *http.Client) Provider {
+	if cfg.IssuerURL == "" {
+		return nil
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &providerImpl{
+		cfg:    cfg,
+		client: client,
+	}
+}
+
+type providerImpl struct {
+	cfg    config.OIDC
+	client *http.Client
+
+	mu       sync.Mutex
+	metadata *metadata
+	keys     map[string]interface{}
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// AuthCodeURL url of authorization endpoint which user is redirected to
+func (pi *providerImpl) AuthCodeURL(state, nonce, codeChallenge string) (string, error) {
+	md, err := pi.discover()
+	if err != nil {
+		return "", err
+	}
+
+	scopes := pi.cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultScopes
+	}
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {pi.cfg.ClientID},
+		"redirect_uri":          {pi.cfg.RedirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(md.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return md.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Exchange exchange authorization code for id token and return verified identity of user
+func (pi *providerImpl) Exchange(code, codeVerifier, nonce string) (*entity.Identity, error) {
+	md, err := pi.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {pi.cfg.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	if pi.cfg.ClientSecret == "" {
+		form.Set("client_id", pi.cfg.ClientID)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, md.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if pi.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(pi.cfg.ClientID), url.QueryEscape(pi.cfg.ClientSecret))
+	}
+
+	tokens := tokenResponse{}
+	status, err := pi.do(req, &tokens)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || tokens.Error != "" {
+		return nil, fmt.Errorf("failed to exchange code, status: %v, error: %v %v", status, tokens.Error, tokens.ErrorDescription)
+	}
+
+	return pi.verify(md, tokens.IDToken, nonce)
+}
+
+// verify check signature, issuer, audience, expiration and nonce of id token
+func (pi *providerImpl) verify(md *metadata, idToken, nonce string) (*entity.Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		default:
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return pi.key(md, kid)
+	})
+	if err != nil {
+		if vErr, ok := err.(*jwt.ValidationError); ok && vErr.Inner == ErrUnknownKey {
+			return nil, ErrUnknownKey
+		}
+		return nil, fmt.Errorf("%v: %v", ErrInvalidIDToken, err)
+	}
+
+	if !claims.VerifyIssuer(md.Issuer, true) || !hasAudience(claims["aud"], pi.cfg.ClientID) {
+		return nil, ErrInvalidIDToken
+	}
+	if _, ok := claims["exp"]; !ok {
+		return nil, ErrInvalidIDToken
+	}
+	if claimNonce, _ := claims["nonce"].(string); claimNonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	identity := &entity.Identity{Issuer: md.Issuer}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.EmailVerified, _ = claims["email_verified"].(bool)
+	identity.PreferredUsername, _ = claims["preferred_username"].(string)
+	if identity.Subject == "" {
+		return nil, ErrInvalidIDToken
+	}
+	return identity, nil
+}
+
+// discover fetch provider metadata once, failed discovery is retried on next call
+func (pi *providerImpl) discover() (*metadata, error) {
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+
+	if pi.metadata != nil {
+		return pi.metadata, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(pi.cfg.IssuerURL, "/")+discoveryPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	md := &metadata{}
+	status, err := pi.do(req, md)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to discover provider, status: %v", status)
+	}
+	if md.Issuer != pi.cfg.IssuerURL {
+		return nil, fmt.Errorf("issuer %q doesn't match configured issuer %q", md.Issuer, pi.cfg.IssuerURL)
+	}
+
+	pi.metadata = md
+	return md, nil
+}
+
+// key get public key by id, keys are refetched when key is unknown so rotated keys are picked up
+func (pi *providerImpl) key(md *metadata, kid string) (interface{}, error) {
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+
+	if key, ok := pi.keys[kid]; ok {
+		return key, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, md.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	jwks := entity.JWKS{}
+	status, err := pi.do(req, &jwks)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to get provider keys, status: %v", status)
+	}
+
+	pi.keys = parseJWKS(jwks)
+	if key, ok := pi.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+func (pi *providerImpl) do(req *http.Request, body interface{}) (int, error) {
+	resp, err := pi.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if len(b) == 0 {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, json.Unmarshal(b, body)
+}
+
+func hasAudience(aud interface{}, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
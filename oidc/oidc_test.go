@@ -0,0 +1,209 @@
+package oidc
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/oidc/oidctest"
+)
+
+const redirectURL = "https://shop.example.com/fruit-store/v1/oidc/callback"
+
+func TestNewDisabled(t *testing.T) {
+	assert.Nil(t, New(config.OIDC{}, nil))
+}
+
+func TestCodeChallenge(t *testing.T) {
+	assert.Equal(t, "iMnq5o6zALKXGivsnlom_0F5_WYda32GHkxlV7mq7hQ", CodeChallenge("verifier"))
+
+	verifier, err := NewCodeVerifier()
+	assert.Nil(t, err)
+	assert.Len(t, verifier, 43)
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	fake, err := oidctest.NewProvider("client", "secret")
+	if err != nil {
+		t.Fatal("failed to start fake provider")
+	}
+	defer fake.Close()
+
+	provider := New(config.OIDC{IssuerURL: fake.Issuer(), ClientID: "client", RedirectURL: redirectURL}, fake.Client())
+
+	authURL, err := provider.AuthCodeURL("state", "nonce", "challenge")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(authURL, fake.URL+"/authorize?"))
+
+	u, _ := url.Parse(authURL)
+	assert.Equal(t, url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"client"},
+		"redirect_uri":          {redirectURL},
+		"scope":                 {"openid email profile"},
+		"state":                 {"state"},
+		"nonce":                 {"nonce"},
+		"code_challenge":        {"challenge"},
+		"code_challenge_method": {"S256"},
+	}, u.Query())
+
+	provider = New(config.OIDC{IssuerURL: fake.Issuer() + "/other", ClientID: "client"}, fake.Client())
+	_, err = provider.AuthCodeURL("state", "nonce", "challenge")
+	assert.NotNil(t, err)
+}
+
+func TestExchange(t *testing.T) {
+	type payload struct {
+		clientSecret string
+		claims       jwt.MapClaims
+		verifier     string
+		nonce        string
+	}
+	type expected struct {
+		identity *entity.Identity
+		isErr    bool
+		err      error
+	}
+
+	fake, err := oidctest.NewProvider("client", "secret")
+	if err != nil {
+		t.Fatal("failed to start fake provider")
+	}
+	defer fake.Close()
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Exchange with success",
+			payload: payload{
+				clientSecret: "secret",
+				claims:       jwt.MapClaims{"sub": "staff-1", "email": "jane@example.com", "email_verified": true, "preferred_username": "jane"},
+				verifier:     "verifier",
+				nonce:        "nonce",
+			},
+			expected: expected{
+				identity: &entity.Identity{Issuer: fake.Issuer(), Subject: "staff-1", Email: "jane@example.com", EmailVerified: true, PreferredUsername: "jane"},
+			},
+		},
+		{
+			name: "Exchange audience list with success",
+			payload: payload{
+				clientSecret: "secret",
+				claims:       jwt.MapClaims{"sub": "staff-1", "aud": []string{"other", "client"}},
+				verifier:     "verifier",
+				nonce:        "nonce",
+			},
+			expected: expected{
+				identity: &entity.Identity{Issuer: fake.Issuer(), Subject: "staff-1"},
+			},
+		},
+		{
+			name: "Exchange wrong code verifier with fail",
+			payload: payload{
+				clientSecret: "secret",
+				verifier:     "other",
+				nonce:        "nonce",
+			},
+			expected: expected{
+				isErr: true,
+			},
+		},
+		{
+			name: "Exchange wrong client secret with fail",
+			payload: payload{
+				clientSecret: "other",
+				verifier:     "verifier",
+				nonce:        "nonce",
+			},
+			expected: expected{
+				isErr: true,
+			},
+		},
+		{
+			name: "Exchange nonce mismatch with fail",
+			payload: payload{
+				clientSecret: "secret",
+				verifier:     "verifier",
+				nonce:        "other",
+			},
+			expected: expected{
+				err: ErrNonceMismatch,
+			},
+		},
+		{
+			name: "Exchange wrong audience with fail",
+			payload: payload{
+				clientSecret: "secret",
+				claims:       jwt.MapClaims{"aud": "other"},
+				verifier:     "verifier",
+				nonce:        "nonce",
+			},
+			expected: expected{
+				err: ErrInvalidIDToken,
+			},
+		},
+		{
+			name: "Exchange wrong issuer with fail",
+			payload: payload{
+				clientSecret: "secret",
+				claims:       jwt.MapClaims{"iss": "https://other.example.com"},
+				verifier:     "verifier",
+				nonce:        "nonce",
+			},
+			expected: expected{
+				err: ErrInvalidIDToken,
+			},
+		},
+		{
+			name: "Exchange expired id token with fail",
+			payload: payload{
+				clientSecret: "secret",
+				claims:       jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()},
+				verifier:     "verifier",
+				nonce:        "nonce",
+			},
+			expected: expected{
+				isErr: true,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			provider := New(config.OIDC{IssuerURL: fake.Issuer(), ClientID: "client", ClientSecret: test.payload.clientSecret,
+				RedirectURL: redirectURL}, fake.Client())
+
+			authURL, err := provider.AuthCodeURL("state", "nonce", CodeChallenge("verifier"))
+			assert.Nil(t, err)
+			code, state, err := fake.Authorize(authURL, test.payload.claims)
+			assert.Nil(t, err)
+			assert.Equal(t, "state", state)
+
+			identity, err := provider.Exchange(code, test.payload.verifier, test.payload.nonce)
+			assert.Equal(t, test.expected.identity, identity)
+			if test.expected.err != nil {
+				assert.Equal(t, test.expected.err, err)
+			}
+			if test.expected.isErr {
+				assert.NotNil(t, err)
+			}
+			if test.expected.identity != nil {
+				assert.Nil(t, err)
+			}
+
+			// code can be exchanged only once
+			_, err = provider.Exchange(code, test.payload.verifier, test.payload.nonce)
+			assert.NotNil(t, err)
+		})
+	}
+}
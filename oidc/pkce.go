@@ -0,0 +1,32 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// NewCodeVerifier generate PKCE code verifier
+func NewCodeVerifier() (string, error) {
+	return randomString()
+}
+
+// NewNonce generate nonce which binds id token to login
+func NewNonce() (string, error) {
+	return randomString()
+}
+
+// CodeChallenge S256 PKCE code challenge of verifier
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString() (string, error) {
+	raw := make([]byte, 32)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
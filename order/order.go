@@ -0,0 +1,79 @@
+package order
+
+import (
+	"github.com/mshto/fruit-store/entity"
+)
+
+// order statuses
+const (
+	StatusPendingPayment = "pending_payment"
+	StatusPaid           = "paid"
+	StatusPacked         = "packed"
+	StatusShipped        = "shipped"
+	StatusDelivered      = "delivered"
+	StatusCancelled      = "cancelled"
+	StatusRefunded       = "refunded"
+)
+
+// transitions statuses order moves to from its status, cancelled and refunded orders are final.
+// Orders are cancelled before they are packed, refunded after it.
+var transitions = map[string][]string{
+	StatusPendingPayment: {StatusPaid, StatusCancelled},
+	StatusPaid:           {StatusPacked, StatusCancelled},
+	StatusPacked:         {StatusShipped, StatusRefunded},
+	StatusShipped:        {StatusDelivered, StatusRefunded},
+	StatusDelivered:      {StatusRefunded},
+	StatusCancelled:      {},
+	StatusRefunded:       {},
+}
+
+// lifecycle statuses in order of lifecycle
+var lifecycle = []string{StatusPendingPayment, StatusPaid, StatusPacked, StatusShipped, StatusDelivered, StatusCancelled, StatusRefunded}
+
+// IsStatus whether status is a known order status
+func IsStatus(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// CanMove whether order of status can move to next status
+func CanMove(status, next string) bool {
+	for _, to := range transitions[status] {
+		if to == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCancellable whether order of status can still be cancelled
+func IsCancellable(status string) bool {
+	return CanMove(status, StatusCancelled)
+}
+
+// Transition transition of order to status with note recorded in its history.
+// Payment captures order total, cancellation voids it and releases stock and delivery slot, refund returns it.
+func Transition(status, note string) (entity.OrderTransition, error) {
+	if !IsStatus(status) {
+		return entity.OrderTransition{}, entity.ErrInvalidOrderStatus
+	}
+
+	from := []string{}
+	for _, source := range lifecycle {
+		if CanMove(source, status) {
+			from = append(from, source)
+		}
+	}
+	if len(from) == 0 {
+		return entity.OrderTransition{}, entity.ErrInvalidTransition
+	}
+
+	return entity.OrderTransition{
+		From:    from,
+		To:      status,
+		Note:    note,
+		Capture: status == StatusPaid,
+		Void:    status == StatusCancelled || status == StatusRefunded,
+		Release: status == StatusCancelled,
+	}, nil
+}
@@ -0,0 +1,98 @@
+package order
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+func TestCanMove(t *testing.T) {
+	tc := []struct {
+		name     string
+		status   string
+		next     string
+		expected bool
+	}{
+		{name: "Pay pending order", status: StatusPendingPayment, next: StatusPaid, expected: true},
+		{name: "Pack paid order", status: StatusPaid, next: StatusPacked, expected: true},
+		{name: "Ship packed order", status: StatusPacked, next: StatusShipped, expected: true},
+		{name: "Deliver shipped order", status: StatusShipped, next: StatusDelivered, expected: true},
+		{name: "Refund delivered order", status: StatusDelivered, next: StatusRefunded, expected: true},
+		{name: "Cancel paid order", status: StatusPaid, next: StatusCancelled, expected: true},
+		{name: "Cancel packed order", status: StatusPacked, next: StatusCancelled},
+		{name: "Skip packing", status: StatusPaid, next: StatusShipped},
+		{name: "Move back", status: StatusShipped, next: StatusPacked},
+		{name: "Reopen cancelled order", status: StatusCancelled, next: StatusPaid},
+		{name: "Move to unknown status", status: StatusPaid, next: "lost"},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, CanMove(test.status, test.next))
+		})
+	}
+}
+
+func TestIsCancellable(t *testing.T) {
+	assert.True(t, IsCancellable(StatusPendingPayment))
+	assert.True(t, IsCancellable(StatusPaid))
+	assert.False(t, IsCancellable(StatusPacked))
+	assert.False(t, IsCancellable(StatusCancelled))
+}
+
+func TestTransition(t *testing.T) {
+	tc := []struct {
+		name     string
+		status   string
+		expected entity.OrderTransition
+		err      error
+	}{
+		{
+			name:     "Transition to paid",
+			status:   StatusPaid,
+			expected: entity.OrderTransition{From: []string{StatusPendingPayment}, To: StatusPaid, Note: "note", Capture: true},
+		},
+		{
+			name:     "Transition to shipped",
+			status:   StatusShipped,
+			expected: entity.OrderTransition{From: []string{StatusPacked}, To: StatusShipped, Note: "note"},
+		},
+		{
+			name:     "Transition to cancelled",
+			status:   StatusCancelled,
+			expected: entity.OrderTransition{From: []string{StatusPendingPayment, StatusPaid}, To: StatusCancelled, Note: "note", Void: true, Release: true},
+		},
+		{
+			name:   "Transition to refunded",
+			status: StatusRefunded,
+			expected: entity.OrderTransition{From: []string{StatusPacked, StatusShipped, StatusDelivered}, To: StatusRefunded,
+				Note: "note", Void: true},
+		},
+		{
+			name:   "Transition to pending payment",
+			status: StatusPendingPayment,
+			err:    entity.ErrInvalidTransition,
+		},
+		{
+			name:   "Transition to unknown status",
+			status: "lost",
+			err:    entity.ErrInvalidOrderStatus,
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			transition, err := Transition(test.status, "note")
+			assert.Equal(t, test.err, err)
+			assert.Equal(t, test.expected, transition)
+		})
+	}
+}
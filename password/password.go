@@ -0,0 +1,113 @@
+package password
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:generate mockgen -destination=mock/password.go -package=passwordmock github.com/mshto/fruit-store/password Policy
+
+const defaultMinLength = 8
+
+// password policy errors
+var (
+	ErrTooShort  = errors.New("password is too short")
+	ErrNoUpper   = errors.New("password must contain an upper case letter")
+	ErrNoLower   = errors.New("password must contain a lower case letter")
+	ErrNoDigit   = errors.New("password must contain a digit")
+	ErrNoSymbol  = errors.New("password must contain a symbol")
+	ErrBreached  = errors.New("password is found in a list of breached passwords")
+	ErrSameAsOld = errors.New("new password must differ from the current one")
+)
+
+// Password struct stores password policy configuration
+type Password struct {
+	MinLength        int    `json:"MinLength"         envconfig:"PASSWORD_MIN_LENGTH"`
+	RequireUpper     bool   `json:"RequireUpper"      envconfig:"PASSWORD_REQUIRE_UPPER"`
+	RequireLower     bool   `json:"RequireLower"      envconfig:"PASSWORD_REQUIRE_LOWER"`
+	RequireDigit     bool   `json:"RequireDigit"      envconfig:"PASSWORD_REQUIRE_DIGIT"`
+	RequireSymbol    bool   `json:"RequireSymbol"     envconfig:"PASSWORD_REQUIRE_SYMBOL"`
+	BreachedListPath string `json:"BreachedListPath"  envconfig:"PASSWORD_BREACHED_LIST_PATH"`
+}
+
+// Policy interface
+type Policy interface {
+	Validate(password string) error
+}
+
+// New init password policy, breached passwords are read from file with one password per line
+func New(cfg Password) (Policy, error) {
+	if cfg.MinLength <= 0 {
+		cfg.MinLength = defaultMinLength
+	}
+
+	breached := map[string]struct{}{}
+	if cfg.BreachedListPath != "" {
+		file, err := os.Open(cfg.BreachedListPath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				breached[strings.ToLower(line)] = struct{}{}
+			}
+		}
+		if err = scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &policyImpl{
+		cfg:      cfg,
+		breached: breached,
+	}, nil
+}
+
+type policyImpl struct {
+	cfg      Password
+	breached map[string]struct{}
+}
+
+// Validate check password against policy
+func (pi *policyImpl) Validate(password string) error {
+	if utf8.RuneCountInString(password) < pi.cfg.MinLength {
+		return ErrTooShort
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	switch {
+	case pi.cfg.RequireUpper && !hasUpper:
+		return ErrNoUpper
+	case pi.cfg.RequireLower && !hasLower:
+		return ErrNoLower
+	case pi.cfg.RequireDigit && !hasDigit:
+		return ErrNoDigit
+	case pi.cfg.RequireSymbol && !hasSymbol:
+		return ErrNoSymbol
+	}
+
+	if _, ok := pi.breached[strings.ToLower(password)]; ok {
+		return ErrBreached
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package password
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "password")
+	if err != nil {
+		t.Fatal("failed to create temp dir")
+	}
+	defer os.RemoveAll(dir)
+
+	breachedPath := filepath.Join(dir, "breached.txt")
+	err = ioutil.WriteFile(breachedPath, []byte("Password1!\n\nqwerty123\n"), 0600)
+	if err != nil {
+		t.Fatal("failed to write breached list")
+	}
+
+	strict := Password{
+		MinLength:        10,
+		RequireUpper:     true,
+		RequireLower:     true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		BreachedListPath: breachedPath,
+	}
+
+	tc := []struct {
+		name     string
+		cfg      Password
+		password string
+		expected error
+	}{
+		{
+			name:     "Validate default policy with success",
+			cfg:      Password{},
+			password: "longenough",
+		},
+		{
+			name:     "Validate empty password with failed",
+			cfg:      Password{},
+			password: "",
+			expected: ErrTooShort,
+		},
+		{
+			name:     "Validate short multibyte password with failed",
+			cfg:      Password{},
+			password: "пароль",
+			expected: ErrTooShort,
+		},
+		{
+			name:     "Validate strict policy with success",
+			cfg:      strict,
+			password: "Fruit-Store-42",
+		},
+		{
+			name:     "Validate without upper with failed",
+			cfg:      strict,
+			password: "fruit-store-42",
+			expected: ErrNoUpper,
+		},
+		{
+			name:     "Validate without lower with failed",
+			cfg:      strict,
+			password: "FRUIT-STORE-42",
+			expected: ErrNoLower,
+		},
+		{
+			name:     "Validate without digit with failed",
+			cfg:      strict,
+			password: "Fruit-Store-xx",
+			expected: ErrNoDigit,
+		},
+		{
+			name:     "Validate without symbol with failed",
+			cfg:      strict,
+			password: "FruitStore42",
+			expected: ErrNoSymbol,
+		},
+		{
+			name:     "Validate breached password with failed",
+			cfg:      Password{BreachedListPath: breachedPath},
+			password: "QWERTY123",
+			expected: ErrBreached,
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		policy, err := New(test.cfg)
+		assert.Nil(t, err)
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, policy.Validate(test.password))
+		})
+	}
+}
+
+func TestNewMissingBreachedList(t *testing.T) {
+	_, err := New(Password{BreachedListPath: "missing.txt"})
+	assert.NotNil(t, err)
+}
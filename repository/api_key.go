@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+//go:generate mockgen -destination=mock/api_key.go -package=repomock github.com/mshto/fruit-store/repository APIKeys
+
+// APIKeys interface
+type APIKeys interface {
+	CreateAPIKey(key *entity.APIKey, keyHash string) error
+	GetAPIKeyByHash(keyHash string) (*entity.APIKey, error)
+	GetAPIKeys(userUUID uuid.UUID) ([]entity.APIKey, error)
+	RevokeAPIKey(ownerUUID, keyUUID uuid.UUID) error
+	TouchAPIKey(keyUUID uuid.UUID, usedAt time.Time) error
+
+	CreateServiceAccount(ownerUUID uuid.UUID, name string) (*entity.ServiceAccount, error)
+	GetServiceAccounts(ownerUUID uuid.UUID) ([]entity.ServiceAccount, error)
+	IsServiceAccountOwner(ownerUUID, accountUUID uuid.UUID) (bool, error)
+}
+
+const uniqueViolation = "23505"
+
+// NewAPIKeys generate new api keys repo
+func NewAPIKeys(db *sql.DB) APIKeys {
+	return &apiKeysImpl{
+		db: db,
+	}
+}
+
+type apiKeysImpl struct {
+	db *sql.DB
+}
+
+var (
+	createAPIKey    = `INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`
+	getAPIKeyByHash = `SELECT id, user_id, name, prefix, scopes, created_at, last_used_at FROM api_keys WHERE key_hash=$1 AND revoked_at IS NULL`
+	getAPIKeys      = `SELECT id, user_id, name, prefix, scopes, created_at, last_used_at FROM api_keys WHERE user_id=$1 AND revoked_at IS NULL ORDER BY created_at`
+	revokeAPIKey    = `UPDATE api_keys SET revoked_at=now() WHERE id=$1 AND revoked_at IS NULL AND (user_id=$2 OR user_id IN (SELECT id FROM users WHERE owner_id=$2))`
+	touchAPIKey     = `UPDATE api_keys SET last_used_at=$2 WHERE id=$1`
+
+	// service account has an empty password which never matches a bcrypt hash, so it can't sign in
+	createServiceAccount  = `INSERT INTO users (username, password, owner_id) VALUES ($1, '', $2) RETURNING id`
+	getServiceAccounts    = `SELECT id, username FROM users WHERE owner_id=$1 ORDER BY username`
+	isServiceAccountOwner = `SELECT exists (SELECT id FROM users WHERE id=$1 AND owner_id=$2)`
+)
+
+// CreateAPIKey store api key hash and set id and creation time of key
+func (aki *apiKeysImpl) CreateAPIKey(key *entity.APIKey, keyHash string) error {
+	return aki.db.QueryRow(createAPIKey, key.UserID, key.Name, key.Prefix, keyHash, pq.Array(key.Scopes)).
+		Scan(&key.ID, &key.CreatedAt)
+}
+
+// GetAPIKeyByHash get not revoked api key by its hash
+func (aki *apiKeysImpl) GetAPIKeyByHash(keyHash string) (*entity.APIKey, error) {
+	key, err := scanAPIKey(aki.db.QueryRow(getAPIKeyByHash, keyHash))
+	if err == sql.ErrNoRows {
+		return key, entity.ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+// GetAPIKeys get not revoked api keys of user
+func (aki *apiKeysImpl) GetAPIKeys(userUUID uuid.UUID) ([]entity.APIKey, error) {
+	keys := []entity.APIKey{}
+
+	rows, err := aki.db.Query(getAPIKeys, userUUID)
+	if err != nil {
+		return keys, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return keys, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey revoke api key of owner or of service account of owner
+func (aki *apiKeysImpl) RevokeAPIKey(ownerUUID, keyUUID uuid.UUID) error {
+	res, err := aki.db.Exec(revokeAPIKey, keyUUID, ownerUUID)
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrAPIKeyNotFound)
+}
+
+// TouchAPIKey set last used time of api key
+func (aki *apiKeysImpl) TouchAPIKey(keyUUID uuid.UUID, usedAt time.Time) error {
+	_, err := aki.db.Exec(touchAPIKey, keyUUID, usedAt)
+	return err
+}
+
+// CreateServiceAccount create service account owned by user, name is unique among all users
+func (aki *apiKeysImpl) CreateServiceAccount(ownerUUID uuid.UUID, name string) (*entity.ServiceAccount, error) {
+	account := &entity.ServiceAccount{Name: name}
+
+	err := aki.db.QueryRow(createServiceAccount, name, ownerUUID).Scan(&account.ID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return account, entity.ErrUserAlreadyExist
+	}
+	return account, err
+}
+
+// GetServiceAccounts get service accounts owned by user
+func (aki *apiKeysImpl) GetServiceAccounts(ownerUUID uuid.UUID) ([]entity.ServiceAccount, error) {
+	accounts := []entity.ServiceAccount{}
+
+	rows, err := aki.db.Query(getServiceAccounts, ownerUUID)
+	if err != nil {
+		return accounts, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		account := entity.ServiceAccount{}
+		err := rows.Scan(&account.ID, &account.Name)
+		if err != nil {
+			return accounts, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+// IsServiceAccountOwner check whether service account is owned by user
+func (aki *apiKeysImpl) IsServiceAccountOwner(ownerUUID, accountUUID uuid.UUID) (bool, error) {
+	var exists bool
+	err := aki.db.QueryRow(isServiceAccountOwner, accountUUID, ownerUUID).Scan(&exists)
+	return exists, err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row rowScanner) (*entity.APIKey, error) {
+	key := &entity.APIKey{}
+	var lastUsedAt pq.NullTime
+
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.CreatedAt, &lastUsedAt)
+	if err != nil {
+		return &entity.APIKey{}, err
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	return key, nil
+}
@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+var apiKeyColumns = []string{"id", "user_id", "name", "prefix", "scopes", "created_at", "last_used_at"}
+
+func TestCreateAPIKey(t *testing.T) {
+	keyUUID := uuid.New()
+	createdAt := time.Date(2020, 11, 24, 0, 0, 0, 0, time.UTC)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	key := &entity.APIKey{UserID: userUUID, Name: "ci", Prefix: "fsk_abcdefgh", Scopes: []string{"cart:read"}}
+
+	rows := sqlmock.NewRows([]string{"id", "created_at"}).AddRow(keyUUID, createdAt)
+	mock.ExpectQuery("INSERT INTO api_keys").
+		WithArgs(userUUID, "ci", "fsk_abcdefgh", "hash", pq.Array([]string{"cart:read"})).
+		WillReturnRows(rows)
+
+	err = NewAPIKeys(db).CreateAPIKey(key, "hash")
+	assert.Nil(t, err)
+	assert.Equal(t, keyUUID, key.ID)
+	assert.Equal(t, createdAt, key.CreatedAt)
+}
+
+func TestGetAPIKeyByHash(t *testing.T) {
+	type expected struct {
+		key entity.APIKey
+		err error
+	}
+	type payload struct {
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	keyUUID := uuid.New()
+	createdAt := time.Date(2020, 11, 24, 0, 0, 0, 0, time.UTC)
+	lastUsedAt := createdAt.Add(time.Hour)
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "GetAPIKeyByHash with success",
+			expected: expected{
+				key: entity.APIKey{ID: keyUUID, UserID: userUUID, Name: "ci", Prefix: "fsk_abcdefgh",
+					Scopes: []string{"cart:read", "cart:write"}, CreatedAt: createdAt, LastUsedAt: &lastUsedAt},
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows(apiKeyColumns).
+						AddRow(keyUUID, userUUID, "ci", "fsk_abcdefgh", "{cart:read,cart:write}", createdAt, lastUsedAt)
+					mock.ExpectQuery("SELECT id, user_id, name, prefix, scopes, created_at, last_used_at FROM api_keys WHERE key_hash").
+						WithArgs("hash").WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "GetAPIKeyByHash never used with success",
+			expected: expected{
+				key: entity.APIKey{ID: keyUUID, UserID: userUUID, Name: "ci", Prefix: "fsk_abcdefgh",
+					Scopes: []string{"cart:read"}, CreatedAt: createdAt},
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows(apiKeyColumns).
+						AddRow(keyUUID, userUUID, "ci", "fsk_abcdefgh", "{cart:read}", createdAt, nil)
+					mock.ExpectQuery("SELECT id, user_id, name, prefix, scopes, created_at, last_used_at FROM api_keys WHERE key_hash").
+						WithArgs("hash").WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "GetAPIKeyByHash ErrNoRows error with failed",
+			expected: expected{
+				err: entity.ErrAPIKeyNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery("SELECT id, user_id, name, prefix, scopes, created_at, last_used_at FROM api_keys WHERE key_hash").
+						WithArgs("hash").WillReturnError(sql.ErrNoRows)
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			key, err := NewAPIKeys(db).GetAPIKeyByHash("hash")
+			assert.Equal(t, test.expected.key, *key)
+			assert.Equal(t, test.expected.err, err)
+		})
+	}
+}
+
+func TestGetAPIKeys(t *testing.T) {
+	keyUUID := uuid.New()
+	createdAt := time.Date(2020, 11, 24, 0, 0, 0, 0, time.UTC)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows(apiKeyColumns).
+		AddRow(keyUUID, userUUID, "ci", "fsk_abcdefgh", "{products:read}", createdAt, nil)
+	mock.ExpectQuery("SELECT id, user_id, name, prefix, scopes, created_at, last_used_at FROM api_keys WHERE user_id").
+		WithArgs(userUUID).WillReturnRows(rows)
+
+	keys, err := NewAPIKeys(db).GetAPIKeys(userUUID)
+	assert.Nil(t, err)
+	assert.Equal(t, []entity.APIKey{
+		{ID: keyUUID, UserID: userUUID, Name: "ci", Prefix: "fsk_abcdefgh", Scopes: []string{"products:read"}, CreatedAt: createdAt},
+	}, keys)
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	type expected struct {
+		err error
+	}
+	type payload struct {
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	keyUUID := uuid.New()
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "RevokeAPIKey with success",
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectExec("UPDATE api_keys SET revoked_at").WithArgs(keyUUID, userUUID).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				},
+			},
+		},
+		{
+			name: "RevokeAPIKey unknown key with failed",
+			expected: expected{
+				err: entity.ErrAPIKeyNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectExec("UPDATE api_keys SET revoked_at").WithArgs(keyUUID, userUUID).
+						WillReturnResult(sqlmock.NewResult(0, 0))
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			err = NewAPIKeys(db).RevokeAPIKey(userUUID, keyUUID)
+			assert.Equal(t, test.expected.err, err)
+		})
+	}
+}
+
+func TestCreateServiceAccount(t *testing.T) {
+	type expected struct {
+		err error
+	}
+	type payload struct {
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	accountUUID := uuid.New()
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "CreateServiceAccount with success",
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows([]string{"id"}).AddRow(accountUUID)
+					mock.ExpectQuery("INSERT INTO users").WithArgs("shop-sync", userUUID).WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "CreateServiceAccount duplicate name with failed",
+			expected: expected{
+				err: entity.ErrUserAlreadyExist,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery("INSERT INTO users").WithArgs("shop-sync", userUUID).
+						WillReturnError(&pq.Error{Code: uniqueViolation})
+				},
+			},
+		},
+		{
+			name: "CreateServiceAccount db error with failed",
+			expected: expected{
+				err: errors.New("error"),
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery("INSERT INTO users").WithArgs("shop-sync", userUUID).
+						WillReturnError(errors.New("error"))
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			account, err := NewAPIKeys(db).CreateServiceAccount(userUUID, "shop-sync")
+			assert.Equal(t, test.expected.err, err)
+			if err == nil {
+				assert.Equal(t, &entity.ServiceAccount{ID: accountUUID, Name: "shop-sync"}, account)
+			}
+		})
+	}
+}
+
+func TestServiceAccounts(t *testing.T) {
+	accountUUID := uuid.New()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "username"}).AddRow(accountUUID, "shop-sync")
+	mock.ExpectQuery("SELECT id, username FROM users WHERE owner_id").WithArgs(userUUID).WillReturnRows(rows)
+
+	rows = sqlmock.NewRows([]string{"exists"}).AddRow(true)
+	mock.ExpectQuery("SELECT exists").WithArgs(accountUUID, userUUID).WillReturnRows(rows)
+
+	repo := NewAPIKeys(db)
+
+	accounts, err := repo.GetServiceAccounts(userUUID)
+	assert.Nil(t, err)
+	assert.Equal(t, []entity.ServiceAccount{{ID: accountUUID, Name: "shop-sync"}}, accounts)
+
+	ok, err := repo.IsServiceAccountOwner(userUUID, accountUUID)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
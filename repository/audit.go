@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+//go:generate mockgen -destination=mock/audit.go -package=repomock github.com/mshto/fruit-store/repository Audit
+
+// Audit interface
+type Audit interface {
+	CreateAuditEvent(event *entity.AuditEvent) error
+	GetAuditEvents(filter entity.AuditFilter) ([]entity.AuditEvent, int, error)
+}
+
+// NewAudit generate new audit repo
+func NewAudit(db *sql.DB) Audit {
+	return &auditImpl{
+		db: db,
+	}
+}
+
+type auditImpl struct {
+	db *sql.DB
+}
+
+var (
+	createAuditEvent = `INSERT INTO audit_events (actor, ip, user_agent, action, target, outcome, details) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, occurred_at`
+	countAuditEvents = `SELECT count(*) FROM audit_events`
+	getAuditEvents   = `SELECT id, occurred_at, actor, ip, user_agent, action, target, outcome, details FROM audit_events`
+)
+
+// CreateAuditEvent append event and set its id and time
+func (adi *auditImpl) CreateAuditEvent(event *entity.AuditEvent) error {
+	return adi.db.QueryRow(createAuditEvent, event.Actor, event.IP, event.UserAgent, event.Action, event.Target, event.Outcome, event.Details).
+		Scan(&event.ID, &event.OccurredAt)
+}
+
+// GetAuditEvents get page of filtered events, newest first, and total count of filtered events
+func (adi *auditImpl) GetAuditEvents(filter entity.AuditFilter) ([]entity.AuditEvent, int, error) {
+	where, args := auditConditions(filter)
+
+	var total int
+	err := adi.db.QueryRow(countAuditEvents+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf("%s%s ORDER BY id DESC LIMIT $%d OFFSET $%d", getAuditEvents, where, len(args)+1, len(args)+2)
+	rows, err := adi.db.Query(query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events := []entity.AuditEvent{}
+	for rows.Next() {
+		var event entity.AuditEvent
+		err = rows.Scan(&event.ID, &event.OccurredAt, &event.Actor, &event.IP, &event.UserAgent, &event.Action, &event.Target, &event.Outcome, &event.Details)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, event)
+	}
+	return events, total, rows.Err()
+}
+
+// auditConditions build WHERE clause with placeholders for set fields of filter
+func auditConditions(filter entity.AuditFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Actor != "" {
+		add("actor=$%d", filter.Actor)
+	}
+	if filter.Action != "" {
+		add("action=$%d", filter.Action)
+	}
+	if filter.Target != "" {
+		add("target=$%d", filter.Target)
+	}
+	if filter.Outcome != "" {
+		add("outcome=$%d", filter.Outcome)
+	}
+	if filter.From != nil {
+		add("occurred_at>=$%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("occurred_at<$%d", *filter.To)
+	}
+
+	return where(conditions), args
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+var (
+	auditColumns = []string{"id", "occurred_at", "actor", "ip", "user_agent", "action", "target", "outcome", "details"}
+	occurredAt   = time.Date(2020, 11, 27, 10, 0, 0, 0, time.UTC)
+	auditEvent   = entity.AuditEvent{
+		ID:         1,
+		OccurredAt: occurredAt,
+		IP:         "10.0.0.1",
+		UserAgent:  "agent",
+		Action:     entity.AuditSignin,
+		Target:     "test",
+		Outcome:    entity.AuditFailure,
+		Details:    "invalid credentials",
+	}
+)
+
+func TestCreateAuditEvent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "occurred_at"}).AddRow(1, occurredAt)
+	mock.ExpectQuery("INSERT INTO audit_events").
+		WithArgs("", "10.0.0.1", "agent", entity.AuditSignin, "test", entity.AuditFailure, "invalid credentials").
+		WillReturnRows(rows)
+
+	event := auditEvent
+	event.ID = 0
+	event.OccurredAt = time.Time{}
+
+	err = NewAudit(db).CreateAuditEvent(&event)
+	assert.Nil(t, err)
+	assert.Equal(t, auditEvent, event)
+}
+
+func TestGetAuditEvents(t *testing.T) {
+	type expected struct {
+		events []entity.AuditEvent
+		total  int
+		err    error
+	}
+	type payload struct {
+		filter  entity.AuditFilter
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	to := occurredAt.Add(time.Hour)
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "GetAuditEvents without filter with success",
+			expected: expected{
+				events: []entity.AuditEvent{auditEvent},
+				total:  1,
+			},
+			payload: payload{
+				filter: entity.AuditFilter{Limit: 50},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery(`SELECT count\(\*\) FROM audit_events$`).
+						WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+					rows := sqlmock.NewRows(auditColumns).
+						AddRow(1, occurredAt, "", "10.0.0.1", "agent", entity.AuditSignin, "test", entity.AuditFailure, "invalid credentials")
+					mock.ExpectQuery(`FROM audit_events ORDER BY id DESC LIMIT \$1 OFFSET \$2`).WithArgs(50, 0).WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "GetAuditEvents with filter with success",
+			expected: expected{
+				events: []entity.AuditEvent{},
+				total:  3,
+			},
+			payload: payload{
+				filter: entity.AuditFilter{Actor: "actor", Action: entity.AuditSignin, Outcome: entity.AuditFailure, From: &occurredAt, To: &to, Limit: 10, Offset: 20},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery(`SELECT count\(\*\) FROM audit_events WHERE actor=\$1 AND action=\$2 AND outcome=\$3 AND occurred_at>=\$4 AND occurred_at<\$5`).
+						WithArgs("actor", entity.AuditSignin, entity.AuditFailure, occurredAt, to).
+						WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+					mock.ExpectQuery(`WHERE actor=\$1 AND action=\$2 AND outcome=\$3 AND occurred_at>=\$4 AND occurred_at<\$5 ORDER BY id DESC LIMIT \$6 OFFSET \$7`).
+						WithArgs("actor", entity.AuditSignin, entity.AuditFailure, occurredAt, to, 10, 20).
+						WillReturnRows(sqlmock.NewRows(auditColumns))
+				},
+			},
+		},
+		{
+			name: "GetAuditEvents count error with fail",
+			expected: expected{
+				err: ErrNotFound,
+			},
+			payload: payload{
+				filter: entity.AuditFilter{Limit: 50},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery(`SELECT count\(\*\) FROM audit_events`).WillReturnError(ErrNotFound)
+				},
+			},
+		},
+		{
+			name: "GetAuditEvents query error with fail",
+			expected: expected{
+				err: ErrNotFound,
+			},
+			payload: payload{
+				filter: entity.AuditFilter{Limit: 50},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery(`SELECT count\(\*\) FROM audit_events`).
+						WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+					mock.ExpectQuery(`FROM audit_events ORDER BY`).WillReturnError(ErrNotFound)
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			events, total, err := NewAudit(db).GetAuditEvents(test.payload.filter)
+			assert.Equal(t, test.expected.events, events)
+			assert.Equal(t, test.expected.total, total)
+			assert.Equal(t, test.expected.err, err)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
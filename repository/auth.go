@@ -3,6 +3,8 @@ package repository
 import (
 	"database/sql"
 
+	"github.com/google/uuid"
+
 	"github.com/mshto/fruit-store/entity"
 )
 
@@ -11,7 +13,16 @@ import (
 // Auth interface
 type Auth interface {
 	GetUserByName(userName string) (*entity.Credentials, error)
+	GetUserByID(userUUID uuid.UUID) (*entity.Credentials, error)
+	UpdatePassword(userUUID uuid.UUID, password string) error
 	Signup(creds *entity.Credentials) error
+
+	GetUserByIdentity(issuer, subject string) (*entity.Credentials, error)
+	LinkIdentity(userUUID uuid.UUID, identity entity.Identity) error
+	SignupWithIdentity(creds *entity.Credentials, identity entity.Identity) error
+
+	GetUserRole(userUUID uuid.UUID) (string, error)
+	UpdateUserRole(userUUID uuid.UUID, role string) error
 }
 
 // NewAuth generate new auth
@@ -26,22 +37,43 @@ type authImpl struct {
 }
 
 var (
-	getUserPasswordByName = "SELECT id, username, password FROM users WHERE username=$1"
+	getUserPasswordByName = "SELECT id, username, password, totp_enabled FROM users WHERE username=$1"
+	getUserPasswordByID   = "SELECT id, username, password, totp_enabled FROM users WHERE id=$1"
+	updatePassword        = "UPDATE users SET password=$2 WHERE id=$1"
 	validateUserByName    = "SELECT exists (SELECT id FROM users WHERE username=$1)"
-	signup                = "INSERT INTO users (username, password) VALUES ($1, $2)"
+	signup                = "INSERT INTO users (username, password) VALUES ($1, $2) RETURNING id"
 )
 
 // GetUserByName get user creds by name
 func (aui *authImpl) GetUserByName(userName string) (*entity.Credentials, error) {
 	var creds entity.Credentials
-	err := aui.db.QueryRow(getUserPasswordByName, userName).Scan(&creds.ID, &creds.Username, &creds.Password)
+	err := aui.db.QueryRow(getUserPasswordByName, userName).Scan(&creds.ID, &creds.Username, &creds.Password, &creds.TOTPEnabled)
 	if err == sql.ErrNoRows {
 		return &creds, entity.ErrUserNotFound
 	}
 	return &creds, err
 }
 
-// Signup sign up user
+// GetUserByID get user creds by id
+func (aui *authImpl) GetUserByID(userUUID uuid.UUID) (*entity.Credentials, error) {
+	var creds entity.Credentials
+	err := aui.db.QueryRow(getUserPasswordByID, userUUID).Scan(&creds.ID, &creds.Username, &creds.Password, &creds.TOTPEnabled)
+	if err == sql.ErrNoRows {
+		return &creds, entity.ErrUserNotFound
+	}
+	return &creds, err
+}
+
+// UpdatePassword set a new password hash of user
+func (aui *authImpl) UpdatePassword(userUUID uuid.UUID, password string) error {
+	res, err := aui.db.Exec(updatePassword, userUUID, password)
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrUserNotFound)
+}
+
+// Signup sign up user and set id of created user
 func (aui *authImpl) Signup(creds *entity.Credentials) error {
 	exists, err := aui.isRowExist(creds.Username)
 	if err != nil {
@@ -51,8 +83,7 @@ func (aui *authImpl) Signup(creds *entity.Credentials) error {
 		return entity.ErrUserAlreadyExist
 	}
 
-	_, err = aui.db.Query(signup, creds.Username, creds.Password)
-	return err
+	return aui.db.QueryRow(signup, creds.Username, creds.Password).Scan(&creds.ID)
 }
 
 func (aui *authImpl) isRowExist(username string) (bool, error) {
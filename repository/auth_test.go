@@ -39,9 +39,9 @@ func TestGetUserByName(t *testing.T) {
 			},
 			payload: payload{
 				sqlMock: func(mock sqlmock.Sqlmock) {
-					rows := sqlmock.NewRows([]string{"id", "username", "password"}).
-						AddRow(cred.ID, cred.Username, cred.Password)
-					mock.ExpectQuery("SELECT id, username, password FROM users").WithArgs(cred.Username).WillReturnRows(rows)
+					rows := sqlmock.NewRows([]string{"id", "username", "password", "totp_enabled"}).
+						AddRow(cred.ID, cred.Username, cred.Password, cred.TOTPEnabled)
+					mock.ExpectQuery("SELECT id, username, password, totp_enabled FROM users").WithArgs(cred.Username).WillReturnRows(rows)
 				},
 			},
 		},
@@ -53,7 +53,7 @@ func TestGetUserByName(t *testing.T) {
 			},
 			payload: payload{
 				sqlMock: func(mock sqlmock.Sqlmock) {
-					mock.ExpectQuery("SELECT id, username, password FROM users").WithArgs(cred.Username).WillReturnError(ErrNotFound)
+					mock.ExpectQuery("SELECT id, username, password, totp_enabled FROM users").WithArgs(cred.Username).WillReturnError(ErrNotFound)
 				},
 			},
 		},
@@ -65,7 +65,7 @@ func TestGetUserByName(t *testing.T) {
 			},
 			payload: payload{
 				sqlMock: func(mock sqlmock.Sqlmock) {
-					mock.ExpectQuery("SELECT id, username, password FROM users").WithArgs(cred.Username).WillReturnError(sql.ErrNoRows)
+					mock.ExpectQuery("SELECT id, username, password, totp_enabled FROM users").WithArgs(cred.Username).WillReturnError(sql.ErrNoRows)
 				},
 			},
 		},
@@ -168,3 +168,142 @@ func TestSignup(t *testing.T) {
 		})
 	}
 }
+
+func TestGetUserByID(t *testing.T) {
+	type expected struct {
+		cred entity.Credentials
+		err  error
+	}
+	type payload struct {
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "GetUserByID with success",
+			expected: expected{
+				cred: cred,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows([]string{"id", "username", "password", "totp_enabled"}).
+						AddRow(cred.ID, cred.Username, cred.Password, cred.TOTPEnabled)
+					mock.ExpectQuery("SELECT id, username, password, totp_enabled FROM users").WithArgs(cred.ID).WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "GetUserByID db error with failed",
+			expected: expected{
+				cred: entity.Credentials{},
+				err:  ErrNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery("SELECT id, username, password, totp_enabled FROM users").WithArgs(cred.ID).WillReturnError(ErrNotFound)
+				},
+			},
+		},
+		{
+			name: "GetUserByID ErrNoRows error with failed",
+			expected: expected{
+				cred: entity.Credentials{},
+				err:  entity.ErrUserNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery("SELECT id, username, password, totp_enabled FROM users").WithArgs(cred.ID).WillReturnError(sql.ErrNoRows)
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			creds, err := NewAuth(db).GetUserByID(cred.ID)
+			assert.Equal(t, test.expected.cred, *creds)
+			assert.Equal(t, test.expected.err, err)
+		})
+	}
+}
+
+func TestUpdatePassword(t *testing.T) {
+	type expected struct {
+		err error
+	}
+	type payload struct {
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "UpdatePassword with success",
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectExec("UPDATE users SET password").WithArgs(userUUID, "hash").
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				},
+			},
+		},
+		{
+			name: "UpdatePassword unknown user with failed",
+			expected: expected{
+				err: entity.ErrUserNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectExec("UPDATE users SET password").WithArgs(userUUID, "hash").
+						WillReturnResult(sqlmock.NewResult(0, 0))
+				},
+			},
+		},
+		{
+			name: "UpdatePassword db error with failed",
+			expected: expected{
+				err: ErrNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectExec("UPDATE users SET password").WithArgs(userUUID, "hash").WillReturnError(ErrNotFound)
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			err = NewAuth(db).UpdatePassword(userUUID, "hash")
+			assert.Equal(t, test.expected.err, err)
+		})
+	}
+}
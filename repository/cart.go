@@ -4,6 +4,7 @@ import (
 	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 
 	"github.com/mshto/fruit-store/entity"
 )
@@ -13,12 +14,24 @@ import (
 // Cart interface
 type Cart interface {
 	GetUserProducts(userUUID uuid.UUID) ([]entity.GetUserProduct, error)
-	CreateUserProduct(userUUID, productUUID uuid.UUID) error
-	CreateUserProducts(userUUID uuid.UUID, prd entity.UserProduct) error
+	CreateUserProduct(userUUID, productUUID uuid.UUID, expected int64) (int64, error)
+	CreateUserProducts(userUUID uuid.UUID, prd entity.UserProduct, expected int64) (int64, error)
 	RemoveUserProducts(userUUID uuid.UUID) error
-	RemoveUserProduct(userUUID, productUUID uuid.UUID) error
+	RemoveUserProduct(userUUID, productUUID uuid.UUID, expected int64) (int64, error)
+	AddUserProducts(userUUID uuid.UUID, prds []entity.UserProduct) error
+	UpdateUserProductAmount(userUUID, productUUID uuid.UUID, delta float64, expected int64) (float64, int64, error)
+	ReplaceUserProducts(userUUID uuid.UUID, prds []entity.UserProduct, expected int64) (int64, error)
+
+	GetCartVersion(userUUID uuid.UUID) (int64, error)
+	IncrCartVersion(userUUID uuid.UUID, expected int64) (int64, error)
 }
 
+// AnyCartVersion expected version which matches any current cart version.
+// Cart mutations take expected version and return the incremented one, a mutation which fails keeps the version.
+const AnyCartVersion int64 = -1
+
+const foreignKeyViolation = "23503"
+
 // NewCartProduct generate a new cart product
 func NewCartProduct(db *sql.DB) Cart {
 	return &cartImpl{
@@ -31,14 +44,23 @@ type cartImpl struct {
 }
 
 var (
-	getUserProducts    = `SELECT users_cart.amount, products.id, products.name, products.price FROM users_cart INNER JOIN products ON users_cart.user_id=$1 AND users_cart.product_id=products.id;`
-	createUserProducts = `INSERT INTO users_cart (user_id, product_id, amount) VALUES ($1, $2, $3) ON CONFLICT (product_id, user_id) DO UPDATE SET amount=$3 RETURNING user_id`
-	createUserProduct  = `INSERT INTO users_cart (user_id, product_id, amount) VALUES ($1, $2, $3) ON CONFLICT (product_id, user_id) DO UPDATE SET amount=users_cart.amount+1 RETURNING user_id`
-	deleteUserProducts = `DELETE FROM users_cart WHERE user_id = $1`
-	deleteUserProduct  = `DELETE FROM users_cart WHERE user_id = $1 AND product_id = $2`
+	getUserProducts     = `SELECT users_cart.amount, products.id, products.name, products.price, COALESCE(products.currency, ''), products.unit, COALESCE(categories.path, ''), COALESCE(users_cart.added_price, products.price) FROM users_cart INNER JOIN products ON users_cart.user_id=$1 AND users_cart.product_id=products.id LEFT JOIN categories ON categories.id=products.category_id;`
+	createUserProducts  = `INSERT INTO users_cart (user_id, product_id, amount, added_price) VALUES ($1, $2, $3, (SELECT price FROM products WHERE id=$2)) ON CONFLICT (product_id, user_id) DO UPDATE SET amount=$3 RETURNING user_id`
+	createUserProduct   = `INSERT INTO users_cart (user_id, product_id, amount, added_price) SELECT $1, id, min_amount, price FROM products WHERE id=$2 ON CONFLICT (product_id, user_id) DO UPDATE SET amount=users_cart.amount+(SELECT step FROM products WHERE id=$2) RETURNING user_id`
+	addUserProducts     = `INSERT INTO users_cart (user_id, product_id, amount, added_price) VALUES ($1, $2, $3, (SELECT price FROM products WHERE id=$2)) ON CONFLICT (product_id, user_id) DO UPDATE SET amount=users_cart.amount+$3`
+	addUserProduct      = `INSERT INTO users_cart (user_id, product_id, amount, added_price) VALUES ($1, $2, $3, (SELECT price FROM products WHERE id=$2)) ON CONFLICT (product_id, user_id) DO UPDATE SET amount=users_cart.amount+$3 RETURNING amount`
+	deleteUserProducts  = `DELETE FROM users_cart WHERE user_id = $1`
+	deleteUserProduct   = `DELETE FROM users_cart WHERE user_id = $1 AND product_id = $2`
+	deleteOtherProducts = `DELETE FROM users_cart WHERE user_id = $1 AND NOT (product_id = ANY($2))`
+	getAmountRule       = `SELECT min_amount, step FROM products WHERE id=$1`
+
+	getCartVersion      = `SELECT version FROM users_cart_version WHERE user_id=$1`
+	incrCartVersion     = `INSERT INTO users_cart_version (user_id, version) VALUES ($1, 1) ON CONFLICT (user_id) DO UPDATE SET version=users_cart_version.version+1 RETURNING version`
+	createCartVersion   = `INSERT INTO users_cart_version (user_id, version) VALUES ($1, 1) ON CONFLICT (user_id) DO NOTHING RETURNING version`
+	incrCartVersionFrom = `UPDATE users_cart_version SET version=version+1 WHERE user_id=$1 AND version=$2 RETURNING version`
 )
 
-// GetUserProducts get user products
+// GetUserProducts get user products, products are flagged when their price changed since they were added
 func (pri *cartImpl) GetUserProducts(userUUID uuid.UUID) ([]entity.GetUserProduct, error) {
 	products := []entity.GetUserProduct{}
 
@@ -54,23 +76,93 @@ func (pri *cartImpl) GetUserProducts(userUUID uuid.UUID) ([]entity.GetUserProduc
 
 	for rows.Next() {
 		p := entity.GetUserProduct{}
-		err := rows.Scan(&p.Amount, &p.ProductUUID, &p.Name, &p.Price)
+		var addedPrice float32
+		err := rows.Scan(&p.Amount, &p.ProductUUID, &p.Name, &p.Price, &p.Currency, &p.Unit, &p.Category, &addedPrice)
 		if err != nil {
 			return products, err
 		}
+		p.SetAddedPrice(addedPrice)
 		products = append(products, p)
 	}
 	return products, nil
 }
 
-// CreateUserProducts create user products
-func (pri *cartImpl) CreateUserProducts(userUUID uuid.UUID, prd entity.UserProduct) error {
-	return pri.db.QueryRow(createUserProducts, userUUID, prd.ProductUUID, prd.Amount).Scan(&prd.UserID)
+// CreateUserProducts set amount of user product, it must fit minimum and step of product
+func (pri *cartImpl) CreateUserProducts(userUUID uuid.UUID, prd entity.UserProduct, expected int64) (int64, error) {
+	tx, version, err := pri.beginVersioned(userUUID, expected)
+	if err != nil {
+		return 0, err
+	}
+
+	err = checkAmount(tx, prd.ProductUUID, prd.Amount)
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		return 0, err
+	}
+
+	err = tx.QueryRow(createUserProducts, userUUID, prd.ProductUUID, prd.Amount).Scan(&prd.UserID)
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		return 0, productErr(err)
+	}
+
+	return version, tx.Commit()
 }
 
-// CreateUserProduct create user products
-func (pri *cartImpl) CreateUserProduct(userUUID, productUUID uuid.UUID) error {
-	return pri.db.QueryRow(createUserProduct, userUUID, productUUID, 1).Scan(&userUUID)
+// CreateUserProduct add one step of product, or its minimum amount when product isn't in the cart yet
+func (pri *cartImpl) CreateUserProduct(userUUID, productUUID uuid.UUID, expected int64) (int64, error) {
+	tx, version, err := pri.beginVersioned(userUUID, expected)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.QueryRow(createUserProduct, userUUID, productUUID).Scan(&userUUID)
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		if err == sql.ErrNoRows {
+			return 0, entity.ErrProductNotFound
+		}
+		return 0, err
+	}
+
+	return version, tx.Commit()
+}
+
+// UpdateUserProductAmount change amount of user product by delta and return a new amount.
+// Product is removed from the cart when its amount reaches zero.
+func (pri *cartImpl) UpdateUserProductAmount(userUUID, productUUID uuid.UUID, delta float64, expected int64) (float64, int64, error) {
+	var amount float64
+
+	tx, version, err := pri.beginVersioned(userUUID, expected)
+	if err != nil {
+		return amount, 0, err
+	}
+
+	err = tx.QueryRow(addUserProduct, userUUID, productUUID, delta).Scan(&amount)
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		return amount, 0, productErr(err)
+	}
+
+	switch {
+	case amount < 0:
+		_ = tx.Rollback() // nolint
+		return amount, 0, entity.ErrInvalidAmount
+	case amount == 0:
+		_, err = tx.Exec(deleteUserProduct, userUUID, productUUID)
+		if err != nil {
+			_ = tx.Rollback() // nolint
+			return amount, 0, err
+		}
+	default:
+		err = checkAmount(tx, productUUID, amount)
+		if err != nil {
+			_ = tx.Rollback() // nolint
+			return amount, 0, err
+		}
+	}
+
+	return amount, version, tx.Commit()
 }
 
 // RemoveUserProducts remove user products
@@ -80,7 +172,146 @@ func (pri *cartImpl) RemoveUserProducts(userUUID uuid.UUID) error {
 }
 
 // RemoveUserProduct remove user product
-func (pri *cartImpl) RemoveUserProduct(userUUID, productUUID uuid.UUID) error {
-	_, err := pri.db.Exec(deleteUserProduct, userUUID, productUUID)
+func (pri *cartImpl) RemoveUserProduct(userUUID, productUUID uuid.UUID, expected int64) (int64, error) {
+	tx, version, err := pri.beginVersioned(userUUID, expected)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(deleteUserProduct, userUUID, productUUID)
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		return 0, err
+	}
+
+	return version, tx.Commit()
+}
+
+// AddUserProducts add amounts of products to the user cart in one transaction
+func (pri *cartImpl) AddUserProducts(userUUID uuid.UUID, prds []entity.UserProduct) error {
+	tx, err := pri.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, prd := range prds {
+		_, err = tx.Exec(addUserProducts, userUUID, prd.ProductUUID, prd.Amount)
+		if err != nil {
+			_ = tx.Rollback() // nolint
+			return productErr(err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ReplaceUserProducts replace all user products in one transaction.
+// Products missing from the list or with zero amount are removed from the cart.
+func (pri *cartImpl) ReplaceUserProducts(userUUID uuid.UUID, prds []entity.UserProduct, expected int64) (int64, error) {
+	tx, version, err := pri.beginVersioned(userUUID, expected)
+	if err != nil {
+		return 0, err
+	}
+
+	keepIDs := make([]string, 0, len(prds))
+	for _, prd := range prds {
+		if prd.Amount == 0 {
+			continue
+		}
+		err = checkAmount(tx, prd.ProductUUID, prd.Amount)
+		if err != nil {
+			_ = tx.Rollback() // nolint
+			return 0, err
+		}
+		_, err = tx.Exec(createUserProducts, userUUID, prd.ProductUUID, prd.Amount)
+		if err != nil {
+			_ = tx.Rollback() // nolint
+			return 0, productErr(err)
+		}
+		keepIDs = append(keepIDs, prd.ProductUUID.String())
+	}
+
+	_, err = tx.Exec(deleteOtherProducts, userUUID, pq.Array(keepIDs))
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		return 0, err
+	}
+
+	return version, tx.Commit()
+}
+
+// GetCartVersion get current version of user cart, 0 for a cart which was never changed
+func (pri *cartImpl) GetCartVersion(userUUID uuid.UUID) (int64, error) {
+	var version int64
+	err := pri.db.QueryRow(getCartVersion, userUUID).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return version, err
+}
+
+// IncrCartVersion increment version of user cart if it equals to expected one, it's used by changes of cart
+// which aren't stored with it, cart mutations increment the version themselves
+func (pri *cartImpl) IncrCartVersion(userUUID uuid.UUID, expected int64) (int64, error) {
+	return incrCartVersionTx(pri.db, userUUID, expected)
+}
+
+// beginVersioned begin transaction of cart mutation with version of user cart checked and incremented,
+// the version row stays locked until transaction ends, so concurrent mutations of the cart are serialized
+func (pri *cartImpl) beginVersioned(userUUID uuid.UUID, expected int64) (*sql.Tx, int64, error) {
+	tx, err := pri.db.Begin()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	version, err := incrCartVersionTx(tx, userUUID, expected)
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		return nil, 0, err
+	}
+	return tx, version, nil
+}
+
+// incrCartVersionTx increment version of user cart if it equals to expected one, nothing is written on mismatch.
+// Version 0 of a cart which was never changed is created as 1.
+func incrCartVersionTx(q queryRower, userUUID uuid.UUID, expected int64) (int64, error) {
+	var version int64
+	var err error
+	switch expected {
+	case AnyCartVersion:
+		return version, q.QueryRow(incrCartVersion, userUUID).Scan(&version)
+	case 0:
+		err = q.QueryRow(createCartVersion, userUUID).Scan(&version)
+	default:
+		err = q.QueryRow(incrCartVersionFrom, userUUID, expected).Scan(&version)
+	}
+	if err == sql.ErrNoRows {
+		return version, entity.ErrCartVersionMismatch
+	}
+	return version, err
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// checkAmount check that amount fits minimum and step of product
+func checkAmount(q queryRower, productUUID uuid.UUID, amount float64) error {
+	var minAmount, step float64
+	err := q.QueryRow(getAmountRule, productUUID).Scan(&minAmount, &step)
+	if err == sql.ErrNoRows {
+		return entity.ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return entity.CheckAmount(amount, minAmount, step)
+}
+
+// productErr converts foreign key violation of unknown product to ErrProductNotFound
+func productErr(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == foreignKeyViolation {
+		return entity.ErrProductNotFound
+	}
 	return err
 }
@@ -1,10 +1,12 @@
 package repository
 
 import (
+	"database/sql"
 	"testing"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/mshto/fruit-store/entity"
 	"github.com/stretchr/testify/assert"
 )
@@ -15,6 +17,8 @@ var (
 		ProductUUID: uuid.New(),
 		Name:        "Product 1",
 		Price:       10.0,
+		Currency:    "EUR",
+		Unit:        entity.UnitPiece,
 		Amount:      1,
 	}
 	userProductOne = entity.UserProduct{
@@ -24,7 +28,26 @@ var (
 	}
 )
 
+// expectAmountRule expect minimum amount and step of userProductOne to be read
+func expectAmountRule(mock sqlmock.Sqlmock, minAmount, step float64) {
+	rows := sqlmock.NewRows([]string{"min_amount", "step"}).AddRow(minAmount, step)
+	mock.ExpectQuery("SELECT min_amount, step FROM products").WithArgs(userProductOne.ProductUUID).WillReturnRows(rows)
+}
+
+// expectCartVersion expect transaction of cart mutation to begin with version of any cart incremented to 1
+func expectCartVersion(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	rows := sqlmock.NewRows([]string{"version"}).AddRow(1)
+	mock.ExpectQuery("INSERT INTO users_cart_version").WithArgs(userUUID).WillReturnRows(rows)
+}
+
 func TestGetUserProducts(t *testing.T) {
+	userProductColumns := []string{"users_cart.amount", "products.id", "products.name", "products.price", "products.currency", "products.unit", "categories.path", "added_price"}
+	addedPrice := float32(8.5)
+	changedProduct := getUserProductOne
+	changedProduct.PriceChanged = true
+	changedProduct.AddedPrice = &addedPrice
+
 	type expected struct {
 		products []entity.GetUserProduct
 		isErr    bool
@@ -46,10 +69,25 @@ func TestGetUserProducts(t *testing.T) {
 			},
 			payload: payload{
 				sqlMock: func(mock sqlmock.Sqlmock) {
-					rows := sqlmock.NewRows([]string{"users_cart.amount", "products.id", "products.name", "products.price"}).
-						AddRow(getUserProductOne.Amount, getUserProductOne.ProductUUID, getUserProductOne.Name, getUserProductOne.Price)
+					rows := sqlmock.NewRows(userProductColumns).
+						AddRow(getUserProductOne.Amount, getUserProductOne.ProductUUID, getUserProductOne.Name, getUserProductOne.Price, getUserProductOne.Currency, getUserProductOne.Unit, getUserProductOne.Category, getUserProductOne.Price)
+
+					mock.ExpectQuery("SELECT users_cart.amount, products.id, products.name, products.price, COALESCE\\(products.currency, ''\\), products.unit, COALESCE\\(categories.path, ''\\), COALESCE\\(users_cart.added_price, products.price\\) FROM users_cart").WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "Get user products with changed price with success",
+			expected: expected{
+				products: []entity.GetUserProduct{changedProduct},
+				isErr:    false,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows(userProductColumns).
+						AddRow(getUserProductOne.Amount, getUserProductOne.ProductUUID, getUserProductOne.Name, getUserProductOne.Price, getUserProductOne.Currency, getUserProductOne.Unit, getUserProductOne.Category, addedPrice)
 
-					mock.ExpectQuery("SELECT users_cart.amount, products.id, products.name, products.price FROM users_cart").WillReturnRows(rows)
+					mock.ExpectQuery("SELECT users_cart.amount, products.id, products.name, products.price, COALESCE\\(products.currency, ''\\), products.unit, COALESCE\\(categories.path, ''\\), COALESCE\\(users_cart.added_price, products.price\\) FROM users_cart").WillReturnRows(rows)
 				},
 			},
 		},
@@ -64,7 +102,7 @@ func TestGetUserProducts(t *testing.T) {
 					rows := sqlmock.NewRows([]string{"id", "name", "wrong"}).
 						AddRow(getUserProductOne.Amount, getUserProductOne.ProductUUID, ErrDB)
 
-					mock.ExpectQuery("SELECT users_cart.amount, products.id, products.name, products.price FROM users_cart").WillReturnRows(rows)
+					mock.ExpectQuery("SELECT users_cart.amount, products.id, products.name, products.price, COALESCE\\(products.currency, ''\\), products.unit, COALESCE\\(categories.path, ''\\), COALESCE\\(users_cart.added_price, products.price\\) FROM users_cart").WillReturnRows(rows)
 				},
 			},
 		},
@@ -76,7 +114,7 @@ func TestGetUserProducts(t *testing.T) {
 			},
 			payload: payload{
 				sqlMock: func(mock sqlmock.Sqlmock) {
-					mock.ExpectQuery("SELECT users_cart.amount, products.id, products.name, products.price FROM users_cart").WillReturnError(ErrNotFound)
+					mock.ExpectQuery("SELECT users_cart.amount, products.id, products.name, products.price, COALESCE\\(products.currency, ''\\), products.unit, COALESCE\\(categories.path, ''\\), COALESCE\\(users_cart.added_price, products.price\\) FROM users_cart").WillReturnError(ErrNotFound)
 				},
 			},
 		},
@@ -124,10 +162,51 @@ func TestCreateUserProducts(t *testing.T) {
 			},
 			payload: payload{
 				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
+					expectAmountRule(mock, 1, 1)
 					rows := sqlmock.NewRows([]string{"id"}).
 						AddRow(userUUID)
 					mock.ExpectQuery("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID, userProductOne.Amount).
 						WillReturnRows(rows)
+					mock.ExpectCommit()
+				},
+			},
+		},
+		{
+			name: "Create user products unknown product with failed",
+			expected: expected{
+				err: entity.ErrProductNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
+					expectAmountRule(mock, 1, 1)
+					mock.ExpectQuery("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID, userProductOne.Amount).
+						WillReturnError(&pq.Error{Code: foreignKeyViolation})
+				},
+			},
+		},
+		{
+			name: "Create user products below minimum with failed",
+			expected: expected{
+				err: entity.ErrInvalidQuantity,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
+					expectAmountRule(mock, 2, 1)
+				},
+			},
+		},
+		{
+			name: "Create user products amount rule of unknown product with failed",
+			expected: expected{
+				err: entity.ErrProductNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
+					mock.ExpectQuery("SELECT min_amount, step FROM products").WillReturnError(sql.ErrNoRows)
 				},
 			},
 		},
@@ -138,6 +217,8 @@ func TestCreateUserProducts(t *testing.T) {
 			},
 			payload: payload{
 				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
+					expectAmountRule(mock, 1, 1)
 					mock.ExpectQuery("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID, userProductOne.Amount).
 						WillReturnError(ErrNotFound)
 				},
@@ -158,7 +239,7 @@ func TestCreateUserProducts(t *testing.T) {
 
 			test.payload.sqlMock(mock)
 
-			err = NewCartProduct(db).CreateUserProducts(userProductOne.UserID, userProductOne)
+			_, err = NewCartProduct(db).CreateUserProducts(userProductOne.UserID, userProductOne, AnyCartVersion)
 			assert.Equal(t, err, test.expected.err)
 		})
 	}
@@ -184,10 +265,25 @@ func TestCreateUserProduct(t *testing.T) {
 			},
 			payload: payload{
 				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
 					rows := sqlmock.NewRows([]string{"id"}).
 						AddRow(userUUID)
-					mock.ExpectQuery("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID, userProductOne.Amount).
+					mock.ExpectQuery("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID).
 						WillReturnRows(rows)
+					mock.ExpectCommit()
+				},
+			},
+		},
+		{
+			name: "Create user product unknown product with failed",
+			expected: expected{
+				err: entity.ErrProductNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
+					mock.ExpectQuery("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID).
+						WillReturnError(sql.ErrNoRows)
 				},
 			},
 		},
@@ -198,7 +294,8 @@ func TestCreateUserProduct(t *testing.T) {
 			},
 			payload: payload{
 				sqlMock: func(mock sqlmock.Sqlmock) {
-					mock.ExpectQuery("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID, userProductOne.Amount).
+					expectCartVersion(mock)
+					mock.ExpectQuery("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID).
 						WillReturnError(ErrNotFound)
 				},
 			},
@@ -218,7 +315,7 @@ func TestCreateUserProduct(t *testing.T) {
 
 			test.payload.sqlMock(mock)
 
-			err = NewCartProduct(db).CreateUserProduct(userProductOne.UserID, userProductOne.ProductUUID)
+			_, err = NewCartProduct(db).CreateUserProduct(userProductOne.UserID, userProductOne.ProductUUID, AnyCartVersion)
 			assert.Equal(t, err, test.expected.err)
 		})
 	}
@@ -302,8 +399,10 @@ func TestRemoveUserProduct(t *testing.T) {
 			},
 			payload: payload{
 				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
 					mock.ExpectExec("DELETE FROM users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID).
 						WillReturnResult(sqlmock.NewResult(1, 1))
+					mock.ExpectCommit()
 				},
 			},
 		},
@@ -314,6 +413,7 @@ func TestRemoveUserProduct(t *testing.T) {
 			},
 			payload: payload{
 				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
 					mock.ExpectExec("DELETE FROM users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID).
 						WillReturnError(ErrNotFound)
 				},
@@ -334,8 +434,587 @@ func TestRemoveUserProduct(t *testing.T) {
 
 			test.payload.sqlMock(mock)
 
-			err = NewCartProduct(db).RemoveUserProduct(userProductOne.UserID, userProductOne.ProductUUID)
+			_, err = NewCartProduct(db).RemoveUserProduct(userProductOne.UserID, userProductOne.ProductUUID, AnyCartVersion)
 			assert.Equal(t, err, test.expected.err)
 		})
 	}
 }
+
+func TestAddUserProducts(t *testing.T) {
+	type expected struct {
+		err error
+	}
+	type payload struct {
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Add user products with success",
+			expected: expected{
+				err: nil,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectBegin()
+					mock.ExpectExec("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID, userProductOne.Amount).
+						WillReturnResult(sqlmock.NewResult(1, 1))
+					mock.ExpectCommit()
+				},
+			},
+		},
+		{
+			name: "Add user products begin with failed",
+			expected: expected{
+				err: ErrNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectBegin().WillReturnError(ErrNotFound)
+				},
+			},
+		},
+		{
+			name: "Add user products with failed",
+			expected: expected{
+				err: ErrNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectBegin()
+					mock.ExpectExec("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID, userProductOne.Amount).
+						WillReturnError(ErrNotFound)
+					mock.ExpectRollback()
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			err = NewCartProduct(db).AddUserProducts(userProductOne.UserID, []entity.UserProduct{userProductOne})
+			assert.Equal(t, err, test.expected.err)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetCartVersion(t *testing.T) {
+	type expected struct {
+		version int64
+		err     error
+	}
+	type payload struct {
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Get cart version with success",
+			expected: expected{
+				version: 3,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows([]string{"version"}).AddRow(3)
+					mock.ExpectQuery("SELECT version FROM users_cart_version").WithArgs(userUUID).WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "Get cart version of unchanged cart with success",
+			expected: expected{
+				version: 0,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery("SELECT version FROM users_cart_version").WithArgs(userUUID).WillReturnError(sql.ErrNoRows)
+				},
+			},
+		},
+		{
+			name: "Get cart version with failed",
+			expected: expected{
+				err: ErrNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery("SELECT version FROM users_cart_version").WithArgs(userUUID).WillReturnError(ErrNotFound)
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			version, err := NewCartProduct(db).GetCartVersion(userUUID)
+			assert.Equal(t, test.expected.version, version)
+			assert.Equal(t, test.expected.err, err)
+		})
+	}
+}
+
+func TestIncrCartVersion(t *testing.T) {
+	type expected struct {
+		version int64
+		err     error
+	}
+	type payload struct {
+		expected int64
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Increment any cart version with success",
+			expected: expected{
+				version: 4,
+			},
+			payload: payload{
+				expected: AnyCartVersion,
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows([]string{"version"}).AddRow(4)
+					mock.ExpectQuery("INSERT INTO users_cart_version").WithArgs(userUUID).WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "Increment matched cart version with success",
+			expected: expected{
+				version: 4,
+			},
+			payload: payload{
+				expected: 3,
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows([]string{"version"}).AddRow(4)
+					mock.ExpectQuery("UPDATE users_cart_version").WithArgs(userUUID, 3).WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "Increment stale cart version with failed",
+			expected: expected{
+				err: entity.ErrCartVersionMismatch,
+			},
+			payload: payload{
+				expected: 3,
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery("UPDATE users_cart_version").WithArgs(userUUID, 3).WillReturnError(sql.ErrNoRows)
+				},
+			},
+		},
+		{
+			name: "Increment unchanged cart version with success",
+			expected: expected{
+				version: 1,
+			},
+			payload: payload{
+				expected: 0,
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows([]string{"version"}).AddRow(1)
+					mock.ExpectQuery("INSERT INTO users_cart_version .* DO NOTHING").WithArgs(userUUID).WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "Increment unchanged cart version of changed cart with failed",
+			expected: expected{
+				err: entity.ErrCartVersionMismatch,
+			},
+			payload: payload{
+				expected: 0,
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery("INSERT INTO users_cart_version .* DO NOTHING").WithArgs(userUUID).WillReturnError(sql.ErrNoRows)
+				},
+			},
+		},
+		{
+			name: "Increment cart version db error with failed",
+			expected: expected{
+				err: ErrNotFound,
+			},
+			payload: payload{
+				expected: AnyCartVersion,
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery("INSERT INTO users_cart_version").WithArgs(userUUID).WillReturnError(ErrNotFound)
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			version, err := NewCartProduct(db).IncrCartVersion(userUUID, test.payload.expected)
+			assert.Equal(t, test.expected.version, version)
+			assert.Equal(t, test.expected.err, err)
+		})
+	}
+}
+
+func TestUpdateUserProductAmount(t *testing.T) {
+	type expected struct {
+		amount float64
+		err    error
+	}
+	type payload struct {
+		delta   float64
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Update user product amount with success",
+			expected: expected{
+				amount: 2,
+			},
+			payload: payload{
+				delta: 1,
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
+					rows := sqlmock.NewRows([]string{"amount"}).AddRow(2)
+					mock.ExpectQuery("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID, 1.0).
+						WillReturnRows(rows)
+					expectAmountRule(mock, 1, 1)
+					mock.ExpectCommit()
+				},
+			},
+		},
+		{
+			name: "Update user product amount below minimum with failed",
+			expected: expected{
+				amount: 0.3,
+				err:    entity.ErrInvalidQuantity,
+			},
+			payload: payload{
+				delta: -0.2,
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
+					rows := sqlmock.NewRows([]string{"amount"}).AddRow("0.300")
+					mock.ExpectQuery("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID, -0.2).
+						WillReturnRows(rows)
+					expectAmountRule(mock, 0.5, 0.1)
+					mock.ExpectRollback()
+				},
+			},
+		},
+		{
+			name: "Update user product amount to zero with success",
+			expected: expected{
+				amount: 0,
+			},
+			payload: payload{
+				delta: -1,
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
+					rows := sqlmock.NewRows([]string{"amount"}).AddRow(0)
+					mock.ExpectQuery("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID, -1.0).
+						WillReturnRows(rows)
+					mock.ExpectExec("DELETE FROM users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID).
+						WillReturnResult(sqlmock.NewResult(1, 1))
+					mock.ExpectCommit()
+				},
+			},
+		},
+		{
+			name: "Update user product amount below zero with failed",
+			expected: expected{
+				amount: -1,
+				err:    entity.ErrInvalidAmount,
+			},
+			payload: payload{
+				delta: -1,
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
+					rows := sqlmock.NewRows([]string{"amount"}).AddRow(-1)
+					mock.ExpectQuery("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID, -1.0).
+						WillReturnRows(rows)
+					mock.ExpectRollback()
+				},
+			},
+		},
+		{
+			name: "Update user product amount unknown product with failed",
+			expected: expected{
+				err: entity.ErrProductNotFound,
+			},
+			payload: payload{
+				delta: 1,
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
+					mock.ExpectQuery("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID, 1.0).
+						WillReturnError(&pq.Error{Code: foreignKeyViolation})
+					mock.ExpectRollback()
+				},
+			},
+		},
+		{
+			name: "Update user product amount delete error with failed",
+			expected: expected{
+				err: ErrNotFound,
+			},
+			payload: payload{
+				delta: -1,
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
+					rows := sqlmock.NewRows([]string{"amount"}).AddRow(0)
+					mock.ExpectQuery("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID, -1.0).
+						WillReturnRows(rows)
+					mock.ExpectExec("DELETE FROM users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID).
+						WillReturnError(ErrNotFound)
+					mock.ExpectRollback()
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			amount, _, err := NewCartProduct(db).UpdateUserProductAmount(userProductOne.UserID, userProductOne.ProductUUID, test.payload.delta, AnyCartVersion)
+			assert.Equal(t, test.expected.amount, amount)
+			assert.Equal(t, test.expected.err, err)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestReplaceUserProducts(t *testing.T) {
+	type expected struct {
+		err error
+	}
+	type payload struct {
+		prds    []entity.UserProduct
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Replace user products with success",
+			payload: payload{
+				prds: []entity.UserProduct{userProductOne, {ProductUUID: uuid.New(), Amount: 0}},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
+					expectAmountRule(mock, 1, 1)
+					mock.ExpectExec("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID, userProductOne.Amount).
+						WillReturnResult(sqlmock.NewResult(1, 1))
+					mock.ExpectExec("DELETE FROM users_cart").WithArgs(userProductOne.UserID, pq.Array([]string{userProductOne.ProductUUID.String()})).
+						WillReturnResult(sqlmock.NewResult(1, 1))
+					mock.ExpectCommit()
+				},
+			},
+		},
+		{
+			name: "Replace user products with empty list with success",
+			payload: payload{
+				prds: []entity.UserProduct{},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
+					mock.ExpectExec("DELETE FROM users_cart").WithArgs(userProductOne.UserID, pq.Array([]string{})).
+						WillReturnResult(sqlmock.NewResult(1, 2))
+					mock.ExpectCommit()
+				},
+			},
+		},
+		{
+			name: "Replace user products unknown product with failed",
+			expected: expected{
+				err: entity.ErrProductNotFound,
+			},
+			payload: payload{
+				prds: []entity.UserProduct{userProductOne},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
+					expectAmountRule(mock, 1, 1)
+					mock.ExpectExec("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID, userProductOne.Amount).
+						WillReturnError(&pq.Error{Code: foreignKeyViolation})
+					mock.ExpectRollback()
+				},
+			},
+		},
+		{
+			name: "Replace user products delete error with failed",
+			expected: expected{
+				err: ErrNotFound,
+			},
+			payload: payload{
+				prds: []entity.UserProduct{userProductOne},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectCartVersion(mock)
+					expectAmountRule(mock, 1, 1)
+					mock.ExpectExec("INSERT INTO users_cart").WithArgs(userProductOne.UserID, userProductOne.ProductUUID, userProductOne.Amount).
+						WillReturnResult(sqlmock.NewResult(1, 1))
+					mock.ExpectExec("DELETE FROM users_cart").WillReturnError(ErrNotFound)
+					mock.ExpectRollback()
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			_, err = NewCartProduct(db).ReplaceUserProducts(userProductOne.UserID, test.payload.prds, AnyCartVersion)
+			assert.Equal(t, test.expected.err, err)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCartMutationVersion(t *testing.T) {
+	type expected struct {
+		version int64
+		err     error
+	}
+	type payload struct {
+		expected int64
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Mutate cart of matched version with success",
+			expected: expected{
+				version: 4,
+			},
+			payload: payload{
+				expected: 3,
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectBegin()
+					rows := sqlmock.NewRows([]string{"version"}).AddRow(4)
+					mock.ExpectQuery("UPDATE users_cart_version").WithArgs(userUUID, 3).WillReturnRows(rows)
+					mock.ExpectExec("DELETE FROM users_cart").WithArgs(userUUID, userProductOne.ProductUUID).
+						WillReturnResult(sqlmock.NewResult(1, 1))
+					mock.ExpectCommit()
+				},
+			},
+		},
+		{
+			name: "Mutate cart of stale version with failed",
+			expected: expected{
+				err: entity.ErrCartVersionMismatch,
+			},
+			payload: payload{
+				expected: 3,
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectBegin()
+					mock.ExpectQuery("UPDATE users_cart_version").WithArgs(userUUID, 3).WillReturnError(sql.ErrNoRows)
+					mock.ExpectRollback()
+				},
+			},
+		},
+		{
+			name: "Failed mutation keeps cart version with failed",
+			expected: expected{
+				err: ErrNotFound,
+			},
+			payload: payload{
+				expected: 3,
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectBegin()
+					rows := sqlmock.NewRows([]string{"version"}).AddRow(4)
+					mock.ExpectQuery("UPDATE users_cart_version").WithArgs(userUUID, 3).WillReturnRows(rows)
+					mock.ExpectExec("DELETE FROM users_cart").WithArgs(userUUID, userProductOne.ProductUUID).
+						WillReturnError(ErrNotFound)
+					mock.ExpectRollback()
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			version, err := NewCartProduct(db).RemoveUserProduct(userUUID, userProductOne.ProductUUID, test.payload.expected)
+			assert.Equal(t, test.expected.version, version)
+			assert.Equal(t, test.expected.err, err)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
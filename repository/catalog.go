@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+//go:generate mockgen -destination=mock/catalog.go -package=repomock github.com/mshto/fruit-store/repository Catalog
+
+// Catalog interface
+type Catalog interface {
+	GetCategories() ([]entity.Category, error)
+	GetCategory(categoryUUID uuid.UUID) (*entity.Category, error)
+	CreateCategory(category *entity.Category) error
+	DeleteCategory(categoryUUID uuid.UUID) error
+
+	SetProductCategory(productUUID uuid.UUID, categoryUUID *uuid.UUID) error
+	SetProductTags(productUUID uuid.UUID, tags []string) error
+	SetProductStock(productUUID uuid.UUID, stock *float64) error
+}
+
+// NewCatalog generate new catalog repo
+func NewCatalog(db *sql.DB) Catalog {
+	return &catalogImpl{
+		db: db,
+	}
+}
+
+type catalogImpl struct {
+	db *sql.DB
+}
+
+var (
+	getCategories  = `SELECT id, parent_id, slug, name, path, created_at FROM categories ORDER BY path`
+	getCategory    = `SELECT id, parent_id, slug, name, path, created_at FROM categories WHERE id=$1`
+	createCategory = `INSERT INTO categories (parent_id, slug, name, path) VALUES ($1, $2, $3, $4) RETURNING id, created_at`
+	deleteCategory = `DELETE FROM categories WHERE id=$1`
+
+	setProductCategory = `UPDATE products SET category_id=$2 WHERE id=$1`
+	setProductStock    = `UPDATE products SET stock=$2 WHERE id=$1`
+	isProductExist     = `SELECT exists (SELECT id FROM products WHERE id=$1)`
+	deleteProductTags  = `DELETE FROM products_tags WHERE product_id=$1`
+	createTags         = `INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING`
+	createProductTags  = `INSERT INTO products_tags (product_id, tag_id) SELECT $1, id FROM tags WHERE name = ANY($2)`
+)
+
+// GetCategories get all categories ordered by path, so parents go before their children
+func (cti *catalogImpl) GetCategories() ([]entity.Category, error) {
+	categories := []entity.Category{}
+
+	rows, err := cti.db.Query(getCategories)
+	if err != nil {
+		return categories, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return categories, err
+		}
+		categories = append(categories, *category)
+	}
+	return categories, rows.Err()
+}
+
+// GetCategory get category by id
+func (cti *catalogImpl) GetCategory(categoryUUID uuid.UUID) (*entity.Category, error) {
+	category, err := scanCategory(cti.db.QueryRow(getCategory, categoryUUID))
+	if err == sql.ErrNoRows {
+		return category, entity.ErrCategoryNotFound
+	}
+	return category, err
+}
+
+// CreateCategory store category under its parent and set id, path and creation time of category
+func (cti *catalogImpl) CreateCategory(category *entity.Category) error {
+	category.Path = category.Slug
+	if category.ParentID != nil {
+		parent, err := cti.GetCategory(*category.ParentID)
+		if err != nil {
+			return err
+		}
+		category.Path = parent.Path + "/" + category.Slug
+	}
+
+	err := cti.db.QueryRow(createCategory, category.ParentID, category.Slug, category.Name, category.Path).
+		Scan(&category.ID, &category.CreatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return entity.ErrCategoryAlreadyExist
+	}
+	return err
+}
+
+// DeleteCategory delete category without subcategories, its products are left without category
+func (cti *catalogImpl) DeleteCategory(categoryUUID uuid.UUID) error {
+	res, err := cti.db.Exec(deleteCategory, categoryUUID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == foreignKeyViolation {
+		return entity.ErrCategoryNotEmpty
+	}
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrCategoryNotFound)
+}
+
+// SetProductCategory move product to category, nil category removes product from its category
+func (cti *catalogImpl) SetProductCategory(productUUID uuid.UUID, categoryUUID *uuid.UUID) error {
+	res, err := cti.db.Exec(setProductCategory, productUUID, categoryUUID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == foreignKeyViolation {
+		return entity.ErrCategoryNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrProductNotFound)
+}
+
+// SetProductStock set stock of product in the unit of product, nil stock isn't tracked
+func (cti *catalogImpl) SetProductStock(productUUID uuid.UUID, stock *float64) error {
+	res, err := cti.db.Exec(setProductStock, productUUID, stock)
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrProductNotFound)
+}
+
+// SetProductTags replace tags of product in one transaction, unknown tags are created
+func (cti *catalogImpl) SetProductTags(productUUID uuid.UUID, tags []string) error {
+	tx, err := cti.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var exists bool
+	err = tx.QueryRow(isProductExist, productUUID).Scan(&exists)
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		return err
+	}
+	if !exists {
+		_ = tx.Rollback() // nolint
+		return entity.ErrProductNotFound
+	}
+
+	_, err = tx.Exec(deleteProductTags, productUUID)
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		return err
+	}
+
+	if len(tags) > 0 {
+		_, err = tx.Exec(createTags, pq.Array(tags))
+		if err != nil {
+			_ = tx.Rollback() // nolint
+			return err
+		}
+		_, err = tx.Exec(createProductTags, productUUID, pq.Array(tags))
+		if err != nil {
+			_ = tx.Rollback() // nolint
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func scanCategory(row rowScanner) (*entity.Category, error) {
+	category := &entity.Category{}
+	err := row.Scan(&category.ID, &category.ParentID, &category.Slug, &category.Name, &category.Path, &category.CreatedAt)
+	return category, err
+}
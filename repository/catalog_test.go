@@ -0,0 +1,336 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+var categoryColumns = []string{"id", "parent_id", "slug", "name", "path", "created_at"}
+
+func TestGetCategories(t *testing.T) {
+	parentUUID := uuid.New()
+	childUUID := uuid.New()
+	createdAt := time.Date(2020, 11, 29, 0, 0, 0, 0, time.UTC)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows(categoryColumns).
+		AddRow(parentUUID, nil, "tropical", "Tropical", "tropical", createdAt).
+		AddRow(childUUID, parentUUID.String(), "exotic", "Exotic", "tropical/exotic", createdAt)
+	mock.ExpectQuery("SELECT id, parent_id, slug, name, path, created_at FROM categories ORDER BY path").WillReturnRows(rows)
+
+	categories, err := NewCatalog(db).GetCategories()
+	assert.Nil(t, err)
+	assert.Equal(t, []entity.Category{
+		{ID: parentUUID, Slug: "tropical", Name: "Tropical", Path: "tropical", CreatedAt: createdAt},
+		{ID: childUUID, ParentID: &parentUUID, Slug: "exotic", Name: "Exotic", Path: "tropical/exotic", CreatedAt: createdAt},
+	}, categories)
+}
+
+func TestCreateCategory(t *testing.T) {
+	type expected struct {
+		path string
+		err  error
+	}
+	type payload struct {
+		category *entity.Category
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}
+
+	parentUUID := uuid.New()
+	categoryUUID := uuid.New()
+	createdAt := time.Date(2020, 11, 29, 0, 0, 0, 0, time.UTC)
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Create root category with success",
+			expected: expected{
+				path: "berries",
+			},
+			payload: payload{
+				category: &entity.Category{Slug: "berries", Name: "Berries"},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows([]string{"id", "created_at"}).AddRow(categoryUUID, createdAt)
+					mock.ExpectQuery("INSERT INTO categories").WithArgs(nil, "berries", "Berries", "berries").WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "Create subcategory with success",
+			expected: expected{
+				path: "tropical/exotic",
+			},
+			payload: payload{
+				category: &entity.Category{ParentID: &parentUUID, Slug: "exotic", Name: "Exotic"},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows(categoryColumns).AddRow(parentUUID, nil, "tropical", "Tropical", "tropical", createdAt)
+					mock.ExpectQuery("SELECT id, parent_id, slug, name, path, created_at FROM categories WHERE id").WithArgs(parentUUID).WillReturnRows(rows)
+
+					rows = sqlmock.NewRows([]string{"id", "created_at"}).AddRow(categoryUUID, createdAt)
+					mock.ExpectQuery("INSERT INTO categories").WithArgs(&parentUUID, "exotic", "Exotic", "tropical/exotic").WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "Create category with unknown parent",
+			expected: expected{
+				err: entity.ErrCategoryNotFound,
+			},
+			payload: payload{
+				category: &entity.Category{ParentID: &parentUUID, Slug: "exotic", Name: "Exotic"},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery("SELECT id, parent_id, slug, name, path, created_at FROM categories WHERE id").WithArgs(parentUUID).
+						WillReturnRows(sqlmock.NewRows(categoryColumns))
+				},
+			},
+		},
+		{
+			name: "Create category which already exists",
+			expected: expected{
+				path: "berries",
+				err:  entity.ErrCategoryAlreadyExist,
+			},
+			payload: payload{
+				category: &entity.Category{Slug: "berries", Name: "Berries"},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery("INSERT INTO categories").WillReturnError(&pq.Error{Code: uniqueViolation})
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			err = NewCatalog(db).CreateCategory(test.payload.category)
+			assert.Equal(t, test.expected.err, err)
+			if test.expected.err == nil {
+				assert.Equal(t, test.expected.path, test.payload.category.Path)
+				assert.Equal(t, categoryUUID, test.payload.category.ID)
+			}
+		})
+	}
+}
+
+func TestDeleteCategory(t *testing.T) {
+	categoryUUID := uuid.New()
+
+	tc := []struct {
+		name     string
+		expected error
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "Delete category with success",
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM categories").WithArgs(categoryUUID).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "Delete unknown category",
+			expected: entity.ErrCategoryNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM categories").WithArgs(categoryUUID).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name:     "Delete category with subcategories",
+			expected: entity.ErrCategoryNotEmpty,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM categories").WithArgs(categoryUUID).WillReturnError(&pq.Error{Code: foreignKeyViolation})
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			err = NewCatalog(db).DeleteCategory(categoryUUID)
+			assert.Equal(t, test.expected, err)
+		})
+	}
+}
+
+func TestSetProductCategory(t *testing.T) {
+	tc := []struct {
+		name     string
+		expected error
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "Set product category with success",
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE products SET category_id").WithArgs(productOne.ID, &categoryUUID).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "Set category of unknown product",
+			expected: entity.ErrProductNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE products SET category_id").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name:     "Set unknown product category",
+			expected: entity.ErrCategoryNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE products SET category_id").WillReturnError(&pq.Error{Code: foreignKeyViolation})
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			err = NewCatalog(db).SetProductCategory(productOne.ID, &categoryUUID)
+			assert.Equal(t, test.expected, err)
+		})
+	}
+}
+
+func TestSetProductTags(t *testing.T) {
+	tags := []string{"local", "organic"}
+
+	tc := []struct {
+		name     string
+		expected error
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "Set product tags with success",
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT exists").WithArgs(productOne.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectExec("DELETE FROM products_tags").WithArgs(productOne.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO tags").WithArgs(pq.Array(tags)).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO products_tags").WithArgs(productOne.ID, pq.Array(tags)).WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:     "Set tags of unknown product",
+			expected: entity.ErrProductNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT exists").WithArgs(productOne.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:     "Set product tags with db error",
+			expected: ErrNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT exists").WithArgs(productOne.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectExec("DELETE FROM products_tags").WillReturnError(ErrNotFound)
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			err = NewCatalog(db).SetProductTags(productOne.ID, tags)
+			assert.Equal(t, test.expected, err)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSetProductStock(t *testing.T) {
+	stock := 12.5
+
+	tc := []struct {
+		name     string
+		expected error
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "Set product stock with success",
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE products SET stock").WithArgs(productOne.ID, &stock).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "Set stock of unknown product",
+			expected: entity.ErrProductNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE products SET stock").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			err = NewCatalog(db).SetProductStock(productOne.ID, &stock)
+			assert.Equal(t, test.expected, err)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+//go:generate mockgen -destination=mock/currency.go -package=repomock github.com/mshto/fruit-store/repository Currencies
+
+// Currencies interface
+type Currencies interface {
+	GetExchangeRates() ([]entity.ExchangeRate, error)
+	SetExchangeRate(rate *entity.ExchangeRate) error
+	DeleteExchangeRate(currency string) error
+	SetProductCurrency(productUUID uuid.UUID, currency *string) error
+}
+
+// NewCurrencies generate new currencies repo
+func NewCurrencies(db *sql.DB) Currencies {
+	return &currenciesImpl{
+		db: db,
+	}
+}
+
+type currenciesImpl struct {
+	db *sql.DB
+}
+
+var (
+	getExchangeRates = `SELECT currency, rate, updated_at FROM exchange_rates ORDER BY currency`
+	setExchangeRate  = `INSERT INTO exchange_rates (currency, rate) VALUES ($1, $2) ` +
+		`ON CONFLICT (currency) DO UPDATE SET rate=$2, updated_at=now() RETURNING updated_at`
+	deleteExchangeRate = `DELETE FROM exchange_rates WHERE currency=$1`
+	setProductCurrency = `UPDATE products SET currency=$2 WHERE id=$1`
+)
+
+// GetExchangeRates get exchange rates of all currencies ordered by currency
+func (cri *currenciesImpl) GetExchangeRates() ([]entity.ExchangeRate, error) {
+	rates := []entity.ExchangeRate{}
+
+	rows, err := cri.db.Query(getExchangeRates)
+	if err != nil {
+		return rates, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rate := entity.ExchangeRate{}
+		err := rows.Scan(&rate.Currency, &rate.Rate, &rate.UpdatedAt)
+		if err != nil {
+			return rates, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
+
+// SetExchangeRate create or update exchange rate of currency and set its update time
+func (cri *currenciesImpl) SetExchangeRate(rate *entity.ExchangeRate) error {
+	return cri.db.QueryRow(setExchangeRate, rate.Currency, rate.Rate).Scan(&rate.UpdatedAt)
+}
+
+// DeleteExchangeRate delete exchange rate of currency no product is priced in
+func (cri *currenciesImpl) DeleteExchangeRate(currency string) error {
+	res, err := cri.db.Exec(deleteExchangeRate, currency)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == foreignKeyViolation {
+		return entity.ErrExchangeRateInUse
+	}
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrExchangeRateNotFound)
+}
+
+// SetProductCurrency set currency price of product is in, currency must have exchange rate,
+// nil currency is the base currency
+func (cri *currenciesImpl) SetProductCurrency(productUUID uuid.UUID, currency *string) error {
+	res, err := cri.db.Exec(setProductCurrency, productUUID, currency)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == foreignKeyViolation {
+		return entity.ErrExchangeRateNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrProductNotFound)
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+var rateUpdatedAt = time.Date(2020, 12, 3, 10, 0, 0, 0, time.UTC)
+
+func TestGetExchangeRates(t *testing.T) {
+	type expected struct {
+		rates []entity.ExchangeRate
+		err   error
+	}
+
+	tc := []struct {
+		name     string
+		expected expected
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "Get exchange rates with success",
+			expected: expected{rates: []entity.ExchangeRate{
+				{Currency: "EUR", Rate: 0.84, UpdatedAt: rateUpdatedAt},
+				{Currency: "PLN", Rate: 3.73, UpdatedAt: rateUpdatedAt},
+			}},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"currency", "rate", "updated_at"}).
+					AddRow("EUR", 0.84, rateUpdatedAt).
+					AddRow("PLN", 3.73, rateUpdatedAt)
+				mock.ExpectQuery("SELECT currency, rate, updated_at FROM exchange_rates").WillReturnRows(rows)
+			},
+		},
+		{
+			name:     "Get exchange rates with db error",
+			expected: expected{rates: []entity.ExchangeRate{}, err: ErrNotFound},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM exchange_rates").WillReturnError(ErrNotFound)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			rates, err := NewCurrencies(db).GetExchangeRates()
+			assert.Equal(t, test.expected.err, err)
+			assert.Equal(t, test.expected.rates, rates)
+		})
+	}
+}
+
+func TestSetExchangeRate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("INSERT INTO exchange_rates").WithArgs("EUR", 0.84).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(rateUpdatedAt))
+
+	rate := &entity.ExchangeRate{Currency: "EUR", Rate: 0.84}
+	err = NewCurrencies(db).SetExchangeRate(rate)
+	assert.Nil(t, err)
+	assert.Equal(t, rateUpdatedAt, rate.UpdatedAt)
+}
+
+func TestDeleteExchangeRate(t *testing.T) {
+	tc := []struct {
+		name     string
+		expected error
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "Delete exchange rate with success",
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM exchange_rates").WithArgs("EUR").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "Delete unknown exchange rate",
+			expected: entity.ErrExchangeRateNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM exchange_rates").WithArgs("EUR").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name:     "Delete exchange rate of currency products are priced in",
+			expected: entity.ErrExchangeRateInUse,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM exchange_rates").WithArgs("EUR").WillReturnError(&pq.Error{Code: foreignKeyViolation})
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			err = NewCurrencies(db).DeleteExchangeRate("EUR")
+			assert.Equal(t, test.expected, err)
+		})
+	}
+}
+
+func TestSetProductCurrency(t *testing.T) {
+	currency := "EUR"
+
+	tc := []struct {
+		name     string
+		expected error
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "Set product currency with success",
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE products SET currency").WithArgs(productOne.ID, &currency).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "Set currency of unknown product",
+			expected: entity.ErrProductNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE products SET currency").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name:     "Set product currency without exchange rate",
+			expected: entity.ErrExchangeRateNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE products SET currency").WillReturnError(&pq.Error{Code: foreignKeyViolation})
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			err = NewCurrencies(db).SetProductCurrency(productOne.ID, &currency)
+			assert.Equal(t, test.expected, err)
+		})
+	}
+}
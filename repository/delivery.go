@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+//go:generate mockgen -destination=mock/delivery.go -package=repomock github.com/mshto/fruit-store/repository Delivery
+
+// Delivery interface
+type Delivery interface {
+	GetAddresses(userUUID uuid.UUID) ([]entity.DeliveryAddress, error)
+	CreateAddress(address *entity.DeliveryAddress) error
+	DeleteAddress(userUUID, addressUUID uuid.UUID) error
+
+	GetCartDelivery(userUUID uuid.UUID) (*entity.CartDelivery, error)
+	SetCartDelivery(userUUID uuid.UUID, delivery *entity.CartDelivery) error
+}
+
+// NewDelivery generate new delivery repo
+func NewDelivery(db *sql.DB) Delivery {
+	return &deliveryImpl{
+		db: db,
+	}
+}
+
+type deliveryImpl struct {
+	db *sql.DB
+}
+
+var (
+	getAddresses = `SELECT id, user_id, line1, line2, city, postal_code, country, created_at FROM delivery_addresses ` +
+		`WHERE user_id=$1 ORDER BY created_at, id`
+	createAddress = `INSERT INTO delivery_addresses (user_id, line1, line2, city, postal_code, country) ` +
+		`VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`
+	deleteAddress   = `DELETE FROM delivery_addresses WHERE id=$1 AND user_id=$2`
+	getCartDelivery = `SELECT d.method, d.slot_id, a.id, COALESCE(a.line1, ''), COALESCE(a.line2, ''), COALESCE(a.city, ''), ` +
+		`COALESCE(a.postal_code, ''), COALESCE(a.country, ''), a.created_at FROM users_cart_delivery d ` +
+		`LEFT JOIN delivery_addresses a ON a.id=d.address_id WHERE d.user_id=$1`
+	// nothing is inserted when address is not of user
+	setCartDelivery = `INSERT INTO users_cart_delivery (user_id, method, address_id) SELECT $1, $2, $3 ` +
+		`WHERE $3::uuid IS NULL OR EXISTS (SELECT 1 FROM delivery_addresses WHERE id=$3 AND user_id=$1) ` +
+		`ON CONFLICT (user_id) DO UPDATE SET method=$2, address_id=$3`
+)
+
+// GetAddresses get delivery addresses of user, oldest first
+func (dli *deliveryImpl) GetAddresses(userUUID uuid.UUID) ([]entity.DeliveryAddress, error) {
+	addresses := []entity.DeliveryAddress{}
+
+	rows, err := dli.db.Query(getAddresses, userUUID)
+	if err != nil {
+		return addresses, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		address := entity.DeliveryAddress{}
+		err := rows.Scan(&address.ID, &address.UserID, &address.Line1, &address.Line2, &address.City,
+			&address.PostalCode, &address.Country, &address.CreatedAt)
+		if err != nil {
+			return addresses, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, rows.Err()
+}
+
+// CreateAddress store delivery address of user and set its id and creation time
+func (dli *deliveryImpl) CreateAddress(address *entity.DeliveryAddress) error {
+	return dli.db.QueryRow(createAddress, address.UserID, address.Line1, address.Line2, address.City, address.PostalCode, address.Country).
+		Scan(&address.ID, &address.CreatedAt)
+}
+
+// DeleteAddress delete delivery address of user, cart delivery to it is removed as well
+func (dli *deliveryImpl) DeleteAddress(userUUID, addressUUID uuid.UUID) error {
+	res, err := dli.db.Exec(deleteAddress, addressUUID, userUUID)
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrAddressNotFound)
+}
+
+// GetCartDelivery get delivery of user cart with its address and reserved slot
+func (dli *deliveryImpl) GetCartDelivery(userUUID uuid.UUID) (*entity.CartDelivery, error) {
+	delivery := &entity.CartDelivery{}
+	address := entity.DeliveryAddress{UserID: userUUID}
+	var createdAt pq.NullTime
+
+	err := dli.db.QueryRow(getCartDelivery, userUUID).Scan(&delivery.Method, &delivery.SlotID, &address.ID, &address.Line1, &address.Line2,
+		&address.City, &address.PostalCode, &address.Country, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrDeliveryNotSelected
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if address.ID != uuid.Nil {
+		address.CreatedAt = createdAt.Time
+		delivery.AddressID = &address.ID
+		delivery.Address = &address
+	}
+	return delivery, nil
+}
+
+// SetCartDelivery set delivery of user cart, address must be of user
+func (dli *deliveryImpl) SetCartDelivery(userUUID uuid.UUID, delivery *entity.CartDelivery) error {
+	res, err := dli.db.Exec(setCartDelivery, userUUID, delivery.Method, delivery.AddressID)
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrAddressNotFound)
+}
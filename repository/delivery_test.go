@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+var (
+	addressUUID      = uuid.MustParse("3b1f0a6e-3540-11eb-adc1-0242ac120002")
+	addressCreatedAt = time.Date(2020, 12, 4, 10, 0, 0, 0, time.UTC)
+	addressOne       = entity.DeliveryAddress{
+		ID:        addressUUID,
+		UserID:    userUUID,
+		Address:   entity.Address{Line1: "Khreshchatyk 1", City: "Kyiv", PostalCode: "01001", Country: "UA"},
+		CreatedAt: addressCreatedAt,
+	}
+)
+
+func TestGetAddresses(t *testing.T) {
+	type expected struct {
+		addresses []entity.DeliveryAddress
+		err       error
+	}
+
+	tc := []struct {
+		name     string
+		expected expected
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name:     "Get addresses with success",
+			expected: expected{addresses: []entity.DeliveryAddress{addressOne}},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "user_id", "line1", "line2", "city", "postal_code", "country", "created_at"}).
+					AddRow(addressUUID, userUUID, "Khreshchatyk 1", "", "Kyiv", "01001", "UA", addressCreatedAt)
+				mock.ExpectQuery("FROM delivery_addresses").WithArgs(userUUID).WillReturnRows(rows)
+			},
+		},
+		{
+			name:     "Get addresses with db error",
+			expected: expected{addresses: []entity.DeliveryAddress{}, err: ErrNotFound},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM delivery_addresses").WillReturnError(ErrNotFound)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			addresses, err := NewDelivery(db).GetAddresses(userUUID)
+			assert.Equal(t, test.expected.err, err)
+			assert.Equal(t, test.expected.addresses, addresses)
+		})
+	}
+}
+
+func TestCreateAddress(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("INSERT INTO delivery_addresses").WithArgs(userUUID, "Khreshchatyk 1", "", "Kyiv", "01001", "UA").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(addressUUID, addressCreatedAt))
+
+	address := &entity.DeliveryAddress{UserID: userUUID, Address: addressOne.Address}
+	err = NewDelivery(db).CreateAddress(address)
+	assert.Nil(t, err)
+	assert.Equal(t, addressOne, *address)
+}
+
+func TestDeleteAddress(t *testing.T) {
+	tc := []struct {
+		name     string
+		expected error
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "Delete address with success",
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM delivery_addresses").WithArgs(addressUUID, userUUID).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "Delete address of other user",
+			expected: entity.ErrAddressNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM delivery_addresses").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			err = NewDelivery(db).DeleteAddress(userUUID, addressUUID)
+			assert.Equal(t, test.expected, err)
+		})
+	}
+}
+
+func TestGetCartDelivery(t *testing.T) {
+	type expected struct {
+		delivery *entity.CartDelivery
+		err      error
+	}
+	columns := []string{"method", "slot_id", "id", "line1", "line2", "city", "postal_code", "country", "created_at"}
+
+	tc := []struct {
+		name     string
+		expected expected
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name:     "Get courier delivery with success",
+			expected: expected{delivery: &entity.CartDelivery{Method: "courier", AddressID: &addressOne.ID, Address: &addressOne}},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).AddRow("courier", nil, addressUUID, "Khreshchatyk 1", "", "Kyiv", "01001", "UA", addressCreatedAt)
+				mock.ExpectQuery("FROM users_cart_delivery").WithArgs(userUUID).WillReturnRows(rows)
+			},
+		},
+		{
+			name:     "Get pickup delivery with success",
+			expected: expected{delivery: &entity.CartDelivery{Method: "pickup", SlotID: &slotUUID}},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).AddRow("pickup", slotUUID.String(), nil, "", "", "", "", "", nil)
+				mock.ExpectQuery("FROM users_cart_delivery").WithArgs(userUUID).WillReturnRows(rows)
+			},
+		},
+		{
+			name:     "Get not selected delivery",
+			expected: expected{err: entity.ErrDeliveryNotSelected},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM users_cart_delivery").WithArgs(userUUID).WillReturnRows(sqlmock.NewRows(columns))
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			delivery, err := NewDelivery(db).GetCartDelivery(userUUID)
+			assert.Equal(t, test.expected.err, err)
+			assert.Equal(t, test.expected.delivery, delivery)
+		})
+	}
+}
+
+func TestSetCartDelivery(t *testing.T) {
+	tc := []struct {
+		name     string
+		expected error
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "Set cart delivery with success",
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO users_cart_delivery").WithArgs(userUUID, "courier", &addressUUID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "Set cart delivery to address of other user",
+			expected: entity.ErrAddressNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO users_cart_delivery").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			err = NewDelivery(db).SetCartDelivery(userUUID, &entity.CartDelivery{Method: "courier", AddressID: &addressUUID})
+			assert.Equal(t, test.expected, err)
+		})
+	}
+}
@@ -28,7 +28,7 @@ type discountImpl struct {
 }
 
 var (
-	getDiscount      = `SELECT id, rule, elements, discount FROM discount WHERE id=$1`
+	getDiscount      = `SELECT id, rule, elements, discount, category FROM discount WHERE id=$1`
 	deleteDiscount   = `DELETE FROM discount WHERE id=$1`
 	validateDiscount = "SELECT exists (SELECT id FROM discount WHERE id=$1)"
 )
@@ -51,7 +51,7 @@ func (dsi *discountImpl) GetDiscount(discountID string) (config.GeneralSale, err
 		return sale, ErrNotFound
 	}
 
-	err = dsi.db.QueryRow(getDiscount, discountID).Scan(&sale.ID, &sale.Rule, &skills, &sale.Discount)
+	err = dsi.db.QueryRow(getDiscount, discountID).Scan(&sale.ID, &sale.Rule, &skills, &sale.Discount, &sale.Category)
 	if err != nil {
 		return sale, err
 	}
@@ -11,7 +11,7 @@ import (
 var (
 	saleOne = config.GeneralSale{
 		ID: "codeID",
-		Elements: map[string]int{
+		Elements: map[string]float64{
 			"Oranges": 1,
 		},
 		Rule:     "rule",
@@ -45,9 +45,9 @@ func TestGetDiscount(t *testing.T) {
 						AddRow(true)
 					mock.ExpectQuery("SELECT exists").WithArgs(saleOne.ID).WillReturnRows(rows)
 
-					rows = sqlmock.NewRows([]string{"id", "rule", "elements", "discount"}).
-						AddRow(saleOne.ID, saleOne.Rule, []byte(`{"Oranges":1}`), saleOne.Discount)
-					mock.ExpectQuery("SELECT id, rule, elements, discount FROM discount").WithArgs(saleOne.ID).WillReturnRows(rows)
+					rows = sqlmock.NewRows([]string{"id", "rule", "elements", "discount", "category"}).
+						AddRow(saleOne.ID, saleOne.Rule, []byte(`{"Oranges":1}`), saleOne.Discount, saleOne.Category)
+					mock.ExpectQuery("SELECT id, rule, elements, discount, category FROM discount").WithArgs(saleOne.ID).WillReturnRows(rows)
 				},
 			},
 		},
@@ -89,7 +89,7 @@ func TestGetDiscount(t *testing.T) {
 						AddRow(true)
 					mock.ExpectQuery("SELECT exists").WithArgs(saleOne.ID).WillReturnRows(rows)
 
-					mock.ExpectQuery("SELECT id, rule, elements, discount FROM discount").WillReturnError(ErrNotFound)
+					mock.ExpectQuery("SELECT id, rule, elements, discount, category FROM discount").WillReturnError(ErrNotFound)
 				},
 			},
 		},
@@ -0,0 +1,348 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mshto/fruit-store/cache"
+	"github.com/mshto/fruit-store/entity"
+)
+
+var (
+	guestCartPattern        = "%s_cart"
+	guestCartVersionPattern = "%s_cart_version"
+	guestCartPricesPattern  = "%s_cart_prices"
+)
+
+// NewGuestCart generate a new cart for anonymous users, stored in cache
+func NewGuestCart(cache cache.Cache, products Products, ttl time.Duration) Cart {
+	return &guestCartImpl{
+		cache:    cache,
+		products: products,
+		ttl:      ttl,
+	}
+}
+
+type guestCartImpl struct {
+	cache    cache.Cache
+	products Products
+	ttl      time.Duration
+}
+
+// guestCartRetries attempts of guest cart change which matches any version before it's rejected as concurrent
+const guestCartRetries = 5
+
+// GetUserProducts get guest products
+func (gci *guestCartImpl) GetUserProducts(guestUUID uuid.UUID) ([]entity.GetUserProduct, error) {
+	products := []entity.GetUserProduct{}
+
+	snapshot, err := gci.getSnapshot(guestUUID)
+	if err != nil || len(snapshot.amounts) == 0 {
+		return products, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(snapshot.amounts))
+	for id := range snapshot.amounts {
+		ids = append(ids, id)
+	}
+
+	prds, err := gci.products.GetByIDs(ids)
+	if err != nil {
+		return products, err
+	}
+
+	for _, prd := range prds {
+		product := entity.GetUserProduct{
+			ProductUUID: prd.ID,
+			Name:        prd.Name,
+			Price:       prd.Price,
+			Currency:    prd.Currency,
+			Unit:        prd.Unit,
+			Amount:      snapshot.amounts[prd.ID],
+			Category:    prd.Category,
+		}
+		if addedPrice, ok := snapshot.prices[prd.ID]; ok {
+			product.SetAddedPrice(addedPrice)
+		}
+		products = append(products, product)
+	}
+	return products, nil
+}
+
+// CreateUserProducts set amount of guest product, it must fit minimum and step of product
+func (gci *guestCartImpl) CreateUserProducts(guestUUID uuid.UUID, prd entity.UserProduct, expected int64) (int64, error) {
+	product, err := gci.getProduct(prd.ProductUUID)
+	if err != nil {
+		return 0, err
+	}
+	err = product.CheckAmount(prd.Amount)
+	if err != nil {
+		return 0, err
+	}
+
+	return gci.update(guestUUID, expected, func(amounts map[uuid.UUID]float64) ([]entity.Product, error) {
+		amounts[prd.ProductUUID] = prd.Amount
+		return []entity.Product{product}, nil
+	})
+}
+
+// CreateUserProduct add one step of guest product, or its minimum amount when product isn't in the cart yet
+func (gci *guestCartImpl) CreateUserProduct(guestUUID, productUUID uuid.UUID, expected int64) (int64, error) {
+	product, err := gci.getProduct(productUUID)
+	if err != nil {
+		return 0, err
+	}
+
+	return gci.update(guestUUID, expected, func(amounts map[uuid.UUID]float64) ([]entity.Product, error) {
+		if amount, ok := amounts[productUUID]; ok {
+			amounts[productUUID] = entity.RoundAmount(amount + product.Step)
+		} else {
+			amounts[productUUID] = product.MinAmount
+		}
+		return []entity.Product{product}, nil
+	})
+}
+
+// UpdateUserProductAmount change amount of guest product by delta and return a new amount
+func (gci *guestCartImpl) UpdateUserProductAmount(guestUUID, productUUID uuid.UUID, delta float64, expected int64) (float64, int64, error) {
+	product, err := gci.getProduct(productUUID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var amount float64
+	version, err := gci.update(guestUUID, expected, func(amounts map[uuid.UUID]float64) ([]entity.Product, error) {
+		amount = entity.RoundAmount(amounts[productUUID] + delta)
+		switch {
+		case amount < 0:
+			return nil, entity.ErrInvalidAmount
+		case amount == 0:
+			delete(amounts, productUUID)
+		default:
+			err := product.CheckAmount(amount)
+			if err != nil {
+				return nil, err
+			}
+			amounts[productUUID] = amount
+		}
+		return []entity.Product{product}, nil
+	})
+	return amount, version, err
+}
+
+// AddUserProducts add amounts of products to the guest cart
+func (gci *guestCartImpl) AddUserProducts(guestUUID uuid.UUID, prds []entity.UserProduct) error {
+	_, err := gci.update(guestUUID, AnyCartVersion, func(amounts map[uuid.UUID]float64) ([]entity.Product, error) {
+		for _, prd := range prds {
+			amounts[prd.ProductUUID] = entity.RoundAmount(amounts[prd.ProductUUID] + prd.Amount)
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// ReplaceUserProducts replace all guest products, zero amounts are not stored
+func (gci *guestCartImpl) ReplaceUserProducts(guestUUID uuid.UUID, prds []entity.UserProduct, expected int64) (int64, error) {
+	replaced := map[uuid.UUID]float64{}
+	products := []entity.Product{}
+	ids := make([]uuid.UUID, 0, len(prds))
+	for _, prd := range prds {
+		if prd.Amount == 0 {
+			continue
+		}
+		replaced[prd.ProductUUID] = prd.Amount
+		ids = append(ids, prd.ProductUUID)
+	}
+
+	if len(ids) != 0 {
+		var err error
+		products, err = gci.products.GetByIDs(ids)
+		if err != nil {
+			return 0, err
+		}
+		if len(products) != len(replaced) {
+			return 0, entity.ErrProductNotFound
+		}
+		for _, product := range products {
+			err = product.CheckAmount(replaced[product.ID])
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return gci.update(guestUUID, expected, func(amounts map[uuid.UUID]float64) ([]entity.Product, error) {
+		for id := range amounts {
+			delete(amounts, id)
+		}
+		for id, amount := range replaced {
+			amounts[id] = amount
+		}
+		return products, nil
+	})
+}
+
+// RemoveUserProducts remove guest products
+func (gci *guestCartImpl) RemoveUserProducts(guestUUID uuid.UUID) error {
+	for _, pattern := range []string{guestCartPattern, guestCartPricesPattern} {
+		err := gci.cache.Del(fmt.Sprintf(pattern, guestUUID))
+		if err != nil && err != cache.ErrNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveUserProduct remove guest product
+func (gci *guestCartImpl) RemoveUserProduct(guestUUID, productUUID uuid.UUID, expected int64) (int64, error) {
+	return gci.update(guestUUID, expected, func(amounts map[uuid.UUID]float64) ([]entity.Product, error) {
+		delete(amounts, productUUID)
+		return nil, nil
+	})
+}
+
+// GetCartVersion get current version of guest cart
+func (gci *guestCartImpl) GetCartVersion(guestUUID uuid.UUID) (int64, error) {
+	value, err := gci.cache.Get(fmt.Sprintf(guestCartVersionPattern, guestUUID))
+	if err == cache.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// IncrCartVersion increment version of guest cart if it equals to expected one, check and increment are one step
+func (gci *guestCartImpl) IncrCartVersion(guestUUID uuid.UUID, expected int64) (int64, error) {
+	for attempt := 0; attempt < guestCartRetries; attempt++ {
+		version, err := gci.GetCartVersion(guestUUID)
+		if err != nil {
+			return 0, err
+		}
+		if expected != AnyCartVersion && expected != version {
+			return 0, entity.ErrCartVersionMismatch
+		}
+
+		version, err = gci.cache.SetVersioned(fmt.Sprintf(guestCartVersionPattern, guestUUID), version, nil, gci.ttl)
+		if err != cache.ErrVersionChanged {
+			return version, err
+		}
+	}
+	return 0, entity.ErrCartVersionMismatch
+}
+
+func (gci *guestCartImpl) getProduct(productUUID uuid.UUID) (entity.Product, error) {
+	products, err := gci.products.GetByIDs([]uuid.UUID{productUUID})
+	if err != nil {
+		return entity.Product{}, err
+	}
+	if len(products) == 0 {
+		return entity.Product{}, entity.ErrProductNotFound
+	}
+	return products[0], nil
+}
+
+// guestCartSnapshot amounts and prices of guest products read together with cart version
+type guestCartSnapshot struct {
+	amounts map[uuid.UUID]float64
+	prices  map[uuid.UUID]float32
+	version int64
+}
+
+func (gci *guestCartImpl) getSnapshot(guestUUID uuid.UUID) (guestCartSnapshot, error) {
+	snapshot := guestCartSnapshot{
+		amounts: map[uuid.UUID]float64{},
+		prices:  map[uuid.UUID]float32{},
+	}
+
+	values, err := gci.cache.MGet(fmt.Sprintf(guestCartPattern, guestUUID), fmt.Sprintf(guestCartPricesPattern, guestUUID),
+		fmt.Sprintf(guestCartVersionPattern, guestUUID))
+	if err != nil {
+		return snapshot, err
+	}
+
+	if values[0] != "" {
+		err = json.Unmarshal([]byte(values[0]), &snapshot.amounts)
+		if err != nil {
+			return snapshot, err
+		}
+	}
+	if values[1] != "" {
+		err = json.Unmarshal([]byte(values[1]), &snapshot.prices)
+		if err != nil {
+			return snapshot, err
+		}
+	}
+	if values[2] != "" {
+		snapshot.version, err = strconv.ParseInt(values[2], 10, 64)
+	}
+	return snapshot, err
+}
+
+// update apply change to amounts of guest products and store them with prices of added products remembered.
+// Cart is stored only if its version is still the one change was applied to, change which matches any version
+// is retried on concurrent update, so updates are never lost.
+func (gci *guestCartImpl) update(guestUUID uuid.UUID, expected int64, change func(amounts map[uuid.UUID]float64) ([]entity.Product, error)) (int64, error) {
+	for attempt := 0; attempt < guestCartRetries; attempt++ {
+		snapshot, err := gci.getSnapshot(guestUUID)
+		if err != nil {
+			return 0, err
+		}
+		if expected != AnyCartVersion && expected != snapshot.version {
+			return 0, entity.ErrCartVersionMismatch
+		}
+
+		added, err := change(snapshot.amounts)
+		if err != nil {
+			return 0, err
+		}
+
+		values, err := guestCartValues(guestUUID, snapshot, added)
+		if err != nil {
+			return 0, err
+		}
+
+		version, err := gci.cache.SetVersioned(fmt.Sprintf(guestCartVersionPattern, guestUUID), snapshot.version, values, gci.ttl)
+		if err != cache.ErrVersionChanged {
+			return version, err
+		}
+		if expected != AnyCartVersion {
+			return 0, entity.ErrCartVersionMismatch
+		}
+	}
+	return 0, entity.ErrCartVersionMismatch
+}
+
+// guestCartValues serialize amounts and prices of guest products, prices of removed products are dropped
+// and prices of added ones are kept as they were when products were added
+func guestCartValues(guestUUID uuid.UUID, snapshot guestCartSnapshot, added []entity.Product) (map[string]string, error) {
+	for id := range snapshot.prices {
+		if _, ok := snapshot.amounts[id]; !ok {
+			delete(snapshot.prices, id)
+		}
+	}
+	for _, product := range added {
+		_, inCart := snapshot.amounts[product.ID]
+		if _, ok := snapshot.prices[product.ID]; !ok && inCart {
+			snapshot.prices[product.ID] = product.Price
+		}
+	}
+
+	amounts, err := json.Marshal(snapshot.amounts)
+	if err != nil {
+		return nil, err
+	}
+	prices, err := json.Marshal(snapshot.prices)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		fmt.Sprintf(guestCartPattern, guestUUID):       string(amounts),
+		fmt.Sprintf(guestCartPricesPattern, guestUUID): string(prices),
+	}, nil
+}
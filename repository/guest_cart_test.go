@@ -0,0 +1,404 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/cache"
+	"github.com/mshto/fruit-store/entity"
+)
+
+func expectProduct(mock sqlmock.Sqlmock) {
+	rows := addProductRow(sqlmock.NewRows(productColumns), productOne)
+	mock.ExpectQuery("ON r.currency=p.currency WHERE p.id = ANY").WillReturnRows(rows)
+}
+
+func TestGuestCart(t *testing.T) {
+	addedPrice := productOne.Price
+	repriced := productOne
+	repriced.Price = productOne.Price + 1
+
+	type expected struct {
+		products []entity.GetUserProduct
+		isErr    bool
+	}
+	type payload struct {
+		update  func(cart Cart, guestUUID uuid.UUID) error
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Get empty guest cart with success",
+			expected: expected{
+				products: []entity.GetUserProduct{},
+			},
+			payload: payload{
+				update: func(cart Cart, guestUUID uuid.UUID) error {
+					return nil
+				},
+				sqlMock: func(mock sqlmock.Sqlmock) {},
+			},
+		},
+		{
+			name: "Add guest products with success",
+			expected: expected{
+				products: []entity.GetUserProduct{
+					{ProductUUID: productOne.ID, Name: productOne.Name, Price: productOne.Price, Currency: productOne.Currency, Unit: productOne.Unit, Amount: 5, Category: productOne.Category},
+				},
+			},
+			payload: payload{
+				update: func(cart Cart, guestUUID uuid.UUID) error {
+					_, err := cart.CreateUserProduct(guestUUID, productOne.ID, AnyCartVersion)
+					if err != nil {
+						return err
+					}
+					_, err = cart.CreateUserProducts(guestUUID, entity.UserProduct{ProductUUID: productOne.ID, Amount: 3}, AnyCartVersion)
+					if err != nil {
+						return err
+					}
+					return cart.AddUserProducts(guestUUID, []entity.UserProduct{{ProductUUID: productOne.ID, Amount: 2}})
+				},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectProduct(mock)
+					expectProduct(mock)
+					expectProduct(mock)
+				},
+			},
+		},
+		{
+			name: "Decrement guest product to zero with success",
+			expected: expected{
+				products: []entity.GetUserProduct{},
+			},
+			payload: payload{
+				update: func(cart Cart, guestUUID uuid.UUID) error {
+					_, err := cart.CreateUserProduct(guestUUID, productOne.ID, AnyCartVersion)
+					if err != nil {
+						return err
+					}
+					amount, _, err := cart.UpdateUserProductAmount(guestUUID, productOne.ID, -0.5, AnyCartVersion)
+					if err != nil || amount != 0 {
+						return fmt.Errorf("unexpected amount: %v, error: %v", amount, err)
+					}
+					_, _, err = cart.UpdateUserProductAmount(guestUUID, productOne.ID, -1, AnyCartVersion)
+					if err != entity.ErrInvalidAmount {
+						return fmt.Errorf("unexpected error: %v", err)
+					}
+					return nil
+				},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectProduct(mock)
+					expectProduct(mock)
+					expectProduct(mock)
+				},
+			},
+		},
+		{
+			name: "Add guest products by steps with success",
+			expected: expected{
+				products: []entity.GetUserProduct{
+					{ProductUUID: productOne.ID, Name: productOne.Name, Price: productOne.Price, Currency: productOne.Currency, Unit: productOne.Unit, Amount: 0.6, Category: productOne.Category},
+				},
+			},
+			payload: payload{
+				update: func(cart Cart, guestUUID uuid.UUID) error {
+					_, err := cart.CreateUserProduct(guestUUID, productOne.ID, AnyCartVersion)
+					if err != nil {
+						return err
+					}
+					amount, _, err := cart.UpdateUserProductAmount(guestUUID, productOne.ID, 0.2, AnyCartVersion)
+					if err != nil || amount != 0.7 {
+						return fmt.Errorf("unexpected amount: %v, error: %v", amount, err)
+					}
+					_, _, err = cart.UpdateUserProductAmount(guestUUID, productOne.ID, -0.1, AnyCartVersion)
+					return err
+				},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectProduct(mock)
+					expectProduct(mock)
+					expectProduct(mock)
+					expectProduct(mock)
+				},
+			},
+		},
+		{
+			name: "Set guest product amount out of steps with failed",
+			expected: expected{
+				products: []entity.GetUserProduct{
+					{ProductUUID: productOne.ID, Name: productOne.Name, Price: productOne.Price, Currency: productOne.Currency, Unit: productOne.Unit, Amount: 0.5, Category: productOne.Category},
+				},
+			},
+			payload: payload{
+				update: func(cart Cart, guestUUID uuid.UUID) error {
+					_, err := cart.CreateUserProduct(guestUUID, productOne.ID, AnyCartVersion)
+					if err != nil {
+						return err
+					}
+					_, err = cart.CreateUserProducts(guestUUID, entity.UserProduct{ProductUUID: productOne.ID, Amount: 0.55}, AnyCartVersion)
+					if err != entity.ErrInvalidQuantity {
+						return fmt.Errorf("unexpected error: %v", err)
+					}
+					_, _, err = cart.UpdateUserProductAmount(guestUUID, productOne.ID, -0.2, AnyCartVersion)
+					if err != entity.ErrInvalidQuantity {
+						return fmt.Errorf("unexpected error: %v", err)
+					}
+					return nil
+				},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectProduct(mock)
+					expectProduct(mock)
+					expectProduct(mock)
+					expectProduct(mock)
+				},
+			},
+		},
+		{
+			name: "Add unknown guest product with failed",
+			expected: expected{
+				products: []entity.GetUserProduct{},
+			},
+			payload: payload{
+				update: func(cart Cart, guestUUID uuid.UUID) error {
+					_, err := cart.CreateUserProduct(guestUUID, productOne.ID, AnyCartVersion)
+					if err != entity.ErrProductNotFound {
+						return fmt.Errorf("unexpected error: %v", err)
+					}
+					return nil
+				},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows(productColumns)
+					mock.ExpectQuery("ON r.currency=p.currency WHERE p.id = ANY").WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "Replace guest products with success",
+			expected: expected{
+				products: []entity.GetUserProduct{
+					{ProductUUID: productOne.ID, Name: productOne.Name, Price: productOne.Price, Currency: productOne.Currency, Unit: productOne.Unit, Amount: 4, Category: productOne.Category},
+				},
+			},
+			payload: payload{
+				update: func(cart Cart, guestUUID uuid.UUID) error {
+					_, err := cart.CreateUserProduct(guestUUID, productOne.ID, AnyCartVersion)
+					if err != nil {
+						return err
+					}
+					_, err = cart.ReplaceUserProducts(guestUUID, []entity.UserProduct{
+						{ProductUUID: productOne.ID, Amount: 4},
+						{ProductUUID: uuid.New(), Amount: 0},
+					}, AnyCartVersion)
+					return err
+				},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectProduct(mock)
+					expectProduct(mock)
+					expectProduct(mock)
+				},
+			},
+		},
+		{
+			name: "Replace guest products with empty list with success",
+			expected: expected{
+				products: []entity.GetUserProduct{},
+			},
+			payload: payload{
+				update: func(cart Cart, guestUUID uuid.UUID) error {
+					_, err := cart.CreateUserProduct(guestUUID, productOne.ID, AnyCartVersion)
+					if err != nil {
+						return err
+					}
+					_, err = cart.ReplaceUserProducts(guestUUID, []entity.UserProduct{}, AnyCartVersion)
+					return err
+				},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectProduct(mock)
+				},
+			},
+		},
+		{
+			name: "Replace unknown guest products with failed",
+			expected: expected{
+				products: []entity.GetUserProduct{},
+			},
+			payload: payload{
+				update: func(cart Cart, guestUUID uuid.UUID) error {
+					_, err := cart.ReplaceUserProducts(guestUUID, []entity.UserProduct{
+						{ProductUUID: productOne.ID, Amount: 1},
+					}, AnyCartVersion)
+					if err != entity.ErrProductNotFound {
+						return fmt.Errorf("unexpected error: %v", err)
+					}
+					return nil
+				},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows(productColumns)
+					mock.ExpectQuery("ON r.currency=p.currency WHERE p.id = ANY").WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "Remove guest products with success",
+			expected: expected{
+				products: []entity.GetUserProduct{},
+			},
+			payload: payload{
+				update: func(cart Cart, guestUUID uuid.UUID) error {
+					_, err := cart.CreateUserProduct(guestUUID, productOne.ID, AnyCartVersion)
+					if err != nil {
+						return err
+					}
+					_, err = cart.RemoveUserProduct(guestUUID, productOne.ID, AnyCartVersion)
+					if err != nil {
+						return err
+					}
+					err = cart.RemoveUserProducts(guestUUID)
+					if err != nil {
+						return err
+					}
+					return cart.RemoveUserProducts(guestUUID)
+				},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectProduct(mock)
+				},
+			},
+		},
+		{
+			name: "Get guest products with changed price with success",
+			expected: expected{
+				products: []entity.GetUserProduct{
+					{ProductUUID: productOne.ID, Name: productOne.Name, Price: repriced.Price, Currency: productOne.Currency, Unit: productOne.Unit, Amount: 0.6, Category: productOne.Category,
+						PriceChanged: true, AddedPrice: &addedPrice},
+				},
+			},
+			payload: payload{
+				update: func(cart Cart, guestUUID uuid.UUID) error {
+					_, err := cart.CreateUserProduct(guestUUID, productOne.ID, AnyCartVersion)
+					if err != nil {
+						return err
+					}
+					_, err = cart.CreateUserProduct(guestUUID, productOne.ID, AnyCartVersion)
+					return err
+				},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectProduct(mock)
+					rows := addProductRow(sqlmock.NewRows(productColumns), repriced)
+					mock.ExpectQuery("ON r.currency=p.currency WHERE p.id = ANY").WillReturnRows(rows)
+					rows = addProductRow(sqlmock.NewRows(productColumns), repriced)
+					mock.ExpectQuery("ON r.currency=p.currency WHERE p.id = ANY").WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "Get guest products db error with failed",
+			expected: expected{
+				products: []entity.GetUserProduct{},
+				isErr:    true,
+			},
+			payload: payload{
+				update: func(cart Cart, guestUUID uuid.UUID) error {
+					_, err := cart.CreateUserProduct(guestUUID, productOne.ID, AnyCartVersion)
+					return err
+				},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					expectProduct(mock)
+					mock.ExpectQuery("ON r.currency=p.currency WHERE p.id = ANY").WillReturnError(ErrNotFound)
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := miniredis.Run()
+			if err != nil {
+				t.Fatal("failed to init miniredis")
+			}
+			defer s.Close()
+
+			redis, err := cache.New(cache.Redis{Address: s.Addr()})
+			if err != nil {
+				t.Fatal("failed to init cache")
+			}
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			guestUUID := uuid.New()
+			cart := NewGuestCart(redis, NewProduct(db), 0)
+
+			err = test.payload.update(cart, guestUUID)
+			assert.Nil(t, err)
+
+			products, err := cart.GetUserProducts(guestUUID)
+			assert.Equal(t, test.expected.products, products)
+			if test.expected.isErr {
+				assert.NotNil(t, err)
+			}
+		})
+	}
+}
+
+func TestGuestCartVersion(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal("failed to init miniredis")
+	}
+	defer s.Close()
+
+	redis, err := cache.New(cache.Redis{Address: s.Addr()})
+	if err != nil {
+		t.Fatal("failed to init cache")
+	}
+
+	guestUUID := uuid.New()
+	cart := NewGuestCart(redis, nil, 0)
+
+	version, err := cart.GetCartVersion(guestUUID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), version)
+
+	version, err = cart.IncrCartVersion(guestUUID, AnyCartVersion)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), version)
+
+	version, err = cart.IncrCartVersion(guestUUID, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), version)
+
+	_, err = cart.IncrCartVersion(guestUUID, 1)
+	assert.Equal(t, entity.ErrCartVersionMismatch, err)
+
+	version, err = cart.RemoveUserProduct(guestUUID, uuid.New(), 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), version)
+
+	_, err = cart.ReplaceUserProducts(guestUUID, []entity.UserProduct{}, 2)
+	assert.Equal(t, entity.ErrCartVersionMismatch, err)
+
+	version, err = cart.ReplaceUserProducts(guestUUID, []entity.UserProduct{}, AnyCartVersion)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), version)
+
+	version, err = cart.GetCartVersion(guestUUID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), version)
+}
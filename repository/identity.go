@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+var (
+	getUserByIdentity = `SELECT u.id, u.username, u.password, u.totp_enabled FROM users u
+		JOIN users_identities i ON i.user_id = u.id WHERE i.issuer=$1 AND i.subject=$2`
+	linkIdentity = `INSERT INTO users_identities (user_id, issuer, subject, email) VALUES ($1, $2, $3, $4)`
+	// the identity is linked only to user whose email is the email of identity
+	linkIdentityByEmail = `INSERT INTO users_identities (user_id, issuer, subject, email)
+		SELECT id, $2, $3, $4 FROM users WHERE id=$1 AND lower(email)=lower($4)`
+	signupWithoutPwd = `INSERT INTO users (username, password) VALUES ($1, '') RETURNING id`
+)
+
+// GetUserByIdentity get user creds by linked identity provider account
+func (aui *authImpl) GetUserByIdentity(issuer, subject string) (*entity.Credentials, error) {
+	var creds entity.Credentials
+	err := aui.db.QueryRow(getUserByIdentity, issuer, subject).Scan(&creds.ID, &creds.Username, &creds.Password, &creds.TOTPEnabled)
+	if err == sql.ErrNoRows {
+		return &creds, entity.ErrUserNotFound
+	}
+	return &creds, err
+}
+
+// LinkIdentity link identity provider account to user whose email is the email of identity,
+// user has at most one account per provider
+func (aui *authImpl) LinkIdentity(userUUID uuid.UUID, identity entity.Identity) error {
+	res, err := aui.db.Exec(linkIdentityByEmail, userUUID, identity.Issuer, identity.Subject, identity.Email)
+	if err != nil {
+		return identityErr(err)
+	}
+	return affectedOrErr(res, entity.ErrIdentityEmailMismatch)
+}
+
+// SignupWithIdentity sign up user without password and link identity provider account in one transaction
+func (aui *authImpl) SignupWithIdentity(creds *entity.Credentials, identity entity.Identity) error {
+	tx, err := aui.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(signupWithoutPwd, creds.Username).Scan(&creds.ID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		err = entity.ErrUserAlreadyExist
+	}
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		return err
+	}
+
+	_, err = tx.Exec(linkIdentity, creds.ID, identity.Issuer, identity.Subject, identity.Email)
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		return identityErr(err)
+	}
+
+	return tx.Commit()
+}
+
+func identityErr(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return entity.ErrIdentityAlreadyLinked
+	}
+	return err
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+var identity = entity.Identity{Issuer: "https://idp.example.com", Subject: "staff-1", Email: "jane@example.com", EmailVerified: true}
+
+func TestGetUserByIdentity(t *testing.T) {
+	type expected struct {
+		cred entity.Credentials
+		err  error
+	}
+	type payload struct {
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "GetUserByIdentity with success",
+			expected: expected{
+				cred: cred,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows([]string{"id", "username", "password", "totp_enabled"}).
+						AddRow(cred.ID, cred.Username, cred.Password, cred.TOTPEnabled)
+					mock.ExpectQuery("SELECT u.id, u.username, u.password, u.totp_enabled FROM users u").
+						WithArgs(identity.Issuer, identity.Subject).WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "GetUserByIdentity ErrNoRows error with failed",
+			expected: expected{
+				err: entity.ErrUserNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery("SELECT u.id, u.username, u.password, u.totp_enabled FROM users u").
+						WithArgs(identity.Issuer, identity.Subject).WillReturnError(sql.ErrNoRows)
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			creds, err := NewAuth(db).GetUserByIdentity(identity.Issuer, identity.Subject)
+			assert.Equal(t, test.expected.cred, *creds)
+			assert.Equal(t, test.expected.err, err)
+		})
+	}
+}
+
+func TestLinkIdentity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO users_identities .* lower\\(email\\)=lower\\(\\$4\\)").WithArgs(userUUID, identity.Issuer, identity.Subject, identity.Email).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO users_identities").WithArgs(userUUID, identity.Issuer, identity.Subject, identity.Email).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO users_identities").WithArgs(userUUID, identity.Issuer, identity.Subject, identity.Email).
+		WillReturnError(&pq.Error{Code: uniqueViolation})
+
+	repo := NewAuth(db)
+	assert.Nil(t, repo.LinkIdentity(userUUID, identity))
+	assert.Equal(t, entity.ErrIdentityEmailMismatch, repo.LinkIdentity(userUUID, identity))
+	assert.Equal(t, entity.ErrIdentityAlreadyLinked, repo.LinkIdentity(userUUID, identity))
+}
+
+func TestSignupWithIdentity(t *testing.T) {
+	type expected struct {
+		err error
+	}
+	type payload struct {
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "SignupWithIdentity with success",
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectBegin()
+					rows := sqlmock.NewRows([]string{"id"}).AddRow(userUUID)
+					mock.ExpectQuery("INSERT INTO users").WithArgs("jane@example.com").WillReturnRows(rows)
+					mock.ExpectExec("INSERT INTO users_identities").WithArgs(userUUID, identity.Issuer, identity.Subject, identity.Email).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectCommit()
+				},
+			},
+		},
+		{
+			name: "SignupWithIdentity duplicate username with failed",
+			expected: expected{
+				err: entity.ErrUserAlreadyExist,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectBegin()
+					mock.ExpectQuery("INSERT INTO users").WithArgs("jane@example.com").WillReturnError(&pq.Error{Code: uniqueViolation})
+					mock.ExpectRollback()
+				},
+			},
+		},
+		{
+			name: "SignupWithIdentity linked identity with failed",
+			expected: expected{
+				err: entity.ErrIdentityAlreadyLinked,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectBegin()
+					rows := sqlmock.NewRows([]string{"id"}).AddRow(userUUID)
+					mock.ExpectQuery("INSERT INTO users").WithArgs("jane@example.com").WillReturnRows(rows)
+					mock.ExpectExec("INSERT INTO users_identities").WithArgs(userUUID, identity.Issuer, identity.Subject, identity.Email).
+						WillReturnError(&pq.Error{Code: uniqueViolation})
+					mock.ExpectRollback()
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			creds := &entity.Credentials{Username: "jane@example.com"}
+			err = NewAuth(db).SignupWithIdentity(creds, identity)
+			assert.Equal(t, test.expected.err, err)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+//go:generate mockgen -destination=mock/media.go -package=repomock github.com/mshto/fruit-store/repository Media
+
+// Media interface
+type Media interface {
+	GetMedia(productUUIDs []uuid.UUID) (map[uuid.UUID][]entity.Media, error)
+	CreateMedia(media *entity.Media) error
+	DeleteMedia(productUUID, mediaUUID uuid.UUID) (*entity.Media, error)
+}
+
+// NewMedia generate new media repo
+func NewMedia(db *sql.DB) Media {
+	return &mediaImpl{
+		db: db,
+	}
+}
+
+type mediaImpl struct {
+	db *sql.DB
+}
+
+var (
+	getMedia = `SELECT id, product_id, key, thumbnail_key, content_type, size, width, height, created_at FROM product_media ` +
+		`WHERE product_id = ANY($1) ORDER BY created_at, id`
+	createMedia = `INSERT INTO product_media (product_id, key, thumbnail_key, content_type, size, width, height) ` +
+		`VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`
+	deleteMedia = `DELETE FROM product_media WHERE id=$1 AND product_id=$2 ` +
+		`RETURNING id, product_id, key, thumbnail_key, content_type, size, width, height, created_at`
+)
+
+// GetMedia get media of products grouped by product, oldest first
+func (mdi *mediaImpl) GetMedia(productUUIDs []uuid.UUID) (map[uuid.UUID][]entity.Media, error) {
+	media := map[uuid.UUID][]entity.Media{}
+
+	strIDs := make([]string, 0, len(productUUIDs))
+	for _, id := range productUUIDs {
+		strIDs = append(strIDs, id.String())
+	}
+
+	rows, err := mdi.db.Query(getMedia, pq.Array(strIDs))
+	if err != nil {
+		return media, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanMedia(rows)
+		if err != nil {
+			return media, err
+		}
+		media[item.ProductID] = append(media[item.ProductID], *item)
+	}
+	return media, rows.Err()
+}
+
+// CreateMedia store media of product and set its id and creation time
+func (mdi *mediaImpl) CreateMedia(media *entity.Media) error {
+	err := mdi.db.QueryRow(createMedia, media.ProductID, media.Key, media.ThumbnailKey, media.ContentType, media.Size, media.Width, media.Height).
+		Scan(&media.ID, &media.CreatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == foreignKeyViolation {
+		return entity.ErrProductNotFound
+	}
+	return err
+}
+
+// DeleteMedia delete media of product, deleted media is returned so its files can be removed
+func (mdi *mediaImpl) DeleteMedia(productUUID, mediaUUID uuid.UUID) (*entity.Media, error) {
+	media, err := scanMedia(mdi.db.QueryRow(deleteMedia, mediaUUID, productUUID))
+	if err == sql.ErrNoRows {
+		return media, entity.ErrMediaNotFound
+	}
+	return media, err
+}
+
+func scanMedia(row rowScanner) (*entity.Media, error) {
+	media := &entity.Media{}
+	err := row.Scan(&media.ID, &media.ProductID, &media.Key, &media.ThumbnailKey, &media.ContentType,
+		&media.Size, &media.Width, &media.Height, &media.CreatedAt)
+	return media, err
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+var (
+	mediaColumns = []string{"id", "product_id", "key", "thumbnail_key", "content_type", "size", "width", "height", "created_at"}
+	mediaUUID    = uuid.MustParse("5a0e2c1e-33a1-11eb-adc1-0242ac120002")
+	mediaOne     = entity.Media{
+		ID:           mediaUUID,
+		ProductID:    productOne.ID,
+		Key:          "products/apple.jpg",
+		ThumbnailKey: "products/apple_thumb.jpg",
+		ContentType:  "image/jpeg",
+		Size:         2048,
+		Width:        640,
+		Height:       480,
+		CreatedAt:    time.Date(2020, 12, 1, 0, 0, 0, 0, time.UTC),
+	}
+)
+
+func addMediaRow(rows *sqlmock.Rows, media entity.Media) *sqlmock.Rows {
+	return rows.AddRow(media.ID, media.ProductID.String(), media.Key, media.ThumbnailKey, media.ContentType,
+		media.Size, media.Width, media.Height, media.CreatedAt)
+}
+
+func TestGetMedia(t *testing.T) {
+	type expected struct {
+		media map[uuid.UUID][]entity.Media
+		err   error
+	}
+
+	mediaTwo := mediaOne
+	mediaTwo.ID = uuid.MustParse("5a0e2c1e-33a1-11eb-adc1-0242ac120003")
+
+	tc := []struct {
+		name     string
+		expected expected
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "Get media with success",
+			expected: expected{
+				media: map[uuid.UUID][]entity.Media{productOne.ID: {mediaOne, mediaTwo}},
+			},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				rows := addMediaRow(addMediaRow(sqlmock.NewRows(mediaColumns), mediaOne), mediaTwo)
+				mock.ExpectQuery("SELECT id, product_id, key, thumbnail_key").WithArgs(pq.Array([]string{productOne.ID.String()})).WillReturnRows(rows)
+			},
+		},
+		{
+			name: "Get media with db error",
+			expected: expected{
+				media: map[uuid.UUID][]entity.Media{},
+				err:   ErrNotFound,
+			},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT id, product_id, key, thumbnail_key").WillReturnError(ErrNotFound)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			media, err := NewMedia(db).GetMedia([]uuid.UUID{productOne.ID})
+			assert.Equal(t, test.expected.err, err)
+			assert.Equal(t, test.expected.media, media)
+		})
+	}
+}
+
+func TestCreateMedia(t *testing.T) {
+	tc := []struct {
+		name     string
+		expected error
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "Create media with success",
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "created_at"}).AddRow(mediaOne.ID, mediaOne.CreatedAt)
+				mock.ExpectQuery("INSERT INTO product_media").
+					WithArgs(productOne.ID, mediaOne.Key, mediaOne.ThumbnailKey, mediaOne.ContentType, mediaOne.Size, mediaOne.Width, mediaOne.Height).
+					WillReturnRows(rows)
+			},
+		},
+		{
+			name:     "Create media of unknown product",
+			expected: entity.ErrProductNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO product_media").WillReturnError(&pq.Error{Code: foreignKeyViolation})
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			media := mediaOne
+			media.ID, media.CreatedAt = uuid.Nil, time.Time{}
+			err = NewMedia(db).CreateMedia(&media)
+			assert.Equal(t, test.expected, err)
+			if test.expected == nil {
+				assert.Equal(t, mediaOne, media)
+			}
+		})
+	}
+}
+
+func TestDeleteMedia(t *testing.T) {
+	type expected struct {
+		media *entity.Media
+		err   error
+	}
+
+	tc := []struct {
+		name     string
+		expected expected
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name:     "Delete media with success",
+			expected: expected{media: &mediaOne},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("DELETE FROM product_media").WithArgs(mediaUUID, productOne.ID).
+					WillReturnRows(addMediaRow(sqlmock.NewRows(mediaColumns), mediaOne))
+			},
+		},
+		{
+			name:     "Delete unknown media",
+			expected: expected{media: &entity.Media{}, err: entity.ErrMediaNotFound},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("DELETE FROM product_media").WithArgs(mediaUUID, productOne.ID).WillReturnRows(sqlmock.NewRows(mediaColumns))
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			media, err := NewMedia(db).DeleteMedia(productOne.ID, mediaUUID)
+			assert.Equal(t, test.expected.err, err)
+			assert.Equal(t, test.expected.media, media)
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mshto/fruit-store/repository (interfaces: APIKeys)
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+	entity "github.com/mshto/fruit-store/entity"
+	reflect "reflect"
+	time "time"
+)
+
+// MockAPIKeys is a mock of APIKeys interface
+type MockAPIKeys struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeysMockRecorder
+}
+
+// MockAPIKeysMockRecorder is the mock recorder for MockAPIKeys
+type MockAPIKeysMockRecorder struct {
+	mock *MockAPIKeys
+}
+
+// NewMockAPIKeys creates a new mock instance
+func NewMockAPIKeys(ctrl *gomock.Controller) *MockAPIKeys {
+	mock := &MockAPIKeys{ctrl: ctrl}
+	mock.recorder = &MockAPIKeysMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAPIKeys) EXPECT() *MockAPIKeysMockRecorder {
+	return m.recorder
+}
+
+// CreateAPIKey mocks base method
+func (m *MockAPIKeys) CreateAPIKey(arg0 *entity.APIKey, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey
+func (mr *MockAPIKeysMockRecorder) CreateAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockAPIKeys)(nil).CreateAPIKey), arg0, arg1)
+}
+
+// CreateServiceAccount mocks base method
+func (m *MockAPIKeys) CreateServiceAccount(arg0 uuid.UUID, arg1 string) (*entity.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateServiceAccount", arg0, arg1)
+	ret0, _ := ret[0].(*entity.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateServiceAccount indicates an expected call of CreateServiceAccount
+func (mr *MockAPIKeysMockRecorder) CreateServiceAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateServiceAccount", reflect.TypeOf((*MockAPIKeys)(nil).CreateServiceAccount), arg0, arg1)
+}
+
+// GetAPIKeyByHash mocks base method
+func (m *MockAPIKeys) GetAPIKeyByHash(arg0 string) (*entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByHash", arg0)
+	ret0, _ := ret[0].(*entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByHash indicates an expected call of GetAPIKeyByHash
+func (mr *MockAPIKeysMockRecorder) GetAPIKeyByHash(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByHash", reflect.TypeOf((*MockAPIKeys)(nil).GetAPIKeyByHash), arg0)
+}
+
+// GetAPIKeys mocks base method
+func (m *MockAPIKeys) GetAPIKeys(arg0 uuid.UUID) ([]entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeys", arg0)
+	ret0, _ := ret[0].([]entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeys indicates an expected call of GetAPIKeys
+func (mr *MockAPIKeysMockRecorder) GetAPIKeys(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeys", reflect.TypeOf((*MockAPIKeys)(nil).GetAPIKeys), arg0)
+}
+
+// GetServiceAccounts mocks base method
+func (m *MockAPIKeys) GetServiceAccounts(arg0 uuid.UUID) ([]entity.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceAccounts", arg0)
+	ret0, _ := ret[0].([]entity.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceAccounts indicates an expected call of GetServiceAccounts
+func (mr *MockAPIKeysMockRecorder) GetServiceAccounts(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceAccounts", reflect.TypeOf((*MockAPIKeys)(nil).GetServiceAccounts), arg0)
+}
+
+// IsServiceAccountOwner mocks base method
+func (m *MockAPIKeys) IsServiceAccountOwner(arg0, arg1 uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsServiceAccountOwner", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsServiceAccountOwner indicates an expected call of IsServiceAccountOwner
+func (mr *MockAPIKeysMockRecorder) IsServiceAccountOwner(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsServiceAccountOwner", reflect.TypeOf((*MockAPIKeys)(nil).IsServiceAccountOwner), arg0, arg1)
+}
+
+// RevokeAPIKey mocks base method
+func (m *MockAPIKeys) RevokeAPIKey(arg0, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey
+func (mr *MockAPIKeysMockRecorder) RevokeAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockAPIKeys)(nil).RevokeAPIKey), arg0, arg1)
+}
+
+// TouchAPIKey mocks base method
+func (m *MockAPIKeys) TouchAPIKey(arg0 uuid.UUID, arg1 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchAPIKey indicates an expected call of TouchAPIKey
+func (mr *MockAPIKeysMockRecorder) TouchAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchAPIKey", reflect.TypeOf((*MockAPIKeys)(nil).TouchAPIKey), arg0, arg1)
+}
@@ -0,0 +1,64 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mshto/fruit-store/repository (interfaces: Audit)
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	entity "github.com/mshto/fruit-store/entity"
+	reflect "reflect"
+)
+
+// MockAudit is a mock of Audit interface
+type MockAudit struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditMockRecorder
+}
+
+// MockAuditMockRecorder is the mock recorder for MockAudit
+type MockAuditMockRecorder struct {
+	mock *MockAudit
+}
+
+// NewMockAudit creates a new mock instance
+func NewMockAudit(ctrl *gomock.Controller) *MockAudit {
+	mock := &MockAudit{ctrl: ctrl}
+	mock.recorder = &MockAuditMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAudit) EXPECT() *MockAuditMockRecorder {
+	return m.recorder
+}
+
+// CreateAuditEvent mocks base method
+func (m *MockAudit) CreateAuditEvent(arg0 *entity.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent
+func (mr *MockAuditMockRecorder) CreateAuditEvent(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockAudit)(nil).CreateAuditEvent), arg0)
+}
+
+// GetAuditEvents mocks base method
+func (m *MockAudit) GetAuditEvents(arg0 entity.AuditFilter) ([]entity.AuditEvent, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditEvents", arg0)
+	ret0, _ := ret[0].([]entity.AuditEvent)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAuditEvents indicates an expected call of GetAuditEvents
+func (mr *MockAuditMockRecorder) GetAuditEvents(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditEvents", reflect.TypeOf((*MockAudit)(nil).GetAuditEvents), arg0)
+}
@@ -6,6 +6,7 @@ package repomock
 
 import (
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 	entity "github.com/mshto/fruit-store/entity"
 	reflect "reflect"
 )
@@ -33,6 +34,36 @@ func (m *MockAuth) EXPECT() *MockAuthMockRecorder {
 	return m.recorder
 }
 
+// GetUserByID mocks base method
+func (m *MockAuth) GetUserByID(arg0 uuid.UUID) (*entity.Credentials, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", arg0)
+	ret0, _ := ret[0].(*entity.Credentials)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID
+func (mr *MockAuthMockRecorder) GetUserByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockAuth)(nil).GetUserByID), arg0)
+}
+
+// GetUserByIdentity mocks base method
+func (m *MockAuth) GetUserByIdentity(arg0, arg1 string) (*entity.Credentials, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByIdentity", arg0, arg1)
+	ret0, _ := ret[0].(*entity.Credentials)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByIdentity indicates an expected call of GetUserByIdentity
+func (mr *MockAuthMockRecorder) GetUserByIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByIdentity", reflect.TypeOf((*MockAuth)(nil).GetUserByIdentity), arg0, arg1)
+}
+
 // GetUserByName mocks base method
 func (m *MockAuth) GetUserByName(arg0 string) (*entity.Credentials, error) {
 	m.ctrl.T.Helper()
@@ -48,6 +79,35 @@ func (mr *MockAuthMockRecorder) GetUserByName(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByName", reflect.TypeOf((*MockAuth)(nil).GetUserByName), arg0)
 }
 
+// GetUserRole mocks base method
+func (m *MockAuth) GetUserRole(arg0 uuid.UUID) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRole", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRole indicates an expected call of GetUserRole
+func (mr *MockAuthMockRecorder) GetUserRole(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRole", reflect.TypeOf((*MockAuth)(nil).GetUserRole), arg0)
+}
+
+// LinkIdentity mocks base method
+func (m *MockAuth) LinkIdentity(arg0 uuid.UUID, arg1 entity.Identity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkIdentity", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkIdentity indicates an expected call of LinkIdentity
+func (mr *MockAuthMockRecorder) LinkIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkIdentity", reflect.TypeOf((*MockAuth)(nil).LinkIdentity), arg0, arg1)
+}
+
 // Signup mocks base method
 func (m *MockAuth) Signup(arg0 *entity.Credentials) error {
 	m.ctrl.T.Helper()
@@ -61,3 +121,45 @@ func (mr *MockAuthMockRecorder) Signup(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Signup", reflect.TypeOf((*MockAuth)(nil).Signup), arg0)
 }
+
+// SignupWithIdentity mocks base method
+func (m *MockAuth) SignupWithIdentity(arg0 *entity.Credentials, arg1 entity.Identity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignupWithIdentity", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SignupWithIdentity indicates an expected call of SignupWithIdentity
+func (mr *MockAuthMockRecorder) SignupWithIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignupWithIdentity", reflect.TypeOf((*MockAuth)(nil).SignupWithIdentity), arg0, arg1)
+}
+
+// UpdatePassword mocks base method
+func (m *MockAuth) UpdatePassword(arg0 uuid.UUID, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword
+func (mr *MockAuthMockRecorder) UpdatePassword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockAuth)(nil).UpdatePassword), arg0, arg1)
+}
+
+// UpdateUserRole mocks base method
+func (m *MockAuth) UpdateUserRole(arg0 uuid.UUID, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserRole", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserRole indicates an expected call of UpdateUserRole
+func (mr *MockAuthMockRecorder) UpdateUserRole(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserRole", reflect.TypeOf((*MockAuth)(nil).UpdateUserRole), arg0, arg1)
+}
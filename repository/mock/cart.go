@@ -34,32 +34,63 @@ func (m *MockCart) EXPECT() *MockCartMockRecorder {
 	return m.recorder
 }
 
-// CreateUserProduct mocks base method
-func (m *MockCart) CreateUserProduct(arg0, arg1 uuid.UUID) error {
+// AddUserProducts mocks base method
+func (m *MockCart) AddUserProducts(arg0 uuid.UUID, arg1 []entity.UserProduct) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUserProduct", arg0, arg1)
+	ret := m.ctrl.Call(m, "AddUserProducts", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// AddUserProducts indicates an expected call of AddUserProducts
+func (mr *MockCartMockRecorder) AddUserProducts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUserProducts", reflect.TypeOf((*MockCart)(nil).AddUserProducts), arg0, arg1)
+}
+
+// CreateUserProduct mocks base method
+func (m *MockCart) CreateUserProduct(arg0, arg1 uuid.UUID, arg2 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserProduct", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // CreateUserProduct indicates an expected call of CreateUserProduct
-func (mr *MockCartMockRecorder) CreateUserProduct(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockCartMockRecorder) CreateUserProduct(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserProduct", reflect.TypeOf((*MockCart)(nil).CreateUserProduct), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserProduct", reflect.TypeOf((*MockCart)(nil).CreateUserProduct), arg0, arg1, arg2)
 }
 
 // CreateUserProducts mocks base method
-func (m *MockCart) CreateUserProducts(arg0 uuid.UUID, arg1 entity.UserProduct) error {
+func (m *MockCart) CreateUserProducts(arg0 uuid.UUID, arg1 entity.UserProduct, arg2 int64) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUserProducts", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "CreateUserProducts", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CreateUserProducts indicates an expected call of CreateUserProducts
-func (mr *MockCartMockRecorder) CreateUserProducts(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockCartMockRecorder) CreateUserProducts(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserProducts", reflect.TypeOf((*MockCart)(nil).CreateUserProducts), arg0, arg1, arg2)
+}
+
+// GetCartVersion mocks base method
+func (m *MockCart) GetCartVersion(arg0 uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCartVersion", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCartVersion indicates an expected call of GetCartVersion
+func (mr *MockCartMockRecorder) GetCartVersion(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserProducts", reflect.TypeOf((*MockCart)(nil).CreateUserProducts), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCartVersion", reflect.TypeOf((*MockCart)(nil).GetCartVersion), arg0)
 }
 
 // GetUserProducts mocks base method
@@ -77,18 +108,34 @@ func (mr *MockCartMockRecorder) GetUserProducts(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserProducts", reflect.TypeOf((*MockCart)(nil).GetUserProducts), arg0)
 }
 
+// IncrCartVersion mocks base method
+func (m *MockCart) IncrCartVersion(arg0 uuid.UUID, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrCartVersion", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrCartVersion indicates an expected call of IncrCartVersion
+func (mr *MockCartMockRecorder) IncrCartVersion(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrCartVersion", reflect.TypeOf((*MockCart)(nil).IncrCartVersion), arg0, arg1)
+}
+
 // RemoveUserProduct mocks base method
-func (m *MockCart) RemoveUserProduct(arg0, arg1 uuid.UUID) error {
+func (m *MockCart) RemoveUserProduct(arg0, arg1 uuid.UUID, arg2 int64) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveUserProduct", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "RemoveUserProduct", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // RemoveUserProduct indicates an expected call of RemoveUserProduct
-func (mr *MockCartMockRecorder) RemoveUserProduct(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockCartMockRecorder) RemoveUserProduct(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUserProduct", reflect.TypeOf((*MockCart)(nil).RemoveUserProduct), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUserProduct", reflect.TypeOf((*MockCart)(nil).RemoveUserProduct), arg0, arg1, arg2)
 }
 
 // RemoveUserProducts mocks base method
@@ -104,3 +151,34 @@ func (mr *MockCartMockRecorder) RemoveUserProducts(arg0 interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUserProducts", reflect.TypeOf((*MockCart)(nil).RemoveUserProducts), arg0)
 }
+
+// ReplaceUserProducts mocks base method
+func (m *MockCart) ReplaceUserProducts(arg0 uuid.UUID, arg1 []entity.UserProduct, arg2 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceUserProducts", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplaceUserProducts indicates an expected call of ReplaceUserProducts
+func (mr *MockCartMockRecorder) ReplaceUserProducts(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceUserProducts", reflect.TypeOf((*MockCart)(nil).ReplaceUserProducts), arg0, arg1, arg2)
+}
+
+// UpdateUserProductAmount mocks base method
+func (m *MockCart) UpdateUserProductAmount(arg0, arg1 uuid.UUID, arg2 float64, arg3 int64) (float64, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserProductAmount", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// UpdateUserProductAmount indicates an expected call of UpdateUserProductAmount
+func (mr *MockCartMockRecorder) UpdateUserProductAmount(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserProductAmount", reflect.TypeOf((*MockCart)(nil).UpdateUserProductAmount), arg0, arg1, arg2, arg3)
+}
@@ -0,0 +1,135 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mshto/fruit-store/repository (interfaces: Catalog)
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+	entity "github.com/mshto/fruit-store/entity"
+	reflect "reflect"
+)
+
+// MockCatalog is a mock of Catalog interface
+type MockCatalog struct {
+	ctrl     *gomock.Controller
+	recorder *MockCatalogMockRecorder
+}
+
+// MockCatalogMockRecorder is the mock recorder for MockCatalog
+type MockCatalogMockRecorder struct {
+	mock *MockCatalog
+}
+
+// NewMockCatalog creates a new mock instance
+func NewMockCatalog(ctrl *gomock.Controller) *MockCatalog {
+	mock := &MockCatalog{ctrl: ctrl}
+	mock.recorder = &MockCatalogMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockCatalog) EXPECT() *MockCatalogMockRecorder {
+	return m.recorder
+}
+
+// CreateCategory mocks base method
+func (m *MockCatalog) CreateCategory(arg0 *entity.Category) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCategory", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCategory indicates an expected call of CreateCategory
+func (mr *MockCatalogMockRecorder) CreateCategory(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCategory", reflect.TypeOf((*MockCatalog)(nil).CreateCategory), arg0)
+}
+
+// DeleteCategory mocks base method
+func (m *MockCatalog) DeleteCategory(arg0 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCategory", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCategory indicates an expected call of DeleteCategory
+func (mr *MockCatalogMockRecorder) DeleteCategory(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategory", reflect.TypeOf((*MockCatalog)(nil).DeleteCategory), arg0)
+}
+
+// GetCategories mocks base method
+func (m *MockCatalog) GetCategories() ([]entity.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategories")
+	ret0, _ := ret[0].([]entity.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategories indicates an expected call of GetCategories
+func (mr *MockCatalogMockRecorder) GetCategories() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategories", reflect.TypeOf((*MockCatalog)(nil).GetCategories))
+}
+
+// GetCategory mocks base method
+func (m *MockCatalog) GetCategory(arg0 uuid.UUID) (*entity.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategory", arg0)
+	ret0, _ := ret[0].(*entity.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategory indicates an expected call of GetCategory
+func (mr *MockCatalogMockRecorder) GetCategory(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategory", reflect.TypeOf((*MockCatalog)(nil).GetCategory), arg0)
+}
+
+// SetProductCategory mocks base method
+func (m *MockCatalog) SetProductCategory(arg0 uuid.UUID, arg1 *uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProductCategory", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProductCategory indicates an expected call of SetProductCategory
+func (mr *MockCatalogMockRecorder) SetProductCategory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProductCategory", reflect.TypeOf((*MockCatalog)(nil).SetProductCategory), arg0, arg1)
+}
+
+// SetProductStock mocks base method
+func (m *MockCatalog) SetProductStock(arg0 uuid.UUID, arg1 *float64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProductStock", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProductStock indicates an expected call of SetProductStock
+func (mr *MockCatalogMockRecorder) SetProductStock(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProductStock", reflect.TypeOf((*MockCatalog)(nil).SetProductStock), arg0, arg1)
+}
+
+// SetProductTags mocks base method
+func (m *MockCatalog) SetProductTags(arg0 uuid.UUID, arg1 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProductTags", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProductTags indicates an expected call of SetProductTags
+func (mr *MockCatalogMockRecorder) SetProductTags(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProductTags", reflect.TypeOf((*MockCatalog)(nil).SetProductTags), arg0, arg1)
+}
@@ -0,0 +1,92 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mshto/fruit-store/repository (interfaces: Currencies)
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+	entity "github.com/mshto/fruit-store/entity"
+	reflect "reflect"
+)
+
+// MockCurrencies is a mock of Currencies interface
+type MockCurrencies struct {
+	ctrl     *gomock.Controller
+	recorder *MockCurrenciesMockRecorder
+}
+
+// MockCurrenciesMockRecorder is the mock recorder for MockCurrencies
+type MockCurrenciesMockRecorder struct {
+	mock *MockCurrencies
+}
+
+// NewMockCurrencies creates a new mock instance
+func NewMockCurrencies(ctrl *gomock.Controller) *MockCurrencies {
+	mock := &MockCurrencies{ctrl: ctrl}
+	mock.recorder = &MockCurrenciesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockCurrencies) EXPECT() *MockCurrenciesMockRecorder {
+	return m.recorder
+}
+
+// DeleteExchangeRate mocks base method
+func (m *MockCurrencies) DeleteExchangeRate(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExchangeRate", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExchangeRate indicates an expected call of DeleteExchangeRate
+func (mr *MockCurrenciesMockRecorder) DeleteExchangeRate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExchangeRate", reflect.TypeOf((*MockCurrencies)(nil).DeleteExchangeRate), arg0)
+}
+
+// GetExchangeRates mocks base method
+func (m *MockCurrencies) GetExchangeRates() ([]entity.ExchangeRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExchangeRates")
+	ret0, _ := ret[0].([]entity.ExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExchangeRates indicates an expected call of GetExchangeRates
+func (mr *MockCurrenciesMockRecorder) GetExchangeRates() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExchangeRates", reflect.TypeOf((*MockCurrencies)(nil).GetExchangeRates))
+}
+
+// SetExchangeRate mocks base method
+func (m *MockCurrencies) SetExchangeRate(arg0 *entity.ExchangeRate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetExchangeRate", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetExchangeRate indicates an expected call of SetExchangeRate
+func (mr *MockCurrenciesMockRecorder) SetExchangeRate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExchangeRate", reflect.TypeOf((*MockCurrencies)(nil).SetExchangeRate), arg0)
+}
+
+// SetProductCurrency mocks base method
+func (m *MockCurrencies) SetProductCurrency(arg0 uuid.UUID, arg1 *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProductCurrency", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProductCurrency indicates an expected call of SetProductCurrency
+func (mr *MockCurrenciesMockRecorder) SetProductCurrency(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProductCurrency", reflect.TypeOf((*MockCurrencies)(nil).SetProductCurrency), arg0, arg1)
+}
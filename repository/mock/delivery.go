@@ -0,0 +1,107 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mshto/fruit-store/repository (interfaces: Delivery)
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+	entity "github.com/mshto/fruit-store/entity"
+	reflect "reflect"
+)
+
+// MockDelivery is a mock of Delivery interface
+type MockDelivery struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeliveryMockRecorder
+}
+
+// MockDeliveryMockRecorder is the mock recorder for MockDelivery
+type MockDeliveryMockRecorder struct {
+	mock *MockDelivery
+}
+
+// NewMockDelivery creates a new mock instance
+func NewMockDelivery(ctrl *gomock.Controller) *MockDelivery {
+	mock := &MockDelivery{ctrl: ctrl}
+	mock.recorder = &MockDeliveryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockDelivery) EXPECT() *MockDeliveryMockRecorder {
+	return m.recorder
+}
+
+// CreateAddress mocks base method
+func (m *MockDelivery) CreateAddress(arg0 *entity.DeliveryAddress) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAddress", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAddress indicates an expected call of CreateAddress
+func (mr *MockDeliveryMockRecorder) CreateAddress(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAddress", reflect.TypeOf((*MockDelivery)(nil).CreateAddress), arg0)
+}
+
+// DeleteAddress mocks base method
+func (m *MockDelivery) DeleteAddress(arg0, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAddress", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAddress indicates an expected call of DeleteAddress
+func (mr *MockDeliveryMockRecorder) DeleteAddress(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAddress", reflect.TypeOf((*MockDelivery)(nil).DeleteAddress), arg0, arg1)
+}
+
+// GetAddresses mocks base method
+func (m *MockDelivery) GetAddresses(arg0 uuid.UUID) ([]entity.DeliveryAddress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAddresses", arg0)
+	ret0, _ := ret[0].([]entity.DeliveryAddress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAddresses indicates an expected call of GetAddresses
+func (mr *MockDeliveryMockRecorder) GetAddresses(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAddresses", reflect.TypeOf((*MockDelivery)(nil).GetAddresses), arg0)
+}
+
+// GetCartDelivery mocks base method
+func (m *MockDelivery) GetCartDelivery(arg0 uuid.UUID) (*entity.CartDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCartDelivery", arg0)
+	ret0, _ := ret[0].(*entity.CartDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCartDelivery indicates an expected call of GetCartDelivery
+func (mr *MockDeliveryMockRecorder) GetCartDelivery(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCartDelivery", reflect.TypeOf((*MockDelivery)(nil).GetCartDelivery), arg0)
+}
+
+// SetCartDelivery mocks base method
+func (m *MockDelivery) SetCartDelivery(arg0 uuid.UUID, arg1 *entity.CartDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCartDelivery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCartDelivery indicates an expected call of SetCartDelivery
+func (mr *MockDeliveryMockRecorder) SetCartDelivery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCartDelivery", reflect.TypeOf((*MockDelivery)(nil).SetCartDelivery), arg0, arg1)
+}
@@ -0,0 +1,79 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mshto/fruit-store/repository (interfaces: Media)
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+	entity "github.com/mshto/fruit-store/entity"
+	reflect "reflect"
+)
+
+// MockMedia is a mock of Media interface
+type MockMedia struct {
+	ctrl     *gomock.Controller
+	recorder *MockMediaMockRecorder
+}
+
+// MockMediaMockRecorder is the mock recorder for MockMedia
+type MockMediaMockRecorder struct {
+	mock *MockMedia
+}
+
+// NewMockMedia creates a new mock instance
+func NewMockMedia(ctrl *gomock.Controller) *MockMedia {
+	mock := &MockMedia{ctrl: ctrl}
+	mock.recorder = &MockMediaMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockMedia) EXPECT() *MockMediaMockRecorder {
+	return m.recorder
+}
+
+// CreateMedia mocks base method
+func (m *MockMedia) CreateMedia(arg0 *entity.Media) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMedia", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMedia indicates an expected call of CreateMedia
+func (mr *MockMediaMockRecorder) CreateMedia(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMedia", reflect.TypeOf((*MockMedia)(nil).CreateMedia), arg0)
+}
+
+// DeleteMedia mocks base method
+func (m *MockMedia) DeleteMedia(arg0, arg1 uuid.UUID) (*entity.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMedia", arg0, arg1)
+	ret0, _ := ret[0].(*entity.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMedia indicates an expected call of DeleteMedia
+func (mr *MockMediaMockRecorder) DeleteMedia(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMedia", reflect.TypeOf((*MockMedia)(nil).DeleteMedia), arg0, arg1)
+}
+
+// GetMedia mocks base method
+func (m *MockMedia) GetMedia(arg0 []uuid.UUID) (map[uuid.UUID][]entity.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMedia", arg0)
+	ret0, _ := ret[0].(map[uuid.UUID][]entity.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMedia indicates an expected call of GetMedia
+func (mr *MockMediaMockRecorder) GetMedia(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMedia", reflect.TypeOf((*MockMedia)(nil).GetMedia), arg0)
+}
@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mshto/fruit-store/repository (interfaces: Orders)
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+	entity "github.com/mshto/fruit-store/entity"
+	reflect "reflect"
+)
+
+// MockOrders is a mock of Orders interface
+type MockOrders struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrdersMockRecorder
+}
+
+// MockOrdersMockRecorder is the mock recorder for MockOrders
+type MockOrdersMockRecorder struct {
+	mock *MockOrders
+}
+
+// NewMockOrders creates a new mock instance
+func NewMockOrders(ctrl *gomock.Controller) *MockOrders {
+	mock := &MockOrders{ctrl: ctrl}
+	mock.recorder = &MockOrdersMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockOrders) EXPECT() *MockOrdersMockRecorder {
+	return m.recorder
+}
+
+// CreateOrder mocks base method
+func (m *MockOrders) CreateOrder(arg0 *entity.Order, arg1 entity.OrderTransition, arg2 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrder", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrder indicates an expected call of CreateOrder
+func (mr *MockOrdersMockRecorder) CreateOrder(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockOrders)(nil).CreateOrder), arg0, arg1, arg2)
+}
+
+// GetOrder mocks base method
+func (m *MockOrders) GetOrder(arg0 uuid.UUID, arg1 *uuid.UUID) (*entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrder", arg0, arg1)
+	ret0, _ := ret[0].(*entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrder indicates an expected call of GetOrder
+func (mr *MockOrdersMockRecorder) GetOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockOrders)(nil).GetOrder), arg0, arg1)
+}
+
+// GetOrders mocks base method
+func (m *MockOrders) GetOrders(arg0 entity.OrderFilter) ([]entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrders", arg0)
+	ret0, _ := ret[0].([]entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrders indicates an expected call of GetOrders
+func (mr *MockOrdersMockRecorder) GetOrders(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrders", reflect.TypeOf((*MockOrders)(nil).GetOrders), arg0)
+}
+
+// SetOrderStatus mocks base method
+func (m *MockOrders) SetOrderStatus(arg0 uuid.UUID, arg1 *uuid.UUID, arg2 entity.OrderTransition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOrderStatus", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetOrderStatus indicates an expected call of SetOrderStatus
+func (mr *MockOrdersMockRecorder) SetOrderStatus(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOrderStatus", reflect.TypeOf((*MockOrders)(nil).SetOrderStatus), arg0, arg1, arg2)
+}
@@ -0,0 +1,93 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mshto/fruit-store/repository (interfaces: Prices)
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+	entity "github.com/mshto/fruit-store/entity"
+	reflect "reflect"
+)
+
+// MockPrices is a mock of Prices interface
+type MockPrices struct {
+	ctrl     *gomock.Controller
+	recorder *MockPricesMockRecorder
+}
+
+// MockPricesMockRecorder is the mock recorder for MockPrices
+type MockPricesMockRecorder struct {
+	mock *MockPrices
+}
+
+// NewMockPrices creates a new mock instance
+func NewMockPrices(ctrl *gomock.Controller) *MockPrices {
+	mock := &MockPrices{ctrl: ctrl}
+	mock.recorder = &MockPricesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPrices) EXPECT() *MockPricesMockRecorder {
+	return m.recorder
+}
+
+// ApplyScheduledPrices mocks base method
+func (m *MockPrices) ApplyScheduledPrices() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyScheduledPrices")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyScheduledPrices indicates an expected call of ApplyScheduledPrices
+func (mr *MockPricesMockRecorder) ApplyScheduledPrices() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyScheduledPrices", reflect.TypeOf((*MockPrices)(nil).ApplyScheduledPrices))
+}
+
+// CancelPrice mocks base method
+func (m *MockPrices) CancelPrice(arg0, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelPrice", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelPrice indicates an expected call of CancelPrice
+func (mr *MockPricesMockRecorder) CancelPrice(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelPrice", reflect.TypeOf((*MockPrices)(nil).CancelPrice), arg0, arg1)
+}
+
+// GetPriceHistory mocks base method
+func (m *MockPrices) GetPriceHistory(arg0 uuid.UUID) ([]entity.ProductPrice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPriceHistory", arg0)
+	ret0, _ := ret[0].([]entity.ProductPrice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPriceHistory indicates an expected call of GetPriceHistory
+func (mr *MockPricesMockRecorder) GetPriceHistory(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPriceHistory", reflect.TypeOf((*MockPrices)(nil).GetPriceHistory), arg0)
+}
+
+// SchedulePrice mocks base method
+func (m *MockPrices) SchedulePrice(arg0 *entity.ProductPrice) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SchedulePrice", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SchedulePrice indicates an expected call of SchedulePrice
+func (mr *MockPricesMockRecorder) SchedulePrice(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchedulePrice", reflect.TypeOf((*MockPrices)(nil).SchedulePrice), arg0)
+}
@@ -6,6 +6,7 @@ package repomock
 
 import (
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 	entity "github.com/mshto/fruit-store/entity"
 	reflect "reflect"
 )
@@ -33,17 +34,33 @@ func (m *MockProducts) EXPECT() *MockProductsMockRecorder {
 	return m.recorder
 }
 
-// GetAll mocks base method
-func (m *MockProducts) GetAll() ([]entity.Product, error) {
+// Find mocks base method
+func (m *MockProducts) Find(arg0 entity.ProductQuery) ([]entity.Product, int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll")
+	ret := m.ctrl.Call(m, "Find", arg0)
+	ret0, _ := ret[0].([]entity.Product)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Find indicates an expected call of Find
+func (mr *MockProductsMockRecorder) Find(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockProducts)(nil).Find), arg0)
+}
+
+// GetByIDs mocks base method
+func (m *MockProducts) GetByIDs(arg0 []uuid.UUID) ([]entity.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDs", arg0)
 	ret0, _ := ret[0].([]entity.Product)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAll indicates an expected call of GetAll
-func (mr *MockProductsMockRecorder) GetAll() *gomock.Call {
+// GetByIDs indicates an expected call of GetByIDs
+func (mr *MockProductsMockRecorder) GetByIDs(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockProducts)(nil).GetAll))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDs", reflect.TypeOf((*MockProducts)(nil).GetByIDs), arg0)
 }
@@ -0,0 +1,78 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mshto/fruit-store/repository (interfaces: Profile)
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+	entity "github.com/mshto/fruit-store/entity"
+	reflect "reflect"
+)
+
+// MockProfile is a mock of Profile interface
+type MockProfile struct {
+	ctrl     *gomock.Controller
+	recorder *MockProfileMockRecorder
+}
+
+// MockProfileMockRecorder is the mock recorder for MockProfile
+type MockProfileMockRecorder struct {
+	mock *MockProfile
+}
+
+// NewMockProfile creates a new mock instance
+func NewMockProfile(ctrl *gomock.Controller) *MockProfile {
+	mock := &MockProfile{ctrl: ctrl}
+	mock.recorder = &MockProfileMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockProfile) EXPECT() *MockProfileMockRecorder {
+	return m.recorder
+}
+
+// DeleteUser mocks base method
+func (m *MockProfile) DeleteUser(arg0 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser
+func (mr *MockProfileMockRecorder) DeleteUser(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockProfile)(nil).DeleteUser), arg0)
+}
+
+// GetProfile mocks base method
+func (m *MockProfile) GetProfile(arg0 uuid.UUID) (*entity.Profile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfile", arg0)
+	ret0, _ := ret[0].(*entity.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfile indicates an expected call of GetProfile
+func (mr *MockProfileMockRecorder) GetProfile(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockProfile)(nil).GetProfile), arg0)
+}
+
+// UpdateProfile mocks base method
+func (m *MockProfile) UpdateProfile(arg0 *entity.Profile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile
+func (mr *MockProfileMockRecorder) UpdateProfile(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockProfile)(nil).UpdateProfile), arg0)
+}
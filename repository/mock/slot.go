@@ -0,0 +1,108 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mshto/fruit-store/repository (interfaces: Slots)
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+	entity "github.com/mshto/fruit-store/entity"
+	reflect "reflect"
+	time "time"
+)
+
+// MockSlots is a mock of Slots interface
+type MockSlots struct {
+	ctrl     *gomock.Controller
+	recorder *MockSlotsMockRecorder
+}
+
+// MockSlotsMockRecorder is the mock recorder for MockSlots
+type MockSlotsMockRecorder struct {
+	mock *MockSlots
+}
+
+// NewMockSlots creates a new mock instance
+func NewMockSlots(ctrl *gomock.Controller) *MockSlots {
+	mock := &MockSlots{ctrl: ctrl}
+	mock.recorder = &MockSlotsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSlots) EXPECT() *MockSlotsMockRecorder {
+	return m.recorder
+}
+
+// CreateSlot mocks base method
+func (m *MockSlots) CreateSlot(arg0 *entity.DeliverySlot) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSlot", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSlot indicates an expected call of CreateSlot
+func (mr *MockSlotsMockRecorder) CreateSlot(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSlot", reflect.TypeOf((*MockSlots)(nil).CreateSlot), arg0)
+}
+
+// DeleteSlot mocks base method
+func (m *MockSlots) DeleteSlot(arg0 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSlot", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSlot indicates an expected call of DeleteSlot
+func (mr *MockSlotsMockRecorder) DeleteSlot(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSlot", reflect.TypeOf((*MockSlots)(nil).DeleteSlot), arg0)
+}
+
+// GetSlot mocks base method
+func (m *MockSlots) GetSlot(arg0 uuid.UUID) (*entity.DeliverySlot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSlot", arg0)
+	ret0, _ := ret[0].(*entity.DeliverySlot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSlot indicates an expected call of GetSlot
+func (mr *MockSlotsMockRecorder) GetSlot(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSlot", reflect.TypeOf((*MockSlots)(nil).GetSlot), arg0)
+}
+
+// GetSlots mocks base method
+func (m *MockSlots) GetSlots(arg0, arg1 time.Time) ([]entity.DeliverySlot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSlots", arg0, arg1)
+	ret0, _ := ret[0].([]entity.DeliverySlot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSlots indicates an expected call of GetSlots
+func (mr *MockSlotsMockRecorder) GetSlots(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSlots", reflect.TypeOf((*MockSlots)(nil).GetSlots), arg0, arg1)
+}
+
+// SetCartSlot mocks base method
+func (m *MockSlots) SetCartSlot(arg0 uuid.UUID, arg1 *uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCartSlot", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCartSlot indicates an expected call of SetCartSlot
+func (mr *MockSlotsMockRecorder) SetCartSlot(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCartSlot", reflect.TypeOf((*MockSlots)(nil).SetCartSlot), arg0, arg1)
+}
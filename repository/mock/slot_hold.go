@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mshto/fruit-store/repository (interfaces: SlotHolds)
+
+// Package repomock is a generated GoMock package.
+package repomock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+	reflect "reflect"
+	time "time"
+)
+
+// MockSlotHolds is a mock of SlotHolds interface
+type MockSlotHolds struct {
+	ctrl     *gomock.Controller
+	recorder *MockSlotHoldsMockRecorder
+}
+
+// MockSlotHoldsMockRecorder is the mock recorder for MockSlotHolds
+type MockSlotHoldsMockRecorder struct {
+	mock *MockSlotHolds
+}
+
+// NewMockSlotHolds creates a new mock instance
+func NewMockSlotHolds(ctrl *gomock.Controller) *MockSlotHolds {
+	mock := &MockSlotHolds{ctrl: ctrl}
+	mock.recorder = &MockSlotHoldsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSlotHolds) EXPECT() *MockSlotHoldsMockRecorder {
+	return m.recorder
+}
+
+// CountHeld mocks base method
+func (m *MockSlotHolds) CountHeld(arg0 []uuid.UUID) (map[uuid.UUID]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountHeld", arg0)
+	ret0, _ := ret[0].(map[uuid.UUID]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountHeld indicates an expected call of CountHeld
+func (mr *MockSlotHoldsMockRecorder) CountHeld(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountHeld", reflect.TypeOf((*MockSlotHolds)(nil).CountHeld), arg0)
+}
+
+// Hold mocks base method
+func (m *MockSlotHolds) Hold(arg0, arg1 uuid.UUID, arg2 int) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Hold", arg0, arg1, arg2)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Hold indicates an expected call of Hold
+func (mr *MockSlotHoldsMockRecorder) Hold(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hold", reflect.TypeOf((*MockSlotHolds)(nil).Hold), arg0, arg1, arg2)
+}
+
+// IsHeld mocks base method
+func (m *MockSlotHolds) IsHeld(arg0, arg1 uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsHeld", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsHeld indicates an expected call of IsHeld
+func (mr *MockSlotHoldsMockRecorder) IsHeld(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsHeld", reflect.TypeOf((*MockSlotHolds)(nil).IsHeld), arg0, arg1)
+}
+
+// Release mocks base method
+func (m *MockSlotHolds) Release(arg0, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release
+func (mr *MockSlotHoldsMockRecorder) Release(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockSlotHolds)(nil).Release), arg0, arg1)
+}
@@ -0,0 +1,307 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+//go:generate mockgen -destination=mock/order.go -package=repomock github.com/mshto/fruit-store/repository Orders
+
+// Orders interface
+type Orders interface {
+	CreateOrder(order *entity.Order, capture entity.OrderTransition, expected int64) (int64, error)
+	GetOrders(filter entity.OrderFilter) ([]entity.Order, error)
+	GetOrder(orderUUID uuid.UUID, userUUID *uuid.UUID) (*entity.Order, error)
+	SetOrderStatus(orderUUID uuid.UUID, userUUID *uuid.UUID, transition entity.OrderTransition) error
+}
+
+// NewOrders generate new orders repo
+func NewOrders(db *sql.DB) Orders {
+	return &ordersImpl{
+		db: db,
+	}
+}
+
+type ordersImpl struct {
+	db *sql.DB
+}
+
+const orderColumns = `id, user_id, status, currency, subtotal, tax, shipping, total, paid, method, address_id, slot_id, created_at, updated_at`
+
+var (
+	createOrder = `INSERT INTO orders (user_id, status, currency, subtotal, tax, shipping, total, method, address_id, slot_id) ` +
+		`VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, created_at, updated_at`
+	createOrderItem = `INSERT INTO order_items (order_id, product_id, name, price, amount) VALUES ($1, $2, $3, $4, $5)`
+	// the row lock serializes concurrent orders of the product, so stock never goes below zero
+	reserveStock       = `UPDATE products SET stock=stock-$2 WHERE id=$1 AND (stock IS NULL OR stock >= $2)`
+	createOrderHistory = `INSERT INTO order_status_history (order_id, status, note) VALUES ($1, $2, $3)`
+
+	getOrders      = `SELECT ` + orderColumns + ` FROM orders`
+	getOrder       = `SELECT ` + orderColumns + ` FROM orders WHERE id=$1 AND ($2::uuid IS NULL OR user_id=$2)`
+	getOrderItems  = `SELECT product_id, name, price, amount FROM order_items WHERE order_id=$1 ORDER BY name`
+	getOrderStatus = `SELECT status, note, changed_at FROM order_status_history WHERE order_id=$1 ORDER BY id`
+
+	// the status is changed only from allowed ones, so concurrent transitions of order can't both succeed
+	setOrderStatus = `UPDATE orders SET status=$2, updated_at=now(), ` +
+		`paid=CASE WHEN $4 THEN total WHEN $5 THEN 0 ELSE paid END ` +
+		`WHERE id=$1 AND status=ANY($3) AND ($6::uuid IS NULL OR user_id=$6)`
+	isOrderExist = `SELECT exists (SELECT id FROM orders WHERE id=$1 AND ($2::uuid IS NULL OR user_id=$2))`
+	releaseStock = `UPDATE products SET stock=products.stock+order_items.amount FROM order_items ` +
+		`WHERE order_items.order_id=$1 AND order_items.product_id=products.id AND products.stock IS NOT NULL`
+	releaseSlot = `UPDATE delivery_slots SET booked=booked-1 FROM delivery_slot_bookings ` +
+		`WHERE delivery_slot_bookings.order_id=$1 AND delivery_slot_bookings.slot_id=delivery_slots.id`
+	deleteOrderBookings = `DELETE FROM delivery_slot_bookings WHERE order_id=$1`
+)
+
+// CreateOrder store order of user cart with its items, move it by capture transition and clear the cart in one transaction,
+// order id and timestamps are set. Cart version is incremented if it equals to expected one and returned.
+// Stock of items is reserved and delivery slot reserved for user cart is booked, order without enough stock isn't created.
+func (ori *ordersImpl) CreateOrder(order *entity.Order, capture entity.OrderTransition, expected int64) (int64, error) {
+	tx, err := ori.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	version, err := incrCartVersionTx(tx, *order.UserID, expected)
+	if err == nil {
+		err = createOrderTx(tx, order)
+	}
+	if err == nil {
+		err = setOrderStatusTx(tx, order.ID, order.UserID, capture)
+	}
+	if err == nil {
+		_, err = tx.Exec(deleteUserProducts, *order.UserID)
+	}
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// createOrderTx store order with its items, reserve their stock and book delivery slot reserved for user cart
+func createOrderTx(tx *sql.Tx, order *entity.Order) error {
+	err := tx.QueryRow(createOrder, order.UserID, order.Status, order.Currency, order.Subtotal, order.Tax, order.Shipping, order.Total,
+		order.Method, order.AddressID, order.SlotID).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range order.Items {
+		err = createItem(tx, order.ID, item)
+		if err != nil {
+			return err
+		}
+	}
+
+	if order.SlotID != nil && order.UserID != nil {
+		err = bookCartSlot(tx, *order.UserID, *order.SlotID, order.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(createOrderHistory, order.ID, order.Status, "")
+	return err
+}
+
+// createItem store order item and reserve its stock
+func createItem(tx *sql.Tx, orderUUID uuid.UUID, item entity.OrderItem) error {
+	_, err := tx.Exec(createOrderItem, orderUUID, item.ProductID, item.Name, item.Price, item.Amount)
+	if err != nil {
+		return productErr(err)
+	}
+
+	res, err := tx.Exec(reserveStock, item.ProductID, item.Amount)
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrOutOfStock)
+}
+
+// GetOrders get filtered orders without items and history, newest first
+func (ori *ordersImpl) GetOrders(filter entity.OrderFilter) ([]entity.Order, error) {
+	orders := []entity.Order{}
+
+	where, args := orderConditions(filter)
+	query := getOrders + where + " ORDER BY created_at DESC, id"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := ori.db.Query(query, args...)
+	if err != nil {
+		return orders, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return orders, err
+		}
+		orders = append(orders, *order)
+	}
+	return orders, rows.Err()
+}
+
+// orderConditions build WHERE clause with placeholders for set fields of filter
+func orderConditions(filter entity.OrderFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.UserID != nil {
+		add("user_id=$%d", *filter.UserID)
+	}
+	if filter.Status != "" {
+		add("status=$%d", filter.Status)
+	}
+
+	return where(conditions), args
+}
+
+// GetOrder get order with items and history, order of other user than set one isn't found
+func (ori *ordersImpl) GetOrder(orderUUID uuid.UUID, userUUID *uuid.UUID) (*entity.Order, error) {
+	order, err := scanOrder(ori.db.QueryRow(getOrder, orderUUID, userUUID))
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	order.Items, err = ori.getItems(orderUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	order.History, err = ori.getHistory(orderUUID)
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (ori *ordersImpl) getItems(orderUUID uuid.UUID) ([]entity.OrderItem, error) {
+	items := []entity.OrderItem{}
+
+	rows, err := ori.db.Query(getOrderItems, orderUUID)
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := entity.OrderItem{}
+		err := rows.Scan(&item.ProductID, &item.Name, &item.Price, &item.Amount)
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (ori *ordersImpl) getHistory(orderUUID uuid.UUID) ([]entity.OrderStatusChange, error) {
+	history := []entity.OrderStatusChange{}
+
+	rows, err := ori.db.Query(getOrderStatus, orderUUID)
+	if err != nil {
+		return history, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		change := entity.OrderStatusChange{}
+		err := rows.Scan(&change.Status, &change.Note, &change.ChangedAt)
+		if err != nil {
+			return history, err
+		}
+		history = append(history, change)
+	}
+	return history, rows.Err()
+}
+
+// SetOrderStatus move order to status of transition in one transaction and record it in order history.
+// Order of other user than set one isn't found, order in status transition isn't allowed from can't be moved.
+func (ori *ordersImpl) SetOrderStatus(orderUUID uuid.UUID, userUUID *uuid.UUID, transition entity.OrderTransition) error {
+	tx, err := ori.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = setOrderStatusTx(tx, orderUUID, userUUID, transition)
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// setOrderStatusTx move order to status of transition, record it in order history and release stock and slot if transition does
+func setOrderStatusTx(tx *sql.Tx, orderUUID uuid.UUID, userUUID *uuid.UUID, transition entity.OrderTransition) error {
+	res, err := tx.Exec(setOrderStatus, orderUUID, transition.To, pq.Array(transition.From), transition.Capture, transition.Void, userUUID)
+	if err == nil {
+		err = affectedOrErr(res, entity.ErrInvalidTransition)
+	}
+	if err == entity.ErrInvalidTransition {
+		err = transitionErr(tx, orderUUID, userUUID)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(createOrderHistory, orderUUID, transition.To, transition.Note)
+	if err != nil {
+		return err
+	}
+
+	if transition.Release {
+		for _, query := range []string{releaseStock, releaseSlot, deleteOrderBookings} {
+			_, err = tx.Exec(query, orderUUID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// transitionErr tell order which isn't found from order in status transition isn't allowed from
+func transitionErr(tx *sql.Tx, orderUUID uuid.UUID, userUUID *uuid.UUID) error {
+	var exists bool
+	err := tx.QueryRow(isOrderExist, orderUUID, userUUID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return entity.ErrOrderNotFound
+	}
+	return entity.ErrInvalidTransition
+}
+
+func scanOrder(row rowScanner) (*entity.Order, error) {
+	order := &entity.Order{}
+	err := row.Scan(&order.ID, &order.UserID, &order.Status, &order.Currency, &order.Subtotal, &order.Tax, &order.Shipping,
+		&order.Total, &order.Paid, &order.Method, &order.AddressID, &order.SlotID, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
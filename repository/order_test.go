@@ -0,0 +1,363 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+var (
+	orderUUID        = uuid.MustParse("9e4a7d7e-3700-11eb-adc1-0242ac120002")
+	orderCreatedAt   = time.Date(2020, 12, 6, 10, 0, 0, 0, time.UTC)
+	orderColumnNames = []string{"id", "user_id", "status", "currency", "subtotal", "tax", "shipping", "total", "paid", "method",
+		"address_id", "slot_id", "created_at", "updated_at"}
+)
+
+func newOrder() *entity.Order {
+	return &entity.Order{
+		UserID:   &userUUID,
+		Status:   "pending_payment",
+		Currency: "USD",
+		Items:    []entity.OrderItem{{ProductID: &productOne.ID, Name: "Apples", Price: 1.72, Amount: 2}},
+		Subtotal: 3.44,
+		Shipping: 5,
+		Total:    8.44,
+		Method:   "pickup",
+		SlotID:   &slotUUID,
+	}
+}
+
+func TestCreateOrder(t *testing.T) {
+	paid := entity.OrderTransition{From: []string{"pending_payment"}, To: "paid", Capture: true}
+
+	tc := []struct {
+		name     string
+		version  int64
+		expected error
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "Create order with success",
+			version: AnyCartVersion,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				expectCartVersion(mock)
+				mock.ExpectQuery("INSERT INTO orders").WithArgs(&userUUID, "pending_payment", "USD", 3.44, 0.0, 5.0, 8.44, "pickup", nil, &slotUUID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(orderUUID, orderCreatedAt, orderCreatedAt))
+				mock.ExpectExec("INSERT INTO order_items").WithArgs(orderUUID, &productOne.ID, "Apples", 1.72, 2.0).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE products SET stock=stock-\\$2").WithArgs(&productOne.ID, 2.0).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE users_cart_delivery SET slot_id=NULL").WithArgs(userUUID, slotUUID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE delivery_slots SET booked=booked\\+1").WithArgs(slotUUID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO delivery_slot_bookings").WithArgs(slotUUID, userUUID, orderUUID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO order_status_history").WithArgs(orderUUID, "pending_payment", "").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE orders SET status").WithArgs(orderUUID, "paid", pq.Array([]string{"pending_payment"}), true, false, &userUUID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO order_status_history").WithArgs(orderUUID, "paid", "").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM users_cart").WithArgs(userUUID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:     "Create order of stale cart version",
+			version:  3,
+			expected: entity.ErrCartVersionMismatch,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE users_cart_version").WithArgs(userUUID, 3).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:     "Create order with failed capture",
+			version:  AnyCartVersion,
+			expected: ErrNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				expectCartVersion(mock)
+				mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(orderUUID, orderCreatedAt, orderCreatedAt))
+				mock.ExpectExec("INSERT INTO order_items").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE products SET stock=stock-\\$2").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE users_cart_delivery SET slot_id=NULL").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE delivery_slots SET booked=booked\\+1").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO delivery_slot_bookings").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO order_status_history").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE orders SET status").WillReturnError(ErrNotFound)
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:     "Create order without enough stock",
+			expected: entity.ErrOutOfStock,
+			version:  AnyCartVersion,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				expectCartVersion(mock)
+				mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(orderUUID, orderCreatedAt, orderCreatedAt))
+				mock.ExpectExec("INSERT INTO order_items").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE products SET stock=stock-\\$2").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:     "Create order of deleted product",
+			expected: entity.ErrProductNotFound,
+			version:  AnyCartVersion,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				expectCartVersion(mock)
+				mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(orderUUID, orderCreatedAt, orderCreatedAt))
+				mock.ExpectExec("INSERT INTO order_items").WillReturnError(&pq.Error{Code: foreignKeyViolation})
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:     "Create order with slot which isn't reserved for cart",
+			expected: entity.ErrSlotNotReserved,
+			version:  AnyCartVersion,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				expectCartVersion(mock)
+				mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(orderUUID, orderCreatedAt, orderCreatedAt))
+				mock.ExpectExec("INSERT INTO order_items").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE products SET stock=stock-\\$2").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE users_cart_delivery SET slot_id=NULL").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:     "Create order with full slot",
+			expected: entity.ErrSlotFull,
+			version:  AnyCartVersion,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				expectCartVersion(mock)
+				mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(orderUUID, orderCreatedAt, orderCreatedAt))
+				mock.ExpectExec("INSERT INTO order_items").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE products SET stock=stock-\\$2").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE users_cart_delivery SET slot_id=NULL").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE delivery_slots SET booked=booked\\+1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			order := newOrder()
+			version, err := NewOrders(db).CreateOrder(order, paid, test.version)
+			assert.Equal(t, test.expected, err)
+			if test.expected == nil {
+				assert.Equal(t, int64(1), version)
+				assert.Equal(t, orderUUID, order.ID)
+			}
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetOrders(t *testing.T) {
+	tc := []struct {
+		name     string
+		filter   entity.OrderFilter
+		expected []entity.Order
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name:     "Get orders of user with success",
+			filter:   entity.OrderFilter{UserID: &userUUID},
+			expected: []entity.Order{{ID: orderUUID, UserID: &userUUID, Status: "paid", Currency: "USD", Total: 8.44, Paid: 8.44, Method: "pickup", CreatedAt: orderCreatedAt, UpdatedAt: orderCreatedAt}},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(orderColumnNames).
+					AddRow(orderUUID, userUUID.String(), "paid", "USD", 0, 0, 0, 8.44, 8.44, "pickup", nil, nil, orderCreatedAt, orderCreatedAt)
+				mock.ExpectQuery("FROM orders WHERE user_id=\\$1 ORDER BY created_at DESC, id$").WithArgs(userUUID).WillReturnRows(rows)
+			},
+		},
+		{
+			name:     "Get page of orders by status with success",
+			filter:   entity.OrderFilter{Status: "paid", Limit: 10, Offset: 20},
+			expected: []entity.Order{},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM orders WHERE status=\\$1 ORDER BY created_at DESC, id LIMIT \\$2 OFFSET \\$3").WithArgs("paid", 10, 20).
+					WillReturnRows(sqlmock.NewRows(orderColumnNames))
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			orders, err := NewOrders(db).GetOrders(test.filter)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, orders)
+		})
+	}
+}
+
+func TestGetOrder(t *testing.T) {
+	type expected struct {
+		order *entity.Order
+		err   error
+	}
+
+	tc := []struct {
+		name     string
+		expected expected
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "Get order with success",
+			expected: expected{order: &entity.Order{
+				ID: orderUUID, UserID: &userUUID, Status: "paid", Currency: "USD", Subtotal: 3.44, Shipping: 5, Total: 8.44, Paid: 8.44,
+				Method: "pickup", SlotID: &slotUUID, CreatedAt: orderCreatedAt, UpdatedAt: orderCreatedAt,
+				Items: []entity.OrderItem{{ProductID: &productOne.ID, Name: "Apples", Price: 1.72, Amount: 2}},
+				History: []entity.OrderStatusChange{
+					{Status: "pending_payment", ChangedAt: orderCreatedAt},
+					{Status: "paid", ChangedAt: orderCreatedAt},
+				},
+			}},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM orders WHERE id=\\$1").WithArgs(orderUUID, &userUUID).WillReturnRows(sqlmock.NewRows(orderColumnNames).
+					AddRow(orderUUID, userUUID.String(), "paid", "USD", 3.44, 0, 5, 8.44, 8.44, "pickup", nil, slotUUID.String(), orderCreatedAt, orderCreatedAt))
+				mock.ExpectQuery("FROM order_items").WithArgs(orderUUID).WillReturnRows(sqlmock.NewRows([]string{"product_id", "name", "price", "amount"}).
+					AddRow(productOne.ID.String(), "Apples", 1.72, 2))
+				mock.ExpectQuery("FROM order_status_history").WithArgs(orderUUID).WillReturnRows(sqlmock.NewRows([]string{"status", "note", "changed_at"}).
+					AddRow("pending_payment", "", orderCreatedAt).AddRow("paid", "", orderCreatedAt))
+			},
+		},
+		{
+			name:     "Get order of other user",
+			expected: expected{err: entity.ErrOrderNotFound},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM orders WHERE id=\\$1").WillReturnRows(sqlmock.NewRows(orderColumnNames))
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			order, err := NewOrders(db).GetOrder(orderUUID, &userUUID)
+			assert.Equal(t, test.expected.err, err)
+			assert.Equal(t, test.expected.order, order)
+		})
+	}
+}
+
+func TestSetOrderStatus(t *testing.T) {
+	tc := []struct {
+		name       string
+		transition entity.OrderTransition
+		expected   error
+		sqlMock    func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name:       "Pack order with success",
+			transition: entity.OrderTransition{From: []string{"paid"}, To: "packed"},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE orders SET status").WithArgs(orderUUID, "packed", pq.Array([]string{"paid"}), false, false, &userUUID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO order_status_history").WithArgs(orderUUID, "packed", "").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:       "Cancel order with success",
+			transition: entity.OrderTransition{From: []string{"pending_payment", "paid"}, To: "cancelled", Note: "cancelled by user", Void: true, Release: true},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE orders SET status").WithArgs(orderUUID, "cancelled", pq.Array([]string{"pending_payment", "paid"}), false, true, &userUUID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO order_status_history").WithArgs(orderUUID, "cancelled", "cancelled by user").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE products SET stock=products.stock\\+order_items.amount").WithArgs(orderUUID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE delivery_slots SET booked=booked-1").WithArgs(orderUUID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM delivery_slot_bookings").WithArgs(orderUUID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:       "Move order in other status",
+			transition: entity.OrderTransition{From: []string{"paid"}, To: "packed"},
+			expected:   entity.ErrInvalidTransition,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE orders SET status").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT exists").WithArgs(orderUUID, &userUUID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:       "Move order of other user",
+			transition: entity.OrderTransition{From: []string{"paid"}, To: "packed"},
+			expected:   entity.ErrOrderNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE orders SET status").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT exists").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:       "Move order with db error",
+			transition: entity.OrderTransition{From: []string{"paid"}, To: "packed"},
+			expected:   ErrNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE orders SET status").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO order_status_history").WillReturnError(ErrNotFound)
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			err = NewOrders(db).SetOrderStatus(orderUUID, &userUUID, test.transition)
+			assert.Equal(t, test.expected, err)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
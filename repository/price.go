@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+//go:generate mockgen -destination=mock/price.go -package=repomock github.com/mshto/fruit-store/repository Prices
+
+// Prices interface
+type Prices interface {
+	GetPriceHistory(productUUID uuid.UUID) ([]entity.ProductPrice, error)
+	SchedulePrice(price *entity.ProductPrice) error
+	CancelPrice(productUUID, priceUUID uuid.UUID) error
+	ApplyScheduledPrices() (int64, error)
+}
+
+// NewPrices generate new prices repo
+func NewPrices(db *sql.DB) Prices {
+	return &pricesImpl{
+		db: db,
+	}
+}
+
+type pricesImpl struct {
+	db *sql.DB
+}
+
+var (
+	getPriceHistory = `SELECT id, product_id, price, effective_from, created_at FROM product_prices ` +
+		`WHERE product_id=$1 AND effective_from <= now() ORDER BY effective_from DESC`
+	// price is effective from now unless set, time in the past is rejected
+	createPrice = `INSERT INTO product_prices (product_id, price, effective_from) ` +
+		`SELECT $1, $2, COALESCE($3::timestamptz, now()) WHERE $3::timestamptz IS NULL OR $3::timestamptz >= now() ` +
+		`RETURNING id, effective_from, created_at`
+	cancelPrice = `DELETE FROM product_prices WHERE id=$1 AND product_id=$2 AND effective_from > now()`
+	// the latest due price of every product or of set one is applied, products already at that price are skipped
+	applyScheduledPrices = `UPDATE products p SET price=due.price FROM (` +
+		`SELECT DISTINCT ON (product_id) product_id, price FROM product_prices ` +
+		`WHERE effective_from <= now() AND ($1::uuid IS NULL OR product_id=$1) ORDER BY product_id, effective_from DESC` +
+		`) due WHERE p.id=due.product_id AND p.price IS DISTINCT FROM due.price`
+)
+
+// GetPriceHistory get prices of product which took effect, newest first
+func (pci *pricesImpl) GetPriceHistory(productUUID uuid.UUID) ([]entity.ProductPrice, error) {
+	prices := []entity.ProductPrice{}
+
+	var exists bool
+	err := pci.db.QueryRow(isProductExist, productUUID).Scan(&exists)
+	if err != nil {
+		return prices, err
+	}
+	if !exists {
+		return prices, entity.ErrProductNotFound
+	}
+
+	rows, err := pci.db.Query(getPriceHistory, productUUID)
+	if err != nil {
+		return prices, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		price := entity.ProductPrice{}
+		err := rows.Scan(&price.ID, &price.ProductID, &price.Price, &price.EffectiveFrom, &price.CreatedAt)
+		if err != nil {
+			return prices, err
+		}
+		prices = append(prices, price)
+	}
+	return prices, rows.Err()
+}
+
+// SchedulePrice store price of product and set its id, effective and creation time. Zero effective time is now
+// of the database. The latest due price of product becomes its current price in the same transaction,
+// so a price doesn't override a newer one which already took effect
+func (pci *pricesImpl) SchedulePrice(price *entity.ProductPrice) error {
+	tx, err := pci.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var effectiveFrom interface{}
+	if !price.EffectiveFrom.IsZero() {
+		effectiveFrom = price.EffectiveFrom
+	}
+	err = tx.QueryRow(createPrice, price.ProductID, price.Price, effectiveFrom).Scan(&price.ID, &price.EffectiveFrom, &price.CreatedAt)
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+			return entity.ErrPriceAlreadyScheduled
+		}
+		if err == sql.ErrNoRows {
+			return entity.ErrPriceInPast
+		}
+		return productErr(err)
+	}
+
+	_, err = tx.Exec(applyScheduledPrices, price.ProductID)
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CancelPrice delete scheduled price of product, prices which took effect are kept as history
+func (pci *pricesImpl) CancelPrice(productUUID, priceUUID uuid.UUID) error {
+	res, err := pci.db.Exec(cancelPrice, priceUUID, productUUID)
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrPriceNotFound)
+}
+
+// ApplyScheduledPrices set current prices of products to their latest due prices and return count of changed products
+func (pci *pricesImpl) ApplyScheduledPrices() (int64, error) {
+	res, err := pci.db.Exec(applyScheduledPrices, nil)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+var (
+	priceUUID = uuid.MustParse("7c2f9e4a-3468-11eb-adc1-0242ac120002")
+	priceNow  = time.Date(2020, 12, 2, 10, 0, 0, 0, time.UTC)
+)
+
+func TestGetPriceHistory(t *testing.T) {
+	type expected struct {
+		prices []entity.ProductPrice
+		err    error
+	}
+
+	price := entity.ProductPrice{ID: priceUUID, ProductID: productOne.ID, Price: 1.5, EffectiveFrom: priceNow, CreatedAt: priceNow}
+
+	tc := []struct {
+		name     string
+		expected expected
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name:     "Get price history with success",
+			expected: expected{prices: []entity.ProductPrice{price}},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT exists").WithArgs(productOne.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				rows := sqlmock.NewRows([]string{"id", "product_id", "price", "effective_from", "created_at"}).
+					AddRow(price.ID, price.ProductID.String(), price.Price, price.EffectiveFrom, price.CreatedAt)
+				mock.ExpectQuery("SELECT id, product_id, price, effective_from, created_at FROM product_prices").WithArgs(productOne.ID).WillReturnRows(rows)
+			},
+		},
+		{
+			name:     "Get price history of unknown product",
+			expected: expected{prices: []entity.ProductPrice{}, err: entity.ErrProductNotFound},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT exists").WithArgs(productOne.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+		},
+		{
+			name:     "Get price history with db error",
+			expected: expected{prices: []entity.ProductPrice{}, err: ErrNotFound},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT exists").WithArgs(productOne.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectQuery("FROM product_prices").WillReturnError(ErrNotFound)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			prices, err := NewPrices(db).GetPriceHistory(productOne.ID)
+			assert.Equal(t, test.expected.err, err)
+			assert.Equal(t, test.expected.prices, prices)
+		})
+	}
+}
+
+func TestSchedulePrice(t *testing.T) {
+	tc := []struct {
+		name          string
+		expected      error
+		effectiveFrom time.Time
+		sqlMock       func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name:          "Schedule future price with success",
+			effectiveFrom: priceNow.Add(time.Hour),
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id", "effective_from", "created_at"}).AddRow(priceUUID, priceNow.Add(time.Hour), priceNow)
+				mock.ExpectQuery("INSERT INTO product_prices").WithArgs(productOne.ID, float32(1.5), priceNow.Add(time.Hour)).WillReturnRows(rows)
+				mock.ExpectExec("UPDATE products p SET price=due.price FROM").WithArgs(productOne.ID).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Set current price with success",
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id", "effective_from", "created_at"}).AddRow(priceUUID, priceNow, priceNow)
+				mock.ExpectQuery("INSERT INTO product_prices").WithArgs(productOne.ID, float32(1.5), nil).WillReturnRows(rows)
+				mock.ExpectExec("UPDATE products p SET price=due.price FROM").WithArgs(productOne.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:          "Schedule price in the past",
+			expected:      entity.ErrPriceInPast,
+			effectiveFrom: priceNow.Add(-time.Hour),
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO product_prices").WithArgs(productOne.ID, float32(1.5), priceNow.Add(-time.Hour)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "effective_from", "created_at"}))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:          "Schedule price of unknown product",
+			expected:      entity.ErrProductNotFound,
+			effectiveFrom: priceNow,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO product_prices").WillReturnError(&pq.Error{Code: foreignKeyViolation})
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:          "Schedule price twice at the same time",
+			expected:      entity.ErrPriceAlreadyScheduled,
+			effectiveFrom: priceNow.Add(time.Hour),
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO product_prices").WillReturnError(&pq.Error{Code: uniqueViolation})
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:          "Set current price with db error",
+			expected:      ErrNotFound,
+			effectiveFrom: priceNow,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id", "effective_from", "created_at"}).AddRow(priceUUID, priceNow, priceNow)
+				mock.ExpectQuery("INSERT INTO product_prices").WillReturnRows(rows)
+				mock.ExpectExec("UPDATE products p SET price=due.price FROM").WillReturnError(ErrNotFound)
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			price := &entity.ProductPrice{ProductID: productOne.ID, Price: 1.5, EffectiveFrom: test.effectiveFrom}
+			err = NewPrices(db).SchedulePrice(price)
+			assert.Equal(t, test.expected, err)
+			if test.expected == nil {
+				assert.Equal(t, priceUUID, price.ID)
+				assert.False(t, price.EffectiveFrom.IsZero())
+			}
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCancelPrice(t *testing.T) {
+	tc := []struct {
+		name     string
+		expected error
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "Cancel scheduled price with success",
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM product_prices").WithArgs(priceUUID, productOne.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "Cancel effective or unknown price",
+			expected: entity.ErrPriceNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM product_prices").WithArgs(priceUUID, productOne.ID).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			err = NewPrices(db).CancelPrice(productOne.ID, priceUUID)
+			assert.Equal(t, test.expected, err)
+		})
+	}
+}
+
+func TestApplyScheduledPrices(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE products p SET price=due.price FROM").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE products p SET price=due.price FROM").WillReturnError(ErrNotFound)
+
+	applied, err := NewPrices(db).ApplyScheduledPrices()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), applied)
+
+	_, err = NewPrices(db).ApplyScheduledPrices()
+	assert.Equal(t, ErrNotFound, err)
+}
@@ -2,6 +2,11 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 
 	"github.com/mshto/fruit-store/entity"
 )
@@ -10,7 +15,8 @@ import (
 
 // Products interface
 type Products interface {
-	GetAll() ([]entity.Product, error)
+	Find(query entity.ProductQuery) ([]entity.Product, int, error)
+	GetByIDs(ids []uuid.UUID) ([]entity.Product, error)
 }
 
 // NewProduct generate a new product
@@ -25,22 +31,123 @@ type productsImpl struct {
 }
 
 var (
-	getAllProducts = `SELECT id, name, price, created_at FROM products`
+	// category path and sorted tag names are read together with each product
+	getAllProducts = `SELECT p.id, p.name, p.price, COALESCE(p.currency, ''), p.unit, p.min_amount, p.step, p.created_at, p.category_id, COALESCE(c.path, ''), ` +
+		`ARRAY(SELECT t.name FROM products_tags pt JOIN tags t ON t.id=pt.tag_id WHERE pt.product_id=p.id ORDER BY t.name) ` +
+		`FROM products p LEFT JOIN categories c ON c.id=p.category_id LEFT JOIN exchange_rates r ON r.currency=p.currency`
+	countProducts    = `SELECT count(*) FROM products p LEFT JOIN categories c ON c.id=p.category_id LEFT JOIN exchange_rates r ON r.currency=p.currency`
+	getProductsByIDs = getAllProducts + ` WHERE p.id = ANY($1)`
 )
 
-// GetAll products
-func (pri *productsImpl) GetAll() ([]entity.Product, error) {
+// basePrice price of product in base currency, products without currency are priced in it
+const basePrice = "p.price / COALESCE(r.rate, 1)"
+
+// sortColumns columns products are sorted by, id breaks ties so cursor position is unique.
+// Columns are NOT NULL, row comparison of keyset with NULL would skip the product.
+var sortColumns = map[string]string{
+	entity.ProductSortCreatedAt: "p.created_at",
+	entity.ProductSortName:      "p.name",
+	entity.ProductSortPrice:     basePrice,
+}
+
+// likeEscaper escapes LIKE wildcards of search text
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Find get page of filtered products after cursor and total count of filtered products.
+// Name search matches substrings and, through trigram word similarity, misspelled words.
+// Prices are filtered and sorted in base currency, cursor of price sort holds the base price.
+func (pri *productsImpl) Find(query entity.ProductQuery) ([]entity.Product, int, error) {
+	column, ok := sortColumns[query.Sort]
+	if !ok {
+		column = sortColumns[entity.ProductSortCreatedAt]
+	}
+	conditions, args := productConditions(query)
+
+	var total int
+	err := pri.db.QueryRow(countProducts+where(conditions), args...).Scan(&total)
+	if err != nil {
+		return []entity.Product{}, 0, err
+	}
+
+	direction, comparison := "ASC", ">"
+	if query.Desc {
+		direction, comparison = "DESC", "<"
+	}
+	if query.After != nil {
+		args = append(args, query.After.Value, query.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(%s, p.id) %s ($%d, $%d)", column, comparison, len(args)-1, len(args)))
+	}
+	args = append(args, query.Limit)
+	page := fmt.Sprintf("%s%s ORDER BY %s %s, p.id %s LIMIT $%d", getAllProducts, where(conditions), column, direction, direction, len(args))
+
+	rows, err := pri.db.Query(page, args...)
+	if err != nil {
+		return []entity.Product{}, 0, err
+	}
+	defer rows.Close()
+
+	products, err := pri.scanProducts(rows)
+	return products, total, err
+}
+
+// productConditions build filter conditions with placeholders for set fields of query
+func productConditions(query entity.ProductQuery) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if query.Search != "" {
+		args = append(args, "%"+likeEscaper.Replace(query.Search)+"%", query.Search)
+		conditions = append(conditions, fmt.Sprintf("(p.name ILIKE $%d OR $%d <%% p.name)", len(args)-1, len(args)))
+	}
+	if query.Category != "" {
+		args = append(args, query.Category, likeEscaper.Replace(query.Category)+"/%")
+		conditions = append(conditions, fmt.Sprintf("(c.path = $%d OR c.path LIKE $%d)", len(args)-1, len(args)))
+	}
+	if query.Tag != "" {
+		args = append(args, query.Tag)
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM products_tags pt JOIN tags t ON t.id=pt.tag_id WHERE pt.product_id=p.id AND t.name=$%d)", len(args)))
+	}
+	if query.MinPrice != nil {
+		args = append(args, *query.MinPrice)
+		conditions = append(conditions, fmt.Sprintf("%s >= $%d", basePrice, len(args)))
+	}
+	if query.MaxPrice != nil {
+		args = append(args, *query.MaxPrice)
+		conditions = append(conditions, fmt.Sprintf("%s <= $%d", basePrice, len(args)))
+	}
+	return conditions, args
+}
+
+func where(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// GetByIDs get products by ids
+func (pri *productsImpl) GetByIDs(ids []uuid.UUID) ([]entity.Product, error) {
 	products := []entity.Product{}
 
-	rows, err := pri.db.Query(getAllProducts)
+	strIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strIDs = append(strIDs, id.String())
+	}
+
+	rows, err := pri.db.Query(getProductsByIDs, pq.Array(strIDs))
 	if err != nil {
 		return products, err
 	}
 	defer rows.Close()
 
+	return pri.scanProducts(rows)
+}
+
+func (pri *productsImpl) scanProducts(rows *sql.Rows) ([]entity.Product, error) {
+	products := []entity.Product{}
 	for rows.Next() {
 		p := entity.Product{}
-		err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.CreatedAt)
+		err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Currency, &p.Unit, &p.MinAmount, &p.Step, &p.CreatedAt, &p.CategoryID, &p.Category, pq.Array(&p.Tags))
 		if err != nil {
 			return products, err
 		}
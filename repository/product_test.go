@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -15,67 +16,222 @@ var (
 )
 
 var (
-	productOne = entity.Product{
-		ID:        uuid.New(),
-		Name:      "Product 1",
-		Price:     10.0,
-		CreatedAt: time.Now(),
+	categoryUUID   = uuid.New()
+	productColumns = []string{"id", "name", "price", "currency", "unit", "min_amount", "step", "created_at", "category_id", "category", "tags"}
+	productOne     = entity.Product{
+		ID:         uuid.New(),
+		Name:       "Product 1",
+		Price:      10.0,
+		Currency:   "EUR",
+		Unit:       entity.UnitKg,
+		MinAmount:  0.5,
+		Step:       0.1,
+		CreatedAt:  time.Now(),
+		CategoryID: &categoryUUID,
+		Category:   "citrus",
+		Tags:       []string{"local", "organic"},
 	}
 )
 
-func TestGetAll(t *testing.T) {
+func addProductRow(rows *sqlmock.Rows, p entity.Product) *sqlmock.Rows {
+	return rows.AddRow(p.ID, p.Name, p.Price, p.Currency, p.Unit, p.MinAmount, p.Step, p.CreatedAt, p.CategoryID.String(), p.Category, "{"+strings.Join(p.Tags, ",")+"}")
+}
+
+func TestFind(t *testing.T) {
 	type expected struct {
 		products []entity.Product
+		total    int
 		isErr    bool
 	}
 	type payload struct {
+		query   entity.ProductQuery
 		sqlMock func(sqlMock sqlmock.Sqlmock)
 	}
 
+	minPrice, maxPrice := float32(1), float32(5)
+	// 11 in base currency goes before 10 EUR, which is about 11.9 in base currency
+	productBase := productOne
+	productBase.ID = uuid.New()
+	productBase.Price = 11
+	productBase.Currency = ""
+	afterID := uuid.New()
+
 	tc := []struct {
 		name string
 		expected
 		payload
 	}{
 		{
-			name: "Get all with success",
+			name: "Find without filter with success",
 			expected: expected{
 				products: []entity.Product{productOne},
-				isErr:    false,
+				total:    1,
 			},
 			payload: payload{
+				query: entity.ProductQuery{Limit: 21},
 				sqlMock: func(mock sqlmock.Sqlmock) {
-					rows := sqlmock.NewRows([]string{"id", "name", "price", "created_at"}).
-						AddRow(productOne.ID, productOne.Name, productOne.Price, productOne.CreatedAt)
-
-					mock.ExpectQuery(getAllProducts).WillReturnRows(rows)
+					mock.ExpectQuery(`SELECT count\(\*\) FROM products p LEFT JOIN categories c ON c.id=p.category_id LEFT JOIN exchange_rates r ON r.currency=p.currency$`).
+						WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+					rows := addProductRow(sqlmock.NewRows(productColumns), productOne)
+					mock.ExpectQuery(`ON r.currency=p.currency ORDER BY p.created_at ASC, p.id ASC LIMIT \$1`).WithArgs(21).WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "Find with search, category, tag, price range and cursor with success",
+			expected: expected{
+				products: []entity.Product{},
+				total:    3,
+			},
+			payload: payload{
+				query: entity.ProductQuery{
+					Search:   "50%_apple",
+					Category: "tropical",
+					Tag:      "organic",
+					MinPrice: &minPrice,
+					MaxPrice: &maxPrice,
+					Sort:     entity.ProductSortPrice,
+					Desc:     true,
+					Limit:    11,
+					After:    &entity.ProductCursor{Sort: entity.ProductSortPrice, Desc: true, Value: "2.5", ID: afterID},
+				},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery(`SELECT count\(\*\) FROM products p LEFT JOIN categories c ON c.id=p.category_id LEFT JOIN exchange_rates r ON r.currency=p.currency `+
+						`WHERE \(p.name ILIKE \$1 OR \$2 <% p.name\) AND \(c.path = \$3 OR c.path LIKE \$4\) `+
+						`AND EXISTS \(SELECT 1 FROM products_tags pt JOIN tags t ON t.id=pt.tag_id WHERE pt.product_id=p.id AND t.name=\$5\) `+
+						`AND p.price / COALESCE\(r.rate, 1\) >= \$6 AND p.price / COALESCE\(r.rate, 1\) <= \$7$`).
+						WithArgs(`%50\%\_apple%`, "50%_apple", "tropical", "tropical/%", "organic", minPrice, maxPrice).
+						WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+					mock.ExpectQuery(`<= \$7 AND \(p.price / COALESCE\(r.rate, 1\), p.id\) < \(\$8, \$9\) ORDER BY p.price / COALESCE\(r.rate, 1\) DESC, p.id DESC LIMIT \$10`).
+						WithArgs(`%50\%\_apple%`, "50%_apple", "tropical", "tropical/%", "organic", minPrice, maxPrice, "2.5", afterID, 11).
+						WillReturnRows(sqlmock.NewRows(productColumns))
+				},
+			},
+		},
+		{
+			name: "Find products of mixed currencies by base price with success",
+			expected: expected{
+				products: []entity.Product{productBase, productOne},
+				total:    2,
+			},
+			payload: payload{
+				query: entity.ProductQuery{MaxPrice: &maxPrice, Sort: entity.ProductSortPrice, Limit: 21},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery(`LEFT JOIN exchange_rates r ON r.currency=p.currency WHERE p.price / COALESCE\(r.rate, 1\) <= \$1$`).
+						WithArgs(maxPrice).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+					rows := addProductRow(addProductRow(sqlmock.NewRows(productColumns), productBase), productOne)
+					mock.ExpectQuery(`ORDER BY p.price / COALESCE\(r.rate, 1\) ASC, p.id ASC LIMIT \$2`).WithArgs(maxPrice, 21).WillReturnRows(rows)
 				},
 			},
 		},
 		{
-			name: "Get all wrong field with failed",
+			name: "Find wrong field with failed",
 			expected: expected{
 				products: []entity.Product{},
+				total:    1,
 				isErr:    true,
 			},
 			payload: payload{
+				query: entity.ProductQuery{Limit: 21},
 				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery(`SELECT count\(\*\) FROM products`).
+						WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 					rows := sqlmock.NewRows([]string{"id", "name", "wrong"}).
 						AddRow(productOne.ID, productOne.Name, ErrDB)
+					mock.ExpectQuery(`ORDER BY`).WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "Find count error with failed",
+			expected: expected{
+				products: []entity.Product{},
+				isErr:    true,
+			},
+			payload: payload{
+				query: entity.ProductQuery{Limit: 21},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery(`SELECT count\(\*\) FROM products`).WillReturnError(ErrNotFound)
+				},
+			},
+		},
+		{
+			name: "Find db error with failed",
+			expected: expected{
+				products: []entity.Product{},
+				isErr:    true,
+			},
+			payload: payload{
+				query: entity.ProductQuery{Limit: 21},
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery(`SELECT count\(\*\) FROM products`).
+						WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+					mock.ExpectQuery(`ORDER BY`).WillReturnError(ErrNotFound)
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			products, total, err := NewProduct(db).Find(test.payload.query)
+			assert.Equal(t, test.expected.products, products)
+			assert.Equal(t, test.expected.total, total)
+			if test.expected.isErr {
+				assert.NotNil(t, err)
+			}
+		})
+	}
+}
+
+func TestGetByIDs(t *testing.T) {
+	type expected struct {
+		products []entity.Product
+		isErr    bool
+	}
+	type payload struct {
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Get by ids with success",
+			expected: expected{
+				products: []entity.Product{productOne},
+				isErr:    false,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := addProductRow(sqlmock.NewRows(productColumns), productOne)
 
-					mock.ExpectQuery(getAllProducts).WillReturnRows(rows)
+					mock.ExpectQuery("ON r.currency=p.currency WHERE p.id = ANY").WillReturnRows(rows)
 				},
 			},
 		},
 		{
-			name: "Get all db error with failed",
+			name: "Get by ids db error with failed",
 			expected: expected{
 				products: []entity.Product{},
 				isErr:    true,
 			},
 			payload: payload{
 				sqlMock: func(mock sqlmock.Sqlmock) {
-					mock.ExpectQuery(getAllProducts).WillReturnError(ErrNotFound)
+					mock.ExpectQuery("ON r.currency=p.currency WHERE p.id = ANY").WillReturnError(ErrNotFound)
 				},
 			},
 		},
@@ -94,7 +250,7 @@ func TestGetAll(t *testing.T) {
 
 			test.payload.sqlMock(mock)
 
-			products, err := NewProduct(db).GetAll()
+			products, err := NewProduct(db).GetByIDs([]uuid.UUID{productOne.ID})
 			assert.Equal(t, products, test.expected.products)
 			if test.expected.isErr {
 				assert.NotNil(t, err)
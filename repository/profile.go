@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+//go:generate mockgen -destination=mock/profile.go -package=repomock github.com/mshto/fruit-store/repository Profile
+
+// Profile interface
+type Profile interface {
+	GetProfile(userUUID uuid.UUID) (*entity.Profile, error)
+	UpdateProfile(profile *entity.Profile) error
+	DeleteUser(userUUID uuid.UUID) error
+}
+
+// NewProfile generate new profile repo
+func NewProfile(db *sql.DB) Profile {
+	return &profileImpl{
+		db: db,
+	}
+}
+
+type profileImpl struct {
+	db *sql.DB
+}
+
+var (
+	getProfile = `SELECT id, username, email, display_name, address_line1, address_line2, city, postal_code, country FROM users WHERE id=$1`
+	// empty email is stored as NULL so it doesn't conflict with the unique email index
+	updateProfile = `UPDATE users SET email=NULLIF($2, ''), display_name=$3, address_line1=$4, address_line2=$5, city=$6, postal_code=$7, country=$8 WHERE id=$1`
+
+	// rows of service accounts owned by user are removed as well, users rows cascade to keys, identities and recovery codes
+	deleteUserCart        = `DELETE FROM users_cart WHERE user_id=$1 OR user_id IN (SELECT id FROM users WHERE owner_id=$1)`
+	deleteUserCartVersion = `DELETE FROM users_cart_version WHERE user_id=$1 OR user_id IN (SELECT id FROM users WHERE owner_id=$1)`
+	deleteUser            = `DELETE FROM users WHERE id=$1`
+)
+
+// GetProfile get profile of user
+func (pri *profileImpl) GetProfile(userUUID uuid.UUID) (*entity.Profile, error) {
+	profile := &entity.Profile{}
+	var email sql.NullString
+
+	err := pri.db.QueryRow(getProfile, userUUID).Scan(&profile.ID, &profile.Username, &email, &profile.DisplayName,
+		&profile.Address.Line1, &profile.Address.Line2, &profile.Address.City, &profile.Address.PostalCode, &profile.Address.Country)
+	if err == sql.ErrNoRows {
+		return profile, entity.ErrUserNotFound
+	}
+	profile.Email = email.String
+	return profile, err
+}
+
+// UpdateProfile store all profile fields of user
+func (pri *profileImpl) UpdateProfile(profile *entity.Profile) error {
+	res, err := pri.db.Exec(updateProfile, profile.ID, profile.Email, profile.DisplayName,
+		profile.Address.Line1, profile.Address.Line2, profile.Address.City, profile.Address.PostalCode, profile.Address.Country)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return entity.ErrEmailAlreadyUsed
+	}
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrUserNotFound)
+}
+
+// DeleteUser remove user with its cart and service accounts in one transaction
+func (pri *profileImpl) DeleteUser(userUUID uuid.UUID) error {
+	tx, err := pri.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, query := range []string{deleteUserCart, deleteUserCartVersion} {
+		_, err = tx.Exec(query, userUUID)
+		if err != nil {
+			_ = tx.Rollback() // nolint
+			return err
+		}
+	}
+
+	res, err := tx.Exec(deleteUser, userUUID)
+	if err == nil {
+		err = affectedOrErr(res, entity.ErrUserNotFound)
+	}
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		return err
+	}
+
+	return tx.Commit()
+}
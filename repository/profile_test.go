@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+var (
+	profileColumns = []string{"id", "username", "email", "display_name", "address_line1", "address_line2", "city", "postal_code", "country"}
+	profile        = entity.Profile{
+		ID:          userUUID,
+		Username:    "test",
+		Email:       "test@example.com",
+		DisplayName: "Test",
+		Address:     entity.Address{Line1: "Main st. 1", City: "Kyiv", PostalCode: "01001", Country: "UA"},
+	}
+)
+
+func TestGetProfile(t *testing.T) {
+	type expected struct {
+		profile entity.Profile
+		err     error
+	}
+	type payload struct {
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "GetProfile with success",
+			expected: expected{
+				profile: profile,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows(profileColumns).AddRow(userUUID, "test", "test@example.com", "Test", "Main st. 1", "", "Kyiv", "01001", "UA")
+					mock.ExpectQuery("SELECT id, username, email, display_name").WithArgs(userUUID).WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "GetProfile without email with success",
+			expected: expected{
+				profile: entity.Profile{ID: userUUID, Username: "test"},
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows(profileColumns).AddRow(userUUID, "test", nil, "", "", "", "", "", "")
+					mock.ExpectQuery("SELECT id, username, email, display_name").WithArgs(userUUID).WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "GetProfile ErrNoRows error with failed",
+			expected: expected{
+				err: entity.ErrUserNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery("SELECT id, username, email, display_name").WithArgs(userUUID).WillReturnError(sql.ErrNoRows)
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			result, err := NewProfile(db).GetProfile(userUUID)
+			assert.Equal(t, test.expected.err, err)
+			if err == nil {
+				assert.Equal(t, test.expected.profile, *result)
+			}
+		})
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	type expected struct {
+		err error
+	}
+	type payload struct {
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	args := []driver.Value{userUUID, "test@example.com", "Test", "Main st. 1", "", "Kyiv", "01001", "UA"}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "UpdateProfile with success",
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectExec("UPDATE users SET email").WithArgs(args...).WillReturnResult(sqlmock.NewResult(0, 1))
+				},
+			},
+		},
+		{
+			name: "UpdateProfile email is used with failed",
+			expected: expected{
+				err: entity.ErrEmailAlreadyUsed,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectExec("UPDATE users SET email").WithArgs(args...).WillReturnError(&pq.Error{Code: uniqueViolation})
+				},
+			},
+		},
+		{
+			name: "UpdateProfile unknown user with failed",
+			expected: expected{
+				err: entity.ErrUserNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectExec("UPDATE users SET email").WithArgs(args...).WillReturnResult(sqlmock.NewResult(0, 0))
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			updated := profile
+			err = NewProfile(db).UpdateProfile(&updated)
+			assert.Equal(t, test.expected.err, err)
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	type expected struct {
+		err error
+	}
+	type payload struct {
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "DeleteUser with success",
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectBegin()
+					mock.ExpectExec("DELETE FROM users_cart WHERE").WithArgs(userUUID).WillReturnResult(sqlmock.NewResult(0, 2))
+					mock.ExpectExec("DELETE FROM users_cart_version").WithArgs(userUUID).WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectExec("DELETE FROM users WHERE").WithArgs(userUUID).WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectCommit()
+				},
+			},
+		},
+		{
+			name: "DeleteUser unknown user with failed",
+			expected: expected{
+				err: entity.ErrUserNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectBegin()
+					mock.ExpectExec("DELETE FROM users_cart WHERE").WithArgs(userUUID).WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec("DELETE FROM users_cart_version").WithArgs(userUUID).WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec("DELETE FROM users WHERE").WithArgs(userUUID).WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectRollback()
+				},
+			},
+		},
+		{
+			name: "DeleteUser db error with failed",
+			expected: expected{
+				err: ErrNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectBegin()
+					mock.ExpectExec("DELETE FROM users_cart WHERE").WithArgs(userUUID).WillReturnError(ErrNotFound)
+					mock.ExpectRollback()
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			err = NewProfile(db).DeleteUser(userUUID)
+			assert.Equal(t, test.expected.err, err)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
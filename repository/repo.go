@@ -7,17 +7,39 @@ import (
 // New initializes new repo container for each table entity
 func New(db *sql.DB) *Repository {
 	return &Repository{
-		Product:  NewProduct(db),
-		Cart:     NewCartProduct(db),
-		Auth:     NewAuth(db),
-		Discount: NewDiscount(db),
+		Product:    NewProduct(db),
+		Cart:       NewCartProduct(db),
+		Auth:       NewAuth(db),
+		Discount:   NewDiscount(db),
+		TwoFactor:  NewTwoFactor(db),
+		APIKeys:    NewAPIKeys(db),
+		Profile:    NewProfile(db),
+		Audit:      NewAudit(db),
+		Catalog:    NewCatalog(db),
+		Media:      NewMedia(db),
+		Prices:     NewPrices(db),
+		Currencies: NewCurrencies(db),
+		Delivery:   NewDelivery(db),
+		Slots:      NewSlots(db),
+		Orders:     NewOrders(db),
 	}
 }
 
 // Repository container for each table entity
 type Repository struct {
-	Product  Products
-	Cart     Cart
-	Auth     Auth
-	Discount Discount
+	Product    Products
+	Cart       Cart
+	Auth       Auth
+	Discount   Discount
+	TwoFactor  TwoFactor
+	APIKeys    APIKeys
+	Profile    Profile
+	Audit      Audit
+	Catalog    Catalog
+	Media      Media
+	Prices     Prices
+	Currencies Currencies
+	Delivery   Delivery
+	Slots      Slots
+	Orders     Orders
 }
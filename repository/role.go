@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+var (
+	getUserRole    = "SELECT role FROM users WHERE id=$1"
+	updateUserRole = "UPDATE users SET role=$2 WHERE id=$1"
+)
+
+// GetUserRole get role of user
+func (aui *authImpl) GetUserRole(userUUID uuid.UUID) (string, error) {
+	var role string
+	err := aui.db.QueryRow(getUserRole, userUUID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return role, entity.ErrUserNotFound
+	}
+	return role, err
+}
+
+// UpdateUserRole set role of user
+func (aui *authImpl) UpdateUserRole(userUUID uuid.UUID, role string) error {
+	res, err := aui.db.Exec(updateUserRole, userUUID, role)
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrUserNotFound)
+}
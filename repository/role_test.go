@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+func TestGetUserRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT role FROM users").WithArgs(userUUID).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow(entity.RoleAdmin))
+	mock.ExpectQuery("SELECT role FROM users").WithArgs(userUUID).WillReturnError(sql.ErrNoRows)
+
+	repo := NewAuth(db)
+	role, err := repo.GetUserRole(userUUID)
+	assert.Equal(t, entity.RoleAdmin, role)
+	assert.Nil(t, err)
+
+	_, err = repo.GetUserRole(userUUID)
+	assert.Equal(t, entity.ErrUserNotFound, err)
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE users SET role").WithArgs(userUUID, entity.RoleAdmin).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET role").WithArgs(userUUID, entity.RoleAdmin).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := NewAuth(db)
+	assert.Nil(t, repo.UpdateUserRole(userUUID, entity.RoleAdmin))
+	assert.Equal(t, entity.ErrUserNotFound, repo.UpdateUserRole(userUUID, entity.RoleAdmin))
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+//go:generate mockgen -destination=mock/slot.go -package=repomock github.com/mshto/fruit-store/repository Slots
+
+// Slots interface
+type Slots interface {
+	GetSlots(from, to time.Time) ([]entity.DeliverySlot, error)
+	GetSlot(slotUUID uuid.UUID) (*entity.DeliverySlot, error)
+	CreateSlot(slot *entity.DeliverySlot) error
+	DeleteSlot(slotUUID uuid.UUID) error
+
+	SetCartSlot(userUUID uuid.UUID, slotUUID *uuid.UUID) error
+}
+
+// NewSlots generate new delivery slots repo
+func NewSlots(db *sql.DB) Slots {
+	return &slotsImpl{
+		db: db,
+	}
+}
+
+type slotsImpl struct {
+	db *sql.DB
+}
+
+var (
+	getSlots = `SELECT id, starts_at, ends_at, capacity, booked FROM delivery_slots ` +
+		`WHERE starts_at >= $1 AND starts_at < $2 ORDER BY starts_at, id`
+	getSlot     = `SELECT id, starts_at, ends_at, capacity, booked FROM delivery_slots WHERE id=$1`
+	createSlot  = `INSERT INTO delivery_slots (starts_at, ends_at, capacity) VALUES ($1, $2, $3) RETURNING id`
+	deleteSlot  = `DELETE FROM delivery_slots WHERE id=$1`
+	setCartSlot = `UPDATE users_cart_delivery SET slot_id=$2 WHERE user_id=$1`
+	// the row lock serializes concurrent payments of the same cart
+	claimCartSlot = `UPDATE users_cart_delivery SET slot_id=NULL WHERE user_id=$1 AND slot_id=$2`
+	// the row lock serializes concurrent bookings of the slot, so booked never exceeds capacity
+	bookSlot      = `UPDATE delivery_slots SET booked=booked+1 WHERE id=$1 AND booked < capacity`
+	createBooking = `INSERT INTO delivery_slot_bookings (slot_id, user_id, order_id) VALUES ($1, $2, $3)`
+)
+
+// GetSlots get delivery slots starting in [from, to), earliest first
+func (sli *slotsImpl) GetSlots(from, to time.Time) ([]entity.DeliverySlot, error) {
+	slots := []entity.DeliverySlot{}
+
+	rows, err := sli.db.Query(getSlots, from, to)
+	if err != nil {
+		return slots, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		slot := entity.DeliverySlot{}
+		err := rows.Scan(&slot.ID, &slot.StartsAt, &slot.EndsAt, &slot.Capacity, &slot.Booked)
+		if err != nil {
+			return slots, err
+		}
+		slots = append(slots, slot)
+	}
+	return slots, rows.Err()
+}
+
+// GetSlot get delivery slot by id
+func (sli *slotsImpl) GetSlot(slotUUID uuid.UUID) (*entity.DeliverySlot, error) {
+	slot := &entity.DeliverySlot{}
+	err := sli.db.QueryRow(getSlot, slotUUID).Scan(&slot.ID, &slot.StartsAt, &slot.EndsAt, &slot.Capacity, &slot.Booked)
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrSlotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return slot, nil
+}
+
+// CreateSlot store delivery slot and set its id
+func (sli *slotsImpl) CreateSlot(slot *entity.DeliverySlot) error {
+	return sli.db.QueryRow(createSlot, slot.StartsAt, slot.EndsAt, slot.Capacity).Scan(&slot.ID)
+}
+
+// DeleteSlot delete delivery slot without bookings, carts it is reserved for lose it
+func (sli *slotsImpl) DeleteSlot(slotUUID uuid.UUID) error {
+	res, err := sli.db.Exec(deleteSlot, slotUUID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == foreignKeyViolation {
+		return entity.ErrSlotHasBookings
+	}
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrSlotNotFound)
+}
+
+// SetCartSlot set delivery slot reserved for user cart, nil slot removes it
+func (sli *slotsImpl) SetCartSlot(userUUID uuid.UUID, slotUUID *uuid.UUID) error {
+	res, err := sli.db.Exec(setCartSlot, userUUID, slotUUID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == foreignKeyViolation {
+		return entity.ErrSlotNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrDeliveryNotSelected)
+}
+
+// bookCartSlot book a place of delivery slot reserved for user cart for order within transaction,
+// the slot is removed from the cart and full slot isn't booked
+func bookCartSlot(tx *sql.Tx, userUUID, slotUUID, orderUUID uuid.UUID) error {
+	res, err := tx.Exec(claimCartSlot, userUUID, slotUUID)
+	if err == nil {
+		err = affectedOrErr(res, entity.ErrSlotNotReserved)
+	}
+	if err != nil {
+		return err
+	}
+
+	res, err = tx.Exec(bookSlot, slotUUID)
+	if err == nil {
+		err = affectedOrErr(res, entity.ErrSlotFull)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(createBooking, slotUUID, userUUID, orderUUID)
+	return err
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mshto/fruit-store/cache"
+	"github.com/mshto/fruit-store/entity"
+)
+
+//go:generate mockgen -destination=mock/slot_hold.go -package=repomock github.com/mshto/fruit-store/repository SlotHolds
+
+// holds of slot are sorted set of users scored by expiration time in milliseconds
+var slotHoldsPattern = "%s_slot_holds"
+
+// SlotHolds interface
+type SlotHolds interface {
+	Hold(slotUUID, userUUID uuid.UUID, limit int) (time.Time, error)
+	IsHeld(slotUUID, userUUID uuid.UUID) (bool, error)
+	Release(slotUUID, userUUID uuid.UUID) error
+	CountHeld(slotUUIDs []uuid.UUID) (map[uuid.UUID]int, error)
+}
+
+// NewSlotHolds generate holds of delivery slots for user carts, stored in cache until ttl expires
+func NewSlotHolds(cache cache.Cache, ttl time.Duration) SlotHolds {
+	return &slotHoldsImpl{
+		cache: cache,
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+type slotHoldsImpl struct {
+	cache cache.Cache
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// Hold hold delivery slot for user unless limit of holds of other users is reached,
+// hold of user is prolonged and its expiration time is returned
+func (shi *slotHoldsImpl) Hold(slotUUID, userUUID uuid.UUID, limit int) (time.Time, error) {
+	now := shi.now()
+	expiresAt := now.Add(shi.ttl)
+
+	added, err := shi.cache.ZAddLimited(holdsKey(slotUUID), userUUID.String(), millis(expiresAt), millis(now), int64(limit), expiresAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !added {
+		return time.Time{}, entity.ErrSlotFull
+	}
+	return expiresAt, nil
+}
+
+// IsHeld whether hold of delivery slot for user didn't expire
+func (shi *slotHoldsImpl) IsHeld(slotUUID, userUUID uuid.UUID) (bool, error) {
+	expiresAt, err := shi.cache.ZScore(holdsKey(slotUUID), userUUID.String())
+	if err == cache.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return expiresAt > millis(shi.now()), nil
+}
+
+// Release remove hold of delivery slot for user, missing hold is released as well
+func (shi *slotHoldsImpl) Release(slotUUID, userUUID uuid.UUID) error {
+	err := shi.cache.ZRem(holdsKey(slotUUID), userUUID.String())
+	if err == cache.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// CountHeld count holds of delivery slots which didn't expire
+func (shi *slotHoldsImpl) CountHeld(slotUUIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	held := make(map[uuid.UUID]int, len(slotUUIDs))
+	now := millis(shi.now())
+
+	for _, slotUUID := range slotUUIDs {
+		count, err := shi.cache.ZCount(holdsKey(slotUUID), now)
+		if err != nil {
+			return held, err
+		}
+		held[slotUUID] = int(count)
+	}
+	return held, nil
+}
+
+func holdsKey(slotUUID uuid.UUID) string {
+	return fmt.Sprintf(slotHoldsPattern, slotUUID)
+}
+
+func millis(t time.Time) float64 {
+	return float64(t.UnixNano() / int64(time.Millisecond))
+}
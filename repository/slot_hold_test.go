@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/cache"
+	"github.com/mshto/fruit-store/entity"
+)
+
+var slotUUID = uuid.MustParse("7c2e5b5c-3610-11eb-adc1-0242ac120002")
+
+func newSlotHolds(t *testing.T, now func() time.Time) (*slotHoldsImpl, func()) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal("failed to init miniredis")
+	}
+
+	redis, err := cache.New(cache.Redis{Address: s.Addr()})
+	if err != nil {
+		t.Fatal("failed to init cache")
+	}
+	return &slotHoldsImpl{cache: redis, ttl: 15 * time.Minute, now: now}, s.Close
+}
+
+func TestSlotHolds(t *testing.T) {
+	start := time.Now()
+	now := start
+	holds, closeRedis := newSlotHolds(t, func() time.Time { return now })
+	defer closeRedis()
+
+	first, second := uuid.New(), uuid.New()
+
+	expiresAt, err := holds.Hold(slotUUID, first, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, start.Add(15*time.Minute), expiresAt)
+
+	_, err = holds.Hold(slotUUID, second, 1)
+	assert.Equal(t, entity.ErrSlotFull, err)
+
+	now = start.Add(time.Minute)
+	expiresAt, err = holds.Hold(slotUUID, first, 1)
+	assert.Nil(t, err, "hold of user is prolonged without taking another place")
+	assert.Equal(t, now.Add(15*time.Minute), expiresAt)
+
+	held, err := holds.IsHeld(slotUUID, first)
+	assert.Nil(t, err)
+	assert.True(t, held)
+
+	now = start.Add(17 * time.Minute)
+	held, err = holds.IsHeld(slotUUID, first)
+	assert.Nil(t, err)
+	assert.False(t, held, "hold expired")
+
+	count, err := holds.CountHeld([]uuid.UUID{slotUUID})
+	assert.Nil(t, err)
+	assert.Equal(t, map[uuid.UUID]int{slotUUID: 0}, count)
+
+	_, err = holds.Hold(slotUUID, second, 1)
+	assert.Nil(t, err, "expired hold frees the place")
+
+	err = holds.Release(slotUUID, second)
+	assert.Nil(t, err)
+	held, err = holds.IsHeld(slotUUID, second)
+	assert.Nil(t, err)
+	assert.False(t, held)
+
+	_, err = holds.Hold(slotUUID, first, 0)
+	assert.Equal(t, entity.ErrSlotFull, err, "booked slot can't be held")
+}
+
+func TestSlotHoldsConcurrent(t *testing.T) {
+	holds, closeRedis := newSlotHolds(t, time.Now)
+	defer closeRedis()
+
+	const (
+		capacity = 5
+		users    = 50
+	)
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		held []uuid.UUID
+	)
+	for i := 0; i < users; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			userUUID := uuid.New()
+			// every user holds twice to race with own hold as well
+			for j := 0; j < 2; j++ {
+				_, err := holds.Hold(slotUUID, userUUID, capacity)
+				if err == entity.ErrSlotFull {
+					return
+				}
+				assert.Nil(t, err)
+			}
+
+			mu.Lock()
+			held = append(held, userUUID)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, held, capacity)
+
+	count, err := holds.CountHeld([]uuid.UUID{slotUUID, uuid.New()})
+	assert.Nil(t, err)
+	assert.Equal(t, capacity, count[slotUUID])
+	assert.Len(t, count, 2)
+
+	for _, userUUID := range held {
+		isHeld, err := holds.IsHeld(slotUUID, userUUID)
+		assert.Nil(t, err)
+		assert.True(t, isHeld)
+	}
+}
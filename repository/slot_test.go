@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+var (
+	slotStartsAt = time.Date(2020, 12, 5, 9, 0, 0, 0, time.UTC)
+	slotOne      = entity.DeliverySlot{ID: slotUUID, StartsAt: slotStartsAt, EndsAt: slotStartsAt.Add(2 * time.Hour), Capacity: 5, Booked: 2}
+	slotColumns  = []string{"id", "starts_at", "ends_at", "capacity", "booked"}
+)
+
+func TestGetSlots(t *testing.T) {
+	type expected struct {
+		slots []entity.DeliverySlot
+		err   error
+	}
+	from, to := slotStartsAt.Truncate(24*time.Hour), slotStartsAt.Truncate(24*time.Hour).Add(24*time.Hour)
+
+	tc := []struct {
+		name     string
+		expected expected
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name:     "Get slots with success",
+			expected: expected{slots: []entity.DeliverySlot{slotOne}},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(slotColumns).AddRow(slotUUID, slotOne.StartsAt, slotOne.EndsAt, 5, 2)
+				mock.ExpectQuery("FROM delivery_slots").WithArgs(from, to).WillReturnRows(rows)
+			},
+		},
+		{
+			name:     "Get slots with db error",
+			expected: expected{slots: []entity.DeliverySlot{}, err: ErrNotFound},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM delivery_slots").WillReturnError(ErrNotFound)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			slots, err := NewSlots(db).GetSlots(from, to)
+			assert.Equal(t, test.expected.err, err)
+			assert.Equal(t, test.expected.slots, slots)
+		})
+	}
+}
+
+func TestGetSlot(t *testing.T) {
+	type expected struct {
+		slot *entity.DeliverySlot
+		err  error
+	}
+
+	tc := []struct {
+		name     string
+		expected expected
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name:     "Get slot with success",
+			expected: expected{slot: &slotOne},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(slotColumns).AddRow(slotUUID, slotOne.StartsAt, slotOne.EndsAt, 5, 2)
+				mock.ExpectQuery("FROM delivery_slots WHERE id").WithArgs(slotUUID).WillReturnRows(rows)
+			},
+		},
+		{
+			name:     "Get unknown slot",
+			expected: expected{err: entity.ErrSlotNotFound},
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM delivery_slots WHERE id").WillReturnRows(sqlmock.NewRows(slotColumns))
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			slot, err := NewSlots(db).GetSlot(slotUUID)
+			assert.Equal(t, test.expected.err, err)
+			assert.Equal(t, test.expected.slot, slot)
+		})
+	}
+}
+
+func TestCreateSlot(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("INSERT INTO delivery_slots").WithArgs(slotOne.StartsAt, slotOne.EndsAt, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(slotUUID))
+
+	slot := &entity.DeliverySlot{StartsAt: slotOne.StartsAt, EndsAt: slotOne.EndsAt, Capacity: 5}
+	err = NewSlots(db).CreateSlot(slot)
+	assert.Nil(t, err)
+	assert.Equal(t, slotUUID, slot.ID)
+}
+
+func TestDeleteSlot(t *testing.T) {
+	tc := []struct {
+		name     string
+		expected error
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "Delete slot with success",
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM delivery_slots").WithArgs(slotUUID).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "Delete unknown slot",
+			expected: entity.ErrSlotNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM delivery_slots").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name:     "Delete slot with bookings",
+			expected: entity.ErrSlotHasBookings,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM delivery_slots").WillReturnError(&pq.Error{Code: foreignKeyViolation})
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			err = NewSlots(db).DeleteSlot(slotUUID)
+			assert.Equal(t, test.expected, err)
+		})
+	}
+}
+
+func TestSetCartSlot(t *testing.T) {
+	tc := []struct {
+		name     string
+		slotUUID *uuid.UUID
+		expected error
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name:     "Set cart slot with success",
+			slotUUID: &slotUUID,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE users_cart_delivery SET slot_id").WithArgs(userUUID, &slotUUID).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "Remove cart slot with success",
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE users_cart_delivery SET slot_id").WithArgs(userUUID, nil).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "Set cart slot without delivery",
+			slotUUID: &slotUUID,
+			expected: entity.ErrDeliveryNotSelected,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE users_cart_delivery SET slot_id").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name:     "Set cart slot deleted meanwhile",
+			slotUUID: &slotUUID,
+			expected: entity.ErrSlotNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE users_cart_delivery SET slot_id").WillReturnError(&pq.Error{Code: foreignKeyViolation})
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			err = NewSlots(db).SetCartSlot(userUUID, test.slotUUID)
+			assert.Equal(t, test.expected, err)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+//go:generate mockgen -destination=mock/two_factor.go -package=repomock github.com/mshto/fruit-store/repository TwoFactor
+
+// TwoFactor interface
+type TwoFactor interface {
+	GetTOTP(userUUID uuid.UUID) (*entity.TOTP, error)
+	SetTOTPSecret(userUUID uuid.UUID, secret string) error
+	EnableTOTP(userUUID uuid.UUID, recoveryCodeHashes []string) error
+	UseTOTPStep(userUUID uuid.UUID, step int64) error
+	UseRecoveryCode(userUUID uuid.UUID, codeHash string) error
+}
+
+// NewTwoFactor generate new two-factor repo
+func NewTwoFactor(db *sql.DB) TwoFactor {
+	return &twoFactorImpl{
+		db: db,
+	}
+}
+
+type twoFactorImpl struct {
+	db *sql.DB
+}
+
+var (
+	getTOTP             = `SELECT totp_secret, totp_enabled FROM users WHERE id=$1`
+	setTOTPSecret       = `UPDATE users SET totp_secret=$2 WHERE id=$1 AND NOT totp_enabled`
+	enableTOTP          = `UPDATE users SET totp_enabled=true WHERE id=$1 AND NOT totp_enabled AND totp_secret IS NOT NULL`
+	useTOTPStep         = `UPDATE users SET totp_last_step=$2 WHERE id=$1 AND (totp_last_step IS NULL OR totp_last_step < $2)`
+	deleteRecoveryCodes = `DELETE FROM users_recovery_codes WHERE user_id=$1`
+	createRecoveryCodes = `INSERT INTO users_recovery_codes (user_id, code_hash) SELECT $1, unnest($2::text[])`
+	useRecoveryCode     = `DELETE FROM users_recovery_codes WHERE user_id=$1 AND code_hash=$2`
+)
+
+// GetTOTP get encrypted TOTP secret of user, secret is empty until set up
+func (tfi *twoFactorImpl) GetTOTP(userUUID uuid.UUID) (*entity.TOTP, error) {
+	var secret sql.NullString
+	totp := &entity.TOTP{}
+
+	err := tfi.db.QueryRow(getTOTP, userUUID).Scan(&secret, &totp.Enabled)
+	if err == sql.ErrNoRows {
+		return totp, entity.ErrUserNotFound
+	}
+	totp.Secret = secret.String
+	return totp, err
+}
+
+// SetTOTPSecret set encrypted TOTP secret of user which didn't enable two-factor authentication yet
+func (tfi *twoFactorImpl) SetTOTPSecret(userUUID uuid.UUID, secret string) error {
+	res, err := tfi.db.Exec(setTOTPSecret, userUUID, secret)
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrTOTPAlreadyEnabled)
+}
+
+// EnableTOTP enable two-factor authentication and replace recovery codes in one transaction
+func (tfi *twoFactorImpl) EnableTOTP(userUUID uuid.UUID, recoveryCodeHashes []string) error {
+	tx, err := tfi.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(enableTOTP, userUUID)
+	if err == nil {
+		err = affectedOrErr(res, entity.ErrTOTPAlreadyEnabled)
+	}
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		return err
+	}
+
+	_, err = tx.Exec(deleteRecoveryCodes, userUUID)
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		return err
+	}
+
+	_, err = tx.Exec(createRecoveryCodes, userUUID, pq.Array(recoveryCodeHashes))
+	if err != nil {
+		_ = tx.Rollback() // nolint
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UseTOTPStep mark time step of TOTP code as used, code of the same or an earlier step can't be used again
+func (tfi *twoFactorImpl) UseTOTPStep(userUUID uuid.UUID, step int64) error {
+	res, err := tfi.db.Exec(useTOTPStep, userUUID, step)
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrInvalidTOTPCode)
+}
+
+// UseRecoveryCode remove recovery code of user, every code can be used only once
+func (tfi *twoFactorImpl) UseRecoveryCode(userUUID uuid.UUID, codeHash string) error {
+	res, err := tfi.db.Exec(useRecoveryCode, userUUID, codeHash)
+	if err != nil {
+		return err
+	}
+	return affectedOrErr(res, entity.ErrInvalidTOTPCode)
+}
+
+// affectedOrErr return notAffected error when statement didn't change any row
+func affectedOrErr(res sql.Result, notAffected error) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notAffected
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+func TestGetTOTP(t *testing.T) {
+	type expected struct {
+		totp entity.TOTP
+		err  error
+	}
+	type payload struct {
+		sqlMock func(sqlMock sqlmock.Sqlmock)
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "GetTOTP with success",
+			expected: expected{
+				totp: entity.TOTP{Secret: "secret", Enabled: true},
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows([]string{"totp_secret", "totp_enabled"}).AddRow("secret", true)
+					mock.ExpectQuery("SELECT totp_secret, totp_enabled FROM users").WithArgs(userUUID).WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "GetTOTP not set up with success",
+			expected: expected{
+				totp: entity.TOTP{},
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					rows := sqlmock.NewRows([]string{"totp_secret", "totp_enabled"}).AddRow(nil, false)
+					mock.ExpectQuery("SELECT totp_secret, totp_enabled FROM users").WithArgs(userUUID).WillReturnRows(rows)
+				},
+			},
+		},
+		{
+			name: "GetTOTP ErrNoRows error with failed",
+			expected: expected{
+				totp: entity.TOTP{},
+				err:  entity.ErrUserNotFound,
+			},
+			payload: payload{
+				sqlMock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectQuery("SELECT totp_secret, totp_enabled FROM users").WithArgs(userUUID).WillReturnError(sql.ErrNoRows)
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.payload.sqlMock(mock)
+
+			totp, err := NewTwoFactor(db).GetTOTP(userUUID)
+			assert.Equal(t, test.expected.totp, *totp)
+			assert.Equal(t, test.expected.err, err)
+		})
+	}
+}
+
+func TestSetTOTPSecret(t *testing.T) {
+	tc := []struct {
+		name     string
+		expected error
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "SetTOTPSecret with success",
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE users SET totp_secret").WithArgs(userUUID, "secret").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "SetTOTPSecret already enabled with failed",
+			expected: entity.ErrTOTPAlreadyEnabled,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE users SET totp_secret").WithArgs(userUUID, "secret").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			err = NewTwoFactor(db).SetTOTPSecret(userUUID, "secret")
+			assert.Equal(t, test.expected, err)
+		})
+	}
+}
+
+func TestEnableTOTP(t *testing.T) {
+	tc := []struct {
+		name     string
+		expected error
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "EnableTOTP with success",
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET totp_enabled=true").WithArgs(userUUID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM users_recovery_codes").WithArgs(userUUID).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO users_recovery_codes").WithArgs(userUUID, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:     "EnableTOTP already enabled with failed",
+			expected: entity.ErrTOTPAlreadyEnabled,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET totp_enabled=true").WithArgs(userUUID).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:     "EnableTOTP insert error with failed",
+			expected: ErrNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET totp_enabled=true").WithArgs(userUUID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM users_recovery_codes").WithArgs(userUUID).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO users_recovery_codes").WithArgs(userUUID, sqlmock.AnyArg()).WillReturnError(ErrNotFound)
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			err = NewTwoFactor(db).EnableTOTP(userUUID, []string{"first", "second"})
+			assert.Equal(t, test.expected, err)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUseTOTPStep(t *testing.T) {
+	tc := []struct {
+		name     string
+		expected error
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "UseTOTPStep with success",
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE users SET totp_last_step").WithArgs(userUUID, int64(42)).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "UseTOTPStep replayed with failed",
+			expected: entity.ErrInvalidTOTPCode,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE users SET totp_last_step").WithArgs(userUUID, int64(42)).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			err = NewTwoFactor(db).UseTOTPStep(userUUID, 42)
+			assert.Equal(t, test.expected, err)
+		})
+	}
+}
+
+func TestUseRecoveryCode(t *testing.T) {
+	tc := []struct {
+		name     string
+		expected error
+		sqlMock  func(sqlMock sqlmock.Sqlmock)
+	}{
+		{
+			name: "UseRecoveryCode with success",
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM users_recovery_codes").WithArgs(userUUID, "hash").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "UseRecoveryCode unknown code with failed",
+			expected: entity.ErrInvalidTOTPCode,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM users_recovery_codes").WithArgs(userUUID, "hash").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name:     "UseRecoveryCode db error with failed",
+			expected: ErrNotFound,
+			sqlMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM users_recovery_codes").WithArgs(userUUID, "hash").WillReturnError(ErrNotFound)
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			test.sqlMock(mock)
+
+			err = NewTwoFactor(db).UseRecoveryCode(userUUID, "hash")
+			assert.Equal(t, test.expected, err)
+		})
+	}
+}
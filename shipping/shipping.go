@@ -0,0 +1,98 @@
+package shipping
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// shipping methods
+const (
+	MethodCourier = "courier"
+	MethodPickup  = "pickup"
+)
+
+// defaultSlotHold time delivery slot is held for cart until payment
+const defaultSlotHold = 15 * time.Minute
+
+// ErrNoZone courier doesn't deliver to country
+var ErrNoZone = errors.New("courier doesn't deliver to country of address")
+
+// Shipping struct stores shipping configuration, fees and thresholds are in base currency
+type Shipping struct {
+	Zones       []Zone `json:"Zones"        validate:"dive"`
+	SlotHoldMin int    `json:"SlotHoldMin"  validate:"min=0"`
+}
+
+// Zone courier zone, zone without countries delivers to any country not listed in other zones.
+// Courier is free from FreeFrom total of products after discounts, zero FreeFrom never makes it free.
+type Zone struct {
+	Name      string   `json:"Name"      validate:"required"`
+	Countries []string `json:"Countries"`
+	Fee       float64  `json:"Fee"       validate:"min=0"`
+	FreeFrom  float64  `json:"FreeFrom"  validate:"min=0"`
+}
+
+// Quote shipping cost of method, amounts are in base currency.
+// LeftToFree is the amount left to reach free shipping, zero when it's reached or can't be.
+type Quote struct {
+	Method     string
+	Zone       string
+	Cost       float64
+	LeftToFree float64
+}
+
+// IsMethod whether method is a known shipping method
+func IsMethod(method string) bool {
+	return method == MethodCourier || method == MethodPickup
+}
+
+// SlotHold time delivery slot is held for cart until payment, 15 minutes by default
+func (s Shipping) SlotHold() time.Duration {
+	if s.SlotHoldMin == 0 {
+		return defaultSlotHold
+	}
+	return time.Duration(s.SlotHoldMin) * time.Minute
+}
+
+// Zone courier zone of country, zones listing the country win over the ones without countries
+func (s Shipping) Zone(country string) (Zone, error) {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	var fallback *Zone
+	for i, zone := range s.Zones {
+		if len(zone.Countries) == 0 && fallback == nil {
+			fallback = &s.Zones[i]
+		}
+		for _, cnt := range zone.Countries {
+			if strings.ToUpper(cnt) == country {
+				return zone, nil
+			}
+		}
+	}
+	if fallback == nil {
+		return Zone{}, ErrNoZone
+	}
+	return *fallback, nil
+}
+
+// Quote shipping cost of method to country for total of products after discounts
+func (s Shipping) Quote(method, country string, total float64) (Quote, error) {
+	if method == MethodPickup {
+		return Quote{Method: method}, nil
+	}
+
+	zone, err := s.Zone(country)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	quote := Quote{Method: method, Zone: zone.Name, Cost: zone.Fee}
+	if zone.FreeFrom > 0 {
+		if total >= zone.FreeFrom {
+			quote.Cost = 0
+		} else {
+			quote.LeftToFree = zone.FreeFrom - total
+		}
+	}
+	return quote, nil
+}
@@ -0,0 +1,91 @@
+package shipping
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var zones = Shipping{
+	Zones: []Zone{
+		{Name: "world", Fee: 20},
+		{Name: "domestic", Countries: []string{"ua"}, Fee: 5, FreeFrom: 50},
+		{Name: "europe", Countries: []string{"PL", "DE"}, Fee: 10},
+	},
+}
+
+func TestQuote(t *testing.T) {
+	tc := []struct {
+		name     string
+		shipping Shipping
+		method   string
+		country  string
+		total    float64
+		expected Quote
+		err      error
+	}{
+		{
+			name:     "Quote courier below free threshold",
+			shipping: zones,
+			method:   MethodCourier,
+			country:  "UA",
+			total:    42.5,
+			expected: Quote{Method: MethodCourier, Zone: "domestic", Cost: 5, LeftToFree: 7.5},
+		},
+		{
+			name:     "Quote courier from free threshold",
+			shipping: zones,
+			method:   MethodCourier,
+			country:  "ua",
+			total:    50,
+			expected: Quote{Method: MethodCourier, Zone: "domestic"},
+		},
+		{
+			name:     "Quote courier without free threshold",
+			shipping: zones,
+			method:   MethodCourier,
+			country:  "DE",
+			total:    500,
+			expected: Quote{Method: MethodCourier, Zone: "europe", Cost: 10},
+		},
+		{
+			name:     "Quote courier to country of no zone",
+			shipping: zones,
+			method:   MethodCourier,
+			country:  "US",
+			total:    10,
+			expected: Quote{Method: MethodCourier, Zone: "world", Cost: 20},
+		},
+		{
+			name:     "Quote courier without zones",
+			shipping: Shipping{},
+			method:   MethodCourier,
+			country:  "US",
+			err:      ErrNoZone,
+		},
+		{
+			name:     "Quote pickup",
+			shipping: zones,
+			method:   MethodPickup,
+			total:    10,
+			expected: Quote{Method: MethodPickup},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			quote, err := test.shipping.Quote(test.method, test.country, test.total)
+			assert.Equal(t, test.err, err)
+			assert.Equal(t, test.expected, quote)
+		})
+	}
+}
+
+func TestSlotHold(t *testing.T) {
+	assert.Equal(t, 15*time.Minute, Shipping{}.SlotHold())
+	assert.Equal(t, 5*time.Minute, Shipping{SlotHoldMin: 5}.SlotHold())
+}
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS users_cart_version;
+CREATE TABLE users_cart_version (
+    user_id uuid REFERENCES users(id),
+    version BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id)
+);
@@ -0,0 +1,11 @@
+ALTER TABLE users
+    ADD COLUMN totp_secret TEXT,
+    ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN totp_last_step BIGINT;
+
+DROP TABLE IF EXISTS users_recovery_codes;
+CREATE TABLE users_recovery_codes (
+    user_id uuid REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    PRIMARY KEY (user_id, code_hash)
+);
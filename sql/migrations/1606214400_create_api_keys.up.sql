@@ -0,0 +1,17 @@
+ALTER TABLE users
+    ADD COLUMN owner_id uuid REFERENCES users(id) ON DELETE CASCADE;
+
+DROP TABLE IF EXISTS api_keys;
+CREATE TABLE api_keys (
+    id uuid DEFAULT uuid_generate_v1(),
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    PRIMARY KEY (id)
+);
+CREATE INDEX api_keys_user_id_idx ON api_keys (user_id);
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS users_identities;
+CREATE TABLE users_identities (
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (issuer, subject),
+    UNIQUE (user_id, issuer)
+);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS users_email_idx;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS email,
+    DROP COLUMN IF EXISTS display_name,
+    DROP COLUMN IF EXISTS address_line1,
+    DROP COLUMN IF EXISTS address_line2,
+    DROP COLUMN IF EXISTS city,
+    DROP COLUMN IF EXISTS postal_code,
+    DROP COLUMN IF EXISTS country;
@@ -0,0 +1,10 @@
+ALTER TABLE users
+    ADD COLUMN email TEXT,
+    ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN address_line1 TEXT NOT NULL DEFAULT '',
+    ADD COLUMN address_line2 TEXT NOT NULL DEFAULT '',
+    ADD COLUMN city TEXT NOT NULL DEFAULT '',
+    ADD COLUMN postal_code TEXT NOT NULL DEFAULT '',
+    ADD COLUMN country TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX users_email_idx ON users (lower(email));
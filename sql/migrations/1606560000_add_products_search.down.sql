@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS products_created_at_idx;
+DROP INDEX IF EXISTS products_price_idx;
+DROP INDEX IF EXISTS products_name_idx;
+DROP INDEX IF EXISTS products_name_trgm_idx;
@@ -0,0 +1,6 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX products_name_trgm_idx ON products USING gin (name gin_trgm_ops);
+CREATE INDEX products_name_idx ON products (name, id);
+CREATE INDEX products_price_idx ON products (price, id);
+CREATE INDEX products_created_at_idx ON products (created_at, id);
@@ -0,0 +1,10 @@
+ALTER TABLE discount
+    DROP COLUMN IF EXISTS category;
+
+DROP TABLE IF EXISTS products_tags;
+DROP TABLE IF EXISTS tags;
+
+ALTER TABLE products
+    DROP COLUMN IF EXISTS category_id;
+
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS categories(
+    id uuid DEFAULT uuid_generate_v1() NOT NULL,
+    parent_id uuid REFERENCES categories(id),
+    slug TEXT NOT NULL,
+    name TEXT NOT NULL,
+    -- slugs of ancestors and the category joined by '/', e.g. tropical/exotic
+    path TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX categories_path_idx ON categories (path text_pattern_ops);
+
+ALTER TABLE products
+    ADD COLUMN category_id uuid REFERENCES categories(id) ON DELETE SET NULL;
+
+CREATE INDEX products_category_id_idx ON products (category_id);
+
+CREATE TABLE IF NOT EXISTS tags(
+    id uuid DEFAULT uuid_generate_v1() NOT NULL,
+    name TEXT NOT NULL UNIQUE,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS products_tags(
+    product_id uuid NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    tag_id uuid NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (product_id, tag_id)
+);
+
+CREATE INDEX products_tags_tag_id_idx ON products_tags (tag_id);
+
+ALTER TABLE discount
+    ADD COLUMN category TEXT NOT NULL DEFAULT '';
+
+INSERT INTO categories (slug, name, path) VALUES
+    ('citrus', 'Citrus', 'citrus'),
+    ('berries', 'Berries', 'berries'),
+    ('tropical', 'Tropical', 'tropical');
+
+INSERT INTO categories (parent_id, slug, name, path)
+    SELECT id, 'exotic', 'Exotic', 'tropical/exotic' FROM categories WHERE path = 'tropical';
+
+INSERT INTO tags (name) VALUES ('organic'), ('local'), ('seasonal');
+
+UPDATE products SET category_id = (SELECT id FROM categories WHERE path = 'citrus') WHERE name = 'Oranges';
+UPDATE products SET category_id = (SELECT id FROM categories WHERE path = 'tropical') WHERE name = 'Bananas';
@@ -0,0 +1,8 @@
+ALTER TABLE users_cart
+    ALTER COLUMN amount TYPE INT USING ceil(amount);
+
+ALTER TABLE products
+    DROP CONSTRAINT IF EXISTS products_amount_check,
+    DROP COLUMN IF EXISTS step,
+    DROP COLUMN IF EXISTS min_amount,
+    DROP COLUMN IF EXISTS unit;
@@ -0,0 +1,12 @@
+-- price is per unit, amounts in cart are in the unit of product
+ALTER TABLE products
+    ADD COLUMN unit TEXT NOT NULL DEFAULT 'piece' CHECK (unit IN ('piece', 'kg', '100g', 'bunch')),
+    ADD COLUMN min_amount NUMERIC(10, 3) NOT NULL DEFAULT 1,
+    ADD COLUMN step NUMERIC(10, 3) NOT NULL DEFAULT 1,
+    ADD CONSTRAINT products_amount_check CHECK (step > 0 AND min_amount > 0);
+
+ALTER TABLE users_cart
+    ALTER COLUMN amount TYPE NUMERIC(10, 3);
+
+UPDATE products SET unit = 'kg', min_amount = 0.5, step = 0.1 WHERE name IN ('Apples', 'Pears');
+UPDATE products SET unit = 'bunch' WHERE name = 'Bananas';
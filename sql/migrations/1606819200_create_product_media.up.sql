@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS product_media(
+    id uuid DEFAULT uuid_generate_v1() NOT NULL,
+    product_id uuid NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    -- storage keys of original image and its thumbnail
+    key TEXT NOT NULL,
+    thumbnail_key TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX product_media_product_id_idx ON product_media (product_id, created_at);
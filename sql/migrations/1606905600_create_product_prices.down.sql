@@ -0,0 +1,4 @@
+ALTER TABLE users_cart
+    DROP COLUMN IF EXISTS added_price;
+
+DROP TABLE IF EXISTS product_prices;
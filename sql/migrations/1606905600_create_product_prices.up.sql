@@ -0,0 +1,21 @@
+-- products.price is the current price, product_prices keeps past and scheduled prices
+CREATE TABLE IF NOT EXISTS product_prices(
+    id uuid DEFAULT uuid_generate_v1() NOT NULL,
+    product_id uuid NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    price float(2) NOT NULL CHECK (price >= 0),
+    effective_from TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (id),
+    UNIQUE (product_id, effective_from)
+);
+
+CREATE INDEX product_prices_effective_from_idx ON product_prices (effective_from);
+
+INSERT INTO product_prices (product_id, price, effective_from)
+    SELECT id, price, COALESCE(created_at, now()) FROM products WHERE price IS NOT NULL;
+
+-- price of product when it was added to the cart
+ALTER TABLE users_cart
+    ADD COLUMN added_price float(2);
+
+UPDATE users_cart SET added_price = products.price FROM products WHERE products.id = users_cart.product_id;
@@ -0,0 +1,4 @@
+ALTER TABLE products
+    DROP COLUMN IF EXISTS currency;
+
+DROP TABLE IF EXISTS exchange_rates;
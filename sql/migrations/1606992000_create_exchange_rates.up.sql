@@ -0,0 +1,11 @@
+-- units of currency per unit of base currency, base currency itself has no rate
+CREATE TABLE IF NOT EXISTS exchange_rates(
+    currency CHAR(3) NOT NULL,
+    rate DOUBLE PRECISION NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (currency)
+);
+
+-- currency price of product is in, null is the base currency
+ALTER TABLE products
+    ADD COLUMN currency CHAR(3) REFERENCES exchange_rates(currency);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS users_cart_delivery;
+DROP TABLE IF EXISTS delivery_addresses;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS delivery_addresses(
+    id uuid DEFAULT uuid_generate_v1() NOT NULL,
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    line1 TEXT NOT NULL,
+    line2 TEXT NOT NULL DEFAULT '',
+    city TEXT NOT NULL,
+    postal_code TEXT NOT NULL,
+    country TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX delivery_addresses_user_id_idx ON delivery_addresses (user_id, created_at);
+
+-- courier delivers to address, pickup has none; selection is removed with its address
+CREATE TABLE IF NOT EXISTS users_cart_delivery(
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    method TEXT NOT NULL CHECK (method IN ('courier', 'pickup')),
+    address_id uuid REFERENCES delivery_addresses(id) ON DELETE CASCADE,
+    CHECK ((method = 'courier') = (address_id IS NOT NULL)),
+    PRIMARY KEY (user_id)
+);
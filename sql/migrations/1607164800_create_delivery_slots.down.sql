@@ -0,0 +1,3 @@
+ALTER TABLE users_cart_delivery DROP COLUMN IF EXISTS slot_id;
+DROP TABLE IF EXISTS delivery_slot_bookings;
+DROP TABLE IF EXISTS delivery_slots;
@@ -0,0 +1,27 @@
+-- booked places never exceed capacity, payment books a place with conditional update
+CREATE TABLE IF NOT EXISTS delivery_slots(
+    id uuid DEFAULT uuid_generate_v1() NOT NULL,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    capacity INTEGER NOT NULL CHECK (capacity > 0),
+    booked INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (ends_at > starts_at),
+    CHECK (booked >= 0 AND booked <= capacity),
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX delivery_slots_starts_at_idx ON delivery_slots (starts_at);
+
+-- slot with bookings can't be deleted
+CREATE TABLE IF NOT EXISTS delivery_slot_bookings(
+    id uuid DEFAULT uuid_generate_v1() NOT NULL,
+    slot_id uuid NOT NULL REFERENCES delivery_slots(id),
+    user_id uuid REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX delivery_slot_bookings_slot_id_idx ON delivery_slot_bookings (slot_id);
+
+ALTER TABLE users_cart_delivery ADD COLUMN slot_id uuid REFERENCES delivery_slots(id) ON DELETE SET NULL;
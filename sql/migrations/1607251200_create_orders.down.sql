@@ -0,0 +1,7 @@
+ALTER TABLE delivery_slot_bookings DROP COLUMN IF EXISTS order_id;
+
+DROP TABLE IF EXISTS order_status_history;
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
+
+ALTER TABLE products DROP COLUMN IF EXISTS stock;
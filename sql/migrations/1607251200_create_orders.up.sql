@@ -0,0 +1,51 @@
+-- untracked stock is null, orders reserve stock of products and cancellation returns it
+ALTER TABLE products
+    ADD COLUMN stock NUMERIC(10, 3) CHECK (stock >= 0);
+
+-- amounts are in base currency, paid is the amount captured from the user; orders outlive their users
+CREATE TABLE IF NOT EXISTS orders(
+    id uuid DEFAULT uuid_generate_v1() NOT NULL,
+    user_id uuid REFERENCES users(id) ON DELETE SET NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending_payment', 'paid', 'packed', 'shipped', 'delivered', 'cancelled', 'refunded')),
+    currency TEXT NOT NULL,
+    subtotal NUMERIC(12, 3) NOT NULL,
+    tax NUMERIC(12, 3) NOT NULL,
+    shipping NUMERIC(12, 3) NOT NULL,
+    total NUMERIC(12, 3) NOT NULL,
+    paid NUMERIC(12, 3) NOT NULL DEFAULT 0,
+    method TEXT NOT NULL CHECK (method IN ('courier', 'pickup')),
+    address_id uuid REFERENCES delivery_addresses(id) ON DELETE SET NULL,
+    slot_id uuid REFERENCES delivery_slots(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX orders_user_id_idx ON orders (user_id, created_at);
+CREATE INDEX orders_status_idx ON orders (status, created_at);
+
+-- name and price are kept as they were at payment
+CREATE TABLE IF NOT EXISTS order_items(
+    order_id uuid NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    product_id uuid REFERENCES products(id) ON DELETE SET NULL,
+    name TEXT NOT NULL,
+    price NUMERIC(12, 3) NOT NULL,
+    amount NUMERIC(10, 3) NOT NULL CHECK (amount > 0)
+);
+
+CREATE INDEX order_items_order_id_idx ON order_items (order_id);
+
+CREATE TABLE IF NOT EXISTS order_status_history(
+    id BIGSERIAL,
+    order_id uuid NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    status TEXT NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX order_status_history_order_id_idx ON order_status_history (order_id, id);
+
+-- cancellation of order removes its booking
+ALTER TABLE delivery_slot_bookings
+    ADD COLUMN order_id uuid REFERENCES orders(id) ON DELETE CASCADE;
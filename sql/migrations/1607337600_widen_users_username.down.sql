@@ -0,0 +1 @@
+ALTER TABLE users ALTER COLUMN username TYPE VARCHAR(34);
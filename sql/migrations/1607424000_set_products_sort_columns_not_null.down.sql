@@ -0,0 +1,4 @@
+ALTER TABLE products
+    ALTER COLUMN price DROP NOT NULL,
+    ALTER COLUMN created_at DROP NOT NULL,
+    ALTER COLUMN created_at DROP DEFAULT;
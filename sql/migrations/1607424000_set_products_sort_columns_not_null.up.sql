@@ -0,0 +1,12 @@
+-- products are paged by keyset of price or created_at, a NULL in either would drop the product from pages
+UPDATE products p SET price = COALESCE((
+    SELECT pp.price FROM product_prices pp WHERE pp.product_id = p.id AND pp.effective_from <= now()
+    ORDER BY pp.effective_from DESC LIMIT 1), 0)
+WHERE p.price IS NULL;
+
+UPDATE products SET created_at = CURRENT_DATE WHERE created_at IS NULL;
+
+ALTER TABLE products
+    ALTER COLUMN price SET NOT NULL,
+    ALTER COLUMN created_at SET NOT NULL,
+    ALTER COLUMN created_at SET DEFAULT CURRENT_DATE;
@@ -0,0 +1,129 @@
+package tax
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mshto/fruit-store/currency"
+)
+
+// tax classes and rounding modes
+const (
+	ClassStandard   = "standard"
+	RoundingLine    = "line"
+	RoundingInvoice = "invoice"
+)
+
+// Tax struct stores tax configuration. Rates are percents by region and tax class,
+// classes map categories with their subcategories to tax classes, other products are of the standard class.
+// Products of region without rates are not taxed.
+type Tax struct {
+	Inclusive bool                          `json:"Inclusive"  envconfig:"TAX_INCLUSIVE"`
+	Rounding  string                        `json:"Rounding"   envconfig:"TAX_ROUNDING"   validate:"omitempty,oneof=line invoice"`
+	Region    string                        `json:"Region"     envconfig:"TAX_REGION"`
+	Classes   map[string]string             `json:"Classes"`
+	Rates     map[string]map[string]float64 `json:"Rates"`
+}
+
+// Line total of product after discounts in base currency
+type Line struct {
+	Category string
+	Total    float64
+}
+
+// Group taxes of lines of the same class, amounts are in display currency
+type Group struct {
+	Class string
+	Rate  float64
+	Net   float64
+	Tax   float64
+}
+
+// Summary taxes of lines grouped by class and their totals, amounts are in display currency
+type Summary struct {
+	Groups []Group
+	Net    float64
+	Tax    float64
+	Gross  float64
+}
+
+// RegionCode region taxes are calculated for, empty region is the configured one
+func (t Tax) RegionCode(region string) string {
+	if region == "" {
+		region = t.Region
+	}
+	return strings.ToUpper(strings.TrimSpace(region))
+}
+
+// Class tax class of category, the most specific configured category wins
+func (t Tax) Class(category string) string {
+	class, matched := ClassStandard, ""
+	for cat, cls := range t.Classes {
+		if (category == cat || strings.HasPrefix(category, cat+"/")) && len(cat) > len(matched) {
+			class, matched = cls, cat
+		}
+	}
+	return class
+}
+
+// Rate rate of tax class in region in percents
+func (t Tax) Rate(region, class string) float64 {
+	return t.Rates[t.RegionCode(region)][class]
+}
+
+// Calculate taxes of lines in region converted to display currency.
+// Line rounding rounds amount and tax of every line, invoice rounding (default) rounds them once per class.
+func (t Tax) Calculate(lines []Line, region string, display currency.Display) Summary {
+	groups := map[string]*Group{}
+	amounts := map[string]float64{}
+	for _, line := range lines {
+		class := t.Class(line.Category)
+		group, ok := groups[class]
+		if !ok {
+			group = &Group{Class: class, Rate: t.Rate(region, class)}
+			groups[class] = group
+		}
+
+		amount := line.Total * display.Rate
+		if t.Rounding != RoundingLine {
+			amounts[class] += amount
+			continue
+		}
+		amount = currency.Round(amount, display.Code)
+		amounts[class] += amount
+		group.Tax += t.taxOf(amount, group.Rate, display.Code)
+	}
+
+	summary := Summary{}
+	for class, group := range groups {
+		amount := currency.Round(amounts[class], display.Code)
+		if t.Rounding != RoundingLine {
+			group.Tax = t.taxOf(amount, group.Rate, display.Code)
+		}
+		group.Tax = currency.Round(group.Tax, display.Code)
+		group.Net = amount
+		if t.Inclusive {
+			group.Net = currency.Round(amount-group.Tax, display.Code)
+		}
+
+		summary.Groups = append(summary.Groups, *group)
+		summary.Net += group.Net
+		summary.Tax += group.Tax
+	}
+	sort.Slice(summary.Groups, func(i, j int) bool {
+		return summary.Groups[i].Class < summary.Groups[j].Class
+	})
+
+	summary.Net = currency.Round(summary.Net, display.Code)
+	summary.Tax = currency.Round(summary.Tax, display.Code)
+	summary.Gross = currency.Round(summary.Net+summary.Tax, display.Code)
+	return summary
+}
+
+// taxOf tax part of amount rounded to minor units of currency, amount includes tax when prices are tax-inclusive
+func (t Tax) taxOf(amount, rate float64, code string) float64 {
+	if t.Inclusive {
+		return currency.Round(amount*rate/(100+rate), code)
+	}
+	return currency.Round(amount*rate/100, code)
+}
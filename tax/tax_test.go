@@ -0,0 +1,130 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/currency"
+)
+
+var vat = Tax{
+	Region:  "DE",
+	Classes: map[string]string{"fruits": "reduced", "fruits/exotic": "luxury"},
+	Rates: map[string]map[string]float64{
+		"DE": {ClassStandard: 19, "reduced": 7, "luxury": 25},
+	},
+}
+
+func TestClass(t *testing.T) {
+	tc := []struct {
+		name     string
+		category string
+		expected string
+	}{
+		{
+			name:     "Class of configured category",
+			category: "fruits",
+			expected: "reduced",
+		},
+		{
+			name:     "Class of subcategory",
+			category: "fruits/apples",
+			expected: "reduced",
+		},
+		{
+			name:     "Class of the most specific category",
+			category: "fruits/exotic/mangoes",
+			expected: "luxury",
+		},
+		{
+			name:     "Class of category with similar prefix",
+			category: "fruitsalads",
+			expected: ClassStandard,
+		},
+		{
+			name:     "Class of product without category",
+			expected: ClassStandard,
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, vat.Class(test.category))
+		})
+	}
+}
+
+func TestRate(t *testing.T) {
+	assert.Equal(t, float64(7), vat.Rate("de", "reduced"))
+	assert.Equal(t, float64(19), vat.Rate("", ClassStandard))
+	assert.Equal(t, float64(0), vat.Rate("PL", ClassStandard))
+}
+
+func TestCalculate(t *testing.T) {
+	usd := currency.Display{Code: "USD", Rate: 1}
+	lines := []Line{{Category: "fruits", Total: 1.005}, {Category: "fruits", Total: 1.005}, {Total: 10}}
+
+	tc := []struct {
+		name     string
+		tax      Tax
+		region   string
+		expected Summary
+	}{
+		{
+			name:   "Calculate tax-exclusive rounded per invoice",
+			tax:    vat,
+			region: "DE",
+			expected: Summary{
+				Groups: []Group{{Class: "reduced", Rate: 7, Net: 2.01, Tax: 0.14}, {Class: ClassStandard, Rate: 19, Net: 10, Tax: 1.9}},
+				Net:    12.01,
+				Tax:    2.04,
+				Gross:  14.05,
+			},
+		},
+		{
+			name:   "Calculate tax-exclusive rounded per line",
+			tax:    Tax{Rounding: RoundingLine, Classes: vat.Classes, Rates: vat.Rates},
+			region: "DE",
+			expected: Summary{
+				Groups: []Group{{Class: "reduced", Rate: 7, Net: 2.02, Tax: 0.14}, {Class: ClassStandard, Rate: 19, Net: 10, Tax: 1.9}},
+				Net:    12.02,
+				Tax:    2.04,
+				Gross:  14.06,
+			},
+		},
+		{
+			name:   "Calculate tax-inclusive",
+			tax:    Tax{Inclusive: true, Classes: vat.Classes, Rates: vat.Rates},
+			region: "DE",
+			expected: Summary{
+				Groups: []Group{{Class: "reduced", Rate: 7, Net: 1.88, Tax: 0.13}, {Class: ClassStandard, Rate: 19, Net: 8.4, Tax: 1.6}},
+				Net:    10.28,
+				Tax:    1.73,
+				Gross:  12.01,
+			},
+		},
+		{
+			name:   "Calculate in region without rates",
+			tax:    vat,
+			region: "PL",
+			expected: Summary{
+				Groups: []Group{{Class: "reduced", Net: 2.01}, {Class: ClassStandard, Net: 10}},
+				Net:    12.01,
+				Gross:  12.01,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, test.tax.Calculate(lines, test.region, usd))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret base32 encoded secret of RFC 6238 test vectors
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	tc := []struct {
+		name     string
+		unix     int64
+		expected string
+	}{
+		{
+			name:     "Code at 59",
+			unix:     59,
+			expected: "287082",
+		},
+		{
+			name:     "Code at 1111111109",
+			unix:     1111111109,
+			expected: "081804",
+		},
+		{
+			name:     "Code at 1234567890",
+			unix:     1234567890,
+			expected: "005924",
+		},
+		{
+			name:     "Code at 2000000000",
+			unix:     2000000000,
+			expected: "279037",
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, err := Code(rfcSecret, Step(time.Unix(test.unix, 0)))
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, code)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	step, ok, err := Validate(rfcSecret, "081804", now)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	// code of previous step is accepted because of clock drift
+	step, ok, err = Validate(rfcSecret, "081804", now.Add(Period))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	_, ok, err = Validate(rfcSecret, "081804", now.Add(2*Period))
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = Validate(rfcSecret, "000000", now)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, _, err = Validate("invalid secret!", "000000", now)
+	assert.NotNil(t, err)
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.Nil(t, err)
+	assert.Len(t, secret, 32)
+
+	code, err := Code(secret, Step(time.Now()))
+	assert.Nil(t, err)
+	assert.Len(t, code, Digits)
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Fruit Store", "test", rfcSecret)
+	assert.Equal(t, "otpauth://totp/Fruit%20Store:test?algorithm=SHA1&digits=6&issuer=Fruit+Store&period=30&secret="+rfcSecret, uri)
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	assert.Nil(t, err)
+	assert.Len(t, codes, 10)
+
+	unique := map[string]struct{}{}
+	for _, code := range codes {
+		assert.Len(t, code, 9)
+		unique[HashRecoveryCode(code)] = struct{}{}
+	}
+	assert.Len(t, unique, 10)
+
+	assert.Equal(t, HashRecoveryCode(codes[0]), HashRecoveryCode(strings.ToUpper(strings.Replace(codes[0], "-", "", 1))))
+}
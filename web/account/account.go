@@ -0,0 +1,307 @@
+package account
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/mshto/fruit-store/authentication"
+	"github.com/mshto/fruit-store/bill"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/repository"
+	"github.com/mshto/fruit-store/web/common/response"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+// profile field limits
+const (
+	maxEmailLength       = 254
+	maxDisplayNameLength = 64
+	maxAddressLength     = 128
+)
+
+const exportDisposition = `attachment; filename="fruit-store-export.json"`
+
+// Service account interface
+type Service interface {
+	GetProfile(w http.ResponseWriter, r *http.Request)
+	UpdateProfile(w http.ResponseWriter, r *http.Request)
+	Export(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+
+	GetAddresses(w http.ResponseWriter, r *http.Request)
+	CreateAddress(w http.ResponseWriter, r *http.Request)
+	DeleteAddress(w http.ResponseWriter, r *http.Request)
+
+	GetOrders(w http.ResponseWriter, r *http.Request)
+	GetOrder(w http.ResponseWriter, r *http.Request)
+	CancelOrder(w http.ResponseWriter, r *http.Request)
+}
+
+type accountHandler struct {
+	cfg         *config.Config
+	log         *logrus.Logger
+	profileRepo repository.Profile
+	authRepo    repository.Auth
+	cartRepo    repository.Cart
+	delivRepo   repository.Delivery
+	ordersRepo  repository.Orders
+	auth        authentication.Auth
+	bil         bill.Bill
+}
+
+// NewAccountHandler init a new account handler
+func NewAccountHandler(cfg *config.Config, log *logrus.Logger, profileRepo repository.Profile, authRepo repository.Auth,
+	cartRepo repository.Cart, delivRepo repository.Delivery, ordersRepo repository.Orders, auth authentication.Auth, bil bill.Bill) Service {
+	return accountHandler{
+		cfg:         cfg,
+		log:         log,
+		profileRepo: profileRepo,
+		authRepo:    authRepo,
+		cartRepo:    cartRepo,
+		delivRepo:   delivRepo,
+		ordersRepo:  ordersRepo,
+		auth:        auth,
+		bil:         bil,
+	}
+}
+
+// GetProfile retrieves profile of user
+func (ach accountHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := ach.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	profile, ok := ach.getProfile(w, userUUID)
+	if !ok {
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, profile)
+}
+
+// UpdateProfile update fields of user profile which are set in request
+func (ach accountHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := ach.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	update := entity.ProfileUpdate{}
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		ach.log.Errorf("failed to decode profile, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	profile, ok := ach.getProfile(w, userUUID)
+	if !ok {
+		return
+	}
+
+	if update.Email != nil {
+		profile.Email = *update.Email
+	}
+	if update.DisplayName != nil {
+		profile.DisplayName = *update.DisplayName
+	}
+	if update.Address != nil {
+		profile.Address = *update.Address
+	}
+
+	err = validateProfile(profile)
+	if err != nil {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	err = ach.profileRepo.UpdateProfile(profile)
+	if err == entity.ErrEmailAlreadyUsed {
+		ach.log.Errorf("failed to update profile, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		ach.log.Errorf("failed to update profile, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, profile)
+}
+
+// Export retrieves all data of user as a downloadable JSON archive
+func (ach accountHandler) Export(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := ach.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	profile, ok := ach.getProfile(w, userUUID)
+	if !ok {
+		return
+	}
+
+	cart, err := ach.cartRepo.GetUserProducts(userUUID)
+	if err != nil {
+		ach.log.Errorf("failed to get user products, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	for i := range cart {
+		if cart[i].Currency == "" {
+			cart[i].Currency = ach.cfg.Currency.BaseCode()
+		}
+	}
+
+	addresses, err := ach.delivRepo.GetAddresses(userUUID)
+	if err != nil {
+		ach.log.Errorf("failed to get addresses, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	orders, err := ach.ordersRepo.GetOrders(entity.OrderFilter{UserID: &userUUID})
+	if err != nil {
+		ach.log.Errorf("failed to get orders, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	sessions, err := ach.auth.GetSessions(userUUID.String())
+	if err != nil {
+		ach.log.Errorf("failed to get sessions, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", exportDisposition)
+	response.RenderResponse(w, http.StatusOK, entity.AccountExport{
+		ExportedAt: time.Now().UTC(),
+		Profile:    *profile,
+		Cart:       cart,
+		Addresses:  addresses,
+		Orders:     orders,
+		Sessions:   sessions,
+	})
+}
+
+// Delete revoke all tokens of user and remove user with its data
+func (ach accountHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := ach.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	req := entity.AccountDelete{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		ach.log.Errorf("failed to decode account delete, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	storedUser, err := ach.authRepo.GetUserByID(userUUID)
+	if err == entity.ErrUserNotFound {
+		ach.log.Errorf("failed to get user by id, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		ach.log.Errorf("failed to get user by id, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// users provisioned by identity provider have no password to confirm
+	if storedUser.Password != "" {
+		if err = bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(req.Password)); err != nil {
+			ach.log.Errorf("failed to compare hash and password, user: %v, error: %v", userUUID, err)
+			response.RenderFailedResponse(w, http.StatusUnauthorized, entity.ErrInvalidCredentials)
+			return
+		}
+	}
+
+	// tokens are revoked first, so a failed removal leaves the account signed out rather than deleted with live tokens
+	err = ach.auth.RevokeSessions(userUUID.String())
+	if err != nil {
+		ach.log.Errorf("failed to revoke sessions, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = ach.bil.RemoveDiscount(userUUID)
+	if err != nil {
+		ach.log.Warnf("failed to remove discount, user: %v, error: %v", userUUID, err)
+	}
+
+	err = ach.profileRepo.DeleteUser(userUUID)
+	if err != nil {
+		ach.log.Errorf("failed to delete user, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+}
+
+func (ach accountHandler) getProfile(w http.ResponseWriter, userUUID uuid.UUID) (*entity.Profile, bool) {
+	profile, err := ach.profileRepo.GetProfile(userUUID)
+	if err == entity.ErrUserNotFound {
+		ach.log.Errorf("failed to get profile, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return nil, false
+	}
+	if err != nil {
+		ach.log.Errorf("failed to get profile, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	return profile, true
+}
+
+func (ach accountHandler) getUserUUID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, ok := r.Context().Value(middleware.UserUUID).(string)
+	if !ok {
+		ach.log.Errorf("failed to get UserUUID")
+		response.RenderFailedResponse(w, http.StatusBadRequest, errors.New("userUUID not found"))
+		return uuid.Nil, false
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		ach.log.Errorf("failed to parse user uuid, user: %v, error: %v", userID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return uuid.Nil, false
+	}
+	return userUUID, true
+}
+
+func validateProfile(profile *entity.Profile) error {
+	if profile.Email != "" {
+		addr, err := mail.ParseAddress(profile.Email)
+		if err != nil || addr.Address != profile.Email || len(profile.Email) > maxEmailLength {
+			return entity.ErrInvalidEmail
+		}
+	}
+	if len(profile.DisplayName) > maxDisplayNameLength {
+		return entity.ErrFieldTooLong
+	}
+
+	address := profile.Address
+	for _, field := range []string{address.Line1, address.Line2, address.City, address.PostalCode, address.Country} {
+		if len(field) > maxAddressLength {
+			return entity.ErrFieldTooLong
+		}
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	authmock "github.com/mshto/fruit-store/authentication/mock"
+	billmock "github.com/mshto/fruit-store/bill/mock"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+var (
+	userUUID = uuid.MustParse("0b6bd0c4-2c3e-11eb-adc1-0242ac120002")
+	// bcrypt hash of "password"
+	passwordHash = "$2a$08$ZtefSglA0MuPtOYRa/dZI.zb.pf.dhUHo1XXmhTrKmUuMz.9Cqg6m"
+)
+
+type mocks struct {
+	profileRepo *repomock.MockProfile
+	authRepo    *repomock.MockAuth
+	cartRepo    *repomock.MockCart
+	delivRepo   *repomock.MockDelivery
+	ordersRepo  *repomock.MockOrders
+	auth        *authmock.MockAuth
+	bil         *billmock.MockBill
+}
+
+func newHandler(mockCtrl *gomock.Controller) (Service, mocks) {
+	logger, _ := loggermock.NewNullLogger()
+
+	m := mocks{
+		profileRepo: repomock.NewMockProfile(mockCtrl),
+		authRepo:    repomock.NewMockAuth(mockCtrl),
+		cartRepo:    repomock.NewMockCart(mockCtrl),
+		delivRepo:   repomock.NewMockDelivery(mockCtrl),
+		ordersRepo:  repomock.NewMockOrders(mockCtrl),
+		auth:        authmock.NewMockAuth(mockCtrl),
+		bil:         billmock.NewMockBill(mockCtrl),
+	}
+	return NewAccountHandler(&config.Config{}, logger, m.profileRepo, m.authRepo, m.cartRepo, m.delivRepo, m.ordersRepo, m.auth, m.bil), m
+}
+
+func newRequest(method, body string) *http.Request {
+	req, _ := http.NewRequest(method, "url", bytes.NewBufferString(body))
+	return req.WithContext(context.WithValue(req.Context(), middleware.UserUUID, userUUID.String()))
+}
+
+func TestGetProfile(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ach, m := newHandler(mockCtrl)
+	m.profileRepo.EXPECT().GetProfile(userUUID).Return(&entity.Profile{ID: userUUID, Username: "test", Email: "test@example.com"}, nil)
+
+	rw := httptest.NewRecorder()
+	ach.GetProfile(rw, newRequest(http.MethodGet, ""))
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, `{"id":"0b6bd0c4-2c3e-11eb-adc1-0242ac120002","username":"test","email":"test@example.com","displayName":"","address":{"line1":"","line2":"","city":"","postalCode":"","country":""}}`, rw.Body.String())
+}
+
+func TestUpdateProfile(t *testing.T) {
+	type payload struct {
+		body     string
+		repoMock func(repoMock *repomock.MockProfile)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	stored := func() *entity.Profile {
+		return &entity.Profile{ID: userUUID, Username: "test", Email: "old@example.com", DisplayName: "Old"}
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Update profile keeps fields which aren't set with success",
+			payload: payload{
+				body: `{"displayName":"New","address":{"city":"Kyiv","country":"UA"}}`,
+				repoMock: func(repoMock *repomock.MockProfile) {
+					repoMock.EXPECT().GetProfile(userUUID).Return(stored(), nil)
+					repoMock.EXPECT().UpdateProfile(&entity.Profile{ID: userUUID, Username: "test", Email: "old@example.com", DisplayName: "New",
+						Address: entity.Address{City: "Kyiv", Country: "UA"}}).Return(nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"id":"0b6bd0c4-2c3e-11eb-adc1-0242ac120002","username":"test","email":"old@example.com","displayName":"New","address":{"line1":"","line2":"","city":"Kyiv","postalCode":"","country":"UA"}}`,
+			},
+		},
+		{
+			name: "Update profile clear email with success",
+			payload: payload{
+				body: `{"email":""}`,
+				repoMock: func(repoMock *repomock.MockProfile) {
+					repoMock.EXPECT().GetProfile(userUUID).Return(stored(), nil)
+					repoMock.EXPECT().UpdateProfile(&entity.Profile{ID: userUUID, Username: "test", DisplayName: "Old"}).Return(nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"id":"0b6bd0c4-2c3e-11eb-adc1-0242ac120002","username":"test","email":"","displayName":"Old","address":{"line1":"","line2":"","city":"","postalCode":"","country":""}}`,
+			},
+		},
+		{
+			name: "Update profile invalid email with fail",
+			payload: payload{
+				body: `{"email":"Jane <jane@example.com>"}`,
+				repoMock: func(repoMock *repomock.MockProfile) {
+					repoMock.EXPECT().GetProfile(userUUID).Return(stored(), nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"email is invalid"}`,
+			},
+		},
+		{
+			name: "Update profile too long display name with fail",
+			payload: payload{
+				body: `{"displayName":"` + strings.Repeat("a", maxDisplayNameLength+1) + `"}`,
+				repoMock: func(repoMock *repomock.MockProfile) {
+					repoMock.EXPECT().GetProfile(userUUID).Return(stored(), nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"profile field is too long"}`,
+			},
+		},
+		{
+			name: "Update profile email is used with fail",
+			payload: payload{
+				body: `{"email":"used@example.com"}`,
+				repoMock: func(repoMock *repomock.MockProfile) {
+					repoMock.EXPECT().GetProfile(userUUID).Return(stored(), nil)
+					repoMock.EXPECT().UpdateProfile(gomock.Any()).Return(entity.ErrEmailAlreadyUsed)
+				},
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"email is already used by another user"}`,
+			},
+		},
+		{
+			name: "Update profile invalid body with fail",
+			payload: payload{
+				body:     `{`,
+				repoMock: func(repoMock *repomock.MockProfile) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"unexpected EOF"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			ach, m := newHandler(mockCtrl)
+			test.payload.repoMock(m.profileRepo)
+
+			rw := httptest.NewRecorder()
+			ach.UpdateProfile(rw, newRequest(http.MethodPatch, test.payload.body))
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ach, m := newHandler(mockCtrl)
+	m.profileRepo.EXPECT().GetProfile(userUUID).Return(&entity.Profile{ID: userUUID, Username: "test"}, nil)
+	m.cartRepo.EXPECT().GetUserProducts(userUUID).Return([]entity.GetUserProduct{{Name: "Apples", Price: 1, Amount: 2}}, nil)
+	m.delivRepo.EXPECT().GetAddresses(userUUID).Return([]entity.DeliveryAddress{}, nil)
+	m.ordersRepo.EXPECT().GetOrders(entity.OrderFilter{UserID: &userUUID}).Return([]entity.Order{orderOne}, nil)
+	m.auth.EXPECT().GetSessions(userUUID.String()).Return([]entity.Session{{ID: "session", CreatedAt: time.Unix(0, 0).UTC()}}, nil)
+
+	rw := httptest.NewRecorder()
+	ach.Export(rw, newRequest(http.MethodGet, ""))
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, exportDisposition, rw.Header().Get("Content-Disposition"))
+	assert.Contains(t, rw.Body.String(), `"profile":{"id":"0b6bd0c4-2c3e-11eb-adc1-0242ac120002","username":"test"`)
+	assert.Contains(t, rw.Body.String(), `"cart":[{"id":"00000000-0000-0000-0000-000000000000","name":"Apples","price":1,"currency":"USD","amount":2}]`)
+	assert.Contains(t, rw.Body.String(), `"addresses":[]`)
+	assert.Contains(t, rw.Body.String(), `"orders":[{"id":"9e4a7d7e-3700-11eb-adc1-0242ac120002","status":"paid"`)
+	assert.Contains(t, rw.Body.String(), `"sessions":[{"id":"session"`)
+}
+
+func TestDelete(t *testing.T) {
+	type payload struct {
+		body  string
+		mocks func(m mocks)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Delete account with success",
+			payload: payload{
+				body: `{"password":"password"}`,
+				mocks: func(m mocks) {
+					m.authRepo.EXPECT().GetUserByID(userUUID).Return(&entity.Credentials{ID: userUUID, Password: passwordHash}, nil)
+					m.auth.EXPECT().RevokeSessions(userUUID.String()).Return(nil)
+					m.bil.EXPECT().RemoveDiscount(userUUID).Return(nil)
+					m.profileRepo.EXPECT().DeleteUser(userUUID).Return(nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Delete account without password with success",
+			payload: payload{
+				body: `{}`,
+				mocks: func(m mocks) {
+					m.authRepo.EXPECT().GetUserByID(userUUID).Return(&entity.Credentials{ID: userUUID}, nil)
+					m.auth.EXPECT().RevokeSessions(userUUID.String()).Return(nil)
+					m.bil.EXPECT().RemoveDiscount(userUUID).Return(errors.New("error"))
+					m.profileRepo.EXPECT().DeleteUser(userUUID).Return(nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Delete account wrong password with fail",
+			payload: payload{
+				body: `{"password":"wrong"}`,
+				mocks: func(m mocks) {
+					m.authRepo.EXPECT().GetUserByID(userUUID).Return(&entity.Credentials{ID: userUUID, Password: passwordHash}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnauthorized,
+				body: `{"error":"invalid username or password"}`,
+			},
+		},
+		{
+			name: "Delete account RevokeSessions error keeps account with fail",
+			payload: payload{
+				body: `{"password":"password"}`,
+				mocks: func(m mocks) {
+					m.authRepo.EXPECT().GetUserByID(userUUID).Return(&entity.Credentials{ID: userUUID, Password: passwordHash}, nil)
+					m.auth.EXPECT().RevokeSessions(userUUID.String()).Return(errors.New("error"))
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+		{
+			name: "Delete account DeleteUser error with fail",
+			payload: payload{
+				body: `{"password":"password"}`,
+				mocks: func(m mocks) {
+					m.authRepo.EXPECT().GetUserByID(userUUID).Return(&entity.Credentials{ID: userUUID, Password: passwordHash}, nil)
+					m.auth.EXPECT().RevokeSessions(userUUID.String()).Return(nil)
+					m.bil.EXPECT().RemoveDiscount(userUUID).Return(nil)
+					m.profileRepo.EXPECT().DeleteUser(userUUID).Return(errors.New("error"))
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			ach, m := newHandler(mockCtrl)
+			test.payload.mocks(m)
+
+			rw := httptest.NewRecorder()
+			ach.Delete(rw, newRequest(http.MethodDelete, test.payload.body))
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package account
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/web/common/response"
+)
+
+// GetAddresses retrieves delivery addresses of user
+func (ach accountHandler) GetAddresses(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := ach.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	addresses, err := ach.delivRepo.GetAddresses(userUUID)
+	if err != nil {
+		ach.log.Errorf("failed to get addresses, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, addresses)
+}
+
+// CreateAddress add delivery address of user
+func (ach accountHandler) CreateAddress(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := ach.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	address := entity.DeliveryAddress{}
+	err := json.NewDecoder(r.Body).Decode(&address.Address)
+	if err != nil {
+		ach.log.Errorf("failed to decode address, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = validateAddress(address.Address)
+	if err != nil {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	address.UserID = userUUID
+	err = ach.delivRepo.CreateAddress(&address)
+	if err != nil {
+		ach.log.Errorf("failed to create address, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusCreated, address)
+}
+
+// DeleteAddress remove delivery address of user, cart delivery to it is removed as well
+func (ach accountHandler) DeleteAddress(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := ach.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	addressUUID, err := uuid.Parse(mux.Vars(r)["addressID"])
+	if err != nil {
+		ach.log.Errorf("failed to parse address id, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = ach.delivRepo.DeleteAddress(userUUID, addressUUID)
+	if err == entity.ErrAddressNotFound {
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		ach.log.Errorf("failed to delete address, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+}
+
+func validateAddress(address entity.Address) error {
+	for _, field := range []string{address.Line1, address.Line2, address.City, address.PostalCode, address.Country} {
+		if len(field) > maxAddressLength {
+			return entity.ErrFieldTooLong
+		}
+	}
+	if address.Line1 == "" || address.City == "" || address.PostalCode == "" || address.Country == "" {
+		return entity.ErrInvalidAddress
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package account
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+)
+
+var (
+	addressUUID = uuid.MustParse("3b1f0a6e-3540-11eb-adc1-0242ac120002")
+	addressOne  = entity.DeliveryAddress{
+		ID:        addressUUID,
+		UserID:    userUUID,
+		Address:   entity.Address{Line1: "Khreshchatyk 1", City: "Kyiv", PostalCode: "01001", Country: "UA"},
+		CreatedAt: time.Date(2020, 12, 4, 10, 0, 0, 0, time.UTC),
+	}
+)
+
+func TestGetAddresses(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ach, m := newHandler(mockCtrl)
+	m.delivRepo.EXPECT().GetAddresses(userUUID).Return([]entity.DeliveryAddress{addressOne}, nil)
+
+	rw := httptest.NewRecorder()
+	ach.GetAddresses(rw, newRequest(http.MethodGet, ""))
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, `[{"id":"3b1f0a6e-3540-11eb-adc1-0242ac120002","line1":"Khreshchatyk 1","line2":"","city":"Kyiv",`+
+		`"postalCode":"01001","country":"UA","createdAt":"2020-12-04T10:00:00Z"}]`, rw.Body.String())
+}
+
+func TestCreateAddress(t *testing.T) {
+	type payload struct {
+		body     string
+		repoMock func(repoMock *repomock.MockDelivery)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Create address with success",
+			payload: payload{
+				body: `{"line1":"Khreshchatyk 1","city":"Kyiv","postalCode":"01001","country":"UA"}`,
+				repoMock: func(repoMock *repomock.MockDelivery) {
+					repoMock.EXPECT().CreateAddress(&entity.DeliveryAddress{UserID: userUUID, Address: addressOne.Address}).
+						DoAndReturn(func(address *entity.DeliveryAddress) error {
+							address.ID = addressOne.ID
+							address.CreatedAt = addressOne.CreatedAt
+							return nil
+						})
+				},
+			},
+			expected: expected{
+				code: http.StatusCreated,
+				body: `{"id":"3b1f0a6e-3540-11eb-adc1-0242ac120002","line1":"Khreshchatyk 1","line2":"","city":"Kyiv",` +
+					`"postalCode":"01001","country":"UA","createdAt":"2020-12-04T10:00:00Z"}`,
+			},
+		},
+		{
+			name: "Create address without country with fail",
+			payload: payload{
+				body:     `{"line1":"Khreshchatyk 1","city":"Kyiv","postalCode":"01001"}`,
+				repoMock: func(repoMock *repomock.MockDelivery) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"line1, city, postal code and country of address must be set"}`,
+			},
+		},
+		{
+			name: "Create address too long field with fail",
+			payload: payload{
+				body:     `{"line1":"` + strings.Repeat("a", maxAddressLength+1) + `","city":"Kyiv","postalCode":"01001","country":"UA"}`,
+				repoMock: func(repoMock *repomock.MockDelivery) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"profile field is too long"}`,
+			},
+		},
+		{
+			name: "Create address db error with fail",
+			payload: payload{
+				body: `{"line1":"Khreshchatyk 1","city":"Kyiv","postalCode":"01001","country":"UA"}`,
+				repoMock: func(repoMock *repomock.MockDelivery) {
+					repoMock.EXPECT().CreateAddress(gomock.Any()).Return(errors.New("error"))
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			ach, m := newHandler(mockCtrl)
+			test.payload.repoMock(m.delivRepo)
+
+			rw := httptest.NewRecorder()
+			ach.CreateAddress(rw, newRequest(http.MethodPost, test.payload.body))
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestDeleteAddress(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name      string
+		addressID string
+		repoMock  func(repoMock *repomock.MockDelivery)
+		expected
+	}{
+		{
+			name:      "Delete address with success",
+			addressID: addressUUID.String(),
+			repoMock: func(repoMock *repomock.MockDelivery) {
+				repoMock.EXPECT().DeleteAddress(userUUID, addressUUID).Return(nil)
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name:      "Delete address of other user with fail",
+			addressID: addressUUID.String(),
+			repoMock: func(repoMock *repomock.MockDelivery) {
+				repoMock.EXPECT().DeleteAddress(userUUID, addressUUID).Return(entity.ErrAddressNotFound)
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"delivery address not found"}`,
+			},
+		},
+		{
+			name:      "Delete address invalid id with fail",
+			addressID: "home",
+			repoMock:  func(repoMock *repomock.MockDelivery) {},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid UUID length: 4"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			ach, m := newHandler(mockCtrl)
+			test.repoMock(m.delivRepo)
+
+			req := mux.SetURLVars(newRequest(http.MethodDelete, ""), map[string]string{"addressID": test.addressID})
+			rw := httptest.NewRecorder()
+			ach.DeleteAddress(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
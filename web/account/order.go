@@ -0,0 +1,103 @@
+package account
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/order"
+	"github.com/mshto/fruit-store/web/common/response"
+)
+
+// cancelNote note of order history recorded for cancellation by user
+const cancelNote = "cancelled by user"
+
+// GetOrders retrieves orders of user without their items, newest first
+func (ach accountHandler) GetOrders(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := ach.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	orders, err := ach.ordersRepo.GetOrders(entity.OrderFilter{UserID: &userUUID})
+	if err != nil {
+		ach.log.Errorf("failed to get orders, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, orders)
+}
+
+// GetOrder retrieves order of user with its items and status history
+func (ach accountHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := ach.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	orderUUID, ok := ach.getOrderUUID(w, r, userUUID)
+	if !ok {
+		return
+	}
+
+	ach.renderOrder(w, userUUID, orderUUID)
+}
+
+// CancelOrder cancel order of user which isn't packed yet, its payment is voided
+// and stock of its products and place in delivery slot are released
+func (ach accountHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := ach.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	orderUUID, ok := ach.getOrderUUID(w, r, userUUID)
+	if !ok {
+		return
+	}
+
+	transition, _ := order.Transition(order.StatusCancelled, cancelNote)
+	err := ach.ordersRepo.SetOrderStatus(orderUUID, &userUUID, transition)
+	switch {
+	case err == entity.ErrOrderNotFound:
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	case err == entity.ErrInvalidTransition:
+		response.RenderFailedResponse(w, http.StatusConflict, entity.ErrOrderNotCancellable)
+		return
+	case err != nil:
+		ach.log.Errorf("failed to cancel order, user: %v, order: %v, error: %v", userUUID, orderUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ach.renderOrder(w, userUUID, orderUUID)
+}
+
+func (ach accountHandler) getOrderUUID(w http.ResponseWriter, r *http.Request, userUUID uuid.UUID) (uuid.UUID, bool) {
+	orderUUID, err := uuid.Parse(mux.Vars(r)["orderID"])
+	if err != nil {
+		ach.log.Errorf("failed to parse order id, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return uuid.Nil, false
+	}
+	return orderUUID, true
+}
+
+func (ach accountHandler) renderOrder(w http.ResponseWriter, userUUID, orderUUID uuid.UUID) {
+	ord, err := ach.ordersRepo.GetOrder(orderUUID, &userUUID)
+	if err == entity.ErrOrderNotFound {
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		ach.log.Errorf("failed to get order, user: %v, order: %v, error: %v", userUUID, orderUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, ord)
+}
@@ -0,0 +1,192 @@
+package account
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/order"
+)
+
+var (
+	orderUUID      = uuid.MustParse("9e4a7d7e-3700-11eb-adc1-0242ac120002")
+	orderCreatedAt = time.Date(2020, 12, 6, 10, 0, 0, 0, time.UTC)
+	orderOne       = entity.Order{
+		ID: orderUUID, Status: "paid", Currency: "USD", Subtotal: 20, Total: 20, Paid: 20, Method: "pickup",
+		CreatedAt: orderCreatedAt, UpdatedAt: orderCreatedAt,
+	}
+)
+
+func TestGetOrders(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ach, m := newHandler(mockCtrl)
+	m.ordersRepo.EXPECT().GetOrders(entity.OrderFilter{UserID: &userUUID}).Return([]entity.Order{orderOne}, nil)
+
+	rw := httptest.NewRecorder()
+	ach.GetOrders(rw, newRequest(http.MethodGet, ""))
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, `[{"id":"9e4a7d7e-3700-11eb-adc1-0242ac120002","status":"paid","currency":"USD","subtotal":20,"tax":0,"shipping":0,`+
+		`"total":20,"paid":20,"method":"pickup","createdAt":"2020-12-06T10:00:00Z","updatedAt":"2020-12-06T10:00:00Z"}]`, rw.Body.String())
+}
+
+func TestGetOrder(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name     string
+		orderID  string
+		repoMock func(m mocks)
+		expected
+	}{
+		{
+			name:    "Get order with success",
+			orderID: orderUUID.String(),
+			repoMock: func(m mocks) {
+				ord := orderOne
+				ord.History = []entity.OrderStatusChange{{Status: "paid", ChangedAt: orderCreatedAt}}
+				m.ordersRepo.EXPECT().GetOrder(orderUUID, &userUUID).Return(&ord, nil)
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"id":"9e4a7d7e-3700-11eb-adc1-0242ac120002","status":"paid","currency":"USD","subtotal":20,"tax":0,"shipping":0,` +
+					`"total":20,"paid":20,"method":"pickup","history":[{"status":"paid","changedAt":"2020-12-06T10:00:00Z"}],` +
+					`"createdAt":"2020-12-06T10:00:00Z","updatedAt":"2020-12-06T10:00:00Z"}`,
+			},
+		},
+		{
+			name:    "Get order of other user with fail",
+			orderID: orderUUID.String(),
+			repoMock: func(m mocks) {
+				m.ordersRepo.EXPECT().GetOrder(orderUUID, &userUUID).Return(nil, entity.ErrOrderNotFound)
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"order not found"}`,
+			},
+		},
+		{
+			name:     "Get order invalid id with fail",
+			orderID:  "last",
+			repoMock: func(m mocks) {},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid UUID length: 4"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			ach, m := newHandler(mockCtrl)
+			test.repoMock(m)
+
+			req := mux.SetURLVars(newRequest(http.MethodGet, ""), map[string]string{"orderID": test.orderID})
+			rw := httptest.NewRecorder()
+			ach.GetOrder(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	cancel, _ := order.Transition(order.StatusCancelled, cancelNote)
+
+	tc := []struct {
+		name     string
+		repoMock func(m mocks)
+		expected
+	}{
+		{
+			name: "Cancel order with success",
+			repoMock: func(m mocks) {
+				ord := orderOne
+				ord.Status = "cancelled"
+				ord.Paid = 0
+				m.ordersRepo.EXPECT().SetOrderStatus(orderUUID, &userUUID, cancel).Return(nil)
+				m.ordersRepo.EXPECT().GetOrder(orderUUID, &userUUID).Return(&ord, nil)
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"id":"9e4a7d7e-3700-11eb-adc1-0242ac120002","status":"cancelled","currency":"USD","subtotal":20,"tax":0,"shipping":0,` +
+					`"total":20,"paid":0,"method":"pickup","createdAt":"2020-12-06T10:00:00Z","updatedAt":"2020-12-06T10:00:00Z"}`,
+			},
+		},
+		{
+			name: "Cancel packed order with fail",
+			repoMock: func(m mocks) {
+				m.ordersRepo.EXPECT().SetOrderStatus(orderUUID, &userUUID, cancel).Return(entity.ErrInvalidTransition)
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"order can't be cancelled after it is packed"}`,
+			},
+		},
+		{
+			name: "Cancel order of other user with fail",
+			repoMock: func(m mocks) {
+				m.ordersRepo.EXPECT().SetOrderStatus(orderUUID, &userUUID, cancel).Return(entity.ErrOrderNotFound)
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"order not found"}`,
+			},
+		},
+		{
+			name: "Cancel order db error with fail",
+			repoMock: func(m mocks) {
+				m.ordersRepo.EXPECT().SetOrderStatus(orderUUID, &userUUID, cancel).Return(errors.New("error"))
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			ach, m := newHandler(mockCtrl)
+			test.repoMock(m)
+
+			req := mux.SetURLVars(newRequest(http.MethodPost, ""), map[string]string{"orderID": orderUUID.String()})
+			rw := httptest.NewRecorder()
+			ach.CancelOrder(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mshto/fruit-store/audit"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/media"
+	"github.com/mshto/fruit-store/repository"
+	"github.com/mshto/fruit-store/web/common/response"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+// page size of audit events and orders
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+var (
+	errInvalidLimit  = errors.New("limit must be 1 to 200")
+	errInvalidOffset = errors.New("offset must not be negative")
+	errOwnRole       = errors.New("own role can't be changed")
+)
+
+// Service admin interface
+type Service interface {
+	GetAuditEvents(w http.ResponseWriter, r *http.Request)
+	UpdateUserRole(w http.ResponseWriter, r *http.Request)
+
+	CreateCategory(w http.ResponseWriter, r *http.Request)
+	DeleteCategory(w http.ResponseWriter, r *http.Request)
+	SetProductCategory(w http.ResponseWriter, r *http.Request)
+	SetProductTags(w http.ResponseWriter, r *http.Request)
+
+	UploadMedia(w http.ResponseWriter, r *http.Request)
+	DeleteMedia(w http.ResponseWriter, r *http.Request)
+
+	SchedulePrice(w http.ResponseWriter, r *http.Request)
+	CancelPrice(w http.ResponseWriter, r *http.Request)
+
+	GetExchangeRates(w http.ResponseWriter, r *http.Request)
+	SetExchangeRate(w http.ResponseWriter, r *http.Request)
+	DeleteExchangeRate(w http.ResponseWriter, r *http.Request)
+	SetProductCurrency(w http.ResponseWriter, r *http.Request)
+
+	GetSlots(w http.ResponseWriter, r *http.Request)
+	CreateSlot(w http.ResponseWriter, r *http.Request)
+	DeleteSlot(w http.ResponseWriter, r *http.Request)
+
+	GetOrders(w http.ResponseWriter, r *http.Request)
+	GetOrder(w http.ResponseWriter, r *http.Request)
+	SetOrderStatus(w http.ResponseWriter, r *http.Request)
+	SetProductStock(w http.ResponseWriter, r *http.Request)
+}
+
+type adminHandler struct {
+	cfg         *config.Config
+	log         *logrus.Logger
+	auditRepo   repository.Audit
+	authRepo    repository.Auth
+	catalogRepo repository.Catalog
+	mediaRepo   repository.Media
+	storage     media.Storage
+	pricesRepo  repository.Prices
+	currRepo    repository.Currencies
+	slotsRepo   repository.Slots
+	ordersRepo  repository.Orders
+	auditor     audit.Auditor
+}
+
+// NewAdminHandler init a new admin handler
+func NewAdminHandler(cfg *config.Config, log *logrus.Logger, auditRepo repository.Audit, authRepo repository.Auth, catalogRepo repository.Catalog,
+	mediaRepo repository.Media, storage media.Storage, pricesRepo repository.Prices, currRepo repository.Currencies, slotsRepo repository.Slots,
+	ordersRepo repository.Orders, auditor audit.Auditor) Service {
+	return adminHandler{
+		cfg:         cfg,
+		log:         log,
+		auditRepo:   auditRepo,
+		authRepo:    authRepo,
+		catalogRepo: catalogRepo,
+		mediaRepo:   mediaRepo,
+		storage:     storage,
+		pricesRepo:  pricesRepo,
+		currRepo:    currRepo,
+		slotsRepo:   slotsRepo,
+		ordersRepo:  ordersRepo,
+		auditor:     auditor,
+	}
+}
+
+// GetAuditEvents retrieves page of audit events, newest first.
+// Events are filtered by actor, action, target, outcome and occurred time in [from, to).
+func (adh adminHandler) GetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		adh.log.Errorf("failed to parse audit filter, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	events, total, err := adh.auditRepo.GetAuditEvents(filter)
+	if err != nil {
+		adh.log.Errorf("failed to get audit events, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, entity.AuditEvents{
+		Events: events,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
+}
+
+// UpdateUserRole set role of user, admins can't change their own role so at least one admin is kept
+func (adh adminHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	userID := mux.Vars(r)["userID"]
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		adh.log.Errorf("failed to parse user id, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	update := entity.RoleUpdate{}
+	err = json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		adh.log.Errorf("failed to decode role, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if update.Role != entity.RoleCustomer && update.Role != entity.RoleAdmin {
+		adh.auditor.Record(r, audit.Event(entity.AuditRoleChange, userID, entity.ErrInvalidRole))
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrInvalidRole)
+		return
+	}
+	if userUUID.String() == adminID {
+		adh.auditor.Record(r, audit.Event(entity.AuditRoleChange, userID, errOwnRole))
+		response.RenderFailedResponse(w, http.StatusConflict, errOwnRole)
+		return
+	}
+
+	err = adh.authRepo.UpdateUserRole(userUUID, update.Role)
+	if err == entity.ErrUserNotFound {
+		adh.log.Errorf("failed to update user role, admin: %v, user: %v, error: %v", adminID, userID, err)
+		adh.auditor.Record(r, audit.Event(entity.AuditRoleChange, userID, err))
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		adh.log.Errorf("failed to update user role, admin: %v, user: %v, error: %v", adminID, userID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	event := audit.Event(entity.AuditRoleChange, userID, nil)
+	event.Details = "role set to " + update.Role
+	adh.auditor.Record(r, event)
+
+	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+}
+
+// parseAuditFilter get filter from query, times are RFC 3339
+func parseAuditFilter(r *http.Request) (entity.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := entity.AuditFilter{
+		Actor:   query.Get("actor"),
+		Action:  query.Get("action"),
+		Target:  query.Get("target"),
+		Outcome: query.Get("outcome"),
+	}
+
+	var err error
+	if filter.From, err = parseTime(query.Get("from")); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTime(query.Get("to")); err != nil {
+		return filter, err
+	}
+
+	filter.Limit, filter.Offset, err = parsePage(query)
+	return filter, err
+}
+
+// parsePage parse limit and offset of page, default limit is used when it isn't set
+func parsePage(query url.Values) (int, int, error) {
+	limit, offset := defaultLimit, 0
+
+	var err error
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return limit, offset, errInvalidLimit
+		}
+	}
+	if value := query.Get("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return limit, offset, errInvalidOffset
+		}
+	}
+	return limit, offset, nil
+}
+
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
@@ -0,0 +1,309 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	auditmock "github.com/mshto/fruit-store/audit/mock"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+var (
+	adminUUID  = uuid.MustParse("3e9e03f7-2c3e-11eb-adc1-0242ac120002")
+	userUUID   = uuid.MustParse("0b6bd0c4-2c3e-11eb-adc1-0242ac120002")
+	occurredAt = time.Date(2020, 11, 27, 10, 0, 0, 0, time.UTC)
+)
+
+func TestGetAuditEvents(t *testing.T) {
+	type payload struct {
+		query     string
+		auditMock func(auditMock *repomock.MockAudit)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	from := occurredAt
+	to := occurredAt.Add(time.Hour)
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Get audit events with success",
+			payload: payload{
+				auditMock: func(auditMock *repomock.MockAudit) {
+					auditMock.EXPECT().GetAuditEvents(entity.AuditFilter{Limit: defaultLimit}).Return([]entity.AuditEvent{{
+						ID: 1, OccurredAt: occurredAt, IP: "10.0.0.1", UserAgent: "agent", Action: entity.AuditSignin, Target: "test",
+						Outcome: entity.AuditFailure, Details: "invalid username or password",
+					}}, 1, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"events":[{"id":1,"occurredAt":"2020-11-27T10:00:00Z","actor":"","ip":"10.0.0.1","userAgent":"agent","action":"auth.signin","target":"test","outcome":"failure","details":"invalid username or password"}],"total":1,"limit":50,"offset":0}`,
+			},
+		},
+		{
+			name: "Get audit events with filter with success",
+			payload: payload{
+				query: "?actor=user&action=auth.signin&target=test&outcome=failure&from=2020-11-27T10:00:00Z&to=2020-11-27T11:00:00Z&limit=10&offset=20",
+				auditMock: func(auditMock *repomock.MockAudit) {
+					auditMock.EXPECT().GetAuditEvents(entity.AuditFilter{
+						Actor: "user", Action: entity.AuditSignin, Target: "test", Outcome: entity.AuditFailure, From: &from, To: &to, Limit: 10, Offset: 20,
+					}).Return([]entity.AuditEvent{}, 20, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"events":[],"total":20,"limit":10,"offset":20}`,
+			},
+		},
+		{
+			name: "Get audit events invalid limit with fail",
+			payload: payload{
+				query:     "?limit=1000",
+				auditMock: func(auditMock *repomock.MockAudit) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"limit must be 1 to 200"}`,
+			},
+		},
+		{
+			name: "Get audit events invalid offset with fail",
+			payload: payload{
+				query:     "?offset=-1",
+				auditMock: func(auditMock *repomock.MockAudit) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"offset must not be negative"}`,
+			},
+		},
+		{
+			name: "Get audit events invalid from with fail",
+			payload: payload{
+				query:     "?from=yesterday",
+				auditMock: func(auditMock *repomock.MockAudit) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"parsing time \"yesterday\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"yesterday\" as \"2006\""}`,
+			},
+		},
+		{
+			name: "Get audit events GetAuditEvents error with fail",
+			payload: payload{
+				auditMock: func(auditMock *repomock.MockAudit) {
+					auditMock.EXPECT().GetAuditEvents(gomock.Any()).Return(nil, 0, errors.New("error"))
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			auditRepo := repomock.NewMockAudit(mockCtrl)
+			test.payload.auditMock(auditRepo)
+
+			req, _ := http.NewRequest(http.MethodGet, "/v1/admin/audit-events"+test.payload.query, nil)
+			rw := httptest.NewRecorder()
+
+			adh := NewAdminHandler(&config.Config{}, logger, auditRepo, repomock.NewMockAuth(mockCtrl), repomock.NewMockCatalog(mockCtrl), repomock.NewMockMedia(mockCtrl), nil, repomock.NewMockPrices(mockCtrl), repomock.NewMockCurrencies(mockCtrl), repomock.NewMockSlots(mockCtrl), repomock.NewMockOrders(mockCtrl), auditmock.NewMockAuditor(mockCtrl))
+			adh.GetAuditEvents(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	type payload struct {
+		userID    string
+		body      string
+		authMock  func(authMock *repomock.MockAuth)
+		auditMock func(auditMock *auditmock.MockAuditor)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Update user role with success",
+			payload: payload{
+				userID: userUUID.String(),
+				body:   `{"role":"admin"}`,
+				authMock: func(authMock *repomock.MockAuth) {
+					authMock.EXPECT().UpdateUserRole(userUUID, entity.RoleAdmin).Return(nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditRoleChange, Target: userUUID.String(), Outcome: entity.AuditSuccess, Details: "role set to admin",
+					})
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Update user role unknown role with fail",
+			payload: payload{
+				userID:   userUUID.String(),
+				body:     `{"role":"root"}`,
+				authMock: func(authMock *repomock.MockAuth) {},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditRoleChange, Target: userUUID.String(), Outcome: entity.AuditFailure, Details: "role is invalid",
+					})
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"role is invalid"}`,
+			},
+		},
+		{
+			name: "Update user role own role with fail",
+			payload: payload{
+				userID:   adminUUID.String(),
+				body:     `{"role":"customer"}`,
+				authMock: func(authMock *repomock.MockAuth) {},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"own role can't be changed"}`,
+			},
+		},
+		{
+			name: "Update user role unknown user with fail",
+			payload: payload{
+				userID: userUUID.String(),
+				body:   `{"role":"admin"}`,
+				authMock: func(authMock *repomock.MockAuth) {
+					authMock.EXPECT().UpdateUserRole(userUUID, entity.RoleAdmin).Return(entity.ErrUserNotFound)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"user not found"}`,
+			},
+		},
+		{
+			name: "Update user role invalid user id with fail",
+			payload: payload{
+				userID:    "invalid",
+				body:      `{"role":"admin"}`,
+				authMock:  func(authMock *repomock.MockAuth) {},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid UUID length: 7"}`,
+			},
+		},
+		{
+			name: "Update user role invalid body with fail",
+			payload: payload{
+				userID:    userUUID.String(),
+				body:      `{`,
+				authMock:  func(authMock *repomock.MockAuth) {},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"unexpected EOF"}`,
+			},
+		},
+		{
+			name: "Update user role UpdateUserRole error with fail",
+			payload: payload{
+				userID: userUUID.String(),
+				body:   `{"role":"customer"}`,
+				authMock: func(authMock *repomock.MockAuth) {
+					authMock.EXPECT().UpdateUserRole(userUUID, entity.RoleCustomer).Return(errors.New("error"))
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			authRepo := repomock.NewMockAuth(mockCtrl)
+			test.payload.authMock(authRepo)
+
+			auditor := auditmock.NewMockAuditor(mockCtrl)
+			test.payload.auditMock(auditor)
+
+			req, _ := http.NewRequest(http.MethodPut, "/v1/admin/users/"+test.payload.userID+"/role", bytes.NewBufferString(test.payload.body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserUUID, adminUUID.String()))
+			rw := httptest.NewRecorder()
+
+			adh := NewAdminHandler(&config.Config{}, logger, repomock.NewMockAudit(mockCtrl), authRepo, repomock.NewMockCatalog(mockCtrl), repomock.NewMockMedia(mockCtrl), nil, repomock.NewMockPrices(mockCtrl), repomock.NewMockCurrencies(mockCtrl), repomock.NewMockSlots(mockCtrl), repomock.NewMockOrders(mockCtrl), auditor)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/v1/admin/users/{userID}/role", adh.UpdateUserRole)
+			router.ServeHTTP(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/mshto/fruit-store/audit"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/web/common/response"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+// category name and tag length limits
+const (
+	maxCategoryNameLength = 100
+	maxTagLength          = 50
+)
+
+var slugRegexp = regexp.MustCompile(`^[a-z0-9-]{1,50}$`)
+
+// CreateCategory create category, it is created under parent category when parentId is set
+func (adh adminHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	create := entity.CategoryCreate{}
+	err := json.NewDecoder(r.Body).Decode(&create)
+	if err != nil {
+		adh.log.Errorf("failed to decode category, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	category := &entity.Category{
+		ParentID: create.ParentID,
+		Slug:     create.Slug,
+		Name:     strings.TrimSpace(create.Name),
+	}
+	if !slugRegexp.MatchString(category.Slug) {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrInvalidSlug)
+		return
+	}
+	if category.Name == "" || utf8.RuneCountInString(category.Name) > maxCategoryNameLength {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrInvalidCategoryName)
+		return
+	}
+
+	err = adh.catalogRepo.CreateCategory(category)
+	switch {
+	case err == entity.ErrCategoryNotFound:
+		adh.auditor.Record(r, audit.Event(entity.AuditCategoryCreate, category.Slug, err))
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	case err == entity.ErrCategoryAlreadyExist:
+		adh.auditor.Record(r, audit.Event(entity.AuditCategoryCreate, category.Path, err))
+		response.RenderFailedResponse(w, http.StatusConflict, err)
+		return
+	case err != nil:
+		adh.log.Errorf("failed to create category, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	adh.auditor.Record(r, audit.Event(entity.AuditCategoryCreate, category.Path, nil))
+	response.RenderResponse(w, http.StatusCreated, category)
+}
+
+// DeleteCategory delete category without subcategories, its products are left without category
+func (adh adminHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	categoryID := mux.Vars(r)["categoryID"]
+	categoryUUID, err := uuid.Parse(categoryID)
+	if err != nil {
+		adh.log.Errorf("failed to parse category id, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = adh.catalogRepo.DeleteCategory(categoryUUID)
+	switch {
+	case err == entity.ErrCategoryNotFound:
+		adh.auditor.Record(r, audit.Event(entity.AuditCategoryDelete, categoryID, err))
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	case err == entity.ErrCategoryNotEmpty:
+		adh.auditor.Record(r, audit.Event(entity.AuditCategoryDelete, categoryID, err))
+		response.RenderFailedResponse(w, http.StatusConflict, err)
+		return
+	case err != nil:
+		adh.log.Errorf("failed to delete category, admin: %v, category: %v, error: %v", adminID, categoryID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	adh.auditor.Record(r, audit.Event(entity.AuditCategoryDelete, categoryID, nil))
+	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+}
+
+// SetProductCategory move product to category, null categoryId removes product from its category
+func (adh adminHandler) SetProductCategory(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	productID := mux.Vars(r)["productID"]
+	productUUID, err := uuid.Parse(productID)
+	if err != nil {
+		adh.log.Errorf("failed to parse product id, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	update := entity.ProductCategory{}
+	err = json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		adh.log.Errorf("failed to decode product category, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = adh.catalogRepo.SetProductCategory(productUUID, update.CategoryID)
+	switch {
+	case err == entity.ErrProductNotFound:
+		adh.auditor.Record(r, audit.Event(entity.AuditProductCategory, productID, err))
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	case err == entity.ErrCategoryNotFound:
+		adh.auditor.Record(r, audit.Event(entity.AuditProductCategory, productID, err))
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	case err != nil:
+		adh.log.Errorf("failed to set product category, admin: %v, product: %v, error: %v", adminID, productID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	event := audit.Event(entity.AuditProductCategory, productID, nil)
+	event.Details = "category removed"
+	if update.CategoryID != nil {
+		event.Details = "category set to " + update.CategoryID.String()
+	}
+	adh.auditor.Record(r, event)
+
+	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+}
+
+// SetProductTags replace tags of product, tags are trimmed and deduplicated
+func (adh adminHandler) SetProductTags(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	productID := mux.Vars(r)["productID"]
+	productUUID, err := uuid.Parse(productID)
+	if err != nil {
+		adh.log.Errorf("failed to parse product id, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	update := entity.ProductTags{}
+	err = json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		adh.log.Errorf("failed to decode product tags, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tags, err := normalizeTags(update.Tags)
+	if err != nil {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	err = adh.catalogRepo.SetProductTags(productUUID, tags)
+	if err == entity.ErrProductNotFound {
+		adh.auditor.Record(r, audit.Event(entity.AuditProductTags, productID, err))
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		adh.log.Errorf("failed to set product tags, admin: %v, product: %v, error: %v", adminID, productID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	event := audit.Event(entity.AuditProductTags, productID, nil)
+	event.Details = "tags set to [" + strings.Join(tags, ", ") + "]"
+	adh.auditor.Record(r, event)
+
+	response.RenderResponse(w, http.StatusOK, entity.ProductTags{Tags: tags})
+}
+
+// normalizeTags trim, deduplicate and sort tags
+// SetProductStock set stock of product in the unit of product, null stock isn't tracked.
+// Orders reserve stock of their products, product without enough stock can't be ordered.
+func (adh adminHandler) SetProductStock(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	productID := mux.Vars(r)["productID"]
+	productUUID, err := uuid.Parse(productID)
+	if err != nil {
+		adh.log.Errorf("failed to parse product id, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	update := entity.ProductStock{}
+	err = json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		adh.log.Errorf("failed to decode product stock, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if update.Stock != nil && *update.Stock < 0 {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrInvalidStock)
+		return
+	}
+
+	err = adh.catalogRepo.SetProductStock(productUUID, update.Stock)
+	switch {
+	case err == entity.ErrProductNotFound:
+		adh.auditor.Record(r, audit.Event(entity.AuditProductStock, productID, err))
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	case err != nil:
+		adh.log.Errorf("failed to set product stock, admin: %v, product: %v, error: %v", adminID, productID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	event := audit.Event(entity.AuditProductStock, productID, nil)
+	event.Details = "stock isn't tracked"
+	if update.Stock != nil {
+		event.Details = fmt.Sprintf("stock set to %v", *update.Stock)
+	}
+	adh.auditor.Record(r, event)
+
+	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+}
+
+func normalizeTags(tags []string) ([]string, error) {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, entity.ErrInvalidTag
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	sort.Strings(result)
+	return result, nil
+}
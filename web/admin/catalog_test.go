@@ -0,0 +1,505 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	auditmock "github.com/mshto/fruit-store/audit/mock"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+var (
+	categoryUUID = uuid.MustParse("0b7a33b2-3197-11eb-adc1-0242ac120002")
+	productUUID  = uuid.MustParse("e2d49480-2c1a-11eb-adc1-0242ac120002")
+)
+
+type catalogPayload struct {
+	method      string
+	url         string
+	body        string
+	catalogMock func(catalogMock *repomock.MockCatalog)
+	auditMock   func(auditMock *auditmock.MockAuditor)
+}
+
+type catalogExpected struct {
+	code int
+	body string
+}
+
+// serveCatalog serve request of admin to catalog route
+func serveCatalog(t *testing.T, route string, handler func(Service) http.HandlerFunc, payload catalogPayload) *httptest.ResponseRecorder {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	logger, _ := loggermock.NewNullLogger()
+
+	catalogRepo := repomock.NewMockCatalog(mockCtrl)
+	payload.catalogMock(catalogRepo)
+
+	auditor := auditmock.NewMockAuditor(mockCtrl)
+	payload.auditMock(auditor)
+
+	req, _ := http.NewRequest(payload.method, payload.url, bytes.NewBufferString(payload.body))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserUUID, adminUUID.String()))
+	rw := httptest.NewRecorder()
+
+	adh := NewAdminHandler(&config.Config{}, logger, repomock.NewMockAudit(mockCtrl), repomock.NewMockAuth(mockCtrl), catalogRepo, repomock.NewMockMedia(mockCtrl), nil, repomock.NewMockPrices(mockCtrl), repomock.NewMockCurrencies(mockCtrl), repomock.NewMockSlots(mockCtrl), repomock.NewMockOrders(mockCtrl), auditor)
+
+	router := mux.NewRouter()
+	router.HandleFunc(route, handler(adh))
+	router.ServeHTTP(rw, req)
+	return rw
+}
+
+func TestCreateCategory(t *testing.T) {
+	tc := []struct {
+		name     string
+		expected catalogExpected
+		payload  catalogPayload
+	}{
+		{
+			name: "Create category with success",
+			payload: catalogPayload{
+				body: `{"parentId":"0b7a33b2-3197-11eb-adc1-0242ac120002","slug":"exotic","name":" Exotic "}`,
+				catalogMock: func(catalogMock *repomock.MockCatalog) {
+					catalogMock.EXPECT().CreateCategory(&entity.Category{ParentID: &categoryUUID, Slug: "exotic", Name: "Exotic"}).
+						DoAndReturn(func(category *entity.Category) error {
+							category.ID = productUUID
+							category.Path = "tropical/exotic"
+							category.CreatedAt = occurredAt
+							return nil
+						})
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditCategoryCreate, Target: "tropical/exotic", Outcome: entity.AuditSuccess,
+					})
+				},
+			},
+			expected: catalogExpected{
+				code: http.StatusCreated,
+				body: `{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","parentId":"0b7a33b2-3197-11eb-adc1-0242ac120002",` +
+					`"slug":"exotic","name":"Exotic","path":"tropical/exotic","createdAt":"2020-11-27T10:00:00Z"}`,
+			},
+		},
+		{
+			name: "Create category invalid slug with fail",
+			payload: catalogPayload{
+				body:        `{"slug":"Exotic Fruit","name":"Exotic"}`,
+				catalogMock: func(catalogMock *repomock.MockCatalog) {},
+				auditMock:   func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: catalogExpected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"slug must be 1 to 50 lowercase letters, digits or dashes"}`,
+			},
+		},
+		{
+			name: "Create category empty name with fail",
+			payload: catalogPayload{
+				body:        `{"slug":"exotic","name":" "}`,
+				catalogMock: func(catalogMock *repomock.MockCatalog) {},
+				auditMock:   func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: catalogExpected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"category name must be 1 to 100 characters long"}`,
+			},
+		},
+		{
+			name: "Create category which already exists with fail",
+			payload: catalogPayload{
+				body: `{"slug":"citrus","name":"Citrus"}`,
+				catalogMock: func(catalogMock *repomock.MockCatalog) {
+					catalogMock.EXPECT().CreateCategory(gomock.Any()).Return(entity.ErrCategoryAlreadyExist)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: catalogExpected{
+				code: http.StatusConflict,
+				body: `{"error":"category already exists"}`,
+			},
+		},
+		{
+			name: "Create category CreateCategory error with fail",
+			payload: catalogPayload{
+				body: `{"slug":"citrus","name":"Citrus"}`,
+				catalogMock: func(catalogMock *repomock.MockCatalog) {
+					catalogMock.EXPECT().CreateCategory(gomock.Any()).Return(errors.New("error"))
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: catalogExpected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			test.payload.method = http.MethodPost
+			test.payload.url = "/v1/admin/categories"
+			rw := serveCatalog(t, "/v1/admin/categories", func(s Service) http.HandlerFunc { return s.CreateCategory }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestDeleteCategory(t *testing.T) {
+	tc := []struct {
+		name     string
+		expected catalogExpected
+		payload  catalogPayload
+	}{
+		{
+			name: "Delete category with success",
+			payload: catalogPayload{
+				url: "/v1/admin/categories/" + categoryUUID.String(),
+				catalogMock: func(catalogMock *repomock.MockCatalog) {
+					catalogMock.EXPECT().DeleteCategory(categoryUUID).Return(nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditCategoryDelete, Target: categoryUUID.String(), Outcome: entity.AuditSuccess,
+					})
+				},
+			},
+			expected: catalogExpected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Delete category with subcategories with fail",
+			payload: catalogPayload{
+				url: "/v1/admin/categories/" + categoryUUID.String(),
+				catalogMock: func(catalogMock *repomock.MockCatalog) {
+					catalogMock.EXPECT().DeleteCategory(categoryUUID).Return(entity.ErrCategoryNotEmpty)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: catalogExpected{
+				code: http.StatusConflict,
+				body: `{"error":"category has subcategories"}`,
+			},
+		},
+		{
+			name: "Delete unknown category with fail",
+			payload: catalogPayload{
+				url: "/v1/admin/categories/" + categoryUUID.String(),
+				catalogMock: func(catalogMock *repomock.MockCatalog) {
+					catalogMock.EXPECT().DeleteCategory(categoryUUID).Return(entity.ErrCategoryNotFound)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: catalogExpected{
+				code: http.StatusNotFound,
+				body: `{"error":"category not found"}`,
+			},
+		},
+		{
+			name: "Delete category invalid id with fail",
+			payload: catalogPayload{
+				url:         "/v1/admin/categories/invalid",
+				catalogMock: func(catalogMock *repomock.MockCatalog) {},
+				auditMock:   func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: catalogExpected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid UUID length: 7"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			test.payload.method = http.MethodDelete
+			rw := serveCatalog(t, "/v1/admin/categories/{categoryID}", func(s Service) http.HandlerFunc { return s.DeleteCategory }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestSetProductCategory(t *testing.T) {
+	tc := []struct {
+		name     string
+		expected catalogExpected
+		payload  catalogPayload
+	}{
+		{
+			name: "Set product category with success",
+			payload: catalogPayload{
+				body: `{"categoryId":"0b7a33b2-3197-11eb-adc1-0242ac120002"}`,
+				catalogMock: func(catalogMock *repomock.MockCatalog) {
+					catalogMock.EXPECT().SetProductCategory(productUUID, &categoryUUID).Return(nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditProductCategory, Target: productUUID.String(), Outcome: entity.AuditSuccess,
+						Details: "category set to " + categoryUUID.String(),
+					})
+				},
+			},
+			expected: catalogExpected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Remove product category with success",
+			payload: catalogPayload{
+				body: `{"categoryId":null}`,
+				catalogMock: func(catalogMock *repomock.MockCatalog) {
+					catalogMock.EXPECT().SetProductCategory(productUUID, nil).Return(nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditProductCategory, Target: productUUID.String(), Outcome: entity.AuditSuccess,
+						Details: "category removed",
+					})
+				},
+			},
+			expected: catalogExpected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Set unknown product category with fail",
+			payload: catalogPayload{
+				body: `{"categoryId":"0b7a33b2-3197-11eb-adc1-0242ac120002"}`,
+				catalogMock: func(catalogMock *repomock.MockCatalog) {
+					catalogMock.EXPECT().SetProductCategory(productUUID, &categoryUUID).Return(entity.ErrCategoryNotFound)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: catalogExpected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"category not found"}`,
+			},
+		},
+		{
+			name: "Set category of unknown product with fail",
+			payload: catalogPayload{
+				body: `{"categoryId":null}`,
+				catalogMock: func(catalogMock *repomock.MockCatalog) {
+					catalogMock.EXPECT().SetProductCategory(productUUID, nil).Return(entity.ErrProductNotFound)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: catalogExpected{
+				code: http.StatusNotFound,
+				body: `{"error":"product not found"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			test.payload.method = http.MethodPut
+			test.payload.url = "/v1/admin/products/" + productUUID.String() + "/category"
+			rw := serveCatalog(t, "/v1/admin/products/{productID}/category", func(s Service) http.HandlerFunc { return s.SetProductCategory }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestSetProductStock(t *testing.T) {
+	stock := 12.5
+
+	tc := []struct {
+		name     string
+		expected catalogExpected
+		payload  catalogPayload
+	}{
+		{
+			name: "Set product stock with success",
+			payload: catalogPayload{
+				body: `{"stock":12.5}`,
+				catalogMock: func(catalogMock *repomock.MockCatalog) {
+					catalogMock.EXPECT().SetProductStock(productUUID, &stock).Return(nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditProductStock, Target: productUUID.String(), Outcome: entity.AuditSuccess,
+						Details: "stock set to 12.5",
+					})
+				},
+			},
+			expected: catalogExpected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Stop tracking product stock with success",
+			payload: catalogPayload{
+				body: `{"stock":null}`,
+				catalogMock: func(catalogMock *repomock.MockCatalog) {
+					catalogMock.EXPECT().SetProductStock(productUUID, nil).Return(nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditProductStock, Target: productUUID.String(), Outcome: entity.AuditSuccess,
+						Details: "stock isn't tracked",
+					})
+				},
+			},
+			expected: catalogExpected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Set negative product stock with fail",
+			payload: catalogPayload{
+				body:        `{"stock":-1}`,
+				catalogMock: func(catalogMock *repomock.MockCatalog) {},
+				auditMock:   func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: catalogExpected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"stock can't be negative"}`,
+			},
+		},
+		{
+			name: "Set stock of unknown product with fail",
+			payload: catalogPayload{
+				body: `{"stock":12.5}`,
+				catalogMock: func(catalogMock *repomock.MockCatalog) {
+					catalogMock.EXPECT().SetProductStock(productUUID, &stock).Return(entity.ErrProductNotFound)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: catalogExpected{
+				code: http.StatusNotFound,
+				body: `{"error":"product not found"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			test.payload.method = http.MethodPut
+			test.payload.url = "/v1/admin/products/" + productUUID.String() + "/stock"
+			rw := serveCatalog(t, "/v1/admin/products/{productID}/stock", func(s Service) http.HandlerFunc { return s.SetProductStock }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestSetProductTags(t *testing.T) {
+	tc := []struct {
+		name     string
+		expected catalogExpected
+		payload  catalogPayload
+	}{
+		{
+			name: "Set product tags with success",
+			payload: catalogPayload{
+				body: `{"tags":["seasonal"," organic","seasonal"]}`,
+				catalogMock: func(catalogMock *repomock.MockCatalog) {
+					catalogMock.EXPECT().SetProductTags(productUUID, []string{"organic", "seasonal"}).Return(nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditProductTags, Target: productUUID.String(), Outcome: entity.AuditSuccess,
+						Details: "tags set to [organic, seasonal]",
+					})
+				},
+			},
+			expected: catalogExpected{
+				code: http.StatusOK,
+				body: `{"tags":["organic","seasonal"]}`,
+			},
+		},
+		{
+			name: "Set product empty tag with fail",
+			payload: catalogPayload{
+				body:        `{"tags":["organic",""]}`,
+				catalogMock: func(catalogMock *repomock.MockCatalog) {},
+				auditMock:   func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: catalogExpected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"tag must be 1 to 50 characters long"}`,
+			},
+		},
+		{
+			name: "Set tags of unknown product with fail",
+			payload: catalogPayload{
+				body: `{"tags":[]}`,
+				catalogMock: func(catalogMock *repomock.MockCatalog) {
+					catalogMock.EXPECT().SetProductTags(productUUID, []string{}).Return(entity.ErrProductNotFound)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: catalogExpected{
+				code: http.StatusNotFound,
+				body: `{"error":"product not found"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			test.payload.method = http.MethodPut
+			test.payload.url = "/v1/admin/products/" + productUUID.String() + "/tags"
+			rw := serveCatalog(t, "/v1/admin/products/{productID}/tags", func(s Service) http.HandlerFunc { return s.SetProductTags }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/mshto/fruit-store/audit"
+	"github.com/mshto/fruit-store/currency"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/web/common/response"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+// GetExchangeRates retrieves exchange rates of all currencies to base currency
+func (adh adminHandler) GetExchangeRates(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	rates, err := adh.currRepo.GetExchangeRates()
+	if err != nil {
+		adh.log.Errorf("failed to get exchange rates, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, rates)
+}
+
+// SetExchangeRate create or update exchange rate of currency, rate is units of currency per unit of base currency
+func (adh adminHandler) SetExchangeRate(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	code, err := currency.Parse(mux.Vars(r)["currency"])
+	if err != nil {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if code == adh.cfg.Currency.BaseCode() {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrBaseCurrencyRate)
+		return
+	}
+
+	update := entity.ExchangeRateUpdate{}
+	err = json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		adh.log.Errorf("failed to decode exchange rate, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if update.Rate <= 0 {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrInvalidExchangeRate)
+		return
+	}
+
+	rate := &entity.ExchangeRate{Currency: code, Rate: update.Rate}
+	err = adh.currRepo.SetExchangeRate(rate)
+	if err != nil {
+		adh.log.Errorf("failed to set exchange rate, admin: %v, currency: %v, error: %v", adminID, code, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	event := audit.Event(entity.AuditExchangeRateSet, code, nil)
+	event.Details = fmt.Sprintf("rate %v", rate.Rate)
+	adh.auditor.Record(r, event)
+
+	response.RenderResponse(w, http.StatusOK, rate)
+}
+
+// DeleteExchangeRate delete exchange rate of currency no product is priced in
+func (adh adminHandler) DeleteExchangeRate(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	code, err := currency.Parse(mux.Vars(r)["currency"])
+	if err != nil {
+		response.RenderFailedResponse(w, http.StatusNotFound, entity.ErrExchangeRateNotFound)
+		return
+	}
+
+	err = adh.currRepo.DeleteExchangeRate(code)
+	switch {
+	case err == entity.ErrExchangeRateNotFound:
+		adh.auditor.Record(r, audit.Event(entity.AuditExchangeRateDelete, code, err))
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	case err == entity.ErrExchangeRateInUse:
+		adh.auditor.Record(r, audit.Event(entity.AuditExchangeRateDelete, code, err))
+		response.RenderFailedResponse(w, http.StatusConflict, err)
+		return
+	case err != nil:
+		adh.log.Errorf("failed to delete exchange rate, admin: %v, currency: %v, error: %v", adminID, code, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	adh.auditor.Record(r, audit.Event(entity.AuditExchangeRateDelete, code, nil))
+	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+}
+
+// SetProductCurrency set currency price of product is in, null or base currency prices product in base currency.
+// Price itself is not converted.
+func (adh adminHandler) SetProductCurrency(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	productID := mux.Vars(r)["productID"]
+	productUUID, err := uuid.Parse(productID)
+	if err != nil {
+		adh.log.Errorf("failed to parse product id, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	update := entity.ProductCurrency{}
+	err = json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		adh.log.Errorf("failed to decode product currency, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	base := adh.cfg.Currency.BaseCode()
+	code := base
+	if update.Currency != nil {
+		code, err = currency.Parse(*update.Currency)
+		if err != nil {
+			response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+	}
+
+	var productCurrency *string
+	if code != base {
+		productCurrency = &code
+	}
+
+	err = adh.currRepo.SetProductCurrency(productUUID, productCurrency)
+	switch {
+	case err == entity.ErrProductNotFound:
+		adh.auditor.Record(r, audit.Event(entity.AuditProductCurrency, productID, err))
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	case err == entity.ErrExchangeRateNotFound:
+		adh.auditor.Record(r, audit.Event(entity.AuditProductCurrency, productID, err))
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	case err != nil:
+		adh.log.Errorf("failed to set product currency, admin: %v, product: %v, error: %v", adminID, productID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	event := audit.Event(entity.AuditProductCurrency, productID, nil)
+	event.Details = "currency set to " + code
+	adh.auditor.Record(r, event)
+
+	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+}
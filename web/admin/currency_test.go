@@ -0,0 +1,414 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	auditmock "github.com/mshto/fruit-store/audit/mock"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+type currencyPayload struct {
+	url       string
+	body      string
+	currMock  func(currMock *repomock.MockCurrencies)
+	auditMock func(auditMock *auditmock.MockAuditor)
+}
+
+// serveCurrency serve request of admin to currency route
+func serveCurrency(t *testing.T, method, route string, handler func(Service) http.HandlerFunc, payload currencyPayload) *httptest.ResponseRecorder {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	logger, _ := loggermock.NewNullLogger()
+
+	currRepo := repomock.NewMockCurrencies(mockCtrl)
+	payload.currMock(currRepo)
+
+	auditor := auditmock.NewMockAuditor(mockCtrl)
+	payload.auditMock(auditor)
+
+	req, _ := http.NewRequest(method, payload.url, bytes.NewBufferString(payload.body))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserUUID, adminUUID.String()))
+	rw := httptest.NewRecorder()
+
+	adh := NewAdminHandler(&config.Config{}, logger, repomock.NewMockAudit(mockCtrl), repomock.NewMockAuth(mockCtrl), repomock.NewMockCatalog(mockCtrl),
+		repomock.NewMockMedia(mockCtrl), nil, repomock.NewMockPrices(mockCtrl), currRepo, repomock.NewMockSlots(mockCtrl), repomock.NewMockOrders(mockCtrl), auditor)
+
+	router := mux.NewRouter()
+	router.HandleFunc(route, handler(adh))
+	router.ServeHTTP(rw, req)
+	return rw
+}
+
+func TestGetExchangeRates(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name     string
+		expected expected
+		payload  currencyPayload
+	}{
+		{
+			name: "Get exchange rates with success",
+			payload: currencyPayload{
+				currMock: func(currMock *repomock.MockCurrencies) {
+					currMock.EXPECT().GetExchangeRates().Return([]entity.ExchangeRate{{Currency: "EUR", Rate: 0.84, UpdatedAt: occurredAt}}, nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `[{"currency":"EUR","rate":0.84,"updatedAt":"2020-11-27T10:00:00Z"}]`,
+			},
+		},
+		{
+			name: "Get exchange rates GetExchangeRates error with fail",
+			payload: currencyPayload{
+				currMock: func(currMock *repomock.MockCurrencies) {
+					currMock.EXPECT().GetExchangeRates().Return(nil, errors.New("error"))
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			test.payload.url = "/v1/admin/exchange-rates"
+			rw := serveCurrency(t, http.MethodGet, "/v1/admin/exchange-rates",
+				func(s Service) http.HandlerFunc { return s.GetExchangeRates }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestSetExchangeRate(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name     string
+		expected expected
+		payload  currencyPayload
+	}{
+		{
+			name: "Set exchange rate with success",
+			payload: currencyPayload{
+				url:  "/v1/admin/exchange-rates/eur",
+				body: `{"rate":0.84}`,
+				currMock: func(currMock *repomock.MockCurrencies) {
+					currMock.EXPECT().SetExchangeRate(&entity.ExchangeRate{Currency: "EUR", Rate: 0.84}).
+						DoAndReturn(func(rate *entity.ExchangeRate) error {
+							rate.UpdatedAt = occurredAt
+							return nil
+						})
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditExchangeRateSet, Target: "EUR", Outcome: entity.AuditSuccess, Details: "rate 0.84",
+					})
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"currency":"EUR","rate":0.84,"updatedAt":"2020-11-27T10:00:00Z"}`,
+			},
+		},
+		{
+			name: "Set exchange rate of base currency with fail",
+			payload: currencyPayload{
+				url:       "/v1/admin/exchange-rates/USD",
+				body:      `{"rate":1}`,
+				currMock:  func(currMock *repomock.MockCurrencies) {},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"rate of base currency can't be set"}`,
+			},
+		},
+		{
+			name: "Set exchange rate of unsupported currency with fail",
+			payload: currencyPayload{
+				url:       "/v1/admin/exchange-rates/BTC",
+				body:      `{"rate":1}`,
+				currMock:  func(currMock *repomock.MockCurrencies) {},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"currency is not supported"}`,
+			},
+		},
+		{
+			name: "Set zero exchange rate with fail",
+			payload: currencyPayload{
+				url:       "/v1/admin/exchange-rates/EUR",
+				body:      `{"rate":0}`,
+				currMock:  func(currMock *repomock.MockCurrencies) {},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"rate must be positive"}`,
+			},
+		},
+		{
+			name: "Set exchange rate SetExchangeRate error with fail",
+			payload: currencyPayload{
+				url:  "/v1/admin/exchange-rates/EUR",
+				body: `{"rate":0.84}`,
+				currMock: func(currMock *repomock.MockCurrencies) {
+					currMock.EXPECT().SetExchangeRate(gomock.Any()).Return(errors.New("error"))
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rw := serveCurrency(t, http.MethodPut, "/v1/admin/exchange-rates/{currency}",
+				func(s Service) http.HandlerFunc { return s.SetExchangeRate }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestDeleteExchangeRate(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name     string
+		expected expected
+		payload  currencyPayload
+	}{
+		{
+			name: "Delete exchange rate with success",
+			payload: currencyPayload{
+				url: "/v1/admin/exchange-rates/EUR",
+				currMock: func(currMock *repomock.MockCurrencies) {
+					currMock.EXPECT().DeleteExchangeRate("EUR").Return(nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditExchangeRateDelete, Target: "EUR", Outcome: entity.AuditSuccess,
+					})
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Delete exchange rate used by products with fail",
+			payload: currencyPayload{
+				url: "/v1/admin/exchange-rates/EUR",
+				currMock: func(currMock *repomock.MockCurrencies) {
+					currMock.EXPECT().DeleteExchangeRate("EUR").Return(entity.ErrExchangeRateInUse)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"exchange rate is used by products"}`,
+			},
+		},
+		{
+			name: "Delete unknown exchange rate with fail",
+			payload: currencyPayload{
+				url: "/v1/admin/exchange-rates/PLN",
+				currMock: func(currMock *repomock.MockCurrencies) {
+					currMock.EXPECT().DeleteExchangeRate("PLN").Return(entity.ErrExchangeRateNotFound)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"exchange rate not found"}`,
+			},
+		},
+		{
+			name: "Delete exchange rate of unsupported currency with fail",
+			payload: currencyPayload{
+				url:       "/v1/admin/exchange-rates/BTC",
+				currMock:  func(currMock *repomock.MockCurrencies) {},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"exchange rate not found"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rw := serveCurrency(t, http.MethodDelete, "/v1/admin/exchange-rates/{currency}",
+				func(s Service) http.HandlerFunc { return s.DeleteExchangeRate }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestSetProductCurrency(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	url := "/v1/admin/products/" + productUUID.String() + "/currency"
+	eur := "EUR"
+
+	tc := []struct {
+		name     string
+		expected expected
+		payload  currencyPayload
+	}{
+		{
+			name: "Set product currency with success",
+			payload: currencyPayload{
+				body: `{"currency":"eur"}`,
+				currMock: func(currMock *repomock.MockCurrencies) {
+					currMock.EXPECT().SetProductCurrency(productUUID, &eur).Return(nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditProductCurrency, Target: productUUID.String(), Outcome: entity.AuditSuccess,
+						Details: "currency set to EUR",
+					})
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Reset product currency to base with success",
+			payload: currencyPayload{
+				body: `{"currency":null}`,
+				currMock: func(currMock *repomock.MockCurrencies) {
+					currMock.EXPECT().SetProductCurrency(productUUID, nil).Return(nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditProductCurrency, Target: productUUID.String(), Outcome: entity.AuditSuccess,
+						Details: "currency set to USD",
+					})
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Set product currency without exchange rate with fail",
+			payload: currencyPayload{
+				body: `{"currency":"PLN"}`,
+				currMock: func(currMock *repomock.MockCurrencies) {
+					currMock.EXPECT().SetProductCurrency(productUUID, gomock.Any()).Return(entity.ErrExchangeRateNotFound)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"exchange rate not found"}`,
+			},
+		},
+		{
+			name: "Set currency of unknown product with fail",
+			payload: currencyPayload{
+				body: `{"currency":"EUR"}`,
+				currMock: func(currMock *repomock.MockCurrencies) {
+					currMock.EXPECT().SetProductCurrency(productUUID, gomock.Any()).Return(entity.ErrProductNotFound)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"product not found"}`,
+			},
+		},
+		{
+			name: "Set unsupported product currency with fail",
+			payload: currencyPayload{
+				body:      `{"currency":"BTC"}`,
+				currMock:  func(currMock *repomock.MockCurrencies) {},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"currency is not supported"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			test.payload.url = url
+			rw := serveCurrency(t, http.MethodPut, "/v1/admin/products/{productID}/currency",
+				func(s Service) http.HandlerFunc { return s.SetProductCurrency }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package admin
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/mshto/fruit-store/audit"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/media"
+	"github.com/mshto/fruit-store/web/common/response"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+// mediaField form field of uploaded image
+const mediaField = "file"
+
+// multipartOverhead room for boundaries, headers and other fields of multipart form
+const multipartOverhead = 64 * 1024
+
+// UploadMedia upload image of product from file field of multipart form, JPEG and PNG images are accepted.
+// Image and its thumbnail are stored in media storage.
+func (adh adminHandler) UploadMedia(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	productID := mux.Vars(r)["productID"]
+	productUUID, err := uuid.Parse(productID)
+	if err != nil {
+		adh.log.Errorf("failed to parse product id, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	data, err := readMediaFile(w, r, adh.cfg.Media.MaxSize())
+	switch {
+	case err == entity.ErrMediaTooLarge:
+		adh.auditor.Record(r, audit.Event(entity.AuditMediaUpload, productID, err))
+		response.RenderFailedResponse(w, http.StatusRequestEntityTooLarge, err)
+		return
+	case err != nil:
+		adh.log.Errorf("failed to read media file, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	img, err := media.Decode(data)
+	switch {
+	case err == media.ErrUnsupportedType:
+		adh.auditor.Record(r, audit.Event(entity.AuditMediaUpload, productID, err))
+		response.RenderFailedResponse(w, http.StatusUnsupportedMediaType, err)
+		return
+	case err != nil:
+		adh.auditor.Record(r, audit.Event(entity.AuditMediaUpload, productID, err))
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	thumb, err := img.Thumbnail(adh.cfg.Media.Thumbnail())
+	if err != nil {
+		adh.log.Errorf("failed to generate thumbnail, admin: %v, product: %v, error: %v", adminID, productID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	name := fmt.Sprintf("products/%v/%v", productUUID, uuid.New())
+	item := &entity.Media{
+		ProductID:    productUUID,
+		Key:          name + img.Extension(),
+		ThumbnailKey: name + "_thumb" + thumb.Extension(),
+		ContentType:  img.ContentType,
+		Size:         int64(len(data)),
+		Width:        img.Width,
+		Height:       img.Height,
+	}
+
+	err = adh.storeMedia(item, img, thumb)
+	if err != nil {
+		adh.log.Errorf("failed to store media, admin: %v, product: %v, error: %v", adminID, productID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = adh.mediaRepo.CreateMedia(item)
+	if err != nil {
+		adh.removeMedia(item)
+	}
+	switch {
+	case err == entity.ErrProductNotFound:
+		adh.auditor.Record(r, audit.Event(entity.AuditMediaUpload, productID, err))
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	case err != nil:
+		adh.log.Errorf("failed to create media, admin: %v, product: %v, error: %v", adminID, productID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	item.URL = adh.storage.URL(item.Key)
+	item.ThumbnailURL = adh.storage.URL(item.ThumbnailKey)
+
+	event := audit.Event(entity.AuditMediaUpload, productID, nil)
+	event.Details = "media " + item.ID.String() + " uploaded"
+	adh.auditor.Record(r, event)
+
+	response.RenderResponse(w, http.StatusCreated, item)
+}
+
+// DeleteMedia delete image of product together with its files
+func (adh adminHandler) DeleteMedia(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	productID := mux.Vars(r)["productID"]
+	productUUID, err := uuid.Parse(productID)
+	if err != nil {
+		adh.log.Errorf("failed to parse product id, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	mediaUUID, err := uuid.Parse(mux.Vars(r)["mediaID"])
+	if err != nil {
+		adh.log.Errorf("failed to parse media id, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	item, err := adh.mediaRepo.DeleteMedia(productUUID, mediaUUID)
+	switch {
+	case err == entity.ErrMediaNotFound:
+		adh.auditor.Record(r, audit.Event(entity.AuditMediaDelete, productID, err))
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	case err != nil:
+		adh.log.Errorf("failed to delete media, admin: %v, product: %v, error: %v", adminID, productID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	adh.removeMedia(item)
+
+	event := audit.Event(entity.AuditMediaDelete, productID, nil)
+	event.Details = "media " + mediaUUID.String() + " deleted"
+	adh.auditor.Record(r, event)
+
+	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+}
+
+// storeMedia put image and thumbnail to media storage, image is removed when thumbnail can't be stored
+func (adh adminHandler) storeMedia(item *entity.Media, img, thumb *media.Image) error {
+	err := adh.storage.Put(item.Key, img.ContentType, img.Data)
+	if err != nil {
+		return err
+	}
+	err = adh.storage.Put(item.ThumbnailKey, thumb.ContentType, thumb.Data)
+	if err != nil {
+		adh.removeMedia(&entity.Media{Key: item.Key})
+	}
+	return err
+}
+
+// removeMedia remove files of media, failures are only logged as media is gone from products anyway
+func (adh adminHandler) removeMedia(item *entity.Media) {
+	for _, key := range []string{item.Key, item.ThumbnailKey} {
+		if key == "" {
+			continue
+		}
+		if err := adh.storage.Delete(key); err != nil {
+			adh.log.Errorf("failed to remove media file, key: %v, error: %v", key, err)
+		}
+	}
+}
+
+// readMediaFile read file field of multipart form, file larger than maxSize isn't read further
+func readMediaFile(w http.ResponseWriter, r *http.Request, maxSize int64) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+multipartOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, entity.ErrMediaFileMissing
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() != mediaField {
+			continue
+		}
+
+		data, err := ioutil.ReadAll(io.LimitReader(part, maxSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) > maxSize {
+			return nil, entity.ErrMediaTooLarge
+		}
+		if len(data) == 0 {
+			return nil, entity.ErrMediaFileMissing
+		}
+		return data, nil
+	}
+}
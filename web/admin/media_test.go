@@ -0,0 +1,411 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	auditmock "github.com/mshto/fruit-store/audit/mock"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/media"
+	mediamock "github.com/mshto/fruit-store/media/mock"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+var mediaUUID = uuid.MustParse("5a0e2c1e-33a1-11eb-adc1-0242ac120002")
+
+type mediaPayload struct {
+	cfg         *config.Config
+	url         string
+	body        *bytes.Buffer
+	contentType string
+	mediaMock   func(mediaMock *repomock.MockMedia, storageMock *mediamock.MockStorage)
+	auditMock   func(auditMock *auditmock.MockAuditor)
+}
+
+// multipartBody multipart form with data in field
+func multipartBody(t *testing.T, field string, data []byte) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile(field, "apples.png")
+	if err != nil {
+		t.Fatalf("failed to create form file, error: %v", err)
+	}
+	_, _ = part.Write(data) // nolint
+	_ = writer.Close()      // nolint
+	return body, writer.FormDataContentType()
+}
+
+// pngImage encode blank png image of size
+func pngImage(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)))
+	if err != nil {
+		t.Fatalf("failed to encode image, error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// serveMedia serve request of admin to media route
+func serveMedia(t *testing.T, method, route string, handler func(Service) http.HandlerFunc, payload mediaPayload) *httptest.ResponseRecorder {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	logger, _ := loggermock.NewNullLogger()
+
+	mediaRepo := repomock.NewMockMedia(mockCtrl)
+	storage := mediamock.NewMockStorage(mockCtrl)
+	payload.mediaMock(mediaRepo, storage)
+
+	auditor := auditmock.NewMockAuditor(mockCtrl)
+	payload.auditMock(auditor)
+
+	if payload.body == nil {
+		payload.body = &bytes.Buffer{}
+	}
+	req, _ := http.NewRequest(method, payload.url, payload.body)
+	req.Header.Set("Content-Type", payload.contentType)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserUUID, adminUUID.String()))
+	rw := httptest.NewRecorder()
+
+	cfg := payload.cfg
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	adh := NewAdminHandler(cfg, logger, repomock.NewMockAudit(mockCtrl), repomock.NewMockAuth(mockCtrl), repomock.NewMockCatalog(mockCtrl),
+		mediaRepo, storage, repomock.NewMockPrices(mockCtrl), repomock.NewMockCurrencies(mockCtrl), repomock.NewMockSlots(mockCtrl), repomock.NewMockOrders(mockCtrl), auditor)
+
+	router := mux.NewRouter()
+	router.HandleFunc(route, handler(adh))
+	router.ServeHTTP(rw, req)
+	return rw
+}
+
+func TestUploadMedia(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	data := pngImage(t, 400, 200)
+	validBody := func() (*bytes.Buffer, string) { return multipartBody(t, "file", data) }
+	url := "/v1/admin/products/" + productUUID.String() + "/media"
+
+	tc := []struct {
+		name     string
+		expected expected
+		payload  func() mediaPayload
+	}{
+		{
+			name: "Upload media with success",
+			payload: func() mediaPayload {
+				body, contentType := validBody()
+				return mediaPayload{
+					url:         url,
+					body:        body,
+					contentType: contentType,
+					mediaMock: func(mediaMock *repomock.MockMedia, storageMock *mediamock.MockStorage) {
+						var key, thumbnailKey string
+						storageMock.EXPECT().Put(gomock.Any(), media.ContentTypePNG, data).DoAndReturn(func(k, _ string, _ []byte) error {
+							key = k
+							return nil
+						})
+						storageMock.EXPECT().Put(gomock.Any(), media.ContentTypePNG, gomock.Any()).DoAndReturn(func(k, _ string, data []byte) error {
+							thumbnailKey = k
+							thumb, err := media.Decode(data)
+							assert.Nil(t, err)
+							assert.Equal(t, []int{256, 128}, []int{thumb.Width, thumb.Height})
+							return nil
+						})
+						mediaMock.EXPECT().CreateMedia(gomock.Any()).DoAndReturn(func(item *entity.Media) error {
+							assert.Regexp(t, "^products/"+productUUID.String()+"/[0-9a-f-]{36}.png$", item.Key)
+							assert.Equal(t, key, item.Key)
+							assert.Equal(t, thumbnailKey, item.ThumbnailKey)
+							assert.Equal(t, item.Key[:len(item.Key)-4]+"_thumb.png", item.ThumbnailKey)
+							assert.Equal(t, entity.Media{
+								ProductID: productUUID, Key: key, ThumbnailKey: thumbnailKey, ContentType: media.ContentTypePNG,
+								Size: int64(len(data)), Width: 400, Height: 200,
+							}, *item)
+							item.ID = mediaUUID
+							item.CreatedAt = occurredAt
+							return nil
+						})
+						storageMock.EXPECT().URL(gomock.Any()).Return("/media/apples.png")
+						storageMock.EXPECT().URL(gomock.Any()).Return("/media/apples_thumb.png")
+					},
+					auditMock: func(auditMock *auditmock.MockAuditor) {
+						auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+							Action: entity.AuditMediaUpload, Target: productUUID.String(), Outcome: entity.AuditSuccess,
+							Details: "media " + mediaUUID.String() + " uploaded",
+						})
+					},
+				}
+			},
+			expected: expected{
+				code: http.StatusCreated,
+				body: `{"id":"5a0e2c1e-33a1-11eb-adc1-0242ac120002","url":"/media/apples.png","thumbnailUrl":"/media/apples_thumb.png",` +
+					`"contentType":"image/png","size":` + strconv.Itoa(len(data)) + `,"width":400,"height":200,"createdAt":"2020-11-27T10:00:00Z"}`,
+			},
+		},
+		{
+			name: "Upload too large media with fail",
+			payload: func() mediaPayload {
+				body, contentType := validBody()
+				cfg := &config.Config{Media: media.Media{MaxSizeKB: 1}}
+				return mediaPayload{
+					cfg:         cfg,
+					url:         url,
+					body:        body,
+					contentType: contentType,
+					mediaMock:   func(mediaMock *repomock.MockMedia, storageMock *mediamock.MockStorage) {},
+					auditMock: func(auditMock *auditmock.MockAuditor) {
+						auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+							Action: entity.AuditMediaUpload, Target: productUUID.String(), Outcome: entity.AuditFailure,
+							Details: entity.ErrMediaTooLarge.Error(),
+						})
+					},
+				}
+			},
+			expected: expected{
+				code: http.StatusRequestEntityTooLarge,
+				body: `{"error":"media file is too large"}`,
+			},
+		},
+		{
+			name: "Upload unsupported media with fail",
+			payload: func() mediaPayload {
+				body, contentType := multipartBody(t, "file", []byte("GIF89a\x01\x00\x01\x00"))
+				return mediaPayload{
+					url:         url,
+					body:        body,
+					contentType: contentType,
+					mediaMock:   func(mediaMock *repomock.MockMedia, storageMock *mediamock.MockStorage) {},
+					auditMock: func(auditMock *auditmock.MockAuditor) {
+						auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+					},
+				}
+			},
+			expected: expected{
+				code: http.StatusUnsupportedMediaType,
+				body: `{"error":"media must be a JPEG or PNG image"}`,
+			},
+		},
+		{
+			name: "Upload broken image with fail",
+			payload: func() mediaPayload {
+				body, contentType := multipartBody(t, "file", data[:60])
+				return mediaPayload{
+					url:         url,
+					body:        body,
+					contentType: contentType,
+					mediaMock:   func(mediaMock *repomock.MockMedia, storageMock *mediamock.MockStorage) {},
+					auditMock: func(auditMock *auditmock.MockAuditor) {
+						auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+					},
+				}
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"image can't be decoded or is too large"}`,
+			},
+		},
+		{
+			name: "Upload media without file field with fail",
+			payload: func() mediaPayload {
+				body, contentType := multipartBody(t, "image", data)
+				return mediaPayload{
+					url:         url,
+					body:        body,
+					contentType: contentType,
+					mediaMock:   func(mediaMock *repomock.MockMedia, storageMock *mediamock.MockStorage) {},
+					auditMock:   func(auditMock *auditmock.MockAuditor) {},
+				}
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"multipart form must contain image in file field"}`,
+			},
+		},
+		{
+			name: "Upload media not multipart with fail",
+			payload: func() mediaPayload {
+				return mediaPayload{
+					url:         url,
+					body:        bytes.NewBuffer(data),
+					contentType: media.ContentTypePNG,
+					mediaMock:   func(mediaMock *repomock.MockMedia, storageMock *mediamock.MockStorage) {},
+					auditMock:   func(auditMock *auditmock.MockAuditor) {},
+				}
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"request Content-Type isn't multipart/form-data"}`,
+			},
+		},
+		{
+			name: "Upload media of unknown product with fail",
+			payload: func() mediaPayload {
+				body, contentType := validBody()
+				return mediaPayload{
+					url:         url,
+					body:        body,
+					contentType: contentType,
+					mediaMock: func(mediaMock *repomock.MockMedia, storageMock *mediamock.MockStorage) {
+						storageMock.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+						mediaMock.EXPECT().CreateMedia(gomock.Any()).Return(entity.ErrProductNotFound)
+						storageMock.EXPECT().Delete(gomock.Any()).Return(nil).Times(2)
+					},
+					auditMock: func(auditMock *auditmock.MockAuditor) {
+						auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+					},
+				}
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"product not found"}`,
+			},
+		},
+		{
+			name: "Upload media storage error with fail",
+			payload: func() mediaPayload {
+				body, contentType := validBody()
+				return mediaPayload{
+					url:         url,
+					body:        body,
+					contentType: contentType,
+					mediaMock: func(mediaMock *repomock.MockMedia, storageMock *mediamock.MockStorage) {
+						storageMock.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+						storageMock.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("error"))
+						storageMock.EXPECT().Delete(gomock.Any()).Return(nil)
+					},
+					auditMock: func(auditMock *auditmock.MockAuditor) {},
+				}
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rw := serveMedia(t, http.MethodPost, "/v1/admin/products/{productID}/media",
+				func(s Service) http.HandlerFunc { return s.UploadMedia }, test.payload())
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestDeleteMedia(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	url := "/v1/admin/products/" + productUUID.String() + "/media/" + mediaUUID.String()
+
+	tc := []struct {
+		name     string
+		expected expected
+		payload  mediaPayload
+	}{
+		{
+			name: "Delete media with success",
+			payload: mediaPayload{
+				url: url,
+				mediaMock: func(mediaMock *repomock.MockMedia, storageMock *mediamock.MockStorage) {
+					mediaMock.EXPECT().DeleteMedia(productUUID, mediaUUID).
+						Return(&entity.Media{ID: mediaUUID, Key: "products/apples.png", ThumbnailKey: "products/apples_thumb.png"}, nil)
+					storageMock.EXPECT().Delete("products/apples.png").Return(nil)
+					storageMock.EXPECT().Delete("products/apples_thumb.png").Return(errors.New("error"))
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditMediaDelete, Target: productUUID.String(), Outcome: entity.AuditSuccess,
+						Details: "media " + mediaUUID.String() + " deleted",
+					})
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Delete unknown media with fail",
+			payload: mediaPayload{
+				url: url,
+				mediaMock: func(mediaMock *repomock.MockMedia, storageMock *mediamock.MockStorage) {
+					mediaMock.EXPECT().DeleteMedia(productUUID, mediaUUID).Return(nil, entity.ErrMediaNotFound)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"media not found"}`,
+			},
+		},
+		{
+			name: "Delete media invalid id with fail",
+			payload: mediaPayload{
+				url:       "/v1/admin/products/" + productUUID.String() + "/media/apples",
+				mediaMock: func(mediaMock *repomock.MockMedia, storageMock *mediamock.MockStorage) {},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid UUID length: 6"}`,
+			},
+		},
+		{
+			name: "Delete media DeleteMedia error with fail",
+			payload: mediaPayload{
+				url: url,
+				mediaMock: func(mediaMock *repomock.MockMedia, storageMock *mediamock.MockStorage) {
+					mediaMock.EXPECT().DeleteMedia(productUUID, mediaUUID).Return(nil, errors.New("error"))
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rw := serveMedia(t, http.MethodDelete, "/v1/admin/products/{productID}/media/{mediaID}",
+				func(s Service) http.HandlerFunc { return s.DeleteMedia }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
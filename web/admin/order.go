@@ -0,0 +1,120 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/mshto/fruit-store/audit"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/order"
+	"github.com/mshto/fruit-store/web/common/response"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+// GetOrders retrieves page of orders without their items, newest first, orders are filtered by status
+func (adh adminHandler) GetOrders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := entity.OrderFilter{Status: query.Get("status")}
+	if filter.Status != "" && !order.IsStatus(filter.Status) {
+		response.RenderFailedResponse(w, http.StatusBadRequest, entity.ErrInvalidOrderStatus)
+		return
+	}
+
+	var err error
+	filter.Limit, filter.Offset, err = parsePage(query)
+	if err != nil {
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	orders, err := adh.ordersRepo.GetOrders(filter)
+	if err != nil {
+		adh.log.Errorf("failed to get orders, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, orders)
+}
+
+// GetOrder retrieves order with its items and status history
+func (adh adminHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	orderUUID, err := uuid.Parse(mux.Vars(r)["orderID"])
+	if err != nil {
+		adh.log.Errorf("failed to parse order id, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	adh.renderOrder(w, adminID, orderUUID)
+}
+
+// SetOrderStatus advance order to status, transition must be allowed from its current status.
+// Cancellation voids payment of order and releases stock of its products and place in delivery slot.
+func (adh adminHandler) SetOrderStatus(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	orderID := mux.Vars(r)["orderID"]
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		adh.log.Errorf("failed to parse order id, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	update := entity.OrderStatusUpdate{}
+	err = json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		adh.log.Errorf("failed to decode order status, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	transition, err := order.Transition(update.Status, update.Note)
+	if err != nil {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	err = adh.ordersRepo.SetOrderStatus(orderUUID, nil, transition)
+	switch {
+	case err == entity.ErrOrderNotFound:
+		adh.auditor.Record(r, audit.Event(entity.AuditOrderStatus, orderID, err))
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	case err == entity.ErrInvalidTransition:
+		adh.auditor.Record(r, audit.Event(entity.AuditOrderStatus, orderID, err))
+		response.RenderFailedResponse(w, http.StatusConflict, err)
+		return
+	case err != nil:
+		adh.log.Errorf("failed to set order status, admin: %v, order: %v, error: %v", adminID, orderID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	event := audit.Event(entity.AuditOrderStatus, orderID, nil)
+	event.Details = "status set to " + update.Status
+	adh.auditor.Record(r, event)
+
+	adh.renderOrder(w, adminID, orderUUID)
+}
+
+func (adh adminHandler) renderOrder(w http.ResponseWriter, adminID string, orderUUID uuid.UUID) {
+	ord, err := adh.ordersRepo.GetOrder(orderUUID, nil)
+	if err == entity.ErrOrderNotFound {
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		adh.log.Errorf("failed to get order, admin: %v, order: %v, error: %v", adminID, orderUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, ord)
+}
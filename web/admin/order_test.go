@@ -0,0 +1,379 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	auditmock "github.com/mshto/fruit-store/audit/mock"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/order"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+var (
+	orderUUID      = uuid.MustParse("1b7c8e2a-3f0a-11eb-b378-0242ac130002")
+	orderCreatedAt = time.Date(2020, 12, 6, 10, 0, 0, 0, time.UTC)
+	orderOne       = entity.Order{
+		ID: orderUUID, UserID: &adminUUID, Status: order.StatusPaid, Currency: "USD",
+		Subtotal: 3, Tax: 0.5, Shipping: 2, Total: 5, Paid: 5, Method: "courier",
+		CreatedAt: orderCreatedAt, UpdatedAt: orderCreatedAt,
+	}
+	orderOneJSON = `{"id":"1b7c8e2a-3f0a-11eb-b378-0242ac130002","userId":"` + adminUUID.String() + `","status":"paid","currency":"USD",` +
+		`"subtotal":3,"tax":0.5,"shipping":2,"total":5,"paid":5,"method":"courier","createdAt":"2020-12-06T10:00:00Z","updatedAt":"2020-12-06T10:00:00Z"}`
+)
+
+type orderPayload struct {
+	url        string
+	body       string
+	ordersMock func(ordersMock *repomock.MockOrders)
+	auditMock  func(auditMock *auditmock.MockAuditor)
+}
+
+// serveOrder serve request of admin to order route
+func serveOrder(t *testing.T, method, route string, handler func(Service) http.HandlerFunc, payload orderPayload) *httptest.ResponseRecorder {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	logger, _ := loggermock.NewNullLogger()
+
+	ordersRepo := repomock.NewMockOrders(mockCtrl)
+	payload.ordersMock(ordersRepo)
+
+	auditor := auditmock.NewMockAuditor(mockCtrl)
+	payload.auditMock(auditor)
+
+	req, _ := http.NewRequest(method, payload.url, bytes.NewBufferString(payload.body))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserUUID, adminUUID.String()))
+	rw := httptest.NewRecorder()
+
+	adh := NewAdminHandler(&config.Config{}, logger, repomock.NewMockAudit(mockCtrl), repomock.NewMockAuth(mockCtrl), repomock.NewMockCatalog(mockCtrl),
+		repomock.NewMockMedia(mockCtrl), nil, repomock.NewMockPrices(mockCtrl), repomock.NewMockCurrencies(mockCtrl), repomock.NewMockSlots(mockCtrl), ordersRepo, auditor)
+
+	router := mux.NewRouter()
+	router.HandleFunc(route, handler(adh))
+	router.ServeHTTP(rw, req)
+	return rw
+}
+
+func TestGetOrders(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name     string
+		expected expected
+		payload  orderPayload
+	}{
+		{
+			name: "Get orders with success",
+			payload: orderPayload{
+				url: "/v1/admin/orders",
+				ordersMock: func(ordersMock *repomock.MockOrders) {
+					ordersMock.EXPECT().GetOrders(entity.OrderFilter{Limit: defaultLimit}).Return([]entity.Order{orderOne}, nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `[` + orderOneJSON + `]`,
+			},
+		},
+		{
+			name: "Get orders of status with success",
+			payload: orderPayload{
+				url: "/v1/admin/orders?status=paid&limit=10&offset=20",
+				ordersMock: func(ordersMock *repomock.MockOrders) {
+					ordersMock.EXPECT().GetOrders(entity.OrderFilter{Status: order.StatusPaid, Limit: 10, Offset: 20}).Return([]entity.Order{}, nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `[]`,
+			},
+		},
+		{
+			name: "Get orders of invalid status with fail",
+			payload: orderPayload{
+				url:        "/v1/admin/orders?status=lost",
+				ordersMock: func(ordersMock *repomock.MockOrders) {},
+				auditMock:  func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"order status is invalid"}`,
+			},
+		},
+		{
+			name: "Get orders with invalid limit with fail",
+			payload: orderPayload{
+				url:        "/v1/admin/orders?limit=0",
+				ordersMock: func(ordersMock *repomock.MockOrders) {},
+				auditMock:  func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"limit must be 1 to 200"}`,
+			},
+		},
+		{
+			name: "Get orders with fail",
+			payload: orderPayload{
+				url: "/v1/admin/orders",
+				ordersMock: func(ordersMock *repomock.MockOrders) {
+					ordersMock.EXPECT().GetOrders(gomock.Any()).Return(nil, errors.New("connection refused"))
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"connection refused"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rw := serveOrder(t, http.MethodGet, "/v1/admin/orders", func(s Service) http.HandlerFunc { return s.GetOrders }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestGetOrder(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name     string
+		expected expected
+		payload  orderPayload
+	}{
+		{
+			name: "Get order with success",
+			payload: orderPayload{
+				url: "/v1/admin/orders/" + orderUUID.String(),
+				ordersMock: func(ordersMock *repomock.MockOrders) {
+					ordersMock.EXPECT().GetOrder(orderUUID, nil).Return(&orderOne, nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: orderOneJSON,
+			},
+		},
+		{
+			name: "Get unknown order with fail",
+			payload: orderPayload{
+				url: "/v1/admin/orders/" + orderUUID.String(),
+				ordersMock: func(ordersMock *repomock.MockOrders) {
+					ordersMock.EXPECT().GetOrder(orderUUID, nil).Return(nil, entity.ErrOrderNotFound)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"order not found"}`,
+			},
+		},
+		{
+			name: "Get order by invalid id with fail",
+			payload: orderPayload{
+				url:        "/v1/admin/orders/invalid",
+				ordersMock: func(ordersMock *repomock.MockOrders) {},
+				auditMock:  func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid UUID length: 7"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rw := serveOrder(t, http.MethodGet, "/v1/admin/orders/{orderID}", func(s Service) http.HandlerFunc { return s.GetOrder }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestSetOrderStatus(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	packed, _ := order.Transition(order.StatusPacked, "")
+	cancelled, _ := order.Transition(order.StatusCancelled, "out of apples")
+
+	tc := []struct {
+		name     string
+		expected expected
+		payload  orderPayload
+	}{
+		{
+			name: "Set order status with success",
+			payload: orderPayload{
+				body: `{"status":"packed"}`,
+				ordersMock: func(ordersMock *repomock.MockOrders) {
+					ordersMock.EXPECT().SetOrderStatus(orderUUID, nil, packed).Return(nil)
+					ordersMock.EXPECT().GetOrder(orderUUID, nil).Return(&orderOne, nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditOrderStatus, Target: orderUUID.String(), Outcome: entity.AuditSuccess,
+						Details: "status set to packed",
+					})
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: orderOneJSON,
+			},
+		},
+		{
+			name: "Cancel order with success",
+			payload: orderPayload{
+				body: `{"status":"cancelled","note":"out of apples"}`,
+				ordersMock: func(ordersMock *repomock.MockOrders) {
+					ordersMock.EXPECT().SetOrderStatus(orderUUID, nil, cancelled).Return(nil)
+					ordersMock.EXPECT().GetOrder(orderUUID, nil).Return(&orderOne, nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: orderOneJSON,
+			},
+		},
+		{
+			name: "Set unknown order status with fail",
+			payload: orderPayload{
+				body:       `{"status":"lost"}`,
+				ordersMock: func(ordersMock *repomock.MockOrders) {},
+				auditMock:  func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"order status is invalid"}`,
+			},
+		},
+		{
+			name: "Set initial order status with fail",
+			payload: orderPayload{
+				body:       `{"status":"pending_payment"}`,
+				ordersMock: func(ordersMock *repomock.MockOrders) {},
+				auditMock:  func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"order can't move to this status from its current one"}`,
+			},
+		},
+		{
+			name: "Set order status not allowed from current one with fail",
+			payload: orderPayload{
+				body: `{"status":"packed"}`,
+				ordersMock: func(ordersMock *repomock.MockOrders) {
+					ordersMock.EXPECT().SetOrderStatus(orderUUID, nil, packed).Return(entity.ErrInvalidTransition)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditOrderStatus, Target: orderUUID.String(), Outcome: entity.AuditFailure,
+						Details: entity.ErrInvalidTransition.Error(),
+					})
+				},
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"order can't move to this status from its current one"}`,
+			},
+		},
+		{
+			name: "Set status of unknown order with fail",
+			payload: orderPayload{
+				body: `{"status":"packed"}`,
+				ordersMock: func(ordersMock *repomock.MockOrders) {
+					ordersMock.EXPECT().SetOrderStatus(orderUUID, nil, packed).Return(entity.ErrOrderNotFound)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"order not found"}`,
+			},
+		},
+		{
+			name: "Set order status with invalid body with fail",
+			payload: orderPayload{
+				body:       `{"status":`,
+				ordersMock: func(ordersMock *repomock.MockOrders) {},
+				auditMock:  func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"unexpected EOF"}`,
+			},
+		},
+		{
+			name: "Set order status with fail",
+			payload: orderPayload{
+				body: `{"status":"packed"}`,
+				ordersMock: func(ordersMock *repomock.MockOrders) {
+					ordersMock.EXPECT().SetOrderStatus(orderUUID, nil, packed).Return(errors.New("connection refused"))
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"connection refused"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			test.payload.url = "/v1/admin/orders/" + orderUUID.String() + "/status"
+			rw := serveOrder(t, http.MethodPut, "/v1/admin/orders/{orderID}/status", func(s Service) http.HandlerFunc { return s.SetOrderStatus }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/mshto/fruit-store/audit"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/web/common/response"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+// SchedulePrice schedule price of product from effectiveFrom, price without effectiveFrom is effective immediately
+func (adh adminHandler) SchedulePrice(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	productID := mux.Vars(r)["productID"]
+	productUUID, err := uuid.Parse(productID)
+	if err != nil {
+		adh.log.Errorf("failed to parse product id, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	schedule := entity.PriceSchedule{}
+	err = json.NewDecoder(r.Body).Decode(&schedule)
+	if err != nil {
+		adh.log.Errorf("failed to decode price, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if schedule.Price == nil || *schedule.Price < 0 {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrInvalidPrice)
+		return
+	}
+
+	// zero effective time is set to now by the database, which also rejects time in the past
+	price := &entity.ProductPrice{ProductID: productUUID, Price: *schedule.Price}
+	if schedule.EffectiveFrom != nil {
+		price.EffectiveFrom = *schedule.EffectiveFrom
+	}
+
+	err = adh.pricesRepo.SchedulePrice(price)
+	switch {
+	case err == entity.ErrPriceInPast:
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	case err == entity.ErrProductNotFound:
+		adh.auditor.Record(r, audit.Event(entity.AuditPriceSchedule, productID, err))
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	case err == entity.ErrPriceAlreadyScheduled:
+		adh.auditor.Record(r, audit.Event(entity.AuditPriceSchedule, productID, err))
+		response.RenderFailedResponse(w, http.StatusConflict, err)
+		return
+	case err != nil:
+		adh.log.Errorf("failed to schedule price, admin: %v, product: %v, error: %v", adminID, productID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	event := audit.Event(entity.AuditPriceSchedule, productID, nil)
+	event.Details = fmt.Sprintf("price %v effective from %v", price.Price, price.EffectiveFrom.UTC().Format(time.RFC3339))
+	adh.auditor.Record(r, event)
+
+	response.RenderResponse(w, http.StatusCreated, price)
+}
+
+// CancelPrice cancel scheduled price of product which didn't take effect yet
+func (adh adminHandler) CancelPrice(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	productID := mux.Vars(r)["productID"]
+	productUUID, err := uuid.Parse(productID)
+	if err != nil {
+		adh.log.Errorf("failed to parse product id, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	priceUUID, err := uuid.Parse(mux.Vars(r)["priceID"])
+	if err != nil {
+		adh.log.Errorf("failed to parse price id, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = adh.pricesRepo.CancelPrice(productUUID, priceUUID)
+	switch {
+	case err == entity.ErrPriceNotFound:
+		adh.auditor.Record(r, audit.Event(entity.AuditPriceCancel, productID, err))
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	case err != nil:
+		adh.log.Errorf("failed to cancel price, admin: %v, product: %v, error: %v", adminID, productID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	event := audit.Event(entity.AuditPriceCancel, productID, nil)
+	event.Details = "price " + priceUUID.String() + " cancelled"
+	adh.auditor.Record(r, event)
+
+	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+}
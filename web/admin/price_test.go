@@ -0,0 +1,295 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	auditmock "github.com/mshto/fruit-store/audit/mock"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+var priceUUID = uuid.MustParse("7c2f9e4a-3468-11eb-adc1-0242ac120002")
+
+type pricePayload struct {
+	url        string
+	body       string
+	pricesMock func(pricesMock *repomock.MockPrices)
+	auditMock  func(auditMock *auditmock.MockAuditor)
+}
+
+// servePrice serve request of admin to price route
+func servePrice(t *testing.T, method, route string, handler func(Service) http.HandlerFunc, payload pricePayload) *httptest.ResponseRecorder {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	logger, _ := loggermock.NewNullLogger()
+
+	pricesRepo := repomock.NewMockPrices(mockCtrl)
+	payload.pricesMock(pricesRepo)
+
+	auditor := auditmock.NewMockAuditor(mockCtrl)
+	payload.auditMock(auditor)
+
+	req, _ := http.NewRequest(method, payload.url, bytes.NewBufferString(payload.body))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserUUID, adminUUID.String()))
+	rw := httptest.NewRecorder()
+
+	adh := NewAdminHandler(&config.Config{}, logger, repomock.NewMockAudit(mockCtrl), repomock.NewMockAuth(mockCtrl), repomock.NewMockCatalog(mockCtrl),
+		repomock.NewMockMedia(mockCtrl), nil, pricesRepo, repomock.NewMockCurrencies(mockCtrl), repomock.NewMockSlots(mockCtrl), repomock.NewMockOrders(mockCtrl), auditor)
+
+	router := mux.NewRouter()
+	router.HandleFunc(route, handler(adh))
+	router.ServeHTTP(rw, req)
+	return rw
+}
+
+func TestSchedulePrice(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	url := "/v1/admin/products/" + productUUID.String() + "/prices"
+	effectiveFrom := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tc := []struct {
+		name     string
+		expected expected
+		payload  pricePayload
+	}{
+		{
+			name: "Schedule price with success",
+			payload: pricePayload{
+				body: `{"price":1.5,"effectiveFrom":"2030-01-01T00:00:00Z"}`,
+				pricesMock: func(pricesMock *repomock.MockPrices) {
+					pricesMock.EXPECT().SchedulePrice(&entity.ProductPrice{ProductID: productUUID, Price: 1.5, EffectiveFrom: effectiveFrom}).
+						DoAndReturn(func(price *entity.ProductPrice) error {
+							price.ID = priceUUID
+							price.CreatedAt = occurredAt
+							return nil
+						})
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditPriceSchedule, Target: productUUID.String(), Outcome: entity.AuditSuccess,
+						Details: "price 1.5 effective from 2030-01-01T00:00:00Z",
+					})
+				},
+			},
+			expected: expected{
+				code: http.StatusCreated,
+				body: `{"id":"7c2f9e4a-3468-11eb-adc1-0242ac120002","price":1.5,"effectiveFrom":"2030-01-01T00:00:00Z","createdAt":"2020-11-27T10:00:00Z"}`,
+			},
+		},
+		{
+			name: "Set current price with success",
+			payload: pricePayload{
+				body: `{"price":2}`,
+				pricesMock: func(pricesMock *repomock.MockPrices) {
+					pricesMock.EXPECT().SchedulePrice(gomock.Any()).DoAndReturn(func(price *entity.ProductPrice) error {
+						assert.Equal(t, float32(2), price.Price)
+						assert.True(t, price.EffectiveFrom.IsZero())
+						price.EffectiveFrom = occurredAt
+						return nil
+					})
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: expected{
+				code: http.StatusCreated,
+			},
+		},
+		{
+			name: "Schedule price in the past with fail",
+			payload: pricePayload{
+				body: `{"price":1.5,"effectiveFrom":"2020-01-01T00:00:00Z"}`,
+				pricesMock: func(pricesMock *repomock.MockPrices) {
+					pricesMock.EXPECT().SchedulePrice(gomock.Any()).Return(entity.ErrPriceInPast)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"effective from must not be in the past"}`,
+			},
+		},
+		{
+			name: "Schedule negative price with fail",
+			payload: pricePayload{
+				body:       `{"price":-1}`,
+				pricesMock: func(pricesMock *repomock.MockPrices) {},
+				auditMock:  func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"price must be set and must not be negative"}`,
+			},
+		},
+		{
+			name: "Schedule price without price with fail",
+			payload: pricePayload{
+				body:       `{"effectiveFrom":"2030-01-01T00:00:00Z"}`,
+				pricesMock: func(pricesMock *repomock.MockPrices) {},
+				auditMock:  func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"price must be set and must not be negative"}`,
+			},
+		},
+		{
+			name: "Schedule price twice with fail",
+			payload: pricePayload{
+				body: `{"price":1.5,"effectiveFrom":"2030-01-01T00:00:00Z"}`,
+				pricesMock: func(pricesMock *repomock.MockPrices) {
+					pricesMock.EXPECT().SchedulePrice(gomock.Any()).Return(entity.ErrPriceAlreadyScheduled)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"price of product is already scheduled at this time"}`,
+			},
+		},
+		{
+			name: "Schedule price of unknown product with fail",
+			payload: pricePayload{
+				body: `{"price":1.5}`,
+				pricesMock: func(pricesMock *repomock.MockPrices) {
+					pricesMock.EXPECT().SchedulePrice(gomock.Any()).Return(entity.ErrProductNotFound)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"product not found"}`,
+			},
+		},
+		{
+			name: "Schedule price SchedulePrice error with fail",
+			payload: pricePayload{
+				body: `{"price":1.5}`,
+				pricesMock: func(pricesMock *repomock.MockPrices) {
+					pricesMock.EXPECT().SchedulePrice(gomock.Any()).Return(errors.New("error"))
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			test.payload.url = url
+			rw := servePrice(t, http.MethodPost, "/v1/admin/products/{productID}/prices",
+				func(s Service) http.HandlerFunc { return s.SchedulePrice }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			if test.expected.body != "" {
+				assert.Equal(t, test.expected.body, rw.Body.String())
+			}
+		})
+	}
+}
+
+func TestCancelPrice(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	url := "/v1/admin/products/" + productUUID.String() + "/prices/" + priceUUID.String()
+
+	tc := []struct {
+		name     string
+		expected expected
+		payload  pricePayload
+	}{
+		{
+			name: "Cancel price with success",
+			payload: pricePayload{
+				url: url,
+				pricesMock: func(pricesMock *repomock.MockPrices) {
+					pricesMock.EXPECT().CancelPrice(productUUID, priceUUID).Return(nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditPriceCancel, Target: productUUID.String(), Outcome: entity.AuditSuccess,
+						Details: "price " + priceUUID.String() + " cancelled",
+					})
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Cancel effective price with fail",
+			payload: pricePayload{
+				url: url,
+				pricesMock: func(pricesMock *repomock.MockPrices) {
+					pricesMock.EXPECT().CancelPrice(productUUID, priceUUID).Return(entity.ErrPriceNotFound)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"scheduled price not found"}`,
+			},
+		},
+		{
+			name: "Cancel price invalid id with fail",
+			payload: pricePayload{
+				url:        "/v1/admin/products/" + productUUID.String() + "/prices/latest",
+				pricesMock: func(pricesMock *repomock.MockPrices) {},
+				auditMock:  func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid UUID length: 6"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rw := servePrice(t, http.MethodDelete, "/v1/admin/products/{productID}/prices/{priceID}",
+				func(s Service) http.HandlerFunc { return s.CancelPrice }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
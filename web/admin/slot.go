@@ -0,0 +1,115 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/mshto/fruit-store/audit"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/web/common/request"
+	"github.com/mshto/fruit-store/web/common/response"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+// slotDays days of delivery slots listed without date
+const slotDays = 7
+
+// GetSlots retrieves delivery slots of date query parameter or of the week from today with their bookings
+func (adh adminHandler) GetSlots(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	date, err := request.Date(r)
+	if err != nil {
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	from, to := date, date.AddDate(0, 0, 1)
+	if date.IsZero() {
+		from = time.Now().UTC().Truncate(24 * time.Hour)
+		to = from.AddDate(0, 0, slotDays)
+	}
+
+	slots, err := adh.slotsRepo.GetSlots(from, to)
+	if err != nil {
+		adh.log.Errorf("failed to get delivery slots, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, slots)
+}
+
+// CreateSlot create delivery slot from startsAt to endsAt with capacity of deliveries
+func (adh adminHandler) CreateSlot(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	input := entity.DeliverySlot{}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		adh.log.Errorf("failed to decode delivery slot, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	slot := &entity.DeliverySlot{StartsAt: input.StartsAt, EndsAt: input.EndsAt, Capacity: input.Capacity}
+	if !slot.EndsAt.After(slot.StartsAt) || slot.Capacity <= 0 {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrInvalidSlot)
+		return
+	}
+	if !slot.StartsAt.After(time.Now()) {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrSlotStarted)
+		return
+	}
+
+	err = adh.slotsRepo.CreateSlot(slot)
+	if err != nil {
+		adh.log.Errorf("failed to create delivery slot, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	event := audit.Event(entity.AuditSlotCreate, slot.ID.String(), nil)
+	event.Details = fmt.Sprintf("from %v to %v, capacity %v", slot.StartsAt.UTC().Format(time.RFC3339), slot.EndsAt.UTC().Format(time.RFC3339), slot.Capacity)
+	adh.auditor.Record(r, event)
+
+	response.RenderResponse(w, http.StatusCreated, slot)
+}
+
+// DeleteSlot delete delivery slot without bookings, carts lose their reservations of it
+func (adh adminHandler) DeleteSlot(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := r.Context().Value(middleware.UserUUID).(string)
+
+	slotID := mux.Vars(r)["slotID"]
+	slotUUID, err := uuid.Parse(slotID)
+	if err != nil {
+		adh.log.Errorf("failed to parse slot id, admin: %v, error: %v", adminID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = adh.slotsRepo.DeleteSlot(slotUUID)
+	switch {
+	case err == entity.ErrSlotNotFound:
+		adh.auditor.Record(r, audit.Event(entity.AuditSlotDelete, slotID, err))
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	case err == entity.ErrSlotHasBookings:
+		adh.auditor.Record(r, audit.Event(entity.AuditSlotDelete, slotID, err))
+		response.RenderFailedResponse(w, http.StatusConflict, err)
+		return
+	case err != nil:
+		adh.log.Errorf("failed to delete delivery slot, admin: %v, slot: %v, error: %v", adminID, slotID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	adh.auditor.Record(r, audit.Event(entity.AuditSlotDelete, slotID, nil))
+
+	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+}
@@ -0,0 +1,322 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	auditmock "github.com/mshto/fruit-store/audit/mock"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+var (
+	slotUUID     = uuid.MustParse("7c2e5b5c-3610-11eb-adc1-0242ac120002")
+	slotStartsAt = time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+)
+
+type slotPayload struct {
+	url       string
+	body      string
+	slotsMock func(slotsMock *repomock.MockSlots)
+	auditMock func(auditMock *auditmock.MockAuditor)
+}
+
+// serveSlot serve request of admin to delivery slot route
+func serveSlot(t *testing.T, method, route string, handler func(Service) http.HandlerFunc, payload slotPayload) *httptest.ResponseRecorder {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	logger, _ := loggermock.NewNullLogger()
+
+	slotsRepo := repomock.NewMockSlots(mockCtrl)
+	payload.slotsMock(slotsRepo)
+
+	auditor := auditmock.NewMockAuditor(mockCtrl)
+	payload.auditMock(auditor)
+
+	req, _ := http.NewRequest(method, payload.url, bytes.NewBufferString(payload.body))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserUUID, adminUUID.String()))
+	rw := httptest.NewRecorder()
+
+	adh := NewAdminHandler(&config.Config{}, logger, repomock.NewMockAudit(mockCtrl), repomock.NewMockAuth(mockCtrl), repomock.NewMockCatalog(mockCtrl),
+		repomock.NewMockMedia(mockCtrl), nil, repomock.NewMockPrices(mockCtrl), repomock.NewMockCurrencies(mockCtrl), slotsRepo, repomock.NewMockOrders(mockCtrl), auditor)
+
+	router := mux.NewRouter()
+	router.HandleFunc(route, handler(adh))
+	router.ServeHTTP(rw, req)
+	return rw
+}
+
+func TestGetSlots(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name     string
+		expected expected
+		payload  slotPayload
+	}{
+		{
+			name: "Get slots of date with success",
+			payload: slotPayload{
+				url: "/v1/admin/delivery-slots?date=2030-01-01",
+				slotsMock: func(slotsMock *repomock.MockSlots) {
+					slotsMock.EXPECT().GetSlots(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)).
+						Return([]entity.DeliverySlot{{ID: slotUUID, StartsAt: slotStartsAt, EndsAt: slotStartsAt.Add(2 * time.Hour), Capacity: 5, Booked: 2}}, nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `[{"id":"7c2e5b5c-3610-11eb-adc1-0242ac120002","startsAt":"2030-01-01T09:00:00Z","endsAt":"2030-01-01T11:00:00Z","capacity":5,"booked":2}]`,
+			},
+		},
+		{
+			name: "Get slots of week with success",
+			payload: slotPayload{
+				url: "/v1/admin/delivery-slots",
+				slotsMock: func(slotsMock *repomock.MockSlots) {
+					slotsMock.EXPECT().GetSlots(gomock.Any(), gomock.Any()).DoAndReturn(func(from, to time.Time) ([]entity.DeliverySlot, error) {
+						assert.Equal(t, time.Now().UTC().Truncate(24*time.Hour), from)
+						assert.Equal(t, from.AddDate(0, 0, 7), to)
+						return []entity.DeliverySlot{}, nil
+					})
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `[]`,
+			},
+		},
+		{
+			name: "Get slots of invalid date with fail",
+			payload: slotPayload{
+				url:       "/v1/admin/delivery-slots?date=2030-13-01",
+				slotsMock: func(slotsMock *repomock.MockSlots) {},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"date must be in format 2006-01-02"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rw := serveSlot(t, http.MethodGet, "/v1/admin/delivery-slots", func(s Service) http.HandlerFunc { return s.GetSlots }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestCreateSlot(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name     string
+		expected expected
+		payload  slotPayload
+	}{
+		{
+			name: "Create slot with success",
+			payload: slotPayload{
+				body: `{"id":"8d3f6c6d-3610-11eb-adc1-0242ac120002","startsAt":"2030-01-01T09:00:00Z","endsAt":"2030-01-01T11:00:00Z","capacity":5,"booked":5}`,
+				slotsMock: func(slotsMock *repomock.MockSlots) {
+					slotsMock.EXPECT().CreateSlot(&entity.DeliverySlot{StartsAt: slotStartsAt, EndsAt: slotStartsAt.Add(2 * time.Hour), Capacity: 5}).
+						DoAndReturn(func(slot *entity.DeliverySlot) error {
+							slot.ID = slotUUID
+							return nil
+						})
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditSlotCreate, Target: slotUUID.String(), Outcome: entity.AuditSuccess,
+						Details: "from 2030-01-01T09:00:00Z to 2030-01-01T11:00:00Z, capacity 5",
+					})
+				},
+			},
+			expected: expected{
+				code: http.StatusCreated,
+				body: `{"id":"7c2e5b5c-3610-11eb-adc1-0242ac120002","startsAt":"2030-01-01T09:00:00Z","endsAt":"2030-01-01T11:00:00Z","capacity":5,"booked":0}`,
+			},
+		},
+		{
+			name: "Create slot ending before start with fail",
+			payload: slotPayload{
+				body:      `{"startsAt":"2030-01-01T09:00:00Z","endsAt":"2030-01-01T08:00:00Z","capacity":5}`,
+				slotsMock: func(slotsMock *repomock.MockSlots) {},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"delivery slot must end after it starts and have positive capacity"}`,
+			},
+		},
+		{
+			name: "Create slot without capacity with fail",
+			payload: slotPayload{
+				body:      `{"startsAt":"2030-01-01T09:00:00Z","endsAt":"2030-01-01T11:00:00Z"}`,
+				slotsMock: func(slotsMock *repomock.MockSlots) {},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"delivery slot must end after it starts and have positive capacity"}`,
+			},
+		},
+		{
+			name: "Create slot in the past with fail",
+			payload: slotPayload{
+				body:      `{"startsAt":"2020-01-01T09:00:00Z","endsAt":"2020-01-01T11:00:00Z","capacity":5}`,
+				slotsMock: func(slotsMock *repomock.MockSlots) {},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"delivery slot already started"}`,
+			},
+		},
+		{
+			name: "Create slot db error with fail",
+			payload: slotPayload{
+				body: `{"startsAt":"2030-01-01T09:00:00Z","endsAt":"2030-01-01T11:00:00Z","capacity":5}`,
+				slotsMock: func(slotsMock *repomock.MockSlots) {
+					slotsMock.EXPECT().CreateSlot(gomock.Any()).Return(errors.New("error"))
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			test.payload.url = "/v1/admin/delivery-slots"
+			rw := serveSlot(t, http.MethodPost, "/v1/admin/delivery-slots", func(s Service) http.HandlerFunc { return s.CreateSlot }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestDeleteSlot(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	url := "/v1/admin/delivery-slots/" + slotUUID.String()
+
+	tc := []struct {
+		name     string
+		expected expected
+		payload  slotPayload
+	}{
+		{
+			name: "Delete slot with success",
+			payload: slotPayload{
+				url: url,
+				slotsMock: func(slotsMock *repomock.MockSlots) {
+					slotsMock.EXPECT().DeleteSlot(slotUUID).Return(nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{
+						Action: entity.AuditSlotDelete, Target: slotUUID.String(), Outcome: entity.AuditSuccess,
+					})
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Delete slot with bookings with fail",
+			payload: slotPayload{
+				url: url,
+				slotsMock: func(slotsMock *repomock.MockSlots) {
+					slotsMock.EXPECT().DeleteSlot(slotUUID).Return(entity.ErrSlotHasBookings)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"delivery slot has bookings"}`,
+			},
+		},
+		{
+			name: "Delete unknown slot with fail",
+			payload: slotPayload{
+				url: url,
+				slotsMock: func(slotsMock *repomock.MockSlots) {
+					slotsMock.EXPECT().DeleteSlot(slotUUID).Return(entity.ErrSlotNotFound)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), gomock.Any())
+				},
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"delivery slot not found"}`,
+			},
+		},
+		{
+			name: "Delete slot invalid id with fail",
+			payload: slotPayload{
+				url:       "/v1/admin/delivery-slots/morning",
+				slotsMock: func(slotsMock *repomock.MockSlots) {},
+				auditMock: func(auditMock *auditmock.MockAuditor) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid UUID length: 7"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rw := serveSlot(t, http.MethodDelete, "/v1/admin/delivery-slots/{slotID}", func(s Service) http.HandlerFunc { return s.DeleteSlot }, test.payload)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package apikeys
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mshto/fruit-store/apikey"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/repository"
+	"github.com/mshto/fruit-store/web/common/response"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+// maxNameLength max length of key and service account name, equal to username length
+const maxNameLength = 34
+
+var errInvalidName = errors.New("name must be 1 to 34 characters long")
+
+// Service api keys interface
+type Service interface {
+	CreateAPIKey(w http.ResponseWriter, r *http.Request)
+	GetAPIKeys(w http.ResponseWriter, r *http.Request)
+	RevokeAPIKey(w http.ResponseWriter, r *http.Request)
+
+	CreateServiceAccount(w http.ResponseWriter, r *http.Request)
+	GetServiceAccounts(w http.ResponseWriter, r *http.Request)
+}
+
+type apiKeysHandler struct {
+	cfg      *config.Config
+	log      *logrus.Logger
+	keys     apikey.Service
+	keysRepo repository.APIKeys
+}
+
+// NewAPIKeysHandler init a new api keys handler
+func NewAPIKeysHandler(cfg *config.Config, log *logrus.Logger, keys apikey.Service, keysRepo repository.APIKeys) Service {
+	return apiKeysHandler{
+		cfg:      cfg,
+		log:      log,
+		keys:     keys,
+		keysRepo: keysRepo,
+	}
+}
+
+// CreateAPIKey create api key for user or for service account of user, the key is shown only once
+func (akh apiKeysHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := akh.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	req := entity.CreateAPIKey{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		akh.log.Errorf("failed to decode api key, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if !validName(req.Name) {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, errInvalidName)
+		return
+	}
+
+	key, err := akh.keys.Create(userUUID, req)
+	if err == entity.ErrInvalidScope || err == entity.ErrEmptyScopes {
+		akh.log.Errorf("failed to create api key, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err == entity.ErrServiceAccountNotFound {
+		akh.log.Errorf("failed to create api key, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		akh.log.Errorf("failed to create api key, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusCreated, key)
+}
+
+// GetAPIKeys retrieves api keys of user or of service account of user
+func (akh apiKeysHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := akh.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	var serviceAccountID *uuid.UUID
+	if id := r.URL.Query().Get("serviceAccountId"); id != "" {
+		accountUUID, err := uuid.Parse(id)
+		if err != nil {
+			akh.log.Errorf("failed to parse service account id, user: %v, error: %v", userUUID, err)
+			response.RenderFailedResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		serviceAccountID = &accountUUID
+	}
+
+	keys, err := akh.keys.List(userUUID, serviceAccountID)
+	if err == entity.ErrServiceAccountNotFound {
+		akh.log.Errorf("failed to get api keys, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		akh.log.Errorf("failed to get api keys, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, keys)
+}
+
+// RevokeAPIKey revoke api key of user or of service account of user
+func (akh apiKeysHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := akh.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	keyUUID, err := uuid.Parse(mux.Vars(r)["keyID"])
+	if err != nil {
+		akh.log.Errorf("failed to parse api key id, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = akh.keys.Revoke(userUUID, keyUUID)
+	if err == entity.ErrAPIKeyNotFound {
+		akh.log.Errorf("failed to revoke api key, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		akh.log.Errorf("failed to revoke api key, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+}
+
+// CreateServiceAccount create service account owned by user
+func (akh apiKeysHandler) CreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := akh.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	req := entity.ServiceAccount{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		akh.log.Errorf("failed to decode service account, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if !validName(req.Name) {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, errInvalidName)
+		return
+	}
+
+	account, err := akh.keysRepo.CreateServiceAccount(userUUID, req.Name)
+	if err == entity.ErrUserAlreadyExist {
+		akh.log.Errorf("failed to create service account, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		akh.log.Errorf("failed to create service account, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusCreated, account)
+}
+
+// GetServiceAccounts retrieves service accounts owned by user
+func (akh apiKeysHandler) GetServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := akh.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	accounts, err := akh.keysRepo.GetServiceAccounts(userUUID)
+	if err != nil {
+		akh.log.Errorf("failed to get service accounts, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, accounts)
+}
+
+func (akh apiKeysHandler) getUserUUID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, ok := r.Context().Value(middleware.UserUUID).(string)
+	if !ok {
+		akh.log.Errorf("failed to get UserUUID")
+		response.RenderFailedResponse(w, http.StatusBadRequest, errors.New("userUUID not found"))
+		return uuid.Nil, false
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		akh.log.Errorf("failed to parse user uuid, user: %v, error: %v", userID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return uuid.Nil, false
+	}
+	return userUUID, true
+}
+
+func validName(name string) bool {
+	return name != "" && len(name) <= maxNameLength
+}
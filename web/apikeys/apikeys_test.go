@@ -0,0 +1,415 @@
+package apikeys
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	apikeymock "github.com/mshto/fruit-store/apikey/mock"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+var (
+	userUUID    = uuid.MustParse("0b6bd0c4-2c3e-11eb-adc1-0242ac120002")
+	keyUUID     = uuid.MustParse("1c7ce1d5-2c3e-11eb-adc1-0242ac120002")
+	accountUUID = uuid.MustParse("2d8df2e6-2c3e-11eb-adc1-0242ac120002")
+	createdAt   = time.Date(2020, 11, 24, 10, 0, 0, 0, time.UTC)
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	type payload struct {
+		body     string
+		keysMock func(keysMock *apikeymock.MockService)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Create api key with success",
+			payload: payload{
+				body: `{"name":"ci","scopes":["cart:read"]}`,
+				keysMock: func(keysMock *apikeymock.MockService) {
+					keysMock.EXPECT().Create(userUUID, entity.CreateAPIKey{Name: "ci", Scopes: []string{"cart:read"}}).
+						Return(&entity.CreatedAPIKey{
+							APIKey: entity.APIKey{ID: keyUUID, UserID: userUUID, Name: "ci", Prefix: "fsk_abcdefgh", Scopes: []string{"cart:read"}, CreatedAt: createdAt},
+							Key:    "fsk_abcdefghijk",
+						}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusCreated,
+				body: `{"id":"1c7ce1d5-2c3e-11eb-adc1-0242ac120002","userId":"0b6bd0c4-2c3e-11eb-adc1-0242ac120002","name":"ci","prefix":"fsk_abcdefgh","scopes":["cart:read"],"createdAt":"2020-11-24T10:00:00Z","key":"fsk_abcdefghijk"}`,
+			},
+		},
+		{
+			name: "Create api key empty name with fail",
+			payload: payload{
+				body:     `{"scopes":["cart:read"]}`,
+				keysMock: func(keysMock *apikeymock.MockService) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"name must be 1 to 34 characters long"}`,
+			},
+		},
+		{
+			name: "Create api key unknown scope with fail",
+			payload: payload{
+				body: `{"name":"ci","scopes":["admin"]}`,
+				keysMock: func(keysMock *apikeymock.MockService) {
+					keysMock.EXPECT().Create(userUUID, gomock.Any()).Return(nil, entity.ErrInvalidScope)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"unknown api key scope"}`,
+			},
+		},
+		{
+			name: "Create api key foreign service account with fail",
+			payload: payload{
+				body: `{"name":"ci","scopes":["cart:read"],"serviceAccountId":"2d8df2e6-2c3e-11eb-adc1-0242ac120002"}`,
+				keysMock: func(keysMock *apikeymock.MockService) {
+					keysMock.EXPECT().Create(userUUID, entity.CreateAPIKey{Name: "ci", Scopes: []string{"cart:read"}, ServiceAccountID: &accountUUID}).
+						Return(nil, entity.ErrServiceAccountNotFound)
+				},
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"service account not found"}`,
+			},
+		},
+		{
+			name: "Create api key invalid body with fail",
+			payload: payload{
+				body:     `{`,
+				keysMock: func(keysMock *apikeymock.MockService) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"unexpected EOF"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			keys := apikeymock.NewMockService(mockCtrl)
+			test.payload.keysMock(keys)
+
+			req, _ := http.NewRequest(http.MethodPost, "url", bytes.NewBufferString(test.payload.body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserUUID, userUUID.String()))
+			rw := httptest.NewRecorder()
+
+			akh := NewAPIKeysHandler(&config.Config{}, logger, keys, repomock.NewMockAPIKeys(mockCtrl))
+			akh.CreateAPIKey(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestGetAPIKeys(t *testing.T) {
+	type payload struct {
+		query    string
+		keysMock func(keysMock *apikeymock.MockService)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Get api keys with success",
+			payload: payload{
+				keysMock: func(keysMock *apikeymock.MockService) {
+					keysMock.EXPECT().List(userUUID, nil).Return([]entity.APIKey{}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `[]`,
+			},
+		},
+		{
+			name: "Get api keys of service account with success",
+			payload: payload{
+				query: "?serviceAccountId=" + accountUUID.String(),
+				keysMock: func(keysMock *apikeymock.MockService) {
+					keysMock.EXPECT().List(userUUID, &accountUUID).Return([]entity.APIKey{
+						{ID: keyUUID, UserID: accountUUID, Name: "ci", Prefix: "fsk_abcdefgh", Scopes: []string{"cart:read"}, CreatedAt: createdAt},
+					}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `[{"id":"1c7ce1d5-2c3e-11eb-adc1-0242ac120002","userId":"2d8df2e6-2c3e-11eb-adc1-0242ac120002","name":"ci","prefix":"fsk_abcdefgh","scopes":["cart:read"],"createdAt":"2020-11-24T10:00:00Z"}]`,
+			},
+		},
+		{
+			name: "Get api keys invalid service account with fail",
+			payload: payload{
+				query:    "?serviceAccountId=invalid",
+				keysMock: func(keysMock *apikeymock.MockService) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid UUID length: 7"}`,
+			},
+		},
+		{
+			name: "Get api keys List error with fail",
+			payload: payload{
+				keysMock: func(keysMock *apikeymock.MockService) {
+					keysMock.EXPECT().List(userUUID, nil).Return(nil, errors.New("error"))
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			keys := apikeymock.NewMockService(mockCtrl)
+			test.payload.keysMock(keys)
+
+			req, _ := http.NewRequest(http.MethodGet, "/v1/api-keys"+test.payload.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserUUID, userUUID.String()))
+			rw := httptest.NewRecorder()
+
+			akh := NewAPIKeysHandler(&config.Config{}, logger, keys, repomock.NewMockAPIKeys(mockCtrl))
+			akh.GetAPIKeys(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	type payload struct {
+		keyID    string
+		keysMock func(keysMock *apikeymock.MockService)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Revoke api key with success",
+			payload: payload{
+				keyID: keyUUID.String(),
+				keysMock: func(keysMock *apikeymock.MockService) {
+					keysMock.EXPECT().Revoke(userUUID, keyUUID).Return(nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Revoke api key unknown key with fail",
+			payload: payload{
+				keyID: keyUUID.String(),
+				keysMock: func(keysMock *apikeymock.MockService) {
+					keysMock.EXPECT().Revoke(userUUID, keyUUID).Return(entity.ErrAPIKeyNotFound)
+				},
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"api key not found"}`,
+			},
+		},
+		{
+			name: "Revoke api key invalid id with fail",
+			payload: payload{
+				keyID:    "invalid",
+				keysMock: func(keysMock *apikeymock.MockService) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid UUID length: 7"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			keys := apikeymock.NewMockService(mockCtrl)
+			test.payload.keysMock(keys)
+
+			req, _ := http.NewRequest(http.MethodDelete, "/v1/api-keys/"+test.payload.keyID, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserUUID, userUUID.String()))
+			rw := httptest.NewRecorder()
+
+			akh := NewAPIKeysHandler(&config.Config{}, logger, keys, repomock.NewMockAPIKeys(mockCtrl))
+
+			router := mux.NewRouter()
+			router.HandleFunc("/v1/api-keys/{keyID}", akh.RevokeAPIKey)
+			router.ServeHTTP(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestCreateServiceAccount(t *testing.T) {
+	type payload struct {
+		body     string
+		repoMock func(repoMock *repomock.MockAPIKeys)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Create service account with success",
+			payload: payload{
+				body: `{"name":"shop-sync"}`,
+				repoMock: func(repoMock *repomock.MockAPIKeys) {
+					repoMock.EXPECT().CreateServiceAccount(userUUID, "shop-sync").Return(&entity.ServiceAccount{ID: accountUUID, Name: "shop-sync"}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusCreated,
+				body: `{"id":"2d8df2e6-2c3e-11eb-adc1-0242ac120002","name":"shop-sync"}`,
+			},
+		},
+		{
+			name: "Create service account duplicate name with fail",
+			payload: payload{
+				body: `{"name":"shop-sync"}`,
+				repoMock: func(repoMock *repomock.MockAPIKeys) {
+					repoMock.EXPECT().CreateServiceAccount(userUUID, "shop-sync").Return(&entity.ServiceAccount{}, entity.ErrUserAlreadyExist)
+				},
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"` + entity.ErrUserAlreadyExist.Error() + `"}`,
+			},
+		},
+		{
+			name: "Create service account empty name with fail",
+			payload: payload{
+				body:     `{}`,
+				repoMock: func(repoMock *repomock.MockAPIKeys) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"name must be 1 to 34 characters long"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			repo := repomock.NewMockAPIKeys(mockCtrl)
+			test.payload.repoMock(repo)
+
+			req, _ := http.NewRequest(http.MethodPost, "url", bytes.NewBufferString(test.payload.body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserUUID, userUUID.String()))
+			rw := httptest.NewRecorder()
+
+			akh := NewAPIKeysHandler(&config.Config{}, logger, apikeymock.NewMockService(mockCtrl), repo)
+			akh.CreateServiceAccount(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestGetServiceAccounts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	logger, _ := loggermock.NewNullLogger()
+
+	repo := repomock.NewMockAPIKeys(mockCtrl)
+	repo.EXPECT().GetServiceAccounts(userUUID).Return([]entity.ServiceAccount{{ID: accountUUID, Name: "shop-sync"}}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "url", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserUUID, userUUID.String()))
+	rw := httptest.NewRecorder()
+
+	akh := NewAPIKeysHandler(&config.Config{}, logger, apikeymock.NewMockService(mockCtrl), repo)
+	akh.GetServiceAccounts(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, `[{"id":"2d8df2e6-2c3e-11eb-adc1-0242ac120002","name":"shop-sync"}]`, rw.Body.String())
+}
@@ -5,39 +5,94 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/mshto/fruit-store/audit"
 	"github.com/mshto/fruit-store/authentication"
+	"github.com/mshto/fruit-store/bill"
 	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/encryption"
 	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/notifier"
+	"github.com/mshto/fruit-store/oidc"
+	"github.com/mshto/fruit-store/password"
 	"github.com/mshto/fruit-store/repository"
+	"github.com/mshto/fruit-store/web/common/request"
 	"github.com/mshto/fruit-store/web/common/response"
 	"github.com/mshto/fruit-store/web/middleware"
 )
 
+// dummyPasswordHash compared with password of unknown user
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), 8) // nolint
+
 // Service auth interface
 type Service interface {
 	Signup(w http.ResponseWriter, r *http.Request)
 	Signin(w http.ResponseWriter, r *http.Request)
 	Refresh(w http.ResponseWriter, r *http.Request)
 	Logout(w http.ResponseWriter, r *http.Request)
+	LogoutAll(w http.ResponseWriter, r *http.Request)
+
+	ChangePassword(w http.ResponseWriter, r *http.Request)
+	ForgotPassword(w http.ResponseWriter, r *http.Request)
+	ResetPassword(w http.ResponseWriter, r *http.Request)
+
+	SetupTwoFactor(w http.ResponseWriter, r *http.Request)
+	ConfirmTwoFactor(w http.ResponseWriter, r *http.Request)
+	SigninTwoFactor(w http.ResponseWriter, r *http.Request)
+
+	OIDCLogin(w http.ResponseWriter, r *http.Request)
+	OIDCCallback(w http.ResponseWriter, r *http.Request)
+	OIDCLink(w http.ResponseWriter, r *http.Request)
+
+	GetSessions(w http.ResponseWriter, r *http.Request)
+	RevokeSession(w http.ResponseWriter, r *http.Request)
+
+	Guest(w http.ResponseWriter, r *http.Request)
+
+	JWKS(w http.ResponseWriter, r *http.Request)
 }
 
 type authHandler struct {
-	cfg      *config.Config
-	log      *logrus.Logger
-	authRepo repository.Auth
-	auth     authentication.Auth
+	cfg       *config.Config
+	log       *logrus.Logger
+	authRepo  repository.Auth
+	auth      authentication.Auth
+	cartRepo  repository.Cart
+	guestCart repository.Cart
+	discRepo  repository.Discount
+	bil       bill.Bill
+	policy    password.Policy
+	ntf       notifier.Sender
+
+	twoFactorRepo repository.TwoFactor
+	cipher        *encryption.Cipher
+	provider      oidc.Provider
+	auditor       audit.Auditor
 }
 
 // NewAuthHandler init new auth handler
-func NewAuthHandler(cfg *config.Config, log *logrus.Logger, authRepo repository.Auth, auth authentication.Auth) Service {
+func NewAuthHandler(cfg *config.Config, log *logrus.Logger, authRepo repository.Auth, auth authentication.Auth,
+	cartRepo, guestCart repository.Cart, discRepo repository.Discount, bil bill.Bill, policy password.Policy, ntf notifier.Sender,
+	twoFactorRepo repository.TwoFactor, cph *encryption.Cipher, provider oidc.Provider, auditor audit.Auditor) Service {
 	return authHandler{
-		cfg:      cfg,
-		log:      log,
-		authRepo: authRepo,
-		auth:     auth,
+		cfg:       cfg,
+		log:       log,
+		authRepo:  authRepo,
+		auth:      auth,
+		cartRepo:  cartRepo,
+		guestCart: guestCart,
+		discRepo:  discRepo,
+		bil:       bil,
+		policy:    policy,
+		ntf:       ntf,
+
+		twoFactorRepo: twoFactorRepo,
+		cipher:        cph,
+		provider:      provider,
+		auditor:       auditor,
 	}
 }
 
@@ -53,7 +108,13 @@ func (ah authHandler) Signup(w http.ResponseWriter, r *http.Request) {
 
 	if creds.Password != creds.PasswordRepeat {
 		ah.log.Errorf("passwords aren't equal, user name: %s", creds.Username)
-		response.RenderFailedResponse(w, http.StatusNotFound, errors.New("passwords aren't equal"))
+		response.RenderFailedResponse(w, http.StatusNotFound, errPasswordsNotEqual)
+		return
+	}
+
+	err = ah.policy.Validate(creds.Password)
+	if err != nil {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
 		return
 	}
 
@@ -77,6 +138,8 @@ func (ah authHandler) Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ah.mergeGuestCart(r, creds.ID)
+
 	response.RenderResponse(w, http.StatusCreated, response.EmptyResp{})
 }
 
@@ -90,34 +153,98 @@ func (ah authHandler) Signin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	storedUser, err := ah.authRepo.GetUserByName(creds.Username)
-	if err == entity.ErrUserNotFound {
-		ah.log.Errorf("failed to get user by name, error: %v", err)
-		response.RenderFailedResponse(w, http.StatusNotFound, err)
+	client := request.GetClient(r)
+	retryAfter, err := ah.auth.SigninAllowed(creds.Username, client.IP)
+	if err != nil {
+		ah.log.Errorf("failed to check sign in attempts, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
 		return
 	}
-	if err != nil {
+	if retryAfter > 0 {
+		ah.log.Warnf("sign in is blocked, user name: %v, ip: %v, retry after: %v", creds.Username, client.IP, retryAfter)
+		ah.auditor.Record(r, audit.Event(entity.AuditSignin, creds.Username, entity.ErrTooManyAttempts))
+		response.SetRetryAfter(w, retryAfter)
+		response.RenderFailedResponse(w, http.StatusTooManyRequests, entity.ErrTooManyAttempts)
+		return
+	}
+
+	storedUser, err := ah.authRepo.GetUserByName(creds.Username)
+	if err != nil && err != entity.ErrUserNotFound {
 		ah.log.Errorf("failed to get user by name, error: %v", err)
 		response.RenderResponse(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	if err = bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(creds.Password)); err != nil {
-		ah.log.Errorf("failed to compare hash and password, error: %v", err)
-		response.RenderResponse(w, http.StatusUnauthorized, response.EmptyResp{})
+	// hash is compared for unknown users as well, so response time doesn't reveal whether user exists
+	userExists := err == nil
+	hashedPassword := dummyPasswordHash
+	if userExists {
+		hashedPassword = []byte(storedUser.Password)
+	}
+	err = bcrypt.CompareHashAndPassword(hashedPassword, []byte(creds.Password))
+	if err != nil || !userExists {
+		ah.log.Errorf("failed to sign in, user name: %v, ip: %v", creds.Username, client.IP)
+		ah.signinFailed(w, r, entity.AuditSignin, creds.Username, entity.ErrInvalidCredentials)
+		return
+	}
+
+	ah.completeSignin(w, r, storedUser, entity.AuditSignin)
+}
+
+// completeSignin render a challenge for user with two-factor authentication and tokens otherwise
+func (ah authHandler) completeSignin(w http.ResponseWriter, r *http.Request, storedUser *entity.Credentials, action string) {
+	// failed attempts are kept until the second factor is passed as well
+	if storedUser.TOTPEnabled {
+		challenge, err := ah.auth.CreateChallenge(storedUser.ID)
+		if err != nil {
+			ah.log.Errorf("failed to create challenge, error: %v", err)
+			response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		response.RenderResponse(w, http.StatusOK, entity.Challenge{ChallengeToken: challenge})
 		return
 	}
 
-	tokens, err := ah.auth.CreateTokens(storedUser.ID)
+	ah.issueTokens(w, r, storedUser, action)
+}
+
+// issueTokens reset failed attempts of signed in user, audit sign in and render its new tokens
+func (ah authHandler) issueTokens(w http.ResponseWriter, r *http.Request, storedUser *entity.Credentials, action string) {
+	err := ah.auth.SigninSucceeded(storedUser.Username)
+	if err != nil {
+		ah.log.Warnf("failed to reset sign in attempts, user name: %v, error: %v", storedUser.Username, err)
+	}
+
+	tokens, err := ah.auth.CreateTokens(storedUser.ID, request.GetClient(r))
 	if err != nil {
 		ah.log.Errorf("failed to create tokens, error: %v", err)
 		response.RenderResponse(w, http.StatusForbidden, err)
 		return
 	}
 
+	event := audit.Event(action, storedUser.Username, nil)
+	event.Actor = storedUser.ID.String()
+	ah.auditor.Record(r, event)
+
+	ah.mergeGuestCart(r, storedUser.ID)
+
 	response.RenderResponse(w, http.StatusOK, tokens)
 }
 
+// signinFailed count and audit failed attempt and render uniform response for any bad credentials
+func (ah authHandler) signinFailed(w http.ResponseWriter, r *http.Request, action, username string, failure error) {
+	ah.auditor.Record(r, audit.Event(action, username, failure))
+
+	retryAfter, err := ah.auth.SigninFailed(username, request.ClientIP(r))
+	if err != nil {
+		ah.log.Errorf("failed to count sign in attempt, user name: %v, error: %v", username, err)
+	}
+	if retryAfter > 0 {
+		response.SetRetryAfter(w, retryAfter)
+	}
+	response.RenderFailedResponse(w, http.StatusUnauthorized, failure)
+}
+
 // Refresh refresh user token
 func (ah authHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	tokens := &entity.Tokens{}
@@ -130,9 +257,11 @@ func (ah authHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	generatedTokens, err := ah.auth.RefreshTokens(tokens.RefreshToken)
 	if err != nil {
 		ah.log.Errorf("failed to refresh tokens, error: %v", err)
+		ah.auditor.Record(r, audit.Event(entity.AuditRefresh, "", err))
 		response.RenderFailedResponse(w, http.StatusUnauthorized, err)
 		return
 	}
+	ah.auditor.Record(r, audit.Event(entity.AuditRefresh, "", nil))
 	response.RenderResponse(w, http.StatusOK, generatedTokens)
 }
 
@@ -154,8 +283,28 @@ func (ah authHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	err := ah.auth.RemoveTokens(accessUUID, userUUID)
 	if err != nil {
 		ah.log.Errorf("failed to remove tokens, error: %v", err)
+		ah.auditor.Record(r, audit.Event(entity.AuditLogout, userUUID, err))
 		response.RenderFailedResponse(w, http.StatusBadRequest, err)
 		return
 	}
+	ah.auditor.Record(r, audit.Event(entity.AuditLogout, userUUID, nil))
 	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
 }
+
+// getUserUUID get uuid of signed in user, failed response is rendered when false is returned
+func (ah authHandler) getUserUUID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, ok := r.Context().Value(middleware.UserUUID).(string)
+	if !ok {
+		ah.log.Errorf("failed to get UserUUID")
+		response.RenderFailedResponse(w, http.StatusBadRequest, errors.New("userUUID not found"))
+		return uuid.Nil, false
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		ah.log.Errorf("failed to parse user uuid, user: %v, error: %v", userID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return uuid.Nil, false
+	}
+	return userUUID, true
+}
@@ -7,24 +7,40 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
 	loggermock "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/mshto/fruit-store/audit"
+	auditmock "github.com/mshto/fruit-store/audit/mock"
 	authmock "github.com/mshto/fruit-store/authentication/mock"
+	billmock "github.com/mshto/fruit-store/bill/mock"
 	"github.com/mshto/fruit-store/config"
 	"github.com/mshto/fruit-store/entity"
+	notifiermock "github.com/mshto/fruit-store/notifier/mock"
+	"github.com/mshto/fruit-store/password"
+	passwordmock "github.com/mshto/fruit-store/password/mock"
 	repomock "github.com/mshto/fruit-store/repository/mock"
 	"github.com/mshto/fruit-store/web/middleware"
 )
 
+// anyAuditor auditor which accepts any events
+func anyAuditor(mockCtrl *gomock.Controller) audit.Auditor {
+	auditor := auditmock.NewMockAuditor(mockCtrl)
+	auditor.EXPECT().Record(gomock.Any(), gomock.Any()).AnyTimes()
+	return auditor
+}
+
 func TestSignup(t *testing.T) {
 	type payload struct {
-		cfg      *config.Config
-		body     []byte
-		repoMock func(repoMock *repomock.MockAuth)
-		authMock func(authMock *authmock.MockAuth)
+		cfg        *config.Config
+		body       []byte
+		repoMock   func(repoMock *repomock.MockAuth)
+		authMock   func(authMock *authmock.MockAuth)
+		policyMock func(policyMock *passwordmock.MockPolicy)
 	}
 	type expected struct {
 		code int
@@ -46,6 +62,9 @@ func TestSignup(t *testing.T) {
 				},
 				authMock: func(authMock *authmock.MockAuth) {
 				},
+				policyMock: func(policyMock *passwordmock.MockPolicy) {
+					policyMock.EXPECT().Validate("password").Return(nil)
+				},
 			},
 			expected: expected{
 				code: http.StatusCreated,
@@ -61,6 +80,8 @@ func TestSignup(t *testing.T) {
 				},
 				authMock: func(authMock *authmock.MockAuth) {
 				},
+				policyMock: func(policyMock *passwordmock.MockPolicy) {
+				},
 			},
 			expected: expected{
 				code: http.StatusBadRequest,
@@ -76,12 +97,32 @@ func TestSignup(t *testing.T) {
 				},
 				authMock: func(authMock *authmock.MockAuth) {
 				},
+				policyMock: func(policyMock *passwordmock.MockPolicy) {
+				},
 			},
 			expected: expected{
 				code: http.StatusNotFound,
 				body: `{"error":"passwords aren't equal"}`,
 			},
 		},
+		{
+			name: "Sign up weak password with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"username":"test","password":"","passwordRepeat":""}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+				},
+				authMock: func(authMock *authmock.MockAuth) {
+				},
+				policyMock: func(policyMock *passwordmock.MockPolicy) {
+					policyMock.EXPECT().Validate("").Return(password.ErrTooShort)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"password is too short"}`,
+			},
+		},
 	}
 
 	for _, test := range tc {
@@ -100,13 +141,19 @@ func TestSignup(t *testing.T) {
 			auth := authmock.NewMockAuth(mockCtrl)
 			test.payload.authMock(auth)
 
+			policy := passwordmock.NewMockPolicy(mockCtrl)
+			test.payload.policyMock(policy)
+
 			req, err := http.NewRequest(http.MethodPost, "url", bytes.NewBuffer(test.payload.body))
 			if err != nil {
 				t.Error("failed to create request")
 			}
 			rw := httptest.NewRecorder()
 
-			auh := NewAuthHandler(test.payload.cfg, logger, authRepo, auth)
+			auh := NewAuthHandler(test.payload.cfg, logger, authRepo, auth,
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				policy, notifiermock.NewMockSender(mockCtrl),
+				repomock.NewMockTwoFactor(mockCtrl), nil, nil, anyAuditor(mockCtrl))
 			auh.Signup(rw, req)
 
 			assert.Equal(t, test.expected.code, rw.Code)
@@ -123,8 +170,9 @@ func TestSignin(t *testing.T) {
 		authMock func(authMock *authmock.MockAuth)
 	}
 	type expected struct {
-		code int
-		body string
+		code       int
+		body       string
+		retryAfter string
 	}
 
 	tc := []struct {
@@ -138,10 +186,12 @@ func TestSignin(t *testing.T) {
 				cfg:  &config.Config{},
 				body: []byte(`{"username":"test","password":"password"}`),
 				repoMock: func(repoMock *repomock.MockAuth) {
-					repoMock.EXPECT().GetUserByName(gomock.Any()).Return(&entity.Credentials{Password: "$2a$08$ZtefSglA0MuPtOYRa/dZI.zb.pf.dhUHo1XXmhTrKmUuMz.9Cqg6m"}, nil)
+					repoMock.EXPECT().GetUserByName(gomock.Any()).Return(&entity.Credentials{Username: "test", Password: "$2a$08$ZtefSglA0MuPtOYRa/dZI.zb.pf.dhUHo1XXmhTrKmUuMz.9Cqg6m"}, nil)
 				},
 				authMock: func(authMock *authmock.MockAuth) {
-					authMock.EXPECT().CreateTokens(gomock.Any()).Return(&entity.Tokens{}, nil)
+					authMock.EXPECT().SigninAllowed("test", gomock.Any()).Return(time.Duration(0), nil)
+					authMock.EXPECT().SigninSucceeded("test").Return(nil)
+					authMock.EXPECT().CreateTokens(gomock.Any(), gomock.Any()).Return(&entity.Tokens{}, nil)
 				},
 			},
 			expected: expected{
@@ -149,6 +199,24 @@ func TestSignin(t *testing.T) {
 				body: `{"access_token":"","refresh_token":""}`,
 			},
 		},
+		{
+			name: "Sign in two-factor challenge with success",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"username":"test","password":"password"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().GetUserByName(gomock.Any()).Return(&entity.Credentials{Username: "test", Password: "$2a$08$ZtefSglA0MuPtOYRa/dZI.zb.pf.dhUHo1XXmhTrKmUuMz.9Cqg6m", TOTPEnabled: true}, nil)
+				},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().SigninAllowed("test", gomock.Any()).Return(time.Duration(0), nil)
+					authMock.EXPECT().CreateChallenge(gomock.Any()).Return("challenge", nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"challenge_token":"challenge"}`,
+			},
+		},
 		{
 			name: "Sign in invalid body with fail",
 			payload: payload{
@@ -173,11 +241,84 @@ func TestSignin(t *testing.T) {
 					repoMock.EXPECT().GetUserByName(gomock.Any()).Return(&entity.Credentials{}, entity.ErrUserNotFound)
 				},
 				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().SigninAllowed("test", gomock.Any()).Return(time.Duration(0), nil)
+					authMock.EXPECT().SigninFailed("test", gomock.Any()).Return(time.Second, nil)
 				},
 			},
 			expected: expected{
-				code: http.StatusNotFound,
-				body: `{"error":"user not found"}`,
+				code:       http.StatusUnauthorized,
+				body:       `{"error":"invalid username or password"}`,
+				retryAfter: "1",
+			},
+		},
+		{
+			name: "Sign in wrong password with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"username":"test","password":"wrong"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().GetUserByName(gomock.Any()).Return(&entity.Credentials{Username: "test", Password: "$2a$08$ZtefSglA0MuPtOYRa/dZI.zb.pf.dhUHo1XXmhTrKmUuMz.9Cqg6m"}, nil)
+				},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().SigninAllowed("test", gomock.Any()).Return(time.Duration(0), nil)
+					authMock.EXPECT().SigninFailed("test", gomock.Any()).Return(time.Second, nil)
+				},
+			},
+			expected: expected{
+				code:       http.StatusUnauthorized,
+				body:       `{"error":"invalid username or password"}`,
+				retryAfter: "1",
+			},
+		},
+		{
+			name: "Sign in SigninFailed error with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"username":"test","password":"wrong"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().GetUserByName(gomock.Any()).Return(&entity.Credentials{Username: "test", Password: "$2a$08$ZtefSglA0MuPtOYRa/dZI.zb.pf.dhUHo1XXmhTrKmUuMz.9Cqg6m"}, nil)
+				},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().SigninAllowed("test", gomock.Any()).Return(time.Duration(0), nil)
+					authMock.EXPECT().SigninFailed("test", gomock.Any()).Return(time.Duration(0), errors.New("error"))
+				},
+			},
+			expected: expected{
+				code: http.StatusUnauthorized,
+				body: `{"error":"invalid username or password"}`,
+			},
+		},
+		{
+			name: "Sign in blocked with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"username":"test","password":"password"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+				},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().SigninAllowed("test", gomock.Any()).Return(90500*time.Millisecond, nil)
+				},
+			},
+			expected: expected{
+				code:       http.StatusTooManyRequests,
+				body:       `{"error":"too many sign in attempts, try again later"}`,
+				retryAfter: "91",
+			},
+		},
+		{
+			name: "Sign in SigninAllowed error with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"username":"test","password":"password"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+				},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().SigninAllowed("test", gomock.Any()).Return(time.Duration(0), errors.New("error"))
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
 			},
 		},
 		{
@@ -189,6 +330,7 @@ func TestSignin(t *testing.T) {
 					repoMock.EXPECT().GetUserByName(gomock.Any()).Return(&entity.Credentials{}, errors.New("error"))
 				},
 				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().SigninAllowed("test", gomock.Any()).Return(time.Duration(0), nil)
 				},
 			},
 			expected: expected{
@@ -220,11 +362,15 @@ func TestSignin(t *testing.T) {
 			}
 			rw := httptest.NewRecorder()
 
-			auh := NewAuthHandler(test.payload.cfg, logger, authRepo, auth)
+			auh := NewAuthHandler(test.payload.cfg, logger, authRepo, auth,
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+				repomock.NewMockTwoFactor(mockCtrl), nil, nil, anyAuditor(mockCtrl))
 			auh.Signin(rw, req)
 
 			assert.Equal(t, test.expected.code, rw.Code)
 			assert.Equal(t, test.expected.body, rw.Body.String())
+			assert.Equal(t, test.expected.retryAfter, rw.Header().Get("Retry-After"))
 		})
 	}
 }
@@ -316,7 +462,10 @@ func TestRefresh(t *testing.T) {
 			}
 			rw := httptest.NewRecorder()
 
-			auh := NewAuthHandler(test.payload.cfg, logger, authRepo, auth)
+			auh := NewAuthHandler(test.payload.cfg, logger, authRepo, auth,
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+				repomock.NewMockTwoFactor(mockCtrl), nil, nil, anyAuditor(mockCtrl))
 			auh.Refresh(rw, req)
 
 			assert.Equal(t, test.expected.code, rw.Code)
@@ -444,7 +593,10 @@ func TestLogout(t *testing.T) {
 
 			rw := httptest.NewRecorder()
 
-			auh := NewAuthHandler(test.payload.cfg, logger, authRepo, auth)
+			auh := NewAuthHandler(test.payload.cfg, logger, authRepo, auth,
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+				repomock.NewMockTwoFactor(mockCtrl), nil, nil, anyAuditor(mockCtrl))
 			auh.Logout(rw, req.WithContext(ctx))
 
 			assert.Equal(t, test.expected.code, rw.Code)
@@ -452,3 +604,90 @@ func TestLogout(t *testing.T) {
 		})
 	}
 }
+
+func TestSigninAudit(t *testing.T) {
+	userUUID := uuid.New()
+
+	type payload struct {
+		body     []byte
+		repoMock func(repoMock *repomock.MockAuth)
+		authMock func(authMock *authmock.MockAuth)
+	}
+
+	tc := []struct {
+		name     string
+		expected entity.AuditEvent
+		payload
+	}{
+		{
+			name: "Sign in audit success",
+			payload: payload{
+				body: []byte(`{"username":"test","password":"password"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().GetUserByName("test").Return(&entity.Credentials{ID: userUUID, Username: "test", Password: "$2a$08$ZtefSglA0MuPtOYRa/dZI.zb.pf.dhUHo1XXmhTrKmUuMz.9Cqg6m"}, nil)
+				},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().SigninAllowed("test", gomock.Any()).Return(time.Duration(0), nil)
+					authMock.EXPECT().SigninSucceeded("test").Return(nil)
+					authMock.EXPECT().CreateTokens(userUUID, gomock.Any()).Return(&entity.Tokens{}, nil)
+				},
+			},
+			expected: entity.AuditEvent{Actor: userUUID.String(), Action: entity.AuditSignin, Target: "test", Outcome: entity.AuditSuccess},
+		},
+		{
+			name: "Sign in audit wrong password",
+			payload: payload{
+				body: []byte(`{"username":"test","password":"wrong"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().GetUserByName("test").Return(&entity.Credentials{ID: userUUID, Username: "test", Password: "$2a$08$ZtefSglA0MuPtOYRa/dZI.zb.pf.dhUHo1XXmhTrKmUuMz.9Cqg6m"}, nil)
+				},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().SigninAllowed("test", gomock.Any()).Return(time.Duration(0), nil)
+					authMock.EXPECT().SigninFailed("test", gomock.Any()).Return(time.Duration(0), nil)
+				},
+			},
+			expected: entity.AuditEvent{Action: entity.AuditSignin, Target: "test", Outcome: entity.AuditFailure, Details: "invalid username or password"},
+		},
+		{
+			name: "Sign in audit blocked",
+			payload: payload{
+				body:     []byte(`{"username":"test","password":"password"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().SigninAllowed("test", gomock.Any()).Return(time.Minute, nil)
+				},
+			},
+			expected: entity.AuditEvent{Action: entity.AuditSignin, Target: "test", Outcome: entity.AuditFailure, Details: "too many sign in attempts, try again later"},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			authRepo := repomock.NewMockAuth(mockCtrl)
+			test.payload.repoMock(authRepo)
+
+			auth := authmock.NewMockAuth(mockCtrl)
+			test.payload.authMock(auth)
+
+			auditor := auditmock.NewMockAuditor(mockCtrl)
+			auditor.EXPECT().Record(gomock.Any(), test.expected)
+
+			req, _ := http.NewRequest(http.MethodPost, "url", bytes.NewBuffer(test.payload.body))
+			rw := httptest.NewRecorder()
+
+			auh := NewAuthHandler(&config.Config{}, logger, authRepo, auth,
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+				repomock.NewMockTwoFactor(mockCtrl), nil, nil, auditor)
+			auh.Signin(rw, req)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/mshto/fruit-store/cache"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/web/common/response"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+// Guest create a token for anonymous cart
+func (ah authHandler) Guest(w http.ResponseWriter, r *http.Request) {
+	token, err := ah.auth.CreateGuestToken()
+	if err != nil {
+		ah.log.Errorf("failed to create guest token, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusCreated, entity.GuestToken{GuestToken: token})
+}
+
+// mergeGuestCart moves products and coupon of guest cart to the user cart.
+// Failures are logged only, so they never break sign in or sign up.
+func (ah authHandler) mergeGuestCart(r *http.Request, userUUID uuid.UUID) {
+	guestToken := r.Header.Get(middleware.GuestTokenHeader)
+	if guestToken == "" {
+		return
+	}
+
+	guestUUID, err := ah.auth.ValidateGuestToken(guestToken)
+	if err != nil {
+		ah.log.Warnf("failed to validate guest token, user: %v, error: %v", userUUID, err)
+		return
+	}
+
+	products, err := ah.guestCart.GetUserProducts(guestUUID)
+	if err != nil {
+		ah.log.Errorf("failed to get guest products, guest: %v, error: %v", guestUUID, err)
+		return
+	}
+
+	prds := make([]entity.UserProduct, 0, len(products))
+	for _, product := range products {
+		prds = append(prds, entity.UserProduct{
+			ProductUUID: product.ProductUUID,
+			UserID:      userUUID,
+			Amount:      product.Amount,
+		})
+	}
+
+	if len(prds) != 0 {
+		err = ah.cartRepo.AddUserProducts(userUUID, prds)
+		if err != nil {
+			ah.log.Errorf("failed to merge guest products, guest: %v, user: %v, error: %v", guestUUID, userUUID, err)
+			return
+		}
+	}
+
+	ah.mergeGuestDiscount(guestUUID, userUUID)
+
+	err = ah.guestCart.RemoveUserProducts(guestUUID)
+	if err != nil {
+		ah.log.Errorf("failed to remove guest products, guest: %v, error: %v", guestUUID, err)
+	}
+}
+
+// mergeGuestDiscount carries over guest coupon if the user has none and it is still valid
+func (ah authHandler) mergeGuestDiscount(guestUUID, userUUID uuid.UUID) {
+	sale, err := ah.bil.GetDiscountByUser(guestUUID)
+	if err == cache.ErrNotFound {
+		return
+	}
+	if err != nil {
+		ah.log.Errorf("failed to get guest discount, guest: %v, error: %v", guestUUID, err)
+		return
+	}
+
+	defer func() {
+		err := ah.bil.RemoveDiscount(guestUUID)
+		if err != nil {
+			ah.log.Errorf("failed to remove guest discount, guest: %v, error: %v", guestUUID, err)
+		}
+	}()
+
+	userSale, err := ah.bil.GetDiscountByUser(userUUID)
+	if err != nil && err != cache.ErrNotFound {
+		ah.log.Errorf("failed to get discount by user, user: %v, error: %v", userUUID, err)
+		return
+	}
+	if userSale.ID != "" {
+		ah.log.Infof("user already has a discount, guest discount is dropped, user: %v", userUUID)
+		return
+	}
+
+	dscRepo, err := ah.discRepo.GetDiscount(sale.ID)
+	if err != nil {
+		ah.log.Warnf("guest discount is not valid anymore, discount: %v, error: %v", sale.ID, err)
+		return
+	}
+
+	err = ah.bil.SetDiscount(userUUID, dscRepo)
+	if err != nil {
+		ah.log.Errorf("failed to set discount, user: %v, error: %v", userUUID, err)
+	}
+}
@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	authmock "github.com/mshto/fruit-store/authentication/mock"
+	billmock "github.com/mshto/fruit-store/bill/mock"
+	"github.com/mshto/fruit-store/cache"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	notifiermock "github.com/mshto/fruit-store/notifier/mock"
+	passwordmock "github.com/mshto/fruit-store/password/mock"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+func TestGuest(t *testing.T) {
+	type payload struct {
+		cfg      *config.Config
+		authMock func(authMock *authmock.MockAuth)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Guest with success",
+			payload: payload{
+				cfg: &config.Config{},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().CreateGuestToken().Return("token", nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusCreated,
+				body: `{"guest_token":"token"}`,
+			},
+		},
+		{
+			name: "Guest CreateGuestToken error with fail",
+			payload: payload{
+				cfg: &config.Config{},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().CreateGuestToken().Return("", errors.New("error"))
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			auth := authmock.NewMockAuth(mockCtrl)
+			test.payload.authMock(auth)
+
+			req, _ := http.NewRequest(http.MethodPost, "url", nil)
+			rw := httptest.NewRecorder()
+
+			auh := NewAuthHandler(test.payload.cfg, logger, repomock.NewMockAuth(mockCtrl), auth,
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+				repomock.NewMockTwoFactor(mockCtrl), nil, nil, anyAuditor(mockCtrl))
+			auh.Guest(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestSigninMergeGuestCart(t *testing.T) {
+	type payload struct {
+		guestToken string
+		authMock   func(authMock *authmock.MockAuth)
+		cartMock   func(cartMock, guestMock *repomock.MockCart, discMock *repomock.MockDiscount)
+		billMock   func(billMock *billmock.MockBill)
+	}
+
+	userUUID := uuid.New()
+	guestUUID := uuid.New()
+	productUUID := uuid.New()
+	storedUser := &entity.Credentials{
+		ID:       userUUID,
+		Username: "test",
+		Password: "$2a$08$ZtefSglA0MuPtOYRa/dZI.zb.pf.dhUHo1XXmhTrKmUuMz.9Cqg6m",
+	}
+
+	tc := []struct {
+		name string
+		payload
+	}{
+		{
+			name: "Sign in merge guest cart and discount with success",
+			payload: payload{
+				guestToken: "guest",
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().ValidateGuestToken("guest").Return(guestUUID, nil)
+				},
+				cartMock: func(cartMock, guestMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					guestMock.EXPECT().GetUserProducts(guestUUID).Return([]entity.GetUserProduct{
+						{ProductUUID: productUUID, Amount: 2},
+					}, nil)
+					cartMock.EXPECT().AddUserProducts(userUUID, []entity.UserProduct{
+						{ProductUUID: productUUID, UserID: userUUID, Amount: 2},
+					}).Return(nil)
+					discMock.EXPECT().GetDiscount("code").Return(config.GeneralSale{ID: "code"}, nil)
+					guestMock.EXPECT().RemoveUserProducts(guestUUID).Return(nil)
+				},
+				billMock: func(billMock *billmock.MockBill) {
+					billMock.EXPECT().GetDiscountByUser(guestUUID).Return(config.GeneralSale{ID: "code"}, nil)
+					billMock.EXPECT().GetDiscountByUser(userUUID).Return(config.GeneralSale{}, cache.ErrNotFound)
+					billMock.EXPECT().SetDiscount(userUUID, config.GeneralSale{ID: "code"}).Return(nil)
+					billMock.EXPECT().RemoveDiscount(guestUUID).Return(nil)
+				},
+			},
+		},
+		{
+			name: "Sign in keep user discount with success",
+			payload: payload{
+				guestToken: "guest",
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().ValidateGuestToken("guest").Return(guestUUID, nil)
+				},
+				cartMock: func(cartMock, guestMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					guestMock.EXPECT().GetUserProducts(guestUUID).Return([]entity.GetUserProduct{}, nil)
+					guestMock.EXPECT().RemoveUserProducts(guestUUID).Return(nil)
+				},
+				billMock: func(billMock *billmock.MockBill) {
+					billMock.EXPECT().GetDiscountByUser(guestUUID).Return(config.GeneralSale{ID: "code"}, nil)
+					billMock.EXPECT().GetDiscountByUser(userUUID).Return(config.GeneralSale{ID: "user"}, nil)
+					billMock.EXPECT().RemoveDiscount(guestUUID).Return(nil)
+				},
+			},
+		},
+		{
+			name: "Sign in AddUserProducts error keeps guest cart",
+			payload: payload{
+				guestToken: "guest",
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().ValidateGuestToken("guest").Return(guestUUID, nil)
+				},
+				cartMock: func(cartMock, guestMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					guestMock.EXPECT().GetUserProducts(guestUUID).Return([]entity.GetUserProduct{
+						{ProductUUID: productUUID, Amount: 2},
+					}, nil)
+					cartMock.EXPECT().AddUserProducts(gomock.Any(), gomock.Any()).Return(errors.New("error"))
+				},
+				billMock: func(billMock *billmock.MockBill) {
+				},
+			},
+		},
+		{
+			name: "Sign in invalid guest token is ignored",
+			payload: payload{
+				guestToken: "invalid",
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().ValidateGuestToken("invalid").Return(uuid.Nil, errors.New("error"))
+				},
+				cartMock: func(cartMock, guestMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+				},
+				billMock: func(billMock *billmock.MockBill) {
+				},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			authRepo := repomock.NewMockAuth(mockCtrl)
+			authRepo.EXPECT().GetUserByName(gomock.Any()).Return(storedUser, nil)
+
+			auth := authmock.NewMockAuth(mockCtrl)
+			auth.EXPECT().SigninAllowed("test", gomock.Any()).Return(time.Duration(0), nil)
+			auth.EXPECT().SigninSucceeded("test").Return(nil)
+			auth.EXPECT().CreateTokens(userUUID, gomock.Any()).Return(&entity.Tokens{}, nil)
+			test.payload.authMock(auth)
+
+			cartRepo := repomock.NewMockCart(mockCtrl)
+			guestCart := repomock.NewMockCart(mockCtrl)
+			discRepo := repomock.NewMockDiscount(mockCtrl)
+			test.payload.cartMock(cartRepo, guestCart, discRepo)
+
+			billMock := billmock.NewMockBill(mockCtrl)
+			test.payload.billMock(billMock)
+
+			req, _ := http.NewRequest(http.MethodPost, "url", bytes.NewBuffer([]byte(`{"username":"test","password":"password"}`)))
+			req.Header.Set(middleware.GuestTokenHeader, test.payload.guestToken)
+			rw := httptest.NewRecorder()
+
+			auh := NewAuthHandler(&config.Config{}, logger, authRepo, auth, cartRepo, guestCart, discRepo, billMock,
+				passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+				repomock.NewMockTwoFactor(mockCtrl), nil, nil, anyAuditor(mockCtrl))
+			auh.Signin(rw, req)
+
+			assert.Equal(t, http.StatusOK, rw.Code)
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/mshto/fruit-store/web/common/response"
+)
+
+// JWKS publish public keys which verify user tokens
+func (ah authHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	response.RenderResponse(w, http.StatusOK, ah.auth.JWKS())
+}
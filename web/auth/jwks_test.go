@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	authmock "github.com/mshto/fruit-store/authentication/mock"
+	billmock "github.com/mshto/fruit-store/bill/mock"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	notifiermock "github.com/mshto/fruit-store/notifier/mock"
+	passwordmock "github.com/mshto/fruit-store/password/mock"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+)
+
+func TestJWKS(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name     string
+		jwks     entity.JWKS
+		expected expected
+	}{
+		{
+			name: "JWKS with success",
+			jwks: entity.JWKS{Keys: []entity.JWK{{Kty: "OKP", Kid: "key", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: "x"}}},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"keys":[{"kty":"OKP","kid":"key","use":"sig","alg":"EdDSA","crv":"Ed25519","x":"x"}]}`,
+			},
+		},
+		{
+			name: "JWKS without keys with success",
+			jwks: entity.JWKS{Keys: []entity.JWK{}},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"keys":[]}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			auth := authmock.NewMockAuth(mockCtrl)
+			auth.EXPECT().JWKS().Return(test.jwks)
+
+			req, _ := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+			rw := httptest.NewRecorder()
+
+			auh := NewAuthHandler(&config.Config{}, logger, repomock.NewMockAuth(mockCtrl), auth,
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+				repomock.NewMockTwoFactor(mockCtrl), nil, nil, anyAuditor(mockCtrl))
+			auh.JWKS(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+			assert.Equal(t, "public, max-age=300", rw.Header().Get("Cache-Control"))
+		})
+	}
+}
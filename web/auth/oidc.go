@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/mshto/fruit-store/audit"
+	"github.com/mshto/fruit-store/authentication"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/oidc"
+	"github.com/mshto/fruit-store/web/common/response"
+)
+
+// OIDCLogin redirect user to identity provider, PKCE verifier and nonce are kept with state until callback
+func (ah authHandler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if ah.provider == nil {
+		response.RenderFailedResponse(w, http.StatusNotFound, entity.ErrOIDCDisabled)
+		return
+	}
+
+	authURL, ok := ah.oidcAuthURL(w, entity.OIDCState{})
+	if !ok {
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCLink return authorization url which links identity provider account to signed in user on callback
+func (ah authHandler) OIDCLink(w http.ResponseWriter, r *http.Request) {
+	if ah.provider == nil {
+		response.RenderFailedResponse(w, http.StatusNotFound, entity.ErrOIDCDisabled)
+		return
+	}
+
+	userUUID, ok := ah.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	authURL, ok := ah.oidcAuthURL(w, entity.OIDCState{UserID: &userUUID})
+	if !ok {
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, entity.OIDCLink{URL: authURL})
+}
+
+// oidcAuthURL keep state with new PKCE verifier and nonce and return authorization url of identity provider. Failure is rendered.
+func (ah authHandler) oidcAuthURL(w http.ResponseWriter, state entity.OIDCState) (string, bool) {
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		ah.log.Errorf("failed to generate code verifier, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return "", false
+	}
+	nonce, err := oidc.NewNonce()
+	if err != nil {
+		ah.log.Errorf("failed to generate nonce, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return "", false
+	}
+	state.Nonce = nonce
+	state.CodeVerifier = verifier
+
+	token, err := ah.auth.CreateOIDCState(state)
+	if err != nil {
+		ah.log.Errorf("failed to create oidc state, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return "", false
+	}
+
+	authURL, err := ah.provider.AuthCodeURL(token, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		ah.log.Errorf("failed to get authorization url, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusBadGateway, err)
+		return "", false
+	}
+	return authURL, true
+}
+
+// OIDCCallback exchange authorization code, provision user or link identity to user who started linking and sign in
+func (ah authHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if ah.provider == nil {
+		response.RenderFailedResponse(w, http.StatusNotFound, entity.ErrOIDCDisabled)
+		return
+	}
+
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		ah.log.Errorf("identity provider denied login, error: %v, description: %v", providerErr, query.Get("error_description"))
+		response.RenderFailedResponse(w, http.StatusUnauthorized, fmt.Errorf("identity provider denied login: %s", providerErr))
+		return
+	}
+
+	state, err := ah.auth.ConsumeOIDCState(query.Get("state"))
+	if err == authentication.ErrOIDCStateInvalid {
+		ah.log.Errorf("failed to consume oidc state, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		ah.log.Errorf("failed to consume oidc state, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	identity, err := ah.provider.Exchange(query.Get("code"), state.CodeVerifier, state.Nonce)
+	if err != nil {
+		ah.log.Errorf("failed to exchange authorization code, error: %v", err)
+		ah.auditor.Record(r, audit.Event(entity.AuditSigninOIDC, "", err))
+		response.RenderFailedResponse(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	var storedUser *entity.Credentials
+	if state.UserID != nil {
+		storedUser, err = ah.linkIdentity(*state.UserID, identity)
+		ah.auditor.Record(r, audit.Event(entity.AuditIdentityLink, state.UserID.String(), err))
+	} else {
+		storedUser, err = ah.oidcUser(identity)
+	}
+	if err == entity.ErrUserAlreadyExist || err == entity.ErrIdentityAlreadyLinked || err == entity.ErrIdentityEmailMismatch {
+		ah.log.Errorf("failed to link identity, issuer: %v, subject: %v, error: %v", identity.Issuer, identity.Subject, err)
+		response.RenderFailedResponse(w, http.StatusConflict, err)
+		return
+	}
+	if err == entity.ErrIdentityNoUsername {
+		ah.log.Errorf("failed to provision user, issuer: %v, subject: %v, error: %v", identity.Issuer, identity.Subject, err)
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err != nil {
+		ah.log.Errorf("failed to get oidc user, issuer: %v, subject: %v, error: %v", identity.Issuer, identity.Subject, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ah.completeSignin(w, r, storedUser, entity.AuditSigninOIDC)
+}
+
+// oidcUser get user linked to identity or provision a new user without password. Identity is never linked
+// to existing user here, user links it while signed in.
+func (ah authHandler) oidcUser(identity *entity.Identity) (*entity.Credentials, error) {
+	storedUser, err := ah.authRepo.GetUserByIdentity(identity.Issuer, identity.Subject)
+	if err != entity.ErrUserNotFound {
+		return storedUser, err
+	}
+
+	username := identity.PreferredUsername
+	if identity.EmailVerified && identity.Email != "" {
+		username = identity.Email
+	}
+	if username == "" {
+		return nil, entity.ErrIdentityNoUsername
+	}
+
+	_, err = ah.authRepo.GetUserByName(username)
+	if err == nil {
+		return nil, entity.ErrUserAlreadyExist
+	}
+	if err != entity.ErrUserNotFound {
+		return nil, err
+	}
+
+	creds := &entity.Credentials{Username: username}
+	return creds, ah.authRepo.SignupWithIdentity(creds, *identity)
+}
+
+// linkIdentity link identity to signed in user who started linking, verified email of identity must be the email of user
+func (ah authHandler) linkIdentity(userUUID uuid.UUID, identity *entity.Identity) (*entity.Credentials, error) {
+	if !identity.EmailVerified || identity.Email == "" {
+		return nil, entity.ErrIdentityEmailMismatch
+	}
+
+	err := ah.authRepo.LinkIdentity(userUUID, *identity)
+	if err != nil {
+		return nil, err
+	}
+	return ah.authRepo.GetUserByID(userUUID)
+}
@@ -0,0 +1,469 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/authentication"
+	authmock "github.com/mshto/fruit-store/authentication/mock"
+	billmock "github.com/mshto/fruit-store/bill/mock"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	notifiermock "github.com/mshto/fruit-store/notifier/mock"
+	"github.com/mshto/fruit-store/oidc"
+	"github.com/mshto/fruit-store/oidc/oidctest"
+	passwordmock "github.com/mshto/fruit-store/password/mock"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+func TestOIDCLoginDisabled(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	logger, _ := loggermock.NewNullLogger()
+
+	auh := NewAuthHandler(&config.Config{}, logger, repomock.NewMockAuth(mockCtrl), authmock.NewMockAuth(mockCtrl),
+		repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+		passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+		repomock.NewMockTwoFactor(mockCtrl), nil, nil, anyAuditor(mockCtrl))
+
+	req, _ := http.NewRequest(http.MethodGet, "url", nil)
+	rw := httptest.NewRecorder()
+	auh.OIDCLogin(rw, req)
+
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+	assert.Equal(t, `{"error":"oidc login is not configured"}`, rw.Body.String())
+}
+
+func TestOIDCLogin(t *testing.T) {
+	type payload struct {
+		claims   jwt.MapClaims
+		authMock func(authMock *authmock.MockAuth)
+		repoMock func(repoMock *repomock.MockAuth, issuer string)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	fake, err := oidctest.NewProvider("client", "secret")
+	if err != nil {
+		t.Fatal("failed to start fake provider")
+	}
+	defer fake.Close()
+
+	userUUID := uuid.New()
+	staffClaims := jwt.MapClaims{"sub": "staff-1", "email": "jane@example.com", "email_verified": true}
+	tokens := &entity.Tokens{AccessToken: "access", RefreshToken: "refresh"}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "OIDC login linked user with success",
+			payload: payload{
+				claims: staffClaims,
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().SigninSucceeded("jane").Return(nil)
+					authMock.EXPECT().CreateTokens(userUUID, gomock.Any()).Return(tokens, nil)
+				},
+				repoMock: func(repoMock *repomock.MockAuth, issuer string) {
+					repoMock.EXPECT().GetUserByIdentity(issuer, "staff-1").Return(&entity.Credentials{ID: userUUID, Username: "jane"}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"access_token":"access","refresh_token":"refresh"}`,
+			},
+		},
+		{
+			name: "OIDC login existing user with verified email with fail",
+			payload: payload{
+				claims:   staffClaims,
+				authMock: func(authMock *authmock.MockAuth) {},
+				repoMock: func(repoMock *repomock.MockAuth, issuer string) {
+					repoMock.EXPECT().GetUserByIdentity(issuer, "staff-1").Return(&entity.Credentials{}, entity.ErrUserNotFound)
+					repoMock.EXPECT().GetUserByName("jane@example.com").Return(&entity.Credentials{ID: userUUID, Username: "jane@example.com"}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"user with current name is already exist"}`,
+			},
+		},
+		{
+			name: "OIDC login provision user with long email with success",
+			payload: payload{
+				claims: jwt.MapClaims{"sub": "staff-5", "email": "jane.doe.from.logistics@warehouse.example.com", "email_verified": true},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().SigninSucceeded("jane.doe.from.logistics@warehouse.example.com").Return(nil)
+					authMock.EXPECT().CreateTokens(userUUID, gomock.Any()).Return(tokens, nil)
+				},
+				repoMock: func(repoMock *repomock.MockAuth, issuer string) {
+					repoMock.EXPECT().GetUserByIdentity(issuer, "staff-5").Return(&entity.Credentials{}, entity.ErrUserNotFound)
+					repoMock.EXPECT().GetUserByName("jane.doe.from.logistics@warehouse.example.com").Return(&entity.Credentials{}, entity.ErrUserNotFound)
+					repoMock.EXPECT().SignupWithIdentity(gomock.Any(), gomock.Any()).
+						DoAndReturn(func(creds *entity.Credentials, identity entity.Identity) error {
+							creds.ID = userUUID
+							return nil
+						})
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"access_token":"access","refresh_token":"refresh"}`,
+			},
+		},
+		{
+			name: "OIDC login provision user with success",
+			payload: payload{
+				claims: jwt.MapClaims{"sub": "staff-2", "preferred_username": "john"},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().SigninSucceeded("john").Return(nil)
+					authMock.EXPECT().CreateTokens(userUUID, gomock.Any()).Return(tokens, nil)
+				},
+				repoMock: func(repoMock *repomock.MockAuth, issuer string) {
+					repoMock.EXPECT().GetUserByIdentity(issuer, "staff-2").Return(&entity.Credentials{}, entity.ErrUserNotFound)
+					repoMock.EXPECT().GetUserByName("john").Return(&entity.Credentials{}, entity.ErrUserNotFound)
+					repoMock.EXPECT().SignupWithIdentity(gomock.Any(), entity.Identity{Issuer: issuer, Subject: "staff-2", PreferredUsername: "john"}).
+						DoAndReturn(func(creds *entity.Credentials, identity entity.Identity) error {
+							creds.ID = userUUID
+							return nil
+						})
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"access_token":"access","refresh_token":"refresh"}`,
+			},
+		},
+		{
+			name: "OIDC login user with two-factor authentication gets challenge with success",
+			payload: payload{
+				claims: staffClaims,
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().CreateChallenge(userUUID).Return("challenge", nil)
+				},
+				repoMock: func(repoMock *repomock.MockAuth, issuer string) {
+					repoMock.EXPECT().GetUserByIdentity(issuer, "staff-1").Return(&entity.Credentials{ID: userUUID, Username: "jane", TOTPEnabled: true}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"challenge_token":"challenge"}`,
+			},
+		},
+		{
+			name: "OIDC login existing user with unverified email with fail",
+			payload: payload{
+				claims:   jwt.MapClaims{"sub": "staff-3", "preferred_username": "jane"},
+				authMock: func(authMock *authmock.MockAuth) {},
+				repoMock: func(repoMock *repomock.MockAuth, issuer string) {
+					repoMock.EXPECT().GetUserByIdentity(issuer, "staff-3").Return(&entity.Credentials{}, entity.ErrUserNotFound)
+					repoMock.EXPECT().GetUserByName("jane").Return(&entity.Credentials{ID: userUUID, Username: "jane"}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"user with current name is already exist"}`,
+			},
+		},
+		{
+			name: "OIDC login without username with fail",
+			payload: payload{
+				claims:   jwt.MapClaims{"sub": "staff-4"},
+				authMock: func(authMock *authmock.MockAuth) {},
+				repoMock: func(repoMock *repomock.MockAuth, issuer string) {
+					repoMock.EXPECT().GetUserByIdentity(issuer, "staff-4").Return(&entity.Credentials{}, entity.ErrUserNotFound)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"identity provider didn't return a usable username"}`,
+			},
+		},
+		{
+			name: "OIDC login expired id token with fail",
+			payload: payload{
+				claims:   jwt.MapClaims{"sub": "staff-1", "exp": time.Now().Add(-time.Minute).Unix()},
+				authMock: func(authMock *authmock.MockAuth) {},
+				repoMock: func(repoMock *repomock.MockAuth, issuer string) {},
+			},
+			expected: expected{
+				code: http.StatusUnauthorized,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			var stored entity.OIDCState
+			auth := authmock.NewMockAuth(mockCtrl)
+			auth.EXPECT().CreateOIDCState(gomock.Any()).DoAndReturn(func(state entity.OIDCState) (string, error) {
+				stored = state
+				return "state", nil
+			})
+			auth.EXPECT().ConsumeOIDCState("state").DoAndReturn(func(token string) (entity.OIDCState, error) {
+				return stored, nil
+			})
+			test.payload.authMock(auth)
+
+			authRepo := repomock.NewMockAuth(mockCtrl)
+			test.payload.repoMock(authRepo, fake.Issuer())
+
+			provider := oidc.New(config.OIDC{IssuerURL: fake.Issuer(), ClientID: "client", ClientSecret: "secret",
+				RedirectURL: "https://shop.example.com/v1/oidc/callback"}, fake.Client())
+
+			auh := NewAuthHandler(&config.Config{}, logger, authRepo, auth,
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+				repomock.NewMockTwoFactor(mockCtrl), nil, provider, anyAuditor(mockCtrl))
+
+			req, _ := http.NewRequest(http.MethodGet, "/v1/oidc/login", nil)
+			rw := httptest.NewRecorder()
+			auh.OIDCLogin(rw, req)
+			assert.Equal(t, http.StatusFound, rw.Code)
+
+			code, state, err := fake.Authorize(rw.Header().Get("Location"), test.payload.claims)
+			assert.Nil(t, err)
+
+			req, _ = http.NewRequest(http.MethodGet, "/v1/oidc/callback?"+url.Values{"code": {code}, "state": {state}}.Encode(), nil)
+			rw = httptest.NewRecorder()
+			auh.OIDCCallback(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			if test.expected.body != "" {
+				assert.Equal(t, test.expected.body, rw.Body.String())
+			}
+		})
+	}
+}
+
+func TestOIDCLink(t *testing.T) {
+	type payload struct {
+		claims   jwt.MapClaims
+		authMock func(authMock *authmock.MockAuth)
+		repoMock func(repoMock *repomock.MockAuth, issuer string)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	fake, err := oidctest.NewProvider("client", "secret")
+	if err != nil {
+		t.Fatal("failed to start fake provider")
+	}
+	defer fake.Close()
+
+	userUUID := uuid.New()
+	staffClaims := jwt.MapClaims{"sub": "staff-1", "email": "jane@example.com", "email_verified": true}
+	staffIdentity := func(issuer string) entity.Identity {
+		return entity.Identity{Issuer: issuer, Subject: "staff-1", Email: "jane@example.com", EmailVerified: true}
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "OIDC link identity to signed in user with success",
+			payload: payload{
+				claims: staffClaims,
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().SigninSucceeded("jane").Return(nil)
+					authMock.EXPECT().CreateTokens(userUUID, gomock.Any()).Return(&entity.Tokens{AccessToken: "access", RefreshToken: "refresh"}, nil)
+				},
+				repoMock: func(repoMock *repomock.MockAuth, issuer string) {
+					repoMock.EXPECT().LinkIdentity(userUUID, staffIdentity(issuer)).Return(nil)
+					repoMock.EXPECT().GetUserByID(userUUID).Return(&entity.Credentials{ID: userUUID, Username: "jane"}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"access_token":"access","refresh_token":"refresh"}`,
+			},
+		},
+		{
+			name: "OIDC link identity with other email with fail",
+			payload: payload{
+				claims:   staffClaims,
+				authMock: func(authMock *authmock.MockAuth) {},
+				repoMock: func(repoMock *repomock.MockAuth, issuer string) {
+					repoMock.EXPECT().LinkIdentity(userUUID, staffIdentity(issuer)).Return(entity.ErrIdentityEmailMismatch)
+				},
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"verified email of identity provider account doesn't match email of user"}`,
+			},
+		},
+		{
+			name: "OIDC link identity with unverified email with fail",
+			payload: payload{
+				claims:   jwt.MapClaims{"sub": "staff-1", "email": "jane@example.com"},
+				authMock: func(authMock *authmock.MockAuth) {},
+				repoMock: func(repoMock *repomock.MockAuth, issuer string) {},
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"verified email of identity provider account doesn't match email of user"}`,
+			},
+		},
+		{
+			name: "OIDC link identity linked to other user with fail",
+			payload: payload{
+				claims:   staffClaims,
+				authMock: func(authMock *authmock.MockAuth) {},
+				repoMock: func(repoMock *repomock.MockAuth, issuer string) {
+					repoMock.EXPECT().LinkIdentity(userUUID, staffIdentity(issuer)).Return(entity.ErrIdentityAlreadyLinked)
+				},
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"identity provider account is already linked to another user"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			var stored entity.OIDCState
+			auth := authmock.NewMockAuth(mockCtrl)
+			auth.EXPECT().CreateOIDCState(gomock.Any()).DoAndReturn(func(state entity.OIDCState) (string, error) {
+				stored = state
+				return "state", nil
+			})
+			auth.EXPECT().ConsumeOIDCState("state").DoAndReturn(func(token string) (entity.OIDCState, error) {
+				return stored, nil
+			})
+			test.payload.authMock(auth)
+
+			authRepo := repomock.NewMockAuth(mockCtrl)
+			test.payload.repoMock(authRepo, fake.Issuer())
+
+			provider := oidc.New(config.OIDC{IssuerURL: fake.Issuer(), ClientID: "client", ClientSecret: "secret",
+				RedirectURL: "https://shop.example.com/v1/oidc/callback"}, fake.Client())
+
+			auh := NewAuthHandler(&config.Config{}, logger, authRepo, auth,
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+				repomock.NewMockTwoFactor(mockCtrl), nil, provider, anyAuditor(mockCtrl))
+
+			req, _ := http.NewRequest(http.MethodPost, "/v1/oidc/link", nil)
+			ctx := context.WithValue(req.Context(), middleware.UserUUID, userUUID.String())
+			rw := httptest.NewRecorder()
+			auh.OIDCLink(rw, req.WithContext(ctx))
+			assert.Equal(t, http.StatusOK, rw.Code)
+			assert.Equal(t, &userUUID, stored.UserID)
+
+			link := entity.OIDCLink{}
+			assert.Nil(t, json.NewDecoder(rw.Body).Decode(&link))
+			code, state, err := fake.Authorize(link.URL, test.payload.claims)
+			assert.Nil(t, err)
+
+			req, _ = http.NewRequest(http.MethodGet, "/v1/oidc/callback?"+url.Values{"code": {code}, "state": {state}}.Encode(), nil)
+			rw = httptest.NewRecorder()
+			auh.OIDCCallback(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestOIDCCallback(t *testing.T) {
+	type payload struct {
+		query    url.Values
+		authMock func(authMock *authmock.MockAuth)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "OIDC callback denied by provider with fail",
+			payload: payload{
+				query:    url.Values{"error": {"access_denied"}, "state": {"state"}},
+				authMock: func(authMock *authmock.MockAuth) {},
+			},
+			expected: expected{
+				code: http.StatusUnauthorized,
+				body: `{"error":"identity provider denied login: access_denied"}`,
+			},
+		},
+		{
+			name: "OIDC callback invalid state with fail",
+			payload: payload{
+				query: url.Values{"code": {"code"}, "state": {"state"}},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().ConsumeOIDCState("state").Return(entity.OIDCState{}, authentication.ErrOIDCStateInvalid)
+				},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"oidc state is invalid or expired"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			auth := authmock.NewMockAuth(mockCtrl)
+			test.payload.authMock(auth)
+
+			auh := NewAuthHandler(&config.Config{}, logger, repomock.NewMockAuth(mockCtrl), auth,
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+				repomock.NewMockTwoFactor(mockCtrl), nil, oidc.New(config.OIDC{IssuerURL: "https://idp.example.com"}, nil), anyAuditor(mockCtrl))
+
+			req, _ := http.NewRequest(http.MethodGet, "/v1/oidc/callback?"+test.payload.query.Encode(), nil)
+			rw := httptest.NewRecorder()
+			auh.OIDCCallback(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
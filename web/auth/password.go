@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/mshto/fruit-store/audit"
+	"github.com/mshto/fruit-store/authentication"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/notifier"
+	"github.com/mshto/fruit-store/password"
+	"github.com/mshto/fruit-store/web/common/response"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+var errPasswordsNotEqual = errors.New("passwords aren't equal")
+
+// ChangePassword change password of user and revoke all other user sessions
+func (ah authHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := ah.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+	userID := userUUID.String()
+
+	change := entity.PasswordChange{}
+	err := json.NewDecoder(r.Body).Decode(&change)
+	if err != nil {
+		ah.log.Errorf("failed to decode password change, user: %v, error: %v", userID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if change.Password != change.PasswordRepeat {
+		ah.log.Errorf("passwords aren't equal, user: %v", userID)
+		response.RenderFailedResponse(w, http.StatusBadRequest, errPasswordsNotEqual)
+		return
+	}
+
+	storedUser, err := ah.authRepo.GetUserByID(userUUID)
+	if err == entity.ErrUserNotFound {
+		ah.log.Errorf("failed to get user by id, user: %v, error: %v", userID, err)
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		ah.log.Errorf("failed to get user by id, user: %v, error: %v", userID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err = bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(change.CurrentPassword)); err != nil {
+		ah.log.Errorf("failed to compare hash and password, user: %v, error: %v", userID, err)
+		ah.auditor.Record(r, audit.Event(entity.AuditPasswordChange, userID, entity.ErrInvalidCredentials))
+		response.RenderResponse(w, http.StatusUnauthorized, response.EmptyResp{})
+		return
+	}
+	if change.Password == change.CurrentPassword {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, password.ErrSameAsOld)
+		return
+	}
+	err = ah.policy.Validate(change.Password)
+	if err != nil {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if !ah.updatePassword(w, userUUID, change.Password) {
+		return
+	}
+
+	sessionID, _ := r.Context().Value(middleware.SessionID).(string)
+	err = ah.auth.RevokeOtherSessions(userID, sessionID)
+	if err != nil {
+		ah.log.Errorf("failed to revoke other sessions, user: %v, error: %v", userID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ah.auditor.Record(r, audit.Event(entity.AuditPasswordChange, userID, nil))
+	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+}
+
+// ForgotPassword send password reset token to user.
+// Response doesn't depend on whether user exists, so it can't be used to enumerate users.
+func (ah authHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	forgot := entity.PasswordForgot{}
+	err := json.NewDecoder(r.Body).Decode(&forgot)
+	if err != nil {
+		ah.log.Errorf("failed to decode password forgot, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	storedUser, err := ah.authRepo.GetUserByName(forgot.Username)
+	if err == entity.ErrUserNotFound {
+		ah.log.Infof("password reset requested for unknown user, user name: %v", forgot.Username)
+		response.RenderResponse(w, http.StatusAccepted, response.EmptyResp{})
+		return
+	}
+	if err != nil {
+		ah.log.Errorf("failed to get user by name, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	token, err := ah.auth.CreateResetToken(storedUser.ID)
+	if err != nil {
+		ah.log.Errorf("failed to create reset token, user: %v, error: %v", storedUser.ID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = ah.ntf.Send(notifier.Message{
+		To:      storedUser.Username,
+		Subject: "Password reset",
+		Body:    fmt.Sprintf("Use the following token to reset your password: %s", token),
+	})
+	if err != nil {
+		ah.log.Errorf("failed to send reset token, user: %v, error: %v", storedUser.ID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusAccepted, response.EmptyResp{})
+}
+
+// ResetPassword set a new password by reset token and revoke all user sessions
+func (ah authHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	reset := entity.PasswordReset{}
+	err := json.NewDecoder(r.Body).Decode(&reset)
+	if err != nil {
+		ah.log.Errorf("failed to decode password reset, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if reset.Password != reset.PasswordRepeat {
+		ah.log.Errorf("passwords aren't equal")
+		response.RenderFailedResponse(w, http.StatusBadRequest, errPasswordsNotEqual)
+		return
+	}
+
+	// token is kept when the new password is rejected, so user can try again
+	err = ah.policy.Validate(reset.Password)
+	if err != nil {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	userUUID, err := ah.auth.ConsumeResetToken(reset.Token)
+	if err == authentication.ErrResetTokenInvalid {
+		ah.log.Errorf("failed to consume reset token, error: %v", err)
+		ah.auditor.Record(r, audit.Event(entity.AuditPasswordReset, "", err))
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		ah.log.Errorf("failed to consume reset token, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !ah.updatePassword(w, userUUID, reset.Password) {
+		return
+	}
+
+	err = ah.auth.RevokeSessions(userUUID.String())
+	if err != nil {
+		ah.log.Errorf("failed to revoke sessions, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ah.auditor.Record(r, audit.Event(entity.AuditPasswordReset, userUUID.String(), nil))
+	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+}
+
+// updatePassword store a new password, failed response is rendered when false is returned
+func (ah authHandler) updatePassword(w http.ResponseWriter, userUUID uuid.UUID, newPassword string) bool {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), 8)
+	if err != nil {
+		ah.log.Errorf("failed to generate from password, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return false
+	}
+
+	err = ah.authRepo.UpdatePassword(userUUID, string(hashedPassword))
+	if err == entity.ErrUserNotFound {
+		ah.log.Errorf("failed to update password, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return false
+	}
+	if err != nil {
+		ah.log.Errorf("failed to update password, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return false
+	}
+	return true
+}
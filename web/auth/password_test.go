@@ -0,0 +1,505 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/mshto/fruit-store/authentication"
+	authmock "github.com/mshto/fruit-store/authentication/mock"
+	billmock "github.com/mshto/fruit-store/bill/mock"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/notifier"
+	notifiermock "github.com/mshto/fruit-store/notifier/mock"
+	"github.com/mshto/fruit-store/password"
+	passwordmock "github.com/mshto/fruit-store/password/mock"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+var passwordUserUUID = uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+func TestChangePassword(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("current"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal("failed to generate password hash")
+	}
+	storedUser := &entity.Credentials{ID: passwordUserUUID, Username: "test", Password: string(hash)}
+
+	userCtx := func(req *http.Request) context.Context {
+		ctx := context.WithValue(req.Context(), middleware.UserUUID, passwordUserUUID.String())
+		ctx = context.WithValue(ctx, middleware.SessionID, "current")
+		return ctx
+	}
+
+	type payload struct {
+		body       []byte
+		repoMock   func(repoMock *repomock.MockAuth)
+		authMock   func(authMock *authmock.MockAuth)
+		policyMock func(policyMock *passwordmock.MockPolicy)
+		ctxMock    func(req *http.Request) context.Context
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Change password with success",
+			payload: payload{
+				body: []byte(`{"currentPassword":"current","password":"new-password","passwordRepeat":"new-password"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().GetUserByID(passwordUserUUID).Return(storedUser, nil)
+					repoMock.EXPECT().UpdatePassword(passwordUserUUID, gomock.Any()).Return(nil)
+				},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().RevokeOtherSessions(passwordUserUUID.String(), "current").Return(nil)
+				},
+				policyMock: func(policyMock *passwordmock.MockPolicy) {
+					policyMock.EXPECT().Validate("new-password").Return(nil)
+				},
+				ctxMock: userCtx,
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Change password invalid userUUID with fail",
+			payload: payload{
+				body:       []byte(`{}`),
+				repoMock:   func(repoMock *repomock.MockAuth) {},
+				authMock:   func(authMock *authmock.MockAuth) {},
+				policyMock: func(policyMock *passwordmock.MockPolicy) {},
+				ctxMock: func(req *http.Request) context.Context {
+					return context.Background()
+				},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"userUUID not found"}`,
+			},
+		},
+		{
+			name: "Change password not equal passwords with fail",
+			payload: payload{
+				body:       []byte(`{"currentPassword":"current","password":"new-password","passwordRepeat":"other"}`),
+				repoMock:   func(repoMock *repomock.MockAuth) {},
+				authMock:   func(authMock *authmock.MockAuth) {},
+				policyMock: func(policyMock *passwordmock.MockPolicy) {},
+				ctxMock:    userCtx,
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"passwords aren't equal"}`,
+			},
+		},
+		{
+			name: "Change password wrong current password with fail",
+			payload: payload{
+				body: []byte(`{"currentPassword":"wrong","password":"new-password","passwordRepeat":"new-password"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().GetUserByID(passwordUserUUID).Return(storedUser, nil)
+				},
+				authMock:   func(authMock *authmock.MockAuth) {},
+				policyMock: func(policyMock *passwordmock.MockPolicy) {},
+				ctxMock:    userCtx,
+			},
+			expected: expected{
+				code: http.StatusUnauthorized,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Change password same password with fail",
+			payload: payload{
+				body: []byte(`{"currentPassword":"current","password":"current","passwordRepeat":"current"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().GetUserByID(passwordUserUUID).Return(storedUser, nil)
+				},
+				authMock:   func(authMock *authmock.MockAuth) {},
+				policyMock: func(policyMock *passwordmock.MockPolicy) {},
+				ctxMock:    userCtx,
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"new password must differ from the current one"}`,
+			},
+		},
+		{
+			name: "Change password weak password with fail",
+			payload: payload{
+				body: []byte(`{"currentPassword":"current","password":"short","passwordRepeat":"short"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().GetUserByID(passwordUserUUID).Return(storedUser, nil)
+				},
+				authMock: func(authMock *authmock.MockAuth) {},
+				policyMock: func(policyMock *passwordmock.MockPolicy) {
+					policyMock.EXPECT().Validate("short").Return(password.ErrTooShort)
+				},
+				ctxMock: userCtx,
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"password is too short"}`,
+			},
+		},
+		{
+			name: "Change password GetUserByID error with fail",
+			payload: payload{
+				body: []byte(`{"currentPassword":"current","password":"new-password","passwordRepeat":"new-password"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().GetUserByID(passwordUserUUID).Return(nil, entity.ErrUserNotFound)
+				},
+				authMock:   func(authMock *authmock.MockAuth) {},
+				policyMock: func(policyMock *passwordmock.MockPolicy) {},
+				ctxMock:    userCtx,
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"user not found"}`,
+			},
+		},
+		{
+			name: "Change password RevokeOtherSessions error with fail",
+			payload: payload{
+				body: []byte(`{"currentPassword":"current","password":"new-password","passwordRepeat":"new-password"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().GetUserByID(passwordUserUUID).Return(storedUser, nil)
+					repoMock.EXPECT().UpdatePassword(passwordUserUUID, gomock.Any()).Return(nil)
+				},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().RevokeOtherSessions(passwordUserUUID.String(), "current").Return(errors.New("error"))
+				},
+				policyMock: func(policyMock *passwordmock.MockPolicy) {
+					policyMock.EXPECT().Validate("new-password").Return(nil)
+				},
+				ctxMock: userCtx,
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			authRepo := repomock.NewMockAuth(mockCtrl)
+			test.payload.repoMock(authRepo)
+
+			auth := authmock.NewMockAuth(mockCtrl)
+			test.payload.authMock(auth)
+
+			policy := passwordmock.NewMockPolicy(mockCtrl)
+			test.payload.policyMock(policy)
+
+			req, err := http.NewRequest(http.MethodPost, "url", bytes.NewBuffer(test.payload.body))
+			if err != nil {
+				t.Error("failed to create request")
+			}
+			rw := httptest.NewRecorder()
+
+			auh := NewAuthHandler(&config.Config{}, logger, authRepo, auth,
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				policy, notifiermock.NewMockSender(mockCtrl),
+				repomock.NewMockTwoFactor(mockCtrl), nil, nil, anyAuditor(mockCtrl))
+			auh.ChangePassword(rw, req.WithContext(test.payload.ctxMock(req)))
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestForgotPassword(t *testing.T) {
+	storedUser := &entity.Credentials{ID: passwordUserUUID, Username: "test"}
+
+	type payload struct {
+		body         []byte
+		repoMock     func(repoMock *repomock.MockAuth)
+		authMock     func(authMock *authmock.MockAuth)
+		notifierMock func(notifierMock *notifiermock.MockSender)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Forgot password with success",
+			payload: payload{
+				body: []byte(`{"username":"test"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().GetUserByName("test").Return(storedUser, nil)
+				},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().CreateResetToken(passwordUserUUID).Return("token", nil)
+				},
+				notifierMock: func(notifierMock *notifiermock.MockSender) {
+					notifierMock.EXPECT().Send(notifier.Message{
+						To:      "test",
+						Subject: "Password reset",
+						Body:    "Use the following token to reset your password: token",
+					}).Return(nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusAccepted,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Forgot password unknown user with success",
+			payload: payload{
+				body: []byte(`{"username":"unknown"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().GetUserByName("unknown").Return(nil, entity.ErrUserNotFound)
+				},
+				authMock:     func(authMock *authmock.MockAuth) {},
+				notifierMock: func(notifierMock *notifiermock.MockSender) {},
+			},
+			expected: expected{
+				code: http.StatusAccepted,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Forgot password invalid body with fail",
+			payload: payload{
+				body:         []byte(`invalid`),
+				repoMock:     func(repoMock *repomock.MockAuth) {},
+				authMock:     func(authMock *authmock.MockAuth) {},
+				notifierMock: func(notifierMock *notifiermock.MockSender) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid character 'i' looking for beginning of value"}`,
+			},
+		},
+		{
+			name: "Forgot password Send error with fail",
+			payload: payload{
+				body: []byte(`{"username":"test"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().GetUserByName("test").Return(storedUser, nil)
+				},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().CreateResetToken(passwordUserUUID).Return("token", nil)
+				},
+				notifierMock: func(notifierMock *notifiermock.MockSender) {
+					notifierMock.EXPECT().Send(gomock.Any()).Return(errors.New("error"))
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			authRepo := repomock.NewMockAuth(mockCtrl)
+			test.payload.repoMock(authRepo)
+
+			auth := authmock.NewMockAuth(mockCtrl)
+			test.payload.authMock(auth)
+
+			ntf := notifiermock.NewMockSender(mockCtrl)
+			test.payload.notifierMock(ntf)
+
+			req, err := http.NewRequest(http.MethodPost, "url", bytes.NewBuffer(test.payload.body))
+			if err != nil {
+				t.Error("failed to create request")
+			}
+			rw := httptest.NewRecorder()
+
+			auh := NewAuthHandler(&config.Config{}, logger, authRepo, auth,
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				passwordmock.NewMockPolicy(mockCtrl), ntf,
+				repomock.NewMockTwoFactor(mockCtrl), nil, nil, anyAuditor(mockCtrl))
+			auh.ForgotPassword(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	type payload struct {
+		body       []byte
+		repoMock   func(repoMock *repomock.MockAuth)
+		authMock   func(authMock *authmock.MockAuth)
+		policyMock func(policyMock *passwordmock.MockPolicy)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Reset password with success",
+			payload: payload{
+				body: []byte(`{"token":"token","password":"new-password","passwordRepeat":"new-password"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().UpdatePassword(passwordUserUUID, gomock.Any()).Return(nil)
+				},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().ConsumeResetToken("token").Return(passwordUserUUID, nil)
+					authMock.EXPECT().RevokeSessions(passwordUserUUID.String()).Return(nil)
+				},
+				policyMock: func(policyMock *passwordmock.MockPolicy) {
+					policyMock.EXPECT().Validate("new-password").Return(nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Reset password weak password keeps token with fail",
+			payload: payload{
+				body:     []byte(`{"token":"token","password":"short","passwordRepeat":"short"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {},
+				authMock: func(authMock *authmock.MockAuth) {},
+				policyMock: func(policyMock *passwordmock.MockPolicy) {
+					policyMock.EXPECT().Validate("short").Return(password.ErrTooShort)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"password is too short"}`,
+			},
+		},
+		{
+			name: "Reset password not equal passwords with fail",
+			payload: payload{
+				body:       []byte(`{"token":"token","password":"new-password","passwordRepeat":"other"}`),
+				repoMock:   func(repoMock *repomock.MockAuth) {},
+				authMock:   func(authMock *authmock.MockAuth) {},
+				policyMock: func(policyMock *passwordmock.MockPolicy) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"passwords aren't equal"}`,
+			},
+		},
+		{
+			name: "Reset password invalid token with fail",
+			payload: payload{
+				body:     []byte(`{"token":"token","password":"new-password","passwordRepeat":"new-password"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().ConsumeResetToken("token").Return(uuid.Nil, authentication.ErrResetTokenInvalid)
+				},
+				policyMock: func(policyMock *passwordmock.MockPolicy) {
+					policyMock.EXPECT().Validate("new-password").Return(nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"reset token is invalid or expired"}`,
+			},
+		},
+		{
+			name: "Reset password UpdatePassword error with fail",
+			payload: payload{
+				body: []byte(`{"token":"token","password":"new-password","passwordRepeat":"new-password"}`),
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().UpdatePassword(passwordUserUUID, gomock.Any()).Return(errors.New("error"))
+				},
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().ConsumeResetToken("token").Return(passwordUserUUID, nil)
+				},
+				policyMock: func(policyMock *passwordmock.MockPolicy) {
+					policyMock.EXPECT().Validate("new-password").Return(nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			authRepo := repomock.NewMockAuth(mockCtrl)
+			test.payload.repoMock(authRepo)
+
+			auth := authmock.NewMockAuth(mockCtrl)
+			test.payload.authMock(auth)
+
+			policy := passwordmock.NewMockPolicy(mockCtrl)
+			test.payload.policyMock(policy)
+
+			req, err := http.NewRequest(http.MethodPost, "url", bytes.NewBuffer(test.payload.body))
+			if err != nil {
+				t.Error("failed to create request")
+			}
+			rw := httptest.NewRecorder()
+
+			auh := NewAuthHandler(&config.Config{}, logger, authRepo, auth,
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				policy, notifiermock.NewMockSender(mockCtrl),
+				repomock.NewMockTwoFactor(mockCtrl), nil, nil, anyAuditor(mockCtrl))
+			auh.ResetPassword(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/mshto/fruit-store/audit"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/web/common/response"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+// GetSessions retrieves active sessions of user
+func (ah authHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userUUID, ok := ctx.Value(middleware.UserUUID).(string)
+	if !ok {
+		ah.log.Errorf("failed to get UserUUID")
+		response.RenderFailedResponse(w, http.StatusBadRequest, errors.New("userUUID not found"))
+		return
+	}
+
+	sessions, err := ah.auth.GetSessions(userUUID)
+	if err != nil {
+		ah.log.Errorf("failed to get sessions, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	sessionID, _ := ctx.Value(middleware.SessionID).(string)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == sessionID
+	}
+
+	response.RenderResponse(w, http.StatusOK, sessions)
+}
+
+// RevokeSession revoke one session of user
+func (ah authHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userUUID, ok := ctx.Value(middleware.UserUUID).(string)
+	if !ok {
+		ah.log.Errorf("failed to get UserUUID")
+		response.RenderFailedResponse(w, http.StatusBadRequest, errors.New("userUUID not found"))
+		return
+	}
+
+	err := ah.auth.RevokeSession(userUUID, mux.Vars(r)["sessionID"])
+	if err == entity.ErrSessionNotFound {
+		ah.log.Errorf("failed to revoke session, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		ah.log.Errorf("failed to revoke session, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+}
+
+// LogoutAll revoke all sessions of user
+func (ah authHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userUUID, ok := ctx.Value(middleware.UserUUID).(string)
+	if !ok {
+		ah.log.Errorf("failed to get UserUUID")
+		response.RenderFailedResponse(w, http.StatusBadRequest, errors.New("userUUID not found"))
+		return
+	}
+
+	err := ah.auth.RevokeSessions(userUUID)
+	if err != nil {
+		ah.log.Errorf("failed to revoke sessions, user: %v, error: %v", userUUID, err)
+		ah.auditor.Record(r, audit.Event(entity.AuditLogoutAll, userUUID, err))
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ah.auditor.Record(r, audit.Event(entity.AuditLogoutAll, userUUID, nil))
+	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+}
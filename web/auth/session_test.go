@@ -0,0 +1,346 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	authmock "github.com/mshto/fruit-store/authentication/mock"
+	billmock "github.com/mshto/fruit-store/bill/mock"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	notifiermock "github.com/mshto/fruit-store/notifier/mock"
+	passwordmock "github.com/mshto/fruit-store/password/mock"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+var createdAt = time.Date(2020, 11, 20, 10, 0, 0, 0, time.UTC)
+
+func TestGetSessions(t *testing.T) {
+	type payload struct {
+		authMock func(authMock *authmock.MockAuth)
+		ctxMock  func(req *http.Request) context.Context
+	}
+	type expected struct {
+		code int
+		body string
+	}
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Get sessions with success",
+			payload: payload{
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().GetSessions("userUUID").Return([]entity.Session{{ID: "current", CreatedAt: createdAt, LastUsedAt: createdAt}, {ID: "other", UserAgent: "agent", IP: "10.0.0.1", CreatedAt: createdAt, LastUsedAt: createdAt}}, nil)
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "userUUID")
+					ctx = context.WithValue(ctx, middleware.SessionID, "current")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `[{"id":"current","userAgent":"","ip":"","createdAt":"2020-11-20T10:00:00Z","lastUsedAt":"2020-11-20T10:00:00Z","current":true},{"id":"other","userAgent":"agent","ip":"10.0.0.1","createdAt":"2020-11-20T10:00:00Z","lastUsedAt":"2020-11-20T10:00:00Z","current":false}]`,
+			},
+		},
+		{
+			name: "Get sessions invalid userUUID with fail",
+			payload: payload{
+				authMock: func(authMock *authmock.MockAuth) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					return context.Background()
+				},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"userUUID not found"}`,
+			},
+		},
+		{
+			name: "Get sessions GetSessions error with fail",
+			payload: payload{
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().GetSessions("userUUID").Return(nil, errors.New("error"))
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "userUUID")
+					ctx = context.WithValue(ctx, middleware.SessionID, "current")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			auth := authmock.NewMockAuth(mockCtrl)
+			test.payload.authMock(auth)
+
+			req, err := http.NewRequest(http.MethodGet, "/v1/sessions", nil)
+			if err != nil {
+				t.Error("failed to create request")
+			}
+
+			ctx := test.payload.ctxMock(req)
+
+			rw := httptest.NewRecorder()
+
+			auh := NewAuthHandler(&config.Config{}, logger, repomock.NewMockAuth(mockCtrl), auth,
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+				repomock.NewMockTwoFactor(mockCtrl), nil, nil, anyAuditor(mockCtrl))
+
+			router := mux.NewRouter()
+			router.HandleFunc("/v1/sessions", auh.GetSessions)
+			router.ServeHTTP(rw, req.WithContext(ctx))
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	type payload struct {
+		authMock func(authMock *authmock.MockAuth)
+		ctxMock  func(req *http.Request) context.Context
+	}
+	type expected struct {
+		code int
+		body string
+	}
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Revoke session with success",
+			payload: payload{
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().RevokeSession("userUUID", "other").Return(nil)
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "userUUID")
+					ctx = context.WithValue(ctx, middleware.SessionID, "current")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Revoke session invalid userUUID with fail",
+			payload: payload{
+				authMock: func(authMock *authmock.MockAuth) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					return context.Background()
+				},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"userUUID not found"}`,
+			},
+		},
+		{
+			name: "Revoke session unknown session with fail",
+			payload: payload{
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().RevokeSession("userUUID", "other").Return(entity.ErrSessionNotFound)
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "userUUID")
+					ctx = context.WithValue(ctx, middleware.SessionID, "current")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"session not found"}`,
+			},
+		},
+		{
+			name: "Revoke session RevokeSession error with fail",
+			payload: payload{
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().RevokeSession("userUUID", "other").Return(errors.New("error"))
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "userUUID")
+					ctx = context.WithValue(ctx, middleware.SessionID, "current")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			auth := authmock.NewMockAuth(mockCtrl)
+			test.payload.authMock(auth)
+
+			req, err := http.NewRequest(http.MethodDelete, "/v1/sessions/other", nil)
+			if err != nil {
+				t.Error("failed to create request")
+			}
+
+			ctx := test.payload.ctxMock(req)
+
+			rw := httptest.NewRecorder()
+
+			auh := NewAuthHandler(&config.Config{}, logger, repomock.NewMockAuth(mockCtrl), auth,
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+				repomock.NewMockTwoFactor(mockCtrl), nil, nil, anyAuditor(mockCtrl))
+
+			router := mux.NewRouter()
+			router.HandleFunc("/v1/sessions/{sessionID}", auh.RevokeSession)
+			router.ServeHTTP(rw, req.WithContext(ctx))
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestLogoutAll(t *testing.T) {
+	type payload struct {
+		authMock func(authMock *authmock.MockAuth)
+		ctxMock  func(req *http.Request) context.Context
+	}
+	type expected struct {
+		code int
+		body string
+	}
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Logout all with success",
+			payload: payload{
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().RevokeSessions("userUUID").Return(nil)
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "userUUID")
+					ctx = context.WithValue(ctx, middleware.SessionID, "current")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Logout all invalid userUUID with fail",
+			payload: payload{
+				authMock: func(authMock *authmock.MockAuth) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					return context.Background()
+				},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"userUUID not found"}`,
+			},
+		},
+		{
+			name: "Logout all RevokeSessions error with fail",
+			payload: payload{
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().RevokeSessions("userUUID").Return(errors.New("error"))
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "userUUID")
+					ctx = context.WithValue(ctx, middleware.SessionID, "current")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			auth := authmock.NewMockAuth(mockCtrl)
+			test.payload.authMock(auth)
+
+			req, err := http.NewRequest(http.MethodPost, "/v1/logout-all", nil)
+			if err != nil {
+				t.Error("failed to create request")
+			}
+
+			ctx := test.payload.ctxMock(req)
+
+			rw := httptest.NewRecorder()
+
+			auh := NewAuthHandler(&config.Config{}, logger, repomock.NewMockAuth(mockCtrl), auth,
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+				repomock.NewMockTwoFactor(mockCtrl), nil, nil, anyAuditor(mockCtrl))
+
+			router := mux.NewRouter()
+			router.HandleFunc("/v1/logout-all", auh.LogoutAll)
+			router.ServeHTTP(rw, req.WithContext(ctx))
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
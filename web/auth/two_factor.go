@@ -0,0 +1,256 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mshto/fruit-store/audit"
+	"github.com/mshto/fruit-store/authentication"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/totp"
+	"github.com/mshto/fruit-store/web/common/request"
+	"github.com/mshto/fruit-store/web/common/response"
+)
+
+const recoveryCodesCount = 10
+
+var totpCodeRegexp = regexp.MustCompile(`^[0-9]{6}$`)
+
+// SetupTwoFactor generate a new TOTP secret of user, it is used only after confirmation
+func (ah authHandler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if ah.cipher == nil {
+		response.RenderFailedResponse(w, http.StatusNotFound, entity.ErrTOTPDisabled)
+		return
+	}
+
+	userUUID, ok := ah.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	storedUser, err := ah.authRepo.GetUserByID(userUUID)
+	if err != nil {
+		ah.log.Errorf("failed to get user by id, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if storedUser.TOTPEnabled {
+		response.RenderFailedResponse(w, http.StatusConflict, entity.ErrTOTPAlreadyEnabled)
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		ah.log.Errorf("failed to generate totp secret, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	encrypted, err := ah.cipher.Encrypt(secret)
+	if err != nil {
+		ah.log.Errorf("failed to encrypt totp secret, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = ah.twoFactorRepo.SetTOTPSecret(userUUID, encrypted)
+	if err == entity.ErrTOTPAlreadyEnabled {
+		response.RenderFailedResponse(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		ah.log.Errorf("failed to set totp secret, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, entity.TOTPSetup{
+		Secret: secret,
+		URI:    totp.URI(ah.cfg.Auth.TOTPIssuer, storedUser.Username, secret),
+	})
+}
+
+// ConfirmTwoFactor enable two-factor authentication once user proves the authenticator app works
+func (ah authHandler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if ah.cipher == nil {
+		response.RenderFailedResponse(w, http.StatusNotFound, entity.ErrTOTPDisabled)
+		return
+	}
+
+	userUUID, ok := ah.getUserUUID(w, r)
+	if !ok {
+		return
+	}
+
+	code := entity.TOTPCode{}
+	err := json.NewDecoder(r.Body).Decode(&code)
+	if err != nil {
+		ah.log.Errorf("failed to decode totp code, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	storedTOTP, err := ah.twoFactorRepo.GetTOTP(userUUID)
+	if err != nil {
+		ah.log.Errorf("failed to get totp, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if storedTOTP.Enabled {
+		response.RenderFailedResponse(w, http.StatusConflict, entity.ErrTOTPAlreadyEnabled)
+		return
+	}
+	if storedTOTP.Secret == "" {
+		response.RenderFailedResponse(w, http.StatusBadRequest, entity.ErrTOTPNotSetUp)
+		return
+	}
+
+	err = ah.verifyTOTP(userUUID, storedTOTP.Secret, code.Code)
+	if err == entity.ErrInvalidTOTPCode {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err != nil {
+		ah.log.Errorf("failed to verify totp code, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	codes, err := totp.GenerateRecoveryCodes(recoveryCodesCount)
+	if err != nil {
+		ah.log.Errorf("failed to generate recovery codes, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, totp.HashRecoveryCode(code))
+	}
+
+	err = ah.twoFactorRepo.EnableTOTP(userUUID, hashes)
+	if err == entity.ErrTOTPAlreadyEnabled {
+		response.RenderFailedResponse(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		ah.log.Errorf("failed to enable totp, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, entity.RecoveryCodes{RecoveryCodes: codes})
+}
+
+// SigninTwoFactor complete sign in challenge with TOTP or recovery code and issue tokens.
+// Wrong codes are counted as failed sign in attempts of user.
+func (ah authHandler) SigninTwoFactor(w http.ResponseWriter, r *http.Request) {
+	verify := entity.ChallengeVerify{}
+	err := json.NewDecoder(r.Body).Decode(&verify)
+	if err != nil {
+		ah.log.Errorf("failed to decode challenge, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	userUUID, err := ah.auth.GetChallenge(verify.ChallengeToken)
+	if err == authentication.ErrChallengeInvalid {
+		response.RenderFailedResponse(w, http.StatusUnauthorized, err)
+		return
+	}
+	if err != nil {
+		ah.log.Errorf("failed to get challenge, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	storedUser, err := ah.authRepo.GetUserByID(userUUID)
+	if err != nil {
+		ah.log.Errorf("failed to get user by id, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	client := request.GetClient(r)
+	retryAfter, err := ah.auth.SigninAllowed(storedUser.Username, client.IP)
+	if err != nil {
+		ah.log.Errorf("failed to check sign in attempts, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if retryAfter > 0 {
+		ah.auditor.Record(r, audit.Event(entity.AuditSigninTwoFactor, storedUser.Username, entity.ErrTooManyAttempts))
+		response.SetRetryAfter(w, retryAfter)
+		response.RenderFailedResponse(w, http.StatusTooManyRequests, entity.ErrTooManyAttempts)
+		return
+	}
+
+	err = ah.verifySecondFactor(userUUID, verify.Code)
+	if err == entity.ErrInvalidTOTPCode {
+		ah.log.Errorf("failed to verify second factor, user: %v, ip: %v", userUUID, client.IP)
+		ah.signinFailed(w, r, entity.AuditSigninTwoFactor, storedUser.Username, err)
+		return
+	}
+	if err == entity.ErrTOTPDisabled {
+		response.RenderFailedResponse(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	if err != nil {
+		ah.log.Errorf("failed to verify second factor, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = ah.auth.CompleteChallenge(verify.ChallengeToken)
+	if err == authentication.ErrChallengeInvalid {
+		response.RenderFailedResponse(w, http.StatusUnauthorized, err)
+		return
+	}
+	if err != nil {
+		ah.log.Errorf("failed to complete challenge, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ah.issueTokens(w, r, storedUser, entity.AuditSigninTwoFactor)
+}
+
+// verifySecondFactor check TOTP code or single use recovery code
+func (ah authHandler) verifySecondFactor(userUUID uuid.UUID, code string) error {
+	if !totpCodeRegexp.MatchString(code) {
+		return ah.twoFactorRepo.UseRecoveryCode(userUUID, totp.HashRecoveryCode(code))
+	}
+
+	storedTOTP, err := ah.twoFactorRepo.GetTOTP(userUUID)
+	if err != nil {
+		return err
+	}
+	if !storedTOTP.Enabled {
+		return entity.ErrInvalidTOTPCode
+	}
+	return ah.verifyTOTP(userUUID, storedTOTP.Secret, code)
+}
+
+// verifyTOTP check TOTP code against encrypted secret, every code can be used only once.
+// Recovery codes still work when two-factor authentication isn't configured, TOTP codes can't be verified then.
+func (ah authHandler) verifyTOTP(userUUID uuid.UUID, encryptedSecret, code string) error {
+	if ah.cipher == nil {
+		return entity.ErrTOTPDisabled
+	}
+
+	secret, err := ah.cipher.Decrypt(encryptedSecret)
+	if err != nil {
+		return err
+	}
+
+	step, ok, err := totp.Validate(secret, code, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return entity.ErrInvalidTOTPCode
+	}
+	return ah.twoFactorRepo.UseTOTPStep(userUUID, step)
+}
@@ -0,0 +1,538 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/authentication"
+	authmock "github.com/mshto/fruit-store/authentication/mock"
+	billmock "github.com/mshto/fruit-store/bill/mock"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/encryption"
+	"github.com/mshto/fruit-store/entity"
+	notifiermock "github.com/mshto/fruit-store/notifier/mock"
+	passwordmock "github.com/mshto/fruit-store/password/mock"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/totp"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+const (
+	testEncryptionKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	testTOTPSecret    = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+)
+
+func newTestCipher(t *testing.T) (*encryption.Cipher, string) {
+	cph, err := encryption.New(testEncryptionKey)
+	if err != nil {
+		t.Fatal("failed to init cipher")
+	}
+	encrypted, err := cph.Encrypt(testTOTPSecret)
+	if err != nil {
+		t.Fatal("failed to encrypt secret")
+	}
+	return cph, encrypted
+}
+
+func currentTOTPCode(t *testing.T) string {
+	code, err := totp.Code(testTOTPSecret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal("failed to generate code")
+	}
+	return code
+}
+
+func TestSetupTwoFactor(t *testing.T) {
+	cph, _ := newTestCipher(t)
+
+	type payload struct {
+		repoMock      func(repoMock *repomock.MockAuth)
+		twoFactorMock func(twoFactorMock *repomock.MockTwoFactor)
+		ctxMock       func(req *http.Request) context.Context
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	userCtx := func(req *http.Request) context.Context {
+		return context.WithValue(req.Context(), middleware.UserUUID, passwordUserUUID.String())
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Setup two-factor already enabled with fail",
+			payload: payload{
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().GetUserByID(passwordUserUUID).Return(&entity.Credentials{Username: "test", TOTPEnabled: true}, nil)
+				},
+				twoFactorMock: func(twoFactorMock *repomock.MockTwoFactor) {},
+				ctxMock:       userCtx,
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"two-factor authentication is already enabled"}`,
+			},
+		},
+		{
+			name: "Setup two-factor invalid userUUID with fail",
+			payload: payload{
+				repoMock:      func(repoMock *repomock.MockAuth) {},
+				twoFactorMock: func(twoFactorMock *repomock.MockTwoFactor) {},
+				ctxMock: func(req *http.Request) context.Context {
+					return context.Background()
+				},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"userUUID not found"}`,
+			},
+		},
+		{
+			name: "Setup two-factor SetTOTPSecret error with fail",
+			payload: payload{
+				repoMock: func(repoMock *repomock.MockAuth) {
+					repoMock.EXPECT().GetUserByID(passwordUserUUID).Return(&entity.Credentials{Username: "test"}, nil)
+				},
+				twoFactorMock: func(twoFactorMock *repomock.MockTwoFactor) {
+					twoFactorMock.EXPECT().SetTOTPSecret(passwordUserUUID, gomock.Any()).Return(errors.New("error"))
+				},
+				ctxMock: userCtx,
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			authRepo := repomock.NewMockAuth(mockCtrl)
+			test.payload.repoMock(authRepo)
+
+			twoFactorRepo := repomock.NewMockTwoFactor(mockCtrl)
+			test.payload.twoFactorMock(twoFactorRepo)
+
+			req, err := http.NewRequest(http.MethodPost, "url", nil)
+			if err != nil {
+				t.Error("failed to create request")
+			}
+			rw := httptest.NewRecorder()
+
+			auh := NewAuthHandler(&config.Config{}, logger, authRepo, authmock.NewMockAuth(mockCtrl),
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+				twoFactorRepo, cph, nil, anyAuditor(mockCtrl))
+			auh.SetupTwoFactor(rw, req.WithContext(test.payload.ctxMock(req)))
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestSetupTwoFactorSecret(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	cph, _ := newTestCipher(t)
+	logger, _ := loggermock.NewNullLogger()
+
+	authRepo := repomock.NewMockAuth(mockCtrl)
+	authRepo.EXPECT().GetUserByID(passwordUserUUID).Return(&entity.Credentials{Username: "test"}, nil)
+
+	// secret is stored encrypted
+	var stored string
+	twoFactorRepo := repomock.NewMockTwoFactor(mockCtrl)
+	twoFactorRepo.EXPECT().SetTOTPSecret(passwordUserUUID, gomock.Any()).DoAndReturn(func(_ uuid.UUID, secret string) error {
+		stored = secret
+		return nil
+	})
+
+	req, err := http.NewRequest(http.MethodPost, "url", nil)
+	if err != nil {
+		t.Error("failed to create request")
+	}
+	ctx := context.WithValue(req.Context(), middleware.UserUUID, passwordUserUUID.String())
+	rw := httptest.NewRecorder()
+
+	auh := NewAuthHandler(&config.Config{Auth: config.Auth{TOTPIssuer: "Fruit Store"}}, logger, authRepo, authmock.NewMockAuth(mockCtrl),
+		repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+		passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+		twoFactorRepo, cph, nil, anyAuditor(mockCtrl))
+	auh.SetupTwoFactor(rw, req.WithContext(ctx))
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+
+	setup := entity.TOTPSetup{}
+	assert.Nil(t, json.Unmarshal(rw.Body.Bytes(), &setup))
+	assert.NotEqual(t, setup.Secret, stored)
+
+	decrypted, err := cph.Decrypt(stored)
+	assert.Nil(t, err)
+	assert.Equal(t, setup.Secret, decrypted)
+	assert.Equal(t, totp.URI("Fruit Store", "test", setup.Secret), setup.URI)
+}
+
+func TestTwoFactorDisabled(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	logger, _ := loggermock.NewNullLogger()
+
+	// without cipher two-factor authentication can't be set up
+	auh := NewAuthHandler(&config.Config{}, logger, repomock.NewMockAuth(mockCtrl), authmock.NewMockAuth(mockCtrl),
+		repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+		passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+		repomock.NewMockTwoFactor(mockCtrl), nil, nil, anyAuditor(mockCtrl))
+
+	for _, handler := range []http.HandlerFunc{auh.SetupTwoFactor, auh.ConfirmTwoFactor} {
+		req, err := http.NewRequest(http.MethodPost, "url", nil)
+		if err != nil {
+			t.Error("failed to create request")
+		}
+		rw := httptest.NewRecorder()
+
+		handler(rw, req.WithContext(context.WithValue(req.Context(), middleware.UserUUID, passwordUserUUID.String())))
+
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+		assert.Equal(t, `{"error":"two-factor authentication is not configured"}`, rw.Body.String())
+	}
+}
+
+func TestConfirmTwoFactor(t *testing.T) {
+	cph, encrypted := newTestCipher(t)
+	code := currentTOTPCode(t)
+
+	type payload struct {
+		body          []byte
+		twoFactorMock func(twoFactorMock *repomock.MockTwoFactor)
+	}
+	type expected struct {
+		code          int
+		body          string
+		recoveryCodes int
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Confirm two-factor with success",
+			payload: payload{
+				body: []byte(`{"code":"` + code + `"}`),
+				twoFactorMock: func(twoFactorMock *repomock.MockTwoFactor) {
+					twoFactorMock.EXPECT().GetTOTP(passwordUserUUID).Return(&entity.TOTP{Secret: encrypted}, nil)
+					twoFactorMock.EXPECT().UseTOTPStep(passwordUserUUID, gomock.Any()).Return(nil)
+					twoFactorMock.EXPECT().EnableTOTP(passwordUserUUID, gomock.Len(recoveryCodesCount)).Return(nil)
+				},
+			},
+			expected: expected{
+				code:          http.StatusOK,
+				recoveryCodes: recoveryCodesCount,
+			},
+		},
+		{
+			name: "Confirm two-factor wrong code with fail",
+			payload: payload{
+				body: []byte(`{"code":"abcdef"}`),
+				twoFactorMock: func(twoFactorMock *repomock.MockTwoFactor) {
+					twoFactorMock.EXPECT().GetTOTP(passwordUserUUID).Return(&entity.TOTP{Secret: encrypted}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"invalid two-factor code"}`,
+			},
+		},
+		{
+			name: "Confirm two-factor not set up with fail",
+			payload: payload{
+				body: []byte(`{"code":"` + code + `"}`),
+				twoFactorMock: func(twoFactorMock *repomock.MockTwoFactor) {
+					twoFactorMock.EXPECT().GetTOTP(passwordUserUUID).Return(&entity.TOTP{}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"two-factor authentication is not set up"}`,
+			},
+		},
+		{
+			name: "Confirm two-factor already enabled with fail",
+			payload: payload{
+				body: []byte(`{"code":"` + code + `"}`),
+				twoFactorMock: func(twoFactorMock *repomock.MockTwoFactor) {
+					twoFactorMock.EXPECT().GetTOTP(passwordUserUUID).Return(&entity.TOTP{Secret: encrypted, Enabled: true}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"two-factor authentication is already enabled"}`,
+			},
+		},
+		{
+			name: "Confirm two-factor invalid body with fail",
+			payload: payload{
+				body:          []byte(`invalid`),
+				twoFactorMock: func(twoFactorMock *repomock.MockTwoFactor) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid character 'i' looking for beginning of value"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			twoFactorRepo := repomock.NewMockTwoFactor(mockCtrl)
+			test.payload.twoFactorMock(twoFactorRepo)
+
+			req, err := http.NewRequest(http.MethodPost, "url", bytes.NewBuffer(test.payload.body))
+			if err != nil {
+				t.Error("failed to create request")
+			}
+			ctx := context.WithValue(req.Context(), middleware.UserUUID, passwordUserUUID.String())
+			rw := httptest.NewRecorder()
+
+			auh := NewAuthHandler(&config.Config{}, logger, repomock.NewMockAuth(mockCtrl), authmock.NewMockAuth(mockCtrl),
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+				twoFactorRepo, cph, nil, anyAuditor(mockCtrl))
+			auh.ConfirmTwoFactor(rw, req.WithContext(ctx))
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			if test.expected.recoveryCodes != 0 {
+				codes := entity.RecoveryCodes{}
+				assert.Nil(t, json.Unmarshal(rw.Body.Bytes(), &codes))
+				assert.Len(t, codes.RecoveryCodes, test.expected.recoveryCodes)
+				return
+			}
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestSigninTwoFactor(t *testing.T) {
+	cph, encrypted := newTestCipher(t)
+	code := currentTOTPCode(t)
+	storedUser := &entity.Credentials{ID: passwordUserUUID, Username: "test"}
+
+	type payload struct {
+		body          []byte
+		authMock      func(authMock *authmock.MockAuth)
+		twoFactorMock func(twoFactorMock *repomock.MockTwoFactor)
+	}
+	type expected struct {
+		code       int
+		body       string
+		retryAfter string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Sign in two-factor TOTP code with success",
+			payload: payload{
+				body: []byte(`{"challenge_token":"challenge","code":"` + code + `"}`),
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().GetChallenge("challenge").Return(passwordUserUUID, nil)
+					authMock.EXPECT().SigninAllowed("test", gomock.Any()).Return(time.Duration(0), nil)
+					authMock.EXPECT().CompleteChallenge("challenge").Return(nil)
+					authMock.EXPECT().SigninSucceeded("test").Return(nil)
+					authMock.EXPECT().CreateTokens(passwordUserUUID, gomock.Any()).Return(&entity.Tokens{AccessToken: "access"}, nil)
+				},
+				twoFactorMock: func(twoFactorMock *repomock.MockTwoFactor) {
+					twoFactorMock.EXPECT().GetTOTP(passwordUserUUID).Return(&entity.TOTP{Secret: encrypted, Enabled: true}, nil)
+					twoFactorMock.EXPECT().UseTOTPStep(passwordUserUUID, gomock.Any()).Return(nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"access_token":"access","refresh_token":""}`,
+			},
+		},
+		{
+			name: "Sign in two-factor recovery code with success",
+			payload: payload{
+				body: []byte(`{"challenge_token":"challenge","code":"ABCD-EFGH"}`),
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().GetChallenge("challenge").Return(passwordUserUUID, nil)
+					authMock.EXPECT().SigninAllowed("test", gomock.Any()).Return(time.Duration(0), nil)
+					authMock.EXPECT().CompleteChallenge("challenge").Return(nil)
+					authMock.EXPECT().SigninSucceeded("test").Return(nil)
+					authMock.EXPECT().CreateTokens(passwordUserUUID, gomock.Any()).Return(&entity.Tokens{AccessToken: "access"}, nil)
+				},
+				twoFactorMock: func(twoFactorMock *repomock.MockTwoFactor) {
+					twoFactorMock.EXPECT().UseRecoveryCode(passwordUserUUID, totp.HashRecoveryCode("abcd-efgh")).Return(nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"access_token":"access","refresh_token":""}`,
+			},
+		},
+		{
+			name: "Sign in two-factor replayed code with fail",
+			payload: payload{
+				body: []byte(`{"challenge_token":"challenge","code":"` + code + `"}`),
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().GetChallenge("challenge").Return(passwordUserUUID, nil)
+					authMock.EXPECT().SigninAllowed("test", gomock.Any()).Return(time.Duration(0), nil)
+					authMock.EXPECT().SigninFailed("test", gomock.Any()).Return(2*time.Second, nil)
+				},
+				twoFactorMock: func(twoFactorMock *repomock.MockTwoFactor) {
+					twoFactorMock.EXPECT().GetTOTP(passwordUserUUID).Return(&entity.TOTP{Secret: encrypted, Enabled: true}, nil)
+					twoFactorMock.EXPECT().UseTOTPStep(passwordUserUUID, gomock.Any()).Return(entity.ErrInvalidTOTPCode)
+				},
+			},
+			expected: expected{
+				code:       http.StatusUnauthorized,
+				body:       `{"error":"invalid two-factor code"}`,
+				retryAfter: "2",
+			},
+		},
+		{
+			name: "Sign in two-factor unknown recovery code with fail",
+			payload: payload{
+				body: []byte(`{"challenge_token":"challenge","code":"wrong"}`),
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().GetChallenge("challenge").Return(passwordUserUUID, nil)
+					authMock.EXPECT().SigninAllowed("test", gomock.Any()).Return(time.Duration(0), nil)
+					authMock.EXPECT().SigninFailed("test", gomock.Any()).Return(time.Second, nil)
+				},
+				twoFactorMock: func(twoFactorMock *repomock.MockTwoFactor) {
+					twoFactorMock.EXPECT().UseRecoveryCode(passwordUserUUID, gomock.Any()).Return(entity.ErrInvalidTOTPCode)
+				},
+			},
+			expected: expected{
+				code:       http.StatusUnauthorized,
+				body:       `{"error":"invalid two-factor code"}`,
+				retryAfter: "1",
+			},
+		},
+		{
+			name: "Sign in two-factor blocked with fail",
+			payload: payload{
+				body: []byte(`{"challenge_token":"challenge","code":"` + code + `"}`),
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().GetChallenge("challenge").Return(passwordUserUUID, nil)
+					authMock.EXPECT().SigninAllowed("test", gomock.Any()).Return(time.Minute, nil)
+				},
+				twoFactorMock: func(twoFactorMock *repomock.MockTwoFactor) {},
+			},
+			expected: expected{
+				code:       http.StatusTooManyRequests,
+				body:       `{"error":"too many sign in attempts, try again later"}`,
+				retryAfter: "60",
+			},
+		},
+		{
+			name: "Sign in two-factor invalid challenge with fail",
+			payload: payload{
+				body: []byte(`{"challenge_token":"challenge","code":"` + code + `"}`),
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().GetChallenge("challenge").Return(uuid.Nil, authentication.ErrChallengeInvalid)
+				},
+				twoFactorMock: func(twoFactorMock *repomock.MockTwoFactor) {},
+			},
+			expected: expected{
+				code: http.StatusUnauthorized,
+				body: `{"error":"challenge token is invalid or expired"}`,
+			},
+		},
+		{
+			name: "Sign in two-factor challenge completed concurrently with fail",
+			payload: payload{
+				body: []byte(`{"challenge_token":"challenge","code":"ABCD-EFGH"}`),
+				authMock: func(authMock *authmock.MockAuth) {
+					authMock.EXPECT().GetChallenge("challenge").Return(passwordUserUUID, nil)
+					authMock.EXPECT().SigninAllowed("test", gomock.Any()).Return(time.Duration(0), nil)
+					authMock.EXPECT().CompleteChallenge("challenge").Return(authentication.ErrChallengeInvalid)
+				},
+				twoFactorMock: func(twoFactorMock *repomock.MockTwoFactor) {
+					twoFactorMock.EXPECT().UseRecoveryCode(passwordUserUUID, gomock.Any()).Return(nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnauthorized,
+				body: `{"error":"challenge token is invalid or expired"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			authRepo := repomock.NewMockAuth(mockCtrl)
+			authRepo.EXPECT().GetUserByID(passwordUserUUID).Return(storedUser, nil).AnyTimes()
+
+			auth := authmock.NewMockAuth(mockCtrl)
+			test.payload.authMock(auth)
+
+			twoFactorRepo := repomock.NewMockTwoFactor(mockCtrl)
+			test.payload.twoFactorMock(twoFactorRepo)
+
+			req, err := http.NewRequest(http.MethodPost, "url", bytes.NewBuffer(test.payload.body))
+			if err != nil {
+				t.Error("failed to create request")
+			}
+			rw := httptest.NewRecorder()
+
+			auh := NewAuthHandler(&config.Config{}, logger, authRepo, auth,
+				repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), billmock.NewMockBill(mockCtrl),
+				passwordmock.NewMockPolicy(mockCtrl), notifiermock.NewMockSender(mockCtrl),
+				twoFactorRepo, cph, nil, anyAuditor(mockCtrl))
+			auh.SigninTwoFactor(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+			assert.Equal(t, test.expected.retryAfter, rw.Header().Get("Retry-After"))
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package cart
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"sort"
@@ -9,11 +10,14 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 
+	"github.com/mshto/fruit-store/audit"
 	"github.com/mshto/fruit-store/bill"
 	"github.com/mshto/fruit-store/cache"
 	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/currency"
 	"github.com/mshto/fruit-store/entity"
 	"github.com/mshto/fruit-store/repository"
+	"github.com/mshto/fruit-store/web/common/request"
 	"github.com/mshto/fruit-store/web/common/response"
 	"github.com/mshto/fruit-store/web/middleware"
 )
@@ -24,33 +28,64 @@ type Service interface {
 	UpdateProduct(w http.ResponseWriter, r *http.Request)
 	AddOneProduct(w http.ResponseWriter, r *http.Request)
 	RemoveProduct(w http.ResponseWriter, r *http.Request)
+	PatchProduct(w http.ResponseWriter, r *http.Request)
+	ReplaceProducts(w http.ResponseWriter, r *http.Request)
 
 	AddDiscout(w http.ResponseWriter, r *http.Request)
 
+	SetDelivery(w http.ResponseWriter, r *http.Request)
+	GetSlots(w http.ResponseWriter, r *http.Request)
+	ReserveSlot(w http.ResponseWriter, r *http.Request)
+	ReleaseSlot(w http.ResponseWriter, r *http.Request)
 	AddPayment(w http.ResponseWriter, r *http.Request)
 }
 
 // ProductHandler product handler struct
 type cartHandler struct {
-	cfg      *config.Config
-	log      *logrus.Logger
-	cartRepo repository.Cart
-	discRepo repository.Discount
-	bil      bill.Bill
+	cfg        *config.Config
+	log        *logrus.Logger
+	cartRepo   repository.Cart
+	guestCart  repository.Cart
+	discRepo   repository.Discount
+	currRepo   repository.Currencies
+	delivRepo  repository.Delivery
+	slotsRepo  repository.Slots
+	slotHolds  repository.SlotHolds
+	ordersRepo repository.Orders
+	bil        bill.Bill
+	auditor    audit.Auditor
 }
 
 // NewCardHandler NewCardHandler
-func NewCardHandler(cfg *config.Config, log *logrus.Logger, cartRepo repository.Cart, discRepo repository.Discount, bil bill.Bill) Service {
+func NewCardHandler(cfg *config.Config, log *logrus.Logger, cartRepo, guestCart repository.Cart, discRepo repository.Discount,
+	currRepo repository.Currencies, delivRepo repository.Delivery, slotsRepo repository.Slots, slotHolds repository.SlotHolds,
+	ordersRepo repository.Orders, bil bill.Bill, auditor audit.Auditor) Service {
 	return cartHandler{
-		cfg:      cfg,
-		log:      log,
-		cartRepo: cartRepo,
-		discRepo: discRepo,
-		bil:      bil,
+		cfg:        cfg,
+		log:        log,
+		cartRepo:   cartRepo,
+		guestCart:  guestCart,
+		discRepo:   discRepo,
+		currRepo:   currRepo,
+		delivRepo:  delivRepo,
+		slotsRepo:  slotsRepo,
+		slotHolds:  slotHolds,
+		ordersRepo: ordersRepo,
+		bil:        bil,
+		auditor:    auditor,
 	}
 }
 
-// GetAll retrieves all user products
+// getCartRepo returns guest cart for anonymous users and user cart otherwise
+func (ph cartHandler) getCartRepo(ctx context.Context) repository.Cart {
+	if isGuest, _ := ctx.Value(middleware.IsGuest).(bool); isGuest {
+		return ph.guestCart
+	}
+	return ph.cartRepo
+}
+
+// GetAll retrieves all user products, prices are in currency of currency query parameter or Accept-Currency header,
+// taxes are of region query parameter or country of delivery address
 func (ph cartHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	userUUID, err := uuid.Parse(ctx.Value(middleware.UserUUID).(string))
@@ -60,42 +95,16 @@ func (ph cartHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	products, err := ph.cartRepo.GetUserProducts(userUUID)
-	if err != nil {
-		ph.log.Errorf("failed to get user products, user: %v, error: %v", userUUID, err)
-		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+	rates, display, ok := ph.getDisplay(w, r, userUUID)
+	if !ok {
 		return
 	}
 
-	sort.Slice(products, func(i, j int) bool {
-		return products[i].Name < products[j].Name
-	})
-
-	total, err := ph.bil.GetTotalInfo(userUUID, products)
-	if err != nil {
-		ph.log.Errorf("failed to get total info, user: %v, error: %v", userUUID, err)
-		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+	if !ph.setVersion(w, r, userUUID) {
 		return
 	}
 
-	var isDiscountAdded bool
-	sale, err := ph.bil.GetDiscountByUser(userUUID)
-	if err != nil && err != cache.ErrNotFound {
-		ph.log.Errorf("failed to get discount by user, user: %v, error: %v", userUUID, err)
-		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
-		return
-	}
-	if sale.ID != "" {
-		isDiscountAdded = true
-	}
-
-	response.RenderResponse(w, http.StatusOK, entity.UserCart{
-		CartProducts:    products,
-		TotalPrice:      total.Price,
-		TotalSavings:    total.Savings,
-		Amount:          total.Amount,
-		IsDiscountAdded: isDiscountAdded,
-	})
+	ph.renderCart(w, r, ph.getCartRepo(ctx), userUUID, rates, display)
 }
 
 // UpdateProduct update user products
@@ -116,13 +125,25 @@ func (ph cartHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = ph.cartRepo.CreateUserProducts(userUUID, *prd)
+	if prd.Amount < 0 {
+		ph.log.Errorf("failed to update user product, user: %v, error: %v", userUUID, entity.ErrInvalidAmount)
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrInvalidAmount)
+		return
+	}
+
+	expected, ok := ph.ifMatch(w, r, userUUID)
+	if !ok {
+		return
+	}
+
+	version, err := ph.setProductAmount(ph.getCartRepo(ctx), userUUID, *prd, expected)
 	if err != nil {
 		ph.log.Errorf("failed to create user product, user: %v, error: %v", userUUID, err)
-		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		renderCartError(w, err)
 		return
 	}
 
+	setETag(w, version)
 	response.RenderResponse(w, http.StatusCreated, response.EmptyResp{})
 }
 
@@ -143,13 +164,19 @@ func (ph cartHandler) AddOneProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = ph.cartRepo.CreateUserProduct(userUUID, productUUID)
+	expected, ok := ph.ifMatch(w, r, userUUID)
+	if !ok {
+		return
+	}
+
+	version, err := ph.getCartRepo(ctx).CreateUserProduct(userUUID, productUUID, expected)
 	if err != nil {
 		ph.log.Errorf("failed to create user product, user: %v, error: %v", userUUID, err)
-		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		renderCartError(w, err)
 		return
 	}
 
+	setETag(w, version)
 	response.RenderResponse(w, http.StatusCreated, response.EmptyResp{})
 }
 
@@ -170,12 +197,250 @@ func (ph cartHandler) RemoveProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = ph.cartRepo.RemoveUserProduct(userUUID, productUUID)
+	expected, ok := ph.ifMatch(w, r, userUUID)
+	if !ok {
+		return
+	}
+
+	version, err := ph.getCartRepo(ctx).RemoveUserProduct(userUUID, productUUID, expected)
 	if err != nil {
 		ph.log.Errorf("failed to get remove user product, user: %v, error: %v", userUUID, err)
-		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		renderCartError(w, err)
 		return
 	}
 
+	setETag(w, version)
 	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
 }
+
+// PatchProduct change amount of user product by delta or set it to an absolute value
+func (ph cartHandler) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userUUID, err := uuid.Parse(ctx.Value(middleware.UserUUID).(string))
+	if err != nil {
+		ph.log.Errorf("failed to get user uuid, user: %v, error: %v", ctx.Value(middleware.UserUUID).(string), err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	productUUID, err := uuid.Parse(mux.Vars(r)["productID"])
+	if err != nil {
+		ph.log.Errorf("failed to get product uuid, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	patch := &entity.CartProductPatch{}
+	err = json.NewDecoder(r.Body).Decode(patch)
+	if err != nil {
+		ph.log.Errorf("failed to decode product patch, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if (patch.Delta == nil) == (patch.Amount == nil) {
+		ph.log.Errorf("failed to patch user product, user: %v, error: %v", userUUID, entity.ErrInvalidPatch)
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrInvalidPatch)
+		return
+	}
+	if patch.Amount != nil && *patch.Amount < 0 {
+		ph.log.Errorf("failed to patch user product, user: %v, error: %v", userUUID, entity.ErrInvalidAmount)
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrInvalidAmount)
+		return
+	}
+
+	expected, ok := ph.ifMatch(w, r, userUUID)
+	if !ok {
+		return
+	}
+
+	prd := entity.UserProduct{
+		ProductUUID: productUUID,
+		UserID:      userUUID,
+	}
+	var version int64
+	if patch.Amount != nil {
+		prd.Amount = *patch.Amount
+		version, err = ph.setProductAmount(ph.getCartRepo(ctx), userUUID, prd, expected)
+	} else {
+		prd.Amount, version, err = ph.getCartRepo(ctx).UpdateUserProductAmount(userUUID, productUUID, *patch.Delta, expected)
+	}
+	if err != nil {
+		ph.log.Errorf("failed to patch user product, user: %v, error: %v", userUUID, err)
+		renderCartError(w, err)
+		return
+	}
+
+	setETag(w, version)
+	response.RenderResponse(w, http.StatusOK, prd)
+}
+
+// getDisplay get exchange rates and currency of request prices are displayed in
+func (ph cartHandler) getDisplay(w http.ResponseWriter, r *http.Request, userUUID uuid.UUID) (currency.Rates, currency.Display, bool) {
+	rates, display, err := currency.Load(ph.currRepo, ph.cfg.Currency.BaseCode(), request.Currency(r))
+	if currency.IsInvalid(err) {
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return rates, display, false
+	}
+	if err != nil {
+		ph.log.Errorf("failed to get exchange rates, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return rates, display, false
+	}
+	return rates, display, true
+}
+
+// renderCart renders user products with totals recalculated in base currency and displayed in display currency,
+// shipping is rendered when delivery of cart is selected
+func (ph cartHandler) renderCart(w http.ResponseWriter, r *http.Request, cartRepo repository.Cart, userUUID uuid.UUID, rates currency.Rates, display currency.Display) {
+	products, err := cartRepo.GetUserProducts(userUUID)
+	if err != nil {
+		ph.log.Errorf("failed to get user products, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].Name < products[j].Name
+	})
+
+	err = rates.CartToBase(products)
+	if err != nil {
+		ph.log.Errorf("failed to convert product prices, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	delivery, err := ph.getCartDelivery(r.Context(), userUUID)
+	if err != nil {
+		ph.log.Errorf("failed to get cart delivery, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	region := request.Region(r)
+	if region == "" && delivery != nil && delivery.Address != nil {
+		region = delivery.Address.Country
+	}
+
+	total, err := ph.bil.GetTotalInfo(userUUID, products, display, region)
+	if err != nil {
+		ph.log.Errorf("failed to get total info, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var isDiscountAdded bool
+	sale, err := ph.bil.GetDiscountByUser(userUUID)
+	if err != nil && err != cache.ErrNotFound {
+		ph.log.Errorf("failed to get discount by user, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if sale.ID != "" {
+		isDiscountAdded = true
+	}
+
+	display.ConvertCart(products)
+	cart := entity.UserCart{
+		CartProducts:    products,
+		TotalPrice:      total.Price,
+		TotalSavings:    total.Savings,
+		Amount:          total.Amount,
+		TotalNet:        total.Net,
+		TotalTax:        total.Tax,
+		TotalGross:      total.Price,
+		Taxes:           total.Taxes,
+		TaxRegion:       ph.cfg.Tax.RegionCode(region),
+		Currency:        display.Code,
+		IsDiscountAdded: isDiscountAdded,
+	}
+	if display.Code != rates.Base() {
+		cart.BaseCurrency = rates.Base()
+		cart.BaseTotalPrice = total.BasePrice
+	}
+	if delivery != nil {
+		cart.Shipping, err = ph.getShipping(delivery, total, display)
+		if err != nil {
+			ph.log.Warnf("failed to get shipping, user: %v, error: %v", userUUID, err)
+		}
+	}
+
+	response.RenderResponse(w, http.StatusOK, cart)
+}
+
+// ReplaceProducts replace the whole user cart and return it recalculated
+func (ph cartHandler) ReplaceProducts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userUUID, err := uuid.Parse(ctx.Value(middleware.UserUUID).(string))
+	if err != nil {
+		ph.log.Errorf("failed to get user uuid, user: %v, error: %v", ctx.Value(middleware.UserUUID).(string), err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	prds := []entity.UserProduct{}
+	err = json.NewDecoder(r.Body).Decode(&prds)
+	if err != nil {
+		ph.log.Errorf("failed to decode user products, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	seen := make(map[uuid.UUID]bool, len(prds))
+	for _, prd := range prds {
+		if prd.Amount < 0 {
+			ph.log.Errorf("failed to replace user products, user: %v, error: %v", userUUID, entity.ErrInvalidAmount)
+			response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrInvalidAmount)
+			return
+		}
+		if seen[prd.ProductUUID] {
+			ph.log.Errorf("failed to replace user products, user: %v, error: %v", userUUID, entity.ErrDuplicateProduct)
+			response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrDuplicateProduct)
+			return
+		}
+		seen[prd.ProductUUID] = true
+	}
+
+	rates, display, ok := ph.getDisplay(w, r, userUUID)
+	if !ok {
+		return
+	}
+
+	expected, ok := ph.ifMatch(w, r, userUUID)
+	if !ok {
+		return
+	}
+
+	cartRepo := ph.getCartRepo(ctx)
+	version, err := cartRepo.ReplaceUserProducts(userUUID, prds, expected)
+	if err != nil {
+		ph.log.Errorf("failed to replace user products, user: %v, error: %v", userUUID, err)
+		renderCartError(w, err)
+		return
+	}
+
+	setETag(w, version)
+	ph.renderCart(w, r, cartRepo, userUUID, rates, display)
+}
+
+// setProductAmount set absolute amount of user product, zero amount removes the product
+func (ph cartHandler) setProductAmount(cartRepo repository.Cart, userUUID uuid.UUID, prd entity.UserProduct, expected int64) (int64, error) {
+	if prd.Amount == 0 {
+		return cartRepo.RemoveUserProduct(userUUID, prd.ProductUUID, expected)
+	}
+	return cartRepo.CreateUserProducts(userUUID, prd, expected)
+}
+
+// renderCartError renders 412 for cart changed since version of If-Match header,
+// 422 for invalid products, amounts and quantities and 500 otherwise
+func renderCartError(w http.ResponseWriter, err error) {
+	switch err {
+	case entity.ErrCartVersionMismatch:
+		response.RenderFailedResponse(w, http.StatusPreconditionFailed, err)
+	case entity.ErrProductNotFound, entity.ErrInvalidAmount, entity.ErrInvalidQuantity:
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+	default:
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+	}
+}
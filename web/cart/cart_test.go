@@ -13,14 +13,33 @@ import (
 	loggermock "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 
+	auditmock "github.com/mshto/fruit-store/audit/mock"
 	"github.com/mshto/fruit-store/bill"
 	billmock "github.com/mshto/fruit-store/bill/mock"
+	"github.com/mshto/fruit-store/cache"
 	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/currency"
 	"github.com/mshto/fruit-store/entity"
 	repomock "github.com/mshto/fruit-store/repository/mock"
 	"github.com/mshto/fruit-store/web/middleware"
 )
 
+var rates = []entity.ExchangeRate{{Currency: "EUR", Rate: 0.84}}
+
+// newCurrencies mock currencies repo which returns exchange rates any times
+func newCurrencies(mockCtrl *gomock.Controller) *repomock.MockCurrencies {
+	currRepo := repomock.NewMockCurrencies(mockCtrl)
+	currRepo.EXPECT().GetExchangeRates().Return(rates, nil).AnyTimes()
+	return currRepo
+}
+
+// newDelivery mock delivery repo of user who didn't select delivery
+func newDelivery(mockCtrl *gomock.Controller) *repomock.MockDelivery {
+	delivRepo := repomock.NewMockDelivery(mockCtrl)
+	delivRepo.EXPECT().GetCartDelivery(gomock.Any()).Return(nil, entity.ErrDeliveryNotSelected).AnyTimes()
+	return delivRepo
+}
+
 func TestGetAll(t *testing.T) {
 	type payload struct {
 		cfg      *config.Config
@@ -45,12 +64,13 @@ func TestGetAll(t *testing.T) {
 				cfg: &config.Config{},
 				url: "/v1/cart/products",
 				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().GetCartVersion(gomock.Any()).Return(int64(1), nil)
 					cartMock.EXPECT().GetUserProducts(gomock.Any()).Return([]entity.GetUserProduct{
 						{Name: "Second"}, {Name: "First"},
 					}, nil)
 				},
 				billMock: func(billMock *billmock.MockBill) {
-					billMock.EXPECT().GetTotalInfo(gomock.Any(), gomock.Any()).Return(bill.TotalInfo{}, nil)
+					billMock.EXPECT().GetTotalInfo(gomock.Any(), gomock.Any(), gomock.Any(), "").Return(bill.TotalInfo{}, nil)
 					billMock.EXPECT().GetDiscountByUser(gomock.Any()).Return(config.GeneralSale{ID: "sale ID"}, nil)
 				},
 				ctxMock: func(req *http.Request) context.Context {
@@ -60,7 +80,78 @@ func TestGetAll(t *testing.T) {
 			},
 			expected: expected{
 				code: http.StatusOK,
-				body: `{"products":[{"id":"00000000-0000-0000-0000-000000000000","name":"First","price":0,"amount":0},{"id":"00000000-0000-0000-0000-000000000000","name":"Second","price":0,"amount":0}],"totalPrice":"","totalSavings":"","totalAmount":"","isDiscountAdded":true}`,
+				body: `{"products":[{"id":"00000000-0000-0000-0000-000000000000","name":"First","price":0,"currency":"USD","amount":0},{"id":"00000000-0000-0000-0000-000000000000","name":"Second","price":0,"currency":"USD","amount":0}],"totalPrice":"","totalSavings":"","totalAmount":"","totalNet":"","totalTax":"","totalGross":"","taxes":null,"currency":"USD","isDiscountAdded":true}`,
+			},
+		},
+		{
+			name: "Get all in requested currency with success",
+			payload: payload{
+				cfg: &config.Config{},
+				url: "/v1/cart/products?currency=EUR",
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().GetCartVersion(gomock.Any()).Return(int64(1), nil)
+					cartMock.EXPECT().GetUserProducts(gomock.Any()).Return([]entity.GetUserProduct{
+						{Name: "Apples", Price: 2.5, Amount: 1},
+					}, nil)
+				},
+				billMock: func(billMock *billmock.MockBill) {
+					billMock.EXPECT().GetTotalInfo(gomock.Any(), []entity.GetUserProduct{{Name: "Apples", Price: 2.5, Currency: "USD", Amount: 1}},
+						currency.Display{Code: "EUR", Rate: 0.84}, "").Return(bill.TotalInfo{Price: "2.10", Savings: "0.00", Amount: "1", BasePrice: "2.50"}, nil)
+					billMock.EXPECT().GetDiscountByUser(gomock.Any()).Return(config.GeneralSale{}, cache.ErrNotFound)
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"products":[{"id":"00000000-0000-0000-0000-000000000000","name":"Apples","price":2.1,"currency":"EUR","amount":1}],` +
+					`"totalPrice":"2.10","totalSavings":"0.00","totalAmount":"1","totalNet":"","totalTax":"","totalGross":"2.10","taxes":null,"currency":"EUR","baseCurrency":"USD","baseTotalPrice":"2.50","isDiscountAdded":false}`,
+			},
+		},
+		{
+			name: "Get all with taxes of region with success",
+			payload: payload{
+				cfg: &config.Config{},
+				url: "/v1/cart/products?region=pl",
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().GetCartVersion(gomock.Any()).Return(int64(1), nil)
+					cartMock.EXPECT().GetUserProducts(gomock.Any()).Return([]entity.GetUserProduct{}, nil)
+				},
+				billMock: func(billMock *billmock.MockBill) {
+					billMock.EXPECT().GetTotalInfo(gomock.Any(), gomock.Any(), gomock.Any(), "pl").Return(bill.TotalInfo{
+						Price: "1.05", Savings: "0.00", Amount: "1", Net: "1.00", Tax: "0.05",
+						Taxes: []entity.TaxLine{{Class: "reduced", Rate: 5, Net: "1.00", Tax: "0.05"}},
+					}, nil)
+					billMock.EXPECT().GetDiscountByUser(gomock.Any()).Return(config.GeneralSale{}, cache.ErrNotFound)
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"products":[],"totalPrice":"1.05","totalSavings":"0.00","totalAmount":"1","totalNet":"1.00","totalTax":"0.05","totalGross":"1.05",` +
+					`"taxes":[{"class":"reduced","rate":5,"net":"1.00","tax":"0.05"}],"taxRegion":"PL","currency":"USD","isDiscountAdded":false}`,
+			},
+		},
+		{
+			name: "Get all in unsupported currency with fail",
+			payload: payload{
+				cfg:      &config.Config{},
+				url:      "/v1/cart/products?currency=BTC",
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {},
+				billMock: func(billMock *billmock.MockBill) {},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"currency is not supported"}`,
 			},
 		},
 		{
@@ -87,6 +178,7 @@ func TestGetAll(t *testing.T) {
 				cfg: &config.Config{},
 				url: "/v1/cart/products",
 				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().GetCartVersion(gomock.Any()).Return(int64(1), nil)
 					cartMock.EXPECT().GetUserProducts(gomock.Any()).Return([]entity.GetUserProduct{}, errors.New("error"))
 				},
 				billMock: func(billMock *billmock.MockBill) {
@@ -107,10 +199,11 @@ func TestGetAll(t *testing.T) {
 				cfg: &config.Config{},
 				url: "/v1/cart/products",
 				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().GetCartVersion(gomock.Any()).Return(int64(1), nil)
 					cartMock.EXPECT().GetUserProducts(gomock.Any()).Return([]entity.GetUserProduct{}, nil)
 				},
 				billMock: func(billMock *billmock.MockBill) {
-					billMock.EXPECT().GetTotalInfo(gomock.Any(), gomock.Any()).Return(bill.TotalInfo{}, errors.New("error"))
+					billMock.EXPECT().GetTotalInfo(gomock.Any(), gomock.Any(), gomock.Any(), "").Return(bill.TotalInfo{}, errors.New("error"))
 				},
 				ctxMock: func(req *http.Request) context.Context {
 					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
@@ -128,10 +221,11 @@ func TestGetAll(t *testing.T) {
 				cfg: &config.Config{},
 				url: "/v1/cart/products",
 				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().GetCartVersion(gomock.Any()).Return(int64(1), nil)
 					cartMock.EXPECT().GetUserProducts(gomock.Any()).Return([]entity.GetUserProduct{}, nil)
 				},
 				billMock: func(billMock *billmock.MockBill) {
-					billMock.EXPECT().GetTotalInfo(gomock.Any(), gomock.Any()).Return(bill.TotalInfo{}, nil)
+					billMock.EXPECT().GetTotalInfo(gomock.Any(), gomock.Any(), gomock.Any(), "").Return(bill.TotalInfo{}, nil)
 					billMock.EXPECT().GetDiscountByUser(gomock.Any()).Return(config.GeneralSale{}, errors.New("error"))
 				},
 				ctxMock: func(req *http.Request) context.Context {
@@ -169,7 +263,7 @@ func TestGetAll(t *testing.T) {
 
 			ctx := test.payload.ctxMock(req)
 
-			crh := NewCardHandler(test.payload.cfg, logger, cartRepo, discRepo, billMock)
+			crh := NewCardHandler(test.payload.cfg, logger, cartRepo, repomock.NewMockCart(mockCtrl), discRepo, newCurrencies(mockCtrl), newDelivery(mockCtrl), repomock.NewMockSlots(mockCtrl), repomock.NewMockSlotHolds(mockCtrl), repomock.NewMockOrders(mockCtrl), billMock, auditmock.NewMockAuditor(mockCtrl))
 
 			router := mux.NewRouter()
 			router.HandleFunc("/v1/cart/products", crh.GetAll)
@@ -205,9 +299,9 @@ func TestUpdateProduct(t *testing.T) {
 			payload: payload{
 				cfg:  &config.Config{},
 				url:  "/v1/cart/products",
-				body: []byte(`{}`),
+				body: []byte(`{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":2}`),
 				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
-					cartMock.EXPECT().CreateUserProducts(gomock.Any(), gomock.Any()).Return(nil)
+					cartMock.EXPECT().CreateUserProducts(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(2), nil)
 				},
 				billMock: func(billMock *billmock.MockBill) {
 				},
@@ -266,9 +360,9 @@ func TestUpdateProduct(t *testing.T) {
 			payload: payload{
 				cfg:  &config.Config{},
 				url:  "/v1/cart/products",
-				body: []byte(`{}`),
+				body: []byte(`{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":2}`),
 				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
-					cartMock.EXPECT().CreateUserProducts(gomock.Any(), gomock.Any()).Return(errors.New("error"))
+					cartMock.EXPECT().CreateUserProducts(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), errors.New("error"))
 				},
 				billMock: func(billMock *billmock.MockBill) {
 				},
@@ -282,6 +376,68 @@ func TestUpdateProduct(t *testing.T) {
 				body: `{"error":"error"}`,
 			},
 		},
+		{
+			name: "Update product zero amount with success",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products",
+				body: []byte(`{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":0}`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().RemoveUserProduct(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(2), nil)
+				},
+				billMock: func(billMock *billmock.MockBill) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusCreated,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Update product negative amount with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products",
+				body: []byte(`{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":-1}`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+				},
+				billMock: func(billMock *billmock.MockBill) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"amount must not be negative"}`,
+			},
+		},
+		{
+			name: "Update product unknown product with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products",
+				body: []byte(`{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":2}`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().CreateUserProducts(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), entity.ErrProductNotFound)
+				},
+				billMock: func(billMock *billmock.MockBill) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"product not found"}`,
+			},
+		},
 	}
 
 	for _, test := range tc {
@@ -307,7 +463,7 @@ func TestUpdateProduct(t *testing.T) {
 
 			ctx := test.payload.ctxMock(req)
 
-			crh := NewCardHandler(test.payload.cfg, logger, cartRepo, discRepo, billMock)
+			crh := NewCardHandler(test.payload.cfg, logger, cartRepo, repomock.NewMockCart(mockCtrl), discRepo, newCurrencies(mockCtrl), newDelivery(mockCtrl), repomock.NewMockSlots(mockCtrl), repomock.NewMockSlotHolds(mockCtrl), repomock.NewMockOrders(mockCtrl), billMock, auditmock.NewMockAuditor(mockCtrl))
 
 			router := mux.NewRouter()
 			router.HandleFunc("/v1/cart/products", crh.UpdateProduct)
@@ -343,7 +499,7 @@ func TestAddOneProduct(t *testing.T) {
 				cfg: &config.Config{},
 				url: "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
 				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
-					cartMock.EXPECT().CreateUserProduct(gomock.Any(), gomock.Any()).Return(nil)
+					cartMock.EXPECT().CreateUserProduct(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(2), nil)
 				},
 				billMock: func(billMock *billmock.MockBill) {
 				},
@@ -401,7 +557,7 @@ func TestAddOneProduct(t *testing.T) {
 				cfg: &config.Config{},
 				url: "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
 				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
-					cartMock.EXPECT().CreateUserProduct(gomock.Any(), gomock.Any()).Return(errors.New("error"))
+					cartMock.EXPECT().CreateUserProduct(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), errors.New("error"))
 				},
 				billMock: func(billMock *billmock.MockBill) {
 				},
@@ -440,7 +596,7 @@ func TestAddOneProduct(t *testing.T) {
 
 			ctx := test.payload.ctxMock(req)
 
-			crh := NewCardHandler(test.payload.cfg, logger, cartRepo, discRepo, billMock)
+			crh := NewCardHandler(test.payload.cfg, logger, cartRepo, repomock.NewMockCart(mockCtrl), discRepo, newCurrencies(mockCtrl), newDelivery(mockCtrl), repomock.NewMockSlots(mockCtrl), repomock.NewMockSlotHolds(mockCtrl), repomock.NewMockOrders(mockCtrl), billMock, auditmock.NewMockAuditor(mockCtrl))
 
 			router := mux.NewRouter()
 			router.HandleFunc("/v1/cart/products/{productID}", crh.AddOneProduct)
@@ -452,12 +608,12 @@ func TestAddOneProduct(t *testing.T) {
 	}
 }
 
-func TestRemoveProduct(t *testing.T) {
+func TestPatchProduct(t *testing.T) {
 	type payload struct {
 		cfg      *config.Config
 		url      string
+		body     []byte
 		repoMock func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount)
-		billMock func(billMock *billmock.MockBill)
 		ctxMock  func(req *http.Request) context.Context
 	}
 	type expected struct {
@@ -471,14 +627,32 @@ func TestRemoveProduct(t *testing.T) {
 		payload
 	}{
 		{
-			name: "Remove product with success",
+			name: "Patch product delta with success",
 			payload: payload{
-				cfg: &config.Config{},
-				url: "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
+				body: []byte(`{"delta":-1}`),
 				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
-					cartMock.EXPECT().RemoveUserProduct(gomock.Any(), gomock.Any()).Return(nil)
+					cartMock.EXPECT().UpdateUserProductAmount(gomock.Any(), gomock.Any(), -1.0, gomock.Any()).Return(1.0, int64(2), nil)
 				},
-				billMock: func(billMock *billmock.MockBill) {
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","userId":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":1}`,
+			},
+		},
+		{
+			name: "Patch product amount with success",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
+				body: []byte(`{"amount":3}`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().CreateUserProducts(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(2), nil)
 				},
 				ctxMock: func(req *http.Request) context.Context {
 					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
@@ -486,21 +660,39 @@ func TestRemoveProduct(t *testing.T) {
 				},
 			},
 			expected: expected{
-				code: http.StatusNoContent,
-				body: `{}`,
+				code: http.StatusOK,
+				body: `{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","userId":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":3}`,
 			},
 		},
 		{
-			name: "Remove product invalid user UUID with fail",
+			name: "Patch product zero amount with success",
 			payload: payload{
-				cfg: &config.Config{},
-				url: "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
+				body: []byte(`{"amount":0}`),
 				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().RemoveUserProduct(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(2), nil)
 				},
-				billMock: func(billMock *billmock.MockBill) {
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","userId":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":0}`,
+			},
+		},
+		{
+			name: "Patch product invalid product id with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products/e2d49480",
+				body: []byte(`{"delta":1}`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
 				},
 				ctxMock: func(req *http.Request) context.Context {
-					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480")
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
 					return ctx
 				},
 			},
@@ -510,14 +702,13 @@ func TestRemoveProduct(t *testing.T) {
 			},
 		},
 		{
-			name: "Remove product invalid product id with fail",
+			name: "Patch product invalid body with fail",
 			payload: payload{
-				cfg: &config.Config{},
-				url: "/v1/cart/products/e2d49480",
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
+				body: []byte(`invalid`),
 				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
 				},
-				billMock: func(billMock *billmock.MockBill) {
-				},
 				ctxMock: func(req *http.Request) context.Context {
 					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
 					return ctx
@@ -525,18 +716,128 @@ func TestRemoveProduct(t *testing.T) {
 			},
 			expected: expected{
 				code: http.StatusBadRequest,
-				body: `{"error":"invalid UUID length: 8"}`,
+				body: `{"error":"invalid character 'i' looking for beginning of value"}`,
 			},
 		},
 		{
-			name: "Remove product db errorwith fail",
+			name: "Patch product empty patch with fail",
 			payload: payload{
-				cfg: &config.Config{},
-				url: "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
+				body: []byte(`{}`),
 				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
-					cartMock.EXPECT().RemoveUserProduct(gomock.Any(), gomock.Any()).Return(errors.New("error"))
 				},
-				billMock: func(billMock *billmock.MockBill) {
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"either delta or amount must be set"}`,
+			},
+		},
+		{
+			name: "Patch product both delta and amount with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
+				body: []byte(`{"delta":1,"amount":1}`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"either delta or amount must be set"}`,
+			},
+		},
+		{
+			name: "Patch product negative amount with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
+				body: []byte(`{"amount":-1}`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"amount must not be negative"}`,
+			},
+		},
+		{
+			name: "Patch product below zero with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
+				body: []byte(`{"delta":-5}`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().UpdateUserProductAmount(gomock.Any(), gomock.Any(), -5.0, gomock.Any()).Return(-4.0, int64(0), entity.ErrInvalidAmount)
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"amount must not be negative"}`,
+			},
+		},
+		{
+			name: "Patch product below minimum with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
+				body: []byte(`{"delta":-0.2}`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().UpdateUserProductAmount(gomock.Any(), gomock.Any(), -0.2, gomock.Any()).Return(0.3, int64(0), entity.ErrInvalidQuantity)
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"amount must be at least the minimum and a multiple of the step of product"}`,
+			},
+		},
+		{
+			name: "Patch product unknown product with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
+				body: []byte(`{"delta":1}`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().UpdateUserProductAmount(gomock.Any(), gomock.Any(), 1.0, gomock.Any()).Return(0.0, int64(0), entity.ErrProductNotFound)
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"product not found"}`,
+			},
+		},
+		{
+			name: "Patch product db error with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
+				body: []byte(`{"delta":1}`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().UpdateUserProductAmount(gomock.Any(), gomock.Any(), 1.0, gomock.Any()).Return(0.0, int64(0), errors.New("error"))
 				},
 				ctxMock: func(req *http.Request) context.Context {
 					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
@@ -565,18 +866,15 @@ func TestRemoveProduct(t *testing.T) {
 
 			test.payload.repoMock(cartRepo, discRepo)
 
-			billMock := billmock.NewMockBill(mockCtrl)
-			test.payload.billMock(billMock)
-
-			req, _ := http.NewRequest(http.MethodGet, test.payload.url, nil)
+			req, _ := http.NewRequest(http.MethodPatch, test.payload.url, bytes.NewBuffer(test.payload.body))
 			rw := httptest.NewRecorder()
 
 			ctx := test.payload.ctxMock(req)
 
-			crh := NewCardHandler(test.payload.cfg, logger, cartRepo, discRepo, billMock)
+			crh := NewCardHandler(test.payload.cfg, logger, cartRepo, repomock.NewMockCart(mockCtrl), discRepo, newCurrencies(mockCtrl), newDelivery(mockCtrl), repomock.NewMockSlots(mockCtrl), repomock.NewMockSlotHolds(mockCtrl), repomock.NewMockOrders(mockCtrl), billmock.NewMockBill(mockCtrl), auditmock.NewMockAuditor(mockCtrl))
 
 			router := mux.NewRouter()
-			router.HandleFunc("/v1/cart/products/{productID}", crh.RemoveProduct)
+			router.HandleFunc("/v1/cart/products/{productID}", crh.PatchProduct)
 			router.ServeHTTP(rw, req.WithContext(ctx))
 
 			assert.Equal(t, test.expected.code, rw.Code)
@@ -584,3 +882,416 @@ func TestRemoveProduct(t *testing.T) {
 		})
 	}
 }
+
+func TestReplaceProducts(t *testing.T) {
+	type payload struct {
+		cfg      *config.Config
+		url      string
+		body     []byte
+		repoMock func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount)
+		billMock func(billMock *billmock.MockBill)
+		ctxMock  func(req *http.Request) context.Context
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Replace products with success",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products",
+				body: []byte(`[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":2}]`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().ReplaceUserProducts(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(2), nil)
+					cartMock.EXPECT().GetUserProducts(gomock.Any()).Return([]entity.GetUserProduct{{Name: "First", Amount: 2}}, nil)
+				},
+				billMock: func(billMock *billmock.MockBill) {
+					billMock.EXPECT().GetTotalInfo(gomock.Any(), gomock.Any(), gomock.Any(), "").Return(bill.TotalInfo{Price: "2.00", Amount: "2.00"}, nil)
+					billMock.EXPECT().GetDiscountByUser(gomock.Any()).Return(config.GeneralSale{}, cache.ErrNotFound)
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"products":[{"id":"00000000-0000-0000-0000-000000000000","name":"First","price":0,"currency":"USD","amount":2}],"totalPrice":"2.00","totalSavings":"","totalAmount":"2.00","totalNet":"","totalTax":"","totalGross":"2.00","taxes":null,"currency":"USD","isDiscountAdded":false}`,
+			},
+		},
+		{
+			name: "Replace products invalid user uuid with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products",
+				body: []byte(`[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":2}]`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+				},
+				billMock: func(billMock *billmock.MockBill) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid UUID length: 8"}`,
+			},
+		},
+		{
+			name: "Replace products invalid body with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products",
+				body: []byte(`invalid`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+				},
+				billMock: func(billMock *billmock.MockBill) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid character 'i' looking for beginning of value"}`,
+			},
+		},
+		{
+			name: "Replace products negative amount with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products",
+				body: []byte(`[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":-1}]`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+				},
+				billMock: func(billMock *billmock.MockBill) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"amount must not be negative"}`,
+			},
+		},
+		{
+			name: "Replace products duplicate product with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products",
+				body: []byte(`[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":1},{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":2}]`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+				},
+				billMock: func(billMock *billmock.MockBill) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"duplicate product in cart"}`,
+			},
+		},
+		{
+			name: "Replace products version mismatch with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products",
+				body: []byte(`[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":2}]`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().ReplaceUserProducts(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), entity.ErrCartVersionMismatch)
+				},
+				billMock: func(billMock *billmock.MockBill) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusPreconditionFailed,
+				body: `{"error":"cart version mismatch"}`,
+			},
+		},
+		{
+			name: "Replace products unknown product with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products",
+				body: []byte(`[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":2}]`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().ReplaceUserProducts(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), entity.ErrProductNotFound)
+				},
+				billMock: func(billMock *billmock.MockBill) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"product not found"}`,
+			},
+		},
+		{
+			name: "Replace products db error with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				url:  "/v1/cart/products",
+				body: []byte(`[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":2}]`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().ReplaceUserProducts(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), errors.New("error"))
+				},
+				billMock: func(billMock *billmock.MockBill) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			cartRepo := repomock.NewMockCart(mockCtrl)
+			discRepo := repomock.NewMockDiscount(mockCtrl)
+
+			test.payload.repoMock(cartRepo, discRepo)
+
+			billMock := billmock.NewMockBill(mockCtrl)
+			test.payload.billMock(billMock)
+
+			req, _ := http.NewRequest(http.MethodPut, test.payload.url, bytes.NewBuffer(test.payload.body))
+			rw := httptest.NewRecorder()
+
+			ctx := test.payload.ctxMock(req)
+
+			crh := NewCardHandler(test.payload.cfg, logger, cartRepo, repomock.NewMockCart(mockCtrl), discRepo, newCurrencies(mockCtrl), newDelivery(mockCtrl), repomock.NewMockSlots(mockCtrl), repomock.NewMockSlotHolds(mockCtrl), repomock.NewMockOrders(mockCtrl), billMock, auditmock.NewMockAuditor(mockCtrl))
+
+			router := mux.NewRouter()
+			router.HandleFunc("/v1/cart/products", crh.ReplaceProducts)
+			router.ServeHTTP(rw, req.WithContext(ctx))
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestRemoveProduct(t *testing.T) {
+	type payload struct {
+		cfg      *config.Config
+		url      string
+		repoMock func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount)
+		billMock func(billMock *billmock.MockBill)
+		ctxMock  func(req *http.Request) context.Context
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Remove product with success",
+			payload: payload{
+				cfg: &config.Config{},
+				url: "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().RemoveUserProduct(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(2), nil)
+				},
+				billMock: func(billMock *billmock.MockBill) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				body: `{}`,
+			},
+		},
+		{
+			name: "Remove product invalid user UUID with fail",
+			payload: payload{
+				cfg: &config.Config{},
+				url: "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+				},
+				billMock: func(billMock *billmock.MockBill) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid UUID length: 8"}`,
+			},
+		},
+		{
+			name: "Remove product invalid product id with fail",
+			payload: payload{
+				cfg: &config.Config{},
+				url: "/v1/cart/products/e2d49480",
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+				},
+				billMock: func(billMock *billmock.MockBill) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid UUID length: 8"}`,
+			},
+		},
+		{
+			name: "Remove product db errorwith fail",
+			payload: payload{
+				cfg: &config.Config{},
+				url: "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002",
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					cartMock.EXPECT().RemoveUserProduct(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), errors.New("error"))
+				},
+				billMock: func(billMock *billmock.MockBill) {
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			cartRepo := repomock.NewMockCart(mockCtrl)
+			discRepo := repomock.NewMockDiscount(mockCtrl)
+
+			test.payload.repoMock(cartRepo, discRepo)
+
+			billMock := billmock.NewMockBill(mockCtrl)
+			test.payload.billMock(billMock)
+
+			req, _ := http.NewRequest(http.MethodGet, test.payload.url, nil)
+			rw := httptest.NewRecorder()
+
+			ctx := test.payload.ctxMock(req)
+
+			crh := NewCardHandler(test.payload.cfg, logger, cartRepo, repomock.NewMockCart(mockCtrl), discRepo, newCurrencies(mockCtrl), newDelivery(mockCtrl), repomock.NewMockSlots(mockCtrl), repomock.NewMockSlotHolds(mockCtrl), repomock.NewMockOrders(mockCtrl), billMock, auditmock.NewMockAuditor(mockCtrl))
+
+			router := mux.NewRouter()
+			router.HandleFunc("/v1/cart/products/{productID}", crh.RemoveProduct)
+			router.ServeHTTP(rw, req.WithContext(ctx))
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestGuestCart(t *testing.T) {
+	type payload struct {
+		ctxMock func(req *http.Request) context.Context
+	}
+	type expected struct {
+		code int
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Add one product to guest cart with success",
+			payload: payload{
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return context.WithValue(ctx, middleware.IsGuest, true)
+				},
+			},
+			expected: expected{
+				code: http.StatusCreated,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			cartRepo := repomock.NewMockCart(mockCtrl)
+			guestCart := repomock.NewMockCart(mockCtrl)
+			guestCart.EXPECT().CreateUserProduct(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+			req, _ := http.NewRequest(http.MethodPost, "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002", nil)
+			rw := httptest.NewRecorder()
+
+			ctx := test.payload.ctxMock(req)
+
+			crh := NewCardHandler(&config.Config{}, logger, cartRepo, guestCart, repomock.NewMockDiscount(mockCtrl), newCurrencies(mockCtrl), newDelivery(mockCtrl), repomock.NewMockSlots(mockCtrl), repomock.NewMockSlotHolds(mockCtrl), repomock.NewMockOrders(mockCtrl), billmock.NewMockBill(mockCtrl), auditmock.NewMockAuditor(mockCtrl))
+
+			router := mux.NewRouter()
+			router.HandleFunc("/v1/cart/products/{productID}", crh.AddOneProduct)
+			router.ServeHTTP(rw, req.WithContext(ctx))
+
+			assert.Equal(t, test.expected.code, rw.Code)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package cart
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/mshto/fruit-store/bill"
+	"github.com/mshto/fruit-store/currency"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/shipping"
+	"github.com/mshto/fruit-store/web/common/response"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+// SetDelivery set shipping method of user cart, courier delivers to delivery address of user
+func (ph cartHandler) SetDelivery(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userUUID, err := uuid.Parse(ctx.Value(middleware.UserUUID).(string))
+	if err != nil {
+		ph.log.Errorf("failed to get user uuid, user: %v, error: %v", ctx.Value(middleware.UserUUID).(string), err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	delivery := &entity.CartDelivery{}
+	err = json.NewDecoder(r.Body).Decode(delivery)
+	if err != nil {
+		ph.log.Errorf("failed to decode delivery, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	switch {
+	case !shipping.IsMethod(delivery.Method):
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrInvalidMethod)
+		return
+	case delivery.Method == shipping.MethodPickup:
+		delivery.AddressID = nil
+	case delivery.AddressID == nil:
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrAddressRequired)
+		return
+	default:
+		delivery.Address, err = ph.getAddress(userUUID, *delivery.AddressID)
+		if err == entity.ErrAddressNotFound {
+			response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		if err != nil {
+			ph.log.Errorf("failed to get addresses, user: %v, error: %v", userUUID, err)
+			response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		_, err = ph.cfg.Shipping.Zone(delivery.Address.Country)
+		if err != nil {
+			response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+	}
+
+	err = ph.delivRepo.SetCartDelivery(userUUID, delivery)
+	if err == entity.ErrAddressNotFound {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err != nil {
+		ph.log.Errorf("failed to set cart delivery, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, delivery)
+}
+
+// getAddress get delivery address of user
+func (ph cartHandler) getAddress(userUUID, addressUUID uuid.UUID) (*entity.DeliveryAddress, error) {
+	addresses, err := ph.delivRepo.GetAddresses(userUUID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range addresses {
+		if addresses[i].ID == addressUUID {
+			return &addresses[i], nil
+		}
+	}
+	return nil, entity.ErrAddressNotFound
+}
+
+// getCartDelivery get delivery of user cart, guests and users who didn't select delivery have none
+func (ph cartHandler) getCartDelivery(ctx context.Context, userUUID uuid.UUID) (*entity.CartDelivery, error) {
+	if isGuest, _ := ctx.Value(middleware.IsGuest).(bool); isGuest {
+		return nil, nil
+	}
+	delivery, err := ph.delivRepo.GetCartDelivery(userUUID)
+	if err == entity.ErrDeliveryNotSelected {
+		return nil, nil
+	}
+	return delivery, err
+}
+
+// getShipping shipping of cart delivery for total of products after discounts in display currency
+func (ph cartHandler) getShipping(delivery *entity.CartDelivery, total bill.TotalInfo, display currency.Display) (*entity.Shipping, error) {
+	var country string
+	if delivery.Address != nil {
+		country = delivery.Address.Country
+	}
+
+	quote, err := ph.cfg.Shipping.Quote(delivery.Method, country, total.BaseTotal)
+	if err != nil {
+		return nil, err
+	}
+
+	shp := &entity.Shipping{
+		Method:    quote.Method,
+		AddressID: delivery.AddressID,
+		Zone:      quote.Zone,
+		SlotID:    delivery.SlotID,
+		Cost:      display.Format(quote.Cost),
+	}
+	if quote.LeftToFree > 0 {
+		shp.LeftToFree = display.Format(quote.LeftToFree)
+	}
+	return shp, nil
+}
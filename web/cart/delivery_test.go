@@ -0,0 +1,236 @@
+package cart
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	auditmock "github.com/mshto/fruit-store/audit/mock"
+	"github.com/mshto/fruit-store/bill"
+	billmock "github.com/mshto/fruit-store/bill/mock"
+	"github.com/mshto/fruit-store/cache"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/shipping"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+var (
+	addressUUID = uuid.MustParse("3b1f0a6e-3540-11eb-adc1-0242ac120002")
+	addressOne  = entity.DeliveryAddress{
+		ID:      addressUUID,
+		Address: entity.Address{Line1: "Khreshchatyk 1", City: "Kyiv", PostalCode: "01001", Country: "UA"},
+	}
+	zones = shipping.Shipping{Zones: []shipping.Zone{{Name: "domestic", Countries: []string{"UA"}, Fee: 5, FreeFrom: 50}}}
+)
+
+func TestSetDelivery(t *testing.T) {
+	type payload struct {
+		body     string
+		repoMock func(delivMock *repomock.MockDelivery)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Set pickup delivery with success",
+			payload: payload{
+				body: `{"method":"pickup","addressId":"3b1f0a6e-3540-11eb-adc1-0242ac120002"}`,
+				repoMock: func(delivMock *repomock.MockDelivery) {
+					delivMock.EXPECT().SetCartDelivery(gomock.Any(), &entity.CartDelivery{Method: "pickup"}).Return(nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"method":"pickup"}`,
+			},
+		},
+		{
+			name: "Set courier delivery with success",
+			payload: payload{
+				body: `{"method":"courier","addressId":"3b1f0a6e-3540-11eb-adc1-0242ac120002"}`,
+				repoMock: func(delivMock *repomock.MockDelivery) {
+					delivMock.EXPECT().GetAddresses(gomock.Any()).Return([]entity.DeliveryAddress{addressOne}, nil)
+					delivMock.EXPECT().SetCartDelivery(gomock.Any(), gomock.Any()).Return(nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"method":"courier","addressId":"3b1f0a6e-3540-11eb-adc1-0242ac120002","address":{"id":"3b1f0a6e-3540-11eb-adc1-0242ac120002",` +
+					`"line1":"Khreshchatyk 1","line2":"","city":"Kyiv","postalCode":"01001","country":"UA","createdAt":"0001-01-01T00:00:00Z"}}`,
+			},
+		},
+		{
+			name: "Set unknown method with fail",
+			payload: payload{
+				body:     `{"method":"drone"}`,
+				repoMock: func(delivMock *repomock.MockDelivery) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"shipping method must be courier or pickup"}`,
+			},
+		},
+		{
+			name: "Set courier without address with fail",
+			payload: payload{
+				body:     `{"method":"courier"}`,
+				repoMock: func(delivMock *repomock.MockDelivery) {},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"courier delivery requires address"}`,
+			},
+		},
+		{
+			name: "Set courier to address of other user with fail",
+			payload: payload{
+				body: `{"method":"courier","addressId":"3b1f0a6e-3540-11eb-adc1-0242ac120002"}`,
+				repoMock: func(delivMock *repomock.MockDelivery) {
+					delivMock.EXPECT().GetAddresses(gomock.Any()).Return([]entity.DeliveryAddress{}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"delivery address not found"}`,
+			},
+		},
+		{
+			name: "Set courier to address out of zones with fail",
+			payload: payload{
+				body: `{"method":"courier","addressId":"3b1f0a6e-3540-11eb-adc1-0242ac120002"}`,
+				repoMock: func(delivMock *repomock.MockDelivery) {
+					address := addressOne
+					address.Country = "PL"
+					delivMock.EXPECT().GetAddresses(gomock.Any()).Return([]entity.DeliveryAddress{address}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"courier doesn't deliver to country of address"}`,
+			},
+		},
+		{
+			name: "Set delivery db error with fail",
+			payload: payload{
+				body: `{"method":"pickup"}`,
+				repoMock: func(delivMock *repomock.MockDelivery) {
+					delivMock.EXPECT().SetCartDelivery(gomock.Any(), gomock.Any()).Return(errors.New("error"))
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			delivRepo := repomock.NewMockDelivery(mockCtrl)
+			test.payload.repoMock(delivRepo)
+
+			req, _ := http.NewRequest(http.MethodPut, "/v1/cart/delivery", bytes.NewBufferString(test.payload.body))
+			rw := httptest.NewRecorder()
+			ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+
+			crh := NewCardHandler(&config.Config{Shipping: zones}, logger, repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl),
+				repomock.NewMockDiscount(mockCtrl), newCurrencies(mockCtrl), delivRepo, repomock.NewMockSlots(mockCtrl), repomock.NewMockSlotHolds(mockCtrl), repomock.NewMockOrders(mockCtrl), billmock.NewMockBill(mockCtrl), auditmock.NewMockAuditor(mockCtrl))
+
+			router := mux.NewRouter()
+			router.HandleFunc("/v1/cart/delivery", crh.SetDelivery)
+			router.ServeHTTP(rw, req.WithContext(ctx))
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestGetAllWithShipping(t *testing.T) {
+	type payload struct {
+		total bill.TotalInfo
+	}
+	type expected struct {
+		shipping string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name:     "Get all with courier fee with success",
+			payload:  payload{total: bill.TotalInfo{Price: "20.00", BaseTotal: 20}},
+			expected: expected{shipping: `"shipping":{"method":"courier","addressId":"3b1f0a6e-3540-11eb-adc1-0242ac120002","zone":"domestic","cost":"5.00","leftToFreeShipping":"30.00"}`},
+		},
+		{
+			name:     "Get all with free courier with success",
+			payload:  payload{total: bill.TotalInfo{Price: "50.00", BaseTotal: 50}},
+			expected: expected{shipping: `"shipping":{"method":"courier","addressId":"3b1f0a6e-3540-11eb-adc1-0242ac120002","zone":"domestic","cost":"0.00"}`},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			cartRepo := repomock.NewMockCart(mockCtrl)
+			cartRepo.EXPECT().GetCartVersion(gomock.Any()).Return(int64(1), nil)
+			cartRepo.EXPECT().GetUserProducts(gomock.Any()).Return([]entity.GetUserProduct{}, nil)
+
+			delivRepo := repomock.NewMockDelivery(mockCtrl)
+			delivRepo.EXPECT().GetCartDelivery(gomock.Any()).Return(&entity.CartDelivery{Method: "courier", AddressID: &addressUUID, Address: &addressOne}, nil)
+
+			billMock := billmock.NewMockBill(mockCtrl)
+			billMock.EXPECT().GetTotalInfo(gomock.Any(), gomock.Any(), gomock.Any(), "UA").Return(test.payload.total, nil)
+			billMock.EXPECT().GetDiscountByUser(gomock.Any()).Return(config.GeneralSale{}, cache.ErrNotFound)
+
+			req, _ := http.NewRequest(http.MethodGet, "/v1/cart/products", nil)
+			rw := httptest.NewRecorder()
+			ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+
+			crh := NewCardHandler(&config.Config{Shipping: zones}, logger, cartRepo, repomock.NewMockCart(mockCtrl),
+				repomock.NewMockDiscount(mockCtrl), newCurrencies(mockCtrl), delivRepo, repomock.NewMockSlots(mockCtrl), repomock.NewMockSlotHolds(mockCtrl), repomock.NewMockOrders(mockCtrl), billMock, auditmock.NewMockAuditor(mockCtrl))
+
+			router := mux.NewRouter()
+			router.HandleFunc("/v1/cart/products", crh.GetAll)
+			router.ServeHTTP(rw, req.WithContext(ctx))
+
+			assert.Equal(t, http.StatusOK, rw.Code)
+			assert.Contains(t, rw.Body.String(), test.expected.shipping)
+		})
+	}
+}
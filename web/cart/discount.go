@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/mshto/fruit-store/audit"
 	"github.com/mshto/fruit-store/cache"
 	"github.com/mshto/fruit-store/entity"
 	"github.com/mshto/fruit-store/repository"
@@ -14,6 +15,8 @@ import (
 	"github.com/mshto/fruit-store/web/middleware"
 )
 
+var errDiscountAlreadyAdded = errors.New("discount is already added")
+
 // AddDiscout add user discout
 func (ph cartHandler) AddDiscout(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -40,13 +43,15 @@ func (ph cartHandler) AddDiscout(w http.ResponseWriter, r *http.Request) {
 	}
 	if sale.ID != "" {
 		ph.log.Warnf("discount is already added, user: %v", userUUID)
-		response.RenderFailedResponse(w, http.StatusConflict, errors.New("discount is already added"))
+		ph.auditor.Record(r, audit.Event(entity.AuditCouponApply, dsc.ID, errDiscountAlreadyAdded))
+		response.RenderFailedResponse(w, http.StatusConflict, errDiscountAlreadyAdded)
 		return
 	}
 
 	dscRepo, err := ph.discRepo.GetDiscount(dsc.ID)
 	if err == repository.ErrNotFound {
 		ph.log.Errorf("failed to get discount, user: %v, error: %v", userUUID, err)
+		ph.auditor.Record(r, audit.Event(entity.AuditCouponApply, dsc.ID, err))
 		response.RenderFailedResponse(w, http.StatusNotFound, err)
 		return
 	}
@@ -56,6 +61,10 @@ func (ph cartHandler) AddDiscout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !ph.bumpVersion(w, r, userUUID) {
+		return
+	}
+
 	err = ph.bil.SetDiscount(userUUID, dscRepo)
 	if err != nil {
 		ph.log.Errorf("failed to set discount, user: %v, error: %v", userUUID, err)
@@ -63,5 +72,6 @@ func (ph cartHandler) AddDiscout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ph.auditor.Record(r, audit.Event(entity.AuditCouponApply, dsc.ID, nil))
 	response.RenderResponse(w, http.StatusCreated, response.EmptyResp{})
 }
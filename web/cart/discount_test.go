@@ -11,19 +11,23 @@ import (
 	loggermock "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 
+	auditmock "github.com/mshto/fruit-store/audit/mock"
 	billmock "github.com/mshto/fruit-store/bill/mock"
 	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/repository"
 	repomock "github.com/mshto/fruit-store/repository/mock"
 	"github.com/mshto/fruit-store/web/middleware"
 )
 
 func TestAddDiscout(t *testing.T) {
 	type payload struct {
-		cfg      *config.Config
-		body     []byte
-		repoMock func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount)
-		billMock func(billMock *billmock.MockBill)
-		ctxMock  func(req *http.Request) context.Context
+		cfg       *config.Config
+		body      []byte
+		repoMock  func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount)
+		billMock  func(billMock *billmock.MockBill)
+		auditMock func(auditMock *auditmock.MockAuditor)
+		ctxMock   func(req *http.Request) context.Context
 	}
 	type expected struct {
 		code int
@@ -42,11 +46,15 @@ func TestAddDiscout(t *testing.T) {
 				body: []byte(`{"id":"discout_id"}`),
 				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
 					discMock.EXPECT().GetDiscount(gomock.Any()).Return(config.GeneralSale{}, nil)
+					cartMock.EXPECT().IncrCartVersion(gomock.Any(), gomock.Any()).Return(int64(2), nil)
 				},
 				billMock: func(billMock *billmock.MockBill) {
 					billMock.EXPECT().GetDiscountByUser(gomock.Any()).Return(config.GeneralSale{}, nil)
 					billMock.EXPECT().SetDiscount(gomock.Any(), gomock.Any()).Return(nil)
 				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{Action: entity.AuditCouponApply, Target: "discout_id", Outcome: entity.AuditSuccess})
+				},
 				ctxMock: func(req *http.Request) context.Context {
 					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
 					return ctx
@@ -66,6 +74,8 @@ func TestAddDiscout(t *testing.T) {
 				},
 				billMock: func(billMock *billmock.MockBill) {
 				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+				},
 				ctxMock: func(req *http.Request) context.Context {
 					ctx := context.WithValue(req.Context(), middleware.UserUUID, "invalid")
 					return ctx
@@ -76,6 +86,53 @@ func TestAddDiscout(t *testing.T) {
 				body: `{"error":"invalid UUID length: 7"}`,
 			},
 		},
+		{
+			name: "Add discout already added with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"id":"discout_id"}`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+				},
+				billMock: func(billMock *billmock.MockBill) {
+					billMock.EXPECT().GetDiscountByUser(gomock.Any()).Return(config.GeneralSale{ID: "other"}, nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{Action: entity.AuditCouponApply, Target: "discout_id", Outcome: entity.AuditFailure, Details: "discount is already added"})
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"discount is already added"}`,
+			},
+		},
+		{
+			name: "Add discout unknown discount with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"id":"discout_id"}`),
+				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
+					discMock.EXPECT().GetDiscount("discout_id").Return(config.GeneralSale{}, repository.ErrNotFound)
+				},
+				billMock: func(billMock *billmock.MockBill) {
+					billMock.EXPECT().GetDiscountByUser(gomock.Any()).Return(config.GeneralSale{}, nil)
+				},
+				auditMock: func(auditMock *auditmock.MockAuditor) {
+					auditMock.EXPECT().Record(gomock.Any(), entity.AuditEvent{Action: entity.AuditCouponApply, Target: "discout_id", Outcome: entity.AuditFailure, Details: repository.ErrNotFound.Error()})
+				},
+				ctxMock: func(req *http.Request) context.Context {
+					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+					return ctx
+				},
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"` + repository.ErrNotFound.Error() + `"}`,
+			},
+		},
 	}
 
 	for _, test := range tc {
@@ -96,12 +153,15 @@ func TestAddDiscout(t *testing.T) {
 			billMock := billmock.NewMockBill(mockCtrl)
 			test.payload.billMock(billMock)
 
+			auditor := auditmock.NewMockAuditor(mockCtrl)
+			test.payload.auditMock(auditor)
+
 			req, _ := http.NewRequest(http.MethodGet, "url", bytes.NewBuffer(test.payload.body))
 			rw := httptest.NewRecorder()
 
 			ctx := test.payload.ctxMock(req)
 
-			crh := NewCardHandler(test.payload.cfg, logger, cartRepo, discRepo, billMock)
+			crh := NewCardHandler(test.payload.cfg, logger, cartRepo, repomock.NewMockCart(mockCtrl), discRepo, newCurrencies(mockCtrl), newDelivery(mockCtrl), repomock.NewMockSlots(mockCtrl), repomock.NewMockSlotHolds(mockCtrl), repomock.NewMockOrders(mockCtrl), billMock, auditor)
 			crh.AddDiscout(rw, req.WithContext(ctx))
 
 			assert.Equal(t, test.expected.code, rw.Code)
@@ -5,12 +5,18 @@ import (
 	"net/http"
 
 	"github.com/google/uuid"
+
+	"github.com/mshto/fruit-store/currency"
 	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/order"
+	"github.com/mshto/fruit-store/web/common/request"
 	"github.com/mshto/fruit-store/web/common/response"
 	"github.com/mshto/fruit-store/web/middleware"
 )
 
-// GetAllProducts retrieves all products from db
+// AddPayment pay for user cart and place an order of its products, delivery of cart must be selected.
+// Order reserves stock of products and books delivery slot reserved for cart unless its hold expired,
+// it is placed, paid and the cart is cleared at once.
 func (ph cartHandler) AddPayment(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	userUUID, err := uuid.Parse(ctx.Value(middleware.UserUUID).(string))
@@ -35,19 +41,150 @@ func (ph cartHandler) AddPayment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = ph.cartRepo.RemoveUserProducts(userUUID)
+	delivery, err := ph.delivRepo.GetCartDelivery(userUUID)
+	if err == entity.ErrDeliveryNotSelected {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
 	if err != nil {
-		ph.log.Errorf("failed to remove user products, user: %v, error: %v", userUUID, err)
+		ph.log.Errorf("failed to get cart delivery, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if delivery.Address != nil {
+		_, err = ph.cfg.Shipping.Zone(delivery.Address.Country)
+		if err != nil {
+			response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+	}
+	if delivery.SlotID != nil {
+		held, err := ph.slotHolds.IsHeld(*delivery.SlotID, userUUID)
+		if err != nil {
+			ph.log.Errorf("failed to check hold of delivery slot, user: %v, error: %v", userUUID, err)
+			response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		if !held {
+			response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrSlotHoldExpired)
+			return
+		}
+	}
+
+	ord, ok := ph.newOrder(w, r, userUUID, delivery)
+	if !ok {
+		return
+	}
+
+	expected, ok := ph.ifMatch(w, r, userUUID)
+	if !ok {
+		return
+	}
+
+	// checkout only validates the card, so its payment is captured as soon as the order is placed
+	capture, _ := order.Transition(order.StatusPaid, "")
+	version, err := ph.ordersRepo.CreateOrder(ord, capture, expected)
+	switch {
+	case err == entity.ErrCartVersionMismatch:
+		ph.log.Warnf("cart was changed concurrently, user: %v, expected version: %v", userUUID, expected)
+		response.RenderFailedResponse(w, http.StatusPreconditionFailed, err)
+		return
+	case err == entity.ErrOutOfStock || err == entity.ErrProductNotFound || err == entity.ErrSlotFull || err == entity.ErrSlotNotReserved:
+		response.RenderFailedResponse(w, http.StatusConflict, err)
+		return
+	case err != nil:
+		ph.log.Errorf("failed to create order, user: %v, error: %v", userUUID, err)
 		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
 		return
 	}
+	ord.Status = order.StatusPaid
+	ord.Paid = ord.Total
+	setETag(w, version)
 
+	if ord.SlotID != nil {
+		// booked place is counted instead of the hold, stale hold only expires later
+		err = ph.slotHolds.Release(*ord.SlotID, userUUID)
+		if err != nil {
+			ph.log.Warnf("failed to release booked delivery slot, user: %v, error: %v", userUUID, err)
+		}
+	}
+
+	// the order is already placed, so discount left behind is only logged
 	err = ph.bil.RemoveDiscount(userUUID)
 	if err != nil {
-		ph.log.Errorf("failed to remove discount, user: %v, error: %v", userUUID, err)
+		ph.log.Warnf("failed to remove discount, user: %v, error: %v", userUUID, err)
+	}
+
+	response.RenderResponse(w, http.StatusCreated, ord)
+}
+
+// newOrder order of user cart products and its delivery in base currency, products are taxed
+// for region query parameter or country of delivery address. Failure is rendered.
+func (ph cartHandler) newOrder(w http.ResponseWriter, r *http.Request, userUUID uuid.UUID, delivery *entity.CartDelivery) (*entity.Order, bool) {
+	products, err := ph.cartRepo.GetUserProducts(userUUID)
+	if err != nil {
+		ph.log.Errorf("failed to get user products, user: %v, error: %v", userUUID, err)
 		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
-		return
+		return nil, false
+	}
+	if len(products) == 0 {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrEmptyCart)
+		return nil, false
 	}
 
-	response.RenderResponse(w, http.StatusCreated, response.EmptyResp{})
+	rates, display, err := currency.Load(ph.currRepo, ph.cfg.Currency.BaseCode(), "")
+	if err == nil {
+		err = rates.CartToBase(products)
+	}
+	if err != nil {
+		ph.log.Errorf("failed to convert product prices, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	var country string
+	if delivery.Address != nil {
+		country = delivery.Address.Country
+	}
+	region := request.Region(r)
+	if region == "" {
+		region = country
+	}
+
+	total, err := ph.bil.GetTotalInfo(userUUID, products, display, region)
+	if err != nil {
+		ph.log.Errorf("failed to get total info, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	quote, err := ph.cfg.Shipping.Quote(delivery.Method, country, total.BaseTotal)
+	if err != nil {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return nil, false
+	}
+
+	base := rates.Base()
+	ord := &entity.Order{
+		UserID:    &userUUID,
+		Status:    order.StatusPendingPayment,
+		Currency:  base,
+		Items:     make([]entity.OrderItem, 0, len(products)),
+		Subtotal:  currency.Round(total.BaseTotal, base),
+		Tax:       currency.Round(total.BaseTax, base),
+		Shipping:  currency.Round(quote.Cost, base),
+		Method:    delivery.Method,
+		AddressID: delivery.AddressID,
+		SlotID:    delivery.SlotID,
+	}
+	ord.Total = currency.Round(ord.Subtotal+ord.Shipping, base)
+	for i := range products {
+		ord.Items = append(ord.Items, entity.OrderItem{
+			ProductID: &products[i].ProductUUID,
+			Name:      products[i].Name,
+			Price:     currency.Round(float64(products[i].Price), base),
+			Amount:    products[i].Amount,
+		})
+	}
+	return ord, true
 }
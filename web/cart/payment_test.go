@@ -3,33 +3,90 @@ package cart
 import (
 	"bytes"
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
 	loggermock "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 
+	auditmock "github.com/mshto/fruit-store/audit/mock"
+	"github.com/mshto/fruit-store/bill"
 	billmock "github.com/mshto/fruit-store/bill/mock"
 	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/order"
+	"github.com/mshto/fruit-store/repository"
 	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/shipping"
 	"github.com/mshto/fruit-store/web/middleware"
 )
 
+var (
+	orderUUID      = uuid.MustParse("9e4a7d7e-3700-11eb-adc1-0242ac120002")
+	orderCreatedAt = time.Date(2020, 12, 6, 10, 0, 0, 0, time.UTC)
+	paymentUser    = uuid.MustParse("e2d49480-2c1a-11eb-adc1-0242ac120002")
+	applesUUID     = uuid.MustParse("c3ff8f6e-2c1a-11eb-adc1-0242ac120002")
+	cartApples     = []entity.GetUserProduct{{ProductUUID: applesUUID, Name: "Apples", Price: 5, Amount: 4}}
+)
+
+type paymentMocks struct {
+	cartRepo   *repomock.MockCart
+	delivRepo  *repomock.MockDelivery
+	slotHolds  *repomock.MockSlotHolds
+	ordersRepo *repomock.MockOrders
+	bil        *billmock.MockBill
+}
+
+// placeOrder expect payment of cart to place an order of apples with id and timestamps set
+func placeOrder(m paymentMocks, expected entity.Order, err error) {
+	m.cartRepo.EXPECT().GetUserProducts(paymentUser).Return(cartApples, nil)
+	m.bil.EXPECT().GetTotalInfo(paymentUser, gomock.Any(), gomock.Any(), gomock.Any()).Return(bill.TotalInfo{BaseTotal: 20, BaseTax: 3.19}, nil)
+	paid, _ := order.Transition(order.StatusPaid, "")
+	m.ordersRepo.EXPECT().CreateOrder(&expected, paid, repository.AnyCartVersion).DoAndReturn(func(ord *entity.Order, _ entity.OrderTransition, _ int64) (int64, error) {
+		ord.ID = orderUUID
+		ord.CreatedAt = orderCreatedAt
+		ord.UpdatedAt = orderCreatedAt
+		if err != nil {
+			return 0, err
+		}
+		return 2, nil
+	})
+}
+
 func TestAddPayment(t *testing.T) {
 	type payload struct {
-		cfg      *config.Config
-		body     []byte
-		repoMock func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount)
-		billMock func(billMock *billmock.MockBill)
-		ctxMock  func(req *http.Request) context.Context
+		cfg   *config.Config
+		mocks func(m paymentMocks)
 	}
 	type expected struct {
 		code int
 		body string
 	}
 
+	courierOrder := entity.Order{
+		UserID:    &paymentUser,
+		Status:    order.StatusPendingPayment,
+		Currency:  "USD",
+		Items:     []entity.OrderItem{{ProductID: &applesUUID, Name: "Apples", Price: 5, Amount: 4}},
+		Subtotal:  20,
+		Tax:       3.19,
+		Shipping:  5,
+		Total:     25,
+		Method:    "courier",
+		AddressID: &addressUUID,
+	}
+	pickupOrder := courierOrder
+	pickupOrder.Shipping = 0
+	pickupOrder.Total = 20
+	pickupOrder.Method = "pickup"
+	pickupOrder.AddressID = nil
+	pickupOrder.SlotID = &slotUUID
+
 	tc := []struct {
 		name string
 		expected
@@ -38,23 +95,181 @@ func TestAddPayment(t *testing.T) {
 		{
 			name: "Add payment with success",
 			payload: payload{
-				cfg:  &config.Config{},
-				body: []byte(`{"number":"number"}`),
-				repoMock: func(cartMock *repomock.MockCart, discMock *repomock.MockDiscount) {
-					cartMock.EXPECT().RemoveUserProducts(gomock.Any()).Return(nil)
+				cfg: &config.Config{Shipping: zones},
+				mocks: func(m paymentMocks) {
+					m.bil.EXPECT().ValidateCard(gomock.Any()).Return(nil)
+					m.delivRepo.EXPECT().GetCartDelivery(paymentUser).Return(&entity.CartDelivery{Method: "courier", AddressID: &addressUUID, Address: &addressOne}, nil)
+					placeOrder(m, courierOrder, nil)
+					m.bil.EXPECT().RemoveDiscount(paymentUser).Return(nil)
 				},
-				billMock: func(billMock *billmock.MockBill) {
-					billMock.EXPECT().ValidateCard(gomock.Any()).Return(nil)
-					billMock.EXPECT().RemoveDiscount(gomock.Any()).Return(nil)
+			},
+			expected: expected{
+				code: http.StatusCreated,
+				body: `{"id":"9e4a7d7e-3700-11eb-adc1-0242ac120002","userId":"e2d49480-2c1a-11eb-adc1-0242ac120002","status":"paid","currency":"USD",` +
+					`"items":[{"productId":"c3ff8f6e-2c1a-11eb-adc1-0242ac120002","name":"Apples","price":5,"amount":4}],` +
+					`"subtotal":20,"tax":3.19,"shipping":5,"total":25,"paid":25,"method":"courier","addressId":"3b1f0a6e-3540-11eb-adc1-0242ac120002",` +
+					`"createdAt":"2020-12-06T10:00:00Z","updatedAt":"2020-12-06T10:00:00Z"}`,
+			},
+		},
+		{
+			name: "Add payment with reserved slot with success",
+			payload: payload{
+				cfg: &config.Config{},
+				mocks: func(m paymentMocks) {
+					m.bil.EXPECT().ValidateCard(gomock.Any()).Return(nil)
+					m.delivRepo.EXPECT().GetCartDelivery(paymentUser).Return(&entity.CartDelivery{Method: "pickup", SlotID: &slotUUID}, nil)
+					m.slotHolds.EXPECT().IsHeld(slotUUID, paymentUser).Return(true, nil)
+					placeOrder(m, pickupOrder, nil)
+					m.slotHolds.EXPECT().Release(slotUUID, paymentUser).Return(nil)
+					m.bil.EXPECT().RemoveDiscount(paymentUser).Return(nil)
 				},
-				ctxMock: func(req *http.Request) context.Context {
-					ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
-					return ctx
+			},
+			expected: expected{
+				code: http.StatusCreated,
+				body: `{"id":"9e4a7d7e-3700-11eb-adc1-0242ac120002","userId":"e2d49480-2c1a-11eb-adc1-0242ac120002","status":"paid","currency":"USD",` +
+					`"items":[{"productId":"c3ff8f6e-2c1a-11eb-adc1-0242ac120002","name":"Apples","price":5,"amount":4}],` +
+					`"subtotal":20,"tax":3.19,"shipping":0,"total":20,"paid":20,"method":"pickup","slotId":"7c2e5b5c-3610-11eb-adc1-0242ac120002",` +
+					`"createdAt":"2020-12-06T10:00:00Z","updatedAt":"2020-12-06T10:00:00Z"}`,
+			},
+		},
+		{
+			name: "Add payment without delivery with fail",
+			payload: payload{
+				cfg: &config.Config{},
+				mocks: func(m paymentMocks) {
+					m.bil.EXPECT().ValidateCard(gomock.Any()).Return(nil)
+					m.delivRepo.EXPECT().GetCartDelivery(paymentUser).Return(nil, entity.ErrDeliveryNotSelected)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"delivery is not selected"}`,
+			},
+		},
+		{
+			name: "Add payment with courier out of zones with fail",
+			payload: payload{
+				cfg: &config.Config{Shipping: shipping.Shipping{Zones: []shipping.Zone{{Name: "domestic", Countries: []string{"UA"}, Fee: 5}}}},
+				mocks: func(m paymentMocks) {
+					m.bil.EXPECT().ValidateCard(gomock.Any()).Return(nil)
+					m.delivRepo.EXPECT().GetCartDelivery(paymentUser).Return(&entity.CartDelivery{
+						Method: "courier", Address: &entity.DeliveryAddress{Address: entity.Address{Country: "PL"}},
+					}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"courier doesn't deliver to country of address"}`,
+			},
+		},
+		{
+			name: "Add payment with expired slot hold with fail",
+			payload: payload{
+				cfg: &config.Config{},
+				mocks: func(m paymentMocks) {
+					m.bil.EXPECT().ValidateCard(gomock.Any()).Return(nil)
+					m.delivRepo.EXPECT().GetCartDelivery(paymentUser).Return(&entity.CartDelivery{Method: "pickup", SlotID: &slotUUID}, nil)
+					m.slotHolds.EXPECT().IsHeld(slotUUID, paymentUser).Return(false, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"reservation of delivery slot expired"}`,
+			},
+		},
+		{
+			name: "Add payment of empty cart with fail",
+			payload: payload{
+				cfg: &config.Config{},
+				mocks: func(m paymentMocks) {
+					m.bil.EXPECT().ValidateCard(gomock.Any()).Return(nil)
+					m.delivRepo.EXPECT().GetCartDelivery(paymentUser).Return(&entity.CartDelivery{Method: "pickup"}, nil)
+					m.cartRepo.EXPECT().GetUserProducts(paymentUser).Return([]entity.GetUserProduct{}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"cart is empty"}`,
+			},
+		},
+		{
+			name: "Add payment with full slot with fail",
+			payload: payload{
+				cfg: &config.Config{},
+				mocks: func(m paymentMocks) {
+					m.bil.EXPECT().ValidateCard(gomock.Any()).Return(nil)
+					m.delivRepo.EXPECT().GetCartDelivery(paymentUser).Return(&entity.CartDelivery{Method: "pickup", SlotID: &slotUUID}, nil)
+					m.slotHolds.EXPECT().IsHeld(slotUUID, paymentUser).Return(true, nil)
+					placeOrder(m, pickupOrder, entity.ErrSlotFull)
+				},
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"delivery slot is full"}`,
+			},
+		},
+		{
+			name: "Add payment without enough stock with fail",
+			payload: payload{
+				cfg: &config.Config{Shipping: zones},
+				mocks: func(m paymentMocks) {
+					m.bil.EXPECT().ValidateCard(gomock.Any()).Return(nil)
+					m.delivRepo.EXPECT().GetCartDelivery(paymentUser).Return(&entity.CartDelivery{Method: "courier", AddressID: &addressUUID, Address: &addressOne}, nil)
+					placeOrder(m, courierOrder, entity.ErrOutOfStock)
+				},
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"product is out of stock"}`,
+			},
+		},
+		{
+			name: "Add payment with stale cart version with fail",
+			payload: payload{
+				cfg: &config.Config{Shipping: zones},
+				mocks: func(m paymentMocks) {
+					m.bil.EXPECT().ValidateCard(gomock.Any()).Return(nil)
+					m.delivRepo.EXPECT().GetCartDelivery(paymentUser).Return(&entity.CartDelivery{Method: "courier", AddressID: &addressUUID, Address: &addressOne}, nil)
+					placeOrder(m, courierOrder, entity.ErrCartVersionMismatch)
+				},
+			},
+			expected: expected{
+				code: http.StatusPreconditionFailed,
+				body: `{"error":"cart version mismatch"}`,
+			},
+		},
+		{
+			name: "Add payment create order error with fail",
+			payload: payload{
+				cfg: &config.Config{Shipping: zones},
+				mocks: func(m paymentMocks) {
+					m.bil.EXPECT().ValidateCard(gomock.Any()).Return(nil)
+					m.delivRepo.EXPECT().GetCartDelivery(paymentUser).Return(&entity.CartDelivery{Method: "courier", AddressID: &addressUUID, Address: &addressOne}, nil)
+					placeOrder(m, courierOrder, errors.New("error"))
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+		{
+			name: "Add payment remove discount error with success",
+			payload: payload{
+				cfg: &config.Config{Shipping: zones},
+				mocks: func(m paymentMocks) {
+					m.bil.EXPECT().ValidateCard(gomock.Any()).Return(nil)
+					m.delivRepo.EXPECT().GetCartDelivery(paymentUser).Return(&entity.CartDelivery{Method: "courier", AddressID: &addressUUID, Address: &addressOne}, nil)
+					placeOrder(m, courierOrder, nil)
+					m.bil.EXPECT().RemoveDiscount(paymentUser).Return(errors.New("error"))
 				},
 			},
 			expected: expected{
 				code: http.StatusCreated,
-				body: `{}`,
+				body: `{"id":"9e4a7d7e-3700-11eb-adc1-0242ac120002","userId":"e2d49480-2c1a-11eb-adc1-0242ac120002","status":"paid","currency":"USD",` +
+					`"items":[{"productId":"c3ff8f6e-2c1a-11eb-adc1-0242ac120002","name":"Apples","price":5,"amount":4}],` +
+					`"subtotal":20,"tax":3.19,"shipping":5,"total":25,"paid":25,"method":"courier","addressId":"3b1f0a6e-3540-11eb-adc1-0242ac120002",` +
+					`"createdAt":"2020-12-06T10:00:00Z","updatedAt":"2020-12-06T10:00:00Z"}`,
 			},
 		},
 	}
@@ -69,20 +284,21 @@ func TestAddPayment(t *testing.T) {
 
 			logger, _ := loggermock.NewNullLogger()
 
-			cartRepo := repomock.NewMockCart(mockCtrl)
-			discRepo := repomock.NewMockDiscount(mockCtrl)
-
-			test.payload.repoMock(cartRepo, discRepo)
+			m := paymentMocks{
+				cartRepo:   repomock.NewMockCart(mockCtrl),
+				delivRepo:  repomock.NewMockDelivery(mockCtrl),
+				slotHolds:  repomock.NewMockSlotHolds(mockCtrl),
+				ordersRepo: repomock.NewMockOrders(mockCtrl),
+				bil:        billmock.NewMockBill(mockCtrl),
+			}
+			test.payload.mocks(m)
 
-			billMock := billmock.NewMockBill(mockCtrl)
-			test.payload.billMock(billMock)
-
-			req, _ := http.NewRequest(http.MethodGet, "url", bytes.NewBuffer(test.payload.body))
+			req, _ := http.NewRequest(http.MethodPost, "url", bytes.NewBufferString(`{"number":"number"}`))
 			rw := httptest.NewRecorder()
+			ctx := context.WithValue(req.Context(), middleware.UserUUID, paymentUser.String())
 
-			ctx := test.payload.ctxMock(req)
-
-			crh := NewCardHandler(test.payload.cfg, logger, cartRepo, discRepo, billMock)
+			crh := NewCardHandler(test.payload.cfg, logger, m.cartRepo, repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), newCurrencies(mockCtrl),
+				m.delivRepo, repomock.NewMockSlots(mockCtrl), m.slotHolds, m.ordersRepo, m.bil, auditmock.NewMockAuditor(mockCtrl))
 			crh.AddPayment(rw, req.WithContext(ctx))
 
 			assert.Equal(t, test.expected.code, rw.Code)
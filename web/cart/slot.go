@@ -0,0 +1,190 @@
+package cart
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/web/common/request"
+	"github.com/mshto/fruit-store/web/common/response"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+// slotDays days of upcoming delivery slots listed without date
+const slotDays = 7
+
+// GetSlots retrieves upcoming delivery slots of date query parameter or of the next week with count of available places
+func (ph cartHandler) GetSlots(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userUUID, err := uuid.Parse(ctx.Value(middleware.UserUUID).(string))
+	if err != nil {
+		ph.log.Errorf("failed to get user uuid, user: %v, error: %v", ctx.Value(middleware.UserUUID).(string), err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	date, err := request.Date(r)
+	if err != nil {
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	from, to := time.Now(), time.Now().AddDate(0, 0, slotDays)
+	if !date.IsZero() {
+		to = date.AddDate(0, 0, 1)
+		if date.After(from) {
+			from = date
+		}
+	}
+
+	slots, err := ph.slotsRepo.GetSlots(from, to)
+	if err != nil {
+		ph.log.Errorf("failed to get delivery slots, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(slots))
+	for _, slot := range slots {
+		ids = append(ids, slot.ID)
+	}
+	held, err := ph.slotHolds.CountHeld(ids)
+	if err != nil {
+		ph.log.Errorf("failed to count holds of delivery slots, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	available := make([]entity.AvailableSlot, 0, len(slots))
+	for _, slot := range slots {
+		places := slot.Capacity - slot.Booked - held[slot.ID]
+		if places < 0 {
+			places = 0
+		}
+		available = append(available, entity.AvailableSlot{DeliverySlot: slot, Available: places})
+	}
+
+	response.RenderResponse(w, http.StatusOK, available)
+}
+
+// ReserveSlot hold delivery slot for user cart until payment or hold expiration, hold of other slot is released
+func (ph cartHandler) ReserveSlot(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userUUID, err := uuid.Parse(ctx.Value(middleware.UserUUID).(string))
+	if err != nil {
+		ph.log.Errorf("failed to get user uuid, user: %v, error: %v", ctx.Value(middleware.UserUUID).(string), err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	reservation := entity.SlotReservation{}
+	err = json.NewDecoder(r.Body).Decode(&reservation)
+	if err != nil {
+		ph.log.Errorf("failed to decode slot reservation, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	delivery, err := ph.delivRepo.GetCartDelivery(userUUID)
+	if err == entity.ErrDeliveryNotSelected {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err != nil {
+		ph.log.Errorf("failed to get cart delivery, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	slot, err := ph.slotsRepo.GetSlot(reservation.SlotID)
+	if err == entity.ErrSlotNotFound {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err != nil {
+		ph.log.Errorf("failed to get delivery slot, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !slot.StartsAt.After(time.Now()) {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrSlotStarted)
+		return
+	}
+
+	if delivery.SlotID != nil && *delivery.SlotID != slot.ID {
+		err = ph.slotHolds.Release(*delivery.SlotID, userUUID)
+		if err != nil {
+			ph.log.Errorf("failed to release delivery slot, user: %v, error: %v", userUUID, err)
+			response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	reservation.ExpiresAt, err = ph.slotHolds.Hold(slot.ID, userUUID, slot.Capacity-slot.Booked)
+	if err == entity.ErrSlotFull {
+		response.RenderFailedResponse(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		ph.log.Errorf("failed to hold delivery slot, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = ph.slotsRepo.SetCartSlot(userUUID, &slot.ID)
+	if err != nil {
+		_ = ph.slotHolds.Release(slot.ID, userUUID) // nolint
+	}
+	if err == entity.ErrSlotNotFound || err == entity.ErrDeliveryNotSelected {
+		response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err != nil {
+		ph.log.Errorf("failed to set cart slot, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, reservation)
+}
+
+// ReleaseSlot release delivery slot reserved for user cart
+func (ph cartHandler) ReleaseSlot(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userUUID, err := uuid.Parse(ctx.Value(middleware.UserUUID).(string))
+	if err != nil {
+		ph.log.Errorf("failed to get user uuid, user: %v, error: %v", ctx.Value(middleware.UserUUID).(string), err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	delivery, err := ph.delivRepo.GetCartDelivery(userUUID)
+	if err != nil && err != entity.ErrDeliveryNotSelected {
+		ph.log.Errorf("failed to get cart delivery, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err == entity.ErrDeliveryNotSelected || delivery.SlotID == nil {
+		response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+		return
+	}
+
+	err = ph.slotsRepo.SetCartSlot(userUUID, nil)
+	if err != nil {
+		ph.log.Errorf("failed to remove cart slot, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = ph.slotHolds.Release(*delivery.SlotID, userUUID)
+	if err != nil {
+		ph.log.Errorf("failed to release delivery slot, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusNoContent, response.EmptyResp{})
+}
@@ -0,0 +1,300 @@
+package cart
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	auditmock "github.com/mshto/fruit-store/audit/mock"
+	billmock "github.com/mshto/fruit-store/bill/mock"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+var (
+	slotUUID      = uuid.MustParse("7c2e5b5c-3610-11eb-adc1-0242ac120002")
+	otherSlotUUID = uuid.MustParse("8d3f6c6d-3610-11eb-adc1-0242ac120002")
+	slotStartsAt  = time.Date(2099, 12, 5, 9, 0, 0, 0, time.UTC)
+	slotOne       = entity.DeliverySlot{ID: slotUUID, StartsAt: slotStartsAt, EndsAt: slotStartsAt.Add(2 * time.Hour), Capacity: 5, Booked: 2}
+	slotUser      = uuid.MustParse("e2d49480-2c1a-11eb-adc1-0242ac120002")
+)
+
+type slotMocks struct {
+	delivRepo *repomock.MockDelivery
+	slotsRepo *repomock.MockSlots
+	slotHolds *repomock.MockSlotHolds
+}
+
+// serveSlot serve request of user to delivery slot handler
+func serveSlot(t *testing.T, method, url, body string, handler func(Service) http.HandlerFunc, mock func(m slotMocks)) *httptest.ResponseRecorder {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	logger, _ := loggermock.NewNullLogger()
+
+	m := slotMocks{
+		delivRepo: repomock.NewMockDelivery(mockCtrl),
+		slotsRepo: repomock.NewMockSlots(mockCtrl),
+		slotHolds: repomock.NewMockSlotHolds(mockCtrl),
+	}
+	mock(m)
+
+	req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+	ctx := context.WithValue(req.Context(), middleware.UserUUID, slotUser.String())
+	rw := httptest.NewRecorder()
+
+	crh := NewCardHandler(&config.Config{}, logger, repomock.NewMockCart(mockCtrl), repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl),
+		newCurrencies(mockCtrl), m.delivRepo, m.slotsRepo, m.slotHolds, repomock.NewMockOrders(mockCtrl), billmock.NewMockBill(mockCtrl), auditmock.NewMockAuditor(mockCtrl))
+	handler(crh)(rw, req.WithContext(ctx))
+	return rw
+}
+
+func TestGetSlots(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		url  string
+		mock func(m slotMocks)
+		expected
+	}{
+		{
+			name: "Get slots of date with success",
+			url:  "/v1/cart/delivery/slots?date=2099-12-05",
+			mock: func(m slotMocks) {
+				full := entity.DeliverySlot{ID: otherSlotUUID, StartsAt: slotStartsAt.Add(2 * time.Hour), EndsAt: slotStartsAt.Add(4 * time.Hour), Capacity: 1}
+				m.slotsRepo.EXPECT().GetSlots(time.Date(2099, 12, 5, 0, 0, 0, 0, time.UTC), time.Date(2099, 12, 6, 0, 0, 0, 0, time.UTC)).
+					Return([]entity.DeliverySlot{slotOne, full}, nil)
+				m.slotHolds.EXPECT().CountHeld([]uuid.UUID{slotUUID, otherSlotUUID}).Return(map[uuid.UUID]int{slotUUID: 1, otherSlotUUID: 2}, nil)
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `[{"id":"7c2e5b5c-3610-11eb-adc1-0242ac120002","startsAt":"2099-12-05T09:00:00Z","endsAt":"2099-12-05T11:00:00Z","capacity":5,"booked":2,"available":2},` +
+					`{"id":"8d3f6c6d-3610-11eb-adc1-0242ac120002","startsAt":"2099-12-05T11:00:00Z","endsAt":"2099-12-05T13:00:00Z","capacity":1,"booked":0,"available":0}]`,
+			},
+		},
+		{
+			name: "Get upcoming slots with success",
+			url:  "/v1/cart/delivery/slots",
+			mock: func(m slotMocks) {
+				m.slotsRepo.EXPECT().GetSlots(gomock.Any(), gomock.Any()).Return([]entity.DeliverySlot{}, nil)
+				m.slotHolds.EXPECT().CountHeld([]uuid.UUID{}).Return(map[uuid.UUID]int{}, nil)
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `[]`,
+			},
+		},
+		{
+			name: "Get slots of invalid date with fail",
+			url:  "/v1/cart/delivery/slots?date=tomorrow",
+			mock: func(m slotMocks) {},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"date must be in format 2006-01-02"}`,
+			},
+		},
+		{
+			name: "Get slots db error with fail",
+			url:  "/v1/cart/delivery/slots",
+			mock: func(m slotMocks) {
+				m.slotsRepo.EXPECT().GetSlots(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rw := serveSlot(t, http.MethodGet, test.url, "", func(s Service) http.HandlerFunc { return s.GetSlots }, test.mock)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestReserveSlot(t *testing.T) {
+	type expected struct {
+		code int
+		body string
+	}
+	body := `{"slotId":"7c2e5b5c-3610-11eb-adc1-0242ac120002"}`
+	expiresAt := time.Date(2020, 12, 5, 9, 15, 0, 0, time.UTC)
+
+	tc := []struct {
+		name string
+		mock func(m slotMocks)
+		expected
+	}{
+		{
+			name: "Reserve slot with success",
+			mock: func(m slotMocks) {
+				m.delivRepo.EXPECT().GetCartDelivery(slotUser).Return(&entity.CartDelivery{Method: "pickup"}, nil)
+				m.slotsRepo.EXPECT().GetSlot(slotUUID).Return(&slotOne, nil)
+				m.slotHolds.EXPECT().Hold(slotUUID, slotUser, 3).Return(expiresAt, nil)
+				m.slotsRepo.EXPECT().SetCartSlot(slotUser, &slotUUID).Return(nil)
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"slotId":"7c2e5b5c-3610-11eb-adc1-0242ac120002","expiresAt":"2020-12-05T09:15:00Z"}`,
+			},
+		},
+		{
+			name: "Reserve other slot with success",
+			mock: func(m slotMocks) {
+				m.delivRepo.EXPECT().GetCartDelivery(slotUser).Return(&entity.CartDelivery{Method: "pickup", SlotID: &otherSlotUUID}, nil)
+				m.slotsRepo.EXPECT().GetSlot(slotUUID).Return(&slotOne, nil)
+				m.slotHolds.EXPECT().Release(otherSlotUUID, slotUser).Return(nil)
+				m.slotHolds.EXPECT().Hold(slotUUID, slotUser, 3).Return(expiresAt, nil)
+				m.slotsRepo.EXPECT().SetCartSlot(slotUser, &slotUUID).Return(nil)
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"slotId":"7c2e5b5c-3610-11eb-adc1-0242ac120002","expiresAt":"2020-12-05T09:15:00Z"}`,
+			},
+		},
+		{
+			name: "Reserve slot without delivery with fail",
+			mock: func(m slotMocks) {
+				m.delivRepo.EXPECT().GetCartDelivery(slotUser).Return(nil, entity.ErrDeliveryNotSelected)
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"delivery is not selected"}`,
+			},
+		},
+		{
+			name: "Reserve unknown slot with fail",
+			mock: func(m slotMocks) {
+				m.delivRepo.EXPECT().GetCartDelivery(slotUser).Return(&entity.CartDelivery{Method: "pickup"}, nil)
+				m.slotsRepo.EXPECT().GetSlot(slotUUID).Return(nil, entity.ErrSlotNotFound)
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"delivery slot not found"}`,
+			},
+		},
+		{
+			name: "Reserve started slot with fail",
+			mock: func(m slotMocks) {
+				started := slotOne
+				started.StartsAt = time.Now().Add(-time.Minute)
+				m.delivRepo.EXPECT().GetCartDelivery(slotUser).Return(&entity.CartDelivery{Method: "pickup"}, nil)
+				m.slotsRepo.EXPECT().GetSlot(slotUUID).Return(&started, nil)
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"delivery slot already started"}`,
+			},
+		},
+		{
+			name: "Reserve full slot with fail",
+			mock: func(m slotMocks) {
+				m.delivRepo.EXPECT().GetCartDelivery(slotUser).Return(&entity.CartDelivery{Method: "pickup"}, nil)
+				m.slotsRepo.EXPECT().GetSlot(slotUUID).Return(&slotOne, nil)
+				m.slotHolds.EXPECT().Hold(slotUUID, slotUser, 3).Return(time.Time{}, entity.ErrSlotFull)
+			},
+			expected: expected{
+				code: http.StatusConflict,
+				body: `{"error":"delivery slot is full"}`,
+			},
+		},
+		{
+			name: "Reserve slot db error releases hold with fail",
+			mock: func(m slotMocks) {
+				m.delivRepo.EXPECT().GetCartDelivery(slotUser).Return(&entity.CartDelivery{Method: "pickup"}, nil)
+				m.slotsRepo.EXPECT().GetSlot(slotUUID).Return(&slotOne, nil)
+				m.slotHolds.EXPECT().Hold(slotUUID, slotUser, 3).Return(expiresAt, nil)
+				m.slotsRepo.EXPECT().SetCartSlot(slotUser, &slotUUID).Return(errors.New("error"))
+				m.slotHolds.EXPECT().Release(slotUUID, slotUser).Return(nil)
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rw := serveSlot(t, http.MethodPut, "/v1/cart/delivery/slot", body, func(s Service) http.HandlerFunc { return s.ReserveSlot }, test.mock)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestReleaseSlot(t *testing.T) {
+	tc := []struct {
+		name string
+		mock func(m slotMocks)
+		code int
+	}{
+		{
+			name: "Release slot with success",
+			mock: func(m slotMocks) {
+				m.delivRepo.EXPECT().GetCartDelivery(slotUser).Return(&entity.CartDelivery{Method: "pickup", SlotID: &slotUUID}, nil)
+				m.slotsRepo.EXPECT().SetCartSlot(slotUser, nil).Return(nil)
+				m.slotHolds.EXPECT().Release(slotUUID, slotUser).Return(nil)
+			},
+			code: http.StatusNoContent,
+		},
+		{
+			name: "Release not reserved slot with success",
+			mock: func(m slotMocks) {
+				m.delivRepo.EXPECT().GetCartDelivery(slotUser).Return(&entity.CartDelivery{Method: "pickup"}, nil)
+			},
+			code: http.StatusNoContent,
+		},
+		{
+			name: "Release slot without delivery with success",
+			mock: func(m slotMocks) {
+				m.delivRepo.EXPECT().GetCartDelivery(slotUser).Return(nil, entity.ErrDeliveryNotSelected)
+			},
+			code: http.StatusNoContent,
+		},
+		{
+			name: "Release slot db error with fail",
+			mock: func(m slotMocks) {
+				m.delivRepo.EXPECT().GetCartDelivery(slotUser).Return(nil, errors.New("error"))
+			},
+			code: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rw := serveSlot(t, http.MethodDelete, "/v1/cart/delivery/slot", "", func(s Service) http.HandlerFunc { return s.ReleaseSlot }, test.mock)
+
+			assert.Equal(t, test.code, rw.Code)
+		})
+	}
+}
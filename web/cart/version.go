@@ -0,0 +1,133 @@
+package cart
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/repository"
+	"github.com/mshto/fruit-store/web/common/response"
+)
+
+const (
+	etagHeader    = "ETag"
+	ifMatchHeader = "If-Match"
+)
+
+// version errors
+var (
+	ErrInvalidIfMatch = errors.New("invalid If-Match header")
+)
+
+// setVersion sets current cart version as ETag header
+func (ph cartHandler) setVersion(w http.ResponseWriter, r *http.Request, userUUID uuid.UUID) bool {
+	version, err := ph.getCartRepo(r.Context()).GetCartVersion(userUUID)
+	if err != nil {
+		ph.log.Errorf("failed to get cart version, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return false
+	}
+
+	setETag(w, version)
+	return true
+}
+
+// ifMatch returns cart version expected by If-Match header, cart mutation checks and increments it in one step.
+// Header listing a few versions expects the current one if it's listed.
+func (ph cartHandler) ifMatch(w http.ResponseWriter, r *http.Request, userUUID uuid.UUID) (int64, bool) {
+	versions, err := parseIfMatch(r.Header.Get(ifMatchHeader))
+	if err != nil {
+		ph.log.Errorf("failed to parse If-Match header, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return 0, false
+	}
+
+	switch {
+	case versions == nil:
+		return repository.AnyCartVersion, true
+	case len(versions) == 1:
+		return versions[0], true
+	case len(versions) > 1:
+		current, err := ph.getCartRepo(r.Context()).GetCartVersion(userUUID)
+		if err != nil {
+			ph.log.Errorf("failed to get cart version, user: %v, error: %v", userUUID, err)
+			response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+			return 0, false
+		}
+		for _, version := range versions {
+			if version == current {
+				return current, true
+			}
+		}
+	}
+
+	ph.log.Warnf("cart version doesn't match If-Match header, user: %v, If-Match: %v", userUUID, r.Header.Get(ifMatchHeader))
+	response.RenderFailedResponse(w, http.StatusPreconditionFailed, entity.ErrCartVersionMismatch)
+	return 0, false
+}
+
+// bumpVersion honors If-Match header and increments cart version for changes which aren't stored with the cart,
+// they are validated before, so only failure to store the change leaves the version incremented
+func (ph cartHandler) bumpVersion(w http.ResponseWriter, r *http.Request, userUUID uuid.UUID) bool {
+	expected, ok := ph.ifMatch(w, r, userUUID)
+	if !ok {
+		return false
+	}
+
+	version, err := ph.getCartRepo(r.Context()).IncrCartVersion(userUUID, expected)
+	if err == entity.ErrCartVersionMismatch {
+		ph.log.Warnf("cart was changed concurrently, user: %v, expected version: %v", userUUID, expected)
+		response.RenderFailedResponse(w, http.StatusPreconditionFailed, err)
+		return false
+	}
+	if err != nil {
+		ph.log.Errorf("failed to increment cart version, user: %v, error: %v", userUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return false
+	}
+
+	setETag(w, version)
+	return true
+}
+
+// setETag sets cart version as ETag header
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set(etagHeader, formatETag(version))
+}
+
+func formatETag(version int64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// parseIfMatch returns versions listed in If-Match header, missing header or "*" match any version and give nil.
+// If-Match compares ETags strongly, so weak ones never match and aren't returned.
+func parseIfMatch(value string) ([]int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+
+	versions := []int64{}
+	for _, etag := range strings.Split(value, ",") {
+		etag = strings.TrimSpace(etag)
+		weak := strings.HasPrefix(etag, "W/")
+		etag = strings.TrimPrefix(etag, "W/")
+		if len(etag) < 2 || !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) {
+			return nil, ErrInvalidIfMatch
+		}
+
+		version, err := strconv.ParseInt(etag[1:len(etag)-1], 10, 64)
+		if err != nil || version < 0 {
+			return nil, ErrInvalidIfMatch
+		}
+		if !weak {
+			versions = append(versions, version)
+		}
+	}
+	return versions, nil
+}
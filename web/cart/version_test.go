@@ -0,0 +1,209 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	auditmock "github.com/mshto/fruit-store/audit/mock"
+	billmock "github.com/mshto/fruit-store/bill/mock"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/repository"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/web/middleware"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tc := []struct {
+		name     string
+		value    string
+		expected []int64
+		isErr    bool
+	}{
+		{name: "Parse empty If-Match with success", value: ""},
+		{name: "Parse any If-Match with success", value: "*"},
+		{name: "Parse strong If-Match with success", value: `"3"`, expected: []int64{3}},
+		{name: "Parse weak If-Match with success", value: `W/"4"`, expected: []int64{}},
+		{name: "Parse list If-Match with success", value: `"3", W/"4" ,"5"`, expected: []int64{3, 5}},
+		{name: "Parse unquoted If-Match with failed", value: "3", isErr: true},
+		{name: "Parse negative If-Match with failed", value: `"-3"`, isErr: true},
+		{name: "Parse invalid If-Match with failed", value: `"abc"`, isErr: true},
+		{name: "Parse list with invalid If-Match with failed", value: `"3", *`, isErr: true},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			versions, err := parseIfMatch(test.value)
+			if test.isErr {
+				assert.Equal(t, ErrInvalidIfMatch, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, versions)
+		})
+	}
+}
+
+func TestCartVersion(t *testing.T) {
+	type payload struct {
+		method   string
+		ifMatch  string
+		repoMock func(cartMock *repomock.MockCart)
+	}
+	type expected struct {
+		code int
+		etag string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Remove product with matched version with success",
+			payload: payload{
+				method:  http.MethodDelete,
+				ifMatch: `"3"`,
+				repoMock: func(cartMock *repomock.MockCart) {
+					cartMock.EXPECT().RemoveUserProduct(gomock.Any(), gomock.Any(), int64(3)).Return(int64(4), nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				etag: `"4"`,
+			},
+		},
+		{
+			name: "Add one product with stale version with fail",
+			payload: payload{
+				method:  http.MethodPost,
+				ifMatch: `"3"`,
+				repoMock: func(cartMock *repomock.MockCart) {
+					cartMock.EXPECT().CreateUserProduct(gomock.Any(), gomock.Any(), int64(3)).Return(int64(0), entity.ErrCartVersionMismatch)
+				},
+			},
+			expected: expected{
+				code: http.StatusPreconditionFailed,
+			},
+		},
+		{
+			name: "Remove product with listed version with success",
+			payload: payload{
+				method:  http.MethodDelete,
+				ifMatch: `"2", "3"`,
+				repoMock: func(cartMock *repomock.MockCart) {
+					cartMock.EXPECT().GetCartVersion(gomock.Any()).Return(int64(3), nil)
+					cartMock.EXPECT().RemoveUserProduct(gomock.Any(), gomock.Any(), int64(3)).Return(int64(4), nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+				etag: `"4"`,
+			},
+		},
+		{
+			name: "Remove product with unlisted version with fail",
+			payload: payload{
+				method:  http.MethodDelete,
+				ifMatch: `"1", "2"`,
+				repoMock: func(cartMock *repomock.MockCart) {
+					cartMock.EXPECT().GetCartVersion(gomock.Any()).Return(int64(3), nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusPreconditionFailed,
+			},
+		},
+		{
+			name: "Remove product with weak version with fail",
+			payload: payload{
+				method:   http.MethodDelete,
+				ifMatch:  `W/"3"`,
+				repoMock: func(cartMock *repomock.MockCart) {},
+			},
+			expected: expected{
+				code: http.StatusPreconditionFailed,
+			},
+		},
+		{
+			name: "Add one product with invalid If-Match with fail",
+			payload: payload{
+				method:  http.MethodPost,
+				ifMatch: "invalid",
+				repoMock: func(cartMock *repomock.MockCart) {
+				},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Add one product CreateUserProduct error with fail",
+			payload: payload{
+				method: http.MethodPost,
+				repoMock: func(cartMock *repomock.MockCart) {
+					cartMock.EXPECT().CreateUserProduct(gomock.Any(), gomock.Any(), repository.AnyCartVersion).Return(int64(0), errors.New("error"))
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+			},
+		},
+		{
+			name: "Get all GetCartVersion error with fail",
+			payload: payload{
+				method: http.MethodGet,
+				repoMock: func(cartMock *repomock.MockCart) {
+					cartMock.EXPECT().GetCartVersion(gomock.Any()).Return(int64(0), errors.New("error"))
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			cartRepo := repomock.NewMockCart(mockCtrl)
+			test.payload.repoMock(cartRepo)
+
+			req, _ := http.NewRequest(test.payload.method, "/v1/cart/products/e2d49480-2c1a-11eb-adc1-0242ac120002", nil)
+			req.Header.Set(ifMatchHeader, test.payload.ifMatch)
+			rw := httptest.NewRecorder()
+
+			ctx := context.WithValue(req.Context(), middleware.UserUUID, "e2d49480-2c1a-11eb-adc1-0242ac120002")
+
+			crh := NewCardHandler(&config.Config{}, logger, cartRepo, repomock.NewMockCart(mockCtrl), repomock.NewMockDiscount(mockCtrl), newCurrencies(mockCtrl), newDelivery(mockCtrl), repomock.NewMockSlots(mockCtrl), repomock.NewMockSlotHolds(mockCtrl), repomock.NewMockOrders(mockCtrl), billmock.NewMockBill(mockCtrl), auditmock.NewMockAuditor(mockCtrl))
+
+			router := mux.NewRouter()
+			router.HandleFunc("/v1/cart/products/{productID}", crh.GetAll).Methods(http.MethodGet)
+			router.HandleFunc("/v1/cart/products/{productID}", crh.AddOneProduct).Methods(http.MethodPost)
+			router.HandleFunc("/v1/cart/products/{productID}", crh.RemoveProduct).Methods(http.MethodDelete)
+			router.ServeHTTP(rw, req.WithContext(ctx))
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.etag, rw.Header().Get(etagHeader))
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package request
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+const (
+	forwardedForHeader   = "X-Forwarded-For"
+	acceptCurrencyHeader = "Accept-Currency"
+	currencyParam        = "currency"
+	regionParam          = "region"
+	dateParam            = "date"
+	dateLayout           = "2006-01-02"
+)
+
+// GetClient returns user agent and ip address of request
+func GetClient(r *http.Request) entity.Client {
+	return entity.Client{
+		UserAgent: r.UserAgent(),
+		IP:        ClientIP(r),
+	}
+}
+
+// ClientIP returns remote address of request, it is the client address once RealIP resolved it behind trusted proxies
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// TrustedProxies networks of reverse proxies whose X-Forwarded-For header is trusted
+type TrustedProxies []*net.IPNet
+
+// NewTrustedProxies parses addresses and CIDR networks of trusted proxies
+func NewTrustedProxies(addrs []string) (TrustedProxies, error) {
+	proxies := TrustedProxies{}
+	for _, addr := range addrs {
+		if !strings.Contains(addr, "/") {
+			ip := net.ParseIP(addr)
+			if ip == nil {
+				return nil, &net.ParseError{Type: "IP address", Text: addr}
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(addr)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// Contains whether ip belongs to a trusted proxy
+func (tp TrustedProxies) Contains(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range tp {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// RealIP returns client address of request. X-Forwarded-For is read only when request comes from a trusted proxy,
+// its rightmost address that isn't a trusted proxy is the client one, as addresses left of it may be forged by the client.
+func (tp TrustedProxies) RealIP(r *http.Request) string {
+	remote := ClientIP(r)
+	if !tp.Contains(remote) {
+		return remote
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header[forwardedForHeader], ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(ip) == nil {
+			break
+		}
+		if !tp.Contains(ip) {
+			return ip
+		}
+		remote = ip
+	}
+	return remote
+}
+
+// Currency returns currency of currency query parameter or the first one of Accept-Currency header,
+// empty currency is the base one
+func Currency(r *http.Request) string {
+	if code := r.URL.Query().Get(currencyParam); code != "" {
+		return code
+	}
+	code := strings.Split(r.Header.Get(acceptCurrencyHeader), ",")[0]
+	return strings.TrimSpace(strings.Split(code, ";")[0])
+}
+
+// Region returns tax region of region query parameter, empty region is the configured one
+func Region(r *http.Request) string {
+	return strings.TrimSpace(r.URL.Query().Get(regionParam))
+}
+
+// Date returns UTC midnight of date query parameter, missing date is zero time
+func Date(r *http.Request) (time.Time, error) {
+	date := r.URL.Query().Get(dateParam)
+	if date == "" {
+		return time.Time{}, nil
+	}
+
+	day, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return time.Time{}, entity.ErrInvalidDate
+	}
+	return day, nil
+}
@@ -0,0 +1,228 @@
+package request
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+)
+
+func TestGetClient(t *testing.T) {
+	type payload struct {
+		remoteAddr string
+		headers    map[string]string
+	}
+
+	tc := []struct {
+		name     string
+		expected entity.Client
+		payload
+	}{
+		{
+			name: "Get client remote address with success",
+			payload: payload{
+				remoteAddr: "10.0.0.1:5432",
+				headers:    map[string]string{"User-Agent": "agent"},
+			},
+			expected: entity.Client{UserAgent: "agent", IP: "10.0.0.1"},
+		},
+		{
+			name: "Get client ignores forwarded address without trusted proxy with success",
+			payload: payload{
+				remoteAddr: "10.0.0.1:5432",
+				headers:    map[string]string{"X-Forwarded-For": "192.168.0.1, 10.0.0.2"},
+			},
+			expected: entity.Client{IP: "10.0.0.1"},
+		},
+		{
+			name: "Get client address without port with success",
+			payload: payload{
+				remoteAddr: "10.0.0.1",
+			},
+			expected: entity.Client{IP: "10.0.0.1"},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, _ := http.NewRequest(http.MethodGet, "url", nil)
+			req.RemoteAddr = test.payload.remoteAddr
+			for key, value := range test.payload.headers {
+				req.Header.Set(key, value)
+			}
+
+			assert.Equal(t, test.expected, GetClient(req))
+		})
+	}
+}
+
+func TestRealIP(t *testing.T) {
+	proxies, err := NewTrustedProxies([]string{"10.0.0.0/24", "172.16.0.1"})
+	assert.NoError(t, err)
+
+	type payload struct {
+		proxies    TrustedProxies
+		remoteAddr string
+		forwarded  []string
+	}
+
+	tc := []struct {
+		name     string
+		expected string
+		payload
+	}{
+		{
+			name:     "Ignore spoofed forwarded address without trusted proxies with success",
+			expected: "203.0.113.7",
+			payload: payload{
+				proxies:    nil,
+				remoteAddr: "203.0.113.7:5432",
+				forwarded:  []string{"192.168.0.1"},
+			},
+		},
+		{
+			name:     "Ignore forwarded address of untrusted remote address with success",
+			expected: "203.0.113.7",
+			payload: payload{
+				proxies:    proxies,
+				remoteAddr: "203.0.113.7:5432",
+				forwarded:  []string{"192.168.0.1"},
+			},
+		},
+		{
+			name:     "Get forwarded address of trusted proxy with success",
+			expected: "198.51.100.2",
+			payload: payload{
+				proxies:    proxies,
+				remoteAddr: "10.0.0.1:5432",
+				forwarded:  []string{"198.51.100.2"},
+			},
+		},
+		{
+			name:     "Get the rightmost untrusted forwarded address with success",
+			expected: "198.51.100.2",
+			payload: payload{
+				proxies:    proxies,
+				remoteAddr: "10.0.0.1:5432",
+				forwarded:  []string{"192.168.0.1, 198.51.100.2", "172.16.0.1, 10.0.0.5"},
+			},
+		},
+		{
+			name:     "Get the leftmost trusted proxy when all forwarded addresses are trusted with success",
+			expected: "172.16.0.1",
+			payload: payload{
+				proxies:    proxies,
+				remoteAddr: "10.0.0.1:5432",
+				forwarded:  []string{"172.16.0.1, 10.0.0.5"},
+			},
+		},
+		{
+			name:     "Stop at invalid forwarded address with success",
+			expected: "10.0.0.5",
+			payload: payload{
+				proxies:    proxies,
+				remoteAddr: "10.0.0.1:5432",
+				forwarded:  []string{"198.51.100.2, unknown, 10.0.0.5"},
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, _ := http.NewRequest(http.MethodGet, "url", nil)
+			req.RemoteAddr = test.payload.remoteAddr
+			for _, value := range test.payload.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+
+			assert.Equal(t, test.expected, test.payload.proxies.RealIP(req))
+		})
+	}
+}
+
+func TestNewTrustedProxies(t *testing.T) {
+	proxies, err := NewTrustedProxies([]string{"10.0.0.0/8", "::1"})
+	assert.NoError(t, err)
+	assert.True(t, proxies.Contains("10.1.2.3"))
+	assert.True(t, proxies.Contains("::1"))
+	assert.False(t, proxies.Contains("192.168.0.1"))
+
+	_, err = NewTrustedProxies([]string{"proxy"})
+	assert.Error(t, err)
+
+	_, err = NewTrustedProxies([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestCurrency(t *testing.T) {
+	tc := []struct {
+		name     string
+		expected string
+		url      string
+		header   string
+	}{
+		{
+			name:     "Get currency of query parameter with success",
+			expected: "EUR",
+			url:      "/products?currency=EUR",
+			header:   "PLN",
+		},
+		{
+			name:     "Get the first currency of header with success",
+			expected: "PLN",
+			url:      "/products",
+			header:   "PLN;q=1, EUR;q=0.5",
+		},
+		{
+			name: "Get base currency without parameter and header",
+			url:  "/products",
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, _ := http.NewRequest(http.MethodGet, test.url, nil)
+			if test.header != "" {
+				req.Header.Set("Accept-Currency", test.header)
+			}
+
+			assert.Equal(t, test.expected, Currency(req))
+		})
+	}
+}
+
+func TestRegion(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/cart/products?region=%20PL%20", nil)
+	assert.Equal(t, "PL", Region(req))
+
+	req, _ = http.NewRequest(http.MethodGet, "/cart/products", nil)
+	assert.Equal(t, "", Region(req))
+}
+
+func TestDate(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/cart/delivery/slots?date=2020-12-05", nil)
+	date, err := Date(req)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2020, 12, 5, 0, 0, 0, 0, time.UTC), date)
+
+	req, _ = http.NewRequest(http.MethodGet, "/cart/delivery/slots", nil)
+	date, err = Date(req)
+	assert.Nil(t, err)
+	assert.True(t, date.IsZero())
+
+	req, _ = http.NewRequest(http.MethodGet, "/cart/delivery/slots?date=05.12.2020", nil)
+	_, err = Date(req)
+	assert.Equal(t, entity.ErrInvalidDate, err)
+}
@@ -2,7 +2,11 @@ package response
 
 import (
 	"encoding/json"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 const (
@@ -42,6 +46,16 @@ func RenderResponse(w http.ResponseWriter, code int, body interface{}) {
 	render(w, code, b)
 }
 
+// SetRetryAfter sets Retry-After header in whole seconds, rounded up
+func SetRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+}
+
+// AddLink adds Link header with url of relation, e.g. next page
+func AddLink(w http.ResponseWriter, rel, url string) {
+	w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="%s"`, url, rel))
+}
+
 func render(w http.ResponseWriter, code int, b []byte) {
 	w.Header().Set("Content-Type", jsonContentType)
 	w.WriteHeader(code)
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -98,3 +99,41 @@ func TestRenderResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestSetRetryAfter(t *testing.T) {
+	tc := []struct {
+		name       string
+		retryAfter time.Duration
+		expected   string
+	}{
+		{
+			name:       "Retry after whole seconds",
+			retryAfter: 2 * time.Second,
+			expected:   "2",
+		},
+		{
+			name:       "Retry after rounded up",
+			retryAfter: 1500 * time.Millisecond,
+			expected:   "2",
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rw := httptest.NewRecorder()
+			SetRetryAfter(rw, test.retryAfter)
+			assert.Equal(t, test.expected, rw.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestAddLink(t *testing.T) {
+	rw := httptest.NewRecorder()
+	AddLink(rw, "first", "/v1/products?limit=2")
+	AddLink(rw, "next", "/v1/products?cursor=abc&limit=2")
+
+	assert.Equal(t, []string{`</v1/products?limit=2>; rel="first"`, `</v1/products?cursor=abc&limit=2>; rel="next"`}, rw.Header()["Link"])
+}
@@ -2,52 +2,149 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/mshto/fruit-store/apikey"
 	"github.com/mshto/fruit-store/authentication"
 	"github.com/mshto/fruit-store/web/common/response"
 )
 
 type contextKey int
 
-// sadassa
+// context keys set by auth middlewares
 const (
 	UserUUID contextKey = iota
 	AccessUUID
+	IsGuest
+	SessionID
+	APIKeyID
+	Scopes
 )
 
-// AuthMiddleware AuthMiddleware
-func AuthMiddleware(auth authentication.Auth, log *logrus.Logger) func(next http.Handler) http.Handler {
+// GuestTokenHeader header with a guest token of anonymous user
+const GuestTokenHeader = "X-Guest-Token"
+
+// AuthMiddleware accepts either a user access token or an api key
+func AuthMiddleware(auth authentication.Auth, keys apikey.Service, log *logrus.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			bearToken := r.Header.Get("Authorization")
-			strArr := strings.Split(bearToken, " ")
-			if len(strArr) != 2 {
-				log.Errorf("failed to split token, value: %v", bearToken)
+			ctx, ok := authenticate(auth, keys, log, r)
+			if !ok {
 				response.RenderResponse(w, http.StatusUnauthorized, response.EmptyResp{})
 				return
 			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
 
-			accessDetails, err := auth.ValidateToken(strArr[1])
-			if err != nil {
-				log.Errorf("failed to validate token, error: %v", err)
-				response.RenderResponse(w, http.StatusUnauthorized, response.EmptyResp{})
+// AuthOrGuestMiddleware accepts either a user access token, an api key or a guest token
+func AuthOrGuestMiddleware(auth authentication.Auth, keys apikey.Service, log *logrus.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			guestToken := r.Header.Get(GuestTokenHeader)
+			if r.Header.Get("Authorization") != "" || r.Header.Get(apikey.Header) != "" || guestToken == "" {
+				ctx, ok := authenticate(auth, keys, log, r)
+				if !ok {
+					response.RenderResponse(w, http.StatusUnauthorized, response.EmptyResp{})
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
 
-			userUUID, err := auth.GetUserUUID(accessDetails.AccessUUID)
-			if err != nil || userUUID != accessDetails.UserUUID {
-				log.Errorf("failed to get user uuid, userUUID: %v, error: %v", accessDetails.UserUUID, err)
+			guestUUID, err := auth.ValidateGuestToken(guestToken)
+			if err != nil {
+				log.Errorf("failed to validate guest token, error: %v", err)
 				response.RenderResponse(w, http.StatusUnauthorized, response.EmptyResp{})
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), UserUUID, userUUID)
-			ctx = context.WithValue(ctx, AccessUUID, accessDetails.AccessUUID)
+			ctx := context.WithValue(r.Context(), UserUUID, guestUUID.String())
+			ctx = context.WithValue(ctx, IsGuest, true)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// RequireScope allows access tokens and api keys which have the scope
+func RequireScope(scope string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scopes, ok := r.Context().Value(Scopes).([]string)
+			if ok && !apikey.HasScope(scopes, scope) {
+				response.RenderFailedResponse(w, http.StatusForbidden, fmt.Errorf("api key lacks scope %s", scope))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UserOnly denies api keys, used for account management
+func UserOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(APIKeyID) != nil {
+			response.RenderFailedResponse(w, http.StatusForbidden, errAPIKeyNotAllowed)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+var errAPIKeyNotAllowed = errors.New("api keys are not allowed for this endpoint")
+
+func authenticate(auth authentication.Auth, keys apikey.Service, log *logrus.Logger, r *http.Request) (context.Context, bool) {
+	if key := r.Header.Get(apikey.Header); key != "" {
+		return authenticateKey(keys, log, r, key)
+	}
+
+	bearToken := r.Header.Get("Authorization")
+	strArr := strings.Split(bearToken, " ")
+	if len(strArr) != 2 {
+		log.Errorf("failed to split token, value: %v", bearToken)
+		return nil, false
+	}
+
+	accessDetails, err := auth.ValidateToken(strArr[1])
+	if err != nil {
+		log.Errorf("failed to validate token, error: %v", err)
+		return nil, false
+	}
+
+	userUUID, err := auth.GetUserUUID(accessDetails.AccessUUID)
+	if err != nil || userUUID != accessDetails.UserUUID {
+		log.Errorf("failed to get user uuid, userUUID: %v, error: %v", accessDetails.UserUUID, err)
+		return nil, false
+	}
+
+	if accessDetails.SessionID != "" {
+		err = auth.TouchSession(userUUID, accessDetails.SessionID)
+		if err != nil {
+			log.Warnf("failed to touch session, userUUID: %v, session: %v, error: %v", userUUID, accessDetails.SessionID, err)
+		}
+	}
+
+	ctx := context.WithValue(r.Context(), UserUUID, userUUID)
+	ctx = context.WithValue(ctx, AccessUUID, accessDetails.AccessUUID)
+	ctx = context.WithValue(ctx, SessionID, accessDetails.SessionID)
+	return ctx, true
+}
+
+func authenticateKey(keys apikey.Service, log *logrus.Logger, r *http.Request, key string) (context.Context, bool) {
+	stored, err := keys.Validate(key)
+	if err != nil {
+		log.Errorf("failed to validate api key, error: %v", err)
+		return nil, false
+	}
+
+	ctx := context.WithValue(r.Context(), UserUUID, stored.UserID.String())
+	ctx = context.WithValue(ctx, APIKeyID, stored.ID.String())
+	ctx = context.WithValue(ctx, Scopes, stored.Scopes)
+	return ctx, true
+}
@@ -1,16 +1,22 @@
 package middleware
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
 	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
 
+	"github.com/mshto/fruit-store/apikey"
+	apikeymock "github.com/mshto/fruit-store/apikey/mock"
 	"github.com/mshto/fruit-store/authentication"
 	authmock "github.com/mshto/fruit-store/authentication/mock"
+	"github.com/mshto/fruit-store/entity"
 )
 
 func TestAuthMiddleware(t *testing.T) {
@@ -33,6 +39,28 @@ func TestAuthMiddleware(t *testing.T) {
 				authorizationHeader: "Bearer eyJhbGciOiJIUzI1",
 			},
 		},
+		{
+			name: "Auth middleware session with success",
+			payload: payload{
+				authMock: func(mock *authmock.MockAuth) {
+					mock.EXPECT().ValidateToken(gomock.Any()).Return(&authentication.AccessDetails{UserUUID: "test", SessionID: "session"}, nil)
+					mock.EXPECT().GetUserUUID(gomock.Any()).Return("test", nil)
+					mock.EXPECT().TouchSession("test", "session").Return(nil)
+				},
+				authorizationHeader: "Bearer eyJhbGciOiJIUzI1",
+			},
+		},
+		{
+			name: "Auth middleware TouchSession error with success",
+			payload: payload{
+				authMock: func(mock *authmock.MockAuth) {
+					mock.EXPECT().ValidateToken(gomock.Any()).Return(&authentication.AccessDetails{UserUUID: "test", SessionID: "session"}, nil)
+					mock.EXPECT().GetUserUUID(gomock.Any()).Return("test", nil)
+					mock.EXPECT().TouchSession("test", "session").Return(errors.New("error"))
+				},
+				authorizationHeader: "Bearer eyJhbGciOiJIUzI1",
+			},
+		},
 		{
 			name: "Auth middleware with fail",
 			payload: payload{
@@ -81,7 +109,287 @@ func TestAuthMiddleware(t *testing.T) {
 			rw := httptest.NewRecorder()
 
 			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
-			AuthMiddleware(auth, logger)(next).ServeHTTP(rw, req)
+			AuthMiddleware(auth, apikeymock.NewMockService(mockCtrl), logger)(next).ServeHTTP(rw, req)
+		})
+	}
+}
+
+func TestAuthOrGuestMiddleware(t *testing.T) {
+	type payload struct {
+		authMock            func(mock *authmock.MockAuth)
+		authorizationHeader string
+		guestHeader         string
+	}
+	type expected struct {
+		code    int
+		isGuest bool
+	}
+
+	guestUUID := uuid.New()
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Auth or guest middleware user with success",
+			payload: payload{
+				authMock: func(mock *authmock.MockAuth) {
+					mock.EXPECT().ValidateToken(gomock.Any()).Return(&authentication.AccessDetails{UserUUID: "test"}, nil)
+					mock.EXPECT().GetUserUUID(gomock.Any()).Return("test", nil)
+				},
+				authorizationHeader: "Bearer eyJhbGciOiJIUzI1",
+				guestHeader:         "guest",
+			},
+			expected: expected{
+				code:    http.StatusOK,
+				isGuest: false,
+			},
+		},
+		{
+			name: "Auth or guest middleware guest with success",
+			payload: payload{
+				authMock: func(mock *authmock.MockAuth) {
+					mock.EXPECT().ValidateGuestToken("guest").Return(guestUUID, nil)
+				},
+				guestHeader: "guest",
+			},
+			expected: expected{
+				code:    http.StatusOK,
+				isGuest: true,
+			},
+		},
+		{
+			name: "Auth or guest middleware ValidateGuestToken with fail",
+			payload: payload{
+				authMock: func(mock *authmock.MockAuth) {
+					mock.EXPECT().ValidateGuestToken("guest").Return(uuid.Nil, errors.New("error"))
+				},
+				guestHeader: "guest",
+			},
+			expected: expected{
+				code: http.StatusUnauthorized,
+			},
+		},
+		{
+			name: "Auth or guest middleware without tokens with fail",
+			payload: payload{
+				authMock: func(mock *authmock.MockAuth) {
+				},
+			},
+			expected: expected{
+				code: http.StatusUnauthorized,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			auth := authmock.NewMockAuth(mockCtrl)
+			test.payload.authMock(auth)
+
+			logger, _ := loggermock.NewNullLogger()
+
+			req, _ := http.NewRequest(http.MethodGet, "url", nil)
+			req.Header.Set("Authorization", test.payload.authorizationHeader)
+			req.Header.Set(GuestTokenHeader, test.payload.guestHeader)
+
+			rw := httptest.NewRecorder()
+
+			var isGuest bool
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				isGuest, _ = r.Context().Value(IsGuest).(bool)
+			})
+			AuthOrGuestMiddleware(auth, apikeymock.NewMockService(mockCtrl), logger)(next).ServeHTTP(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.isGuest, isGuest)
 		})
 	}
 }
+
+func TestAuthMiddlewareAPIKey(t *testing.T) {
+	type payload struct {
+		keysMock func(mock *apikeymock.MockService)
+		guest    bool
+	}
+	type expected struct {
+		code     int
+		userUUID string
+		scopes   []string
+	}
+
+	keyUUID := uuid.New()
+	userUUID := uuid.New()
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Auth middleware api key with success",
+			payload: payload{
+				keysMock: func(mock *apikeymock.MockService) {
+					mock.EXPECT().Validate("fsk_key").Return(&entity.APIKey{ID: keyUUID, UserID: userUUID, Scopes: []string{apikey.ScopeCartRead}}, nil)
+				},
+			},
+			expected: expected{
+				code:     http.StatusOK,
+				userUUID: userUUID.String(),
+				scopes:   []string{apikey.ScopeCartRead},
+			},
+		},
+		{
+			name: "Auth or guest middleware api key takes precedence over guest token with success",
+			payload: payload{
+				keysMock: func(mock *apikeymock.MockService) {
+					mock.EXPECT().Validate("fsk_key").Return(&entity.APIKey{ID: keyUUID, UserID: userUUID, Scopes: []string{apikey.ScopeCartRead}}, nil)
+				},
+				guest: true,
+			},
+			expected: expected{
+				code:     http.StatusOK,
+				userUUID: userUUID.String(),
+				scopes:   []string{apikey.ScopeCartRead},
+			},
+		},
+		{
+			name: "Auth middleware api key Validate with fail",
+			payload: payload{
+				keysMock: func(mock *apikeymock.MockService) {
+					mock.EXPECT().Validate("fsk_key").Return(nil, entity.ErrAPIKeyInvalid)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnauthorized,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			keys := apikeymock.NewMockService(mockCtrl)
+			test.payload.keysMock(keys)
+
+			logger, _ := loggermock.NewNullLogger()
+
+			req, _ := http.NewRequest(http.MethodGet, "url", nil)
+			req.Header.Set(apikey.Header, "fsk_key")
+
+			rw := httptest.NewRecorder()
+
+			var userUUID string
+			var scopes []string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				userUUID, _ = r.Context().Value(UserUUID).(string)
+				scopes, _ = r.Context().Value(Scopes).([]string)
+			})
+
+			mw := AuthMiddleware(authmock.NewMockAuth(mockCtrl), keys, logger)
+			if test.payload.guest {
+				req.Header.Set(GuestTokenHeader, "guest")
+				mw = AuthOrGuestMiddleware(authmock.NewMockAuth(mockCtrl), keys, logger)
+			}
+			mw(next).ServeHTTP(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.userUUID, userUUID)
+			assert.Equal(t, test.expected.scopes, scopes)
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	type payload struct {
+		apiKey bool
+		scopes []string
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Require scope access token with success",
+			expected: expected{
+				code: http.StatusOK,
+			},
+		},
+		{
+			name: "Require scope api key with success",
+			payload: payload{
+				apiKey: true,
+				scopes: []string{apikey.ScopeCartRead, apikey.ScopeCartWrite},
+			},
+			expected: expected{
+				code: http.StatusOK,
+			},
+		},
+		{
+			name: "Require scope api key without scope with fail",
+			payload: payload{
+				apiKey: true,
+				scopes: []string{apikey.ScopeCartRead},
+			},
+			expected: expected{
+				code: http.StatusForbidden,
+				body: `{"error":"api key lacks scope cart:write"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, _ := http.NewRequest(http.MethodGet, "url", nil)
+			if test.payload.apiKey {
+				ctx := context.WithValue(req.Context(), APIKeyID, "key")
+				ctx = context.WithValue(ctx, Scopes, test.payload.scopes)
+				req = req.WithContext(ctx)
+			}
+
+			rw := httptest.NewRecorder()
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+			RequireScope(apikey.ScopeCartWrite)(next).ServeHTTP(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
+
+func TestUserOnly(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	req, _ := http.NewRequest(http.MethodGet, "url", nil)
+	rw := httptest.NewRecorder()
+	UserOnly(next).ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusOK, rw.Code)
+
+	req = req.WithContext(context.WithValue(req.Context(), APIKeyID, "key"))
+	rw = httptest.NewRecorder()
+	UserOnly(next).ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusForbidden, rw.Code)
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/mshto/fruit-store/web/common/request"
+)
+
+// RealIPMiddleware middleware to replace remote address of request coming from a trusted proxy with the client one
+type RealIPMiddleware struct {
+	proxies request.TrustedProxies
+}
+
+// NewRealIPMiddleware new RealIPMiddleware, X-Forwarded-For is ignored without trusted proxies
+func NewRealIPMiddleware(proxies request.TrustedProxies) *RealIPMiddleware {
+	return &RealIPMiddleware{proxies: proxies}
+}
+
+func (s *RealIPMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if ip := s.proxies.RealIP(r); ip != request.ClientIP(r) {
+		r.RemoteAddr = net.JoinHostPort(ip, "0")
+	}
+
+	next(w, r)
+}
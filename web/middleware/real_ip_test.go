@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/web/common/request"
+)
+
+func TestRealIPMiddleware(t *testing.T) {
+	proxies, _ := request.NewTrustedProxies([]string{"10.0.0.0/24"})
+
+	tc := []struct {
+		name       string
+		expected   string
+		proxies    request.TrustedProxies
+		remoteAddr string
+	}{
+		{
+			name:       "Real IP middleware ignores spoofed header without trusted proxies with success",
+			expected:   "203.0.113.7",
+			proxies:    nil,
+			remoteAddr: "203.0.113.7:5432",
+		},
+		{
+			name:       "Real IP middleware replaces address of trusted proxy with success",
+			expected:   "198.51.100.2",
+			proxies:    proxies,
+			remoteAddr: "10.0.0.1:5432",
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, _ := http.NewRequest(http.MethodGet, "url", nil)
+			req.RemoteAddr = test.remoteAddr
+			req.Header.Set("X-Forwarded-For", "198.51.100.2")
+
+			rw := httptest.NewRecorder()
+
+			NewRealIPMiddleware(test.proxies).ServeHTTP(rw, req, func(rw http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, test.expected, request.ClientIP(r))
+			})
+		})
+	}
+}
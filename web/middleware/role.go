@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mshto/fruit-store/repository"
+	"github.com/mshto/fruit-store/web/common/response"
+)
+
+var errRoleRequired = errors.New("user lacks required role")
+
+// RequireRole allows authenticated users with the role, role is read on each request so revoking it takes effect at once
+func RequireRole(authRepo repository.Auth, role string, log *logrus.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, _ := r.Context().Value(UserUUID).(string)
+			userUUID, err := uuid.Parse(userID)
+			if err != nil {
+				log.Errorf("failed to parse user uuid, user: %v, error: %v", userID, err)
+				response.RenderFailedResponse(w, http.StatusForbidden, errRoleRequired)
+				return
+			}
+
+			userRole, err := authRepo.GetUserRole(userUUID)
+			if err != nil {
+				log.Errorf("failed to get user role, user: %v, error: %v", userID, err)
+				response.RenderFailedResponse(w, http.StatusForbidden, errRoleRequired)
+				return
+			}
+			if userRole != role {
+				log.Warnf("user lacks role, user: %v, role: %v", userID, role)
+				response.RenderFailedResponse(w, http.StatusForbidden, errRoleRequired)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/entity"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+)
+
+func TestRequireRole(t *testing.T) {
+	type payload struct {
+		userID   string
+		authMock func(mock *repomock.MockAuth)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	userUUID := uuid.New()
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Require role with success",
+			payload: payload{
+				userID: userUUID.String(),
+				authMock: func(mock *repomock.MockAuth) {
+					mock.EXPECT().GetUserRole(userUUID).Return(entity.RoleAdmin, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+			},
+		},
+		{
+			name: "Require role other role with fail",
+			payload: payload{
+				userID: userUUID.String(),
+				authMock: func(mock *repomock.MockAuth) {
+					mock.EXPECT().GetUserRole(userUUID).Return(entity.RoleCustomer, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusForbidden,
+				body: `{"error":"user lacks required role"}`,
+			},
+		},
+		{
+			name: "Require role GetUserRole error with fail",
+			payload: payload{
+				userID: userUUID.String(),
+				authMock: func(mock *repomock.MockAuth) {
+					mock.EXPECT().GetUserRole(userUUID).Return("", errors.New("error"))
+				},
+			},
+			expected: expected{
+				code: http.StatusForbidden,
+				body: `{"error":"user lacks required role"}`,
+			},
+		},
+		{
+			name: "Require role invalid user uuid with fail",
+			payload: payload{
+				userID:   "test",
+				authMock: func(mock *repomock.MockAuth) {},
+			},
+			expected: expected{
+				code: http.StatusForbidden,
+				body: `{"error":"user lacks required role"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			authRepo := repomock.NewMockAuth(mockCtrl)
+			test.payload.authMock(authRepo)
+
+			logger, _ := loggermock.NewNullLogger()
+
+			req, _ := http.NewRequest(http.MethodGet, "url", nil)
+			req = req.WithContext(context.WithValue(req.Context(), UserUUID, test.payload.userID))
+
+			rw := httptest.NewRecorder()
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+			RequireRole(authRepo, entity.RoleAdmin, logger)(next).ServeHTTP(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
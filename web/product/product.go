@@ -1,18 +1,50 @@
 package product
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 
 	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/currency"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/media"
 	"github.com/mshto/fruit-store/repository"
+	"github.com/mshto/fruit-store/web/common/request"
 	"github.com/mshto/fruit-store/web/common/response"
 )
 
+// page size and search length limits
+const (
+	defaultLimit    = 20
+	maxLimit        = 100
+	maxSearchLength = 100
+	maxTagLength    = 50
+)
+
+var (
+	errInvalidLimit      = errors.New("limit must be 1 to 100")
+	errInvalidSort       = errors.New("sort must be one of price, name, created_at, optionally prefixed with -")
+	errInvalidPrice      = errors.New("price range is invalid")
+	errInvalidCursor     = errors.New("cursor is invalid")
+	errCursorSortChanged = errors.New("cursor was issued for another sort")
+	errSearchTooLong     = errors.New("search must be at most 100 characters")
+)
+
 // Service product interface
 type Service interface {
 	GetAll(w http.ResponseWriter, r *http.Request)
+	GetCategories(w http.ResponseWriter, r *http.Request)
+	GetCategoryProducts(w http.ResponseWriter, r *http.Request)
+	GetPriceHistory(w http.ResponseWriter, r *http.Request)
 }
 
 // ProductHandler product handler
@@ -20,25 +52,337 @@ type productHandler struct {
 	cfg         *config.Config
 	log         *logrus.Logger
 	productRepo repository.Products
+	catalogRepo repository.Catalog
+	mediaRepo   repository.Media
+	storage     media.Storage
+	pricesRepo  repository.Prices
+	currRepo    repository.Currencies
 }
 
 // NewProductHandler init a new product handler
-func NewProductHandler(cfg *config.Config, log *logrus.Logger, productRepo repository.Products) Service {
+func NewProductHandler(cfg *config.Config, log *logrus.Logger, productRepo repository.Products, catalogRepo repository.Catalog,
+	mediaRepo repository.Media, storage media.Storage, pricesRepo repository.Prices, currRepo repository.Currencies) Service {
 	return productHandler{
 		cfg:         cfg,
 		log:         log,
 		productRepo: productRepo,
+		catalogRepo: catalogRepo,
+		mediaRepo:   mediaRepo,
+		storage:     storage,
+		pricesRepo:  pricesRepo,
+		currRepo:    currRepo,
 	}
 }
 
-// GetAllProducts retrieves all products
+// GetAll retrieves page of products filtered by q, min_price, max_price and tag and sorted by sort.
+// Next page is requested with cursor of the page, its url is in the Link header as well.
+// Prices and price range are in currency of currency query parameter or Accept-Currency header.
 func (ph productHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	products, err := ph.productRepo.GetAll()
+	query, err := parseQuery(r)
+	if err != nil {
+		ph.log.Errorf("failed to parse product query, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ph.renderPage(w, r, query)
+}
+
+// GetCategories retrieves all categories, parents go before their children
+func (ph productHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := ph.catalogRepo.GetCategories()
+	if err != nil {
+		ph.log.Errorf("failed to get categories, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, categories)
+}
+
+// GetCategoryProducts retrieves page of products of category and its subcategories,
+// it accepts the same query as GetAll
+func (ph productHandler) GetCategoryProducts(w http.ResponseWriter, r *http.Request) {
+	categoryUUID, err := uuid.Parse(mux.Vars(r)["categoryID"])
+	if err != nil {
+		ph.log.Errorf("failed to parse category id, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query, err := parseQuery(r)
+	if err != nil {
+		ph.log.Errorf("failed to parse product query, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	category, err := ph.catalogRepo.GetCategory(categoryUUID)
+	if err == entity.ErrCategoryNotFound {
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		ph.log.Errorf("failed to get category, category: %v, error: %v", categoryUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	query.Category = category.Path
+
+	ph.renderPage(w, r, query)
+}
+
+// GetPriceHistory retrieves prices of product which took effect, newest first
+func (ph productHandler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	productUUID, err := uuid.Parse(mux.Vars(r)["productID"])
+	if err != nil {
+		ph.log.Errorf("failed to parse product id, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	prices, err := ph.pricesRepo.GetPriceHistory(productUUID)
+	if err == entity.ErrProductNotFound {
+		response.RenderFailedResponse(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		ph.log.Errorf("failed to get price history, product: %v, error: %v", productUUID, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, prices)
+}
+
+// renderPage find page of products by query and render it with paging links
+func (ph productHandler) renderPage(w http.ResponseWriter, r *http.Request, query entity.ProductQuery) {
+	limit := query.Limit
+
+	rates, display, err := currency.Load(ph.currRepo, ph.cfg.Currency.BaseCode(), request.Currency(r))
+	if currency.IsInvalid(err) {
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		ph.log.Errorf("failed to get exchange rates, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// price range is in display currency, products are filtered by their base prices
+	query.MinPrice, err = toBase(rates, query.MinPrice, display.Code)
+	if err == nil {
+		query.MaxPrice, err = toBase(rates, query.MaxPrice, display.Code)
+	}
+	if err != nil {
+		ph.log.Errorf("failed to convert price range, currency: %v, error: %v", display.Code, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// one more product is read to know whether there is a next page
+	query.Limit++
+	products, total, err := ph.productRepo.Find(query)
 	if err != nil {
 		ph.log.Errorf("failed to get all product, error: %v", err)
 		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	response.RenderResponse(w, http.StatusOK, products)
+	page := entity.ProductPage{
+		Products: products,
+		Paging:   entity.Paging{Limit: limit, Total: total},
+	}
+
+	response.AddLink(w, "first", pageURL(r, ""))
+	if len(products) > limit {
+		page.Products = products[:limit]
+		page.Paging.NextCursor, err = encodeCursor(query, page.Products[limit-1], rates)
+		if err != nil {
+			ph.log.Errorf("failed to encode cursor, error: %v", err)
+			response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		response.AddLink(w, "next", pageURL(r, page.Paging.NextCursor))
+	}
+
+	err = ph.attachMedia(page.Products)
+	if err != nil {
+		ph.log.Errorf("failed to get product media, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = rates.ConvertProducts(page.Products, display)
+	if err != nil {
+		ph.log.Errorf("failed to convert product prices, currency: %v, error: %v", display.Code, err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.RenderResponse(w, http.StatusOK, page)
+}
+
+// attachMedia set media of products with urls of media storage
+func (ph productHandler) attachMedia(products []entity.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	productMedia, err := ph.mediaRepo.GetMedia(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range products {
+		for _, item := range productMedia[products[i].ID] {
+			item.URL = ph.storage.URL(item.Key)
+			item.ThumbnailURL = ph.storage.URL(item.ThumbnailKey)
+			products[i].Media = append(products[i].Media, item)
+		}
+	}
+	return nil
+}
+
+// parseQuery get product query from url query
+func parseQuery(r *http.Request) (entity.ProductQuery, error) {
+	values := r.URL.Query()
+	query := entity.ProductQuery{
+		Search: strings.TrimSpace(values.Get("q")),
+		Tag:    strings.TrimSpace(values.Get("tag")),
+		Sort:   entity.ProductSortCreatedAt,
+		Limit:  defaultLimit,
+	}
+	if len(query.Search) > maxSearchLength {
+		return query, errSearchTooLong
+	}
+	if len(query.Tag) > maxTagLength {
+		return query, entity.ErrInvalidTag
+	}
+
+	if sort := values.Get("sort"); sort != "" {
+		query.Desc = strings.HasPrefix(sort, "-")
+		query.Sort = strings.TrimPrefix(sort, "-")
+		if query.Sort != entity.ProductSortCreatedAt && query.Sort != entity.ProductSortName && query.Sort != entity.ProductSortPrice {
+			return query, errInvalidSort
+		}
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		var err error
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit < 1 || query.Limit > maxLimit {
+			return query, errInvalidLimit
+		}
+	}
+
+	var err error
+	if query.MinPrice, err = parsePrice(values.Get("min_price")); err != nil {
+		return query, err
+	}
+	if query.MaxPrice, err = parsePrice(values.Get("max_price")); err != nil {
+		return query, err
+	}
+	if query.MinPrice != nil && query.MaxPrice != nil && *query.MinPrice > *query.MaxPrice {
+		return query, errInvalidPrice
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		query.After, err = decodeCursor(cursor)
+		if err != nil {
+			return query, err
+		}
+		if query.After.Sort != query.Sort || query.After.Desc != query.Desc {
+			return query, errCursorSortChanged
+		}
+	}
+	return query, nil
+}
+
+func parsePrice(value string) (*float32, error) {
+	if value == "" {
+		return nil, nil
+	}
+	price, err := strconv.ParseFloat(value, 32)
+	if err != nil || price < 0 {
+		return nil, errInvalidPrice
+	}
+	result := float32(price)
+	return &result, nil
+}
+
+// toBase convert price in currency to base currency, nil price stays nil
+func toBase(rates currency.Rates, price *float32, code string) (*float32, error) {
+	if price == nil {
+		return nil, nil
+	}
+	amount, err := rates.ToBase(float64(*price), code)
+	if err != nil {
+		return nil, err
+	}
+	result := float32(amount)
+	return &result, nil
+}
+
+// encodeCursor encode position of product in sort of query, price position is the base price of product
+func encodeCursor(query entity.ProductQuery, last entity.Product, rates currency.Rates) (string, error) {
+	cursor := entity.ProductCursor{Sort: query.Sort, Desc: query.Desc, ID: last.ID}
+	switch query.Sort {
+	case entity.ProductSortName:
+		cursor.Value = last.Name
+	case entity.ProductSortPrice:
+		price, err := rates.ToBase(float64(last.Price), last.Currency)
+		if err != nil {
+			return "", err
+		}
+		cursor.Value = strconv.FormatFloat(price, 'f', -1, 64)
+	default:
+		cursor.Value = last.CreatedAt.Format(time.RFC3339Nano)
+	}
+
+	b, _ := json.Marshal(cursor) // nolint
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(value string) (*entity.ProductCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	cursor := &entity.ProductCursor{}
+	if err = json.Unmarshal(b, cursor); err != nil {
+		return nil, errInvalidCursor
+	}
+
+	// position must be comparable with the sort column, name is any text
+	switch cursor.Sort {
+	case entity.ProductSortPrice:
+		_, err = strconv.ParseFloat(cursor.Value, 64)
+	case entity.ProductSortCreatedAt:
+		_, err = time.Parse(time.RFC3339Nano, cursor.Value)
+	}
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	return cursor, nil
+}
+
+// pageURL url of request with cursor replaced
+func pageURL(r *http.Request, cursor string) string {
+	values := r.URL.Query()
+	values.Del("cursor")
+	if cursor != "" {
+		values.Set("cursor", cursor)
+	}
+
+	url := r.URL.Path
+	if encoded := values.Encode(); encoded != "" {
+		url += "?" + encoded
+	}
+	return url
 }
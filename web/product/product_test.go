@@ -1,54 +1,345 @@
 package product
 
 import (
+	"encoding/base64"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	loggermock "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/currency"
 	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/media"
 	repomock "github.com/mshto/fruit-store/repository/mock"
 )
 
+var (
+	createdAt = time.Date(2020, 11, 28, 0, 0, 0, 0, time.UTC)
+	apples    = entity.Product{ID: uuid.MustParse("e2d49480-2c1a-11eb-adc1-0242ac120002"), Name: "Apples", Price: 1.72, Unit: entity.UnitKg, MinAmount: 0.5, Step: 0.1, CreatedAt: createdAt}
+	storage   = media.NewLocal("", media.LocalURL)
+	bananas   = entity.Product{ID: uuid.MustParse("f3e5a591-2c1a-11eb-adc1-0242ac120002"), Name: "Bananas", Price: 2.34, Unit: entity.UnitBunch, MinAmount: 1, Step: 1, CreatedAt: createdAt}
+	rates     = []entity.ExchangeRate{{Currency: "EUR", Rate: 0.84, UpdatedAt: createdAt}}
+)
+
+// anyRates expect exchange rates to be read any times
+func anyRates(currMock *repomock.MockCurrencies) {
+	currMock.EXPECT().GetExchangeRates().Return(rates, nil).AnyTimes()
+}
+
 func TestGetAll(t *testing.T) {
 	type payload struct {
 		cfg      *config.Config
-		repoMock func(repoMock *repomock.MockProducts)
+		url      string
+		repoMock func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia)
+		currMock func(currMock *repomock.MockCurrencies)
 	}
 	type expected struct {
-		code int
-		body string
+		code  int
+		body  string
+		links []string
 	}
 
+	minPrice, maxPrice := float32(1), float32(2.5)
+	baseRates := currency.NewRates("USD", rates)
+	priceCursor, _ := encodeCursor(entity.ProductQuery{Sort: entity.ProductSortPrice, Desc: true}, apples, baseRates)
+	pears := entity.Product{ID: uuid.MustParse("0b7d3b2e-2c1b-11eb-adc1-0242ac120002"), Name: "Pears", Price: 1.26, Currency: "EUR", Unit: entity.UnitKg,
+		MinAmount: 0.5, Step: 0.1, CreatedAt: createdAt}
+	pearsCursor, _ := encodeCursor(entity.ProductQuery{Sort: entity.ProductSortPrice}, pears, baseRates)
+	eurMaxPrice := float32(float64(float32(1.68)) / 0.84)
+	badPriceCursor := base64.RawURLEncoding.EncodeToString([]byte(`{"s":"price","v":"abc","id":"` + apples.ID.String() + `"}`))
+	badCreatedAtCursor := base64.RawURLEncoding.EncodeToString([]byte(`{"s":"created_at","d":true,"v":"yesterday","id":"` + apples.ID.String() + `"}`))
+
 	tc := []struct {
 		name string
 		expected
 		payload
 	}{
 		{
-			name: "Get total info user sale with success",
+			name: "Get all products with success",
+			payload: payload{
+				cfg: &config.Config{},
+				url: "/v1/products",
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {
+					repoMock.EXPECT().Find(entity.ProductQuery{Sort: entity.ProductSortCreatedAt, Limit: defaultLimit + 1}).Return([]entity.Product{}, 0, nil)
+				},
+			},
+			expected: expected{
+				code:  http.StatusOK,
+				body:  `{"products":[],"paging":{"limit":20,"total":0}}`,
+				links: []string{`</v1/products>; rel="first"`},
+			},
+		},
+		{
+			name: "Get products page with next cursor with success",
+			payload: payload{
+				cfg: &config.Config{},
+				url: "/v1/products?sort=-price&limit=1&min_price=1&max_price=2.5&q=%20apple%20",
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {
+					repoMock.EXPECT().Find(entity.ProductQuery{
+						Search: "apple", MinPrice: &minPrice, MaxPrice: &maxPrice, Sort: entity.ProductSortPrice, Desc: true, Limit: 2,
+					}).Return([]entity.Product{apples, bananas}, 5, nil)
+					mediaMock.EXPECT().GetMedia([]uuid.UUID{apples.ID}).Return(map[uuid.UUID][]entity.Media{}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","name":"Apples","price":1.72,"currency":"USD","unit":"kg","minAmount":0.5,"step":0.1,"createdAt":"2020-11-28T00:00:00Z"}],` +
+					`"paging":{"limit":1,"total":5,"nextCursor":"` + priceCursor + `"}}`,
+				links: []string{
+					`</v1/products?limit=1&max_price=2.5&min_price=1&q=+apple+&sort=-price>; rel="first"`,
+					`</v1/products?cursor=` + priceCursor + `&limit=1&max_price=2.5&min_price=1&q=+apple+&sort=-price>; rel="next"`,
+				},
+			},
+		},
+		{
+			name: "Get products by tag with success",
+			payload: payload{
+				cfg: &config.Config{},
+				url: "/v1/products?tag=organic",
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {
+					repoMock.EXPECT().Find(entity.ProductQuery{Tag: "organic", Sort: entity.ProductSortCreatedAt, Limit: defaultLimit + 1}).Return([]entity.Product{}, 0, nil)
+				},
+			},
+			expected: expected{
+				code:  http.StatusOK,
+				body:  `{"products":[],"paging":{"limit":20,"total":0}}`,
+				links: []string{`</v1/products?tag=organic>; rel="first"`},
+			},
+		},
+		{
+			name: "Get products after cursor with success",
+			payload: payload{
+				cfg: &config.Config{},
+				url: "/v1/products?sort=-price&limit=1&cursor=" + priceCursor,
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {
+					repoMock.EXPECT().Find(entity.ProductQuery{
+						Sort: entity.ProductSortPrice, Desc: true, Limit: 2,
+						After: &entity.ProductCursor{Sort: entity.ProductSortPrice, Desc: true, Value: "1.7200000286102295", ID: apples.ID},
+					}).Return([]entity.Product{bananas}, 2, nil)
+					mediaMock.EXPECT().GetMedia([]uuid.UUID{bananas.ID}).Return(map[uuid.UUID][]entity.Media{}, nil)
+				},
+			},
+			expected: expected{
+				code:  http.StatusOK,
+				body:  `{"products":[{"id":"f3e5a591-2c1a-11eb-adc1-0242ac120002","name":"Bananas","price":2.34,"currency":"USD","unit":"bunch","minAmount":1,"step":1,"createdAt":"2020-11-28T00:00:00Z"}],"paging":{"limit":1,"total":2}}`,
+				links: []string{`</v1/products?limit=1&sort=-price>; rel="first"`},
+			},
+		},
+		{
+			name: "Get products with media with success",
 			payload: payload{
 				cfg: &config.Config{},
-				repoMock: func(repoMock *repomock.MockProducts) {
-					repoMock.EXPECT().GetAll().Return([]entity.Product{}, nil)
+				url: "/v1/products?limit=1",
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {
+					repoMock.EXPECT().Find(entity.ProductQuery{Sort: entity.ProductSortCreatedAt, Limit: 2}).Return([]entity.Product{apples}, 1, nil)
+					mediaMock.EXPECT().GetMedia([]uuid.UUID{apples.ID}).Return(map[uuid.UUID][]entity.Media{
+						apples.ID: {{
+							ID: uuid.MustParse("5a0e2c1e-33a1-11eb-adc1-0242ac120002"), ProductID: apples.ID, Key: "products/apples.jpg",
+							ThumbnailKey: "products/apples_thumb.jpg", ContentType: "image/jpeg", Size: 2048, Width: 640, Height: 480, CreatedAt: createdAt,
+						}},
+					}, nil)
 				},
 			},
 			expected: expected{
 				code: http.StatusOK,
-				body: `[]`,
+				body: `{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","name":"Apples","price":1.72,"currency":"USD","unit":"kg","minAmount":0.5,"step":0.1,"createdAt":"2020-11-28T00:00:00Z",` +
+					`"media":[{"id":"5a0e2c1e-33a1-11eb-adc1-0242ac120002","url":"/media/products/apples.jpg","thumbnailUrl":"/media/products/apples_thumb.jpg",` +
+					`"contentType":"image/jpeg","size":2048,"width":640,"height":480,"createdAt":"2020-11-28T00:00:00Z"}]}],"paging":{"limit":1,"total":1}}`,
+				links: []string{`</v1/products?limit=1>; rel="first"`},
+			},
+		},
+		{
+			name: "Get products media error with fail",
+			payload: payload{
+				cfg: &config.Config{},
+				url: "/v1/products",
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {
+					repoMock.EXPECT().Find(gomock.Any()).Return([]entity.Product{apples}, 1, nil)
+					mediaMock.EXPECT().GetMedia([]uuid.UUID{apples.ID}).Return(nil, errors.New("error"))
+				},
+			},
+			expected: expected{
+				code:  http.StatusInternalServerError,
+				body:  `{"error":"error"}`,
+				links: []string{`</v1/products>; rel="first"`},
+			},
+		},
+		{
+			name: "Get products in requested currency with success",
+			payload: payload{
+				cfg: &config.Config{},
+				url: "/v1/products?limit=1&currency=eur",
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {
+					repoMock.EXPECT().Find(entity.ProductQuery{Sort: entity.ProductSortCreatedAt, Limit: 2}).Return([]entity.Product{apples}, 1, nil)
+					mediaMock.EXPECT().GetMedia([]uuid.UUID{apples.ID}).Return(map[uuid.UUID][]entity.Media{}, nil)
+				},
+			},
+			expected: expected{
+				code:  http.StatusOK,
+				body:  `{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","name":"Apples","price":1.44,"currency":"EUR","unit":"kg","minAmount":0.5,"step":0.1,"createdAt":"2020-11-28T00:00:00Z"}],"paging":{"limit":1,"total":1}}`,
+				links: []string{`</v1/products?currency=eur&limit=1>; rel="first"`},
 			},
 		},
 		{
-			name: "Get total info user sale with fail",
+			name: "Get products of mixed currencies by base price with success",
 			payload: payload{
 				cfg: &config.Config{},
-				repoMock: func(repoMock *repomock.MockProducts) {
-					repoMock.EXPECT().GetAll().Return([]entity.Product{}, errors.New("error"))
+				url: "/v1/products?sort=price&limit=1&currency=eur&max_price=1.68",
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {
+					repoMock.EXPECT().Find(entity.ProductQuery{MaxPrice: &eurMaxPrice, Sort: entity.ProductSortPrice, Limit: 2}).
+						Return([]entity.Product{pears, apples}, 2, nil)
+					mediaMock.EXPECT().GetMedia([]uuid.UUID{pears.ID}).Return(map[uuid.UUID][]entity.Media{}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"products":[{"id":"0b7d3b2e-2c1b-11eb-adc1-0242ac120002","name":"Pears","price":1.26,"currency":"EUR","unit":"kg","minAmount":0.5,"step":0.1,"createdAt":"2020-11-28T00:00:00Z"}],` +
+					`"paging":{"limit":1,"total":2,"nextCursor":"` + pearsCursor + `"}}`,
+				links: []string{
+					`</v1/products?currency=eur&limit=1&max_price=1.68&sort=price>; rel="first"`,
+					`</v1/products?currency=eur&cursor=` + pearsCursor + `&limit=1&max_price=1.68&sort=price>; rel="next"`,
+				},
+			},
+		},
+		{
+			name: "Get products in currency without exchange rate with fail",
+			payload: payload{
+				cfg:      &config.Config{},
+				url:      "/v1/products?currency=PLN",
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"exchange rate of currency is not set"}`,
+			},
+		},
+		{
+			name: "Get products in unsupported currency with fail",
+			payload: payload{
+				cfg:      &config.Config{},
+				url:      "/v1/products?currency=BTC",
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"currency is not supported"}`,
+			},
+		},
+		{
+			name: "Get products GetExchangeRates error with fail",
+			payload: payload{
+				cfg:      &config.Config{},
+				url:      "/v1/products",
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {},
+				currMock: func(currMock *repomock.MockCurrencies) {
+					currMock.EXPECT().GetExchangeRates().Return(nil, errors.New("error"))
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+		{
+			name: "Get products cursor of other sort with fail",
+			payload: payload{
+				cfg:      &config.Config{},
+				url:      "/v1/products?sort=name&cursor=" + priceCursor,
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"cursor was issued for another sort"}`,
+			},
+		},
+		{
+			name: "Get products invalid cursor with fail",
+			payload: payload{
+				cfg:      &config.Config{},
+				url:      "/v1/products?cursor=!",
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"cursor is invalid"}`,
+			},
+		},
+		{
+			name: "Get products cursor with malformed price with fail",
+			payload: payload{
+				cfg:      &config.Config{},
+				url:      "/v1/products?sort=price&cursor=" + badPriceCursor,
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"cursor is invalid"}`,
+			},
+		},
+		{
+			name: "Get products cursor with malformed creation time with fail",
+			payload: payload{
+				cfg:      &config.Config{},
+				url:      "/v1/products?cursor=" + badCreatedAtCursor,
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"cursor is invalid"}`,
+			},
+		},
+		{
+			name: "Get products invalid sort with fail",
+			payload: payload{
+				cfg:      &config.Config{},
+				url:      "/v1/products?sort=id",
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"sort must be one of price, name, created_at, optionally prefixed with -"}`,
+			},
+		},
+		{
+			name: "Get products invalid limit with fail",
+			payload: payload{
+				cfg:      &config.Config{},
+				url:      "/v1/products?limit=0",
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"limit must be 1 to 100"}`,
+			},
+		},
+		{
+			name: "Get products inverted price range with fail",
+			payload: payload{
+				cfg:      &config.Config{},
+				url:      "/v1/products?min_price=3&max_price=1",
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"price range is invalid"}`,
+			},
+		},
+		{
+			name: "Get products Find error with fail",
+			payload: payload{
+				cfg: &config.Config{},
+				url: "/v1/products",
+				repoMock: func(repoMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {
+					repoMock.EXPECT().Find(gomock.Any()).Return([]entity.Product{}, 0, errors.New("error"))
 				},
 			},
 			expected: expected{
@@ -69,17 +360,271 @@ func TestGetAll(t *testing.T) {
 			logger, _ := loggermock.NewNullLogger()
 
 			productRepo := repomock.NewMockProducts(mockCtrl)
+			mediaRepo := repomock.NewMockMedia(mockCtrl)
+
+			test.payload.repoMock(productRepo, mediaRepo)
 
-			test.payload.repoMock(productRepo)
+			currRepo := repomock.NewMockCurrencies(mockCtrl)
+			if test.payload.currMock == nil {
+				test.payload.currMock = anyRates
+			}
+			test.payload.currMock(currRepo)
 
-			req, _ := http.NewRequest(http.MethodGet, "url", nil)
+			req, _ := http.NewRequest(http.MethodGet, test.payload.url, nil)
 			rw := httptest.NewRecorder()
 
-			pdh := NewProductHandler(test.payload.cfg, logger, productRepo)
+			pdh := NewProductHandler(test.payload.cfg, logger, productRepo, repomock.NewMockCatalog(mockCtrl), mediaRepo, storage,
+				repomock.NewMockPrices(mockCtrl), currRepo)
 			pdh.GetAll(rw, req)
 
 			assert.Equal(t, test.expected.code, rw.Code)
 			assert.Equal(t, test.expected.body, rw.Body.String())
+			assert.Equal(t, test.expected.links, rw.Header()["Link"])
+		})
+	}
+}
+
+func TestEncodePriceCursor(t *testing.T) {
+	baseRates := currency.NewRates("USD", rates)
+	pears := entity.Product{ID: uuid.New(), Price: 1.26, Currency: "EUR"}
+
+	value, err := encodeCursor(entity.ProductQuery{Sort: entity.ProductSortPrice}, pears, baseRates)
+	assert.Nil(t, err)
+
+	cursor, err := decodeCursor(value)
+	assert.Nil(t, err)
+	price, err := strconv.ParseFloat(cursor.Value, 64)
+	assert.Nil(t, err)
+	assert.InDelta(t, 1.5, price, 0.0001)
+
+	_, err = encodeCursor(entity.ProductQuery{Sort: entity.ProductSortPrice}, entity.Product{Price: 1, Currency: "PLN"}, baseRates)
+	assert.Equal(t, currency.ErrNoRate, err)
+}
+
+func TestGetCategories(t *testing.T) {
+	categoryUUID := uuid.MustParse("0b7a33b2-3197-11eb-adc1-0242ac120002")
+
+	tc := []struct {
+		name     string
+		code     int
+		body     string
+		repoMock func(repoMock *repomock.MockCatalog)
+	}{
+		{
+			name: "Get categories with success",
+			code: http.StatusOK,
+			body: `[{"id":"0b7a33b2-3197-11eb-adc1-0242ac120002","slug":"citrus","name":"Citrus","path":"citrus","createdAt":"2020-11-28T00:00:00Z"}]`,
+			repoMock: func(repoMock *repomock.MockCatalog) {
+				repoMock.EXPECT().GetCategories().Return([]entity.Category{
+					{ID: categoryUUID, Slug: "citrus", Name: "Citrus", Path: "citrus", CreatedAt: createdAt},
+				}, nil)
+			},
+		},
+		{
+			name: "Get categories with fail",
+			code: http.StatusInternalServerError,
+			body: `{"error":"error"}`,
+			repoMock: func(repoMock *repomock.MockCatalog) {
+				repoMock.EXPECT().GetCategories().Return(nil, errors.New("error"))
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			catalogRepo := repomock.NewMockCatalog(mockCtrl)
+			test.repoMock(catalogRepo)
+
+			req, _ := http.NewRequest(http.MethodGet, "/v1/categories", nil)
+			rw := httptest.NewRecorder()
+
+			pdh := NewProductHandler(&config.Config{}, logger, repomock.NewMockProducts(mockCtrl), catalogRepo, repomock.NewMockMedia(mockCtrl), storage,
+				repomock.NewMockPrices(mockCtrl), repomock.NewMockCurrencies(mockCtrl))
+			pdh.GetCategories(rw, req)
+
+			assert.Equal(t, test.code, rw.Code)
+			assert.Equal(t, test.body, rw.Body.String())
+		})
+	}
+}
+
+func TestGetCategoryProducts(t *testing.T) {
+	type payload struct {
+		categoryID string
+		url        string
+		repoMock   func(catalogMock *repomock.MockCatalog, productMock *repomock.MockProducts, mediaMock *repomock.MockMedia)
+	}
+	type expected struct {
+		code  int
+		body  string
+		links []string
+	}
+
+	categoryUUID := uuid.MustParse("0b7a33b2-3197-11eb-adc1-0242ac120002")
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Get category products with success",
+			payload: payload{
+				categoryID: categoryUUID.String(),
+				url:        "/v1/categories/" + categoryUUID.String() + "/products?sort=name",
+				repoMock: func(catalogMock *repomock.MockCatalog, productMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {
+					catalogMock.EXPECT().GetCategory(categoryUUID).Return(&entity.Category{ID: categoryUUID, Path: "tropical"}, nil)
+					productMock.EXPECT().Find(entity.ProductQuery{Category: "tropical", Sort: entity.ProductSortName, Limit: defaultLimit + 1}).
+						Return([]entity.Product{bananas}, 1, nil)
+					mediaMock.EXPECT().GetMedia([]uuid.UUID{bananas.ID}).Return(map[uuid.UUID][]entity.Media{}, nil)
+				},
+			},
+			expected: expected{
+				code:  http.StatusOK,
+				body:  `{"products":[{"id":"f3e5a591-2c1a-11eb-adc1-0242ac120002","name":"Bananas","price":2.34,"currency":"USD","unit":"bunch","minAmount":1,"step":1,"createdAt":"2020-11-28T00:00:00Z"}],"paging":{"limit":20,"total":1}}`,
+				links: []string{`</v1/categories/` + categoryUUID.String() + `/products?sort=name>; rel="first"`},
+			},
+		},
+		{
+			name: "Get products of unknown category with fail",
+			payload: payload{
+				categoryID: categoryUUID.String(),
+				url:        "/v1/categories/" + categoryUUID.String() + "/products",
+				repoMock: func(catalogMock *repomock.MockCatalog, productMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {
+					catalogMock.EXPECT().GetCategory(categoryUUID).Return(nil, entity.ErrCategoryNotFound)
+				},
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"category not found"}`,
+			},
+		},
+		{
+			name: "Get products of invalid category id with fail",
+			payload: payload{
+				categoryID: "citrus",
+				url:        "/v1/categories/citrus/products",
+				repoMock: func(catalogMock *repomock.MockCatalog, productMock *repomock.MockProducts, mediaMock *repomock.MockMedia) {
+				},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid UUID length: 6"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			catalogRepo := repomock.NewMockCatalog(mockCtrl)
+			productRepo := repomock.NewMockProducts(mockCtrl)
+			mediaRepo := repomock.NewMockMedia(mockCtrl)
+			test.payload.repoMock(catalogRepo, productRepo, mediaRepo)
+
+			currRepo := repomock.NewMockCurrencies(mockCtrl)
+			anyRates(currRepo)
+
+			req, _ := http.NewRequest(http.MethodGet, test.payload.url, nil)
+			req = mux.SetURLVars(req, map[string]string{"categoryID": test.payload.categoryID})
+			rw := httptest.NewRecorder()
+
+			pdh := NewProductHandler(&config.Config{}, logger, productRepo, catalogRepo, mediaRepo, storage, repomock.NewMockPrices(mockCtrl), currRepo)
+			pdh.GetCategoryProducts(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+			assert.Equal(t, test.expected.links, rw.Header()["Link"])
+		})
+	}
+}
+
+func TestGetPriceHistory(t *testing.T) {
+	priceUUID := uuid.MustParse("7c2f9e4a-3468-11eb-adc1-0242ac120002")
+
+	tc := []struct {
+		name      string
+		productID string
+		code      int
+		body      string
+		repoMock  func(repoMock *repomock.MockPrices)
+	}{
+		{
+			name:      "Get price history with success",
+			productID: apples.ID.String(),
+			code:      http.StatusOK,
+			body:      `[{"id":"7c2f9e4a-3468-11eb-adc1-0242ac120002","price":1.72,"effectiveFrom":"2020-11-28T00:00:00Z","createdAt":"2020-11-28T00:00:00Z"}]`,
+			repoMock: func(repoMock *repomock.MockPrices) {
+				repoMock.EXPECT().GetPriceHistory(apples.ID).Return([]entity.ProductPrice{
+					{ID: priceUUID, ProductID: apples.ID, Price: 1.72, EffectiveFrom: createdAt, CreatedAt: createdAt},
+				}, nil)
+			},
+		},
+		{
+			name:      "Get price history of unknown product with fail",
+			productID: apples.ID.String(),
+			code:      http.StatusNotFound,
+			body:      `{"error":"product not found"}`,
+			repoMock: func(repoMock *repomock.MockPrices) {
+				repoMock.EXPECT().GetPriceHistory(apples.ID).Return([]entity.ProductPrice{}, entity.ErrProductNotFound)
+			},
+		},
+		{
+			name:      "Get price history GetPriceHistory error with fail",
+			productID: apples.ID.String(),
+			code:      http.StatusInternalServerError,
+			body:      `{"error":"error"}`,
+			repoMock: func(repoMock *repomock.MockPrices) {
+				repoMock.EXPECT().GetPriceHistory(apples.ID).Return(nil, errors.New("error"))
+			},
+		},
+		{
+			name:      "Get price history of invalid product id with fail",
+			productID: "apples",
+			code:      http.StatusBadRequest,
+			body:      `{"error":"invalid UUID length: 6"}`,
+			repoMock:  func(repoMock *repomock.MockPrices) {},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			pricesRepo := repomock.NewMockPrices(mockCtrl)
+			test.repoMock(pricesRepo)
+
+			req, _ := http.NewRequest(http.MethodGet, "/v1/products/"+test.productID+"/price-history", nil)
+			req = mux.SetURLVars(req, map[string]string{"productID": test.productID})
+			rw := httptest.NewRecorder()
+
+			pdh := NewProductHandler(&config.Config{}, logger, repomock.NewMockProducts(mockCtrl), repomock.NewMockCatalog(mockCtrl),
+				repomock.NewMockMedia(mockCtrl), storage, pricesRepo, repomock.NewMockCurrencies(mockCtrl))
+			pdh.GetPriceHistory(rw, req)
+
+			assert.Equal(t, test.code, rw.Code)
+			assert.Equal(t, test.body, rw.Body.String())
 		})
 	}
 }
@@ -0,0 +1,170 @@
+package quote
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mshto/fruit-store/bill"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/currency"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/repository"
+	"github.com/mshto/fruit-store/web/common/request"
+	"github.com/mshto/fruit-store/web/common/response"
+)
+
+// Service quote interface
+type Service interface {
+	GetQuote(w http.ResponseWriter, r *http.Request)
+}
+
+// quoteHandler quote handler
+type quoteHandler struct {
+	cfg         *config.Config
+	log         *logrus.Logger
+	productRepo repository.Products
+	discRepo    repository.Discount
+	currRepo    repository.Currencies
+	bil         bill.Bill
+}
+
+// NewQuoteHandler init a new quote handler
+func NewQuoteHandler(cfg *config.Config, log *logrus.Logger, productRepo repository.Products, discRepo repository.Discount,
+	currRepo repository.Currencies, bil bill.Bill) Service {
+	return quoteHandler{
+		cfg:         cfg,
+		log:         log,
+		productRepo: productRepo,
+		discRepo:    discRepo,
+		currRepo:    currRepo,
+		bil:         bil,
+	}
+}
+
+// GetQuote prices products with catalog prices and optional coupon without saving anything,
+// prices are in currency of currency query parameter or Accept-Currency header, taxes are of region query parameter
+func (qh quoteHandler) GetQuote(w http.ResponseWriter, r *http.Request) {
+	req := &entity.QuoteRequest{}
+	err := json.NewDecoder(r.Body).Decode(req)
+	if err != nil {
+		qh.log.Errorf("failed to decode quote request, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rates, display, err := currency.Load(qh.currRepo, qh.cfg.Currency.BaseCode(), request.Currency(r))
+	if currency.IsInvalid(err) {
+		response.RenderFailedResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		qh.log.Errorf("failed to get exchange rates, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	amounts := make(map[uuid.UUID]float64, len(req.Products))
+	ids := make([]uuid.UUID, 0, len(req.Products))
+	for _, prd := range req.Products {
+		if prd.Amount < 0 {
+			qh.log.Errorf("failed to get quote, error: %v", entity.ErrInvalidAmount)
+			response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrInvalidAmount)
+			return
+		}
+		if _, ok := amounts[prd.ProductUUID]; ok {
+			qh.log.Errorf("failed to get quote, error: %v", entity.ErrDuplicateProduct)
+			response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrDuplicateProduct)
+			return
+		}
+		amounts[prd.ProductUUID] = prd.Amount
+		ids = append(ids, prd.ProductUUID)
+	}
+
+	products := []entity.GetUserProduct{}
+	if len(ids) != 0 {
+		catalog, err := qh.productRepo.GetByIDs(ids)
+		if err != nil {
+			qh.log.Errorf("failed to get products, error: %v", err)
+			response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		if len(catalog) != len(ids) {
+			qh.log.Errorf("failed to get quote, error: %v", entity.ErrProductNotFound)
+			response.RenderFailedResponse(w, http.StatusUnprocessableEntity, entity.ErrProductNotFound)
+			return
+		}
+
+		for _, prd := range catalog {
+			err = prd.CheckAmount(amounts[prd.ID])
+			if err != nil {
+				qh.log.Errorf("failed to get quote, product: %v, error: %v", prd.ID, err)
+				response.RenderFailedResponse(w, http.StatusUnprocessableEntity, err)
+				return
+			}
+			products = append(products, entity.GetUserProduct{
+				ProductUUID: prd.ID,
+				Name:        prd.Name,
+				Price:       prd.Price,
+				Currency:    prd.Currency,
+				Unit:        prd.Unit,
+				Amount:      amounts[prd.ID],
+				Category:    prd.Category,
+			})
+		}
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].Name < products[j].Name
+	})
+
+	var coupons []config.GeneralSale
+	if req.Coupon != "" {
+		sale, err := qh.discRepo.GetDiscount(req.Coupon)
+		if err == repository.ErrNotFound {
+			qh.log.Errorf("failed to get discount, coupon: %v, error: %v", req.Coupon, err)
+			response.RenderFailedResponse(w, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			qh.log.Errorf("failed to get discount, coupon: %v, error: %v", req.Coupon, err)
+			response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		coupons = append(coupons, sale)
+	}
+
+	err = rates.CartToBase(products)
+	if err != nil {
+		qh.log.Errorf("failed to convert product prices, error: %v", err)
+		response.RenderFailedResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	region := request.Region(r)
+	total := qh.bil.GetQuoteInfo(products, display, region, coupons...)
+
+	display.ConvertCart(products)
+	quote := entity.UserCart{
+		CartProducts:    products,
+		TotalPrice:      total.Price,
+		TotalSavings:    total.Savings,
+		Amount:          total.Amount,
+		TotalNet:        total.Net,
+		TotalTax:        total.Tax,
+		TotalGross:      total.Price,
+		Taxes:           total.Taxes,
+		TaxRegion:       qh.cfg.Tax.RegionCode(region),
+		Currency:        display.Code,
+		IsDiscountAdded: len(coupons) != 0,
+	}
+	if display.Code != rates.Base() {
+		quote.BaseCurrency = rates.Base()
+		quote.BaseTotalPrice = total.BasePrice
+	}
+
+	response.RenderResponse(w, http.StatusOK, quote)
+}
@@ -0,0 +1,302 @@
+package quote
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	loggermock "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mshto/fruit-store/bill"
+	redismock "github.com/mshto/fruit-store/cache/mock"
+	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/repository"
+	repomock "github.com/mshto/fruit-store/repository/mock"
+	"github.com/mshto/fruit-store/tax"
+)
+
+var productUUID = uuid.MustParse("e2d49480-2c1a-11eb-adc1-0242ac120002")
+
+func TestGetQuote(t *testing.T) {
+	type payload struct {
+		cfg      *config.Config
+		body     []byte
+		currency string
+		region   string
+		repoMock func(productMock *repomock.MockProducts, discMock *repomock.MockDiscount)
+	}
+	type expected struct {
+		code int
+		body string
+	}
+
+	tc := []struct {
+		name string
+		expected
+		payload
+	}{
+		{
+			name: "Get quote with coupon with success",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":2}],"coupon":"coupon"}`),
+				repoMock: func(productMock *repomock.MockProducts, discMock *repomock.MockDiscount) {
+					productMock.EXPECT().GetByIDs(gomock.Any()).Return([]entity.Product{{ID: productUUID, Name: "Apples", Price: 100}}, nil)
+					discMock.EXPECT().GetDiscount("coupon").Return(config.GeneralSale{ID: "coupon", Elements: map[string]float64{"Apples": 1}, Rule: "more", Discount: 10}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","name":"Apples","price":100,"currency":"USD","amount":2}],"totalPrice":"180.00","totalSavings":"20.00","totalAmount":"2","totalNet":"180.00","totalTax":"0.00","totalGross":"180.00","taxes":[{"class":"standard","rate":0,"net":"180.00","tax":"0.00"}],"currency":"USD","isDiscountAdded":true}`,
+			},
+		},
+		{
+			name: "Get quote without coupon with success",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":2}],"coupon":""}`),
+				repoMock: func(productMock *repomock.MockProducts, discMock *repomock.MockDiscount) {
+					productMock.EXPECT().GetByIDs(gomock.Any()).Return([]entity.Product{{ID: productUUID, Name: "Apples", Price: 100}}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","name":"Apples","price":100,"currency":"USD","amount":2}],"totalPrice":"200.00","totalSavings":"0.00","totalAmount":"2","totalNet":"200.00","totalTax":"0.00","totalGross":"200.00","taxes":[{"class":"standard","rate":0,"net":"200.00","tax":"0.00"}],"currency":"USD","isDiscountAdded":false}`,
+			},
+		},
+		{
+			name: "Get quote in requested currency with success",
+			payload: payload{
+				cfg:      &config.Config{},
+				body:     []byte(`{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":2}],"coupon":""}`),
+				currency: "PLN",
+				repoMock: func(productMock *repomock.MockProducts, discMock *repomock.MockDiscount) {
+					productMock.EXPECT().GetByIDs(gomock.Any()).Return([]entity.Product{{ID: productUUID, Name: "Apples", Price: 84, Currency: "EUR"}}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","name":"Apples","price":370,"currency":"PLN","amount":2}],` +
+					`"totalPrice":"740.00","totalSavings":"0.00","totalAmount":"2","totalNet":"740.00","totalTax":"0.00","totalGross":"740.00","taxes":[{"class":"standard","rate":0,"net":"740.00","tax":"0.00"}],"currency":"PLN","baseCurrency":"USD","baseTotalPrice":"200.00","isDiscountAdded":false}`,
+			},
+		},
+		{
+			name: "Get quote with taxes of region with success",
+			payload: payload{
+				cfg: &config.Config{Tax: tax.Tax{
+					Region:  "DE",
+					Classes: map[string]string{"fruits": "reduced"},
+					Rates:   map[string]map[string]float64{"DE": {"reduced": 7}, "PL": {"reduced": 5}},
+				}},
+				body:   []byte(`{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":2}],"coupon":""}`),
+				region: "pl",
+				repoMock: func(productMock *repomock.MockProducts, discMock *repomock.MockDiscount) {
+					productMock.EXPECT().GetByIDs(gomock.Any()).Return([]entity.Product{{ID: productUUID, Name: "Apples", Price: 100, Category: "fruits"}}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","name":"Apples","price":100,"currency":"USD","amount":2}],` +
+					`"totalPrice":"210.00","totalSavings":"0.00","totalAmount":"2","totalNet":"200.00","totalTax":"10.00","totalGross":"210.00",` +
+					`"taxes":[{"class":"reduced","rate":5,"net":"200.00","tax":"10.00"}],"taxRegion":"PL","currency":"USD","isDiscountAdded":false}`,
+			},
+		},
+		{
+			name: "Get quote in unsupported currency with fail",
+			payload: payload{
+				cfg:      &config.Config{},
+				body:     []byte(`{}`),
+				currency: "BTC",
+				repoMock: func(productMock *repomock.MockProducts, discMock *repomock.MockDiscount) {
+				},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"currency is not supported"}`,
+			},
+		},
+		{
+			name: "Get quote empty basket with success",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{}`),
+				repoMock: func(productMock *repomock.MockProducts, discMock *repomock.MockDiscount) {
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"products":[],"totalPrice":"0.00","totalSavings":"0.00","totalAmount":"0","totalNet":"0.00","totalTax":"0.00","totalGross":"0.00","taxes":[],"currency":"USD","isDiscountAdded":false}`,
+			},
+		},
+		{
+			name: "Get quote invalid body with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`invalid`),
+				repoMock: func(productMock *repomock.MockProducts, discMock *repomock.MockDiscount) {
+				},
+			},
+			expected: expected{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid character 'i' looking for beginning of value"}`,
+			},
+		},
+		{
+			name: "Get quote negative amount with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":-1}]}`),
+				repoMock: func(productMock *repomock.MockProducts, discMock *repomock.MockDiscount) {
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"amount must not be negative"}`,
+			},
+		},
+		{
+			name: "Get quote duplicate product with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":1},{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":1}]}`),
+				repoMock: func(productMock *repomock.MockProducts, discMock *repomock.MockDiscount) {
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"duplicate product in cart"}`,
+			},
+		},
+		{
+			name: "Get quote per kg with success",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":1.3}],"coupon":""}`),
+				repoMock: func(productMock *repomock.MockProducts, discMock *repomock.MockDiscount) {
+					productMock.EXPECT().GetByIDs(gomock.Any()).Return([]entity.Product{
+						{ID: productUUID, Name: "Apples", Price: 2, Unit: entity.UnitKg, MinAmount: 0.5, Step: 0.1},
+					}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusOK,
+				body: `{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","name":"Apples","price":2,"currency":"USD","unit":"kg","amount":1.3}],"totalPrice":"2.60","totalSavings":"0.00","totalAmount":"1.3","totalNet":"2.60","totalTax":"0.00","totalGross":"2.60","taxes":[{"class":"standard","rate":0,"net":"2.60","tax":"0.00"}],"currency":"USD","isDiscountAdded":false}`,
+			},
+		},
+		{
+			name: "Get quote amount out of steps with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":0.25}],"coupon":""}`),
+				repoMock: func(productMock *repomock.MockProducts, discMock *repomock.MockDiscount) {
+					productMock.EXPECT().GetByIDs(gomock.Any()).Return([]entity.Product{
+						{ID: productUUID, Name: "Apples", Price: 2, Unit: entity.UnitKg, MinAmount: 0.2, Step: 0.1},
+					}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"amount must be at least the minimum and a multiple of the step of product"}`,
+			},
+		},
+		{
+			name: "Get quote unknown product with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":2}],"coupon":""}`),
+				repoMock: func(productMock *repomock.MockProducts, discMock *repomock.MockDiscount) {
+					productMock.EXPECT().GetByIDs(gomock.Any()).Return([]entity.Product{}, nil)
+				},
+			},
+			expected: expected{
+				code: http.StatusUnprocessableEntity,
+				body: `{"error":"product not found"}`,
+			},
+		},
+		{
+			name: "Get quote products db error with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":2}],"coupon":""}`),
+				repoMock: func(productMock *repomock.MockProducts, discMock *repomock.MockDiscount) {
+					productMock.EXPECT().GetByIDs(gomock.Any()).Return([]entity.Product{}, errors.New("error"))
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+		{
+			name: "Get quote unknown coupon with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":2}],"coupon":"coupon"}`),
+				repoMock: func(productMock *repomock.MockProducts, discMock *repomock.MockDiscount) {
+					productMock.EXPECT().GetByIDs(gomock.Any()).Return([]entity.Product{{ID: productUUID, Name: "Apples", Price: 100}}, nil)
+					discMock.EXPECT().GetDiscount("coupon").Return(config.GeneralSale{}, repository.ErrNotFound)
+				},
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+				body: `{"error":"not found"}`,
+			},
+		},
+		{
+			name: "Get quote coupon db error with fail",
+			payload: payload{
+				cfg:  &config.Config{},
+				body: []byte(`{"products":[{"id":"e2d49480-2c1a-11eb-adc1-0242ac120002","amount":2}],"coupon":"coupon"}`),
+				repoMock: func(productMock *repomock.MockProducts, discMock *repomock.MockDiscount) {
+					productMock.EXPECT().GetByIDs(gomock.Any()).Return([]entity.Product{{ID: productUUID, Name: "Apples", Price: 100}}, nil)
+					discMock.EXPECT().GetDiscount("coupon").Return(config.GeneralSale{}, errors.New("error"))
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+				body: `{"error":"error"}`,
+			},
+		},
+	}
+
+	for _, test := range tc {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logger, _ := loggermock.NewNullLogger()
+
+			productRepo := repomock.NewMockProducts(mockCtrl)
+			discRepo := repomock.NewMockDiscount(mockCtrl)
+
+			test.payload.repoMock(productRepo, discRepo)
+
+			// cache mock without expectations fails the test on any discount read or write
+			bil := bill.New(test.payload.cfg, logger, redismock.NewMockCache(mockCtrl))
+
+			currRepo := repomock.NewMockCurrencies(mockCtrl)
+			currRepo.EXPECT().GetExchangeRates().Return([]entity.ExchangeRate{{Currency: "EUR", Rate: 0.84}, {Currency: "PLN", Rate: 3.7}}, nil).AnyTimes()
+
+			req, _ := http.NewRequest(http.MethodPost, "url?region="+test.payload.region, bytes.NewBuffer(test.payload.body))
+			if test.payload.currency != "" {
+				req.Header.Set("Accept-Currency", test.payload.currency)
+			}
+			rw := httptest.NewRecorder()
+
+			qth := NewQuoteHandler(test.payload.cfg, logger, productRepo, discRepo, currRepo, bil)
+			qth.GetQuote(rw, req)
+
+			assert.Equal(t, test.expected.code, rw.Code)
+			assert.Equal(t, test.expected.body, rw.Body.String())
+		})
+	}
+}
@@ -2,52 +2,158 @@ package web
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 
+	"github.com/mshto/fruit-store/apikey"
+	"github.com/mshto/fruit-store/audit"
 	"github.com/mshto/fruit-store/authentication"
 	"github.com/mshto/fruit-store/bill"
 	"github.com/mshto/fruit-store/cache"
 	"github.com/mshto/fruit-store/config"
+	"github.com/mshto/fruit-store/encryption"
+	"github.com/mshto/fruit-store/entity"
+	"github.com/mshto/fruit-store/media"
+	"github.com/mshto/fruit-store/notifier"
+	"github.com/mshto/fruit-store/oidc"
+	"github.com/mshto/fruit-store/password"
 	"github.com/mshto/fruit-store/repository"
+	"github.com/mshto/fruit-store/web/account"
+	"github.com/mshto/fruit-store/web/admin"
+	"github.com/mshto/fruit-store/web/apikeys"
 	"github.com/mshto/fruit-store/web/auth"
 	"github.com/mshto/fruit-store/web/cart"
 	"github.com/mshto/fruit-store/web/middleware"
 	"github.com/mshto/fruit-store/web/product"
+	"github.com/mshto/fruit-store/web/quote"
 )
 
 // New creates a router for URL-to-service mapping
-func New(cfg *config.Config, log *logrus.Logger, repo *repository.Repository, redis cache.Cache) *mux.Router {
-	jwt := authentication.New(cfg, log, redis)
+func New(cfg *config.Config, log *logrus.Logger, repo *repository.Repository, redis cache.Cache, keys *authentication.KeySet,
+	policy password.Policy, ntf notifier.Sender, cph *encryption.Cipher, provider oidc.Provider, storage media.Storage) *mux.Router {
+	jwt := authentication.New(cfg, log, redis, keys)
 	bil := bill.New(cfg, log, redis)
+	akeys := apikey.New(log, repo.APIKeys)
+	auditor := audit.New(log, repo.Audit)
 
-	pdh := product.NewProductHandler(cfg, log, repo.Product)
-	cth := cart.NewCardHandler(cfg, log, repo.Cart, repo.Discount, bil)
-	auh := auth.NewAuthHandler(cfg, log, repo.Auth, jwt)
+	guestCart := repository.NewGuestCart(redis, repo.Product, time.Duration(cfg.Auth.GuestExpiresInMin)*time.Minute)
+	slotHolds := repository.NewSlotHolds(redis, cfg.Shipping.SlotHold())
+
+	pdh := product.NewProductHandler(cfg, log, repo.Product, repo.Catalog, repo.Media, storage, repo.Prices, repo.Currencies)
+	cth := cart.NewCardHandler(cfg, log, repo.Cart, guestCart, repo.Discount, repo.Currencies, repo.Delivery, repo.Slots, slotHolds, repo.Orders, bil, auditor)
+	qth := quote.NewQuoteHandler(cfg, log, repo.Product, repo.Discount, repo.Currencies, bil)
+	auh := auth.NewAuthHandler(cfg, log, repo.Auth, jwt, repo.Cart, guestCart, repo.Discount, bil, policy, ntf,
+		repo.TwoFactor, cph, provider, auditor)
+	akh := apikeys.NewAPIKeysHandler(cfg, log, akeys, repo.APIKeys)
+	ach := account.NewAccountHandler(cfg, log, repo.Profile, repo.Auth, repo.Cart, repo.Delivery, repo.Orders, jwt, bil)
+	adh := admin.NewAdminHandler(cfg, log, repo.Audit, repo.Auth, repo.Catalog, repo.Media, storage, repo.Prices, repo.Currencies, repo.Slots, repo.Orders, auditor)
+
+	scoped := func(scope string, h http.HandlerFunc) http.Handler {
+		return middleware.RequireScope(scope)(h)
+	}
 
 	router := mux.NewRouter().StrictSlash(true)
+	router.HandleFunc("/.well-known/jwks.json", auh.JWKS).Methods(http.MethodGet)
+	if files := media.FileServer(cfg.Media); files != nil {
+		router.PathPrefix(media.LocalURL + "/").Handler(files).Methods(http.MethodGet)
+	}
+
 	api := router.PathPrefix(cfg.URLPrefix).Subrouter()
 
 	routerV1 := api.PathPrefix("/v1").Subrouter()
 	routerV1.HandleFunc("/signup", auh.Signup).Methods(http.MethodPost)
 	routerV1.HandleFunc("/signin", auh.Signin).Methods(http.MethodPost)
+	routerV1.HandleFunc("/signin/2fa", auh.SigninTwoFactor).Methods(http.MethodPost)
 	routerV1.HandleFunc("/refresh", auh.Refresh).Methods(http.MethodPost)
+	routerV1.HandleFunc("/guest", auh.Guest).Methods(http.MethodPost)
+	routerV1.HandleFunc("/password/forgot", auh.ForgotPassword).Methods(http.MethodPost)
+	routerV1.HandleFunc("/password/reset", auh.ResetPassword).Methods(http.MethodPost)
+	routerV1.HandleFunc("/oidc/login", auh.OIDCLogin).Methods(http.MethodGet)
+	routerV1.HandleFunc("/oidc/callback", auh.OIDCCallback).Methods(http.MethodGet)
+
+	routerV1Guest := api.PathPrefix("/v1").Subrouter()
+	routerV1Guest.Use(middleware.AuthOrGuestMiddleware(jwt, akeys, log))
+
+	routerV1Guest.Handle("/products", scoped(apikey.ScopeProductsRead, pdh.GetAll)).Methods(http.MethodGet)
+	routerV1Guest.Handle("/products/{productID}/price-history", scoped(apikey.ScopeProductsRead, pdh.GetPriceHistory)).Methods(http.MethodGet)
+	routerV1Guest.Handle("/categories", scoped(apikey.ScopeProductsRead, pdh.GetCategories)).Methods(http.MethodGet)
+	routerV1Guest.Handle("/categories/{categoryID}/products", scoped(apikey.ScopeProductsRead, pdh.GetCategoryProducts)).Methods(http.MethodGet)
+	routerV1Guest.Handle("/quote", scoped(apikey.ScopeProductsRead, qth.GetQuote)).Methods(http.MethodPost)
+
+	routerV1Guest.Handle("/cart/products", scoped(apikey.ScopeCartRead, cth.GetAll)).Methods(http.MethodGet)
+	routerV1Guest.Handle("/cart/products", scoped(apikey.ScopeCartWrite, cth.UpdateProduct)).Methods(http.MethodPost)
+	routerV1Guest.Handle("/cart/products", scoped(apikey.ScopeCartWrite, cth.ReplaceProducts)).Methods(http.MethodPut)
+	routerV1Guest.Handle("/cart/products/{productID}", scoped(apikey.ScopeCartWrite, cth.AddOneProduct)).Methods(http.MethodPost)
+	routerV1Guest.Handle("/cart/products/{productID}", scoped(apikey.ScopeCartWrite, cth.RemoveProduct)).Methods(http.MethodDelete)
+	routerV1Guest.Handle("/cart/products/{productID}", scoped(apikey.ScopeCartWrite, cth.PatchProduct)).Methods(http.MethodPatch)
+
+	routerV1Guest.Handle("/cart/discount", scoped(apikey.ScopeCartWrite, cth.AddDiscout)).Methods(http.MethodPost)
 
 	routerV1Auth := api.PathPrefix("/v1").Subrouter()
-	routerV1Auth.Use(middleware.AuthMiddleware(jwt, log))
+	routerV1Auth.Use(middleware.AuthMiddleware(jwt, akeys, log))
+
+	routerV1Auth.Handle("/cart/delivery", scoped(apikey.ScopeCartWrite, cth.SetDelivery)).Methods(http.MethodPut)
+	routerV1Auth.Handle("/cart/delivery/slots", scoped(apikey.ScopeCartRead, cth.GetSlots)).Methods(http.MethodGet)
+	routerV1Auth.Handle("/cart/delivery/slot", scoped(apikey.ScopeCartWrite, cth.ReserveSlot)).Methods(http.MethodPut)
+	routerV1Auth.Handle("/cart/delivery/slot", scoped(apikey.ScopeCartWrite, cth.ReleaseSlot)).Methods(http.MethodDelete)
+	routerV1Auth.Handle("/cart/payment", scoped(apikey.ScopePaymentWrite, cth.AddPayment)).Methods(http.MethodPost)
+
+	// account management is not available with api keys
+	routerV1User := api.PathPrefix("/v1").Subrouter()
+	routerV1User.Use(middleware.AuthMiddleware(jwt, akeys, log), middleware.UserOnly)
+
+	routerV1User.HandleFunc("/logout", auh.Logout).Methods(http.MethodPost)
+	routerV1User.HandleFunc("/logout-all", auh.LogoutAll).Methods(http.MethodPost)
+	routerV1User.HandleFunc("/sessions", auh.GetSessions).Methods(http.MethodGet)
+	routerV1User.HandleFunc("/sessions/{sessionID}", auh.RevokeSession).Methods(http.MethodDelete)
+	routerV1User.HandleFunc("/password/change", auh.ChangePassword).Methods(http.MethodPost)
+	routerV1User.HandleFunc("/2fa/setup", auh.SetupTwoFactor).Methods(http.MethodPost)
+	routerV1User.HandleFunc("/2fa/confirm", auh.ConfirmTwoFactor).Methods(http.MethodPost)
+	routerV1User.HandleFunc("/oidc/link", auh.OIDCLink).Methods(http.MethodPost)
 
-	routerV1Auth.HandleFunc("/logout", auh.Logout).Methods(http.MethodPost)
-	routerV1Auth.HandleFunc("/products", pdh.GetAll).Methods(http.MethodGet)
+	routerV1User.HandleFunc("/me", ach.GetProfile).Methods(http.MethodGet)
+	routerV1User.HandleFunc("/me", ach.UpdateProfile).Methods(http.MethodPatch)
+	routerV1User.HandleFunc("/me", ach.Delete).Methods(http.MethodDelete)
+	routerV1User.HandleFunc("/me/export", ach.Export).Methods(http.MethodGet)
+	routerV1User.HandleFunc("/me/addresses", ach.GetAddresses).Methods(http.MethodGet)
+	routerV1User.HandleFunc("/me/addresses", ach.CreateAddress).Methods(http.MethodPost)
+	routerV1User.HandleFunc("/me/addresses/{addressID}", ach.DeleteAddress).Methods(http.MethodDelete)
+	routerV1User.HandleFunc("/me/orders", ach.GetOrders).Methods(http.MethodGet)
+	routerV1User.HandleFunc("/me/orders/{orderID}", ach.GetOrder).Methods(http.MethodGet)
+	routerV1User.HandleFunc("/me/orders/{orderID}/cancel", ach.CancelOrder).Methods(http.MethodPost)
 
-	routerV1Auth.HandleFunc("/cart/products", cth.GetAll).Methods(http.MethodGet)
-	routerV1Auth.HandleFunc("/cart/products", cth.UpdateProduct).Methods(http.MethodPost)
-	routerV1Auth.HandleFunc("/cart/products/{productID}", cth.AddOneProduct).Methods(http.MethodPost)
-	routerV1Auth.HandleFunc("/cart/products/{productID}", cth.RemoveProduct).Methods(http.MethodDelete)
+	routerV1User.HandleFunc("/api-keys", akh.CreateAPIKey).Methods(http.MethodPost)
+	routerV1User.HandleFunc("/api-keys", akh.GetAPIKeys).Methods(http.MethodGet)
+	routerV1User.HandleFunc("/api-keys/{keyID}", akh.RevokeAPIKey).Methods(http.MethodDelete)
+	routerV1User.HandleFunc("/service-accounts", akh.CreateServiceAccount).Methods(http.MethodPost)
+	routerV1User.HandleFunc("/service-accounts", akh.GetServiceAccounts).Methods(http.MethodGet)
 
-	routerV1Auth.HandleFunc("/cart/discount", cth.AddDiscout).Methods(http.MethodPost)
+	routerV1Admin := api.PathPrefix("/v1/admin").Subrouter()
+	routerV1Admin.Use(middleware.AuthMiddleware(jwt, akeys, log), middleware.UserOnly, middleware.RequireRole(repo.Auth, entity.RoleAdmin, log))
 
-	routerV1Auth.HandleFunc("/cart/payment", cth.AddPayment).Methods(http.MethodPost)
+	routerV1Admin.HandleFunc("/audit-events", adh.GetAuditEvents).Methods(http.MethodGet)
+	routerV1Admin.HandleFunc("/users/{userID}/role", adh.UpdateUserRole).Methods(http.MethodPut)
+	routerV1Admin.HandleFunc("/categories", adh.CreateCategory).Methods(http.MethodPost)
+	routerV1Admin.HandleFunc("/categories/{categoryID}", adh.DeleteCategory).Methods(http.MethodDelete)
+	routerV1Admin.HandleFunc("/products/{productID}/category", adh.SetProductCategory).Methods(http.MethodPut)
+	routerV1Admin.HandleFunc("/products/{productID}/tags", adh.SetProductTags).Methods(http.MethodPut)
+	routerV1Admin.HandleFunc("/products/{productID}/media", adh.UploadMedia).Methods(http.MethodPost)
+	routerV1Admin.HandleFunc("/products/{productID}/media/{mediaID}", adh.DeleteMedia).Methods(http.MethodDelete)
+	routerV1Admin.HandleFunc("/products/{productID}/prices", adh.SchedulePrice).Methods(http.MethodPost)
+	routerV1Admin.HandleFunc("/products/{productID}/prices/{priceID}", adh.CancelPrice).Methods(http.MethodDelete)
+	routerV1Admin.HandleFunc("/products/{productID}/currency", adh.SetProductCurrency).Methods(http.MethodPut)
+	routerV1Admin.HandleFunc("/products/{productID}/stock", adh.SetProductStock).Methods(http.MethodPut)
+	routerV1Admin.HandleFunc("/exchange-rates", adh.GetExchangeRates).Methods(http.MethodGet)
+	routerV1Admin.HandleFunc("/exchange-rates/{currency}", adh.SetExchangeRate).Methods(http.MethodPut)
+	routerV1Admin.HandleFunc("/exchange-rates/{currency}", adh.DeleteExchangeRate).Methods(http.MethodDelete)
+	routerV1Admin.HandleFunc("/delivery-slots", adh.GetSlots).Methods(http.MethodGet)
+	routerV1Admin.HandleFunc("/delivery-slots", adh.CreateSlot).Methods(http.MethodPost)
+	routerV1Admin.HandleFunc("/delivery-slots/{slotID}", adh.DeleteSlot).Methods(http.MethodDelete)
+	routerV1Admin.HandleFunc("/orders", adh.GetOrders).Methods(http.MethodGet)
+	routerV1Admin.HandleFunc("/orders/{orderID}", adh.GetOrder).Methods(http.MethodGet)
+	routerV1Admin.HandleFunc("/orders/{orderID}/status", adh.SetOrderStatus).Methods(http.MethodPut)
 
 	return router
 }
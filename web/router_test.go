@@ -25,6 +25,6 @@ func TestConfigValidation(t *testing.T) {
 
 	logger, _ := loggermock.NewNullLogger()
 
-	route := New(&config.Config{}, logger, repository.New(db), redismock.NewMockCache(mockCtrl))
+	route := New(&config.Config{}, logger, repository.New(db), redismock.NewMockCache(mockCtrl), nil, nil, nil, nil, nil, nil)
 	assert.NotNil(t, route)
 }